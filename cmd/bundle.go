@@ -6,18 +6,20 @@ import (
 	"path/filepath"
 	"strings"
 
-	"sw_runtime/internal/bundler"
+	"github.com/issueye/sw_runtime/internal/bundler"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	outputFile   string
-	minify       bool
-	sourcemap    bool
-	excludeFiles []string
-	encrypt      bool
-	encryptKey   string
+	outputFile    string
+	minify        bool
+	withSourcemap bool
+	excludeFiles  []string
+	encrypt       bool
+	encryptKey    string
+	bundleImport  string
+	bundleRemote  remoteFlags
 )
 
 var bundleCmd = &cobra.Command{
@@ -35,12 +37,16 @@ bundle 命令会从入口文件开始，递归分析所有依赖的模块，并
   • 可选代码压缩
   • 支持 Source Map
   • 代码加密保护 (AES-256-GCM)
+  • 导入映射 (--import-map 或 sw.json 的 importMap)
+  • 内联 http(s) 远程模块 (完整性记录在 sw.lock)
 
 示例:
   sw_runtime bundle app.ts -o bundle.js
   sw_runtime bundle main.js -o dist/app.js --minify
   sw_runtime bundle server.ts -o server.bundle.js --exclude utils.js,helpers.js
-  sw_runtime bundle app.js --encrypt -o app.encrypted.js`,
+  sw_runtime bundle app.js --encrypt -o app.encrypted.js
+  sw_runtime bundle app.js --import-map import_map.json
+  sw_runtime bundle app.ts --offline`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		entryFile := args[0]
@@ -58,15 +64,28 @@ bundle 命令会从入口文件开始，递归分析所有依赖的模块，并
 			outputFile = base + ".bundle.js"
 		}
 
+		importMap, err := loadImportMap(bundleImport, entryFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ 加载导入映射失败: %v\n", err)
+			os.Exit(1)
+		}
+		remoteLoader, err := bundleRemote.loader(entryFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+
 		// 创建打包器
 		b := bundler.New(bundler.Options{
 			EntryFile:    entryFile,
 			OutputFile:   outputFile,
 			Minify:       minify,
-			Sourcemap:    sourcemap,
+			Sourcemap:    withSourcemap,
 			ExcludeFiles: excludeFiles,
 			Encrypt:      encrypt,
 			EncryptKey:   encryptKey,
+			ImportMap:    importMap,
+			Remote:       remoteLoader,
 		})
 
 		// 执行打包
@@ -89,7 +108,7 @@ bundle 命令会从入口文件开始，递归分析所有依赖的模块，并
 		}
 
 		// 如果需要 sourcemap，写入 map 文件
-		if sourcemap && result.Sourcemap != "" {
+		if withSourcemap && result.Sourcemap != "" {
 			mapFile := outputFile + ".map"
 			err = os.WriteFile(mapFile, []byte(result.Sourcemap), 0644)
 			if err != nil {
@@ -121,7 +140,7 @@ bundle 命令会从入口文件开始，递归分析所有依赖的模块，并
 				}
 			}
 
-			if sourcemap {
+			if withSourcemap {
 				fmt.Printf("🗺️  Source Map: %s.map\n", outputFile)
 			}
 
@@ -145,8 +164,10 @@ func init() {
 
 	bundleCmd.Flags().StringVarP(&outputFile, "output", "o", "", "输出文件路径 (默认: <entry>.bundle.js)")
 	bundleCmd.Flags().BoolVarP(&minify, "minify", "m", false, "压缩输出代码")
-	bundleCmd.Flags().BoolVar(&sourcemap, "sourcemap", false, "生成 source map")
+	bundleCmd.Flags().BoolVar(&withSourcemap, "sourcemap", false, "生成 source map")
 	bundleCmd.Flags().StringSliceVar(&excludeFiles, "exclude", []string{}, "排除指定文件（逗号分隔）")
 	bundleCmd.Flags().BoolVar(&encrypt, "encrypt", false, "加密打包后的代码 (AES-256-GCM)")
 	bundleCmd.Flags().StringVar(&encryptKey, "encrypt-key", "", "指定加密密钥（不指定则自动生成）")
+	addImportMapFlag(bundleCmd, &bundleImport)
+	addRemoteFlags(bundleCmd, &bundleRemote)
 }
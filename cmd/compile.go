@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+
+	"github.com/issueye/sw_runtime/internal/bundler"
+	"github.com/issueye/sw_runtime/internal/runtime"
+	"github.com/issueye/sw_runtime/internal/standalone"
+
+	"github.com/spf13/cobra"
+)
+
+// standaloneKeyEnv 加密密钥的环境变量：compile --encrypt 从中读取加密密钥，
+// 未嵌入密钥的独立可执行文件运行时从中读取解密密钥
+const standaloneKeyEnv = "SW_RUNTIME_DECRYPT_KEY"
+
+var (
+	compileOutput     string
+	compileMinify     bool
+	compileExclude    []string
+	compileEncrypt    bool
+	compileEncryptKey string
+	compileEmbedKey   bool
+	compilePrintKey   bool
+	compileAssets     []string
+	compileRuntime    string
+	compileImportMap  string
+	compileRemote     remoteFlags
+)
+
+var compileCmd = &cobra.Command{
+	Use:   "compile <entry-file>",
+	Short: "将脚本编译为独立可执行文件",
+	Long: `将 JavaScript/TypeScript 项目编译为独立的可执行文件
+
+compile 命令先像 bundle 一样把入口文件及其依赖打包成单个脚本，
+再将脚本和资源文件追加到运行时可执行文件的副本末尾。生成的程序
+启动时会自动运行嵌入的脚本，命令行参数原样传入 process.argv。
+
+资源文件通过 require('standalone') 读取:
+  const { getAsset, listAssets } = require('standalone');
+  const html = getAsset('public/index.html', 'utf8');
+
+特性:
+  • 无需在目标机器上安装 sw_runtime
+  • 可嵌入资源文件或目录 (--asset)
+  • 代码加密保护 (AES-256-GCM)，密钥默认不嵌入，运行时从 SW_RUNTIME_DECRYPT_KEY 读取
+  • 可指定其他平台的运行时二进制进行交叉编译 (--runtime)
+
+示例:
+  sw_runtime compile app.ts -o app
+  sw_runtime compile server.ts -o server --asset public --asset config.json
+  SW_RUNTIME_DECRYPT_KEY=<密钥> sw_runtime compile app.ts -o app --encrypt
+  sw_runtime compile app.ts -o app --encrypt --print-key
+  sw_runtime compile app.ts -o app --encrypt --embed-key
+  sw_runtime compile app.ts -o app.exe --runtime ./dist/sw_runtime-windows-amd64.exe`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		entryFile := args[0]
+
+		// 检查入口文件是否存在
+		if _, err := os.Stat(entryFile); os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "❌ 入口文件不存在: %s\n", entryFile)
+			os.Exit(1)
+		}
+
+		// 如果没有指定输出文件，使用入口文件名
+		if compileOutput == "" {
+			compileOutput = strings.TrimSuffix(entryFile, filepath.Ext(entryFile))
+			if compileRuntime == "" && goruntime.GOOS == "windows" {
+				compileOutput += ".exe"
+			}
+		}
+
+		// 确定运行时二进制
+		runtimePath := compileRuntime
+		if runtimePath == "" {
+			exe, err := os.Executable()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "❌ 无法定位运行时可执行文件: %v\n", err)
+				os.Exit(1)
+			}
+			runtimePath = exe
+		}
+
+		quietMode, _ := cmd.Flags().GetBool("quiet")
+		if !quietMode {
+			fmt.Printf("📦 正在编译: %s\n", entryFile)
+		}
+
+		importMap, err := loadImportMap(compileImportMap, entryFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ 加载导入映射失败: %v\n", err)
+			os.Exit(1)
+		}
+		remoteLoader, err := compileRemote.loader(entryFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		// 加密密钥：--encrypt-key，其次为环境变量。密钥不嵌入时只有用户要求打印才自动生成，
+		// 避免生成无法运行的程序或把密钥输出到终端日志中
+		encryptKey := compileEncryptKey
+		if compileEncrypt && encryptKey == "" {
+			encryptKey = os.Getenv(standaloneKeyEnv)
+			if encryptKey == "" && !compileEmbedKey && !compilePrintKey {
+				fmt.Fprintf(os.Stderr, "❌ --encrypt 需要密钥：请设置 %s 环境变量，或使用 --print-key 生成并打印新密钥\n", standaloneKeyEnv)
+				os.Exit(1)
+			}
+		}
+
+		// 打包脚本
+		b := bundler.New(bundler.Options{
+			EntryFile:    entryFile,
+			Minify:       compileMinify,
+			ExcludeFiles: compileExclude,
+			Encrypt:      compileEncrypt,
+			EncryptKey:   encryptKey,
+			ImportMap:    importMap,
+			Remote:       remoteLoader,
+		})
+		result, err := b.Bundle()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ 打包失败: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 收集资源文件
+		assets, err := collectAssets(filepath.Dir(entryFile), compileAssets)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ 读取资源文件失败: %v\n", err)
+			os.Exit(1)
+		}
+
+		payload := &standalone.Payload{
+			Entry:     filepath.Base(entryFile),
+			Code:      result.Code,
+			Encrypted: result.Encrypted,
+			Assets:    assets,
+		}
+		if result.Encrypted && compileEmbedKey {
+			payload.Key = result.EncryptKey
+		}
+
+		// 生成可执行文件
+		if err := standalone.Build(runtimePath, compileOutput, payload); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ 生成可执行文件失败: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 显示结果
+		if !quietMode {
+			verboseMode, _ := cmd.Flags().GetBool("verbose")
+			fmt.Printf("\n✅ 编译完成!\n\n")
+			fmt.Printf("📄 输出文件: %s\n", compileOutput)
+			if info, err := os.Stat(compileOutput); err == nil {
+				fmt.Printf("📊 文件大小: %.2f MB\n", float64(info.Size())/1024/1024)
+			}
+			fmt.Printf("📦 包含模块: %d 个\n", len(result.Modules))
+			fmt.Printf("🗂️  嵌入资源: %d 个\n", len(assets))
+
+			if verboseMode {
+				fmt.Printf("⚙️  运行时: %s\n", runtimePath)
+				fmt.Printf("\n包含的模块:\n")
+				for _, mod := range result.Modules {
+					fmt.Printf("  • %s\n", mod)
+				}
+				if len(assets) > 0 {
+					fmt.Printf("\n嵌入的资源:\n")
+					for _, name := range payload.AssetNames() {
+						fmt.Printf("  • %s\n", name)
+					}
+				}
+			}
+
+			// 显示加密信息
+			if result.Encrypted {
+				if compileEmbedKey {
+					// 密钥与密文在同一个文件中，只是混淆，不提供保密性
+					fmt.Printf("\n🙈 代码已混淆（密钥嵌入在可执行文件中，任何人都可以还原源码）\n")
+				} else {
+					fmt.Printf("\n🔒 代码已加密 (AES-256-GCM)，密钥未嵌入\n")
+					fmt.Printf("   运行时需要通过环境变量提供密钥: %s=<密钥> ./%s\n", standaloneKeyEnv, filepath.Base(compileOutput))
+				}
+			}
+		}
+
+		// 只有显式要求时才输出密钥（即使在 --quiet 模式下），输出到 stdout 便于重定向保存
+		if result.Encrypted && compilePrintKey {
+			fmt.Println(result.EncryptKey)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(compileCmd)
+
+	compileCmd.Flags().StringVarP(&compileOutput, "output", "o", "", "输出文件路径 (默认: 入口文件名去掉扩展名)")
+	compileCmd.Flags().BoolVarP(&compileMinify, "minify", "m", false, "压缩嵌入的代码")
+	compileCmd.Flags().StringSliceVar(&compileExclude, "exclude", []string{}, "排除指定文件（逗号分隔）")
+	compileCmd.Flags().BoolVar(&compileEncrypt, "encrypt", false, "加密嵌入的代码 (AES-256-GCM)")
+	compileCmd.Flags().StringVar(&compileEncryptKey, "encrypt-key", "", "指定加密密钥（会留在 shell 历史中，建议使用 "+standaloneKeyEnv+" 环境变量）")
+	compileCmd.Flags().BoolVar(&compileEmbedKey, "embed-key", false, "将解密密钥嵌入可执行文件（仅混淆，无法保护源码）")
+	compileCmd.Flags().BoolVar(&compilePrintKey, "print-key", false, "打印加密密钥（未提供密钥时自动生成）")
+	compileCmd.Flags().StringSliceVar(&compileAssets, "asset", []string{}, "嵌入资源文件或目录（可多次指定）")
+	compileCmd.Flags().StringVar(&compileRuntime, "runtime", "", "作为基础的运行时可执行文件 (默认: 当前程序)")
+	addImportMapFlag(compileCmd, &compileImportMap)
+	addRemoteFlags(compileCmd, &compileRemote)
+}
+
+// collectAssets 读取资源文件和目录，资源名为相对入口文件目录的 / 分隔路径
+func collectAssets(baseDir string, paths []string) (map[string][]byte, error) {
+	baseAbs, err := filepath.Abs(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	assets := make(map[string][]byte)
+	for _, p := range paths {
+		root, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(baseAbs, path)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return fmt.Errorf("资源文件必须位于入口文件目录内: %s", path)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			assets[filepath.ToSlash(rel)] = data
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return assets, nil
+}
+
+// runStandalone 运行可执行文件中嵌入的脚本，命令行参数全部传给脚本
+func runStandalone(payload *standalone.Payload) error {
+	code := payload.Code
+	if payload.Encrypted {
+		key := payload.Key
+		if key == "" {
+			key = os.Getenv(standaloneKeyEnv)
+		}
+		if key == "" {
+			return fmt.Errorf("程序已加密，请通过 %s 环境变量提供解密密钥", standaloneKeyEnv)
+		}
+		decrypted, err := decryptBundle(code, key)
+		if err != nil {
+			return fmt.Errorf("解密失败: %w", err)
+		}
+		code = decrypted
+	}
+
+	runner, err := runtime.New()
+	if err != nil {
+		return err
+	}
+	defer runner.Close()
+
+	exe, err := os.Executable()
+	if err != nil {
+		exe = os.Args[0]
+	}
+	runner.SetArgv(append([]string{exe, payload.Entry}, os.Args[1:]...))
+
+	return runner.RunCode(code)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
+
+	"github.com/spf13/cobra"
+)
+
+// consoleFlags console 输出相关的标志
+type consoleFlags struct {
+	level  string
+	format string
+}
+
+// addConsoleFlags 为命令注册 --log-level 和 --log-format 标志
+func addConsoleFlags(cmd *cobra.Command, f *consoleFlags) {
+	cmd.Flags().StringVar(&f.level, "log-level", "debug", "console 输出级别: debug、info、warn、error、silent")
+	cmd.Flags().StringVar(&f.format, "log-format", "text", "console 输出格式: text、json（每行一条 JSON，便于日志采集）")
+}
+
+// options 根据标志构造 console 选项
+func (f *consoleFlags) options() (runtime.ConsoleOptions, error) {
+	opts := runtime.DefaultConsoleOptions()
+	level, err := runtime.ParseLogLevel(f.level)
+	if err != nil {
+		return opts, fmt.Errorf("无效的 --log-level: %s（可选 debug、info、warn、error、silent）", f.level)
+	}
+	opts.Level = level
+
+	switch f.format {
+	case "text":
+	case "json":
+		opts.JSON = true
+	default:
+		return opts, fmt.Errorf("无效的 --log-format: %s（可选 text、json）", f.format)
+	}
+	return opts, nil
+}
@@ -3,11 +3,20 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"sw_runtime/internal/runtime"
+	"time"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
+	"github.com/issueye/sw_runtime/internal/sourcemap"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	evalTimeout time.Duration
+	evalPerms   permissionFlags
+	evalConsole consoleFlags
+)
+
 // evalCmd 代表 eval 命令
 var evalCmd = &cobra.Command{
 	Use:   "eval <code>",
@@ -17,7 +26,9 @@ var evalCmd = &cobra.Command{
 示例:
   sw_runtime eval "console.log('Hello, World!')"
   sw_runtime eval "const x = 10; const y = 20; console.log(x + y)"
-  sw_runtime eval "Promise.resolve(42).then(v => console.log(v))"`,
+  sw_runtime eval "Promise.resolve(42).then(v => console.log(v))"
+  sw_runtime eval --timeout 5s "while (true) {}"
+  sw_runtime eval --sandbox "require('fs').fs.readFileSync('/etc/passwd')"`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		code := args[0]
@@ -31,14 +42,25 @@ var evalCmd = &cobra.Command{
 			fmt.Println("---")
 		}
 
+		consoleOpts, err := evalConsole.options()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+
 		// 创建运行器
 		runner := runtime.NewOrPanic()
 		defer runner.Close()
+		runner.SetPermissions(evalPerms.permissions(cmd))
+		runner.SetConsoleOptions(consoleOpts)
 
 		// 执行代码
-		err := runner.RunCode(code)
+		ctx, cancel := timeoutContext(evalTimeout)
+		defer cancel()
+
+		err = runner.RunCodeContext(ctx, code)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "❌ 执行失败: %v\n", err)
+			fmt.Fprintf(os.Stderr, "❌ %s\n", sourcemap.FormatError(timeoutError(err, evalTimeout, "执行失败")))
 			os.Exit(1)
 		}
 
@@ -51,4 +73,8 @@ var evalCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(evalCmd)
+
+	evalCmd.Flags().DurationVar(&evalTimeout, "timeout", 0, "最长执行时间（如 5s），超时后中断代码，0 表示不限制")
+	addPermissionFlags(evalCmd, &evalPerms)
+	addConsoleFlags(evalCmd, &evalConsole)
 }
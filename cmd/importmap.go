@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/issueye/sw_runtime/internal/importmap"
+	"github.com/issueye/sw_runtime/internal/project"
+
+	"github.com/spf13/cobra"
+)
+
+// addImportMapFlag 为命令注册 --import-map 标志
+func addImportMapFlag(cmd *cobra.Command, path *string) {
+	cmd.Flags().StringVar(path, "import-map", "", "导入映射文件（WHATWG import map 格式），默认使用 sw.json 的 importMap")
+}
+
+// loadImportMap 加载 --import-map 指定的导入映射；未指定时从入口文件所在目录向上查找 sw.json，
+// 使用其中的 importMap 配置。都没有时返回 nil
+func loadImportMap(path string, entryFile string) (*importmap.ImportMap, error) {
+	if path != "" {
+		return importmap.Load(path)
+	}
+	config, err := project.Find(filepath.Dir(entryFile))
+	if err != nil {
+		return nil, err
+	}
+	return config.LoadImportMap()
+}
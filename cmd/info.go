@@ -2,7 +2,8 @@ package cmd
 
 import (
 	"fmt"
-	"sw_runtime/internal/runtime"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
 
 	"github.com/spf13/cobra"
 )
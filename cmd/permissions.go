@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"github.com/issueye/sw_runtime/internal/security"
+
+	"github.com/spf13/cobra"
+)
+
+// permissionFlags --allow-* 权限标志
+type permissionFlags struct {
+	read     []string
+	write    []string
+	net      []string
+	run      []string
+	env      []string
+	allowAll bool
+	sandbox  bool
+}
+
+// addPermissionFlags 为命令注册权限标志。
+// 未指定任何权限标志时不做限制；指定任意 --allow-* 或 --sandbox 后，未授权的访问抛出 PermissionDenied
+func addPermissionFlags(cmd *cobra.Command, f *permissionFlags) {
+	flags := cmd.Flags()
+	flags.StringSliceVar(&f.read, "allow-read", nil, "允许读取的文件或目录（逗号分隔，不带值表示全部）")
+	flags.StringSliceVar(&f.write, "allow-write", nil, "允许写入的文件或目录（逗号分隔，不带值表示全部）")
+	flags.StringSliceVar(&f.net, "allow-net", nil, "允许访问或监听的主机，格式 host 或 host:port（不带值表示全部）")
+	flags.StringSliceVar(&f.run, "allow-run", nil, "允许执行的命令（逗号分隔，不带值表示全部）")
+	flags.StringSliceVar(&f.env, "allow-env", nil, "允许读写的环境变量（逗号分隔，不带值表示全部）")
+	flags.BoolVarP(&f.allowAll, "allow-all", "A", false, "允许全部访问")
+	flags.BoolVar(&f.sandbox, "sandbox", false, "启用权限限制，只允许 --allow-* 标志授权的访问")
+
+	for _, name := range []string{"allow-read", "allow-write", "allow-net", "allow-run", "allow-env"} {
+		flags.Lookup(name).NoOptDefVal = security.AllowAllEntry
+	}
+}
+
+// permissions 根据标志构造权限配置，未启用限制时返回 nil
+func (f *permissionFlags) permissions(cmd *cobra.Command) *security.Permissions {
+	if f.allowAll {
+		return security.AllowAll()
+	}
+	restricted := f.sandbox
+	for _, name := range []string{"allow-read", "allow-write", "allow-net", "allow-run", "allow-env"} {
+		if cmd.Flags().Changed(name) {
+			restricted = true
+		}
+	}
+	if !restricted {
+		return nil
+	}
+	return &security.Permissions{
+		Read:  f.read,
+		Write: f.write,
+		Net:   f.net,
+		Run:   f.run,
+		Env:   f.env,
+	}
+}
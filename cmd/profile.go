@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/issueye/sw_runtime/internal/profiler"
+	"github.com/issueye/sw_runtime/internal/runtime"
+
+	"github.com/spf13/cobra"
+)
+
+// profileFlags 性能分析相关的标志
+type profileFlags struct {
+	cpuProf      string
+	heapSnapshot string
+	signal       bool
+}
+
+// addProfileFlags 为命令注册 --cpu-prof、--heap-snapshot 和 --prof-signal 标志
+func addProfileFlags(cmd *cobra.Command, f *profileFlags) {
+	cmd.Flags().StringVar(&f.cpuProf, "cpu-prof", "", "采样 JS 执行时间并在退出时写入 pprof 文件（用 go tool pprof 查看）")
+	cmd.Flags().StringVar(&f.heapSnapshot, "heap-snapshot", "", "退出时将按构造函数统计的堆摘要写入 JSON 文件")
+	cmd.Flags().BoolVar(&f.signal, "prof-signal", false, "允许通过信号切换分析：SIGUSR2 开始/停止 CPU 采样，SIGUSR1 输出堆摘要")
+}
+
+// enabled 返回是否启用了任一性能分析功能
+func (f *profileFlags) enabled() bool {
+	return f.cpuProf != "" || f.heapSnapshot != "" || f.signal
+}
+
+// profileSession 一次运行中的性能分析状态
+type profileSession struct {
+	flags   *profileFlags
+	runner  *runtime.Runner
+	verbose bool
+	done    chan struct{}
+}
+
+// startProfile 按标志开始性能分析，运行结束后调用 finish 写入结果
+func startProfile(f *profileFlags, runner *runtime.Runner, verbose bool) (*profileSession, error) {
+	s := &profileSession{flags: f, runner: runner, verbose: verbose, done: make(chan struct{})}
+	if f.signal {
+		if cpuProfileSignal == nil {
+			return nil, fmt.Errorf("当前平台不支持 --prof-signal")
+		}
+		s.watchSignals()
+	}
+	if f.cpuProf != "" {
+		if err := profiler.StartCPU(); err != nil {
+			close(s.done)
+			return nil, fmt.Errorf("启动 CPU 采样失败: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// watchSignals 监听切换分析的信号，直到 finish 被调用
+func (s *profileSession) watchSignals() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, cpuProfileSignal, heapSummarySignal)
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-s.done:
+				return
+			case sig := <-ch:
+				if sig == heapSummarySignal {
+					s.runner.RequestHeapSummary(func(summary *profiler.HeapSummary) {
+						s.report(writeHeapSummary(signalFileName("heap", "json"), summary))
+					})
+					continue
+				}
+				s.toggleCPU()
+			}
+		}
+	}()
+}
+
+// toggleCPU 开始 CPU 采样，已在采样时停止并写入文件
+func (s *profileSession) toggleCPU() {
+	if !profiler.CPUActive() {
+		if err := profiler.StartCPU(); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ 启动 CPU 采样失败: %v\n", err)
+			return
+		}
+		fmt.Fprintln(os.Stderr, "📊 已开始 CPU 采样")
+		return
+	}
+	file := s.flags.cpuProf
+	if file == "" {
+		file = signalFileName("cpu", "pprof")
+	}
+	s.report(writeCPUProfile(file))
+}
+
+// report 输出写入结果
+func (s *profileSession) report(file string, err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "📊 已写入 %s\n", file)
+}
+
+// finish 停止信号监听，写入 CPU 采样结果和堆摘要。
+// 脚本被中断时 VM 无法再执行 JS，跳过堆摘要
+func (s *profileSession) finish() error {
+	close(s.done)
+
+	var errs []error
+	if s.flags.cpuProf != "" {
+		file, err := writeCPUProfile(s.flags.cpuProf)
+		switch {
+		case errors.Is(err, profiler.ErrNotActive):
+			// 已通过信号停止并写入
+		case err != nil:
+			errs = append(errs, err)
+		case s.verbose:
+			fmt.Printf("📊 CPU 采样已写入: %s\n", file)
+		}
+	}
+	if s.flags.heapSnapshot != "" && !s.runner.Interrupted() {
+		file, err := writeHeapSummary(s.flags.heapSnapshot, s.runner.HeapSummary())
+		if err != nil {
+			errs = append(errs, err)
+		} else if s.verbose {
+			fmt.Printf("📊 堆摘要已写入: %s\n", file)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeCPUProfile 停止 CPU 采样并写入文件
+func writeCPUProfile(file string) (string, error) {
+	data, err := profiler.StopCPU()
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return "", fmt.Errorf("写入 CPU 采样失败: %w", err)
+	}
+	return file, nil
+}
+
+// writeHeapSummary 将堆摘要写入 JSON 文件
+func writeHeapSummary(file string, summary *profiler.HeapSummary) (string, error) {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(file, append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("写入堆摘要失败: %w", err)
+	}
+	return file, nil
+}
+
+// signalFileName 生成信号触发时的输出文件名，如 cpu-1234-20060102-150405.pprof
+func signalFileName(kind, ext string) string {
+	return fmt.Sprintf("%s-%d-%s.%s", kind, os.Getpid(), time.Now().Format("20060102-150405"), ext)
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package cmd
+
+import "os"
+
+// 当前平台没有 SIGUSR1/SIGUSR2，不支持通过信号切换性能分析
+var (
+	cpuProfileSignal  os.Signal
+	heapSummarySignal os.Signal
+)
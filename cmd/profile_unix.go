@@ -0,0 +1,14 @@
+//go:build unix
+
+package cmd
+
+import (
+	"os"
+	"syscall"
+)
+
+// 运行时切换性能分析的信号：SIGUSR2 开始/停止 CPU 采样，SIGUSR1 输出堆摘要
+var (
+	cpuProfileSignal  os.Signal = syscall.SIGUSR2
+	heapSummarySignal os.Signal = syscall.SIGUSR1
+)
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/issueye/sw_runtime/internal/project"
+	"github.com/issueye/sw_runtime/internal/remote"
+
+	"github.com/spf13/cobra"
+)
+
+// remoteFlags 远程模块（http(s) URL 导入）相关的标志
+type remoteFlags struct {
+	reload       bool
+	lockWrite    bool
+	offline      bool
+	allowPrivate bool
+	lock         string
+}
+
+// addRemoteFlags 为命令注册远程模块标志
+func addRemoteFlags(cmd *cobra.Command, f *remoteFlags) {
+	flags := cmd.Flags()
+	flags.BoolVar(&f.reload, "reload", false, "忽略下载缓存，重新下载远程模块")
+	flags.BoolVar(&f.lockWrite, "lock-write", false, "远程模块内容变化时更新 sw.lock 中的完整性哈希")
+	flags.BoolVar(&f.offline, "offline", false, "离线模式：只使用已缓存的远程模块")
+	flags.BoolVar(&f.allowPrivate, "allow-private-imports", false, "允许从内网或本机地址导入远程模块")
+	flags.StringVar(&f.lock, "lock", "", "sw.lock 路径（默认位于 sw.json 所在目录，没有 sw.json 时位于入口文件所在目录）")
+}
+
+// loader 根据标志创建远程模块加载器
+func (f *remoteFlags) loader(entryFile string) (*remote.Loader, error) {
+	if f.reload && f.offline {
+		return nil, fmt.Errorf("--reload 与 --offline 不能同时使用")
+	}
+
+	lockFile := f.lock
+	if lockFile == "" {
+		dir := filepath.Dir(entryFile)
+		config, err := project.Find(dir)
+		if err != nil {
+			return nil, err
+		}
+		if config != nil {
+			dir = config.Dir()
+		}
+		lockFile = filepath.Join(dir, remote.LockFileName)
+	}
+	lockFile, err := filepath.Abs(lockFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return remote.New(remote.Options{
+		LockFile:     lockFile,
+		Reload:       f.reload,
+		LockWrite:    f.lockWrite,
+		Offline:      f.offline,
+		AllowPrivate: f.allowPrivate,
+	}), nil
+}
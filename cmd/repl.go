@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/issueye/sw_runtime/internal/repl"
+	"github.com/issueye/sw_runtime/internal/runtime"
+
+	"github.com/spf13/cobra"
+)
+
+// replCmd 代表 repl 命令
+var replCmd = &cobra.Command{
+	Use:   "repl",
+	Short: "启动交互式 REPL",
+	Long: `启动交互式 JavaScript/TypeScript 命令行，所有输入共享同一个运行环境。
+
+特性:
+  • 多行输入 - 未闭合的括号、模板字符串会继续读取下一行
+  • TypeScript - 输入会自动编译
+  • 顶层 await - 可直接 await Promise，声明的变量在后续输入中可用
+  • Tab 补全 - 补全全局变量与对象属性
+  • 历史记录 - 保存在 ~/.sw_runtime_repl_history（可通过 SW_RUNTIME_REPL_HISTORY 修改）
+
+命令:
+  .help          显示帮助
+  .load <file>   在当前会话中执行文件
+  .save <file>   保存本次会话的输入
+  .break         放弃当前的多行输入
+  .exit          退出
+
+示例:
+  sw_runtime
+  sw_runtime repl
+  > const db = await require('sqlite').open('app.db')
+  > await db.all('SELECT * FROM users LIMIT 5')`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runREPL()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(replCmd)
+}
+
+// runREPL 启动交互式会话
+func runREPL() {
+	runner, err := runtime.New()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 创建运行器失败: %v\n", err)
+		os.Exit(1)
+	}
+	defer runner.Close()
+	runner.SetArgv([]string{"sw_runtime"})
+
+	session := runner.NewREPL()
+	session.SetColors(repl.IsTerminal(os.Stdout) && os.Getenv("NO_COLOR") == "")
+
+	err = repl.Run(session, repl.Options{
+		HistoryFile: repl.DefaultHistoryFile(),
+		Banner:      fmt.Sprintf("欢迎使用 SW Runtime v%s\n输入 .help 查看帮助，.exit 或 Ctrl+D 退出", version),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+}
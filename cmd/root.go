@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/issueye/sw_runtime/internal/sourcemap"
+	"github.com/issueye/sw_runtime/internal/standalone"
+
 	"github.com/spf13/cobra"
 )
 
@@ -25,16 +28,35 @@ var rootCmd = &cobra.Command{
   • 零依赖 - 无需安装 Node.js
 
 示例:
+  sw_runtime                              启动交互式 REPL
   sw_runtime run app.ts                   运行 TypeScript 脚本
   sw_runtime run app.js                   运行 JavaScript 脚本
   sw_runtime eval "console.log('Hello')"  执行 JavaScript 代码
   sw_runtime bundle app.js -o dist.js     打包多个脚本
+  sw_runtime compile app.ts -o app        编译为独立可执行文件
   sw_runtime version                      显示版本信息`,
 	Version: version,
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// 不带参数时进入交互式 REPL
+		runREPL()
+	},
 }
 
 // Execute 添加所有子命令到 root 命令并适当设置标志
 func Execute() {
+	// 独立可执行文件直接运行嵌入的脚本，不解析命令行
+	if payload, err := standalone.Current(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 读取嵌入的脚本失败: %v\n", err)
+		os.Exit(1)
+	} else if payload != nil {
+		if err := runStandalone(payload); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ 运行失败: %s\n", sourcemap.FormatError(err))
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
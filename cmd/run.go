@@ -1,13 +1,23 @@
 package cmd
 
 import (
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
-	"sw_runtime/internal/runtime"
+	"time"
+
+	"github.com/issueye/sw_runtime/internal/cache"
+	"github.com/issueye/sw_runtime/internal/importmap"
+	"github.com/issueye/sw_runtime/internal/remote"
+	"github.com/issueye/sw_runtime/internal/runtime"
+	"github.com/issueye/sw_runtime/internal/security"
+	"github.com/issueye/sw_runtime/internal/sourcemap"
 
 	"github.com/spf13/cobra"
 )
@@ -18,6 +28,12 @@ var (
 	decryptKeyFile string
 	workingDir     string
 	watchMode      bool
+	runTimeout     time.Duration
+	runImportMap   string
+	runRemote      remoteFlags
+	runPerms       permissionFlags
+	runConsole     consoleFlags
+	runProfile     profileFlags
 )
 
 // runCmd 代表 run 命令
@@ -37,7 +53,15 @@ var runCmd = &cobra.Command{
   sw_runtime run --clear-cache app.ts
   sw_runtime run --decrypt-key=<key> encrypted.bundle.js
   sw_runtime run --decrypt-key-file=bundle.key encrypted.bundle.js
-  sw_runtime run --watch app.ts`,
+  sw_runtime run --watch app.ts
+  sw_runtime run --timeout 30s job.ts
+  sw_runtime run --import-map import_map.json app.js
+  sw_runtime run --reload --lock-write app.ts
+  sw_runtime run --offline app.ts
+  sw_runtime run --allow-read=./data --allow-net=api.example.com app.ts
+  sw_runtime run --sandbox untrusted.js
+  sw_runtime run --log-level warn --log-format json server.ts
+  sw_runtime run --cpu-prof cpu.pprof --heap-snapshot heap.json app.ts`,
 	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		scriptPath := args[0]
@@ -58,38 +82,99 @@ var runCmd = &cobra.Command{
 			}
 		}
 
+		consoleOpts, err := runConsole.options()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+
+		importMap, err := loadImportMap(runImportMap, scriptPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ 加载导入映射失败: %v\n", err)
+			os.Exit(1)
+		}
+		if importMap != nil && verbose && !quiet {
+			fmt.Printf("🗺️  使用导入映射: %s\n", importMap.Path)
+		}
+		remoteLoader, err := runRemote.loader(scriptPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+
 		// 执行脚本
-		err := runScript(scriptPath, args[1:], workingDir, clearCache, decryptKey, decryptKeyFile, watchMode, verbose, quiet)
+		err = runScript(scriptPath, args[1:], workingDir, clearCache, decryptKey, decryptKeyFile, watchMode, runTimeout,
+			runPerms.permissions(cmd), importMap, remoteLoader, consoleOpts, &runProfile, verbose, quiet)
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "❌ 运行失败: %v\n", err)
+			fmt.Fprintf(os.Stderr, "❌ 运行失败: %s\n", sourcemap.FormatError(err))
 			os.Exit(1)
 		}
 	},
 }
 
+// exitCodeError 脚本正常结束但设置了非零的 process.exitCode
+type exitCodeError struct {
+	code int
+}
+
+func (e *exitCodeError) Error() string {
+	return fmt.Sprintf("exit code %d", e.code)
+}
+
 func init() {
 	rootCmd.AddCommand(runCmd)
 
 	// 本地标志
-	runCmd.Flags().BoolVarP(&clearCache, "clear-cache", "c", false, "运行前清除模块缓存")
+	runCmd.Flags().BoolVarP(&clearCache, "clear-cache", "c", false, "运行前清除模块缓存和磁盘上的编译缓存")
 	runCmd.Flags().StringVar(&decryptKey, "decrypt-key", "", "解密密钥（用于加密的 bundle 文件）")
 	runCmd.Flags().StringVar(&decryptKeyFile, "decrypt-key-file", "", "解密密钥文件路径")
 	runCmd.Flags().StringVar(&workingDir, "dir", "", "指定工作目录（用于 fs 模块的沙箱基础路径）")
 	runCmd.Flags().BoolVarP(&watchMode, "watch", "w", false, "监控文件变化并热重载")
+	runCmd.Flags().DurationVar(&runTimeout, "timeout", 0, "最长执行时间（如 30s、5m），超时后中断脚本，0 表示不限制")
+	addImportMapFlag(runCmd, &runImportMap)
+	addRemoteFlags(runCmd, &runRemote)
+	addPermissionFlags(runCmd, &runPerms)
+	addConsoleFlags(runCmd, &runConsole)
+	addProfileFlags(runCmd, &runProfile)
 }
 
 // runScript 执行脚本并支持热加载
 func runScript(scriptPath string, scriptArgs []string, workingDir string, clearCache bool, decryptKey, decryptKeyFile string,
-	watchMode, verbose, quiet bool) error {
+	watchMode bool, timeout time.Duration, perms *security.Permissions, importMap *importmap.ImportMap, remoteLoader *remote.Loader,
+	consoleOpts runtime.ConsoleOptions,
+	prof *profileFlags, verbose, quiet bool) error {
 
 	// 如果有加密文件，暂时不支持监控模式
 	if watchMode && (decryptKey != "" || decryptKeyFile != "") {
 		return fmt.Errorf("加密文件暂不支持监控模式")
 	}
+	if watchMode && timeout > 0 {
+		return fmt.Errorf("监控模式不支持 --timeout")
+	}
+	if watchMode && prof.enabled() {
+		return fmt.Errorf("监控模式不支持性能分析")
+	}
+
+	// 清除磁盘上的 TypeScript/ES 模块编译缓存
+	if clearCache {
+		if err := cache.Default().Clear(); err != nil {
+			return fmt.Errorf("清除编译缓存失败: %w", err)
+		}
+		if verbose && !quiet && cache.DefaultDir() != "" {
+			fmt.Printf("🧹 已清除编译缓存: %s\n", cache.DefaultDir())
+		}
+	}
 
 	// 处理加密文件
 	var actualScriptPath = scriptPath
 	if decryptKey != "" || decryptKeyFile != "" {
+		// 解密后的代码不写入编译缓存
+		cache.Disable()
+
 		// 读取密钥
 		key := decryptKey
 		if decryptKeyFile != "" {
@@ -121,15 +206,21 @@ func runScript(scriptPath string, scriptArgs []string, workingDir string, clearC
 		// 使用运行器管理器
 		manager := runtime.NewRunnerManager(scriptPath, workingDir, clearCache,
 			decryptKey, decryptKeyFile, verbose, quiet)
+		manager.SetPermissions(perms)
+		manager.SetImportMap(importMap)
+		manager.SetRemoteLoader(remoteLoader)
+		manager.SetConsoleOptions(consoleOpts)
 		return manager.Start()
 	} else {
 		// 传统模式：单次运行
-		return runScriptOnce(actualScriptPath, scriptArgs, workingDir, clearCache, verbose, quiet)
+		return runScriptOnce(actualScriptPath, scriptArgs, workingDir, clearCache, timeout, perms, importMap, remoteLoader, consoleOpts, prof, verbose, quiet)
 	}
 }
 
 // runScriptOnce 单次运行脚本
-func runScriptOnce(scriptPath string, scriptArgs []string, workingDir string, clearCache, verbose, quiet bool) error {
+func runScriptOnce(scriptPath string, scriptArgs []string, workingDir string, clearCache bool, timeout time.Duration,
+	perms *security.Permissions, importMap *importmap.ImportMap, remoteLoader *remote.Loader, consoleOpts runtime.ConsoleOptions,
+	prof *profileFlags, verbose, quiet bool) error {
 	// 创建运行器
 	var runner *runtime.Runner
 	if workingDir != "" {
@@ -146,6 +237,10 @@ func runScriptOnce(scriptPath string, scriptArgs []string, workingDir string, cl
 	// 设置脚本参数
 	argv := append([]string{"sw_runtime", scriptPath}, scriptArgs...)
 	runner.SetArgv(argv)
+	runner.SetPermissions(perms)
+	runner.SetImportMap(importMap)
+	runner.SetRemoteLoader(remoteLoader)
+	runner.SetConsoleOptions(consoleOpts)
 
 	// 如果需要清除缓存
 	if clearCache {
@@ -160,18 +255,52 @@ func runScriptOnce(scriptPath string, scriptArgs []string, workingDir string, cl
 		fmt.Printf("🚀 正在运行: %s\n", scriptPath)
 	}
 
-	err := runner.RunFile(scriptPath)
+	session, err := startProfile(prof, runner, verbose && !quiet)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := timeoutContext(timeout)
+	defer cancel()
+
+	err = runner.RunFileContext(ctx, scriptPath)
+	if profErr := session.finish(); profErr != nil {
+		if err == nil {
+			return profErr
+		}
+		fmt.Fprintf(os.Stderr, "❌ %v\n", profErr)
+	}
 	if err != nil {
-		return fmt.Errorf("运行失败: %w", err)
+		return timeoutError(err, timeout, "运行失败")
 	}
 
 	if verbose && !quiet {
 		fmt.Println("✅ 执行完成")
 	}
 
+	if code := runner.ExitCode(); code != 0 {
+		return &exitCodeError{code: code}
+	}
 	return nil
 }
 
+// timeoutContext 创建限制执行时间的 context，timeout 为 0 时不限制
+func timeoutContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// timeoutError 将执行超时转换为易读的错误，其他错误加上 prefix 前缀
+func timeoutError(err error, timeout time.Duration, prefix string) error {
+	var timeoutErr *runtime.TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return fmt.Errorf("执行超时（超过 %s），脚本已被中断", timeout)
+	}
+	return fmt.Errorf("%s: %w", prefix, err)
+}
+
 // decryptBundleFile 解密 bundle 文件
 func decryptBundleFile(encryptedFile string, keyStr string) (string, error) {
 	// 读取加密文件
@@ -180,21 +309,17 @@ func decryptBundleFile(encryptedFile string, keyStr string) (string, error) {
 		return "", err
 	}
 
-	// 提取 ENCRYPTED_CODE 变量
-	re := regexp.MustCompile(`const ENCRYPTED_CODE = "([^"]+)";`)
-	matches := re.FindStringSubmatch(string(content))
-	if len(matches) < 2 {
-		return "", fmt.Errorf("文件不是加密的 bundle 文件")
-	}
-
-	encryptedCode := matches[1]
-
 	// 解密
-	decryptedCode, err := decryptCode(encryptedCode, keyStr)
+	decryptedCode, err := decryptBundle(string(content), keyStr)
 	if err != nil {
 		return "", err
 	}
 
+	// 代码在临时文件中执行，source map 的相对路径改为相对 bundle 所在目录
+	if dir, err := filepath.Abs(filepath.Dir(encryptedFile)); err == nil {
+		decryptedCode = sourcemap.Rebase(decryptedCode, dir)
+	}
+
 	// 创建临时文件
 	tmpFile, err := os.CreateTemp("", "sw_decrypted_*.js")
 	if err != nil {
@@ -211,6 +336,18 @@ func decryptBundleFile(encryptedFile string, keyStr string) (string, error) {
 	return tmpFile.Name(), nil
 }
 
+// decryptBundle 从加密的 bundle 内容中提取并解密代码
+func decryptBundle(content string, keyStr string) (string, error) {
+	// 提取 ENCRYPTED_CODE 变量
+	re := regexp.MustCompile(`const ENCRYPTED_CODE = "([^"]+)";`)
+	matches := re.FindStringSubmatch(content)
+	if len(matches) < 2 {
+		return "", fmt.Errorf("文件不是加密的 bundle 文件")
+	}
+
+	return decryptCode(matches[1], keyStr)
+}
+
 // decryptCode 使用 AES-256-GCM 解密代码
 func decryptCode(encryptedCode string, keyStr string) (string, error) {
 	// 解码 base64 加密数据
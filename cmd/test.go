@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
+	"github.com/issueye/sw_runtime/internal/testrunner"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	testFilter   string
+	testTimeout  int
+	testReporter string
+	testOutput   string
+	testWatch    bool
+)
+
+// testCmd 代表 test 命令
+var testCmd = &cobra.Command{
+	Use:   "test [paths...]",
+	Short: "运行 JavaScript/TypeScript 测试",
+	Long: `查找并运行测试文件（*.test.ts、*.test.js、*.spec.ts、*.spec.js 等）。
+
+测试文件中可直接使用的全局函数:
+  • describe / describe.skip / describe.only
+  • it / test / it.skip / it.only / it.todo
+  • beforeAll / afterAll / beforeEach / afterEach
+  • expect(value).toBe / toEqual / toThrow / resolves / rejects / not ...
+
+每个测试文件在独立的运行器中执行，支持 async 测试与 done 回调。
+
+示例:
+  sw_runtime test
+  sw_runtime test tests/ src/math.spec.ts
+  sw_runtime test --filter "user > login"
+  sw_runtime test --timeout 10000
+  sw_runtime test --reporter junit --output report.xml
+  sw_runtime test --watch`,
+	Run: func(cmd *cobra.Command, args []string) {
+		reporter := testReporter
+		quiet, _ := cmd.Flags().GetBool("quiet")
+
+		opts := testrunner.Options{
+			Filter:  testFilter,
+			Timeout: time.Duration(testTimeout) * time.Millisecond,
+		}
+
+		if testWatch {
+			if err := watchTests(args, opts, reporter); err != nil {
+				fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		ok, err := runTests(args, opts, reporter, testOutput, quiet)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(testCmd)
+
+	testCmd.Flags().StringVar(&testFilter, "filter", "", "只运行完整名称（describe > it）匹配该正则表达式的测试")
+	testCmd.Flags().IntVar(&testTimeout, "timeout", int(testrunner.DefaultTimeout.Milliseconds()), "单个测试的默认超时时间（毫秒）")
+	testCmd.Flags().StringVarP(&testReporter, "reporter", "r", "spec", "报告格式: spec、tap、junit")
+	testCmd.Flags().StringVarP(&testOutput, "output", "o", "", "将报告写入文件（默认输出到终端）")
+	testCmd.Flags().BoolVarP(&testWatch, "watch", "w", false, "监控文件变化并重新运行测试")
+}
+
+// runTests 查找并运行测试，返回是否全部通过
+func runTests(paths []string, opts testrunner.Options, reporterName, output string, quiet bool) (bool, error) {
+	files, err := testrunner.Discover(paths)
+	if err != nil {
+		return false, fmt.Errorf("查找测试文件失败: %w", err)
+	}
+	if len(files) == 0 {
+		return false, fmt.Errorf("未找到测试文件")
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return false, fmt.Errorf("创建报告文件失败: %w", err)
+		}
+		defer f.Close()
+		w = f
+	} else if quiet && reporterName == "spec" {
+		w = io.Discard
+	}
+
+	reporter, err := testrunner.NewReporter(reporterName, w)
+	if err != nil {
+		return false, err
+	}
+
+	results := testrunner.Run(files, opts, reporter)
+	if err := reporter.Finish(results); err != nil {
+		return false, fmt.Errorf("输出报告失败: %w", err)
+	}
+
+	summary := testrunner.Summarize(results)
+	if output != "" && !quiet {
+		fmt.Printf("测试: %d 通过, %d 失败, %d 跳过，报告已写入 %s\n", summary.Passed, summary.Failed+summary.Errors, summary.Skipped, output)
+	}
+	return summary.OK(), nil
+}
+
+// watchTests 运行测试并在文件变化后重新运行，直到收到中断信号
+func watchTests(paths []string, opts testrunner.Options, reporterName string) error {
+	rerun := make(chan struct{}, 1)
+	reloader, err := runtime.NewHotReloader(func() {
+		select {
+		case rerun <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		return err
+	}
+	defer reloader.Stop()
+
+	// 监控参数中的路径与所有测试文件所在目录
+	watchPaths := paths
+	if len(watchPaths) == 0 {
+		watchPaths = []string{"."}
+	}
+	files, err := testrunner.Discover(paths)
+	if err != nil {
+		return fmt.Errorf("查找测试文件失败: %w", err)
+	}
+	for _, file := range files {
+		watchPaths = append(watchPaths, filepath.Dir(file))
+	}
+	for _, path := range watchPaths {
+		if err := reloader.AddWatch(path); err != nil {
+			return err
+		}
+	}
+	reloader.Start()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	for {
+		if _, err := runTests(paths, opts, reporterName, "", false); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		}
+		fmt.Println("\n👀 正在监控文件变化... (按 Ctrl+C 退出)")
+
+		select {
+		case <-rerun:
+		case <-sigChan:
+			return nil
+		}
+	}
+}
@@ -46,7 +46,7 @@ const testAsyncContent = 'Hello, Async File System!';
 fs.writeFile(testAsyncFile, testAsyncContent)
     .then(() => {
         console.log('✓ 异步文件写入成功');
-        return fs.readFile(testAsyncFile);
+        return fs.readFile(testAsyncFile, 'utf8');
     })
     .then((content: string) => {
         console.log('✓ 异步文件读取成功:', content);
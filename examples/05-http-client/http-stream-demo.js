@@ -41,11 +41,8 @@ async function streamDownloadExample() {
     let totalBytes = 0;
     let chunks = 0;
 
-    while (true) {
-        // 读取 8KB 数据块
-        const chunk = response2.data.read(8192);
-        if (!chunk || chunk.length === 0) break;
-
+    // response.data 是 Readable 流，可以用 for await 逐块读取
+    for await (const chunk of response2.data) {
         totalBytes += chunk.length;
         chunks++;
 
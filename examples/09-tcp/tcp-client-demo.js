@@ -12,7 +12,8 @@ net.connectTCP('localhost:8080', {
     console.log('Remote address:', socket.remoteAddress);
     console.log();
     
-    // 接收数据
+    // 接收数据（未设置编码时 data 为 Buffer）
+    socket.setEncoding('utf8');
     socket.on('data', (data) => {
         console.log('Received:', data.trim());
     });
@@ -12,7 +12,8 @@ server.on('connection', (socket) => {
     // 发送欢迎消息
     socket.write('Welcome to TCP Server!\n');
     
-    // 接收数据
+    // 接收数据（未设置编码时 data 为 Buffer）
+    socket.setEncoding('utf8');
     socket.on('data', (data) => {
         console.log('Received from client:', data.trim());
         
@@ -17,7 +17,7 @@ socket.bind('0', '0.0.0.0', () => {
     // 接收回复
     socket.on('message', (msg, rinfo) => {
         console.log('Received reply from', rinfo.address + ':' + rinfo.port);
-        console.log('Reply:', msg.trim());
+        console.log('Reply:', msg.toString().trim());
         console.log();
     });
     
@@ -9,7 +9,7 @@ const socket = net.createUDPSocket('udp4');
 // 接收消息
 socket.on('message', (msg, rinfo) => {
     console.log('Received message from', rinfo.address + ':' + rinfo.port);
-    console.log('Message:', msg.trim());
+    console.log('Message:', msg.toString().trim());
     console.log();
     
     // 回复客户端
@@ -1,17 +1,20 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
 	stdruntime "runtime"
+	"strings"
+	"time"
 
-	rt "sw_runtime/internal/runtime"
+	rt "github.com/issueye/sw_runtime/internal/runtime"
 )
 
 // scriptsDir 保存边缘脚本所在的目录路径（与本示例 main.go 同级的 scripts 目录）。
@@ -45,6 +48,9 @@ type EdgeResponse struct {
 
 var runnerPool = rt.NewRunnerPool()
 
+// scriptTimeout 单个边缘脚本的最长执行时间，防止租户脚本死循环占满 CPU。
+const scriptTimeout = 2 * time.Second
+
 func main() {
 	http.HandleFunc("/edge/", edgeHandler)
 
@@ -72,6 +78,7 @@ func edgeHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 2. 从池中获取 Runner（每个请求独占一个 Runner，避免并发冲突）。
+	// 超时被中断的 Runner 在归还时会被自动关闭，不会回到池中。
 	runner := rt.AcquireRunner()
 	defer rt.ReleaseRunner(runner)
 
@@ -98,9 +105,17 @@ func edgeHandler(w http.ResponseWriter, r *http.Request) {
 	// 清理上一次可能遗留的 response
 	runner.SetValue("response", nil)
 
-	// 4. 安全执行脚本文件（捕获 goja panic）
-	if err := runner.SafeRunFile(scriptPath); err != nil {
-		log.Printf("edge script panic (%s): %v", scriptPath, err)
+	// 4. 限时执行脚本文件：超时或客户端断开时中断脚本（同时捕获 goja panic）
+	ctx, cancel := context.WithTimeout(r.Context(), scriptTimeout)
+	defer cancel()
+	if err := runner.RunFileContext(ctx, scriptPath); err != nil {
+		var timeoutErr *rt.TimeoutError
+		if errors.As(err, &timeoutErr) {
+			log.Printf("edge script interrupted (%s): %v", scriptPath, err)
+			http.Error(w, "edge script timed out", http.StatusGatewayTimeout)
+			return
+		}
+		log.Printf("edge script failed (%s): %v", scriptPath, err)
 		http.Error(w, "edge script failed", http.StatusInternalServerError)
 		return
 	}
@@ -0,0 +1,507 @@
+package buffer
+
+import (
+	"bytes"
+	"unicode/utf16"
+
+	"github.com/dop251/goja"
+)
+
+// kMaxLength Buffer 最大长度
+const kMaxLength = 0x7fffffff
+
+// constructorKey 在全局对象上缓存 Buffer 构造函数的隐藏键，
+// 保证同一个 VM 中所有内置模块返回的 Buffer 共享同一原型
+var constructorKey = goja.NewSymbol("sw.Buffer")
+
+// bufferProgram Buffer 实现（预编译，可在多个 VM 间复用）
+var bufferProgram = goja.MustCompile("buffer.js", bufferSource, true)
+
+// BufferModule Buffer 模块
+type BufferModule struct {
+	vm *goja.Runtime
+}
+
+// NewBufferModule 创建 Buffer 模块
+func NewBufferModule(vm *goja.Runtime) *BufferModule {
+	return &BufferModule{vm: vm}
+}
+
+// GetModule 获取 Buffer 模块对象
+func (b *BufferModule) GetModule() *goja.Object {
+	obj := b.vm.NewObject()
+	obj.Set("Buffer", Constructor(b.vm))
+	obj.Set("kMaxLength", kMaxLength)
+
+	constants := b.vm.NewObject()
+	constants.Set("MAX_LENGTH", kMaxLength)
+	constants.Set("MAX_STRING_LENGTH", 1<<29-24)
+	obj.Set("constants", constants)
+
+	return obj
+}
+
+// Constructor 获取 VM 中的 Buffer 构造函数，首次调用时初始化
+func Constructor(vm *goja.Runtime) *goja.Object {
+	global := vm.GlobalObject()
+	if ctor, ok := global.GetSymbol(constructorKey).(*goja.Object); ok {
+		return ctor
+	}
+
+	factory, err := vm.RunProgram(bufferProgram)
+	if err != nil {
+		panic(err)
+	}
+	fn, ok := goja.AssertFunction(factory)
+	if !ok {
+		panic(vm.NewTypeError("invalid Buffer factory"))
+	}
+	ctor, err := fn(goja.Undefined(), newNative(vm))
+	if err != nil {
+		panic(err)
+	}
+
+	ctorObj := ctor.ToObject(vm)
+	global.DefineDataPropertySymbol(constructorKey, ctorObj, goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_FALSE)
+	return ctorObj
+}
+
+// New 使用给定字节创建 Buffer，data 的所有权转移给 Buffer，调用方不应再修改
+func New(vm *goja.Runtime, data []byte) goja.Value {
+	if data == nil {
+		data = []byte{}
+	}
+	obj, err := vm.New(Constructor(vm), vm.ToValue(vm.NewArrayBuffer(data)))
+	if err != nil {
+		panic(err)
+	}
+	return obj
+}
+
+// Bytes 获取 Buffer、TypedArray、DataView 或 ArrayBuffer 底层的字节（共享内存，不复制）
+func Bytes(vm *goja.Runtime, value goja.Value) ([]byte, bool) {
+	obj, ok := value.(*goja.Object)
+	if !ok {
+		return nil, false
+	}
+
+	switch v := obj.Export().(type) {
+	case []byte:
+		return v, true
+	case goja.ArrayBuffer:
+		return v.Bytes(), true
+	}
+
+	// 其他 TypedArray 与 DataView 按字节视图导出
+	buf := obj.Get("buffer")
+	if buf == nil {
+		return nil, false
+	}
+	if _, ok := buf.Export().(goja.ArrayBuffer); ok && obj.Get("byteOffset") != nil {
+		var data []byte
+		if err := vm.ExportTo(obj, &data); err == nil {
+			return data, true
+		}
+	}
+	return nil, false
+}
+
+// ToBytes 将二进制数据或字符串（UTF-8）转换为字节
+func ToBytes(vm *goja.Runtime, value goja.Value) []byte {
+	if data, ok := Bytes(vm, value); ok {
+		return data
+	}
+	return []byte(value.String())
+}
+
+// IsBinary 检查值是否为二进制数据
+func IsBinary(vm *goja.Runtime, value goja.Value) bool {
+	_, ok := Bytes(vm, value)
+	return ok
+}
+
+// Encoding 解析可选的编码参数（字符串或 {encoding} 对象），缺省时返回 fallback
+func Encoding(vm *goja.Runtime, value goja.Value, fallback string) string {
+	if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+		return fallback
+	}
+	if obj, ok := value.(*goja.Object); ok {
+		encoding := obj.Get("encoding")
+		if encoding == nil || goja.IsUndefined(encoding) || goja.IsNull(encoding) {
+			return fallback
+		}
+		return encoding.String()
+	}
+	return value.String()
+}
+
+// Encode 按编码返回数据："buffer" 返回 Buffer，其他编码返回字符串
+func Encode(vm *goja.Runtime, data []byte, encoding string) goja.Value {
+	if encoding == "buffer" {
+		return New(vm, data)
+	}
+	if NormalizeEncoding(encoding) == "" {
+		panic(vm.NewTypeError("Unknown encoding: " + encoding))
+	}
+	return vm.ToValue(EncodeToString(data, encoding))
+}
+
+// newNative 创建 Buffer 实现所需的原生辅助函数
+func newNative(vm *goja.Runtime) *goja.Object {
+	native := vm.NewObject()
+
+	native.Set("normalize", func(encoding string) string {
+		if encoding == "" {
+			return ""
+		}
+		return NormalizeEncoding(encoding)
+	})
+
+	native.Set("encode", func(s string, encoding string) goja.Value {
+		return vm.ToValue(vm.NewArrayBuffer(DecodeString(s, encoding)))
+	})
+
+	native.Set("byteLength", func(s string, encoding string) int {
+		switch NormalizeEncoding(encoding) {
+		case "utf8":
+			return len(s)
+		case "latin1", "ascii":
+			return len(utf16.Encode([]rune(s)))
+		case "utf16le":
+			return len(utf16.Encode([]rune(s))) * 2
+		}
+		return len(DecodeString(s, encoding))
+	})
+
+	native.Set("decode", func(view goja.Value, encoding string, start, end int) string {
+		data, _ := Bytes(vm, view)
+		if start < 0 {
+			start = 0
+		}
+		if end > len(data) {
+			end = len(data)
+		}
+		if start >= end {
+			return ""
+		}
+		return EncodeToString(data[start:end], encoding)
+	})
+
+	native.Set("compare", func(a, b goja.Value) int {
+		left, ok := Bytes(vm, a)
+		right, ok2 := Bytes(vm, b)
+		if !ok || !ok2 {
+			panic(vm.NewTypeError("The arguments must be one of type Buffer or Uint8Array"))
+		}
+		return bytes.Compare(left, right)
+	})
+
+	native.Set("indexOf", func(haystack, needle goja.Value, offset int, first bool) int {
+		data, _ := Bytes(vm, haystack)
+		pattern, _ := Bytes(vm, needle)
+		if offset < 0 {
+			offset += len(data)
+			if offset < 0 {
+				offset = 0
+			}
+		}
+		if first {
+			if offset > len(data) {
+				return -1
+			}
+			index := bytes.Index(data[offset:], pattern)
+			if index < 0 {
+				return -1
+			}
+			return index + offset
+		}
+		limit := offset + len(pattern)
+		if limit > len(data) {
+			limit = len(data)
+		}
+		return bytes.LastIndex(data[:limit], pattern)
+	})
+
+	return native
+}
+
+// bufferSource Node.js 兼容的 Buffer 实现，继承自 Uint8Array
+const bufferSource = `(function (native) {
+	const kMaxLength = 0x7fffffff;
+
+	function normalize(encoding) {
+		if (encoding === undefined || encoding === null) return 'utf8';
+		const normalized = native.normalize(String(encoding));
+		if (!normalized) throw new TypeError('Unknown encoding: ' + encoding);
+		return normalized;
+	}
+
+	function checkSize(size) {
+		if (typeof size !== 'number' || size < 0 || size > kMaxLength || size !== size) {
+			throw new RangeError('The argument "size" is invalid. Received ' + size);
+		}
+	}
+
+	function toIndex(value, fallback) {
+		if (value === undefined) return fallback;
+		value = Math.trunc(Number(value));
+		return value !== value ? fallback : value;
+	}
+
+	function checkOffset(buf, offset, size) {
+		if (offset === undefined) offset = 0;
+		if (typeof offset !== 'number' || offset % 1 !== 0) {
+			throw new TypeError('The "offset" argument must be of type integer. Received ' + offset);
+		}
+		if (offset < 0 || offset + size > buf.length) {
+			throw new RangeError('The value of "offset" is out of range. It must be >= 0 and <= ' + (buf.length - size) + '. Received ' + offset);
+		}
+		return offset;
+	}
+
+	function view(buf) {
+		return new DataView(buf.buffer, buf.byteOffset, buf.byteLength);
+	}
+
+	function search(buf, value, byteOffset, encoding, first) {
+		if (typeof byteOffset === 'string') {
+			encoding = byteOffset;
+			byteOffset = undefined;
+		}
+		if (typeof value === 'number') {
+			if (first) return Uint8Array.prototype.indexOf.call(buf, value & 255, toIndex(byteOffset, 0));
+			return Uint8Array.prototype.lastIndexOf.call(buf, value & 255, toIndex(byteOffset, buf.length - 1));
+		}
+		const needle = typeof value === 'string' ? Buffer.from(value, encoding) : value;
+		if (!(needle instanceof Uint8Array)) {
+			throw new TypeError('The "value" argument must be one of type number, string, Buffer, or Uint8Array');
+		}
+		return native.indexOf(buf, needle, toIndex(byteOffset, first ? 0 : buf.length), first);
+	}
+
+	class Buffer extends Uint8Array {
+		constructor(value, encodingOrOffset, length) {
+			if (typeof value === 'string') {
+				super(native.encode(value, normalize(encodingOrOffset)));
+			} else if (value instanceof ArrayBuffer) {
+				const offset = toIndex(encodingOrOffset, 0);
+				super(value, offset, length === undefined ? value.byteLength - offset : length);
+			} else {
+				super(value);
+			}
+		}
+
+		static from(value, encodingOrOffset, length) {
+			if (typeof value === 'string') return new Buffer(value, encodingOrOffset);
+			if (value instanceof ArrayBuffer) return new Buffer(value, encodingOrOffset, length);
+			if (ArrayBuffer.isView(value)) {
+				if (value instanceof DataView) {
+					return new Buffer(value.buffer.slice(value.byteOffset, value.byteOffset + value.byteLength));
+				}
+				return new Buffer(value);
+			}
+			if (value !== null && typeof value === 'object') {
+				if (value.type === 'Buffer' && Array.isArray(value.data)) return new Buffer(value.data);
+				if (Array.isArray(value) || typeof value.length === 'number') return new Buffer(value);
+				const primitive = value.valueOf();
+				if (primitive !== value && primitive !== null && primitive !== undefined) {
+					return Buffer.from(primitive, encodingOrOffset, length);
+				}
+				if (typeof value[Symbol.toPrimitive] === 'function') {
+					return Buffer.from(value[Symbol.toPrimitive]('string'), encodingOrOffset, length);
+				}
+			}
+			throw new TypeError('The first argument must be of type string, Buffer, ArrayBuffer, Array, or Array-like Object. Received ' + value);
+		}
+
+		static alloc(size, fill, encoding) {
+			checkSize(size);
+			const buf = new Buffer(size);
+			if (fill !== undefined && fill !== 0) buf.fill(fill, encoding);
+			return buf;
+		}
+
+		static allocUnsafe(size) {
+			checkSize(size);
+			return new Buffer(size);
+		}
+
+		static allocUnsafeSlow(size) {
+			return Buffer.allocUnsafe(size);
+		}
+
+		static byteLength(value, encoding) {
+			if (typeof value === 'string') return native.byteLength(value, normalize(encoding));
+			if (ArrayBuffer.isView(value) || value instanceof ArrayBuffer) return value.byteLength;
+			throw new TypeError('The "string" argument must be of type string, Buffer, or ArrayBuffer');
+		}
+
+		static isBuffer(value) {
+			return value instanceof Buffer;
+		}
+
+		static isEncoding(encoding) {
+			return typeof encoding === 'string' && native.normalize(encoding) !== '';
+		}
+
+		static concat(list, totalLength) {
+			if (!Array.isArray(list)) {
+				throw new TypeError('The "list" argument must be an instance of Array');
+			}
+			if (totalLength === undefined) {
+				totalLength = 0;
+				for (const item of list) totalLength += item.length;
+			}
+			const result = Buffer.alloc(totalLength);
+			let offset = 0;
+			for (const item of list) {
+				if (!(item instanceof Uint8Array)) {
+					throw new TypeError('The "list" argument must contain only Buffer or Uint8Array instances');
+				}
+				if (offset >= totalLength) break;
+				const chunk = offset + item.length > totalLength ? item.subarray(0, totalLength - offset) : item;
+				result.set(chunk, offset);
+				offset += chunk.length;
+			}
+			return result;
+		}
+
+		static compare(a, b) {
+			return native.compare(a, b);
+		}
+
+		toString(encoding, start, end) {
+			return native.decode(this, normalize(encoding), toIndex(start, 0), toIndex(end, this.length));
+		}
+
+		toLocaleString(encoding, start, end) {
+			return this.toString(encoding, start, end);
+		}
+
+		toJSON() {
+			return { type: 'Buffer', data: Array.from(this) };
+		}
+
+		equals(other) {
+			if (!(other instanceof Uint8Array)) {
+				throw new TypeError('The "otherBuffer" argument must be an instance of Buffer or Uint8Array');
+			}
+			return native.compare(this, other) === 0;
+		}
+
+		compare(target, targetStart, targetEnd, sourceStart, sourceEnd) {
+			const t = target.subarray(toIndex(targetStart, 0), toIndex(targetEnd, target.length));
+			const s = this.subarray(toIndex(sourceStart, 0), toIndex(sourceEnd, this.length));
+			return native.compare(s, t);
+		}
+
+		slice(start, end) {
+			return this.subarray(start, end);
+		}
+
+		write(string, offset, length, encoding) {
+			if (typeof offset === 'string') {
+				encoding = offset;
+				offset = undefined;
+				length = undefined;
+			} else if (typeof length === 'string') {
+				encoding = length;
+				length = undefined;
+			}
+			offset = toIndex(offset, 0);
+			if (offset < 0 || offset > this.length) {
+				throw new RangeError('The value of "offset" is out of range. Received ' + offset);
+			}
+			const remaining = this.length - offset;
+			length = length === undefined ? remaining : Math.min(toIndex(length, remaining), remaining);
+			const data = new Uint8Array(native.encode(String(string), normalize(encoding)));
+			const count = Math.min(data.length, length);
+			this.set(data.subarray(0, count), offset);
+			return count;
+		}
+
+		copy(target, targetStart, sourceStart, sourceEnd) {
+			targetStart = toIndex(targetStart, 0);
+			sourceStart = toIndex(sourceStart, 0);
+			sourceEnd = Math.min(toIndex(sourceEnd, this.length), this.length);
+			if (targetStart >= target.length || sourceStart >= sourceEnd) return 0;
+			const count = Math.min(sourceEnd - sourceStart, target.length - targetStart);
+			target.set(this.subarray(sourceStart, sourceStart + count), targetStart);
+			return count;
+		}
+
+		fill(value, offset, end, encoding) {
+			if (typeof offset === 'string') {
+				encoding = offset;
+				offset = undefined;
+				end = undefined;
+			} else if (typeof end === 'string') {
+				encoding = end;
+				end = undefined;
+			}
+			offset = toIndex(offset, 0);
+			end = Math.min(toIndex(end, this.length), this.length);
+			if (typeof value === 'number' || typeof value === 'boolean') {
+				return super.fill(Number(value) & 255, offset, end);
+			}
+			const data = typeof value === 'string'
+				? new Uint8Array(native.encode(value, normalize(encoding)))
+				: value;
+			if (data.length === 0) return super.fill(0, offset, end);
+			for (let i = offset, j = 0; i < end; i++) {
+				this[i] = data[j];
+				j = (j + 1) % data.length;
+			}
+			return this;
+		}
+
+		indexOf(value, byteOffset, encoding) {
+			return search(this, value, byteOffset, encoding, true);
+		}
+
+		lastIndexOf(value, byteOffset, encoding) {
+			return search(this, value, byteOffset, encoding, false);
+		}
+
+		includes(value, byteOffset, encoding) {
+			return this.indexOf(value, byteOffset, encoding) !== -1;
+		}
+	}
+
+	// readUInt8 / writeInt16LE / readDoubleBE ... 基于 DataView 实现
+	const numberTypes = [
+		['UInt8', 'Uint8', 1], ['Int8', 'Int8', 1],
+		['UInt16', 'Uint16', 2], ['Int16', 'Int16', 2],
+		['UInt32', 'Uint32', 4], ['Int32', 'Int32', 4],
+		['Float', 'Float32', 4], ['Double', 'Float64', 8],
+		['BigUInt64', 'BigUint64', 8], ['BigInt64', 'BigInt64', 8]
+	];
+	function define(name, fn) {
+		Object.defineProperty(Buffer.prototype, name, { value: fn, writable: true, configurable: true });
+	}
+	for (const [name, viewName, size] of numberTypes) {
+		const getter = DataView.prototype['get' + viewName];
+		const setter = DataView.prototype['set' + viewName];
+		const endians = size === 1 ? [['', false]] : [['LE', true], ['BE', false]];
+		for (const [suffix, little] of endians) {
+			const read = function (offset) {
+				offset = checkOffset(this, offset, size);
+				return getter.call(view(this), offset, little);
+			};
+			const write = function (value, offset) {
+				offset = checkOffset(this, offset, size);
+				setter.call(view(this), offset, value, little);
+				return offset + size;
+			};
+			define('read' + name + suffix, read);
+			define('write' + name + suffix, write);
+			if (name.indexOf('UInt') !== -1) {
+				define('read' + name.replace('UInt', 'Uint') + suffix, read);
+				define('write' + name.replace('UInt', 'Uint') + suffix, write);
+			}
+		}
+	}
+
+	Buffer.poolSize = 8192;
+	return Buffer;
+})`
@@ -0,0 +1,128 @@
+package buffer
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"unicode/utf16"
+)
+
+// NormalizeEncoding 规范化编码名称，未知编码返回空字符串
+func NormalizeEncoding(encoding string) string {
+	switch strings.ToLower(encoding) {
+	case "", "utf8", "utf-8":
+		return "utf8"
+	case "hex":
+		return "hex"
+	case "base64":
+		return "base64"
+	case "base64url":
+		return "base64url"
+	case "latin1", "binary":
+		return "latin1"
+	case "ascii":
+		return "ascii"
+	case "ucs2", "ucs-2", "utf16le", "utf-16le":
+		return "utf16le"
+	}
+	return ""
+}
+
+// DecodeString 按编码将字符串转换为字节
+func DecodeString(s string, encoding string) []byte {
+	switch NormalizeEncoding(encoding) {
+	case "hex":
+		return decodeHex(s)
+	case "base64", "base64url":
+		return decodeBase64(s)
+	case "latin1", "ascii":
+		units := utf16.Encode([]rune(s))
+		data := make([]byte, len(units))
+		for i, unit := range units {
+			data[i] = byte(unit)
+		}
+		return data
+	case "utf16le":
+		units := utf16.Encode([]rune(s))
+		data := make([]byte, len(units)*2)
+		for i, unit := range units {
+			data[i*2] = byte(unit)
+			data[i*2+1] = byte(unit >> 8)
+		}
+		return data
+	}
+	return []byte(s)
+}
+
+// EncodeToString 按编码将字节转换为字符串
+func EncodeToString(data []byte, encoding string) string {
+	switch NormalizeEncoding(encoding) {
+	case "hex":
+		return hex.EncodeToString(data)
+	case "base64":
+		return base64.StdEncoding.EncodeToString(data)
+	case "base64url":
+		return base64.RawURLEncoding.EncodeToString(data)
+	case "latin1":
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	case "ascii":
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b & 0x7f)
+		}
+		return string(runes)
+	case "utf16le":
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = uint16(data[i*2]) | uint16(data[i*2+1])<<8
+		}
+		return string(utf16.Decode(units))
+	}
+	return string(data)
+}
+
+// decodeHex 解码十六进制字符串，遇到非法字符时停止（与 Node.js 一致）
+func decodeHex(s string) []byte {
+	data := make([]byte, 0, len(s)/2)
+	for i := 0; i+1 < len(s); i += 2 {
+		b, err := hex.DecodeString(s[i : i+2])
+		if err != nil {
+			break
+		}
+		data = append(data, b[0])
+	}
+	return data
+}
+
+// decodeBase64 宽松解码 base64/base64url，忽略空白与填充
+func decodeBase64(s string) []byte {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '+', r == '/':
+			sb.WriteRune(r)
+		case r == '-':
+			sb.WriteByte('+')
+		case r == '_':
+			sb.WriteByte('/')
+		case r == '=':
+			// 填充字符之后的内容忽略
+			goto done
+		}
+	}
+done:
+	clean := sb.String()
+	if len(clean)%4 == 1 {
+		clean = clean[:len(clean)-1]
+	}
+	data, err := base64.RawStdEncoding.DecodeString(clean)
+	if err != nil {
+		return nil
+	}
+	return data
+}
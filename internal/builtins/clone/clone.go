@@ -0,0 +1,426 @@
+package clone
+
+import (
+	"math/big"
+	"strconv"
+
+	"github.com/dop251/goja"
+)
+
+// sharedKey 在全局对象上缓存 SharedArrayBuffer 构造函数的隐藏键
+var sharedKey = goja.NewSymbol("sw.SharedArrayBuffer")
+
+// sharedProgram SharedArrayBuffer 实现：与普通 ArrayBuffer 相同，但结构化克隆时共享底层内存
+var sharedProgram = goja.MustCompile("shared_array_buffer.js", `(class SharedArrayBuffer extends ArrayBuffer {
+	get [Symbol.toStringTag]() { return 'SharedArrayBuffer'; }
+})`, true)
+
+// typedArrayNames 支持克隆的 TypedArray 类型
+var typedArrayNames = []string{
+	"Uint8Array", "Int8Array", "Uint8ClampedArray",
+	"Uint16Array", "Int16Array", "Uint32Array", "Int32Array",
+	"Float32Array", "Float64Array", "BigInt64Array", "BigUint64Array",
+}
+
+// Data 结构化克隆后的数据，与 VM 无关，可以在不同 Runner 之间传递
+type Data struct {
+	root node
+}
+
+type node interface{}
+
+type undefinedNode struct{}
+
+type objectNode struct {
+	keys   []string
+	values []node
+}
+
+type arrayNode struct {
+	items []node
+}
+
+type dateNode struct {
+	msec float64
+}
+
+type regexpNode struct {
+	source string
+	flags  string
+}
+
+type mapNode struct {
+	keys   []node
+	values []node
+}
+
+type setNode struct {
+	items []node
+}
+
+type errorNode struct {
+	name    string
+	message string
+	stack   string
+}
+
+type boxNode struct {
+	value node
+}
+
+type bufferNode struct {
+	data   []byte
+	shared bool
+}
+
+type viewNode struct {
+	kind   string
+	buffer *bufferNode
+	offset int64
+	length int64
+}
+
+// SharedArrayBuffer 获取 VM 中的 SharedArrayBuffer 构造函数，首次调用时初始化
+func SharedArrayBuffer(vm *goja.Runtime) *goja.Object {
+	global := vm.GlobalObject()
+	if ctor, ok := global.GetSymbol(sharedKey).(*goja.Object); ok {
+		return ctor
+	}
+
+	ctor, err := vm.RunProgram(sharedProgram)
+	if err != nil {
+		panic(err)
+	}
+	ctorObj := ctor.ToObject(vm)
+	global.DefineDataPropertySymbol(sharedKey, ctorObj, goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_FALSE)
+	return ctorObj
+}
+
+// Serialize 对值进行结构化克隆序列化，遇到无法克隆的值（函数、Symbol 等）时抛出 DataCloneError
+func Serialize(vm *goja.Runtime, value goja.Value) *Data {
+	s := &serializer{
+		vm:   vm,
+		memo: make(map[*goja.Object]node),
+	}
+	return &Data{root: s.serialize(value)}
+}
+
+// Deserialize 在目标 VM 中重建克隆的值，同一个 Data 可以多次反序列化
+func (d *Data) Deserialize(vm *goja.Runtime) goja.Value {
+	ds := &deserializer{
+		vm:   vm,
+		memo: make(map[node]*goja.Object),
+	}
+	return ds.deserialize(d.root)
+}
+
+// StructuredClone 在同一个 VM 内深拷贝值
+func StructuredClone(vm *goja.Runtime, value goja.Value) goja.Value {
+	return Serialize(vm, value).Deserialize(vm)
+}
+
+// newDataCloneError 创建 DataCloneError 异常
+func newDataCloneError(vm *goja.Runtime, what string) *goja.Object {
+	err, _ := vm.New(vm.Get("Error"), vm.ToValue(what+" could not be cloned."))
+	err.Set("name", "DataCloneError")
+	return err
+}
+
+// serializer 结构化克隆序列化器
+type serializer struct {
+	vm   *goja.Runtime
+	memo map[*goja.Object]node
+}
+
+func (s *serializer) serialize(value goja.Value) node {
+	if value == nil || goja.IsUndefined(value) {
+		return undefinedNode{}
+	}
+	if goja.IsNull(value) {
+		return nil
+	}
+	if _, ok := value.(*goja.Symbol); ok {
+		panic(newDataCloneError(s.vm, value.String()))
+	}
+
+	obj, ok := value.(*goja.Object)
+	if !ok {
+		// 原始值：string、number、boolean、bigint
+		return value.Export()
+	}
+	if n, ok := s.memo[obj]; ok {
+		return n
+	}
+	if _, ok := goja.AssertFunction(obj); ok {
+		panic(newDataCloneError(s.vm, obj.String()))
+	}
+
+	switch obj.ClassName() {
+	case "Array":
+		n := &arrayNode{}
+		s.memo[obj] = n
+		length := obj.Get("length").ToInteger()
+		n.items = make([]node, length)
+		for i := int64(0); i < length; i++ {
+			n.items[i] = s.serialize(obj.Get(strconv.FormatInt(i, 10)))
+		}
+		return n
+	case "Date":
+		n := &dateNode{msec: obj.ToNumber().ToFloat()}
+		s.memo[obj] = n
+		return n
+	case "RegExp":
+		n := &regexpNode{source: obj.Get("source").String(), flags: obj.Get("flags").String()}
+		s.memo[obj] = n
+		return n
+	case "Error":
+		n := &errorNode{name: "Error"}
+		s.memo[obj] = n
+		if name := obj.Get("name"); name != nil && !goja.IsUndefined(name) {
+			n.name = name.String()
+		}
+		if message := obj.Get("message"); message != nil && !goja.IsUndefined(message) {
+			n.message = message.String()
+		}
+		if stack := obj.Get("stack"); stack != nil && !goja.IsUndefined(stack) {
+			n.stack = stack.String()
+		}
+		return n
+	case "Number", "Boolean", "String":
+		return s.serializeBox(obj)
+	}
+
+	switch {
+	case s.isView(obj):
+		return s.serializeView(obj)
+	case s.instanceOf(obj, "ArrayBuffer"):
+		data := obj.Export().(goja.ArrayBuffer).Bytes()
+		n := &bufferNode{data: data, shared: s.vm.InstanceOf(obj, SharedArrayBuffer(s.vm))}
+		if !n.shared {
+			n.data = append([]byte(nil), data...)
+		}
+		s.memo[obj] = n
+		return n
+	case s.instanceOf(obj, "BigInt"):
+		return s.serializeBox(obj)
+	case s.instanceOf(obj, "Map"):
+		n := &mapNode{}
+		s.memo[obj] = n
+		for _, entry := range s.entries(obj) {
+			pair := entry.ToObject(s.vm)
+			n.keys = append(n.keys, s.serialize(pair.Get("0")))
+			n.values = append(n.values, s.serialize(pair.Get("1")))
+		}
+		return n
+	case s.instanceOf(obj, "Set"):
+		n := &setNode{}
+		s.memo[obj] = n
+		for _, item := range s.entries(obj) {
+			n.items = append(n.items, s.serialize(item))
+		}
+		return n
+	case s.instanceOf(obj, "Promise"), s.instanceOf(obj, "WeakMap"), s.instanceOf(obj, "WeakSet"):
+		panic(newDataCloneError(s.vm, "#<"+s.constructorName(obj)+">"))
+	}
+
+	// 普通对象：仅复制自有可枚举字符串属性
+	n := &objectNode{}
+	s.memo[obj] = n
+	for _, key := range obj.Keys() {
+		n.keys = append(n.keys, key)
+		n.values = append(n.values, s.serialize(obj.Get(key)))
+	}
+	return n
+}
+
+// serializeBox 序列化包装对象（new Number、new String、Object(1n) 等）
+func (s *serializer) serializeBox(obj *goja.Object) node {
+	n := &boxNode{}
+	s.memo[obj] = n
+	valueOf, ok := goja.AssertFunction(obj.Get("valueOf"))
+	if !ok {
+		panic(newDataCloneError(s.vm, obj.String()))
+	}
+	primitive, err := valueOf(obj)
+	if err != nil {
+		panic(err)
+	}
+	n.value = primitive.Export()
+	return n
+}
+
+// isView 检查对象是否为 TypedArray 或 DataView
+func (s *serializer) isView(obj *goja.Object) bool {
+	isView, ok := goja.AssertFunction(s.vm.Get("ArrayBuffer").ToObject(s.vm).Get("isView"))
+	if !ok {
+		return false
+	}
+	result, err := isView(goja.Undefined(), obj)
+	return err == nil && result.ToBoolean()
+}
+
+// serializeView 序列化 TypedArray 或 DataView，底层 ArrayBuffer 参与引用去重
+func (s *serializer) serializeView(obj *goja.Object) node {
+	kind := "DataView"
+	for _, name := range typedArrayNames {
+		if s.instanceOf(obj, name) {
+			kind = name
+			break
+		}
+	}
+
+	n := &viewNode{kind: kind, offset: obj.Get("byteOffset").ToInteger()}
+	s.memo[obj] = n
+	if kind == "DataView" {
+		n.length = obj.Get("byteLength").ToInteger()
+	} else {
+		n.length = obj.Get("length").ToInteger()
+	}
+	if buf, ok := s.serialize(obj.Get("buffer")).(*bufferNode); ok {
+		n.buffer = buf
+	}
+	return n
+}
+
+// instanceOf 检查对象是否为指定全局构造函数的实例
+func (s *serializer) instanceOf(obj *goja.Object, name string) bool {
+	ctor, ok := s.vm.Get(name).(*goja.Object)
+	return ok && s.vm.InstanceOf(obj, ctor)
+}
+
+// constructorName 获取对象构造函数名称，用于错误信息
+func (s *serializer) constructorName(obj *goja.Object) string {
+	if ctor, ok := obj.Get("constructor").(*goja.Object); ok {
+		if name := ctor.Get("name"); name != nil {
+			return name.String()
+		}
+	}
+	return obj.ClassName()
+}
+
+// entries 通过 Array.from 展开 Map/Set 的迭代结果
+func (s *serializer) entries(obj *goja.Object) []goja.Value {
+	from, _ := goja.AssertFunction(s.vm.Get("Array").ToObject(s.vm).Get("from"))
+	result, err := from(goja.Undefined(), obj)
+	if err != nil {
+		panic(err)
+	}
+	arr := result.ToObject(s.vm)
+	length := arr.Get("length").ToInteger()
+	items := make([]goja.Value, length)
+	for i := int64(0); i < length; i++ {
+		items[i] = arr.Get(strconv.FormatInt(i, 10))
+	}
+	return items
+}
+
+// deserializer 结构化克隆反序列化器
+type deserializer struct {
+	vm   *goja.Runtime
+	memo map[node]*goja.Object
+}
+
+func (d *deserializer) deserialize(n node) goja.Value {
+	switch v := n.(type) {
+	case nil:
+		return goja.Null()
+	case undefinedNode:
+		return goja.Undefined()
+	case string, bool, int64, float64, *big.Int:
+		return d.vm.ToValue(v)
+	}
+
+	if obj, ok := d.memo[n]; ok {
+		return obj
+	}
+
+	switch v := n.(type) {
+	case *objectNode:
+		obj := d.vm.NewObject()
+		d.memo[n] = obj
+		for i, key := range v.keys {
+			obj.Set(key, d.deserialize(v.values[i]))
+		}
+		return obj
+	case *arrayNode:
+		obj := d.vm.NewArray()
+		d.memo[n] = obj
+		for i, item := range v.items {
+			obj.Set(strconv.Itoa(i), d.deserialize(item))
+		}
+		return obj
+	case *dateNode:
+		obj := d.construct("Date", d.vm.ToValue(v.msec))
+		d.memo[n] = obj
+		return obj
+	case *regexpNode:
+		obj := d.construct("RegExp", d.vm.ToValue(v.source), d.vm.ToValue(v.flags))
+		d.memo[n] = obj
+		return obj
+	case *errorNode:
+		ctorName := v.name
+		switch ctorName {
+		case "EvalError", "RangeError", "ReferenceError", "SyntaxError", "TypeError", "URIError":
+		default:
+			ctorName = "Error"
+		}
+		obj := d.construct(ctorName, d.vm.ToValue(v.message))
+		if ctorName != v.name {
+			obj.Set("name", v.name)
+		}
+		if v.stack != "" {
+			obj.Set("stack", v.stack)
+		}
+		d.memo[n] = obj
+		return obj
+	case *boxNode:
+		obj := d.vm.ToValue(v.value).ToObject(d.vm)
+		d.memo[n] = obj
+		return obj
+	case *mapNode:
+		obj := d.construct("Map")
+		d.memo[n] = obj
+		set, _ := goja.AssertFunction(obj.Get("set"))
+		for i := range v.keys {
+			if _, err := set(obj, d.deserialize(v.keys[i]), d.deserialize(v.values[i])); err != nil {
+				panic(err)
+			}
+		}
+		return obj
+	case *setNode:
+		obj := d.construct("Set")
+		d.memo[n] = obj
+		add, _ := goja.AssertFunction(obj.Get("add"))
+		for _, item := range v.items {
+			if _, err := add(obj, d.deserialize(item)); err != nil {
+				panic(err)
+			}
+		}
+		return obj
+	case *bufferNode:
+		obj := d.vm.ToValue(d.vm.NewArrayBuffer(v.data)).ToObject(d.vm)
+		if v.shared {
+			obj.SetPrototype(SharedArrayBuffer(d.vm).Get("prototype").ToObject(d.vm))
+		}
+		d.memo[n] = obj
+		return obj
+	case *viewNode:
+		var buffer goja.Value = d.vm.ToValue(d.vm.NewArrayBuffer(nil))
+		if v.buffer != nil {
+			buffer = d.deserialize(v.buffer)
+		}
+		obj := d.construct(v.kind, buffer, d.vm.ToValue(v.offset), d.vm.ToValue(v.length))
+		d.memo[n] = obj
+		return obj
+	}
+	return goja.Undefined()
+}
+
+// construct 调用全局构造函数创建对象
+func (d *deserializer) construct(name string, args ...goja.Value) *goja.Object {
+	obj, err := d.vm.New(d.vm.Get(name), args...)
+	if err != nil {
+		panic(err)
+	}
+	return obj
+}
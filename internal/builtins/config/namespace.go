@@ -1,7 +1,8 @@
 package config
 
 import (
-	"sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/security"
 
 	"github.com/dop251/goja"
 )
@@ -13,10 +14,10 @@ type Namespace struct {
 }
 
 // NewNamespace 创建 config 命名空间
-func NewNamespace(vm *goja.Runtime) *Namespace {
+func NewNamespace(vm *goja.Runtime, guard *security.Guard) *Namespace {
 	return &Namespace{
 		vm:    vm,
-		viper: NewViperModule(vm),
+		viper: NewViperModule(vm, guard),
 	}
 }
 
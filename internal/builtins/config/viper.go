@@ -5,22 +5,34 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/security"
+
 	"github.com/dop251/goja"
 	"github.com/spf13/viper"
 )
 
 // ViperModule viper 配置模块
 type ViperModule struct {
-	vm   *goja.Runtime
-	mu   sync.RWMutex
-	vips map[string]*viper.Viper // 支持多个 viper 实例
+	vm    *goja.Runtime
+	mu    sync.RWMutex
+	vips  map[string]*viper.Viper // 支持多个 viper 实例
+	guard *security.Guard
 }
 
 // NewViperModule 创建 viper 配置模块
-func NewViperModule(vm *goja.Runtime) *ViperModule {
+func NewViperModule(vm *goja.Runtime, guard *security.Guard) *ViperModule {
 	return &ViperModule{
-		vm:   vm,
-		vips: make(map[string]*viper.Viper),
+		vm:    vm,
+		vips:  make(map[string]*viper.Viper),
+		guard: guard,
+	}
+}
+
+// check 权限检查失败时抛出 PermissionDenied
+func (v *ViperModule) check(err error) {
+	if err != nil {
+		panic(types.NewPermissionError(v.vm, err))
 	}
 }
 
@@ -60,13 +72,19 @@ func (v *ViperModule) createViperObject(vp *viper.Viper, name string) goja.Value
 	// 设置实例名
 	obj.Set("name", name)
 
+	// 记录配置文件位置和环境变量前缀，用于权限检查
+	var configFile, envPrefix string
+	var configPaths []string
+	envReplacer := strings.NewReplacer()
+
 	// === 文件配置 ===
 	// SetConfigFile 设置配置文件路径
 	obj.Set("setConfigFile", func(call goja.FunctionCall) goja.Value {
 		if len(call.Arguments) < 1 {
 			panic(v.vm.NewTypeError("setConfigFile requires configFile argument"))
 		}
-		configFile := call.Arguments[0].String()
+		configFile = call.Arguments[0].String()
+		v.check(v.guard.CheckRead(configFile))
 		vp.SetConfigFile(configFile)
 		return goja.Undefined()
 	})
@@ -87,6 +105,8 @@ func (v *ViperModule) createViperObject(vp *viper.Viper, name string) goja.Value
 			panic(v.vm.NewTypeError("addConfigPath requires path argument"))
 		}
 		path := call.Arguments[0].String()
+		v.check(v.guard.CheckRead(path))
+		configPaths = append(configPaths, path)
 		vp.AddConfigPath(path)
 		return goja.Undefined()
 	})
@@ -113,6 +133,12 @@ func (v *ViperModule) createViperObject(vp *viper.Viper, name string) goja.Value
 
 	// SafeWriteConfig 安全写入配置
 	obj.Set("safeWriteConfig", func(call goja.FunctionCall) goja.Value {
+		// 与 viper 一致：优先写入配置文件，否则写入第一个搜索路径
+		if configFile != "" {
+			v.check(v.guard.CheckWrite(configFile))
+		} else if len(configPaths) > 0 {
+			v.check(v.guard.CheckWrite(configPaths[0]))
+		}
 		err := vp.SafeWriteConfig()
 		if err != nil {
 			panic(v.vm.NewGoError(err))
@@ -265,6 +291,18 @@ func (v *ViperModule) createViperObject(vp *viper.Viper, name string) goja.Value
 		for i, arg := range call.Arguments {
 			args[i] = arg.String()
 		}
+		// 未指定环境变量名时，viper 根据前缀和键名推导
+		envNames := args[1:]
+		if len(envNames) == 0 {
+			envName := strings.ToUpper(args[0])
+			if envPrefix != "" {
+				envName = strings.ToUpper(envPrefix + "_" + args[0])
+			}
+			envNames = []string{envReplacer.Replace(envName)}
+		}
+		for _, envName := range envNames {
+			v.check(v.guard.CheckEnv(envName))
+		}
 		vp.BindEnv(args...)
 		return goja.Undefined()
 	})
@@ -275,6 +313,7 @@ func (v *ViperModule) createViperObject(vp *viper.Viper, name string) goja.Value
 			panic(v.vm.NewTypeError("setEnvPrefix requires prefix argument"))
 		}
 		prefix := call.Arguments[0].String()
+		envPrefix = prefix
 		vp.SetEnvPrefix(prefix)
 		return goja.Undefined()
 	})
@@ -296,7 +335,8 @@ func (v *ViperModule) createViperObject(vp *viper.Viper, name string) goja.Value
 		}
 		oldStr := call.Arguments[0].String()
 		newStr := call.Arguments[1].String()
-		vp.SetEnvKeyReplacer(strings.NewReplacer(oldStr, newStr))
+		envReplacer = strings.NewReplacer(oldStr, newStr)
+		vp.SetEnvKeyReplacer(envReplacer)
 		return goja.Undefined()
 	})
 
@@ -1,7 +1,8 @@
 package db
 
 import (
-	"sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/security"
 
 	"github.com/dop251/goja"
 )
@@ -12,11 +13,11 @@ type Namespace struct {
 	sqlite *SQLiteModule
 }
 
-func NewNamespace(vm *goja.Runtime) *Namespace {
+func NewNamespace(vm *goja.Runtime, guard *security.Guard) *Namespace {
 	return &Namespace{
 		vm:     vm,
-		redis:  NewRedisModule(vm),
-		sqlite: NewSQLiteModule(vm),
+		redis:  NewRedisModule(vm, guard),
+		sqlite: NewSQLiteModule(vm, guard),
 	}
 }
 
@@ -32,6 +33,12 @@ func (h *Namespace) GetModule() *goja.Object {
 	return obj
 }
 
+// Reset 关闭 Redis 客户端和 SQLite 数据库
+func (h *Namespace) Reset() {
+	h.redis.Reset()
+	h.sqlite.Reset()
+}
+
 func (h *Namespace) GetSubModule(name string) (types.BuiltinModule, bool) {
 	switch name {
 	case "redis":
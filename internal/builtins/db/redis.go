@@ -5,8 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/security"
+
 	"github.com/dop251/goja"
 	"github.com/go-redis/redis/v8"
 )
@@ -15,13 +19,16 @@ import (
 type RedisModule struct {
 	vm      *goja.Runtime
 	clients map[string]*redis.Client
+	mu      sync.Mutex
+	guard   *security.Guard
 }
 
 // NewRedisModule 创建 Redis 模块
-func NewRedisModule(vm *goja.Runtime) *RedisModule {
+func NewRedisModule(vm *goja.Runtime, guard *security.Guard) *RedisModule {
 	return &RedisModule{
 		vm:      vm,
 		clients: make(map[string]*redis.Client),
+		guard:   guard,
 	}
 }
 
@@ -81,6 +88,9 @@ func (r *RedisModule) parseRedisConfig(args []goja.Value) *RedisConfig {
 // createClient 创建 Redis 客户端
 func (r *RedisModule) createClient(call goja.FunctionCall) goja.Value {
 	config := r.parseRedisConfig(call.Arguments)
+	if err := r.guard.CheckNet(config.Host, strconv.Itoa(config.Port)); err != nil {
+		return types.RejectPermission(r.vm, err)
+	}
 
 	client := redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%d", config.Host, config.Port),
@@ -99,7 +109,9 @@ func (r *RedisModule) createClient(call goja.FunctionCall) goja.Value {
 			reject(r.vm.NewGoError(fmt.Errorf("failed to connect to Redis: %w", err)))
 		} else {
 			// 存储客户端
+			r.mu.Lock()
 			r.clients[config.Name] = client
+			r.mu.Unlock()
 			// 创建客户端对象
 			clientObj := r.createClientObject(client)
 			resolve(clientObj)
@@ -109,6 +121,16 @@ func (r *RedisModule) createClient(call goja.FunctionCall) goja.Value {
 	return r.vm.ToValue(promise)
 }
 
+// Reset 关闭所有 Redis 客户端
+func (r *RedisModule) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, client := range r.clients {
+		client.Close()
+		delete(r.clients, name)
+	}
+}
+
 // connect 连接到 Redis（别名）
 func (r *RedisModule) connect(call goja.FunctionCall) goja.Value {
 	return r.createClient(call)
@@ -6,6 +6,10 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/security"
 
 	"github.com/dop251/goja"
 	_ "modernc.org/sqlite"
@@ -15,13 +19,16 @@ import (
 type SQLiteModule struct {
 	vm        *goja.Runtime
 	databases map[string]*sql.DB
+	mu        sync.Mutex
+	guard     *security.Guard
 }
 
 // NewSQLiteModule 创建 SQLite 模块
-func NewSQLiteModule(vm *goja.Runtime) *SQLiteModule {
+func NewSQLiteModule(vm *goja.Runtime, guard *security.Guard) *SQLiteModule {
 	return &SQLiteModule{
 		vm:        vm,
 		databases: make(map[string]*sql.DB),
+		guard:     guard,
 	}
 }
 
@@ -92,6 +99,16 @@ func (s *SQLiteModule) open(call goja.FunctionCall) goja.Value {
 	if config.Database == ":memory:" {
 		dsn = ":memory:"
 	} else {
+		// 数据库文件需要读权限，非只读模式还需要写权限
+		if err := s.guard.CheckRead(config.Database); err != nil {
+			return types.RejectPermission(s.vm, err)
+		}
+		if config.Mode != "ro" {
+			if err := s.guard.CheckWrite(config.Database); err != nil {
+				return types.RejectPermission(s.vm, err)
+			}
+		}
+
 		// 确保目录存在
 		if dir := filepath.Dir(config.Database); dir != "." {
 			os.MkdirAll(dir, 0755)
@@ -129,7 +146,9 @@ func (s *SQLiteModule) open(call goja.FunctionCall) goja.Value {
 		}
 
 		// 存储数据库连接
+		s.mu.Lock()
 		s.databases[config.Name] = db
+		s.mu.Unlock()
 
 		// 创建数据库对象
 		dbObj := s.createDatabaseObject(db, config.Database)
@@ -139,6 +158,16 @@ func (s *SQLiteModule) open(call goja.FunctionCall) goja.Value {
 	return s.vm.ToValue(promise)
 }
 
+// Reset 关闭所有打开的数据库连接
+func (s *SQLiteModule) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, db := range s.databases {
+		db.Close()
+		delete(s.databases, name)
+	}
+}
+
 // version 获取 SQLite 版本
 func (s *SQLiteModule) version(call goja.FunctionCall) goja.Value {
 	promise, resolve, reject := s.vm.NewPromise()
@@ -0,0 +1,428 @@
+package events
+
+import (
+	"fmt"
+
+	"github.com/dop251/goja"
+)
+
+// constructorKey 在全局对象上缓存 EventEmitter 构造函数的隐藏键，
+// 保证同一个 VM 中脚本和内置模块创建的事件对象共享同一原型
+var constructorKey = goja.NewSymbol("sw.EventEmitter")
+
+// watchKey emitter 上保存 Watch 回调的隐藏键，脚本无法访问
+var watchKey = goja.NewSymbol("sw.events.watch")
+
+// boundMethods Bind 添加到目标对象上的 EventEmitter 方法
+var boundMethods = []string{
+	"on", "addListener", "prependListener", "once", "prependOnceListener",
+	"off", "removeListener", "removeAllListeners", "emit",
+	"listeners", "rawListeners", "listenerCount", "eventNames", "setMaxListeners", "getMaxListeners",
+}
+
+// eventsProgram EventEmitter 实现（预编译，可在多个 VM 间复用）
+var eventsProgram = goja.MustCompile("events.js", eventsSource, true)
+
+// EventsModule events 模块
+type EventsModule struct {
+	vm *goja.Runtime
+}
+
+// NewEventsModule 创建 events 模块
+func NewEventsModule(vm *goja.Runtime) *EventsModule {
+	return &EventsModule{vm: vm}
+}
+
+// GetModule 获取 events 模块对象，与 Node.js 一致即 EventEmitter 构造函数本身
+func (e *EventsModule) GetModule() *goja.Object {
+	return Constructor(e.vm)
+}
+
+// Constructor 获取 VM 中的 EventEmitter 构造函数，首次调用时初始化
+func Constructor(vm *goja.Runtime) *goja.Object {
+	global := vm.GlobalObject()
+	if ctor, ok := global.GetSymbol(constructorKey).(*goja.Object); ok {
+		return ctor
+	}
+
+	factory, err := vm.RunProgram(eventsProgram)
+	if err != nil {
+		panic(err)
+	}
+	fn, ok := goja.AssertFunction(factory)
+	if !ok {
+		panic(vm.NewTypeError("invalid EventEmitter factory"))
+	}
+	ctor, err := fn(goja.Undefined(), watchKey)
+	if err != nil {
+		panic(err)
+	}
+
+	ctorObj := ctor.ToObject(vm)
+	global.DefineDataPropertySymbol(constructorKey, ctorObj, goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_FALSE)
+	return ctorObj
+}
+
+// New 创建 EventEmitter 实例，内置模块在其上添加方法后作为连接、服务器等对象返回给脚本
+func New(vm *goja.Runtime) *goja.Object {
+	obj, err := vm.New(Constructor(vm))
+	if err != nil {
+		panic(err)
+	}
+	return obj
+}
+
+// Bind 在 target 上添加作用于 emitter 的方法，names 为空时添加全部 EventEmitter 方法。
+// 返回 emitter 本身的方法（on、off 等）改为返回 target，用于让多个对象或全局对象共享同一组监听器
+func Bind(vm *goja.Runtime, target, emitter *goja.Object, names ...string) {
+	if len(names) == 0 {
+		names = boundMethods
+	}
+	for _, name := range names {
+		method, ok := goja.AssertFunction(emitter.Get(name))
+		if !ok {
+			continue
+		}
+		target.Set(name, func(call goja.FunctionCall) goja.Value {
+			result, err := method(emitter, call.Arguments...)
+			if err != nil {
+				panic(err)
+			}
+			if result.SameAs(emitter) {
+				return call.This
+			}
+			return result
+		})
+	}
+}
+
+// Watch 在 emitter 的监听器数量变化后以事件名和新的数量调用 fn，fn 在 VM 线程中调用。
+// 用于在其他 goroutine 中判断是否有监听器，或按监听器保持事件循环存活
+func Watch(vm *goja.Runtime, emitter *goja.Object, fn func(name string, count int)) {
+	watch := vm.ToValue(func(call goja.FunctionCall) goja.Value {
+		fn(call.Argument(0).String(), int(call.Argument(1).ToInteger()))
+		return goja.Undefined()
+	})
+	emitter.DefineDataPropertySymbol(watchKey, watch, goja.FLAG_TRUE, goja.FLAG_FALSE, goja.FLAG_FALSE)
+}
+
+// Emit 调用 emitter.emit 触发事件。监听器抛出异常，或 error 事件没有监听器时返回错误
+func Emit(vm *goja.Runtime, emitter *goja.Object, name string, args ...goja.Value) error {
+	emit, ok := goja.AssertFunction(emitter.Get("emit"))
+	if !ok {
+		return fmt.Errorf("emitter has no emit method")
+	}
+	_, err := emit(emitter, append([]goja.Value{vm.ToValue(name)}, args...)...)
+	return err
+}
+
+// ListenerCount 返回 emitter 上事件的监听器数量
+func ListenerCount(vm *goja.Runtime, emitter *goja.Object, name string) int {
+	count, ok := goja.AssertFunction(emitter.Get("listenerCount"))
+	if !ok {
+		return 0
+	}
+	n, err := count(emitter, vm.ToValue(name))
+	if err != nil {
+		return 0
+	}
+	return int(n.ToInteger())
+}
+
+// eventsSource Node.js 兼容的 EventEmitter 实现，参数 kWatch 为 Watch 使用的隐藏键
+const eventsSource = `(function (kWatch) {
+	'use strict';
+
+	const kErrorMonitor = Symbol('events.errorMonitor');
+	let defaultMaxListeners = 10;
+
+	function checkListener(listener) {
+		if (typeof listener !== 'function') {
+			throw new TypeError('The "listener" argument must be of type function. Received ' + (listener === null ? 'null' : typeof listener));
+		}
+	}
+
+	function hidden(obj, key, value) {
+		Object.defineProperty(obj, key, { value: value, writable: true, configurable: true, enumerable: false });
+	}
+
+	// 监听器表，通过 Object.assign 混入原型或未调用构造函数的对象在首次使用时创建
+	function eventsOf(emitter) {
+		if (!Object.prototype.hasOwnProperty.call(emitter, '_events') || !emitter._events) {
+			hidden(emitter, '_events', Object.create(null));
+			hidden(emitter, '_eventsCount', 0);
+		}
+		return emitter._events;
+	}
+
+	// 通知宿主监听器数量变化
+	function notify(target, type, count) {
+		const watch = target[kWatch];
+		if (watch !== undefined) watch(type, count);
+	}
+
+	function describe(value) {
+		if (typeof value === 'string') return "'" + value + "'";
+		if (typeof value === 'object' && value !== null) {
+			try {
+				return JSON.stringify(value);
+			} catch (_) {
+				// 循环引用等无法序列化的对象
+			}
+		}
+		return String(value);
+	}
+
+	function addListener(target, type, listener, prepend) {
+		checkListener(listener);
+		const events = eventsOf(target);
+		if (events.newListener !== undefined) {
+			target.emit('newListener', type, listener.listener ? listener.listener : listener);
+		}
+
+		let list = events[type];
+		if (list === undefined) {
+			list = events[type] = [listener];
+			target._eventsCount++;
+		} else if (prepend) {
+			list.unshift(listener);
+		} else {
+			list.push(listener);
+		}
+		notify(target, type, list.length);
+
+		const max = target.getMaxListeners();
+		if (max > 0 && list.length > max && !list.warned) {
+			list.warned = true;
+			console.warn('MaxListenersExceededWarning: Possible EventEmitter memory leak detected. ' +
+				list.length + ' ' + String(type) + ' listeners added. Use emitter.setMaxListeners() to increase limit');
+		}
+		return target;
+	}
+
+	function onceWrapper(target, type, listener) {
+		let fired = false;
+		function wrapped() {
+			if (fired) return undefined;
+			fired = true;
+			target.removeListener(type, wrapped);
+			return listener.apply(target, arguments);
+		}
+		wrapped.listener = listener;
+		return wrapped;
+	}
+
+	function EventEmitter(opts) {
+		EventEmitter.init.call(this, opts);
+	}
+
+	EventEmitter.init = function () {
+		eventsOf(this);
+	};
+
+	EventEmitter.prototype.addListener = function (type, listener) {
+		return addListener(this, type, listener, false);
+	};
+	EventEmitter.prototype.on = EventEmitter.prototype.addListener;
+
+	EventEmitter.prototype.prependListener = function (type, listener) {
+		return addListener(this, type, listener, true);
+	};
+
+	EventEmitter.prototype.once = function (type, listener) {
+		checkListener(listener);
+		return addListener(this, type, onceWrapper(this, type, listener), false);
+	};
+
+	EventEmitter.prototype.prependOnceListener = function (type, listener) {
+		checkListener(listener);
+		return addListener(this, type, onceWrapper(this, type, listener), true);
+	};
+
+	// 移除最近添加的一个匹配监听器（包括通过 once 添加的）
+	EventEmitter.prototype.removeListener = function (type, listener) {
+		checkListener(listener);
+		const events = eventsOf(this);
+		const list = events[type];
+		if (list === undefined) return this;
+
+		for (let i = list.length - 1; i >= 0; i--) {
+			if (list[i] === listener || list[i].listener === listener) {
+				const removed = list[i].listener || list[i];
+				list.splice(i, 1);
+				if (list.length === 0) {
+					delete events[type];
+					this._eventsCount--;
+				}
+				notify(this, type, list.length);
+				if (events.removeListener !== undefined) {
+					this.emit('removeListener', type, removed);
+				}
+				break;
+			}
+		}
+		return this;
+	};
+	EventEmitter.prototype.off = EventEmitter.prototype.removeListener;
+
+	EventEmitter.prototype.removeAllListeners = function (type) {
+		const events = eventsOf(this);
+		if (events.removeListener === undefined) {
+			if (arguments.length === 0) {
+				hidden(this, '_events', Object.create(null));
+				this._eventsCount = 0;
+				for (const key of Reflect.ownKeys(events)) notify(this, key, 0);
+			} else if (events[type] !== undefined) {
+				delete events[type];
+				this._eventsCount--;
+				notify(this, type, 0);
+			}
+			return this;
+		}
+
+		// 有 removeListener 监听器时逐个移除，removeListener 本身最后移除
+		if (arguments.length === 0) {
+			for (const key of Reflect.ownKeys(events)) {
+				if (key !== 'removeListener') this.removeAllListeners(key);
+			}
+			this.removeAllListeners('removeListener');
+			return this;
+		}
+		const list = events[type];
+		if (list !== undefined) {
+			for (let i = list.length - 1; i >= 0; i--) {
+				this.removeListener(type, list[i]);
+			}
+		}
+		return this;
+	};
+
+	// 依次同步调用监听器，error 事件没有监听器时抛出错误
+	EventEmitter.prototype.emit = function (type) {
+		const args = Array.prototype.slice.call(arguments, 1);
+		const events = eventsOf(this);
+		if (type === 'error' && events[kErrorMonitor] !== undefined) {
+			this.emit.apply(this, [kErrorMonitor].concat(args));
+		}
+
+		const list = events[type];
+		if (list === undefined) {
+			if (type === 'error') {
+				const err = args[0];
+				if (err instanceof Error) throw err;
+				const unhandled = new Error('Unhandled error. (' + describe(err) + ')');
+				unhandled.code = 'ERR_UNHANDLED_ERROR';
+				unhandled.context = err;
+				throw unhandled;
+			}
+			return false;
+		}
+
+		const handlers = list.slice();
+		for (let i = 0; i < handlers.length; i++) {
+			handlers[i].apply(this, args);
+		}
+		return true;
+	};
+
+	EventEmitter.prototype.listeners = function (type) {
+		const list = eventsOf(this)[type];
+		return list === undefined ? [] : list.map((l) => l.listener || l);
+	};
+
+	EventEmitter.prototype.rawListeners = function (type) {
+		const list = eventsOf(this)[type];
+		return list === undefined ? [] : list.slice();
+	};
+
+	EventEmitter.prototype.listenerCount = function (type, listener) {
+		const list = eventsOf(this)[type];
+		if (list === undefined) return 0;
+		if (listener === undefined) return list.length;
+		return list.filter((l) => l === listener || l.listener === listener).length;
+	};
+
+	EventEmitter.prototype.eventNames = function () {
+		return Reflect.ownKeys(eventsOf(this));
+	};
+
+	EventEmitter.prototype.setMaxListeners = function (n) {
+		if (typeof n !== 'number' || n < 0 || n !== n) {
+			throw new RangeError('The value of "n" is out of range. It must be a non-negative number. Received ' + n);
+		}
+		hidden(this, '_maxListeners', n);
+		return this;
+	};
+
+	EventEmitter.prototype.getMaxListeners = function () {
+		return this._maxListeners === undefined ? defaultMaxListeners : this._maxListeners;
+	};
+
+	function abortError(signal) {
+		const err = new Error('The operation was aborted');
+		err.name = 'AbortError';
+		err.code = 'ABORT_ERR';
+		err.cause = signal.reason;
+		return err;
+	}
+
+	// once 等待事件触发一次，返回以参数数组 resolve 的 Promise；
+	// 等待期间 emitter 触发 error 事件时 reject。同时支持 EventTarget
+	function once(emitter, name, options) {
+		const signal = options ? options.signal : undefined;
+		const isTarget = typeof emitter.on !== 'function' && typeof emitter.addEventListener === 'function';
+		const add = isTarget ? (n, fn) => emitter.addEventListener(n, fn) : (n, fn) => emitter.on(n, fn);
+		const remove = isTarget ? (n, fn) => emitter.removeEventListener(n, fn) : (n, fn) => emitter.removeListener(n, fn);
+
+		return new Promise((resolve, reject) => {
+			if (signal && signal.aborted) {
+				reject(abortError(signal));
+				return;
+			}
+
+			const watchError = !isTarget && name !== 'error';
+			const resolver = function () {
+				cleanup();
+				resolve(Array.prototype.slice.call(arguments));
+			};
+			const errorListener = (err) => {
+				cleanup();
+				reject(err);
+			};
+			const abortListener = () => {
+				cleanup();
+				reject(abortError(signal));
+			};
+			function cleanup() {
+				remove(name, resolver);
+				if (watchError) remove('error', errorListener);
+				if (signal) signal.removeEventListener('abort', abortListener);
+			}
+
+			add(name, resolver);
+			if (watchError) add('error', errorListener);
+			if (signal) signal.addEventListener('abort', abortListener);
+		});
+	}
+
+	EventEmitter.EventEmitter = EventEmitter;
+	EventEmitter.errorMonitor = kErrorMonitor;
+	EventEmitter.once = once;
+	EventEmitter.listenerCount = (emitter, type) => emitter.listenerCount(type);
+	EventEmitter.getEventListeners = (emitter, type) => emitter.listeners(type);
+	Object.defineProperty(EventEmitter, 'defaultMaxListeners', {
+		enumerable: true,
+		get() {
+			return defaultMaxListeners;
+		},
+		set(n) {
+			if (typeof n !== 'number' || n < 0 || n !== n) {
+				throw new RangeError('The value of "defaultMaxListeners" is out of range. It must be a non-negative number. Received ' + n);
+			}
+			defaultMaxListeners = n;
+		},
+	});
+
+	return EventEmitter;
+})
+`
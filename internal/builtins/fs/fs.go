@@ -1,6 +1,8 @@
 package fs
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -8,8 +10,10 @@ import (
 
 	"github.com/dop251/goja"
 
-	"sw_runtime/internal/consts"
-	"sw_runtime/internal/security"
+	"github.com/issueye/sw_runtime/internal/builtins/buffer"
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/consts"
+	"github.com/issueye/sw_runtime/internal/security"
 )
 
 // FSModule 文件系统模块
@@ -17,10 +21,11 @@ type FSModule struct {
 	vm        *goja.Runtime
 	basePath  string
 	validator *security.PathValidator
+	guard     *security.Guard
 }
 
 // NewFSModule 创建文件系统模块
-func NewFSModule(vm *goja.Runtime, basePath string) *FSModule {
+func NewFSModule(vm *goja.Runtime, basePath string, guard *security.Guard) *FSModule {
 	// 使用传入的基础路径，如果为空则使用当前工作目录
 	if basePath == "" {
 		var err error
@@ -34,6 +39,7 @@ func NewFSModule(vm *goja.Runtime, basePath string) *FSModule {
 		vm:        vm,
 		basePath:  basePath,
 		validator: security.NewPathValidator(basePath),
+		guard:     guard,
 	}
 }
 
@@ -67,9 +73,30 @@ func (f *FSModule) sanitizePath(path string) (string, error) {
 	return f.validator.Validate(cleanPath)
 }
 
-// validatePath 验证路径并返回绝对路径
-func (f *FSModule) validatePath(path string) (string, error) {
-	return f.sanitizePath(path)
+// validatePath 验证路径在沙箱内且具有 perm 权限，返回绝对路径
+func (f *FSModule) validatePath(path string, perm security.Permission) (string, error) {
+	safePath, err := f.sanitizePath(path)
+	if err != nil {
+		return "", err
+	}
+	if perm == security.PermWrite {
+		err = f.guard.CheckWrite(safePath)
+	} else {
+		err = f.guard.CheckRead(safePath)
+	}
+	if err != nil {
+		return "", err
+	}
+	return safePath, nil
+}
+
+// accessError 将路径验证错误转换为 JS 错误，权限不足时为 PermissionDenied
+func (f *FSModule) accessError(err error) *goja.Object {
+	var denied *security.PermissionDeniedError
+	if errors.As(err, &denied) {
+		return types.NewPermissionError(f.vm, err)
+	}
+	return f.vm.NewGoError(fmt.Errorf("access denied: %w", err))
 }
 
 // GetModule 获取文件系统模块对象
@@ -100,6 +127,10 @@ func (f *FSModule) GetModule() *goja.Object {
 	obj.Set("copyFile", f.copyFile)
 	obj.Set("rename", f.rename)
 
+	// 流式读写，适合处理大文件
+	obj.Set("createReadStream", f.createReadStream)
+	obj.Set("createWriteStream", f.createWriteStream)
+
 	return obj
 }
 
@@ -110,9 +141,9 @@ func (f *FSModule) readFileSync(call goja.FunctionCall) goja.Value {
 	}
 
 	filename := call.Arguments[0].String()
-	safePath, err := f.validatePath(filename)
+	safePath, err := f.validatePath(filename, security.PermRead)
 	if err != nil {
-		panic(f.vm.NewGoError(fmt.Errorf("access denied: %w", err)))
+		panic(f.accessError(err))
 	}
 
 	content, err := os.ReadFile(safePath)
@@ -120,25 +151,21 @@ func (f *FSModule) readFileSync(call goja.FunctionCall) goja.Value {
 		panic(f.vm.NewGoError(err))
 	}
 
-	// 检查编码选项
-	if len(call.Arguments) > 1 {
-		options := call.Arguments[1]
-		if options.ExportType().Kind().String() == "string" {
-			encoding := options.String()
-			if encoding == "utf8" || encoding == "utf-8" {
-				return f.vm.ToValue(string(content))
-			}
-		} else if obj := options.ToObject(f.vm); obj != nil {
-			if enc := obj.Get("encoding"); enc != nil && enc != goja.Undefined() {
-				encoding := enc.String()
-				if encoding == "utf8" || encoding == "utf-8" {
-					return f.vm.ToValue(string(content))
-				}
-			}
-		}
-	}
+	// 未指定编码时返回 Buffer，否则按编码返回字符串
+	return f.encodeContent(content, call.Argument(1))
+}
+
+// encodeContent 按 encoding 选项返回文件内容
+func (f *FSModule) encodeContent(content []byte, options goja.Value) goja.Value {
+	return buffer.Encode(f.vm, content, buffer.Encoding(f.vm, options, "buffer"))
+}
 
-	return f.vm.ToValue(string(content))
+// decodeContent 将写入数据（Buffer、TypedArray 或字符串）转换为字节
+func (f *FSModule) decodeContent(data goja.Value, options goja.Value) []byte {
+	if content, ok := buffer.Bytes(f.vm, data); ok {
+		return content
+	}
+	return buffer.DecodeString(data.String(), buffer.Encoding(f.vm, options, "utf8"))
 }
 
 // writeFileSync 同步写入文件
@@ -148,14 +175,14 @@ func (f *FSModule) writeFileSync(call goja.FunctionCall) goja.Value {
 	}
 
 	filename := call.Arguments[0].String()
-	safePath, err := f.validatePath(filename)
+	safePath, err := f.validatePath(filename, security.PermWrite)
 	if err != nil {
-		panic(f.vm.NewGoError(fmt.Errorf("access denied: %w", err)))
+		panic(f.accessError(err))
 	}
 
-	data := call.Arguments[1].String()
+	data := f.decodeContent(call.Arguments[1], call.Argument(2))
 
-	err = os.WriteFile(safePath, []byte(data), consts.FilePermReadWrite)
+	err = os.WriteFile(safePath, data, consts.FilePermReadWrite)
 	if err != nil {
 		panic(f.vm.NewGoError(err))
 	}
@@ -169,7 +196,7 @@ func (f *FSModule) existsSync(call goja.FunctionCall) goja.Value {
 		return f.vm.ToValue(false)
 	}
 	filename := call.Arguments[0].String()
-	safePath, err := f.validatePath(filename)
+	safePath, err := f.validatePath(filename, security.PermRead)
 	if err != nil {
 		fmt.Println("validatePath Error:", err)
 		return f.vm.ToValue(false)
@@ -189,9 +216,9 @@ func (f *FSModule) statSync(call goja.FunctionCall) goja.Value {
 	}
 
 	filename := call.Arguments[0].String()
-	safePath, err := f.validatePath(filename)
+	safePath, err := f.validatePath(filename, security.PermRead)
 	if err != nil {
-		panic(f.vm.NewGoError(fmt.Errorf("access denied: %w", err)))
+		panic(f.accessError(err))
 	}
 
 	info, err := os.Stat(safePath)
@@ -217,9 +244,9 @@ func (f *FSModule) mkdirSync(call goja.FunctionCall) goja.Value {
 	}
 
 	path := call.Arguments[0].String()
-	safePath, err := f.validatePath(path)
+	safePath, err := f.validatePath(path, security.PermWrite)
 	if err != nil {
-		panic(f.vm.NewGoError(fmt.Errorf("access denied: %w", err)))
+		panic(f.accessError(err))
 	}
 
 	// 检查选项
@@ -253,9 +280,9 @@ func (f *FSModule) readdirSync(call goja.FunctionCall) goja.Value {
 	}
 
 	path := call.Arguments[0].String()
-	safePath, err := f.validatePath(path)
+	safePath, err := f.validatePath(path, security.PermRead)
 	if err != nil {
-		panic(f.vm.NewGoError(fmt.Errorf("access denied: %w", err)))
+		panic(f.accessError(err))
 	}
 
 	entries, err := os.ReadDir(safePath)
@@ -278,9 +305,9 @@ func (f *FSModule) unlinkSync(call goja.FunctionCall) goja.Value {
 	}
 
 	filename := call.Arguments[0].String()
-	safePath, err := f.validatePath(filename)
+	safePath, err := f.validatePath(filename, security.PermWrite)
 	if err != nil {
-		panic(f.vm.NewGoError(fmt.Errorf("access denied: %w", err)))
+		panic(f.accessError(err))
 	}
 
 	err = os.Remove(safePath)
@@ -298,9 +325,9 @@ func (f *FSModule) rmdirSync(call goja.FunctionCall) goja.Value {
 	}
 
 	path := call.Arguments[0].String()
-	safePath, err := f.validatePath(path)
+	safePath, err := f.validatePath(path, security.PermWrite)
 	if err != nil {
-		panic(f.vm.NewGoError(fmt.Errorf("access denied: %w", err)))
+		panic(f.accessError(err))
 	}
 
 	// 检查选项
@@ -336,12 +363,12 @@ func (f *FSModule) copyFileSync(call goja.FunctionCall) goja.Value {
 	src := call.Arguments[0].String()
 	dst := call.Arguments[1].String()
 
-	safeSrc, err := f.validatePath(src)
+	safeSrc, err := f.validatePath(src, security.PermRead)
 	if err != nil {
 		panic(f.vm.NewGoError(fmt.Errorf("access denied (source): %w", err)))
 	}
 
-	safeDst, err := f.validatePath(dst)
+	safeDst, err := f.validatePath(dst, security.PermWrite)
 	if err != nil {
 		panic(f.vm.NewGoError(fmt.Errorf("access denied (destination): %w", err)))
 	}
@@ -368,12 +395,12 @@ func (f *FSModule) renameSync(call goja.FunctionCall) goja.Value {
 	oldPath := call.Arguments[0].String()
 	newPath := call.Arguments[1].String()
 
-	safeOld, err := f.validatePath(oldPath)
+	safeOld, err := f.validatePath(oldPath, security.PermWrite)
 	if err != nil {
 		panic(f.vm.NewGoError(fmt.Errorf("access denied (old path): %w", err)))
 	}
 
-	safeNew, err := f.validatePath(newPath)
+	safeNew, err := f.validatePath(newPath, security.PermWrite)
 	if err != nil {
 		panic(f.vm.NewGoError(fmt.Errorf("access denied (new path): %w", err)))
 	}
@@ -395,12 +422,13 @@ func (f *FSModule) readFile(call goja.FunctionCall) goja.Value {
 	}
 
 	filename := call.Arguments[0].String()
+	options := call.Argument(1)
 	promise, resolve, reject := f.vm.NewPromise()
 
 	go func() {
-		safePath, err := f.validatePath(filename)
+		safePath, err := f.validatePath(filename, security.PermRead)
 		if err != nil {
-			reject(f.vm.NewGoError(fmt.Errorf("access denied: %w", err)))
+			reject(f.accessError(err))
 			return
 		}
 
@@ -408,7 +436,7 @@ func (f *FSModule) readFile(call goja.FunctionCall) goja.Value {
 		if err != nil {
 			reject(f.vm.NewGoError(err))
 		} else {
-			resolve(f.vm.ToValue(string(content)))
+			resolve(f.encodeContent(content, options))
 		}
 	}()
 
@@ -422,17 +450,18 @@ func (f *FSModule) writeFile(call goja.FunctionCall) goja.Value {
 	}
 
 	filename := call.Arguments[0].String()
-	data := call.Arguments[1].String()
+	// 在调用时复制数据，避免写入过程中 Buffer 被修改
+	data := bytes.Clone(f.decodeContent(call.Arguments[1], call.Argument(2)))
 	promise, resolve, reject := f.vm.NewPromise()
 
 	go func() {
-		safePath, err := f.validatePath(filename)
+		safePath, err := f.validatePath(filename, security.PermWrite)
 		if err != nil {
-			reject(f.vm.NewGoError(fmt.Errorf("access denied: %w", err)))
+			reject(f.accessError(err))
 			return
 		}
 
-		err = os.WriteFile(safePath, []byte(data), consts.FilePermReadWrite)
+		err = os.WriteFile(safePath, data, consts.FilePermReadWrite)
 		if err != nil {
 			reject(f.vm.NewGoError(err))
 		} else {
@@ -453,7 +482,7 @@ func (f *FSModule) exists(call goja.FunctionCall) goja.Value {
 	promise, resolve, _ := f.vm.NewPromise()
 
 	go func() {
-		safePath, err := f.validatePath(filename)
+		safePath, err := f.validatePath(filename, security.PermRead)
 		if err != nil {
 			resolve(f.vm.ToValue(false))
 			return
@@ -476,9 +505,9 @@ func (f *FSModule) stat(call goja.FunctionCall) goja.Value {
 	promise, resolve, reject := f.vm.NewPromise()
 
 	go func() {
-		safePath, err := f.validatePath(filename)
+		safePath, err := f.validatePath(filename, security.PermRead)
 		if err != nil {
-			reject(f.vm.NewGoError(fmt.Errorf("access denied: %w", err)))
+			reject(f.accessError(err))
 			return
 		}
 
@@ -510,9 +539,9 @@ func (f *FSModule) mkdir(call goja.FunctionCall) goja.Value {
 	promise, resolve, reject := f.vm.NewPromise()
 
 	go func() {
-		safePath, err := f.validatePath(path)
+		safePath, err := f.validatePath(path, security.PermWrite)
 		if err != nil {
-			reject(f.vm.NewGoError(fmt.Errorf("access denied: %w", err)))
+			reject(f.accessError(err))
 			return
 		}
 
@@ -537,9 +566,9 @@ func (f *FSModule) readdir(call goja.FunctionCall) goja.Value {
 	promise, resolve, reject := f.vm.NewPromise()
 
 	go func() {
-		safePath, err := f.validatePath(path)
+		safePath, err := f.validatePath(path, security.PermRead)
 		if err != nil {
-			reject(f.vm.NewGoError(fmt.Errorf("access denied: %w", err)))
+			reject(f.accessError(err))
 			return
 		}
 
@@ -568,9 +597,9 @@ func (f *FSModule) unlink(call goja.FunctionCall) goja.Value {
 	promise, resolve, reject := f.vm.NewPromise()
 
 	go func() {
-		safePath, err := f.validatePath(filename)
+		safePath, err := f.validatePath(filename, security.PermWrite)
 		if err != nil {
-			reject(f.vm.NewGoError(fmt.Errorf("access denied: %w", err)))
+			reject(f.accessError(err))
 			return
 		}
 
@@ -595,9 +624,9 @@ func (f *FSModule) rmdir(call goja.FunctionCall) goja.Value {
 	promise, resolve, reject := f.vm.NewPromise()
 
 	go func() {
-		safePath, err := f.validatePath(path)
+		safePath, err := f.validatePath(path, security.PermWrite)
 		if err != nil {
-			reject(f.vm.NewGoError(fmt.Errorf("access denied: %w", err)))
+			reject(f.accessError(err))
 			return
 		}
 
@@ -623,13 +652,13 @@ func (f *FSModule) copyFile(call goja.FunctionCall) goja.Value {
 	promise, resolve, reject := f.vm.NewPromise()
 
 	go func() {
-		safeSrc, err := f.validatePath(src)
+		safeSrc, err := f.validatePath(src, security.PermRead)
 		if err != nil {
 			reject(f.vm.NewGoError(fmt.Errorf("access denied (source): %w", err)))
 			return
 		}
 
-		safeDst, err := f.validatePath(dst)
+		safeDst, err := f.validatePath(dst, security.PermWrite)
 		if err != nil {
 			reject(f.vm.NewGoError(fmt.Errorf("access denied (destination): %w", err)))
 			return
@@ -663,13 +692,13 @@ func (f *FSModule) rename(call goja.FunctionCall) goja.Value {
 	promise, resolve, reject := f.vm.NewPromise()
 
 	go func() {
-		safeOld, err := f.validatePath(oldPath)
+		safeOld, err := f.validatePath(oldPath, security.PermWrite)
 		if err != nil {
 			reject(f.vm.NewGoError(fmt.Errorf("access denied (old path): %w", err)))
 			return
 		}
 
-		safeNew, err := f.validatePath(newPath)
+		safeNew, err := f.validatePath(newPath, security.PermWrite)
 		if err != nil {
 			reject(f.vm.NewGoError(fmt.Errorf("access denied (new path): %w", err)))
 			return
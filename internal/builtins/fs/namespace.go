@@ -1,7 +1,8 @@
 package fs
 
 import (
-	"sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/security"
 
 	"github.com/dop251/goja"
 )
@@ -14,10 +15,10 @@ type Namespace struct {
 }
 
 // NewNamespace 创建 fs 命名空间
-func NewNamespace(vm *goja.Runtime, basePath string) *Namespace {
+func NewNamespace(vm *goja.Runtime, basePath string, guard *security.Guard) *Namespace {
 	return &Namespace{
 		vm:  vm,
-		fs:  NewFSModule(vm, basePath),
+		fs:  NewFSModule(vm, basePath, guard),
 		os:  NewOSModule(vm),
 	}
 }
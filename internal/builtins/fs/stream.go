@@ -0,0 +1,143 @@
+package fs
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dop251/goja"
+
+	"github.com/issueye/sw_runtime/internal/builtins/stream"
+	"github.com/issueye/sw_runtime/internal/consts"
+	"github.com/issueye/sw_runtime/internal/security"
+)
+
+// streamOptions 把字符串形式的选项（编码）转换为对象
+func (f *FSModule) streamOptions(options goja.Value) *goja.Object {
+	if options == nil || goja.IsUndefined(options) || goja.IsNull(options) {
+		return f.vm.NewObject()
+	}
+	if _, ok := options.Export().(string); ok {
+		obj := f.vm.NewObject()
+		obj.Set("encoding", options.String())
+		return obj
+	}
+	return options.ToObject(f.vm)
+}
+
+// openFlags 把 Node.js 风格的打开标志转换为 os.OpenFile 参数
+func openFlags(flags string) (int, error) {
+	switch flags {
+	case "", "w":
+		return os.O_WRONLY | os.O_CREATE | os.O_TRUNC, nil
+	case "wx", "xw":
+		return os.O_WRONLY | os.O_CREATE | os.O_TRUNC | os.O_EXCL, nil
+	case "w+":
+		return os.O_RDWR | os.O_CREATE | os.O_TRUNC, nil
+	case "a":
+		return os.O_WRONLY | os.O_CREATE | os.O_APPEND, nil
+	case "ax", "xa":
+		return os.O_WRONLY | os.O_CREATE | os.O_APPEND | os.O_EXCL, nil
+	case "a+":
+		return os.O_RDWR | os.O_CREATE | os.O_APPEND, nil
+	case "r+":
+		return os.O_RDWR, nil
+	}
+	return 0, fmt.Errorf("invalid flags: %s", flags)
+}
+
+// createReadStream 创建文件读取流，按 highWaterMark 分块读取，支持 start/end（包含）字节范围。
+// 打开失败时流触发 error 事件
+func (f *FSModule) createReadStream(call goja.FunctionCall) goja.Value {
+	if len(call.Arguments) == 0 {
+		panic(f.vm.NewTypeError("createReadStream requires a path"))
+	}
+
+	filename := call.Arguments[0].String()
+	safePath, err := f.validatePath(filename, security.PermRead)
+	if err != nil {
+		panic(f.accessError(err))
+	}
+
+	opts := f.streamOptions(call.Argument(1))
+	start := int64(0)
+	if v := opts.Get("start"); v != nil && !goja.IsUndefined(v) {
+		start = v.ToInteger()
+	}
+	end := int64(-1)
+	if v := opts.Get("end"); v != nil && !goja.IsUndefined(v) && !goja.IsNull(v) {
+		end = v.ToInteger()
+	}
+
+	var reader io.Reader
+	file, openErr := os.Open(safePath)
+	if openErr == nil && start > 0 {
+		_, openErr = file.Seek(start, io.SeekStart)
+	}
+	if openErr == nil {
+		reader = file
+		if end >= 0 {
+			reader = io.LimitReader(file, end-start+1)
+		}
+	}
+
+	var readable *goja.Object
+	if openErr != nil {
+		if file != nil {
+			file.Close()
+		}
+		readable = stream.NewReadable(f.vm, eofReader{}, opts)
+		// error 事件在当前同步代码结束后触发，调用方有机会添加监听器
+		if err := stream.Destroy(f.vm, readable, openErr); err != nil {
+			panic(err)
+		}
+	} else {
+		readable = stream.NewReadable(f.vm, reader, opts, file)
+	}
+	readable.Set("path", filename)
+	return readable
+}
+
+// createWriteStream 创建文件写入流，flags 默认为 'w'（'a' 追加）
+func (f *FSModule) createWriteStream(call goja.FunctionCall) goja.Value {
+	if len(call.Arguments) == 0 {
+		panic(f.vm.NewTypeError("createWriteStream requires a path"))
+	}
+
+	filename := call.Arguments[0].String()
+	safePath, err := f.validatePath(filename, security.PermWrite)
+	if err != nil {
+		panic(f.accessError(err))
+	}
+
+	opts := f.streamOptions(call.Argument(1))
+	if enc := opts.Get("encoding"); enc != nil && !goja.IsUndefined(enc) && opts.Get("defaultEncoding") == nil {
+		opts.Set("defaultEncoding", enc)
+	}
+	flags := ""
+	if v := opts.Get("flags"); v != nil && !goja.IsUndefined(v) {
+		flags = v.String()
+	}
+
+	var writable *goja.Object
+	mode, openErr := openFlags(flags)
+	var file *os.File
+	if openErr == nil {
+		file, openErr = os.OpenFile(safePath, mode, consts.FilePermReadWrite)
+	}
+	if openErr != nil {
+		writable = stream.NewWritable(f.vm, io.Discard, nil, opts)
+		if err := stream.Destroy(f.vm, writable, openErr); err != nil {
+			panic(err)
+		}
+	} else {
+		writable = stream.NewWritable(f.vm, file, file.Close, opts, file)
+	}
+	writable.Set("path", filename)
+	return writable
+}
+
+// eofReader 打开失败时的占位读取器
+type eofReader struct{}
+
+func (eofReader) Read([]byte) (int, error) { return 0, io.EOF }
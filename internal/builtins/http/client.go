@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,8 +15,11 @@ import (
 
 	"github.com/dop251/goja"
 
-	"sw_runtime/internal/consts"
-	"sw_runtime/internal/security"
+	"github.com/issueye/sw_runtime/internal/builtins/buffer"
+	"github.com/issueye/sw_runtime/internal/builtins/stream"
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/consts"
+	"github.com/issueye/sw_runtime/internal/security"
 )
 
 // HTTPModule HTTP 客户端模块
@@ -25,19 +29,38 @@ type HTTPModule struct {
 	urlValidator        *security.URLValidator
 	requestInterceptor  goja.Callable
 	responseInterceptor goja.Callable
+	guard               *security.Guard
 }
 
 // NewHTTPModule 创建 HTTP 模块
-func NewHTTPModule(vm *goja.Runtime) *HTTPModule {
+func NewHTTPModule(vm *goja.Runtime, guard *security.Guard) *HTTPModule {
 	return &HTTPModule{
 		vm:           vm,
 		urlValidator: security.NewURLValidator(), // 默认阻止内网访问
 		client: &http.Client{
 			Timeout: consts.DefaultHTTPTimeout,
 		},
+		guard: guard,
 	}
 }
 
+// validateURL 检查网络权限并校验 URL 安全性（防止 SSRF 攻击）
+func (h *HTTPModule) validateURL(rawURL string) error {
+	if err := h.guard.CheckURL(rawURL); err != nil {
+		return err
+	}
+	return h.urlValidator.Validate(rawURL)
+}
+
+// requestError 将请求错误转换为 JS 错误，权限不足时为 PermissionDenied
+func requestError(vm *goja.Runtime, err error) *goja.Object {
+	var denied *security.PermissionDeniedError
+	if errors.As(err, &denied) {
+		return types.NewPermissionError(vm, err)
+	}
+	return vm.NewGoError(err)
+}
+
 // GetModule 获取 HTTP 模块对象
 func (h *HTTPModule) GetModule() *goja.Object {
 	obj := h.vm.NewObject()
@@ -134,41 +157,7 @@ type StreamResponse struct {
 	URL       string
 	Status    int
 	StatusText string
-}
-
-// Read 读取流式数据
-func (s *StreamResponse) Read(call goja.FunctionCall) goja.Value {
-	var n int
-	var err error
-
-	if len(call.Arguments) > 0 {
-		size := call.Arguments[0].ToInteger()
-		buf := make([]byte, size)
-		n, err = s.Body.Read(buf)
-		if err != nil && err != io.EOF {
-			panic(s.vm.NewGoError(err))
-		}
-		if n == 0 {
-			return s.vm.ToValue("")
-		}
-		return s.vm.ToValue(string(buf[:n]))
-	}
-	// 默认读取一块数据
-	buf := make([]byte, 4096)
-	n, err = s.Body.Read(buf)
-	if err != nil && err != io.EOF {
-		panic(s.vm.NewGoError(err))
-	}
-	if n == 0 {
-		return s.vm.ToValue("")
-	}
-	return s.vm.ToValue(string(buf[:n]))
-}
-
-// Close 关闭流
-func (s *StreamResponse) Close(call goja.FunctionCall) goja.Value {
-	s.Body.Close()
-	return goja.Undefined()
+	guard     *security.Guard
 }
 
 // PipeToFile 将流写入文件
@@ -177,6 +166,9 @@ func (s *StreamResponse) PipeToFile(call goja.FunctionCall) goja.Value {
 		panic(s.vm.NewGoError(fmt.Errorf("file path required")))
 	}
 	filePath := call.Arguments[0].String()
+	if err := s.guard.CheckWrite(filePath); err != nil {
+		panic(types.NewPermissionError(s.vm, err))
+	}
 
 	file, err := os.Create(filePath)
 	if err != nil {
@@ -261,8 +253,9 @@ type HTTPConfig struct {
 	Proxy             string                 `json:"proxy"`
 	Cookies           map[string]string      `json:"cookies"`
 	Config            map[string]interface{} `json:"config"`
-	ResponseType      string                 `json:"responseType"` // "json" | "text" | "stream"
+	ResponseType      string                 `json:"responseType"` // "json" | "text" | "buffer" | "arraybuffer" | "stream"
 	FilePath          string                 `json:"filePath"`     // 上传文件路径
+	BodyStream        io.ReadCloser          `json:"-"`            // data 为 Readable 流时的请求体
 	BeforeRequest     goja.Callable          `json:"-"`
 	AfterResponse     goja.Callable          `json:"-"`
 	TransformRequest  goja.Callable          `json:"-"`
@@ -300,7 +293,19 @@ func (h *HTTPModule) parseConfig(args []goja.Value) *HTTPConfig {
 				}
 			}
 			if data := configObj.Get("data"); data != nil && data != goja.Undefined() {
-				config.Data = data.Export()
+				if binary, ok := buffer.Bytes(h.vm, data); ok {
+					// 二进制数据在发起请求前复制，避免请求过程中被修改
+					config.Data = bytes.Clone(binary)
+				} else if stream.IsReadable(h.vm, data) {
+					// Readable 流作为请求体，边读边发送
+					body, err := stream.Reader(h.vm, data.ToObject(h.vm))
+					if err != nil {
+						panic(h.vm.NewGoError(err))
+					}
+					config.BodyStream = body
+				} else {
+					config.Data = data.Export()
+				}
 			}
 			if params := configObj.Get("params"); params != nil && params != goja.Undefined() {
 				paramsObj := params.ToObject(h.vm)
@@ -356,11 +361,10 @@ func (h *HTTPModule) parseConfig(args []goja.Value) *HTTPConfig {
 	return config
 }
 
-// makeRequest 执行 HTTP 请求
-func (h *HTTPModule) makeRequest(config *HTTPConfig) (*HTTPResponse, error) {
-	// 验证 URL 安全性（防止 SSRF 攻击）
-	if err := h.urlValidator.Validate(config.URL); err != nil {
-		return nil, fmt.Errorf("URL validation failed: %w", err)
+// prepareRequest 校验 URL 安全性（防止 SSRF 攻击）并应用全局请求拦截器
+func (h *HTTPModule) prepareRequest(config *HTTPConfig) error {
+	if err := h.validateURL(config.URL); err != nil {
+		return fmt.Errorf("URL validation failed: %w", err)
 	}
 
 	// 应用全局请求拦截器
@@ -368,15 +372,15 @@ func (h *HTTPModule) makeRequest(config *HTTPConfig) (*HTTPResponse, error) {
 		configObj := h.vm.ToValue(config).ToObject(h.vm)
 		result, err := h.requestInterceptor(goja.Undefined(), configObj)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		// 更新配置
 		if resultObj := result.ToObject(h.vm); resultObj != nil {
 			if url := resultObj.Get("url"); url != nil && url != goja.Undefined() {
 				config.URL = url.String()
 				// 拦截器修改后也要验证 URL
-				if err := h.urlValidator.Validate(config.URL); err != nil {
-					return nil, fmt.Errorf("URL validation failed (after interceptor): %w", err)
+				if err := h.validateURL(config.URL); err != nil {
+					return fmt.Errorf("URL validation failed (after interceptor): %w", err)
 				}
 			}
 			if headers := resultObj.Get("headers"); headers != nil && headers != goja.Undefined() {
@@ -402,6 +406,39 @@ func (h *HTTPModule) makeRequest(config *HTTPConfig) (*HTTPResponse, error) {
 			}
 		}
 	}
+	return nil
+}
+
+// applyResponseInterceptor 应用全局响应拦截器，可修改响应数据与响应头
+func (h *HTTPModule) applyResponseInterceptor(response *HTTPResponse) {
+	if h.responseInterceptor != nil {
+		responseObj := h.vm.ToValue(response).ToObject(h.vm)
+		result, err := h.responseInterceptor(goja.Undefined(), responseObj)
+		if err == nil {
+			if resultObj := result.ToObject(h.vm); resultObj != nil {
+				if data := resultObj.Get("data"); data != nil && data != goja.Undefined() {
+					response.Data = data.Export()
+				}
+				if headers := resultObj.Get("headers"); headers != nil && headers != goja.Undefined() {
+					headersObj := headers.ToObject(h.vm)
+					if headersObj != nil {
+						response.Headers = make(map[string]string)
+						for _, key := range headersObj.Keys() {
+							response.Headers[key] = headersObj.Get(key).String()
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// makeRequest 执行 HTTP 请求
+func (h *HTTPModule) makeRequest(config *HTTPConfig) (*HTTPResponse, error) {
+	// 校验 URL 并应用全局请求拦截器
+	if err := h.prepareRequest(config); err != nil {
+		return nil, err
+	}
 
 	// 应用 beforeRequest 拦截器
 	if config.BeforeRequest != nil {
@@ -415,7 +452,7 @@ func (h *HTTPModule) makeRequest(config *HTTPConfig) (*HTTPResponse, error) {
 			if url := resultObj.Get("url"); url != nil && url != goja.Undefined() {
 				config.URL = url.String()
 				// beforeRequest 修改后也要验证 URL
-				if err := h.urlValidator.Validate(config.URL); err != nil {
+				if err := h.validateURL(config.URL); err != nil {
 					return nil, fmt.Errorf("URL validation failed (after beforeRequest): %w", err)
 				}
 			}
@@ -469,8 +506,18 @@ func (h *HTTPModule) makeRequest(config *HTTPConfig) (*HTTPResponse, error) {
 
 	// 准备请求体
 	var body io.Reader
-	if config.FilePath != "" {
+	if config.BodyStream != nil {
+		// 流式上传，请求结束后关闭，使未发送完的源流停止
+		defer config.BodyStream.Close()
+		body = config.BodyStream
+		if config.Headers["Content-Type"] == "" {
+			config.Headers["Content-Type"] = "application/octet-stream"
+		}
+	} else if config.FilePath != "" {
 		// 文件上传模式
+		if err := h.guard.CheckRead(config.FilePath); err != nil {
+			return nil, err
+		}
 		file, err := os.Open(config.FilePath)
 		if err != nil {
 			return nil, fmt.Errorf("failed to open file: %w", err)
@@ -513,6 +560,11 @@ func (h *HTTPModule) makeRequest(config *HTTPConfig) (*HTTPResponse, error) {
 		switch data := config.Data.(type) {
 		case string:
 			body = strings.NewReader(data)
+		case []byte:
+			body = bytes.NewReader(data)
+			if config.Headers["Content-Type"] == "" {
+				config.Headers["Content-Type"] = "application/octet-stream"
+			}
 		case map[string]interface{}:
 			jsonData, err := json.Marshal(data)
 			if err != nil {
@@ -596,6 +648,7 @@ func (h *HTTPModule) makeRequest(config *HTTPConfig) (*HTTPResponse, error) {
 		// 创建 StreamResponse，保留 Body 不关闭（由用户调用 close）
 		streamResponse := &StreamResponse{
 			vm:         h.vm,
+			guard:      h.guard,
 			Body:       resp.Body,
 			Headers:    response.Headers,
 			URL:        reqURL,
@@ -603,10 +656,14 @@ func (h *HTTPModule) makeRequest(config *HTTPConfig) (*HTTPResponse, error) {
 			StatusText: resp.Status,
 		}
 
-		// 将 StreamResponse 暴露给 JS
-		streamObj := h.vm.NewObject()
-		streamObj.Set("read", streamResponse.Read)
-		streamObj.Set("close", streamResponse.Close)
+		// 将 StreamResponse 暴露给 JS：Readable 流，支持 pipe 和 for await
+		streamObj := stream.NewReadable(h.vm, resp.Body, nil, resp.Body)
+		streamObj.Set("close", func(call goja.FunctionCall) goja.Value {
+			if err := stream.Destroy(h.vm, streamObj, nil); err != nil {
+				panic(err)
+			}
+			return goja.Undefined()
+		})
 		streamObj.Set("pipeToFile", streamResponse.PipeToFile)
 		streamObj.Set("copy", streamResponse.Copy)
 		streamObj.Set("headers", h.vm.ToValue(response.Headers))
@@ -631,12 +688,17 @@ func (h *HTTPModule) makeRequest(config *HTTPConfig) (*HTTPResponse, error) {
 
 	response.Text = string(respBody)
 
-	// 尝试解析 JSON
-	var jsonData interface{}
-	if err := json.Unmarshal(respBody, &jsonData); err == nil {
-		response.Data = jsonData
+	if config.ResponseType == "arraybuffer" || config.ResponseType == "buffer" {
+		// 二进制响应：data 为 Buffer
+		response.Data = buffer.New(h.vm, respBody)
 	} else {
-		response.Data = string(respBody)
+		// 尝试解析 JSON
+		var jsonData interface{}
+		if err := json.Unmarshal(respBody, &jsonData); err == nil {
+			response.Data = jsonData
+		} else {
+			response.Data = string(respBody)
+		}
 	}
 
 	// 应用 transformResponse 拦截器
@@ -670,26 +732,7 @@ func (h *HTTPModule) makeRequest(config *HTTPConfig) (*HTTPResponse, error) {
 	}
 
 	// 应用全局响应拦截器
-	if h.responseInterceptor != nil {
-		responseObj := h.vm.ToValue(response).ToObject(h.vm)
-		result, err := h.responseInterceptor(goja.Undefined(), responseObj)
-		if err == nil {
-			if resultObj := result.ToObject(h.vm); resultObj != nil {
-				if data := resultObj.Get("data"); data != nil && data != goja.Undefined() {
-					response.Data = data.Export()
-				}
-				if headers := resultObj.Get("headers"); headers != nil && headers != goja.Undefined() {
-					headersObj := headers.ToObject(h.vm)
-					if headersObj != nil {
-						response.Headers = make(map[string]string)
-						for _, key := range headersObj.Keys() {
-							response.Headers[key] = headersObj.Get(key).String()
-						}
-					}
-				}
-			}
-		}
-	}
+	h.applyResponseInterceptor(response)
 
 	return response, nil
 }
@@ -704,7 +747,7 @@ func (h *HTTPModule) get(call goja.FunctionCall) goja.Value {
 	go func() {
 		response, err := h.makeRequest(config)
 		if err != nil {
-			reject(h.vm.NewGoError(err))
+			reject(requestError(h.vm, err))
 		} else {
 			resolve(h.vm.ToValue(response))
 		}
@@ -723,7 +766,7 @@ func (h *HTTPModule) post(call goja.FunctionCall) goja.Value {
 	go func() {
 		response, err := h.makeRequest(config)
 		if err != nil {
-			reject(h.vm.NewGoError(err))
+			reject(requestError(h.vm, err))
 		} else {
 			resolve(h.vm.ToValue(response))
 		}
@@ -742,7 +785,7 @@ func (h *HTTPModule) put(call goja.FunctionCall) goja.Value {
 	go func() {
 		response, err := h.makeRequest(config)
 		if err != nil {
-			reject(h.vm.NewGoError(err))
+			reject(requestError(h.vm, err))
 		} else {
 			resolve(h.vm.ToValue(response))
 		}
@@ -761,7 +804,7 @@ func (h *HTTPModule) delete(call goja.FunctionCall) goja.Value {
 	go func() {
 		response, err := h.makeRequest(config)
 		if err != nil {
-			reject(h.vm.NewGoError(err))
+			reject(requestError(h.vm, err))
 		} else {
 			resolve(h.vm.ToValue(response))
 		}
@@ -780,7 +823,7 @@ func (h *HTTPModule) patch(call goja.FunctionCall) goja.Value {
 	go func() {
 		response, err := h.makeRequest(config)
 		if err != nil {
-			reject(h.vm.NewGoError(err))
+			reject(requestError(h.vm, err))
 		} else {
 			resolve(h.vm.ToValue(response))
 		}
@@ -799,7 +842,7 @@ func (h *HTTPModule) head(call goja.FunctionCall) goja.Value {
 	go func() {
 		response, err := h.makeRequest(config)
 		if err != nil {
-			reject(h.vm.NewGoError(err))
+			reject(requestError(h.vm, err))
 		} else {
 			resolve(h.vm.ToValue(response))
 		}
@@ -818,7 +861,7 @@ func (h *HTTPModule) options(call goja.FunctionCall) goja.Value {
 	go func() {
 		response, err := h.makeRequest(config)
 		if err != nil {
-			reject(h.vm.NewGoError(err))
+			reject(requestError(h.vm, err))
 		} else {
 			resolve(h.vm.ToValue(response))
 		}
@@ -839,7 +882,7 @@ func (h *HTTPModule) request(call goja.FunctionCall) goja.Value {
 	go func() {
 		response, err := h.makeRequest(config)
 		if err != nil {
-			reject(h.vm.NewGoError(err))
+			reject(requestError(h.vm, err))
 		} else {
 			resolve(h.vm.ToValue(response))
 		}
@@ -865,7 +908,7 @@ func (h *HTTPModule) createClient(call goja.FunctionCall) goja.Value {
 
 	// 创建客户端实例对象
 	clientObj := h.vm.NewObject()
-	httpModule := &HTTPModule{vm: h.vm, client: client}
+	httpModule := &HTTPModule{vm: h.vm, client: client, urlValidator: h.urlValidator, guard: h.guard}
 
 	clientObj.Set("get", httpModule.get)
 	clientObj.Set("post", httpModule.post)
@@ -0,0 +1,325 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dop251/goja"
+
+	"github.com/issueye/sw_runtime/internal/builtins/stream"
+	"github.com/issueye/sw_runtime/internal/consts"
+)
+
+// ServerWorker 多 VM 服务器模式下的工作 VM。
+// 工作 VM 重新执行入口脚本，脚本中的 listen 调用不会绑定端口，而是把服务器加入父服务器的池中
+type ServerWorker interface {
+	// VM 获取工作 VM
+	VM() *goja.Runtime
+	// Start 在独立 goroutine 中执行入口脚本，脚本结束或被停止后调用 onExit
+	Start(onExit func(error))
+	// Stop 停止工作 VM
+	Stop()
+}
+
+// WorkerSpawner 创建工作 VM，由 runtime 包注入
+type WorkerSpawner func() (ServerWorker, error)
+
+// 工作池注册表：监听地址 -> 服务器池，工作 VM -> 所属服务器池
+var poolRegistry = struct {
+	sync.RWMutex
+	pools   map[string]*serverPool
+	members map[*goja.Runtime]*serverPool
+}{
+	pools:   make(map[string]*serverPool),
+	members: make(map[*goja.Runtime]*serverPool),
+}
+
+// poolOf 获取工作 VM 所属的服务器池，非工作 VM 返回 nil
+func poolOf(vm *goja.Runtime) *serverPool {
+	poolRegistry.RLock()
+	defer poolRegistry.RUnlock()
+	return poolRegistry.members[vm]
+}
+
+// serverPool 同一地址上由多个工作 VM 组成的服务器池
+type serverPool struct {
+	addr    string
+	size    int
+	workers []ServerWorker
+
+	mu      sync.RWMutex
+	members []*poolMember
+	next    atomic.Uint64
+	closing atomic.Bool
+
+	joined chan struct{}
+	failed chan error
+}
+
+// poolMember 已加入池的工作 VM 服务器
+type poolMember struct {
+	server   *HTTPServer
+	inflight atomic.Int64
+}
+
+// newServerPool 创建服务器池并注册监听地址
+func newServerPool(addr string, size int) (*serverPool, error) {
+	poolRegistry.Lock()
+	defer poolRegistry.Unlock()
+	if _, exists := poolRegistry.pools[addr]; exists {
+		return nil, fmt.Errorf("server pool already listening on %s", addr)
+	}
+
+	p := &serverPool{
+		addr:   addr,
+		size:   size,
+		joined: make(chan struct{}, size),
+		failed: make(chan error, size),
+	}
+	poolRegistry.pools[addr] = p
+	return p, nil
+}
+
+// start 创建工作 VM 并等待它们全部加入池
+func (p *serverPool) start(spawner WorkerSpawner) error {
+	for i := 0; i < p.size; i++ {
+		if p.closing.Load() {
+			return fmt.Errorf("server pool on %s closed", p.addr)
+		}
+		w, err := spawner()
+		if err != nil {
+			return fmt.Errorf("failed to create server worker: %w", err)
+		}
+
+		poolRegistry.Lock()
+		poolRegistry.members[w.VM()] = p
+		poolRegistry.Unlock()
+
+		p.mu.Lock()
+		p.workers = append(p.workers, w)
+		p.mu.Unlock()
+		w.Start(func(err error) {
+			p.exited(w, err)
+		})
+	}
+
+	timeout := time.NewTimer(consts.DefaultHTTPTimeout)
+	defer timeout.Stop()
+	for joined := 0; joined < p.size; {
+		select {
+		case <-p.joined:
+			joined++
+		case err := <-p.failed:
+			return err
+		case <-timeout.C:
+			return fmt.Errorf("timeout waiting for server workers to listen on %s", p.addr)
+		}
+	}
+	return nil
+}
+
+// join 工作 VM 中的服务器加入池
+func (p *serverPool) join(server *HTTPServer) {
+	p.mu.Lock()
+	p.members = append(p.members, &poolMember{server: server})
+	p.mu.Unlock()
+	select {
+	case p.joined <- struct{}{}:
+	default:
+	}
+}
+
+// exited 工作 VM 退出后将其移出池
+func (p *serverPool) exited(w ServerWorker, err error) {
+	vm := w.VM()
+	poolRegistry.Lock()
+	delete(poolRegistry.members, vm)
+	poolRegistry.Unlock()
+
+	p.mu.Lock()
+	for i, m := range p.members {
+		if m.server.vm == vm {
+			p.members = append(p.members[:i:i], p.members[i+1:]...)
+			break
+		}
+	}
+	p.mu.Unlock()
+
+	if p.closing.Load() {
+		return
+	}
+	if err == nil {
+		err = fmt.Errorf("server worker exited before listening on %s", p.addr)
+	}
+	select {
+	case p.failed <- err:
+	default:
+	}
+}
+
+// pick 选择正在处理请求数最少的工作 VM，数量相同时轮询
+func (p *serverPool) pick() *poolMember {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	n := len(p.members)
+	if n == 0 {
+		return nil
+	}
+
+	start := int(p.next.Add(1) % uint64(n))
+	best := p.members[start]
+	for i := 1; i < n; i++ {
+		m := p.members[(start+i)%n]
+		if m.inflight.Load() < best.inflight.Load() {
+			best = m
+		}
+	}
+	return best
+}
+
+// ServeHTTP 将请求转发给工作 VM 的路由
+func (p *serverPool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m := p.pick()
+	if m == nil {
+		http.Error(w, "No server workers available", http.StatusServiceUnavailable)
+		return
+	}
+	m.inflight.Add(1)
+	defer m.inflight.Add(-1)
+	m.server.mux.ServeHTTP(w, r)
+}
+
+// close 停止所有工作 VM 并注销监听地址
+func (p *serverPool) close() {
+	if !p.closing.CompareAndSwap(false, true) {
+		return
+	}
+
+	poolRegistry.Lock()
+	if poolRegistry.pools[p.addr] == p {
+		delete(poolRegistry.pools, p.addr)
+	}
+	poolRegistry.Unlock()
+
+	p.mu.RLock()
+	workers := append([]ServerWorker(nil), p.workers...)
+	p.mu.RUnlock()
+	for _, w := range workers {
+		w.Stop()
+	}
+}
+
+// listenWorkers 以多 VM 模式启动服务器：先启动工作 VM 池，全部加入后再绑定端口
+func (h *HTTPServerModule) listenWorkers(server *HTTPServer, port string, workers int, callback goja.Value, label string, serve func() error) goja.Value {
+	if h.spawner == nil {
+		panic(h.vm.NewTypeError("workers option requires the server to run inside a Runner"))
+	}
+
+	pool, err := newServerPool(port, workers)
+	if err != nil {
+		panic(h.vm.NewGoError(err))
+	}
+	server.pool = pool
+
+	promise, resolve, reject := h.vm.NewPromise()
+	registerServer(server)
+
+	// 等待工作 VM 加入池在独立 goroutine 中进行，监听回调与 promise 的 settle
+	// 回到所属 Runner 的事件循环执行（服务器 VM 处理器与事件循环并不串行）
+	stream.Async(h.vm, func() func(vm *goja.Runtime) error {
+		err := pool.start(h.spawner)
+		return func(vm *goja.Runtime) error {
+			if err != nil {
+				pool.close()
+				unregisterServer(server)
+				reject(vm.NewGoError(err))
+				return nil
+			}
+
+			server.server = server.newHTTPServer(port, pool)
+			h.mutex.Lock()
+			h.servers[port] = server
+			h.mutex.Unlock()
+
+			h.serveWorkers(server, pool, reject, serve)
+
+			if fn, ok := goja.AssertFunction(callback); ok {
+				if _, err := fn(vm.GlobalObject()); err != nil {
+					fmt.Printf("Callback error: %v\n", err)
+				}
+			}
+			resolve(vm.ToValue(fmt.Sprintf("%s listening on %s with %d workers", label, port, workers)))
+			return nil
+		}
+	})
+
+	return h.vm.ToValue(promise)
+}
+
+// serveWorkers 在独立 goroutine 中绑定端口并处理请求，启动失败时在事件循环中 reject
+func (h *HTTPServerModule) serveWorkers(server *HTTPServer, pool *serverPool, reject func(interface{}) error, serve func() error) {
+	go func() {
+		err := serve()
+		if err == nil || err == http.ErrServerClosed {
+			unregisterServer(server)
+			return
+		}
+		pool.close()
+		unregisterServer(server)
+		stream.Async(h.vm, func() func(vm *goja.Runtime) error {
+			return func(vm *goja.Runtime) error {
+				reject(vm.NewGoError(err))
+				return nil
+			}
+		})
+	}()
+}
+
+// joinPool 工作 VM 中的 listen：不绑定端口，将服务器加入父服务器的池。
+// 与池地址不同的 listen 调用直接忽略，避免多个工作 VM 重复绑定同一端口
+func (h *HTTPServerModule) joinPool(server *HTTPServer, pool *serverPool, port string, callback goja.Value) goja.Value {
+	promise, resolve, _ := h.vm.NewPromise()
+	if pool.addr != port {
+		resolve(h.vm.ToValue(fmt.Sprintf("Server on %s is not served in worker", port)))
+		return h.vm.ToValue(promise)
+	}
+
+	// 入口脚本同步执行完毕、事件循环开始处理回调后才加入池，
+	// 避免请求处理与仍在执行的入口脚本同时访问工作 VM
+	registerServer(server)
+	stream.Async(h.vm, func() func(vm *goja.Runtime) error {
+		return func(vm *goja.Runtime) error {
+			pool.join(server)
+			return nil
+		}
+	})
+
+	if fn, ok := goja.AssertFunction(callback); ok {
+		if _, err := fn(h.vm.GlobalObject()); err != nil {
+			fmt.Printf("Callback error: %v\n", err)
+		}
+	}
+	resolve(h.vm.ToValue(fmt.Sprintf("Server worker joined %s", port)))
+	return h.vm.ToValue(promise)
+}
+
+// parseListenOptions 解析 listen 的可选参数：回调函数，或 { workers } 配置对象加回调函数
+func parseListenOptions(args []goja.Value) (callback goja.Value, workers int) {
+	for _, arg := range args {
+		if _, ok := goja.AssertFunction(arg); ok {
+			if callback == nil {
+				callback = arg
+			}
+			continue
+		}
+		if obj, ok := arg.(*goja.Object); ok {
+			if n := obj.Get("workers"); n != nil && !goja.IsUndefined(n) {
+				workers = int(n.ToInteger())
+			}
+		}
+	}
+	return callback, workers
+}
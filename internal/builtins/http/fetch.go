@@ -0,0 +1,1213 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/issueye/sw_runtime/internal/builtins/stream"
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/security"
+
+	"github.com/dop251/goja"
+)
+
+// fetchChunkSize 流式读取响应体时每块的大小
+const fetchChunkSize = 64 * 1024
+
+// FetchModule WHATWG fetch API，基于 HTTPModule 的传输层，
+// 因此 URLValidator 的 SSRF 校验与全局拦截器同样生效
+type FetchModule struct {
+	vm       *goja.Runtime
+	client   *HTTPModule
+	internal *goja.Symbol
+
+	headersCtor    *goja.Object
+	requestCtor    *goja.Object
+	responseCtor   *goja.Object
+	controllerCtor *goja.Object
+	signalCtor     *goja.Object
+}
+
+// NewFetchModule 创建 fetch 模块
+func NewFetchModule(vm *goja.Runtime, client *HTTPModule) *FetchModule {
+	f := &FetchModule{
+		vm:       vm,
+		client:   client,
+		internal: goja.NewSymbol("sw.internal"),
+	}
+
+	f.headersCtor = vm.ToValue(f.newHeaders).ToObject(vm)
+	f.requestCtor = vm.ToValue(f.newRequest).ToObject(vm)
+	f.responseCtor = vm.ToValue(f.newResponse).ToObject(vm)
+	f.controllerCtor = vm.ToValue(f.newAbortController).ToObject(vm)
+	f.signalCtor = vm.ToValue(func(call goja.ConstructorCall) *goja.Object {
+		panic(vm.NewTypeError("Illegal constructor"))
+	}).ToObject(vm)
+
+	f.responseCtor.Set("json", f.responseJSON)
+	f.responseCtor.Set("error", f.responseError)
+	f.responseCtor.Set("redirect", f.responseRedirect)
+	f.signalCtor.Set("abort", f.signalAbort)
+	f.signalCtor.Set("timeout", f.signalTimeout)
+	f.signalCtor.Set("any", f.signalAny)
+
+	return f
+}
+
+// GetModule 获取 fetch 模块对象
+func (f *FetchModule) GetModule() *goja.Object {
+	obj := f.vm.NewObject()
+	obj.Set("fetch", f.fetch)
+	obj.Set("Headers", f.headersCtor)
+	obj.Set("Request", f.requestCtor)
+	obj.Set("Response", f.responseCtor)
+	obj.Set("AbortController", f.controllerCtor)
+	obj.Set("AbortSignal", f.signalCtor)
+	return obj
+}
+
+// unwrap 取出挂在 JS 对象上的 Go 数据
+func (f *FetchModule) unwrap(value goja.Value) interface{} {
+	if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+		return nil
+	}
+	obj, ok := value.(*goja.Object)
+	if !ok {
+		return nil
+	}
+	internal := obj.GetSymbol(f.internal)
+	if internal == nil {
+		return nil
+	}
+	return internal.Export()
+}
+
+// prototypeOf 获取构造函数的 prototype
+func (f *FetchModule) prototypeOf(ctor *goja.Object) *goja.Object {
+	return ctor.Get("prototype").ToObject(f.vm)
+}
+
+// iterator 将条目包装为 JS 迭代器
+func (f *FetchModule) iterator(items []interface{}) goja.Value {
+	arr := f.vm.NewArray(items...)
+	values, _ := goja.AssertFunction(arr.Get("values"))
+	it, err := values(arr)
+	if err != nil {
+		panic(err)
+	}
+	return it
+}
+
+// newError 创建带 name 的错误对象（如 AbortError、TimeoutError）
+func (f *FetchModule) newError(name string, message string) *goja.Object {
+	errObj, err := f.vm.New(f.vm.Get("Error"), f.vm.ToValue(message))
+	if err != nil {
+		panic(err)
+	}
+	errObj.Set("name", name)
+	return errObj
+}
+
+// newUint8Array 创建 Uint8Array
+func (f *FetchModule) newUint8Array(data []byte) goja.Value {
+	arr, err := f.vm.New(f.vm.Get("Uint8Array"), f.vm.ToValue(f.vm.NewArrayBuffer(data)))
+	if err != nil {
+		panic(err)
+	}
+	return arr
+}
+
+// exceptionValue 将 Go 错误转换为 Promise 拒绝值
+func (f *FetchModule) exceptionValue(err error) goja.Value {
+	var exception *goja.Exception
+	if errors.As(err, &exception) {
+		return exception.Value()
+	}
+	return f.vm.NewGoError(err)
+}
+
+// ---------------------------------------------------------------------------
+// Headers
+// ---------------------------------------------------------------------------
+
+// fetchHeaders Headers 对象的内部数据
+type fetchHeaders struct {
+	header    http.Header
+	immutable bool
+}
+
+// newHeaders Headers 构造函数
+func (f *FetchModule) newHeaders(call goja.ConstructorCall) *goja.Object {
+	h := &fetchHeaders{header: make(http.Header)}
+	f.fillHeaders(h, call.Argument(0))
+	f.bindHeaders(call.This, h)
+	return nil
+}
+
+// createHeaders 从 Go 侧创建 Headers 对象
+func (f *FetchModule) createHeaders(h *fetchHeaders) *goja.Object {
+	obj := f.vm.CreateObject(f.prototypeOf(f.headersCtor))
+	f.bindHeaders(obj, h)
+	return obj
+}
+
+// validateHeaderName 校验请求头名称
+func (f *FetchModule) validateHeaderName(name string) {
+	if name == "" || strings.ContainsAny(name, " \t\r\n:()<>@,;\\\"/[]?={}") {
+		panic(f.vm.NewTypeError(fmt.Sprintf("Invalid header name: %q", name)))
+	}
+}
+
+// fillHeaders 从 HeadersInit（Headers、二维数组或普通对象）填充请求头
+func (f *FetchModule) fillHeaders(h *fetchHeaders, init goja.Value) {
+	if init == nil || goja.IsUndefined(init) || goja.IsNull(init) {
+		return
+	}
+	if other, ok := f.unwrap(init).(*fetchHeaders); ok {
+		for key, values := range other.header {
+			h.header[key] = append([]string(nil), values...)
+		}
+		return
+	}
+
+	obj := init.ToObject(f.vm)
+	if obj.ClassName() == "Array" {
+		for _, pair := range obj.Export().([]interface{}) {
+			entry, ok := pair.([]interface{})
+			if !ok || len(entry) != 2 {
+				panic(f.vm.NewTypeError("Headers init pairs must have exactly two items"))
+			}
+			name := fmt.Sprint(entry[0])
+			f.validateHeaderName(name)
+			h.header.Add(name, fmt.Sprint(entry[1]))
+		}
+		return
+	}
+
+	for _, key := range obj.Keys() {
+		f.validateHeaderName(key)
+		h.header.Add(key, obj.Get(key).String())
+	}
+}
+
+// entries 返回按名称排序的 [name, value] 条目，名称为小写
+func (h *fetchHeaders) entries() [][2]string {
+	names := make([]string, 0, len(h.header))
+	for key := range h.header {
+		names = append(names, key)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+
+	var result [][2]string
+	for _, key := range names {
+		lower := strings.ToLower(key)
+		values := h.header[key]
+		if lower == "set-cookie" {
+			for _, value := range values {
+				result = append(result, [2]string{lower, value})
+			}
+			continue
+		}
+		result = append(result, [2]string{lower, strings.Join(values, ", ")})
+	}
+	return result
+}
+
+// toMap 转换为单值映射（多值以逗号连接）
+func (h *fetchHeaders) toMap() map[string]string {
+	result := make(map[string]string, len(h.header))
+	for _, entry := range h.entries() {
+		if existing, ok := result[entry[0]]; ok {
+			result[entry[0]] = existing + ", " + entry[1]
+			continue
+		}
+		result[entry[0]] = entry[1]
+	}
+	return result
+}
+
+// bindHeaders 为 Headers 对象绑定方法
+func (f *FetchModule) bindHeaders(obj *goja.Object, h *fetchHeaders) {
+	obj.SetSymbol(f.internal, f.vm.ToValue(h))
+
+	checkMutable := func() {
+		if h.immutable {
+			panic(f.vm.NewTypeError("Headers are immutable"))
+		}
+	}
+
+	obj.Set("append", func(name, value string) {
+		checkMutable()
+		f.validateHeaderName(name)
+		h.header.Add(name, value)
+	})
+	obj.Set("set", func(name, value string) {
+		checkMutable()
+		f.validateHeaderName(name)
+		h.header.Set(name, value)
+	})
+	obj.Set("delete", func(name string) {
+		checkMutable()
+		h.header.Del(name)
+	})
+	obj.Set("get", func(name string) goja.Value {
+		values := h.header.Values(name)
+		if len(values) == 0 {
+			return goja.Null()
+		}
+		return f.vm.ToValue(strings.Join(values, ", "))
+	})
+	obj.Set("has", func(name string) bool {
+		return len(h.header.Values(name)) > 0
+	})
+	obj.Set("getSetCookie", func() []string {
+		return append([]string{}, h.header.Values("Set-Cookie")...)
+	})
+	obj.Set("forEach", func(call goja.FunctionCall) goja.Value {
+		callback, ok := goja.AssertFunction(call.Argument(0))
+		if !ok {
+			panic(f.vm.NewTypeError("Headers.forEach callback must be a function"))
+		}
+		for _, entry := range h.entries() {
+			if _, err := callback(call.Argument(1), f.vm.ToValue(entry[1]), f.vm.ToValue(entry[0]), obj); err != nil {
+				panic(err)
+			}
+		}
+		return goja.Undefined()
+	})
+
+	entries := func() goja.Value {
+		var items []interface{}
+		for _, entry := range h.entries() {
+			items = append(items, f.vm.NewArray(entry[0], entry[1]))
+		}
+		return f.iterator(items)
+	}
+	obj.Set("entries", entries)
+	obj.Set("keys", func() goja.Value {
+		var items []interface{}
+		for _, entry := range h.entries() {
+			items = append(items, entry[0])
+		}
+		return f.iterator(items)
+	})
+	obj.Set("values", func() goja.Value {
+		var items []interface{}
+		for _, entry := range h.entries() {
+			items = append(items, entry[1])
+		}
+		return f.iterator(items)
+	})
+	obj.SetSymbol(goja.SymIterator, entries)
+}
+
+// ---------------------------------------------------------------------------
+// Body
+// ---------------------------------------------------------------------------
+
+// fetchBody Request/Response 共用的消息体
+type fetchBody struct {
+	has    bool
+	data   []byte
+	reader io.ReadCloser
+	used   bool
+	signal *abortSignal
+	stream *goja.Object
+}
+
+// bufferedSource 克隆网络响应时共享的缓冲数据源
+type bufferedSource struct {
+	once sync.Once
+	src  io.ReadCloser
+	data []byte
+	err  error
+}
+
+func (s *bufferedSource) load() ([]byte, error) {
+	s.once.Do(func() {
+		s.data, s.err = io.ReadAll(s.src)
+		s.src.Close()
+	})
+	return s.data, s.err
+}
+
+// bufferedReader 从共享缓冲数据源读取
+type bufferedReader struct {
+	source *bufferedSource
+	reader *bytes.Reader
+}
+
+func (r *bufferedReader) Read(p []byte) (int, error) {
+	if r.reader == nil {
+		data, err := r.source.load()
+		if err != nil {
+			return 0, err
+		}
+		r.reader = bytes.NewReader(data)
+	}
+	return r.reader.Read(p)
+}
+
+func (r *bufferedReader) Close() error {
+	return nil
+}
+
+// clone 复制消息体，网络流改为共享缓冲读取
+func (b *fetchBody) clone() *fetchBody {
+	cloned := &fetchBody{has: b.has, data: b.data, signal: b.signal}
+	if b.reader != nil {
+		source := &bufferedSource{src: b.reader}
+		b.reader = &bufferedReader{source: source}
+		cloned.reader = &bufferedReader{source: source}
+	}
+	return cloned
+}
+
+// bodyFromValue 将 BodyInit 转换为字节，返回默认 Content-Type
+func (f *FetchModule) bodyFromValue(value goja.Value) (*fetchBody, string) {
+	if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+		return &fetchBody{}, ""
+	}
+
+	switch exported := value.Export().(type) {
+	case string:
+		return &fetchBody{has: true, data: []byte(exported)}, "text/plain;charset=UTF-8"
+	case goja.ArrayBuffer:
+		return &fetchBody{has: true, data: append([]byte(nil), exported.Bytes()...)}, ""
+	}
+
+	if obj, ok := value.(*goja.Object); ok {
+		// TypedArray / DataView
+		if buffer, ok := obj.Get("buffer").Export().(goja.ArrayBuffer); ok {
+			offset := obj.Get("byteOffset").ToInteger()
+			length := obj.Get("byteLength").ToInteger()
+			data := buffer.Bytes()[offset : offset+length]
+			return &fetchBody{has: true, data: append([]byte(nil), data...)}, ""
+		}
+		if ctor := obj.Get("constructor"); ctor != nil {
+			if ctorObj, ok := ctor.(*goja.Object); ok && ctorObj.Get("name").String() == "URLSearchParams" {
+				return &fetchBody{has: true, data: []byte(value.String())}, "application/x-www-form-urlencoded;charset=UTF-8"
+			}
+		}
+	}
+
+	return &fetchBody{has: true, data: []byte(value.String())}, "text/plain;charset=UTF-8"
+}
+
+// readError 将读取错误转换为拒绝值（中止时使用 signal.reason）
+func (f *FetchModule) readError(b *fetchBody, err error) goja.Value {
+	if b.signal != nil && b.signal.isAborted() {
+		return b.signal.reason
+	}
+	return f.vm.NewTypeError(fmt.Sprintf("failed to read body: %v", err))
+}
+
+// consume 读取完整消息体后以 convert 的结果 resolve
+func (f *FetchModule) consume(b *fetchBody, convert func([]byte) (goja.Value, error)) goja.Value {
+	promise, resolve, reject := f.vm.NewPromise()
+
+	if b.used {
+		reject(f.vm.NewTypeError("Body has already been consumed"))
+		return f.vm.ToValue(promise)
+	}
+	b.used = true
+
+	settle := func(data []byte) {
+		result, err := convert(data)
+		if err != nil {
+			reject(f.exceptionValue(err))
+			return
+		}
+		resolve(result)
+	}
+
+	if b.reader == nil {
+		settle(b.data)
+		return f.vm.ToValue(promise)
+	}
+
+	// 只在 goroutine 中读取数据，promise 在事件循环中 settle
+	reader := b.reader
+	stream.Async(f.vm, func() func(vm *goja.Runtime) error {
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		return func(vm *goja.Runtime) error {
+			if err != nil {
+				reject(f.readError(b, err))
+				return nil
+			}
+			settle(data)
+			return nil
+		}
+	})
+
+	return f.vm.ToValue(promise)
+}
+
+// bindBody 为 Request/Response 绑定消息体读取方法
+func (f *FetchModule) bindBody(obj *goja.Object, b *fetchBody) {
+	obj.Set("text", func() goja.Value {
+		return f.consume(b, func(data []byte) (goja.Value, error) {
+			return f.vm.ToValue(string(data)), nil
+		})
+	})
+	obj.Set("json", func() goja.Value {
+		return f.consume(b, func(data []byte) (goja.Value, error) {
+			parse, _ := goja.AssertFunction(f.vm.Get("JSON").ToObject(f.vm).Get("parse"))
+			return parse(goja.Undefined(), f.vm.ToValue(string(data)))
+		})
+	})
+	obj.Set("arrayBuffer", func() goja.Value {
+		return f.consume(b, func(data []byte) (goja.Value, error) {
+			return f.vm.ToValue(f.vm.NewArrayBuffer(data)), nil
+		})
+	})
+	obj.Set("bytes", func() goja.Value {
+		return f.consume(b, func(data []byte) (goja.Value, error) {
+			return f.newUint8Array(data), nil
+		})
+	})
+
+	obj.DefineAccessorProperty("bodyUsed", f.vm.ToValue(func() bool {
+		return b.used
+	}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	obj.DefineAccessorProperty("body", f.vm.ToValue(func() goja.Value {
+		if !b.has {
+			return goja.Null()
+		}
+		if b.stream == nil {
+			b.stream = f.createBodyStream(b)
+		}
+		return b.stream
+	}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
+}
+
+// createBodyStream 创建 ReadableStream 风格的消息体流，支持 getReader() 与 for await
+func (f *FetchModule) createBodyStream(b *fetchBody) *goja.Object {
+	bodyStream := f.vm.NewObject()
+	locked := false
+	done := false
+
+	read := func() goja.Value {
+		promise, resolve, reject := f.vm.NewPromise()
+		result := func(value goja.Value, finished bool) *goja.Object {
+			obj := f.vm.NewObject()
+			obj.Set("value", value)
+			obj.Set("done", finished)
+			return obj
+		}
+
+		if done {
+			resolve(result(goja.Undefined(), true))
+			return f.vm.ToValue(promise)
+		}
+		b.used = true
+
+		if b.reader == nil {
+			done = true
+			if len(b.data) == 0 {
+				resolve(result(goja.Undefined(), true))
+			} else {
+				resolve(result(f.newUint8Array(b.data), false))
+			}
+			return f.vm.ToValue(promise)
+		}
+
+		reader := b.reader
+		stream.Async(f.vm, func() func(vm *goja.Runtime) error {
+			buf := make([]byte, fetchChunkSize)
+			n, err := reader.Read(buf)
+			if n == 0 && (err == nil || err == io.EOF) {
+				reader.Close()
+			}
+			return func(vm *goja.Runtime) error {
+				if n > 0 {
+					resolve(result(f.newUint8Array(buf[:n]), false))
+					return nil
+				}
+				if err != nil && err != io.EOF {
+					reject(f.readError(b, err))
+					return nil
+				}
+				done = true
+				resolve(result(goja.Undefined(), true))
+				return nil
+			}
+		})
+		return f.vm.ToValue(promise)
+	}
+
+	cancel := func() goja.Value {
+		done = true
+		b.used = true
+		if b.reader != nil {
+			b.reader.Close()
+		}
+		promise, resolve, _ := f.vm.NewPromise()
+		resolve(goja.Undefined())
+		return f.vm.ToValue(promise)
+	}
+
+	bodyStream.DefineAccessorProperty("locked", f.vm.ToValue(func() bool {
+		return locked
+	}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	bodyStream.Set("cancel", cancel)
+	bodyStream.Set("getReader", func() goja.Value {
+		if locked {
+			panic(f.vm.NewTypeError("ReadableStream is locked"))
+		}
+		locked = true
+		reader := f.vm.NewObject()
+		reader.Set("read", read)
+		reader.Set("cancel", cancel)
+		reader.Set("releaseLock", func() {
+			locked = false
+		})
+		return reader
+	})
+
+	// for await (const chunk of response.body)
+	if asyncIterator, ok := f.vm.Get("Symbol").ToObject(f.vm).Get("asyncIterator").(*goja.Symbol); ok {
+		bodyStream.SetSymbol(asyncIterator, func() goja.Value {
+			it := f.vm.NewObject()
+			it.Set("next", read)
+			it.Set("return", func() goja.Value {
+				cancel()
+				promise, resolve, _ := f.vm.NewPromise()
+				obj := f.vm.NewObject()
+				obj.Set("done", true)
+				resolve(obj)
+				return f.vm.ToValue(promise)
+			})
+			return it
+		})
+	}
+
+	return bodyStream
+}
+
+// ---------------------------------------------------------------------------
+// Request
+// ---------------------------------------------------------------------------
+
+// fetchRequest Request 对象的内部数据
+type fetchRequest struct {
+	url        string
+	method     string
+	headers    *fetchHeaders
+	headersObj *goja.Object
+	body       *fetchBody
+	signal     *abortSignal
+	redirect   string
+}
+
+// normalizeMethod 规范化请求方法
+func normalizeMethod(method string) string {
+	switch upper := strings.ToUpper(method); upper {
+	case "DELETE", "GET", "HEAD", "OPTIONS", "POST", "PUT", "PATCH":
+		return upper
+	}
+	return method
+}
+
+// newRequest Request 构造函数
+func (f *FetchModule) newRequest(call goja.ConstructorCall) *goja.Object {
+	input := call.Argument(0)
+	req := &fetchRequest{
+		method:   "GET",
+		headers:  &fetchHeaders{header: make(http.Header)},
+		body:     &fetchBody{},
+		redirect: "follow",
+	}
+
+	// 从已有 Request 复制
+	if source, ok := f.unwrap(input).(*fetchRequest); ok {
+		if source.body.used {
+			panic(f.vm.NewTypeError("Cannot construct a Request with a Request whose body has already been used"))
+		}
+		req.url = source.url
+		req.method = source.method
+		req.redirect = source.redirect
+		req.signal = source.signal
+		for key, values := range source.headers.header {
+			req.headers.header[key] = append([]string(nil), values...)
+		}
+		req.body = source.body.clone()
+	} else {
+		parsed, err := url.Parse(input.String())
+		if err != nil || !parsed.IsAbs() {
+			panic(f.vm.NewTypeError(fmt.Sprintf("Invalid URL: %s", input.String())))
+		}
+		req.url = parsed.String()
+	}
+
+	if init := call.Argument(1); !goja.IsUndefined(init) && !goja.IsNull(init) {
+		initObj := init.ToObject(f.vm)
+		if method := initObj.Get("method"); method != nil && !goja.IsUndefined(method) {
+			req.method = normalizeMethod(method.String())
+		}
+		if headers := initObj.Get("headers"); headers != nil && !goja.IsUndefined(headers) {
+			req.headers = &fetchHeaders{header: make(http.Header)}
+			f.fillHeaders(req.headers, headers)
+		}
+		if body := initObj.Get("body"); body != nil && !goja.IsUndefined(body) {
+			var contentType string
+			req.body, contentType = f.bodyFromValue(body)
+			if contentType != "" && req.headers.header.Get("Content-Type") == "" {
+				req.headers.header.Set("Content-Type", contentType)
+			}
+		}
+		if signal := initObj.Get("signal"); signal != nil && !goja.IsUndefined(signal) {
+			req.signal, _ = f.unwrap(signal).(*abortSignal)
+		}
+		if redirect := initObj.Get("redirect"); redirect != nil && !goja.IsUndefined(redirect) {
+			switch mode := redirect.String(); mode {
+			case "follow", "manual", "error":
+				req.redirect = mode
+			default:
+				panic(f.vm.NewTypeError(fmt.Sprintf("Invalid redirect mode: %s", mode)))
+			}
+		}
+	}
+
+	if req.body.has && (req.method == "GET" || req.method == "HEAD") {
+		panic(f.vm.NewTypeError("Request with GET/HEAD method cannot have body"))
+	}
+	if req.signal == nil {
+		req.signal = f.newSignal()
+	}
+	req.body.signal = req.signal
+
+	f.bindRequest(call.This, req)
+	return nil
+}
+
+// bindRequest 为 Request 对象绑定属性与方法
+func (f *FetchModule) bindRequest(obj *goja.Object, req *fetchRequest) {
+	obj.SetSymbol(f.internal, f.vm.ToValue(req))
+	req.headersObj = f.createHeaders(req.headers)
+
+	obj.Set("url", req.url)
+	obj.Set("method", req.method)
+	obj.Set("headers", req.headersObj)
+	obj.Set("signal", req.signal.obj)
+	obj.Set("redirect", req.redirect)
+	f.bindBody(obj, req.body)
+
+	obj.Set("clone", func() goja.Value {
+		if req.body.used {
+			panic(f.vm.NewTypeError("Request body is already used"))
+		}
+		cloned := &fetchRequest{
+			url:      req.url,
+			method:   req.method,
+			headers:  &fetchHeaders{header: req.headers.header.Clone()},
+			body:     req.body.clone(),
+			signal:   req.signal,
+			redirect: req.redirect,
+		}
+		clonedObj := f.vm.CreateObject(f.prototypeOf(f.requestCtor))
+		f.bindRequest(clonedObj, cloned)
+		return clonedObj
+	})
+}
+
+// ---------------------------------------------------------------------------
+// Response
+// ---------------------------------------------------------------------------
+
+// fetchResponse Response 对象的内部数据
+type fetchResponse struct {
+	status     int
+	statusText string
+	url        string
+	redirected bool
+	typ        string
+	headers    *fetchHeaders
+	body       *fetchBody
+}
+
+// newResponse Response 构造函数
+func (f *FetchModule) newResponse(call goja.ConstructorCall) *goja.Object {
+	resp := &fetchResponse{
+		status:  200,
+		typ:     "default",
+		headers: &fetchHeaders{header: make(http.Header)},
+	}
+	f.applyResponseInit(resp, call.Argument(1))
+
+	var contentType string
+	resp.body, contentType = f.bodyFromValue(call.Argument(0))
+	if contentType != "" && resp.headers.header.Get("Content-Type") == "" {
+		resp.headers.header.Set("Content-Type", contentType)
+	}
+	if resp.body.has && (resp.status == 101 || resp.status == 204 || resp.status == 205 || resp.status == 304) {
+		panic(f.vm.NewTypeError(fmt.Sprintf("Response with null body status %d cannot have body", resp.status)))
+	}
+
+	f.bindResponse(call.This, resp)
+	return nil
+}
+
+// applyResponseInit 解析 ResponseInit
+func (f *FetchModule) applyResponseInit(resp *fetchResponse, init goja.Value) {
+	if init == nil || goja.IsUndefined(init) || goja.IsNull(init) {
+		return
+	}
+	initObj := init.ToObject(f.vm)
+	if status := initObj.Get("status"); status != nil && !goja.IsUndefined(status) {
+		resp.status = int(status.ToInteger())
+		if resp.status < 200 || resp.status > 599 {
+			panic(f.vm.NewGoError(fmt.Errorf("RangeError: status %d is not in the range 200 to 599", resp.status)))
+		}
+	}
+	if statusText := initObj.Get("statusText"); statusText != nil && !goja.IsUndefined(statusText) {
+		resp.statusText = statusText.String()
+	}
+	if headers := initObj.Get("headers"); headers != nil && !goja.IsUndefined(headers) {
+		f.fillHeaders(resp.headers, headers)
+	}
+}
+
+// createResponse 从 Go 侧创建 Response 对象
+func (f *FetchModule) createResponse(resp *fetchResponse) *goja.Object {
+	obj := f.vm.CreateObject(f.prototypeOf(f.responseCtor))
+	f.bindResponse(obj, resp)
+	return obj
+}
+
+// bindResponse 为 Response 对象绑定属性与方法
+func (f *FetchModule) bindResponse(obj *goja.Object, resp *fetchResponse) {
+	obj.SetSymbol(f.internal, f.vm.ToValue(resp))
+
+	obj.Set("status", resp.status)
+	obj.Set("statusText", resp.statusText)
+	obj.Set("ok", resp.status >= 200 && resp.status <= 299)
+	obj.Set("headers", f.createHeaders(resp.headers))
+	obj.Set("url", resp.url)
+	obj.Set("redirected", resp.redirected)
+	obj.Set("type", resp.typ)
+	f.bindBody(obj, resp.body)
+
+	obj.Set("clone", func() goja.Value {
+		if resp.body.used {
+			panic(f.vm.NewTypeError("Response body is already used"))
+		}
+		cloned := *resp
+		cloned.headers = &fetchHeaders{header: resp.headers.header.Clone(), immutable: resp.headers.immutable}
+		cloned.body = resp.body.clone()
+		return f.createResponse(&cloned)
+	})
+}
+
+// responseJSON Response.json(data, init)
+func (f *FetchModule) responseJSON(call goja.FunctionCall) goja.Value {
+	stringify, _ := goja.AssertFunction(f.vm.Get("JSON").ToObject(f.vm).Get("stringify"))
+	text, err := stringify(goja.Undefined(), call.Argument(0))
+	if err != nil {
+		panic(err)
+	}
+	if goja.IsUndefined(text) {
+		panic(f.vm.NewTypeError("Response.json: data is not JSON serializable"))
+	}
+
+	resp := &fetchResponse{
+		status:  200,
+		typ:     "default",
+		headers: &fetchHeaders{header: make(http.Header)},
+	}
+	f.applyResponseInit(resp, call.Argument(1))
+	resp.body = &fetchBody{has: true, data: []byte(text.String())}
+	if resp.headers.header.Get("Content-Type") == "" {
+		resp.headers.header.Set("Content-Type", "application/json")
+	}
+	return f.createResponse(resp)
+}
+
+// responseError Response.error()
+func (f *FetchModule) responseError(call goja.FunctionCall) goja.Value {
+	return f.createResponse(&fetchResponse{
+		typ:     "error",
+		headers: &fetchHeaders{header: make(http.Header), immutable: true},
+		body:    &fetchBody{},
+	})
+}
+
+// responseRedirect Response.redirect(url, status)
+func (f *FetchModule) responseRedirect(call goja.FunctionCall) goja.Value {
+	status := 302
+	if !goja.IsUndefined(call.Argument(1)) {
+		status = int(call.Argument(1).ToInteger())
+	}
+	switch status {
+	case 301, 302, 303, 307, 308:
+	default:
+		panic(f.vm.NewGoError(fmt.Errorf("RangeError: invalid redirect status %d", status)))
+	}
+	headers := &fetchHeaders{header: make(http.Header), immutable: true}
+	headers.header.Set("Location", call.Argument(0).String())
+	return f.createResponse(&fetchResponse{
+		status:  status,
+		typ:     "default",
+		headers: headers,
+		body:    &fetchBody{},
+	})
+}
+
+// ---------------------------------------------------------------------------
+// AbortController / AbortSignal
+// ---------------------------------------------------------------------------
+
+// abortSignal AbortSignal 对象的内部数据
+type abortSignal struct {
+	f         *FetchModule
+	obj       *goja.Object
+	mu        sync.Mutex
+	aborted   bool
+	reason    goja.Value
+	listeners []goja.Value
+	cancels   map[int]context.CancelFunc
+	nextID    int
+}
+
+// newSignal 创建 AbortSignal
+func (f *FetchModule) newSignal() *abortSignal {
+	s := &abortSignal{
+		f:       f,
+		obj:     f.vm.CreateObject(f.prototypeOf(f.signalCtor)),
+		reason:  goja.Undefined(),
+		cancels: make(map[int]context.CancelFunc),
+	}
+	obj := s.obj
+	obj.SetSymbol(f.internal, f.vm.ToValue(s))
+	obj.Set("onabort", goja.Null())
+
+	obj.DefineAccessorProperty("aborted", f.vm.ToValue(func() bool {
+		return s.isAborted()
+	}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	obj.DefineAccessorProperty("reason", f.vm.ToValue(func() goja.Value {
+		return s.reason
+	}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
+
+	obj.Set("addEventListener", func(eventType string, listener goja.Value) {
+		if eventType == "abort" {
+			if _, ok := goja.AssertFunction(listener); ok {
+				s.listeners = append(s.listeners, listener)
+			}
+		}
+	})
+	obj.Set("removeEventListener", func(eventType string, listener goja.Value) {
+		if eventType != "abort" {
+			return
+		}
+		for i, existing := range s.listeners {
+			if existing.SameAs(listener) {
+				s.listeners = append(s.listeners[:i], s.listeners[i+1:]...)
+				return
+			}
+		}
+	})
+	obj.Set("throwIfAborted", func() {
+		if s.isAborted() {
+			panic(f.vm.ToValue(s.reason))
+		}
+	})
+	return s
+}
+
+// isAborted 是否已中止
+func (s *abortSignal) isAborted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.aborted
+}
+
+// onCancel 注册中止时取消的请求上下文，返回注销函数
+func (s *abortSignal) onCancel(cancel context.CancelFunc) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.nextID
+	s.nextID++
+	s.cancels[id] = cancel
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.cancels, id)
+	}
+}
+
+// abort 中止信号：取消关联请求并派发 abort 事件
+func (s *abortSignal) abort(reason goja.Value) {
+	s.mu.Lock()
+	if s.aborted {
+		s.mu.Unlock()
+		return
+	}
+	if reason == nil || goja.IsUndefined(reason) {
+		reason = s.f.newError("AbortError", "This operation was aborted")
+	}
+	s.aborted = true
+	s.reason = reason
+	cancels := s.cancels
+	s.cancels = make(map[int]context.CancelFunc)
+	s.mu.Unlock()
+
+	for _, cancel := range cancels {
+		cancel()
+	}
+
+	event := s.f.vm.NewObject()
+	event.Set("type", "abort")
+	event.Set("target", s.obj)
+	if onabort, ok := goja.AssertFunction(s.obj.Get("onabort")); ok {
+		onabort(s.obj, event)
+	}
+	for _, listener := range append([]goja.Value(nil), s.listeners...) {
+		if fn, ok := goja.AssertFunction(listener); ok {
+			fn(s.obj, event)
+		}
+	}
+}
+
+// newAbortController AbortController 构造函数
+func (f *FetchModule) newAbortController(call goja.ConstructorCall) *goja.Object {
+	signal := f.newSignal()
+	call.This.Set("signal", signal.obj)
+	call.This.Set("abort", func(c goja.FunctionCall) goja.Value {
+		signal.abort(c.Argument(0))
+		return goja.Undefined()
+	})
+	return nil
+}
+
+// signalAbort AbortSignal.abort(reason) 返回已中止的信号
+func (f *FetchModule) signalAbort(call goja.FunctionCall) goja.Value {
+	signal := f.newSignal()
+	signal.abort(call.Argument(0))
+	return signal.obj
+}
+
+// signalTimeout AbortSignal.timeout(ms) 在指定时间后以 TimeoutError 中止
+// 通过全局 setTimeout 调度，保证回调在事件循环中执行
+func (f *FetchModule) signalTimeout(call goja.FunctionCall) goja.Value {
+	signal := f.newSignal()
+	setTimeout, ok := goja.AssertFunction(f.vm.Get("setTimeout"))
+	if !ok {
+		panic(f.vm.NewTypeError("AbortSignal.timeout requires setTimeout"))
+	}
+	callback := func() {
+		signal.abort(f.newError("TimeoutError", "The operation timed out"))
+	}
+	if _, err := setTimeout(goja.Undefined(), f.vm.ToValue(callback), call.Argument(0)); err != nil {
+		panic(err)
+	}
+	return signal.obj
+}
+
+// signalAny AbortSignal.any(signals) 任一信号中止时中止
+func (f *FetchModule) signalAny(call goja.FunctionCall) goja.Value {
+	signal := f.newSignal()
+	list := call.Argument(0).ToObject(f.vm)
+	length := int(list.Get("length").ToInteger())
+	for i := 0; i < length; i++ {
+		source, ok := f.unwrap(list.Get(fmt.Sprint(i))).(*abortSignal)
+		if !ok {
+			continue
+		}
+		if source.isAborted() {
+			signal.abort(source.reason)
+			break
+		}
+		source.listeners = append(source.listeners, f.vm.ToValue(func() {
+			signal.abort(source.reason)
+		}))
+	}
+	return signal.obj
+}
+
+// ---------------------------------------------------------------------------
+// fetch
+// ---------------------------------------------------------------------------
+
+// fetchClient 返回 fetch 使用的 HTTP 客户端：不设整体超时（由 AbortSignal 控制），
+// 每次重定向同样经过 URLValidator 校验
+func (f *FetchModule) fetchClient(redirect string) *http.Client {
+	client := *f.client.client
+	client.Timeout = 0
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		switch redirect {
+		case "manual":
+			return http.ErrUseLastResponse
+		case "error":
+			return fmt.Errorf("redirect mode is set to error")
+		}
+		if len(via) >= 20 {
+			return fmt.Errorf("too many redirects")
+		}
+		return f.client.validateURL(req.URL.String())
+	}
+	return &client
+}
+
+// requestError 将请求失败转换为 TypeError，权限不足时为 PermissionDenied
+func (f *FetchModule) requestError(err error) *goja.Object {
+	var denied *security.PermissionDeniedError
+	if errors.As(err, &denied) {
+		return types.NewPermissionError(f.vm, err)
+	}
+	return f.vm.NewTypeError(fmt.Sprintf("fetch failed: %v", err))
+}
+
+// fetch 全局 fetch(input, init)
+func (f *FetchModule) fetch(call goja.FunctionCall) goja.Value {
+	promise, resolve, reject := f.vm.NewPromise()
+
+	reqObj, err := f.vm.New(f.requestCtor, call.Arguments...)
+	if err != nil {
+		reject(f.exceptionValue(err))
+		return f.vm.ToValue(promise)
+	}
+	req := f.unwrap(reqObj).(*fetchRequest)
+
+	if req.signal.isAborted() {
+		reject(req.signal.reason)
+		return f.vm.ToValue(promise)
+	}
+
+	// 复用 HTTPModule 的 URL 校验与请求拦截器
+	config := &HTTPConfig{
+		Method:  req.method,
+		URL:     req.url,
+		Headers: req.headers.toMap(),
+		Params:  make(map[string]string),
+	}
+	if req.body.has {
+		config.Data = string(req.body.data)
+	}
+	if err := f.client.prepareRequest(config); err != nil {
+		reject(f.requestError(err))
+		return f.vm.ToValue(promise)
+	}
+
+	var body io.Reader
+	switch data := config.Data.(type) {
+	case nil:
+	case string:
+		body = strings.NewReader(data)
+	default:
+		reject(f.vm.NewTypeError("fetch failed: request interceptor must return string data"))
+		return f.vm.ToValue(promise)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	unregister := req.signal.onCancel(cancel)
+
+	httpReq, err := http.NewRequestWithContext(ctx, config.Method, config.URL, body)
+	if err != nil {
+		unregister()
+		cancel()
+		reject(f.vm.NewTypeError(fmt.Sprintf("fetch failed: %v", err)))
+		return f.vm.ToValue(promise)
+	}
+	if len(config.Params) > 0 {
+		q := httpReq.URL.Query()
+		for key, value := range config.Params {
+			q.Add(key, value)
+		}
+		httpReq.URL.RawQuery = q.Encode()
+	}
+	for key, value := range config.Headers {
+		httpReq.Header.Set(key, value)
+	}
+
+	client := f.fetchClient(req.redirect)
+	signal := req.signal
+
+	// 只在 goroutine 中执行网络请求，响应拦截器、Response 对象的创建与 promise 的 settle
+	// 都在事件循环中进行（goja.Runtime 不是并发安全的）
+	stream.Async(f.vm, func() func(vm *goja.Runtime) error {
+		resp, err := client.Do(httpReq)
+		return func(vm *goja.Runtime) error {
+			if err != nil {
+				unregister()
+				cancel()
+				if signal.isAborted() {
+					reject(signal.reason)
+					return nil
+				}
+				reject(f.requestError(err))
+				return nil
+			}
+
+			// 全局响应拦截器可改写响应头
+			intercepted := &HTTPResponse{
+				Status:     resp.StatusCode,
+				StatusText: resp.Status,
+				Headers:    make(map[string]string),
+				URL:        resp.Request.URL.String(),
+				Config:     make(map[string]interface{}),
+			}
+			for key, values := range resp.Header {
+				intercepted.Headers[key] = strings.Join(values, ", ")
+			}
+			headers := resp.Header
+			if f.client.responseInterceptor != nil {
+				f.client.applyResponseInterceptor(intercepted)
+				headers = make(http.Header)
+				for key, value := range intercepted.Headers {
+					headers.Set(key, value)
+				}
+			}
+
+			fetchResp := &fetchResponse{
+				status:     resp.StatusCode,
+				statusText: strings.TrimSpace(strings.TrimPrefix(resp.Status, fmt.Sprint(resp.StatusCode))),
+				url:        resp.Request.URL.String(),
+				redirected: resp.Request.URL.String() != req.url,
+				typ:        "basic",
+				headers:    &fetchHeaders{header: headers, immutable: true},
+				body:       &fetchBody{signal: signal},
+			}
+
+			if config.Method == "HEAD" || resp.StatusCode == 204 || resp.StatusCode == 304 {
+				resp.Body.Close()
+				unregister()
+				cancel()
+			} else {
+				fetchResp.body.has = true
+				fetchResp.body.reader = &fetchBodyReader{ReadCloser: resp.Body, done: func() {
+					unregister()
+					cancel()
+				}}
+			}
+
+			resolve(f.createResponse(fetchResp))
+			return nil
+		}
+	})
+
+	return f.vm.ToValue(promise)
+}
+
+// fetchBodyReader 关闭响应体时释放请求上下文
+type fetchBodyReader struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+func (r *fetchBodyReader) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.done)
+	return err
+}
@@ -1,7 +1,8 @@
 package http
 
 import (
-	"sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/security"
 
 	"github.com/dop251/goja"
 )
@@ -10,13 +11,16 @@ type Namespace struct {
 	vm     *goja.Runtime
 	client *HTTPModule
 	server *HTTPServerModule
+	fetch  *FetchModule
 }
 
-func NewNamespace(vm *goja.Runtime) *Namespace {
+func NewNamespace(vm *goja.Runtime, guard *security.Guard) *Namespace {
+	client := NewHTTPModule(vm, guard)
 	return &Namespace{
 		vm:     vm,
-		client: NewHTTPModule(vm),
-		server: NewHTTPServerModule(vm),
+		client: client,
+		server: NewHTTPServerModule(vm, guard),
+		fetch:  NewFetchModule(vm, client),
 	}
 }
 
@@ -29,6 +33,9 @@ func (h *Namespace) GetModule() *goja.Object {
 	serverObj := h.server.GetModule()
 	obj.Set("server", serverObj)
 
+	fetchObj := h.fetch.GetModule()
+	obj.Set("fetch", fetchObj)
+
 	return obj
 }
 
@@ -38,6 +45,23 @@ func (h *Namespace) GetSubModule(name string) (types.BuiltinModule, bool) {
 		return h.client, true
 	case "server":
 		return h.server, true
+	case "fetch":
+		return h.fetch, true
 	}
 	return nil, false
 }
+
+// Reset 清空 HTTP 服务器模块的共享存储
+func (h *Namespace) Reset() {
+	h.server.Reset()
+}
+
+// Server 获取 HTTP 服务器模块
+func (h *Namespace) Server() *HTTPServerModule {
+	return h.server
+}
+
+// Fetch 获取 fetch 模块，用于安装全局 fetch API
+func (h *Namespace) Fetch() *FetchModule {
+	return h.fetch
+}
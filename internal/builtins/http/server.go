@@ -18,7 +18,13 @@ import (
 	"github.com/dop251/goja"
 	"github.com/gorilla/websocket"
 
-	"sw_runtime/internal/consts"
+	"github.com/issueye/sw_runtime/internal/builtins/buffer"
+	"github.com/issueye/sw_runtime/internal/builtins/events"
+	"github.com/issueye/sw_runtime/internal/builtins/stream"
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/consts"
+	"github.com/issueye/sw_runtime/internal/security"
+	"github.com/issueye/sw_runtime/internal/sourcemap"
 )
 
 // 全局变量，标记是否有 HTTP 服务器在运行
@@ -39,6 +45,18 @@ func IsHTTPServerRunning() bool {
 	return len(serverRegistry.servers) > 0
 }
 
+// HasHTTPServers 检查指定 VM 是否有 HTTP 服务器在运行
+func HasHTTPServers(vm *goja.Runtime) bool {
+	serverRegistry.RLock()
+	defer serverRegistry.RUnlock()
+	for s := range serverRegistry.servers {
+		if s.vm == vm {
+			return true
+		}
+	}
+	return false
+}
+
 // registerServer 注册服务器
 func registerServer(s *HTTPServer) {
 	serverRegistry.Lock()
@@ -55,10 +73,22 @@ func unregisterServer(s *HTTPServer) {
 
 // CloseAllHTTPServers 关闭所有注册的 HTTP 服务器
 func CloseAllHTTPServers() {
+	closeHTTPServers(nil)
+}
+
+// CloseHTTPServers 关闭指定 VM 创建的 HTTP 服务器（Worker 退出时不影响其他 VM 的服务器）
+func CloseHTTPServers(vm *goja.Runtime) {
+	closeHTTPServers(vm)
+}
+
+// closeHTTPServers 关闭注册的 HTTP 服务器，vm 为 nil 时关闭全部
+func closeHTTPServers(vm *goja.Runtime) {
 	serverRegistry.Lock()
 	servers := make([]*HTTPServer, 0, len(serverRegistry.servers))
 	for s := range serverRegistry.servers {
-		servers = append(servers, s)
+		if vm == nil || s.vm == vm {
+			servers = append(servers, s)
+		}
 	}
 	serverRegistry.Unlock()
 
@@ -89,6 +119,9 @@ type HTTPServerModule struct {
 	vm      *goja.Runtime
 	servers map[string]*HTTPServer
 	mutex   sync.RWMutex
+	guard   *security.Guard
+	spawner WorkerSpawner // 多 VM 模式下创建工作 VM
+	shared  *SharedStore  // 跨 VM 共享存储，工作 VM 与 Worker 线程使用父 VM 的存储
 }
 
 // HTTPServer HTTP 服务器实例
@@ -112,6 +145,10 @@ type HTTPServer struct {
 	idleTimeout       time.Duration
 	readHeaderTimeout time.Duration
 	maxHeaderBytes    int
+	requestTimeout    time.Duration // 请求进入 VM 处理队列的等待超时
+
+	// 多 VM 模式的工作 VM 池
+	pool *serverPool
 
 	// 请求处理队列（用于保护 goja.Runtime 并发访问，通过事件队列处理）
 	requestChan chan func(*goja.Runtime)
@@ -180,13 +217,35 @@ func (p *routePattern) match(path string) (map[string]string, bool) {
 }
 
 // NewHTTPServerModule 创建 HTTP 服务器模块
-func NewHTTPServerModule(vm *goja.Runtime) *HTTPServerModule {
+func NewHTTPServerModule(vm *goja.Runtime, guard *security.Guard) *HTTPServerModule {
 	return &HTTPServerModule{
 		vm:      vm,
 		servers: make(map[string]*HTTPServer),
+		guard:   guard,
+		shared:  NewSharedStore(),
 	}
 }
 
+// SetWorkerSpawner 设置多 VM 模式下创建工作 VM 的函数
+func (h *HTTPServerModule) SetWorkerSpawner(spawner WorkerSpawner) {
+	h.spawner = spawner
+}
+
+// SetSharedStore 使用父 VM 的共享存储，需要在脚本加载 http/server 之前调用
+func (h *HTTPServerModule) SetSharedStore(store *SharedStore) {
+	h.shared = store
+}
+
+// SharedStore 获取共享存储
+func (h *HTTPServerModule) SharedStore() *SharedStore {
+	return h.shared
+}
+
+// Reset 清空共享存储，服务器由 CloseHTTPServers 关闭
+func (h *HTTPServerModule) Reset() {
+	h.shared.Clear()
+}
+
 // GetModule 获取 HTTP 服务器模块对象
 func (h *HTTPServerModule) GetModule() *goja.Object {
 	obj := h.vm.NewObject()
@@ -195,6 +254,12 @@ func (h *HTTPServerModule) GetModule() *goja.Object {
 	obj.Set("createServer", h.createServer)
 	obj.Set("Server", h.createServer) // 别名
 
+	// 跨 VM 共享存储
+	obj.Set("shared", newSharedStoreObject(h.vm, h.shared))
+
+	// 当前 VM 是否为多 VM 服务器的工作 VM
+	obj.Set("isWorker", poolOf(h.vm) != nil)
+
 	// 状态码常量
 	statusCodes := h.vm.NewObject()
 	statusCodes.Set("OK", 200)
@@ -231,6 +296,7 @@ func (h *HTTPServerModule) createServer(call goja.FunctionCall) goja.Value {
 		idleTimeout:       consts.DefaultIdleTimeout,
 		readHeaderTimeout: 10 * time.Second,
 		maxHeaderBytes:    consts.MaxHeaderSize,
+		requestTimeout:    consts.DefaultHTTPTimeout,
 	}
 
 	// 初始化路由列表
@@ -243,32 +309,27 @@ func (h *HTTPServerModule) createServer(call goja.FunctionCall) goja.Value {
 	if len(call.Arguments) > 0 && call.Arguments[0] != goja.Undefined() && call.Arguments[0] != goja.Null() {
 		configObj := call.Arguments[0].ToObject(h.vm)
 		if configObj != nil {
-			// 读取超时配置（秒）
-			if readTimeout := configObj.Get("readTimeout"); readTimeout != nil && readTimeout != goja.Undefined() {
-				if seconds, ok := readTimeout.Export().(int64); ok {
-					server.readTimeout = time.Duration(seconds) * time.Second
-				}
+			// 读取超时配置：数字为秒（可为小数），字符串为时长（如 "500ms"、"2s"）
+			if timeout, ok := timeoutOption(configObj.Get("readTimeout")); ok {
+				server.readTimeout = timeout
 			}
-			if writeTimeout := configObj.Get("writeTimeout"); writeTimeout != nil && writeTimeout != goja.Undefined() {
-				if seconds, ok := writeTimeout.Export().(int64); ok {
-					server.writeTimeout = time.Duration(seconds) * time.Second
-				}
+			if timeout, ok := timeoutOption(configObj.Get("writeTimeout")); ok {
+				server.writeTimeout = timeout
 			}
-			if idleTimeout := configObj.Get("idleTimeout"); idleTimeout != nil && idleTimeout != goja.Undefined() {
-				if seconds, ok := idleTimeout.Export().(int64); ok {
-					server.idleTimeout = time.Duration(seconds) * time.Second
-				}
+			if timeout, ok := timeoutOption(configObj.Get("idleTimeout")); ok {
+				server.idleTimeout = timeout
 			}
-			if readHeaderTimeout := configObj.Get("readHeaderTimeout"); readHeaderTimeout != nil && readHeaderTimeout != goja.Undefined() {
-				if seconds, ok := readHeaderTimeout.Export().(int64); ok {
-					server.readHeaderTimeout = time.Duration(seconds) * time.Second
-				}
+			if timeout, ok := timeoutOption(configObj.Get("readHeaderTimeout")); ok {
+				server.readHeaderTimeout = timeout
 			}
 			if maxHeaderBytes := configObj.Get("maxHeaderBytes"); maxHeaderBytes != nil && maxHeaderBytes != goja.Undefined() {
 				if bytes, ok := maxHeaderBytes.Export().(int64); ok {
 					server.maxHeaderBytes = int(bytes)
 				}
 			}
+			if timeout, ok := timeoutOption(configObj.Get("requestTimeout")); ok {
+				server.requestTimeout = timeout
+			}
 		}
 	}
 
@@ -288,6 +349,23 @@ func (h *HTTPServerModule) createServer(call goja.FunctionCall) goja.Value {
 	return h.createServerObject(server)
 }
 
+// timeoutOption 解析超时配置：数字按秒计算（可为小数），字符串按 Go 时长格式解析（如 "500ms"、"1.5s"）
+func timeoutOption(value goja.Value) (time.Duration, bool) {
+	if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+		return 0, false
+	}
+	switch v := value.Export().(type) {
+	case int64:
+		return time.Duration(v) * time.Second, v >= 0
+	case float64:
+		return time.Duration(v * float64(time.Second)), v >= 0
+	case string:
+		d, err := time.ParseDuration(v)
+		return d, err == nil && d >= 0
+	}
+	return 0, false
+}
+
 // checkWebSocketOrigin 检查 WebSocket 请求的来源是否允许
 func (s *HTTPServer) checkWebSocketOrigin(r *http.Request) bool {
 	// 如果明确允许所有来源（仅用于开发环境）
@@ -416,10 +494,28 @@ func (s *HTTPServer) Close() {
 		cancel()
 	}
 
+	// 停止工作 VM
+	if s.pool != nil {
+		s.pool.close()
+	}
+
 	// 从注册表中移除
 	unregisterServer(s)
 }
 
+// newHTTPServer 创建绑定到指定地址的 http.Server
+func (s *HTTPServer) newHTTPServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       s.readTimeout,
+		WriteTimeout:      s.writeTimeout,
+		IdleTimeout:       s.idleTimeout,
+		ReadHeaderTimeout: s.readHeaderTimeout,
+		MaxHeaderBytes:    s.maxHeaderBytes,
+	}
+}
+
 // createSetWSAllowedOrigins 创建设置允许来源的方法
 func (h *HTTPServerModule) createSetWSAllowedOrigins(server *HTTPServer) func(goja.FunctionCall) goja.Value {
 	return func(call goja.FunctionCall) goja.Value {
@@ -609,6 +705,10 @@ func (h *HTTPServerModule) createStaticHandler(server *HTTPServer) func(goja.Fun
 			prefix = call.Arguments[1].String()
 		}
 
+		if err := h.guard.CheckRead(dir); err != nil {
+			panic(types.NewPermissionError(h.vm, err))
+		}
+
 		fileServer := http.FileServer(http.Dir(dir))
 		server.mux.Handle(prefix, http.StripPrefix(prefix, fileServer))
 
@@ -627,12 +727,20 @@ func (h *HTTPServerModule) createListenHandler(server *HTTPServer) func(goja.Fun
 		if !strings.Contains(port, ":") {
 			port = ":" + port
 		}
+		if err := h.guard.CheckAddr(port); err != nil {
+			return types.RejectPermission(h.vm, err)
+		}
 
-		var callback goja.Value
-		if len(call.Arguments) > 1 {
-			if _, ok := goja.AssertFunction(call.Arguments[1]); ok {
-				callback = call.Arguments[1]
-			}
+		callback, workers := parseListenOptions(call.Arguments[1:])
+
+		// 工作 VM 中加入父服务器的池，不绑定端口
+		if pool := poolOf(h.vm); pool != nil {
+			return h.joinPool(server, pool, port, callback)
+		}
+		if workers > 1 {
+			return h.listenWorkers(server, port, workers, callback, "Server", func() error {
+				return server.server.ListenAndServe()
+			})
 		}
 
 		promise, resolve, reject := h.vm.NewPromise()
@@ -641,15 +749,7 @@ func (h *HTTPServerModule) createListenHandler(server *HTTPServer) func(goja.Fun
 		registerServer(server)
 
 		go func() {
-			server.server = &http.Server{
-				Addr:              port,
-				Handler:           server.mux,
-				ReadTimeout:       server.readTimeout,
-				WriteTimeout:      server.writeTimeout,
-				IdleTimeout:       server.idleTimeout,
-				ReadHeaderTimeout: server.readHeaderTimeout,
-				MaxHeaderBytes:    server.maxHeaderBytes,
-			}
+			server.server = server.newHTTPServer(port, server.mux)
 
 			h.mutex.Lock()
 			h.servers[port] = server
@@ -730,29 +830,34 @@ func (h *HTTPServerModule) createListenTLSHandler(server *HTTPServer) func(goja.
 
 		certFile := call.Arguments[1].String()
 		keyFile := call.Arguments[2].String()
-
-		var callback goja.Value
-		if len(call.Arguments) > 3 {
-			if _, ok := goja.AssertFunction(call.Arguments[3]); ok {
-				callback = call.Arguments[3]
+		if err := h.guard.CheckAddr(port); err != nil {
+			return types.RejectPermission(h.vm, err)
+		}
+		for _, file := range []string{certFile, keyFile} {
+			if err := h.guard.CheckRead(file); err != nil {
+				return types.RejectPermission(h.vm, err)
 			}
 		}
 
+		callback, workers := parseListenOptions(call.Arguments[3:])
+
+		// 工作 VM 中加入父服务器的池，不绑定端口
+		if pool := poolOf(h.vm); pool != nil {
+			return h.joinPool(server, pool, port, callback)
+		}
+		if workers > 1 {
+			return h.listenWorkers(server, port, workers, callback, "HTTPS Server", func() error {
+				return server.server.ListenAndServeTLS(certFile, keyFile)
+			})
+		}
+
 		promise, resolve, reject := h.vm.NewPromise()
 
 		// 注册服务器
 		registerServer(server)
 
 		go func() {
-			server.server = &http.Server{
-				Addr:              port,
-				Handler:           server.mux,
-				ReadTimeout:       server.readTimeout,
-				WriteTimeout:      server.writeTimeout,
-				IdleTimeout:       server.idleTimeout,
-				ReadHeaderTimeout: server.readHeaderTimeout,
-				MaxHeaderBytes:    server.maxHeaderBytes,
-			}
+			server.server = server.newHTTPServer(port, server.mux)
 
 			h.mutex.Lock()
 			h.servers[port] = server
@@ -834,7 +939,10 @@ func (h *HTTPServerModule) createCloseHandler(server *HTTPServer) func(goja.Func
 				}
 			}
 
-			// 2. 停止 VM 处理器
+			// 2. 停止工作 VM 与 VM 处理器
+			if server.pool != nil {
+				server.pool.close()
+			}
 			server.stopVMProcessor()
 
 			// 3. 等待所有 goroutine 完成
@@ -934,7 +1042,10 @@ func (h *HTTPServerModule) createHTTPHandler(server *HTTPServer, method, path st
 		rw := &responseWriter{
 			ResponseWriter: w,
 			statusCode:     200,
+			finished:       make(chan struct{}),
 		}
+		var reqObj, resObj *goja.Object
+		waiting := false
 
 		// 使用 channel 等待处理完成
 		done := make(chan struct{})
@@ -945,7 +1056,7 @@ func (h *HTTPServerModule) createHTTPHandler(server *HTTPServer, method, path st
 			defer close(done)
 			defer func() {
 				if r := recover(); r != nil {
-					fmt.Printf("Handler panic at %s: %v\n", path, r)
+					fmt.Printf("Handler panic at %s: %s\n", path, sourcemap.FormatError(r))
 					if !rw.written {
 						// 可以根据环境变量判断是否显示详细信息，这里先默认显示简略信息
 						http.Error(w, "Internal Server Error", http.StatusInternalServerError)
@@ -953,16 +1064,16 @@ func (h *HTTPServerModule) createHTTPHandler(server *HTTPServer, method, path st
 				}
 			}()
 
-			// 在 VM goroutine 中创建请求和响应对象
-			reqObj := h.createRequestObject(r)
+			// 在 VM goroutine 中创建请求和响应对象（请求体与响应体均为流）
+			reqObj = h.createRequestObject(r, server.schedule)
 			if params != nil {
 				// 将路径参数注入到 req.params
-				pObj := reqObj.ToObject(vm).Get("params").ToObject(vm)
+				pObj := reqObj.Get("params").ToObject(vm)
 				for k, v := range params {
 					pObj.Set(k, v)
 				}
 			}
-			resObj := h.createResponseObjectWithWrapper(rw, r)
+			resObj = h.createResponseObjectWithWrapper(rw, r, server.schedule)
 
 			// 执行中间件链
 			middlewareIndex := 0
@@ -971,9 +1082,14 @@ func (h *HTTPServerModule) createHTTPHandler(server *HTTPServer, method, path st
 				if middlewareIndex >= len(middleware) {
 					// 所有中间件执行完毕，执行路由处理器
 					if fn, ok := goja.AssertFunction(handler); ok {
-						_, err := fn(goja.Undefined(), reqObj, resObj)
-						if err != nil && !rw.written {
-							http.Error(w, "Handler error: "+err.Error(), http.StatusInternalServerError)
+						result, err := fn(goja.Undefined(), reqObj, resObj)
+						if err != nil {
+							fmt.Printf("Handler error at %s: %s\n", path, sourcemap.FormatError(err))
+							if !rw.written {
+								http.Error(w, "Handler error: "+err.Error(), http.StatusInternalServerError)
+							}
+						} else if h.watchResult(rw, result, path) {
+							waiting = true
 						}
 					}
 					return
@@ -985,8 +1101,11 @@ func (h *HTTPServerModule) createHTTPHandler(server *HTTPServer, method, path st
 				if fn, ok := goja.AssertFunction(mw); ok {
 					nextFunc := vm.ToValue(executeNext)
 					_, err := fn(goja.Undefined(), reqObj, resObj, nextFunc)
-					if err != nil && !rw.written {
-						http.Error(w, "Middleware error", http.StatusInternalServerError)
+					if err != nil {
+						fmt.Printf("Middleware error at %s: %s\n", path, sourcemap.FormatError(err))
+						if !rw.written {
+							http.Error(w, "Middleware error", http.StatusInternalServerError)
+						}
 					}
 				}
 			}
@@ -998,13 +1117,33 @@ func (h *HTTPServerModule) createHTTPHandler(server *HTTPServer, method, path st
 				w.WriteHeader(rw.statusCode)
 				w.Write(rw.body)
 			}
+
+			// 流式响应或异步处理器在响应结束后再释放请求，否则立即释放请求体和响应体流
+			waiting = waiting || rw.streaming
+			if !waiting {
+				stream.Destroy(vm, reqObj, nil)
+				stream.Destroy(vm, resObj, nil)
+			}
 		}:
 			// 等待处理完成
 			<-done
-		case <-time.After(30 * time.Second):
+		case <-time.After(server.requestTimeout):
 			// 超时处理
 			http.Error(w, "Request processing timeout", http.StatusRequestTimeout)
 		}
+
+		if waiting {
+			// 等待响应结束或客户端断开连接
+			select {
+			case <-rw.finished:
+			case <-r.Context().Done():
+			}
+			rw.close()
+			server.schedule(func(vm *goja.Runtime) error {
+				stream.Destroy(vm, reqObj, nil)
+				return stream.Destroy(vm, resObj, nil)
+			})
+		}
 	}
 }
 
@@ -1014,11 +1153,16 @@ type responseWriter struct {
 	statusCode int
 	body       []byte
 	written    bool
+	streaming  bool          // 通过 write/end 或 pipe 流式发送响应
+	mu         sync.Mutex    // 保护 closed 和流式写入
+	closed     bool          // 处理器已返回，不再允许写入
+	finished   chan struct{} // 流式或异步响应结束时关闭
+	finishOnce sync.Once
 }
 
-// createRequestObject 创建请求对象 (增强版)
-func (h *HTTPServerModule) createRequestObject(r *http.Request) goja.Value {
-	obj := h.vm.NewObject()
+// createRequestObject 创建请求对象 (增强版)，请求对象本身是读取请求体的 Readable 流
+func (h *HTTPServerModule) createRequestObject(r *http.Request, schedule stream.Scheduler) *goja.Object {
+	obj := stream.NewReadableOn(h.vm, schedule, r.Body, nil)
 
 	// 1. 基本信息
 	obj.Set("method", r.Method)
@@ -1088,39 +1232,64 @@ func (h *HTTPServerModule) createRequestObject(r *http.Request) goja.Value {
 	}
 	obj.Set("params", params)
 
-	// 8. Body 解析增强
-	if r.Body != nil {
-		// 注意：io.ReadAll 会消耗 Body，如果后续需要重新读取，这里需要处理
-		// 目前方案是解析后存入对象。对于大 Body，可能需要流式支持
-		body, err := io.ReadAll(r.Body)
-		if err == nil {
-			bodyStr := string(body)
-			obj.Set("body", bodyStr)
-
-			contentType := r.Header.Get("Content-Type")
-
-			// JSON 解析
-			if strings.Contains(contentType, "application/json") {
-				var jsonData interface{}
-				if json.Unmarshal(body, &jsonData) == nil {
-					obj.Set("json", h.vm.ToValue(jsonData))
-				}
-			} else if strings.Contains(contentType, "application/x-www-form-urlencoded") {
-				// Form 表单解析
-				if values, err := url.ParseQuery(bodyStr); err == nil {
-					formObj := h.vm.NewObject()
-					for k, v := range values {
-						if len(v) == 1 {
-							formObj.Set(k, v[0])
-						} else {
-							formObj.Set(k, v)
-						}
-					}
-					obj.Set("form", formObj)
-				}
+	// 8. Body 解析增强：首次访问 body、rawBody、json 或 form 时读取完整请求体，
+	// 大请求体可以通过 for await (const chunk of req) 或 req.pipe() 分块读取
+	var body []byte
+	loaded := false
+	readBody := func() []byte {
+		if !loaded {
+			loaded = true
+			if r.Body != nil {
+				body, _ = io.ReadAll(r.Body)
 			}
 		}
+		return body
+	}
+	lazy := func(name string, get func() goja.Value) {
+		var value goja.Value
+		obj.DefineAccessorProperty(name, h.vm.ToValue(func() goja.Value {
+			if value == nil {
+				value = get()
+			}
+			return value
+		}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
 	}
+	contentType := r.Header.Get("Content-Type")
+
+	lazy("body", func() goja.Value {
+		return h.vm.ToValue(string(readBody()))
+	})
+	// 原始请求体（Buffer）
+	lazy("rawBody", func() goja.Value {
+		return buffer.New(h.vm, readBody())
+	})
+	// JSON 解析
+	lazy("json", func() goja.Value {
+		var jsonData interface{}
+		if strings.Contains(contentType, "application/json") && json.Unmarshal(readBody(), &jsonData) == nil {
+			return h.vm.ToValue(jsonData)
+		}
+		return goja.Undefined()
+	})
+	// Form 表单解析
+	lazy("form", func() goja.Value {
+		if !strings.Contains(contentType, "application/x-www-form-urlencoded") {
+			return goja.Undefined()
+		}
+		values, err := url.ParseQuery(string(readBody()))
+		if err != nil {
+			return goja.Undefined()
+		}
+		formObj := h.vm.NewObject()
+		for k, v := range values {
+			if len(v) == 1 {
+				formObj.Set(k, v[0])
+			} else {
+				formObj.Set(k, v)
+			}
+		}
+		return formObj
+	})
 
 	// 9. 类型检查方法 (类似 Express 的 req.is)
 	obj.Set("is", func(call goja.FunctionCall) goja.Value {
@@ -1140,14 +1309,15 @@ func (h *HTTPServerModule) createRequestObject(r *http.Request) goja.Value {
 }
 
 // createResponseObject 创建响应对象
-func (h *HTTPServerModule) createResponseObject(w http.ResponseWriter, r *http.Request) goja.Value {
-	return h.createResponseObjectWithWrapper(&responseWriter{ResponseWriter: w, statusCode: 200}, r)
+func (h *HTTPServerModule) createResponseObject(w http.ResponseWriter, r *http.Request, schedule stream.Scheduler) *goja.Object {
+	return h.createResponseObjectWithWrapper(&responseWriter{ResponseWriter: w, statusCode: 200}, r, schedule)
 }
 
-// createResponseObjectWithWrapper 使用包装器创建响应对象
-func (h *HTTPServerModule) createResponseObjectWithWrapper(rw *responseWriter, r *http.Request) goja.Value {
-	obj := h.vm.NewObject()
-	w := rw.ResponseWriter
+// createResponseObjectWithWrapper 使用包装器创建响应对象。响应对象是 Writable 流，
+// 除 send、json 等一次性发送的方法外，也可以通过 write/end 或 pipe 流式发送响应体
+func (h *HTTPServerModule) createResponseObjectWithWrapper(rw *responseWriter, r *http.Request, schedule stream.Scheduler) *goja.Object {
+	obj := h.newResponseStream(rw, schedule)
+	w := guardedResponse{rw}
 
 	// 设置状态码
 	obj.Set("status", func(call goja.FunctionCall) goja.Value {
@@ -1172,13 +1342,22 @@ func (h *HTTPServerModule) createResponseObjectWithWrapper(rw *responseWriter, r
 	// 发送文本响应
 	obj.Set("send", func(call goja.FunctionCall) goja.Value {
 		if len(call.Arguments) > 0 {
-			data := call.Arguments[0].String()
+			// Buffer/TypedArray 按原始字节发送
+			data, binary := buffer.Bytes(h.vm, call.Arguments[0])
+			if !binary {
+				data = []byte(call.Arguments[0].String())
+			}
 			if w.Header().Get("Content-Type") == "" {
-				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+				if binary {
+					w.Header().Set("Content-Type", "application/octet-stream")
+				} else {
+					w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+				}
 			}
 			w.WriteHeader(rw.statusCode)
-			w.Write([]byte(data))
+			w.Write(data)
 			rw.written = true
+			rw.finish()
 		}
 		return obj
 	})
@@ -1193,6 +1372,7 @@ func (h *HTTPServerModule) createResponseObjectWithWrapper(rw *responseWriter, r
 				w.WriteHeader(rw.statusCode)
 				w.Write(jsonData)
 				rw.written = true
+				rw.finish()
 			}
 		}
 		return obj
@@ -1206,6 +1386,7 @@ func (h *HTTPServerModule) createResponseObjectWithWrapper(rw *responseWriter, r
 			w.WriteHeader(rw.statusCode)
 			w.Write([]byte(data))
 			rw.written = true
+			rw.finish()
 		}
 		return obj
 	})
@@ -1215,6 +1396,7 @@ func (h *HTTPServerModule) createResponseObjectWithWrapper(rw *responseWriter, r
 		if len(call.Arguments) > 0 {
 			filePath := call.Arguments[0].String()
 			h.sendFileResponse(w, rw, r, filePath)
+			rw.finish()
 		}
 		return obj
 	})
@@ -1232,6 +1414,7 @@ func (h *HTTPServerModule) createResponseObjectWithWrapper(rw *responseWriter, r
 
 			w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
 			h.sendFileResponse(w, rw, r, filePath)
+			rw.finish()
 		}
 		return obj
 	})
@@ -1248,6 +1431,7 @@ func (h *HTTPServerModule) createResponseObjectWithWrapper(rw *responseWriter, r
 			}
 			http.Redirect(w, r, url, code)
 			rw.written = true
+			rw.finish()
 		}
 		return obj
 	})
@@ -1273,6 +1457,9 @@ func (h *HTTPServerModule) sendFileResponse(w http.ResponseWriter, rw *responseW
 		rw.written = true
 		return
 	}
+	if err := h.guard.CheckRead(absPath); err != nil {
+		panic(types.NewPermissionError(h.vm, err))
+	}
 
 	// 检查文件是否存在
 	fileInfo, err := os.Stat(absPath)
@@ -1372,7 +1559,7 @@ func (h *HTTPServerModule) createWebSocketHTTPHandler(server *HTTPServer, path s
 		}:
 			// 等待处理完成
 			<-done
-		case <-time.After(30 * time.Second):
+		case <-time.After(server.requestTimeout):
 			// 超时处理
 			fmt.Printf("WebSocket handler timeout\n")
 			conn.Close()
@@ -1380,33 +1567,9 @@ func (h *HTTPServerModule) createWebSocketHTTPHandler(server *HTTPServer, path s
 	}
 }
 
-// createWebSocketObject 创建 WebSocket 连接对象
+// createWebSocketObject 创建 WebSocket 连接对象（EventEmitter），触发 message 事件
 func (h *HTTPServerModule) createWebSocketObject(server *HTTPServer, conn *websocket.Conn) goja.Value {
-	obj := h.vm.NewObject()
-
-	// 事件监听器
-	listeners := make(map[string][]goja.Value)
-	var listenersMutex sync.RWMutex
-
-	// 设置事件监听器
-	obj.Set("on", func(call goja.FunctionCall) goja.Value {
-		if len(call.Arguments) < 2 {
-			return goja.Undefined()
-		}
-
-		eventName := call.Arguments[0].String()
-		handler := call.Arguments[1]
-
-		if _, ok := goja.AssertFunction(handler); !ok {
-			return goja.Undefined()
-		}
-
-		listenersMutex.Lock()
-		listeners[eventName] = append(listeners[eventName], handler)
-		listenersMutex.Unlock()
-
-		return goja.Undefined()
-	})
+	obj := events.New(h.vm)
 
 	// 发送消息
 	obj.Set("send", func(call goja.FunctionCall) goja.Value {
@@ -1419,7 +1582,10 @@ func (h *HTTPServerModule) createWebSocketObject(server *HTTPServer, conn *webso
 		var err error
 
 		// 判断数据类型
-		if data.ExportType().Kind() == reflect.String {
+		if binary, ok := buffer.Bytes(h.vm, data); ok {
+			// 二进制消息
+			err = conn.WriteMessage(websocket.BinaryMessage, binary)
+		} else if data.ExportType().Kind() == reflect.String {
 			// 文本消息
 			message = []byte(data.String())
 			err = conn.WriteMessage(websocket.TextMessage, message)
@@ -1497,42 +1663,29 @@ func (h *HTTPServerModule) createWebSocketObject(server *HTTPServer, conn *webso
 				break
 			}
 
-			// 触发 message 事件
-			listenersMutex.RLock()
-			handlers := listeners["message"]
-			listenersMutex.RUnlock()
-
-			if len(handlers) > 0 {
-				var data interface{}
-				if messageType == websocket.TextMessage {
-					// 尝试解析为 JSON
-					if json.Unmarshal(message, &data) != nil {
-						data = string(message)
-					}
-				} else {
-					data = message
+			var data interface{}
+			if messageType == websocket.TextMessage {
+				// 尝试解析为 JSON
+				if json.Unmarshal(message, &data) != nil {
+					data = string(message)
 				}
+			}
 
-				// 调用事件处理器（提交到 VM 处理队列）
-				for _, handler := range handlers {
-					if fn, ok := goja.AssertFunction(handler); ok {
-						// 提交到 VM 处理队列异步执行
-						select {
-						case server.requestChan <- func(vm *goja.Runtime) {
-							defer func() {
-								if r := recover(); r != nil {
-									fmt.Printf("WebSocket message handler panic: %v\n", r)
-								}
-							}()
-							fn(goja.Undefined(), h.vm.ToValue(data))
-						}:
-							// 提交成功，继续
-						default:
-							// 队列已满，跳过
-							fmt.Printf("WebSocket message queue full, dropping message\n")
-						}
-					}
+			// 触发 message 事件（提交到 VM 处理队列异步执行）
+			select {
+			case server.requestChan <- func(vm *goja.Runtime) {
+				payload := vm.ToValue(data)
+				if messageType == websocket.BinaryMessage {
+					payload = buffer.New(vm, message)
+				}
+				if err := events.Emit(vm, obj, "message", payload); err != nil {
+					fmt.Printf("WebSocket message handler error: %v\n", err)
 				}
+			}:
+				// 提交成功，继续
+			default:
+				// 队列已满，跳过
+				fmt.Printf("WebSocket message queue full, dropping message\n")
 			}
 		}
 	}()
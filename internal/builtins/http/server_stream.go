@@ -0,0 +1,174 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/dop251/goja"
+
+	"github.com/issueye/sw_runtime/internal/builtins/stream"
+	"github.com/issueye/sw_runtime/internal/sourcemap"
+)
+
+// schedule 把请求体、响应体流的 I/O 回调投递到服务器的请求处理队列，与路由处理器串行执行
+func (s *HTTPServer) schedule(fn func(*goja.Runtime) error) {
+	// 服务器关闭后请求通道被关闭，丢弃回调
+	defer func() { recover() }()
+
+	task := func(vm *goja.Runtime) {
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Printf("Stream callback panic: %s\n", sourcemap.FormatError(r))
+			}
+		}()
+		if err := fn(vm); err != nil {
+			fmt.Printf("Stream callback error: %s\n", sourcemap.FormatError(err))
+		}
+	}
+	select {
+	case s.requestChan <- task:
+	case <-s.stopChan:
+	}
+}
+
+// finish 标记响应已结束，等待中的处理器返回
+func (rw *responseWriter) finish() {
+	rw.finishOnce.Do(func() {
+		if rw.finished != nil {
+			close(rw.finished)
+		}
+	})
+}
+
+// close 处理器返回后禁止继续写入（底层连接可能已被复用）
+func (rw *responseWriter) close() {
+	rw.mu.Lock()
+	rw.closed = true
+	rw.mu.Unlock()
+}
+
+// guardedResponse 处理器返回后忽略写入的 ResponseWriter，供响应对象的各个方法使用
+type guardedResponse struct {
+	rw *responseWriter
+}
+
+func (g guardedResponse) Header() http.Header {
+	return g.rw.ResponseWriter.Header()
+}
+
+func (g guardedResponse) WriteHeader(code int) {
+	g.rw.mu.Lock()
+	defer g.rw.mu.Unlock()
+	if !g.rw.closed {
+		g.rw.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (g guardedResponse) Write(p []byte) (int, error) {
+	g.rw.mu.Lock()
+	defer g.rw.mu.Unlock()
+	if g.rw.closed {
+		return 0, http.ErrHandlerTimeout
+	}
+	return g.rw.ResponseWriter.Write(p)
+}
+
+// bodyWriter 响应流的底层 writer：首次写入时发送状态码和响应头，每次写入后立即 flush
+type bodyWriter struct {
+	rw *responseWriter
+}
+
+func (b bodyWriter) Write(p []byte) (int, error) {
+	rw := b.rw
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if rw.closed {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !rw.written {
+		rw.ResponseWriter.WriteHeader(rw.statusCode)
+		rw.written = true
+	}
+	n, err := rw.ResponseWriter.Write(p)
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok && err == nil {
+		flusher.Flush()
+	}
+	return n, err
+}
+
+// end 响应流结束：没有写入过数据时发送状态码和响应头
+func (b bodyWriter) end() error {
+	rw := b.rw
+	rw.mu.Lock()
+	if !rw.closed && !rw.written {
+		rw.ResponseWriter.WriteHeader(rw.statusCode)
+		rw.written = true
+	}
+	rw.mu.Unlock()
+	rw.finish()
+	return nil
+}
+
+// newResponseStream 创建作为响应对象的 Writable 流，write/end 或作为 pipe 目标时进入流式响应模式
+func (h *HTTPServerModule) newResponseStream(rw *responseWriter, schedule stream.Scheduler) *goja.Object {
+	writer := bodyWriter{rw: rw}
+	obj := stream.NewWritableOn(h.vm, schedule, writer, writer.end, nil)
+
+	proto := stream.Class(h.vm, "Writable").Get("prototype").ToObject(h.vm)
+	for _, name := range []string{"write", "end"} {
+		method, _ := goja.AssertFunction(proto.Get(name))
+		obj.Set(name, func(call goja.FunctionCall) goja.Value {
+			rw.streaming = true
+			result, err := method(obj, call.Arguments...)
+			if err != nil {
+				panic(err)
+			}
+			return result
+		})
+	}
+	on, _ := goja.AssertFunction(obj.Get("on"))
+	on(obj, h.vm.ToValue("pipe"), h.vm.ToValue(func(goja.FunctionCall) goja.Value {
+		rw.streaming = true
+		return goja.Undefined()
+	}))
+	// 客户端断开导致的写入错误不作为未捕获异常报告，流已被销毁
+	on(obj, h.vm.ToValue("error"), h.vm.ToValue(func(goja.FunctionCall) goja.Value {
+		return goja.Undefined()
+	}))
+	return obj
+}
+
+// watchResult 处理器返回 Promise（异步处理器）时在其完成后结束请求，拒绝时返回 500。
+// 返回 true 表示需要等待响应结束
+func (h *HTTPServerModule) watchResult(rw *responseWriter, result goja.Value, path string) bool {
+	if result == nil {
+		return false
+	}
+	if _, ok := result.Export().(*goja.Promise); !ok {
+		return false
+	}
+	then, ok := goja.AssertFunction(result.ToObject(h.vm).Get("then"))
+	if !ok {
+		return false
+	}
+
+	onFulfilled := func(goja.FunctionCall) goja.Value {
+		if !rw.streaming {
+			rw.finish()
+		}
+		return goja.Undefined()
+	}
+	onRejected := func(call goja.FunctionCall) goja.Value {
+		fmt.Printf("Handler error at %s: %s\n", path, sourcemap.FormatError(call.Argument(0)))
+		if !rw.written {
+			http.Error(guardedResponse{rw}, "Handler error: "+call.Argument(0).String(), http.StatusInternalServerError)
+			rw.written = true
+		}
+		rw.finish()
+		return goja.Undefined()
+	}
+	if _, err := then(result, h.vm.ToValue(onFulfilled), h.vm.ToValue(onRejected)); err != nil {
+		return false
+	}
+	return true
+}
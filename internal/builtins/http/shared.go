@@ -0,0 +1,110 @@
+package http
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/dop251/goja"
+
+	"github.com/issueye/sw_runtime/internal/builtins/clone"
+)
+
+// SharedStore 跨 VM 共享存储，属于一个 Runner 及其创建的多 VM 服务器工作 VM 与 Worker 线程，
+// 各 VM 通过它共享状态。值以结构化克隆保存，读写都会复制，不会在 VM 之间共享对象引用
+type SharedStore struct {
+	sync.Mutex
+	values map[string]*clone.Data
+}
+
+// NewSharedStore 创建空的共享存储
+func NewSharedStore() *SharedStore {
+	return &SharedStore{values: make(map[string]*clone.Data)}
+}
+
+// Clear 清空共享存储，Runner 重置时调用
+func (s *SharedStore) Clear() {
+	s.Lock()
+	s.values = make(map[string]*clone.Data)
+	s.Unlock()
+}
+
+// newSharedStoreObject 创建共享存储的 JS 接口
+func newSharedStoreObject(vm *goja.Runtime, sharedStore *SharedStore) *goja.Object {
+	obj := vm.NewObject()
+
+	obj.Set("get", func(call goja.FunctionCall) goja.Value {
+		key := call.Argument(0).String()
+		sharedStore.Lock()
+		data, ok := sharedStore.values[key]
+		sharedStore.Unlock()
+		if !ok {
+			return goja.Undefined()
+		}
+		return data.Deserialize(vm)
+	})
+
+	obj.Set("set", func(call goja.FunctionCall) goja.Value {
+		key := call.Argument(0).String()
+		data := clone.Serialize(vm, call.Argument(1))
+		sharedStore.Lock()
+		sharedStore.values[key] = data
+		sharedStore.Unlock()
+		return obj
+	})
+
+	obj.Set("has", func(call goja.FunctionCall) goja.Value {
+		key := call.Argument(0).String()
+		sharedStore.Lock()
+		_, ok := sharedStore.values[key]
+		sharedStore.Unlock()
+		return vm.ToValue(ok)
+	})
+
+	obj.Set("delete", func(call goja.FunctionCall) goja.Value {
+		key := call.Argument(0).String()
+		sharedStore.Lock()
+		_, ok := sharedStore.values[key]
+		delete(sharedStore.values, key)
+		sharedStore.Unlock()
+		return vm.ToValue(ok)
+	})
+
+	// incr 原子地增加数值并返回新值，键不存在时从 0 开始
+	obj.Set("incr", func(call goja.FunctionCall) goja.Value {
+		key := call.Argument(0).String()
+		delta := 1.0
+		if len(call.Arguments) > 1 {
+			delta = call.Argument(1).ToFloat()
+		}
+
+		sharedStore.Lock()
+		defer sharedStore.Unlock()
+		current := 0.0
+		if data, ok := sharedStore.values[key]; ok {
+			current = data.Deserialize(vm).ToFloat()
+		}
+		result := vm.ToValue(current + delta)
+		sharedStore.values[key] = clone.Serialize(vm, result)
+		return result
+	})
+
+	obj.Set("keys", func(call goja.FunctionCall) goja.Value {
+		sharedStore.Lock()
+		keys := make([]interface{}, 0, len(sharedStore.values))
+		for key := range sharedStore.values {
+			keys = append(keys, key)
+		}
+		sharedStore.Unlock()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].(string) < keys[j].(string)
+		})
+		return vm.NewArray(keys...)
+	})
+
+	obj.Set("clear", func(call goja.FunctionCall) goja.Value {
+		sharedStore.Clear()
+		return goja.Undefined()
+	})
+
+	return obj
+}
@@ -1,17 +1,25 @@
 package builtins
 
 import (
+	"io"
 	"strings"
-	"sw_runtime/internal/builtins/config"
-	"sw_runtime/internal/builtins/db"
-	"sw_runtime/internal/builtins/fs"
-	"sw_runtime/internal/builtins/http"
-	"sw_runtime/internal/builtins/net"
-	"sw_runtime/internal/builtins/process"
-	"sw_runtime/internal/builtins/types"
-	"sw_runtime/internal/builtins/utils"
 	"time"
 
+	"github.com/issueye/sw_runtime/internal/builtins/buffer"
+	"github.com/issueye/sw_runtime/internal/builtins/clone"
+	"github.com/issueye/sw_runtime/internal/builtins/config"
+	"github.com/issueye/sw_runtime/internal/builtins/db"
+	"github.com/issueye/sw_runtime/internal/builtins/events"
+	"github.com/issueye/sw_runtime/internal/builtins/fs"
+	"github.com/issueye/sw_runtime/internal/builtins/http"
+	"github.com/issueye/sw_runtime/internal/builtins/net"
+	"github.com/issueye/sw_runtime/internal/builtins/process"
+	"github.com/issueye/sw_runtime/internal/builtins/stream"
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/builtins/utils"
+	"github.com/issueye/sw_runtime/internal/builtins/web"
+	"github.com/issueye/sw_runtime/internal/security"
+
 	"github.com/dop251/goja"
 )
 
@@ -22,6 +30,7 @@ type Manager struct {
 	basePath   string
 	startTime  time.Time
 	argv       []string
+	guard      *security.Guard // 所有内置模块共享的权限检查器
 }
 
 func NewManager(vm *goja.Runtime, basePath string) *Manager {
@@ -32,19 +41,29 @@ func NewManager(vm *goja.Runtime, basePath string) *Manager {
 		basePath:   basePath,
 		startTime:  time.Now(),
 		argv:       make([]string, 0),
+		guard:      security.NewGuard(),
 	}
 	m.registerBuiltinModules()
 	return m
 }
 
 func (m *Manager) registerBuiltinModules() {
+	// Buffer 模块
+	m.modules["buffer"] = buffer.NewBufferModule(m.vm)
+
+	// EventEmitter 模块
+	m.modules["events"] = events.NewEventsModule(m.vm)
+
+	// Stream 模块
+	m.modules["stream"] = stream.NewStreamModule(m.vm)
+
 	// HTTP 命名空间
-	httpNS := http.NewNamespace(m.vm)
+	httpNS := http.NewNamespace(m.vm, m.guard)
 	m.namespaces["http"] = httpNS
 	m.modules["http"] = httpNS
 
 	// DB 命名空间
-	dbNS := db.NewNamespace(m.vm)
+	dbNS := db.NewNamespace(m.vm, m.guard)
 	m.namespaces["db"] = dbNS
 	m.modules["db"] = dbNS
 
@@ -53,21 +72,24 @@ func (m *Manager) registerBuiltinModules() {
 	m.namespaces["utils"] = utilsNS
 
 	// Net 命名空间 (net, proxy, websocket)
-	netNS := net.NewNamespace(m.vm)
+	netNS := net.NewNamespace(m.vm, m.guard)
 	m.namespaces["net"] = netNS
 
 	// FS 命名空间 (fs, os)
-	fsNS := fs.NewNamespace(m.vm, m.basePath)
+	fsNS := fs.NewNamespace(m.vm, m.basePath, m.guard)
 	m.namespaces["fs"] = fsNS
 
 	// Config 命名空间 (viper)
-	configNS := config.NewNamespace(m.vm)
+	configNS := config.NewNamespace(m.vm, m.guard)
 	m.namespaces["config"] = configNS
 
 	// Process 命名空间 (process, exec)
-	processNS := process.NewNamespace(m.vm, m.argv, m.startTime)
+	processNS := process.NewNamespace(m.vm, m.argv, m.startTime, m.guard)
 	m.namespaces["process"] = processNS
 	m.modules["process"] = processNS
+
+	// 独立可执行文件资源
+	m.modules["standalone"] = process.NewStandaloneModule(m.vm)
 }
 
 func (m *Manager) GetModule(name string) (types.BuiltinModule, bool) {
@@ -121,14 +143,118 @@ func (m *Manager) RegisterModule(name string, module types.BuiltinModule) {
 	m.modules[name] = module
 }
 
+// InstallGlobals 安装全局对象（Buffer、SharedArrayBuffer、fetch、Headers、Request、Response、AbortController、
+// URL、TextEncoder、structuredClone、performance、crypto 等）
+func (m *Manager) InstallGlobals(global *goja.Object) {
+	global.Set("Buffer", buffer.Constructor(m.vm))
+	global.Set("SharedArrayBuffer", clone.SharedArrayBuffer(m.vm))
+	web.Install(m.vm, global, m.startTime)
+
+	if httpNS, ok := m.namespaces["http"].(*http.Namespace); ok {
+		fetchObj := httpNS.Fetch().GetModule()
+		for _, key := range fetchObj.Keys() {
+			global.Set(key, fetchObj.Get(key))
+		}
+	}
+}
+
+// Close 关闭当前 VM 创建的服务器
 func (m *Manager) Close() {
-	http.CloseAllHTTPServers()
-	net.CloseAllTCPServers()
+	http.CloseHTTPServers(m.vm)
+	net.CloseTCPServers(m.vm)
+	stream.CloseAll(m.vm)
+}
+
+// Reset 关闭当前 VM 创建的服务器，以及各模块（包括自定义模块）持有的连接和数据库，
+// 用于 Runner 复用前的重置
+func (m *Manager) Reset() {
+	m.Close()
+
+	seen := make(map[types.Resetter]struct{})
+	reset := func(module interface{}) {
+		r, ok := module.(types.Resetter)
+		if !ok {
+			return
+		}
+		if _, done := seen[r]; done {
+			return
+		}
+		seen[r] = struct{}{}
+		r.Reset()
+	}
+	for _, ns := range m.namespaces {
+		reset(ns)
+	}
+	for _, module := range m.modules {
+		reset(module)
+	}
+}
+
+// SetServerWorkerSpawner 设置多 VM HTTP 服务器创建工作 VM 的函数
+func (m *Manager) SetServerWorkerSpawner(spawner http.WorkerSpawner) {
+	if httpNS, ok := m.namespaces["http"].(*http.Namespace); ok {
+		httpNS.Server().SetWorkerSpawner(spawner)
+	}
+}
+
+// SetSharedStore 设置 http/server 的跨 VM 共享存储，子 VM 通过它与父 VM 共享数据
+func (m *Manager) SetSharedStore(store *http.SharedStore) {
+	if httpNS, ok := m.namespaces["http"].(*http.Namespace); ok {
+		httpNS.Server().SetSharedStore(store)
+	}
+}
+
+// SharedStore 返回 http/server 的跨 VM 共享存储
+func (m *Manager) SharedStore() *http.SharedStore {
+	if httpNS, ok := m.namespaces["http"].(*http.Namespace); ok {
+		return httpNS.Server().SharedStore()
+	}
+	return nil
 }
 
 // SetArgv 设置命令行参数
 func (m *Manager) SetArgv(argv []string) {
 	m.argv = argv
+	if processNS, ok := m.namespaces["process"].(*process.Namespace); ok {
+		processNS.SetArgv(argv)
+	}
+}
+
+// SetHeapRoots 设置 process.profile.heapSummary 的遍历起点
+func (m *Manager) SetHeapRoots(roots func() []goja.Value) {
+	if processNS, ok := m.namespaces["process"].(*process.Namespace); ok {
+		processNS.SetHeapRoots(roots)
+	}
+}
+
+// ProcessEvents 返回 process 的生命周期事件
+func (m *Manager) ProcessEvents() *process.Events {
+	if processNS, ok := m.namespaces["process"].(*process.Namespace); ok {
+		return processNS.Events()
+	}
+	return nil
+}
+
+// SetStdio 设置 process.stdout 和 process.stderr 的输出目标，nil 表示标准输出/标准错误
+func (m *Manager) SetStdio(stdout, stderr io.Writer) {
+	if processNS, ok := m.namespaces["process"].(*process.Namespace); ok {
+		processNS.SetStdio(stdout, stderr)
+	}
+}
+
+// SetPermissions 设置内置模块的访问权限，nil 表示不限制
+func (m *Manager) SetPermissions(p *security.Permissions) {
+	m.guard.Set(p)
+}
+
+// Permissions 返回当前权限配置，未限制时返回 nil
+func (m *Manager) Permissions() *security.Permissions {
+	return m.guard.Permissions()
+}
+
+// Guard 返回内置模块共享的权限检查器
+func (m *Manager) Guard() *security.Guard {
+	return m.guard
 }
 
 // SetStartTime 设置起始时间
@@ -138,5 +264,5 @@ func (m *Manager) SetStartTime(t time.Time) {
 
 // NewHTTPServerModule 创建 HTTP 服务器模块（向后兼容导出）
 func NewHTTPServerModule(vm *goja.Runtime) *http.HTTPServerModule {
-	return http.NewHTTPServerModule(vm)
+	return http.NewHTTPServerModule(vm, nil)
 }
@@ -1,7 +1,8 @@
 package net
 
 import (
-	"sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/security"
 
 	"github.com/dop251/goja"
 )
@@ -15,12 +16,12 @@ type Namespace struct {
 }
 
 // NewNamespace 创建 net 命名空间
-func NewNamespace(vm *goja.Runtime) *Namespace {
+func NewNamespace(vm *goja.Runtime, guard *security.Guard) *Namespace {
 	return &Namespace{
 		vm:       vm,
-		net:      NewNetModule(vm),
-		proxy:    NewProxyModule(vm),
-		websocket: NewWebSocketModule(vm),
+		net:      NewNetModule(vm, guard),
+		proxy:    NewProxyModule(vm, guard),
+		websocket: NewWebSocketModule(vm, guard),
 	}
 }
 
@@ -41,6 +42,13 @@ func (n *Namespace) GetModule() *goja.Object {
 	return obj
 }
 
+// Reset 关闭网络模块、代理和 WebSocket 持有的连接与服务器
+func (n *Namespace) Reset() {
+	n.net.Reset()
+	n.proxy.Reset()
+	n.websocket.Reset()
+}
+
 // GetSubModule 获取子模块
 func (n *Namespace) GetSubModule(name string) (types.BuiltinModule, bool) {
 	switch name {
@@ -1,13 +1,18 @@
 package net
 
 import (
-	"bufio"
+	"bytes"
 	"fmt"
-	"io"
 	"net"
 	"sync"
 	"time"
 
+	"github.com/issueye/sw_runtime/internal/builtins/buffer"
+	"github.com/issueye/sw_runtime/internal/builtins/events"
+	"github.com/issueye/sw_runtime/internal/builtins/stream"
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/security"
+
 	"github.com/dop251/goja"
 )
 
@@ -26,6 +31,18 @@ func IsTCPServerRunning() bool {
 	return len(tcpServerRegistry.servers) > 0
 }
 
+// HasTCPServers 检查指定 VM 是否有 TCP 服务器在运行
+func HasTCPServers(vm *goja.Runtime) bool {
+	tcpServerRegistry.RLock()
+	defer tcpServerRegistry.RUnlock()
+	for s := range tcpServerRegistry.servers {
+		if s.vm == vm {
+			return true
+		}
+	}
+	return false
+}
+
 // registerTCPServer 注册 TCP 服务器
 func registerTCPServer(s *TCPServer) {
 	tcpServerRegistry.Lock()
@@ -47,14 +64,16 @@ type NetModule struct {
 	listeners   map[string]net.Listener
 	mutex       sync.RWMutex
 	connID      int
+	guard       *security.Guard
 }
 
 // NewNetModule 创建网络模块
-func NewNetModule(vm *goja.Runtime) *NetModule {
+func NewNetModule(vm *goja.Runtime, guard *security.Guard) *NetModule {
 	return &NetModule{
 		vm:          vm,
 		connections: make(map[string]net.Conn),
 		listeners:   make(map[string]net.Listener),
+		guard:       guard,
 	}
 }
 
@@ -77,20 +96,32 @@ type TCPServer struct {
 	vm       *goja.Runtime
 	listener net.Listener
 	module   *NetModule
-	handlers map[string]goja.Value // 事件处理器
-	mutex    sync.RWMutex
+	emitter  *goja.Object // 服务器对象（EventEmitter），触发 connection 事件
+}
+
+// Reset 关闭所有监听器和连接（包括 UDP 套接字）
+func (n *NetModule) Reset() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	for id, listener := range n.listeners {
+		listener.Close()
+		delete(n.listeners, id)
+	}
+	for id, conn := range n.connections {
+		conn.Close()
+		delete(n.connections, id)
+	}
 }
 
 // createTCPServer 创建 TCP 服务器
 func (n *NetModule) createTCPServer(call goja.FunctionCall) goja.Value {
+	obj := events.New(n.vm)
 	server := &TCPServer{
-		vm:       n.vm,
-		module:   n,
-		handlers: make(map[string]goja.Value),
+		vm:      n.vm,
+		module:  n,
+		emitter: obj,
 	}
 
-	obj := n.vm.NewObject()
-
 	// 监听端口
 	obj.Set("listen", func(call goja.FunctionCall) goja.Value {
 		if len(call.Arguments) < 1 {
@@ -101,6 +132,9 @@ func (n *NetModule) createTCPServer(call goja.FunctionCall) goja.Value {
 		if port[0] != ':' {
 			port = ":" + port
 		}
+		if err := n.guard.CheckAddr(port); err != nil {
+			return types.RejectPermission(n.vm, err)
+		}
 
 		var callback goja.Value
 		if len(call.Arguments) > 1 {
@@ -158,22 +192,6 @@ func (n *NetModule) createTCPServer(call goja.FunctionCall) goja.Value {
 		return n.vm.ToValue(promise)
 	})
 
-	// 注册事件处理器
-	obj.Set("on", func(call goja.FunctionCall) goja.Value {
-		if len(call.Arguments) < 2 {
-			panic(n.vm.NewTypeError("on requires event and handler"))
-		}
-
-		event := call.Arguments[0].String()
-		handler := call.Arguments[1]
-
-		server.mutex.Lock()
-		server.handlers[event] = handler
-		server.mutex.Unlock()
-
-		return obj
-	})
-
 	// 关闭服务器
 	obj.Set("close", func(call goja.FunctionCall) goja.Value {
 		promise, resolve, reject := n.vm.NewPromise()
@@ -195,93 +213,60 @@ func (n *NetModule) createTCPServer(call goja.FunctionCall) goja.Value {
 	return obj
 }
 
-// handleConnection 处理新连接
+// handleConnection 处理新连接，在事件循环中创建连接对象并触发 connection 事件
 func (s *TCPServer) handleConnection(conn net.Conn) {
-	// 创建连接对象
-	connObj := s.createTCPConnection(conn)
+	stream.Async(s.vm, func() func(vm *goja.Runtime) error {
+		return func(vm *goja.Runtime) error {
+			connObj := s.createTCPConnection(conn)
+			return events.Emit(vm, s.emitter, "connection", connObj)
+		}
+	})
+}
 
-	// 触发 connection 事件
-	s.mutex.RLock()
-	handler := s.handlers["connection"]
-	s.mutex.RUnlock()
+// tcpConn 关闭时从模块的连接表中移除
+type tcpConn struct {
+	net.Conn
+	id     string
+	module *NetModule
+}
 
-	if handler != nil {
-		if fn, ok := goja.AssertFunction(handler); ok {
-			fn(goja.Undefined(), connObj)
-		}
+func (c *tcpConn) Close() error {
+	c.module.mutex.Lock()
+	delete(c.module.connections, c.id)
+	c.module.mutex.Unlock()
+	return c.Conn.Close()
+}
+
+// closeWrite 关闭连接的写方向（发送 FIN），对端仍可继续发送数据
+func (c *tcpConn) closeWrite() error {
+	if tc, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return tc.CloseWrite()
 	}
+	return nil
 }
 
-// createTCPConnection 创建 TCP 连接对象
+// createTCPConnection 创建 TCP 连接对象（Duplex 流）。可读端触发 data、end 事件，
+// 写入支持背压（write 返回 false 时等待 drain），对端结束后本端自动结束，随后触发 close 事件
 func (s *TCPServer) createTCPConnection(conn net.Conn) goja.Value {
-	obj := s.vm.NewObject()
-	handlers := make(map[string]goja.Value)
-	var handlersMutex sync.RWMutex
-
 	connID := fmt.Sprintf("tcp_conn_%d", s.module.getNextConnID())
 	s.module.mutex.Lock()
 	s.module.connections[connID] = conn
 	s.module.mutex.Unlock()
 
+	c := &tcpConn{Conn: conn, id: connID, module: s.module}
+	opts := s.vm.NewObject()
+	opts.Set("allowHalfOpen", false)
+	obj := stream.NewDuplex(s.vm, conn, c.closeWrite, opts, c)
+
 	// 连接信息
 	obj.Set("remoteAddress", conn.RemoteAddr().String())
 	obj.Set("localAddress", conn.LocalAddr().String())
 
-	// 发送数据
-	obj.Set("write", func(call goja.FunctionCall) goja.Value {
-		if len(call.Arguments) < 1 {
-			panic(s.vm.NewTypeError("write requires data"))
-		}
-
-		data := call.Arguments[0].String()
-		promise, resolve, reject := s.vm.NewPromise()
-
-		go func() {
-			_, err := conn.Write([]byte(data))
-			if err != nil {
-				reject(s.vm.NewGoError(err))
-				return
-			}
-			resolve(s.vm.ToValue(true))
-		}()
-
-		return s.vm.ToValue(promise)
-	})
-
-	// 注册事件
-	obj.Set("on", func(call goja.FunctionCall) goja.Value {
-		if len(call.Arguments) < 2 {
-			panic(s.vm.NewTypeError("on requires event and handler"))
-		}
-
-		event := call.Arguments[0].String()
-		handler := call.Arguments[1]
-
-		handlersMutex.Lock()
-		handlers[event] = handler
-		handlersMutex.Unlock()
-
-		return obj
-	})
-
-	// 关闭连接
+	// 立即关闭连接（等同于 destroy）
 	obj.Set("close", func(call goja.FunctionCall) goja.Value {
-		conn.Close()
-		s.module.mutex.Lock()
-		delete(s.module.connections, connID)
-		s.module.mutex.Unlock()
-
-		// 触发 close 事件
-		handlersMutex.RLock()
-		closeHandler := handlers["close"]
-		handlersMutex.RUnlock()
-
-		if closeHandler != nil {
-			if fn, ok := goja.AssertFunction(closeHandler); ok {
-				fn(goja.Undefined())
-			}
+		if err := stream.Destroy(s.vm, obj, nil); err != nil {
+			panic(err)
 		}
-
 		return goja.Undefined()
 	})
 
@@ -296,54 +281,6 @@ func (s *TCPServer) createTCPConnection(conn net.Conn) goja.Value {
 		return obj
 	})
 
-	// 开始读取数据
-	go func() {
-		reader := bufio.NewReader(conn)
-		for {
-			data, err := reader.ReadBytes('\n')
-			if err != nil {
-				if err != io.EOF {
-					// 触发 error 事件
-					handlersMutex.RLock()
-					errorHandler := handlers["error"]
-					handlersMutex.RUnlock()
-
-					if errorHandler != nil {
-						if fn, ok := goja.AssertFunction(errorHandler); ok {
-							errObj := s.vm.NewObject()
-							errObj.Set("message", err.Error())
-							fn(goja.Undefined(), errObj)
-						}
-					}
-				}
-				conn.Close()
-				break
-			}
-
-			// 触发 data 事件
-			handlersMutex.RLock()
-			dataHandler := handlers["data"]
-			handlersMutex.RUnlock()
-
-			if dataHandler != nil {
-				if fn, ok := goja.AssertFunction(dataHandler); ok {
-					fn(goja.Undefined(), s.vm.ToValue(string(data)))
-				}
-			}
-		}
-
-		// 触发 close 事件
-		handlersMutex.RLock()
-		closeHandler := handlers["close"]
-		handlersMutex.RUnlock()
-
-		if closeHandler != nil {
-			if fn, ok := goja.AssertFunction(closeHandler); ok {
-				fn(goja.Undefined())
-			}
-		}
-	}()
-
 	return obj
 }
 
@@ -368,32 +305,36 @@ func (n *NetModule) connectTCP(call goja.FunctionCall) goja.Value {
 		}
 	}
 
+	if err := n.guard.CheckAddr(address); err != nil {
+		return types.RejectPermission(n.vm, err)
+	}
+
 	promise, resolve, reject := n.vm.NewPromise()
 
-	go func() {
+	stream.Async(n.vm, func() func(vm *goja.Runtime) error {
 		conn, err := net.DialTimeout("tcp", address, timeout)
-		if err != nil {
-			reject(n.vm.NewGoError(err))
-			return
-		}
-
-		// 创建连接对象
-		server := &TCPServer{vm: n.vm, module: n}
-		connObj := server.createTCPConnection(conn)
+		return func(vm *goja.Runtime) error {
+			if err != nil {
+				reject(vm.NewGoError(err))
+				return nil
+			}
 
-		resolve(connObj)
-	}()
+			// 创建连接对象
+			server := &TCPServer{vm: n.vm, module: n}
+			resolve(server.createTCPConnection(conn))
+			return nil
+		}
+	})
 
 	return n.vm.ToValue(promise)
 }
 
 // UDPSocket UDP 套接字
 type UDPSocket struct {
-	vm       *goja.Runtime
-	conn     *net.UDPConn
-	module   *NetModule
-	handlers map[string]goja.Value
-	mutex    sync.RWMutex
+	vm      *goja.Runtime
+	conn    *net.UDPConn
+	module  *NetModule
+	emitter *goja.Object // 套接字对象（EventEmitter），触发 message、error、close 事件
 }
 
 // createUDPSocket 创建 UDP 套接字
@@ -403,14 +344,13 @@ func (n *NetModule) createUDPSocket(call goja.FunctionCall) goja.Value {
 		socketType = call.Arguments[0].String()
 	}
 
+	obj := events.New(n.vm)
 	socket := &UDPSocket{
-		vm:       n.vm,
-		module:   n,
-		handlers: make(map[string]goja.Value),
+		vm:      n.vm,
+		module:  n,
+		emitter: obj,
 	}
 
-	obj := n.vm.NewObject()
-
 	// 绑定端口
 	obj.Set("bind", func(call goja.FunctionCall) goja.Value {
 		if len(call.Arguments) < 1 {
@@ -423,6 +363,10 @@ func (n *NetModule) createUDPSocket(call goja.FunctionCall) goja.Value {
 			host = call.Arguments[1].String()
 		}
 
+		if err := n.guard.CheckNet(host, port); err != nil {
+			return types.RejectPermission(n.vm, err)
+		}
+
 		var callback goja.Value
 		if len(call.Arguments) > 2 {
 			if _, ok := goja.AssertFunction(call.Arguments[2]); ok {
@@ -446,6 +390,10 @@ func (n *NetModule) createUDPSocket(call goja.FunctionCall) goja.Value {
 			}
 
 			socket.conn = conn
+			socketID := fmt.Sprintf("udp_socket_%d", n.getNextConnID())
+			n.mutex.Lock()
+			n.connections[socketID] = conn
+			n.mutex.Unlock()
 
 			// 调用回调
 			if callback != nil {
@@ -469,10 +417,14 @@ func (n *NetModule) createUDPSocket(call goja.FunctionCall) goja.Value {
 			panic(n.vm.NewTypeError("send requires data, port, host"))
 		}
 
-		data := call.Arguments[0].String()
+		data := bytes.Clone(buffer.ToBytes(n.vm, call.Arguments[0]))
 		port := call.Arguments[1].String()
 		host := call.Arguments[2].String()
 
+		if err := n.guard.CheckNet(host, port); err != nil {
+			return types.RejectPermission(n.vm, err)
+		}
+
 		var callback goja.Value
 		if len(call.Arguments) > 3 {
 			if _, ok := goja.AssertFunction(call.Arguments[3]); ok {
@@ -498,13 +450,13 @@ func (n *NetModule) createUDPSocket(call goja.FunctionCall) goja.Value {
 				}
 				defer conn.Close()
 
-				_, err = conn.Write([]byte(data))
+				_, err = conn.Write(data)
 				if err != nil {
 					reject(n.vm.NewGoError(err))
 					return
 				}
 			} else {
-				_, err = socket.conn.WriteToUDP([]byte(data), addr)
+				_, err = socket.conn.WriteToUDP(data, addr)
 				if err != nil {
 					reject(n.vm.NewGoError(err))
 					return
@@ -524,22 +476,6 @@ func (n *NetModule) createUDPSocket(call goja.FunctionCall) goja.Value {
 		return n.vm.ToValue(promise)
 	})
 
-	// 注册事件
-	obj.Set("on", func(call goja.FunctionCall) goja.Value {
-		if len(call.Arguments) < 2 {
-			panic(n.vm.NewTypeError("on requires event and handler"))
-		}
-
-		event := call.Arguments[0].String()
-		handler := call.Arguments[1]
-
-		socket.mutex.Lock()
-		socket.handlers[event] = handler
-		socket.mutex.Unlock()
-
-		return obj
-	})
-
 	// 关闭套接字
 	obj.Set("close", func(call goja.FunctionCall) goja.Value {
 		if socket.conn != nil {
@@ -548,15 +484,7 @@ func (n *NetModule) createUDPSocket(call goja.FunctionCall) goja.Value {
 		}
 
 		// 触发 close 事件
-		socket.mutex.RLock()
-		closeHandler := socket.handlers["close"]
-		socket.mutex.RUnlock()
-
-		if closeHandler != nil {
-			if fn, ok := goja.AssertFunction(closeHandler); ok {
-				fn(goja.Undefined())
-			}
-		}
+		events.Emit(n.vm, obj, "close")
 
 		return goja.Undefined()
 	})
@@ -582,40 +510,25 @@ func (n *NetModule) createUDPSocket(call goja.FunctionCall) goja.Value {
 // startReceiving 开始接收 UDP 数据
 func (s *UDPSocket) startReceiving() {
 	go func() {
-		buffer := make([]byte, 65535)
+		buf := make([]byte, 65535)
 		for {
-			n, addr, err := s.conn.ReadFromUDP(buffer)
+			n, addr, err := s.conn.ReadFromUDP(buf)
 			if err != nil {
-				// 触发 error 事件
-				s.mutex.RLock()
-				errorHandler := s.handlers["error"]
-				s.mutex.RUnlock()
-
-				if errorHandler != nil {
-					if fn, ok := goja.AssertFunction(errorHandler); ok {
-						errObj := s.vm.NewObject()
-						errObj.Set("message", err.Error())
-						fn(goja.Undefined(), errObj)
-					}
-				}
+				// 触发 error 事件，没有监听器时忽略
+				errObj := s.vm.NewObject()
+				errObj.Set("message", err.Error())
+				events.Emit(s.vm, s.emitter, "error", errObj)
 				break
 			}
 
 			// 触发 message 事件
-			s.mutex.RLock()
-			messageHandler := s.handlers["message"]
-			s.mutex.RUnlock()
-
-			if messageHandler != nil {
-				if fn, ok := goja.AssertFunction(messageHandler); ok {
-					msgObj := s.vm.NewObject()
-					msgObj.Set("data", string(buffer[:n]))
-					msgObj.Set("address", addr.IP.String())
-					msgObj.Set("port", addr.Port)
-
-					fn(goja.Undefined(), s.vm.ToValue(string(buffer[:n])), msgObj)
-				}
-			}
+			msg := buffer.New(s.vm, bytes.Clone(buf[:n]))
+			msgObj := s.vm.NewObject()
+			msgObj.Set("data", msg)
+			msgObj.Set("address", addr.IP.String())
+			msgObj.Set("port", addr.Port)
+			msgObj.Set("size", n)
+			events.Emit(s.vm, s.emitter, "message", msg, msgObj)
 		}
 	}()
 }
@@ -630,10 +543,22 @@ func (n *NetModule) getNextConnID() int {
 
 // CloseAllTCPServers 关闭所有注册的 TCP 服务器
 func CloseAllTCPServers() {
+	closeTCPServers(nil)
+}
+
+// CloseTCPServers 关闭指定 VM 创建的 TCP 服务器
+func CloseTCPServers(vm *goja.Runtime) {
+	closeTCPServers(vm)
+}
+
+// closeTCPServers 关闭注册的 TCP 服务器，vm 为 nil 时关闭全部
+func closeTCPServers(vm *goja.Runtime) {
 	tcpServerRegistry.Lock()
 	servers := make([]*TCPServer, 0, len(tcpServerRegistry.servers))
 	for s := range tcpServerRegistry.servers {
-		servers = append(servers, s)
+		if vm == nil || s.vm == vm {
+			servers = append(servers, s)
+		}
 	}
 	tcpServerRegistry.Unlock()
 
@@ -13,6 +13,11 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/issueye/sw_runtime/internal/builtins/events"
+	"github.com/issueye/sw_runtime/internal/builtins/stream"
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/security"
+
 	"github.com/dop251/goja"
 )
 
@@ -20,16 +25,17 @@ import (
 type ProxyModule struct {
 	vm      *goja.Runtime
 	proxies map[string]*ProxyServer
+	tcp     []*TCPProxy
 	mutex   sync.RWMutex
+	guard   *security.Guard
 }
 
 // ProxyServer 代理服务器
 type ProxyServer struct {
 	server    *http.Server
 	vm        *goja.Runtime
-	handlers  map[string]goja.Value // event -> handler
-	mutex     sync.RWMutex
-	proxyType string // "http" or "tcp"
+	emitter   *goja.Object // 代理对象（EventEmitter），触发 request、response、error 事件
+	proxyType string       // "http" or "tcp"
 }
 
 // TCPProxy TCP 代理
@@ -37,17 +43,32 @@ type TCPProxy struct {
 	listener net.Listener
 	vm       *goja.Runtime
 	target   string
-	handlers map[string]goja.Value
-	mutex    sync.RWMutex
-	closed   bool
+	emitter  *goja.Object // 代理对象（EventEmitter），触发 connection、data、error、close 事件
+	closed   atomic.Bool
 }
 
 // NewProxyModule 创建代理模块
-func NewProxyModule(vm *goja.Runtime) *ProxyModule {
+func NewProxyModule(vm *goja.Runtime, guard *security.Guard) *ProxyModule {
 	return &ProxyModule{
 		vm:      vm,
 		proxies: make(map[string]*ProxyServer),
+		guard:   guard,
+	}
+}
+
+// Reset 关闭所有 HTTP 和 TCP 代理服务器
+func (p *ProxyModule) Reset() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for port, proxy := range p.proxies {
+		proxy.server.Close()
+		delete(p.proxies, port)
 	}
+	for _, tcpProxy := range p.tcp {
+		tcpProxy.closed.Store(true)
+		tcpProxy.listener.Close()
+	}
+	p.tcp = nil
 }
 
 // GetModule 获取代理模块对象
@@ -76,11 +97,14 @@ func (p *ProxyModule) createHTTPProxy(call goja.FunctionCall) goja.Value {
 	if err != nil {
 		panic(p.vm.NewGoError(fmt.Errorf("invalid target URL: %v", err)))
 	}
+	if err := p.guard.CheckURL(targetURL); err != nil {
+		panic(types.NewPermissionError(p.vm, err))
+	}
 
 	// 创建代理服务器
 	proxy := &ProxyServer{
 		vm:        p.vm,
-		handlers:  make(map[string]goja.Value),
+		emitter:   events.New(p.vm),
 		proxyType: "http",
 	}
 
@@ -100,92 +124,64 @@ func (p *ProxyModule) createHTTPProxy(call goja.FunctionCall) goja.Value {
 		ExpectContinueTimeout: 1 * time.Second,
 	}
 
-	// 请求修改器
+	// 请求修改器：在事件循环中触发 request 事件并等待监听器执行完毕，
+	// 监听器对 req.path 与 req.headers 的修改会应用到转发的请求
 	originalDirector := reverseProxy.Director
 	reverseProxy.Director = func(req *http.Request) {
 		originalDirector(req)
 
-		// 触发 request 事件
-		proxy.mutex.RLock()
-		handler, exists := proxy.handlers["request"]
-		proxy.mutex.RUnlock()
-
-		if exists {
-			if fn, ok := goja.AssertFunction(handler); ok {
-				reqObj := p.vm.NewObject()
-				reqObj.Set("method", req.Method)
-				reqObj.Set("url", req.URL.String())
-				reqObj.Set("path", req.URL.Path)
-				reqObj.Set("host", req.Host)
-				reqObj.Set("remoteAddr", req.RemoteAddr)
-
-				// 请求头
-				headers := p.vm.NewObject()
-				for k, v := range req.Header {
-					if len(v) > 0 {
-						headers.Set(k, v[0])
-					}
-				}
-				reqObj.Set("headers", headers)
-
-				_, err := fn(goja.Undefined(), p.vm.ToValue(reqObj))
-				if err != nil {
-					fmt.Printf("Request handler error: %v\n", err)
-				}
+		info := proxyRequest{
+			method:     req.Method,
+			url:        req.URL.String(),
+			path:       req.URL.Path,
+			host:       req.Host,
+			remoteAddr: req.RemoteAddr,
+			headers:    req.Header.Clone(),
+		}
+		var changes *proxyRequest
+		ok := runSync(req.Context(), p.vm, func(vm *goja.Runtime) {
+			reqObj := info.object(vm)
+			if err := events.Emit(vm, proxy.emitter, "request", reqObj); err != nil {
+				fmt.Printf("Request handler error: %v\n", err)
 			}
+			changes = requestChanges(reqObj)
+		})
+		if ok && changes != nil {
+			changes.apply(req, &info)
 		}
 	}
 
 	// 响应修改器
 	reverseProxy.ModifyResponse = func(resp *http.Response) error {
-		// 触发 response 事件
-		proxy.mutex.RLock()
-		handler, exists := proxy.handlers["response"]
-		proxy.mutex.RUnlock()
-
-		if exists {
-			if fn, ok := goja.AssertFunction(handler); ok {
-				respObj := p.vm.NewObject()
-				respObj.Set("status", resp.StatusCode)
-				respObj.Set("statusText", resp.Status)
-
-				// 响应头
-				headers := p.vm.NewObject()
-				for k, v := range resp.Header {
-					if len(v) > 0 {
-						headers.Set(k, v[0])
-					}
-				}
-				respObj.Set("headers", headers)
-
-				_, err := fn(goja.Undefined(), p.vm.ToValue(respObj))
-				if err != nil {
-					fmt.Printf("Response handler error: %v\n", err)
-				}
-			}
-		}
+		status, statusText, header := resp.StatusCode, resp.Status, resp.Header.Clone()
+		emit(p.vm, proxy.emitter, "response", func(vm *goja.Runtime) []goja.Value {
+			respObj := vm.NewObject()
+			respObj.Set("status", status)
+			respObj.Set("statusText", statusText)
+			respObj.Set("headers", headerObject(vm, header))
+			return []goja.Value{respObj}
+		})
 		return nil
 	}
 
 	// 错误处理器
 	reverseProxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
-		// 触发 error 事件
-		proxy.mutex.RLock()
-		handler, exists := proxy.handlers["error"]
-		proxy.mutex.RUnlock()
-
-		if exists {
-			if fn, ok := goja.AssertFunction(handler); ok {
-				errObj := p.vm.NewObject()
-				errObj.Set("message", err.Error())
-				errObj.Set("url", r.URL.String())
-
-				_, handlerErr := fn(goja.Undefined(), p.vm.ToValue(errObj))
-				if handlerErr != nil {
+		// 触发 error 事件，没有监听器时只返回默认错误响应
+		message, target := err.Error(), r.URL.String()
+		stream.Async(p.vm, func() func(vm *goja.Runtime) error {
+			return func(vm *goja.Runtime) error {
+				if events.ListenerCount(vm, proxy.emitter, "error") == 0 {
+					return nil
+				}
+				errObj := vm.NewObject()
+				errObj.Set("message", message)
+				errObj.Set("url", target)
+				if handlerErr := events.Emit(vm, proxy.emitter, "error", errObj); handlerErr != nil {
 					fmt.Printf("Error handler error: %v\n", handlerErr)
 				}
+				return nil
 			}
-		}
+		})
 
 		// 默认错误响应
 		w.WriteHeader(http.StatusBadGateway)
@@ -210,11 +206,14 @@ func (p *ProxyModule) createTCPProxy(call goja.FunctionCall) goja.Value {
 	}
 
 	target := call.Arguments[0].String()
+	if err := p.guard.CheckAddr(target); err != nil {
+		panic(types.NewPermissionError(p.vm, err))
+	}
 
 	tcpProxy := &TCPProxy{
-		vm:       p.vm,
-		target:   target,
-		handlers: make(map[string]goja.Value),
+		vm:      p.vm,
+		target:  target,
+		emitter: events.New(p.vm),
 	}
 
 	return p.vm.ToValue(p.createTCPProxyObject(tcpProxy))
@@ -222,23 +221,7 @@ func (p *ProxyModule) createTCPProxy(call goja.FunctionCall) goja.Value {
 
 // createProxyObject 创建 HTTP 代理对象
 func (p *ProxyModule) createProxyObject(proxy *ProxyServer) *goja.Object {
-	obj := p.vm.NewObject()
-
-	// 注册事件监听器
-	obj.Set("on", func(call goja.FunctionCall) goja.Value {
-		if len(call.Arguments) < 2 {
-			panic(p.vm.NewTypeError("on requires event name and handler"))
-		}
-
-		event := call.Arguments[0].String()
-		handler := call.Arguments[1]
-
-		proxy.mutex.Lock()
-		proxy.handlers[event] = handler
-		proxy.mutex.Unlock()
-
-		return goja.Undefined()
-	})
+	obj := proxy.emitter
 
 	// 启动代理服务器
 	obj.Set("listen", func(call goja.FunctionCall) goja.Value {
@@ -250,6 +233,9 @@ func (p *ProxyModule) createProxyObject(proxy *ProxyServer) *goja.Object {
 		if port[0] != ':' {
 			port = ":" + port
 		}
+		if err := p.guard.CheckAddr(port); err != nil {
+			return types.RejectPermission(p.vm, err)
+		}
 
 		var callback goja.Value
 		if len(call.Arguments) > 1 {
@@ -259,31 +245,38 @@ func (p *ProxyModule) createProxyObject(proxy *ProxyServer) *goja.Object {
 		}
 
 		promise, resolve, reject := p.vm.NewPromise()
+		proxy.server.Addr = port
 
-		go func() {
-			proxy.server.Addr = port
+		// 在 goroutine 中绑定端口，回调与 Promise 在事件循环中执行
+		stream.Async(p.vm, func() func(vm *goja.Runtime) error {
+			listener, err := net.Listen("tcp", port)
+			return func(vm *goja.Runtime) error {
+				if err != nil {
+					return reject(vm.NewGoError(err))
+				}
 
-			p.mutex.Lock()
-			p.proxies[port] = proxy
-			p.mutex.Unlock()
+				p.mutex.Lock()
+				p.proxies[port] = proxy
+				p.mutex.Unlock()
 
-			// 调用回调函数
-			if callback != nil {
-				if fn, ok := goja.AssertFunction(callback); ok {
-					_, err := fn(goja.Undefined())
-					if err != nil {
-						fmt.Printf("Callback error: %v\n", err)
+				// 启动服务器
+				go func() {
+					if err := proxy.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+						fmt.Printf("HTTP proxy error: %v\n", err)
 					}
-				}
-			}
-
-			resolve(p.vm.ToValue(fmt.Sprintf("HTTP Proxy listening on %s", port)))
+				}()
 
-			// 启动服务器
-			if err := proxy.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-				reject(p.vm.NewGoError(err))
+				// 调用回调函数
+				if callback != nil {
+					if fn, ok := goja.AssertFunction(callback); ok {
+						if _, err := fn(goja.Undefined()); err != nil {
+							fmt.Printf("Callback error: %v\n", err)
+						}
+					}
+				}
+				return resolve(vm.ToValue(fmt.Sprintf("HTTP Proxy listening on %s", port)))
 			}
-		}()
+		})
 
 		return p.vm.ToValue(promise)
 	})
@@ -292,18 +285,20 @@ func (p *ProxyModule) createProxyObject(proxy *ProxyServer) *goja.Object {
 	obj.Set("close", func(call goja.FunctionCall) goja.Value {
 		promise, resolve, reject := p.vm.NewPromise()
 
-		go func() {
+		stream.Async(p.vm, func() func(vm *goja.Runtime) error {
+			var err error
 			if proxy.server != nil {
 				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 				defer cancel()
-
-				if err := proxy.server.Shutdown(ctx); err != nil {
-					reject(p.vm.NewGoError(err))
-					return
+				err = proxy.server.Shutdown(ctx)
+			}
+			return func(vm *goja.Runtime) error {
+				if err != nil {
+					return reject(vm.NewGoError(err))
 				}
+				return resolve(vm.ToValue("Proxy server closed"))
 			}
-			resolve(p.vm.ToValue("Proxy server closed"))
-		}()
+		})
 
 		return p.vm.ToValue(promise)
 	})
@@ -313,23 +308,7 @@ func (p *ProxyModule) createProxyObject(proxy *ProxyServer) *goja.Object {
 
 // createTCPProxyObject 创建 TCP 代理对象
 func (p *ProxyModule) createTCPProxyObject(tcpProxy *TCPProxy) *goja.Object {
-	obj := p.vm.NewObject()
-
-	// 注册事件监听器
-	obj.Set("on", func(call goja.FunctionCall) goja.Value {
-		if len(call.Arguments) < 2 {
-			panic(p.vm.NewTypeError("on requires event name and handler"))
-		}
-
-		event := call.Arguments[0].String()
-		handler := call.Arguments[1]
-
-		tcpProxy.mutex.Lock()
-		tcpProxy.handlers[event] = handler
-		tcpProxy.mutex.Unlock()
-
-		return goja.Undefined()
-	})
+	obj := tcpProxy.emitter
 
 	// 启动 TCP 代理服务器
 	obj.Set("listen", func(call goja.FunctionCall) goja.Value {
@@ -341,6 +320,9 @@ func (p *ProxyModule) createTCPProxyObject(tcpProxy *TCPProxy) *goja.Object {
 		if port[0] != ':' {
 			port = ":" + port
 		}
+		if err := p.guard.CheckAddr(port); err != nil {
+			return types.RejectPermission(p.vm, err)
+		}
 
 		var callback goja.Value
 		if len(call.Arguments) > 1 {
@@ -351,56 +333,30 @@ func (p *ProxyModule) createTCPProxyObject(tcpProxy *TCPProxy) *goja.Object {
 
 		promise, resolve, reject := p.vm.NewPromise()
 
-		go func() {
+		stream.Async(p.vm, func() func(vm *goja.Runtime) error {
 			listener, err := net.Listen("tcp", port)
-			if err != nil {
-				reject(p.vm.NewGoError(err))
-				return
-			}
-
-			tcpProxy.listener = listener
-
-			// 调用回调函数
-			if callback != nil {
-				if fn, ok := goja.AssertFunction(callback); ok {
-					_, err := fn(goja.Undefined())
-					if err != nil {
-						fmt.Printf("Callback error: %v\n", err)
-					}
-				}
-			}
-
-			resolve(p.vm.ToValue(fmt.Sprintf("TCP Proxy listening on %s", port)))
-
-			// 接受连接
-			for {
-				if tcpProxy.closed {
-					break
+			return func(vm *goja.Runtime) error {
+				if err != nil {
+					return reject(vm.NewGoError(err))
 				}
 
-				conn, err := listener.Accept()
-				if err != nil {
-					if !tcpProxy.closed {
-						// 触发 error 事件
-						tcpProxy.mutex.RLock()
-						handler, exists := tcpProxy.handlers["error"]
-						tcpProxy.mutex.RUnlock()
-
-						if exists {
-							if fn, ok := goja.AssertFunction(handler); ok {
-								errObj := p.vm.NewObject()
-								errObj.Set("message", err.Error())
-								_, _ = fn(goja.Undefined(), p.vm.ToValue(errObj))
-							}
+				tcpProxy.listener = listener
+				p.mutex.Lock()
+				p.tcp = append(p.tcp, tcpProxy)
+				p.mutex.Unlock()
+				go p.acceptTCPProxy(tcpProxy)
+
+				// 调用回调函数
+				if callback != nil {
+					if fn, ok := goja.AssertFunction(callback); ok {
+						if _, err := fn(goja.Undefined()); err != nil {
+							fmt.Printf("Callback error: %v\n", err)
 						}
 					}
-					continue
 				}
-
-				// 处理连接
-				go p.handleTCPProxyConnection(tcpProxy, conn)
+				return resolve(vm.ToValue(fmt.Sprintf("TCP Proxy listening on %s", port)))
 			}
-		}()
+		})
 
 		return p.vm.ToValue(promise)
 	})
@@ -409,16 +365,20 @@ func (p *ProxyModule) createTCPProxyObject(tcpProxy *TCPProxy) *goja.Object {
 	obj.Set("close", func(call goja.FunctionCall) goja.Value {
 		promise, resolve, reject := p.vm.NewPromise()
 
-		go func() {
-			tcpProxy.closed = true
-			if tcpProxy.listener != nil {
-				if err := tcpProxy.listener.Close(); err != nil {
-					reject(p.vm.NewGoError(err))
-					return
+		tcpProxy.closed.Store(true)
+		listener := tcpProxy.listener
+		stream.Async(p.vm, func() func(vm *goja.Runtime) error {
+			var err error
+			if listener != nil {
+				err = listener.Close()
+			}
+			return func(vm *goja.Runtime) error {
+				if err != nil {
+					return reject(vm.NewGoError(err))
 				}
+				return resolve(vm.ToValue("TCP Proxy server closed"))
 			}
-			resolve(p.vm.ToValue("TCP Proxy server closed"))
-		}()
+		})
 
 		return p.vm.ToValue(promise)
 	})
@@ -426,7 +386,24 @@ func (p *ProxyModule) createTCPProxyObject(tcpProxy *TCPProxy) *goja.Object {
 	return obj
 }
 
-// handleTCPProxyConnection 处理 TCP 代理连接
+// acceptTCPProxy 接受 TCP 代理的连接，直到代理关闭
+func (p *ProxyModule) acceptTCPProxy(tcpProxy *TCPProxy) {
+	for !tcpProxy.closed.Load() {
+		conn, err := tcpProxy.listener.Accept()
+		if err != nil {
+			if !tcpProxy.closed.Load() {
+				// 触发 error 事件
+				emit(p.vm, tcpProxy.emitter, "error", errorEvent(err.Error(), ""))
+			}
+			continue
+		}
+
+		// 处理连接
+		go p.handleTCPProxyConnection(tcpProxy, conn)
+	}
+}
+
+// handleTCPProxyConnection 处理 TCP 代理连接，事件在事件循环中触发
 func (p *ProxyModule) handleTCPProxyConnection(tcpProxy *TCPProxy, clientConn net.Conn) {
 	defer clientConn.Close()
 
@@ -434,122 +411,163 @@ func (p *ProxyModule) handleTCPProxyConnection(tcpProxy *TCPProxy, clientConn ne
 	targetConn, err := net.DialTimeout("tcp", tcpProxy.target, 10*time.Second)
 	if err != nil {
 		// 触发 error 事件
-		tcpProxy.mutex.RLock()
-		handler, exists := tcpProxy.handlers["error"]
-		tcpProxy.mutex.RUnlock()
-
-		if exists {
-			if fn, ok := goja.AssertFunction(handler); ok {
-				errObj := p.vm.NewObject()
-				errObj.Set("message", fmt.Sprintf("Failed to connect to target: %v", err))
-				_, _ = fn(goja.Undefined(), p.vm.ToValue(errObj))
-			}
-		}
+		emit(p.vm, tcpProxy.emitter, "error", errorEvent(fmt.Sprintf("Failed to connect to target: %v", err), ""))
 		return
 	}
 	defer targetConn.Close()
 
 	// 触发 connection 事件
-	tcpProxy.mutex.RLock()
-	connHandler, exists := tcpProxy.handlers["connection"]
-	tcpProxy.mutex.RUnlock()
-
-	if exists {
-		if fn, ok := goja.AssertFunction(connHandler); ok {
-			connObj := p.vm.NewObject()
-			connObj.Set("remoteAddr", clientConn.RemoteAddr().String())
-			connObj.Set("target", tcpProxy.target)
-			_, _ = fn(goja.Undefined(), p.vm.ToValue(connObj))
-		}
-	}
+	remoteAddr := clientConn.RemoteAddr().String()
+	emit(p.vm, tcpProxy.emitter, "connection", func(vm *goja.Runtime) []goja.Value {
+		connObj := vm.NewObject()
+		connObj.Set("remoteAddr", remoteAddr)
+		connObj.Set("target", tcpProxy.target)
+		return []goja.Value{connObj}
+	})
 
 	// 双向转发数据
 	var wg sync.WaitGroup
 	wg.Add(2)
-
-	// 客户端 -> 目标服务器
-	go func() {
+	forward := func(dst, src net.Conn, direction string) {
 		defer wg.Done()
-		bytesTransferred, err := io.Copy(targetConn, clientConn)
+		bytesTransferred, err := io.Copy(dst, src)
 
 		// 触发 data 事件
-		tcpProxy.mutex.RLock()
-		dataHandler, exists := tcpProxy.handlers["data"]
-		tcpProxy.mutex.RUnlock()
-
-		if exists && bytesTransferred > 0 {
-			if fn, ok := goja.AssertFunction(dataHandler); ok {
-				dataObj := p.vm.NewObject()
-				dataObj.Set("direction", "client->target")
+		if bytesTransferred > 0 {
+			emit(p.vm, tcpProxy.emitter, "data", func(vm *goja.Runtime) []goja.Value {
+				dataObj := vm.NewObject()
+				dataObj.Set("direction", direction)
 				dataObj.Set("bytes", bytesTransferred)
-				_, _ = fn(goja.Undefined(), p.vm.ToValue(dataObj))
-			}
+				return []goja.Value{dataObj}
+			})
 		}
 
 		if err != nil && err != io.EOF {
 			// 触发 error 事件
-			tcpProxy.mutex.RLock()
-			errHandler, exists := tcpProxy.handlers["error"]
-			tcpProxy.mutex.RUnlock()
-
-			if exists {
-				if fn, ok := goja.AssertFunction(errHandler); ok {
-					errObj := p.vm.NewObject()
-					errObj.Set("message", err.Error())
-					errObj.Set("direction", "client->target")
-					_, _ = fn(goja.Undefined(), p.vm.ToValue(errObj))
-				}
-			}
+			emit(p.vm, tcpProxy.emitter, "error", errorEvent(err.Error(), direction))
 		}
-	}()
+	}
+
+	// 客户端 -> 目标服务器
+	go forward(targetConn, clientConn, "client->target")
 
 	// 目标服务器 -> 客户端
-	go func() {
-		defer wg.Done()
-		bytesTransferred, err := io.Copy(clientConn, targetConn)
+	go forward(clientConn, targetConn, "target->client")
 
-		// 触发 data 事件
-		tcpProxy.mutex.RLock()
-		dataHandler, exists := tcpProxy.handlers["data"]
-		tcpProxy.mutex.RUnlock()
-
-		if exists && bytesTransferred > 0 {
-			if fn, ok := goja.AssertFunction(dataHandler); ok {
-				dataObj := p.vm.NewObject()
-				dataObj.Set("direction", "target->client")
-				dataObj.Set("bytes", bytesTransferred)
-				_, _ = fn(goja.Undefined(), p.vm.ToValue(dataObj))
-			}
+	wg.Wait()
+
+	// 触发 close 事件
+	emit(p.vm, tcpProxy.emitter, "close", func(vm *goja.Runtime) []goja.Value { return nil })
+}
+
+// proxyRequest 转发请求的快照，在事件循环中转换为 request 事件的参数
+type proxyRequest struct {
+	method     string
+	url        string
+	path       string
+	host       string
+	remoteAddr string
+	headers    http.Header
+}
+
+// object 创建 request 事件的请求对象
+func (r *proxyRequest) object(vm *goja.Runtime) *goja.Object {
+	reqObj := vm.NewObject()
+	reqObj.Set("method", r.method)
+	reqObj.Set("url", r.url)
+	reqObj.Set("path", r.path)
+	reqObj.Set("host", r.host)
+	reqObj.Set("remoteAddr", r.remoteAddr)
+	reqObj.Set("headers", headerObject(vm, r.headers))
+	return reqObj
+}
+
+// requestChanges 读取监听器修改后的 path 与 headers，headers 中删除的请求头会从转发的请求中移除
+func requestChanges(reqObj *goja.Object) *proxyRequest {
+	changed := &proxyRequest{path: reqObj.Get("path").String(), headers: http.Header{}}
+	headers, ok := reqObj.Get("headers").(*goja.Object)
+	if !ok {
+		return changed
+	}
+	for _, key := range headers.Keys() {
+		value := headers.Get(key)
+		if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+			continue
 		}
+		changed.headers.Set(key, value.String())
+	}
+	return changed
+}
 
-		if err != nil && err != io.EOF {
-			// 触发 error 事件
-			tcpProxy.mutex.RLock()
-			errHandler, exists := tcpProxy.handlers["error"]
-			tcpProxy.mutex.RUnlock()
-
-			if exists {
-				if fn, ok := goja.AssertFunction(errHandler); ok {
-					errObj := p.vm.NewObject()
-					errObj.Set("message", err.Error())
-					errObj.Set("direction", "target->client")
-					_, _ = fn(goja.Undefined(), p.vm.ToValue(errObj))
-				}
-			}
+// apply 将监听器相对 original 所做的修改应用到转发的请求
+func (r *proxyRequest) apply(req *http.Request, original *proxyRequest) {
+	if r.path != original.path {
+		req.URL.Path = r.path
+		req.URL.RawPath = ""
+	}
+	for key := range original.headers {
+		if _, kept := r.headers[key]; !kept {
+			req.Header.Del(key)
+		}
+	}
+	for key, values := range r.headers {
+		if original.headers.Get(key) != values[0] {
+			req.Header[key] = values
+		}
+	}
+}
+
+// headerObject 将 HTTP 头转换为 JS 对象，每个头只保留第一个取值
+func headerObject(vm *goja.Runtime, header http.Header) *goja.Object {
+	headers := vm.NewObject()
+	for k, v := range header {
+		if len(v) > 0 {
+			headers.Set(k, v[0])
 		}
-	}()
+	}
+	return headers
+}
 
-	wg.Wait()
+// errorEvent 创建 error 事件的参数，direction 为空时不设置
+func errorEvent(message, direction string) func(vm *goja.Runtime) []goja.Value {
+	return func(vm *goja.Runtime) []goja.Value {
+		errObj := vm.NewObject()
+		errObj.Set("message", message)
+		if direction != "" {
+			errObj.Set("direction", direction)
+		}
+		return []goja.Value{errObj}
+	}
+}
 
-	// 触发 close 事件
-	tcpProxy.mutex.RLock()
-	closeHandler, exists := tcpProxy.handlers["close"]
-	tcpProxy.mutex.RUnlock()
+// emit 在事件循环中触发事件，args 在事件循环中创建参数，监听器抛出的异常只输出日志
+func emit(vm *goja.Runtime, emitter *goja.Object, event string, args func(vm *goja.Runtime) []goja.Value) {
+	stream.Async(vm, func() func(vm *goja.Runtime) error {
+		return func(vm *goja.Runtime) error {
+			if err := events.Emit(vm, emitter, event, args(vm)...); err != nil {
+				fmt.Printf("Proxy %s handler error: %v\n", event, err)
+			}
+			return nil
+		}
+	})
+}
 
-	if exists {
-		if fn, ok := goja.AssertFunction(closeHandler); ok {
-			_, _ = fn(goja.Undefined())
+// runSync 在事件循环中执行 fn 并等待其完成。ctx 先结束时返回 false，fn 之后仍会执行，
+// 调用方不应再读取 fn 写入的结果
+func runSync(ctx context.Context, vm *goja.Runtime, fn func(vm *goja.Runtime)) bool {
+	done := make(chan struct{})
+	stream.Async(vm, func() func(vm *goja.Runtime) error {
+		return func(vm *goja.Runtime) error {
+			defer close(done)
+			fn(vm)
+			return nil
 		}
+	})
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
 	}
 }
 
@@ -7,6 +7,11 @@ import (
 	"sync"
 	"time"
 
+	"github.com/issueye/sw_runtime/internal/builtins/buffer"
+	"github.com/issueye/sw_runtime/internal/builtins/events"
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/security"
+
 	"github.com/dop251/goja"
 	"github.com/gorilla/websocket"
 )
@@ -17,13 +22,25 @@ type WebSocketModule struct {
 	conns map[int]*websocket.Conn
 	mutex sync.RWMutex
 	id    int
+	guard *security.Guard
 }
 
 // NewWebSocketModule 创建 WebSocket 客户端模块
-func NewWebSocketModule(vm *goja.Runtime) *WebSocketModule {
+func NewWebSocketModule(vm *goja.Runtime, guard *security.Guard) *WebSocketModule {
 	return &WebSocketModule{
 		vm:    vm,
 		conns: make(map[int]*websocket.Conn),
+		guard: guard,
+	}
+}
+
+// Reset 关闭所有 WebSocket 连接
+func (w *WebSocketModule) Reset() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for id, conn := range w.conns {
+		conn.Close()
+		delete(w.conns, id)
 	}
 }
 
@@ -47,6 +64,9 @@ func (w *WebSocketModule) connect(call goja.FunctionCall) goja.Value {
 	}
 
 	url := call.Arguments[0].String()
+	if err := w.guard.CheckURL(url); err != nil {
+		return types.RejectPermission(w.vm, err)
+	}
 
 	// 解析选项
 	var options *websocket.Dialer
@@ -127,37 +147,20 @@ func (w *WebSocketModule) connect(call goja.FunctionCall) goja.Value {
 	return w.vm.ToValue(promise)
 }
 
-// createClientObject 创建客户端对象
+// createClientObject 创建客户端对象（EventEmitter），触发 message、pong、close、error 事件
 func (w *WebSocketModule) createClientObject(conn *websocket.Conn, connID int) goja.Value {
-	obj := w.vm.NewObject()
-
-	// 事件监听器
-	listeners := make(map[string][]goja.Value)
-	var listenersMutex sync.RWMutex
+	obj := events.New(w.vm)
 
 	// 连接状态
 	var closed bool
 	var closeMutex sync.RWMutex
 
-	// 设置事件监听器
-	obj.Set("on", func(call goja.FunctionCall) goja.Value {
-		if len(call.Arguments) < 2 {
-			return goja.Undefined()
+	// emit 触发事件，监听器抛出的异常只输出日志，不中断接收循环
+	emit := func(event string, args ...goja.Value) {
+		if err := events.Emit(w.vm, obj, event, args...); err != nil {
+			fmt.Printf("WebSocket %s handler error: %v\n", event, err)
 		}
-
-		eventName := call.Arguments[0].String()
-		handler := call.Arguments[1]
-
-		if _, ok := goja.AssertFunction(handler); !ok {
-			return goja.Undefined()
-		}
-
-		listenersMutex.Lock()
-		listeners[eventName] = append(listeners[eventName], handler)
-		listenersMutex.Unlock()
-
-		return goja.Undefined()
-	})
+	}
 
 	// 发送文本消息
 	obj.Set("send", func(call goja.FunctionCall) goja.Value {
@@ -177,6 +180,11 @@ func (w *WebSocketModule) createClientObject(conn *websocket.Conn, connID int) g
 		var message []byte
 		var messageType int
 
+		// 二进制数据（Buffer、TypedArray、ArrayBuffer）作为二进制消息发送
+		if binary, ok := buffer.Bytes(w.vm, call.Arguments[0]); ok {
+			data = binary
+		}
+
 		// 判断消息类型
 		switch v := data.(type) {
 		case string:
@@ -249,15 +257,12 @@ func (w *WebSocketModule) createClientObject(conn *websocket.Conn, connID int) g
 			return w.vm.ToValue(false)
 		}
 
-		data := call.Arguments[0].Export()
 		var message []byte
-
-		switch v := data.(type) {
-		case []byte:
-			message = v
-		case string:
-			message = []byte(v)
-		default:
+		if binary, ok := buffer.Bytes(w.vm, call.Arguments[0]); ok {
+			message = binary
+		} else if s, ok := call.Arguments[0].Export().(string); ok {
+			message = []byte(s)
+		} else {
 			return w.vm.ToValue(false)
 		}
 
@@ -282,7 +287,7 @@ func (w *WebSocketModule) createClientObject(conn *websocket.Conn, connID int) g
 
 		data := []byte{}
 		if len(call.Arguments) > 0 {
-			data = []byte(call.Arguments[0].String())
+			data = buffer.ToBytes(w.vm, call.Arguments[0])
 		}
 
 		err := conn.WriteMessage(websocket.PingMessage, data)
@@ -354,43 +359,14 @@ func (w *WebSocketModule) createClientObject(conn *websocket.Conn, connID int) g
 		for {
 			messageType, message, err := conn.ReadMessage()
 			if err != nil {
-				// 触发 error 或 close 事件
-				listenersMutex.RLock()
-				handlers := listeners["close"]
-				listenersMutex.RUnlock()
-
-				for _, handler := range handlers {
-					if fn, ok := goja.AssertFunction(handler); ok {
-						func() {
-							defer func() {
-								if r := recover(); r != nil {
-									fmt.Printf("WebSocket close handler panic: %v\n", r)
-								}
-							}()
-							fn(goja.Undefined())
-						}()
-					}
-				}
-
-				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
-					listenersMutex.RLock()
-					errorHandlers := listeners["error"]
-					listenersMutex.RUnlock()
-
-					for _, handler := range errorHandlers {
-						if fn, ok := goja.AssertFunction(handler); ok {
-							func() {
-								defer func() {
-									if r := recover(); r != nil {
-										fmt.Printf("WebSocket error handler panic: %v\n", r)
-									}
-								}()
-								errObj := w.vm.NewObject()
-								errObj.Set("message", err.Error())
-								fn(goja.Undefined(), w.vm.ToValue(errObj))
-							}()
-						}
-					}
+				// 触发 close 事件，异常关闭时再触发 error 事件（没有监听器时忽略）
+				emit("close")
+
+				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) &&
+					events.ListenerCount(w.vm, obj, "error") > 0 {
+					errObj := w.vm.NewObject()
+					errObj.Set("message", err.Error())
+					emit("error", errObj)
 				}
 				break
 			}
@@ -407,47 +383,17 @@ func (w *WebSocketModule) createClientObject(conn *websocket.Conn, connID int) g
 					data = string(message)
 				}
 			case websocket.BinaryMessage:
-				data = message
+				data = buffer.New(w.vm, message)
 			case websocket.PongMessage:
 				// 触发 pong 事件
-				listenersMutex.RLock()
-				pongHandlers := listeners["pong"]
-				listenersMutex.RUnlock()
-
-				for _, handler := range pongHandlers {
-					if fn, ok := goja.AssertFunction(handler); ok {
-						func() {
-							defer func() {
-								if r := recover(); r != nil {
-									fmt.Printf("WebSocket pong handler panic: %v\n", r)
-								}
-							}()
-							fn(goja.Undefined(), w.vm.ToValue(string(message)))
-						}()
-					}
-				}
+				emit("pong", w.vm.ToValue(string(message)))
 				continue
 			default:
 				continue
 			}
 
 			// 触发 message 事件
-			listenersMutex.RLock()
-			handlers := listeners["message"]
-			listenersMutex.RUnlock()
-
-			for _, handler := range handlers {
-				if fn, ok := goja.AssertFunction(handler); ok {
-					func() {
-						defer func() {
-							if r := recover(); r != nil {
-								fmt.Printf("WebSocket message handler panic: %v\n", r)
-							}
-						}()
-						fn(goja.Undefined(), w.vm.ToValue(data))
-					}()
-				}
-			}
+			emit("message", w.vm.ToValue(data))
 		}
 	}()
 
@@ -0,0 +1,133 @@
+package process
+
+import (
+	"sync"
+
+	"github.com/issueye/sw_runtime/internal/builtins/events"
+
+	"github.com/dop251/goja"
+)
+
+// Events process 对象的事件（exit、beforeExit、SIGINT 等信号、uncaughtException、unhandledRejection）
+// 和 process.exitCode。监听器保存在 process 模块对象的 EventEmitter 中，只在 VM 线程中调用；
+// HasListeners 和 ExitCode 可以在任意 goroutine 中调用
+type Events struct {
+	vm      *goja.Runtime
+	emitter *goja.Object // process 模块对象，未创建时为 nil
+
+	mu       sync.Mutex
+	counts   map[string]int // 各事件的监听器数量
+	exitCode goja.Value     // 未设置时为 nil
+	exited   bool           // exit 事件已触发
+}
+
+// newEvents 创建 process 事件
+func newEvents(vm *goja.Runtime) *Events {
+	return &Events{
+		vm:     vm,
+		counts: make(map[string]int),
+	}
+}
+
+// install 将 EventEmitter 对象作为 process 事件的目标，并添加 exitCode 属性。
+// 事件方法绑定到该对象，以它为原型的全局 process 添加的监听器也保存在同一处
+func (e *Events) install(obj *goja.Object) {
+	e.emitter = obj
+	events.Bind(e.vm, obj, obj)
+	events.Watch(e.vm, obj, e.watch)
+	obj.DefineAccessorProperty("exitCode", e.vm.ToValue(e.getExitCode), e.vm.ToValue(e.setExitCode), goja.FLAG_FALSE, goja.FLAG_TRUE)
+}
+
+// watch 记录事件的监听器数量
+func (e *Events) watch(name string, count int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if count == 0 {
+		delete(e.counts, name)
+		return
+	}
+	e.counts[name] = count
+}
+
+// getExitCode process.exitCode 的 Getter，未设置时为 undefined
+func (e *Events) getExitCode(call goja.FunctionCall) goja.Value {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.exitCode == nil {
+		return goja.Undefined()
+	}
+	return e.exitCode
+}
+
+// setExitCode process.exitCode 的 Setter，只接受整数或 undefined
+func (e *Events) setExitCode(call goja.FunctionCall) goja.Value {
+	v := call.Argument(0)
+	if goja.IsUndefined(v) || goja.IsNull(v) {
+		v = nil
+	} else if n := v.ToFloat(); n != float64(int64(n)) {
+		panic(e.vm.NewTypeError("The \"code\" argument must be an integer"))
+	} else {
+		v = e.vm.ToValue(int64(n))
+	}
+
+	e.mu.Lock()
+	e.exitCode = v
+	e.mu.Unlock()
+	return goja.Undefined()
+}
+
+// ExitCode 返回 process.exitCode，未设置时为 0
+func (e *Events) ExitCode() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.exitCode == nil {
+		return 0
+	}
+	return int(e.exitCode.ToInteger())
+}
+
+// HasListeners 返回事件是否有监听器
+func (e *Events) HasListeners(name string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.counts[name] > 0
+}
+
+// Emit 依次调用事件的监听器，返回是否有监听器。
+// 监听器抛出异常时停止调用后续监听器并返回该异常，必须在 VM 线程中调用
+func (e *Events) Emit(name string, args ...goja.Value) (bool, error) {
+	if e.emitter == nil || !e.HasListeners(name) {
+		return false, nil
+	}
+	return true, events.Emit(e.vm, e.emitter, name, args...)
+}
+
+// EmitExit 设置 exitCode 并触发 exit 事件，每个 VM 只触发一次
+func (e *Events) EmitExit(code int) error {
+	e.mu.Lock()
+	if e.exited {
+		e.mu.Unlock()
+		return nil
+	}
+	e.exited = true
+	e.exitCode = e.vm.ToValue(code)
+	e.mu.Unlock()
+
+	_, err := e.Emit("exit", e.vm.ToValue(code))
+	return err
+}
+
+// Reset 移除全部监听器并清除 exitCode，必须在 VM 线程中或事件循环空闲时调用
+func (e *Events) Reset() {
+	if e.emitter != nil {
+		if removeAll, ok := goja.AssertFunction(e.emitter.Get("removeAllListeners")); ok {
+			removeAll(e.emitter)
+		}
+	}
+
+	e.mu.Lock()
+	e.counts = make(map[string]int)
+	e.exitCode = nil
+	e.exited = false
+	e.mu.Unlock()
+}
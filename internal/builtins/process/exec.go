@@ -11,17 +11,38 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/security"
+
 	"github.com/dop251/goja"
 )
 
 // ExecModule 命令执行模块
 type ExecModule struct {
-	vm *goja.Runtime
+	vm    *goja.Runtime
+	guard *security.Guard
 }
 
 // NewExecModule 创建命令执行模块
-func NewExecModule(vm *goja.Runtime) *ExecModule {
-	return &ExecModule{vm: vm}
+func NewExecModule(vm *goja.Runtime, guard *security.Guard) *ExecModule {
+	return &ExecModule{vm: vm, guard: guard}
+}
+
+// checkRun 检查是否允许执行命令，不允许时抛出 PermissionDenied
+func (e *ExecModule) checkRun(command string) {
+	if err := e.guard.CheckRun(command); err != nil {
+		panic(types.NewPermissionError(e.vm, err))
+	}
+}
+
+// shellCommand 创建通过系统 shell 执行的命令，需要 shell 本身的执行权限
+func (e *ExecModule) shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		e.checkRun("cmd")
+		return exec.Command("cmd", "/C", command)
+	}
+	e.checkRun("sh")
+	return exec.Command("sh", "-c", command)
 }
 
 // GetModule 获取模块对象
@@ -35,6 +56,9 @@ func (e *ExecModule) GetModule() *goja.Object {
 	// 异步执行命令
 	obj.Set("execAsync", e.execAsync)
 
+	// 启动子进程，以流的方式读写标准输入输出
+	obj.Set("spawn", e.spawn)
+
 	// 执行命令并返回输出
 	obj.Set("run", e.run)
 
@@ -88,6 +112,7 @@ func (e *ExecModule) execSync(call goja.FunctionCall) goja.Value {
 	options := e.parseOptions(call, 2)
 
 	// 创建命令
+	e.checkRun(command)
 	cmd := exec.Command(command, args...)
 
 	// 设置工作目录
@@ -159,6 +184,9 @@ func (e *ExecModule) execAsync(call goja.FunctionCall) goja.Value {
 	options := e.parseOptions(call, 2)
 
 	// 创建 Promise
+	if err := e.guard.CheckRun(command); err != nil {
+		return types.RejectPermission(e.vm, err)
+	}
 	promise, resolve, _ := e.vm.NewPromise()
 
 	go func() {
@@ -223,12 +251,7 @@ func (e *ExecModule) run(call goja.FunctionCall) goja.Value {
 	command := call.Arguments[0].String()
 
 	// 根据操作系统选择 shell
-	var cmd *exec.Cmd
-	if runtime.GOOS == "windows" {
-		cmd = exec.Command("cmd", "/C", command)
-	} else {
-		cmd = exec.Command("sh", "-c", command)
-	}
+	cmd := e.shellCommand(command)
 
 	// 解析选项
 	if len(call.Arguments) > 1 {
@@ -272,12 +295,7 @@ func (e *ExecModule) shell(call goja.FunctionCall) goja.Value {
 	command := call.Arguments[0].String()
 
 	// 根据操作系统选择 shell
-	var cmd *exec.Cmd
-	if runtime.GOOS == "windows" {
-		cmd = exec.Command("cmd", "/C", command)
-	} else {
-		cmd = exec.Command("sh", "-c", command)
-	}
+	cmd := e.shellCommand(command)
 
 	// 解析选项
 	if len(call.Arguments) > 1 {
@@ -342,6 +360,7 @@ func (e *ExecModule) execWithTimeout(call goja.FunctionCall) goja.Value {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
+	e.checkRun(command)
 	cmd := exec.CommandContext(ctx, command, args...)
 
 	var stdout, stderr bytes.Buffer
@@ -384,11 +403,11 @@ func (e *ExecModule) execWithTimeout(call goja.FunctionCall) goja.Value {
 // getEnv 获取环境变量
 func (e *ExecModule) getEnv(call goja.FunctionCall) goja.Value {
 	if len(call.Arguments) < 1 {
-		// 返回所有环境变量
+		// 返回所有环境变量（启用权限限制时只包含允许访问的变量）
 		envMap := e.vm.NewObject()
 		for _, env := range os.Environ() {
 			parts := strings.SplitN(env, "=", 2)
-			if len(parts) == 2 {
+			if len(parts) == 2 && e.guard.CheckEnv(parts[0]) == nil {
 				envMap.Set(parts[0], parts[1])
 			}
 		}
@@ -396,6 +415,9 @@ func (e *ExecModule) getEnv(call goja.FunctionCall) goja.Value {
 	}
 
 	key := call.Arguments[0].String()
+	if err := e.guard.CheckEnv(key); err != nil {
+		panic(types.NewPermissionError(e.vm, err))
+	}
 	value := os.Getenv(key)
 
 	if value == "" {
@@ -417,6 +439,9 @@ func (e *ExecModule) setEnv(call goja.FunctionCall) goja.Value {
 
 	key := call.Arguments[0].String()
 	value := call.Arguments[1].String()
+	if err := e.guard.CheckEnv(key); err != nil {
+		panic(types.NewPermissionError(e.vm, err))
+	}
 
 	err := os.Setenv(key, value)
 	if err != nil {
@@ -442,6 +467,9 @@ func (e *ExecModule) chdir(call goja.FunctionCall) goja.Value {
 	}
 
 	path := call.Arguments[0].String()
+	if err := e.guard.CheckRead(path); err != nil {
+		panic(types.NewPermissionError(e.vm, err))
+	}
 	err := os.Chdir(path)
 	if err != nil {
 		return e.vm.ToValue(false)
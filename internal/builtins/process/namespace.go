@@ -1,9 +1,12 @@
 package process
 
 import (
-	"sw_runtime/internal/builtins/types"
+	"io"
 	"time"
 
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/security"
+
 	"github.com/dop251/goja"
 )
 
@@ -15,11 +18,11 @@ type Namespace struct {
 }
 
 // NewNamespace 创建 net 命名空间
-func NewNamespace(vm *goja.Runtime, args []string, startTime time.Time) *Namespace {
+func NewNamespace(vm *goja.Runtime, args []string, startTime time.Time, guard *security.Guard) *Namespace {
 	return &Namespace{
 		vm:      vm,
-		exec:    NewExecModule(vm),
-		process: NewProcessModule(vm, args, startTime),
+		exec:    NewExecModule(vm, guard),
+		process: NewProcessModule(vm, args, startTime, guard),
 	}
 }
 
@@ -33,9 +36,33 @@ func (n *Namespace) GetModule() *goja.Object {
 	processObj := n.process.GetModule()
 	obj.Set("process", processObj)
 
+	// 全局 process 即命名空间对象，以 process 模块对象为原型，
+	// 直接暴露 argv、env、on、exitCode、profile 等属性
+	obj.SetPrototype(processObj)
+
 	return obj
 }
 
+// SetArgv 设置命令行参数
+func (n *Namespace) SetArgv(argv []string) {
+	n.process.SetArgv(argv)
+}
+
+// SetHeapRoots 设置 process.profile.heapSummary 的遍历起点
+func (n *Namespace) SetHeapRoots(roots func() []goja.Value) {
+	n.process.SetHeapRoots(roots)
+}
+
+// SetStdio 设置 process.stdout 和 process.stderr 的输出目标
+func (n *Namespace) SetStdio(stdout, stderr io.Writer) {
+	n.process.SetStdio(stdout, stderr)
+}
+
+// Events 返回 process 的生命周期事件
+func (n *Namespace) Events() *Events {
+	return n.process.Events()
+}
+
 // GetSubModule 获取子模块
 func (n *Namespace) GetSubModule(name string) (types.BuiltinModule, bool) {
 	switch name {
@@ -1,10 +1,17 @@
 package process
 
 import (
+	"io"
 	"os"
 	"runtime"
+	"syscall"
 	"time"
 
+	"github.com/issueye/sw_runtime/internal/builtins/events"
+	"github.com/issueye/sw_runtime/internal/builtins/stream"
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/security"
+
 	"github.com/dop251/goja"
 	"github.com/shirou/gopsutil/v3/process"
 )
@@ -14,20 +21,32 @@ type ProcessModule struct {
 	vm        *goja.Runtime
 	avgs      []string
 	startTime time.Time
+	guard     *security.Guard
+	heapRoots func() []goja.Value // 堆摘要的遍历起点
+	stdout    io.Writer           // nil 表示 os.Stdout
+	stderr    io.Writer           // nil 表示 os.Stderr
+	events    *Events
+	obj       *goja.Object // 模块对象，全局 process 与各次 require 共享同一个
 }
 
 // NewProcessModule 创建进程模块
-func NewProcessModule(vm *goja.Runtime, avgs []string, startTime time.Time) *ProcessModule {
+func NewProcessModule(vm *goja.Runtime, avgs []string, startTime time.Time, guard *security.Guard) *ProcessModule {
 	return &ProcessModule{
 		vm:        vm,
 		avgs:      avgs,
 		startTime: startTime,
+		guard:     guard,
+		events:    newEvents(vm),
 	}
 }
 
-// GetModule 获取模块对象
+// GetModule 获取模块对象，模块对象是 EventEmitter，首次调用时创建
 func (p *ProcessModule) GetModule() *goja.Object {
-	obj := p.vm.NewObject()
+	if p.obj != nil {
+		return p.obj
+	}
+	obj := events.New(p.vm)
+	p.obj = obj
 
 	// 基础属性
 	obj.Set("pid", os.Getpid())
@@ -51,9 +70,15 @@ func (p *ProcessModule) GetModule() *goja.Object {
 	obj.Set("hrtime", p.hrtime)
 	obj.Set("kill", p.kill)
 
-	// 标准流
-	obj.Set("stdout", p.getStdout())
-	obj.Set("stderr", p.getStderr())
+	// 生命周期事件和 exitCode
+	p.events.install(obj)
+
+	// 性能分析
+	obj.Set("profile", p.getProfile())
+
+	// 标准流，首次访问时创建
+	obj.DefineAccessorProperty("stdout", p.vm.ToValue(p.lazy(p.getStdout)), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	obj.DefineAccessorProperty("stderr", p.vm.ToValue(p.lazy(p.getStderr)), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
 
 	// 环境变量和参数 - 使用 Getter 确保在 SetArgv 之后也能正确获取
 	obj.DefineAccessorProperty("env", p.vm.ToValue(p.envGetter), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
@@ -62,13 +87,15 @@ func (p *ProcessModule) GetModule() *goja.Object {
 	return obj
 }
 
-// envGetter 环境变量 Getter
+// envGetter 环境变量 Getter，启用权限限制时只包含允许访问的变量
 func (p *ProcessModule) envGetter(call goja.FunctionCall) goja.Value {
 	obj := p.vm.NewObject()
 	for _, env := range os.Environ() {
 		for i := 0; i < len(env); i++ {
 			if env[i] == '=' {
-				obj.Set(env[:i], env[i+1:])
+				if p.guard.CheckEnv(env[:i]) == nil {
+					obj.Set(env[:i], env[i+1:])
+				}
 				break
 			}
 		}
@@ -76,6 +103,11 @@ func (p *ProcessModule) envGetter(call goja.FunctionCall) goja.Value {
 	return obj
 }
 
+// SetArgv 设置命令行参数
+func (p *ProcessModule) SetArgv(argv []string) {
+	p.avgs = argv
+}
+
 // argvGetter 命令行参数 Getter
 func (p *ProcessModule) argvGetter(call goja.FunctionCall) goja.Value {
 	if len(p.avgs) == 0 {
@@ -84,28 +116,44 @@ func (p *ProcessModule) argvGetter(call goja.FunctionCall) goja.Value {
 	return p.vm.ToValue(p.avgs)
 }
 
-// getStdout 获取 stdout 对象
+// getStdout 获取 stdout 对象（同步写入的 Writable 流）
 func (p *ProcessModule) getStdout() *goja.Object {
-	obj := p.vm.NewObject()
-	obj.Set("write", func(call goja.FunctionCall) goja.Value {
-		if len(call.Arguments) > 0 {
-			os.Stdout.WriteString(call.Arguments[0].String())
-		}
-		return goja.Undefined()
+	return stream.NewSyncWritable(p.vm, func(data []byte) error {
+		_, err := writerOr(p.stdout, os.Stdout).Write(data)
+		return err
 	})
-	return obj
 }
 
-// getStderr 获取 stderr 对象
-func (p *ProcessModule) getStderr() *goja.Object {
-	obj := p.vm.NewObject()
-	obj.Set("write", func(call goja.FunctionCall) goja.Value {
-		if len(call.Arguments) > 0 {
-			os.Stderr.WriteString(call.Arguments[0].String())
+// lazy 返回只在首次调用时创建对象的 Getter
+func (p *ProcessModule) lazy(create func() *goja.Object) func(goja.FunctionCall) goja.Value {
+	var obj *goja.Object
+	return func(goja.FunctionCall) goja.Value {
+		if obj == nil {
+			obj = create()
 		}
-		return goja.Undefined()
+		return obj
+	}
+}
+
+// SetStdio 设置 process.stdout 和 process.stderr 的输出目标，nil 表示标准输出/标准错误
+func (p *ProcessModule) SetStdio(stdout, stderr io.Writer) {
+	p.stdout, p.stderr = stdout, stderr
+}
+
+// writerOr w 为 nil 时返回 def
+func writerOr(w io.Writer, def io.Writer) io.Writer {
+	if w == nil {
+		return def
+	}
+	return w
+}
+
+// getStderr 获取 stderr 对象（同步写入的 Writable 流）
+func (p *ProcessModule) getStderr() *goja.Object {
+	return stream.NewSyncWritable(p.vm, func(data []byte) error {
+		_, err := writerOr(p.stderr, os.Stderr).Write(data)
+		return err
 	})
-	return obj
 }
 
 // hrtime 高精度时间
@@ -137,6 +185,11 @@ func (p *ProcessModule) kill(call goja.FunctionCall) goja.Value {
 		panic(p.vm.NewTypeError("kill requires pid"))
 	}
 
+	// 与 Deno 一致，发送信号需要 kill 命令的执行权限
+	if err := p.guard.CheckRun("kill"); err != nil {
+		panic(types.NewPermissionError(p.vm, err))
+	}
+
 	pid := int(call.Arguments[0].ToInteger())
 	sig := os.Interrupt
 
@@ -148,6 +201,10 @@ func (p *ProcessModule) kill(call goja.FunctionCall) goja.Value {
 			sig = os.Interrupt
 		case "SIGKILL":
 			sig = os.Kill
+		case "SIGTERM":
+			sig = syscall.SIGTERM
+		case "SIGHUP":
+			sig = syscall.SIGHUP
 		}
 	}
 
@@ -174,6 +231,9 @@ func (p *ProcessModule) chdir(call goja.FunctionCall) goja.Value {
 	if len(call.Arguments) < 1 {
 		panic(p.vm.NewTypeError("chdir requires path"))
 	}
+	if err := p.guard.CheckRead(call.Arguments[0].String()); err != nil {
+		panic(types.NewPermissionError(p.vm, err))
+	}
 	err := os.Chdir(call.Arguments[0].String())
 	if err != nil {
 		panic(p.vm.NewGoError(err))
@@ -181,12 +241,18 @@ func (p *ProcessModule) chdir(call goja.FunctionCall) goja.Value {
 	return goja.Undefined()
 }
 
-// exit 退出进程
+// Events 返回 process 的生命周期事件
+func (p *ProcessModule) Events() *Events {
+	return p.events
+}
+
+// exit 触发 exit 事件后退出进程，未指定退出码时使用 process.exitCode
 func (p *ProcessModule) exit(call goja.FunctionCall) goja.Value {
-	code := 0
-	if len(call.Arguments) > 0 {
+	code := p.events.ExitCode()
+	if len(call.Arguments) > 0 && !goja.IsUndefined(call.Arguments[0]) {
 		code = int(call.Arguments[0].ToInteger())
 	}
+	p.events.EmitExit(code)
 	os.Exit(code)
 	return goja.Undefined()
 }
@@ -0,0 +1,89 @@
+package process
+
+import (
+	"os"
+
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/profiler"
+
+	"github.com/dop251/goja"
+)
+
+// SetHeapRoots 设置堆摘要遍历的起点，默认只有全局对象
+func (p *ProcessModule) SetHeapRoots(roots func() []goja.Value) {
+	p.heapRoots = roots
+}
+
+// getProfile 创建 process.profile 对象：
+// start() 开始 CPU 采样，stop(file?) 停止采样并写入 pprof 文件（不带参数时返回 ArrayBuffer），
+// isActive() 返回是否正在采样，heapSummary() 按构造函数统计可达对象数量
+func (p *ProcessModule) getProfile() *goja.Object {
+	obj := p.vm.NewObject()
+	obj.Set("start", p.profileStart)
+	obj.Set("stop", p.profileStop)
+	obj.Set("isActive", func(call goja.FunctionCall) goja.Value {
+		return p.vm.ToValue(profiler.CPUActive())
+	})
+	obj.Set("heapSummary", p.heapSummary)
+	return obj
+}
+
+// profileStart 开始 CPU 采样
+func (p *ProcessModule) profileStart(call goja.FunctionCall) goja.Value {
+	if err := profiler.StartCPU(); err != nil {
+		panic(p.vm.NewGoError(err))
+	}
+	return goja.Undefined()
+}
+
+// profileStop 停止 CPU 采样，指定文件时写入文件并返回路径
+func (p *ProcessModule) profileStop(call goja.FunctionCall) goja.Value {
+	file := ""
+	if arg := call.Argument(0); !goja.IsUndefined(arg) && !goja.IsNull(arg) {
+		file = arg.String()
+		// 在停止采样前检查权限，避免丢失采样结果
+		if err := p.guard.CheckWrite(file); err != nil {
+			panic(types.NewPermissionError(p.vm, err))
+		}
+	}
+
+	data, err := profiler.StopCPU()
+	if err != nil {
+		panic(p.vm.NewGoError(err))
+	}
+	if file == "" {
+		return p.vm.ToValue(p.vm.NewArrayBuffer(data))
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		panic(p.vm.NewGoError(err))
+	}
+	return p.vm.ToValue(file)
+}
+
+// heapSummary 返回 { objects, constructors: [{ name, count }] }，可传入 limit 只返回数量最多的前几项
+func (p *ProcessModule) heapSummary(call goja.FunctionCall) goja.Value {
+	roots := []goja.Value{p.vm.GlobalObject()}
+	if p.heapRoots != nil {
+		roots = p.heapRoots()
+	}
+	summary := profiler.TakeHeapSummary(p.vm, roots...)
+
+	constructors := summary.Constructors
+	if limit := call.Argument(0); !goja.IsUndefined(limit) {
+		if n := int(limit.ToInteger()); n >= 0 && n < len(constructors) {
+			constructors = constructors[:n]
+		}
+	}
+
+	list := make([]interface{}, len(constructors))
+	for i, c := range constructors {
+		item := p.vm.NewObject()
+		item.Set("name", c.Name)
+		item.Set("count", c.Count)
+		list[i] = item
+	}
+	obj := p.vm.NewObject()
+	obj.Set("objects", summary.Objects)
+	obj.Set("constructors", p.vm.NewArray(list...))
+	return obj
+}
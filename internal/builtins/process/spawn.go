@@ -0,0 +1,173 @@
+package process
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/issueye/sw_runtime/internal/builtins/events"
+	"github.com/issueye/sw_runtime/internal/builtins/stream"
+
+	"github.com/dop251/goja"
+)
+
+// signals kill() 支持的信号名
+var signals = map[string]syscall.Signal{
+	"SIGINT":  syscall.SIGINT,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGHUP":  syscall.SIGHUP,
+}
+
+// signalName 返回信号的名称，如 'SIGTERM'
+func signalName(sig syscall.Signal) string {
+	for name, s := range signals {
+		if s == sig {
+			return name
+		}
+	}
+	return sig.String()
+}
+
+// spawn 启动子进程，返回 ChildProcess（EventEmitter）：stdin 为 Writable 流，stdout、stderr 为 Readable 流，
+// 进程退出时触发 exit(code, signal)，标准输出流全部关闭后触发 close(code, signal)
+func (e *ExecModule) spawn(call goja.FunctionCall) goja.Value {
+	if len(call.Arguments) < 1 {
+		panic(e.vm.NewTypeError("spawn requires command"))
+	}
+
+	command := call.Arguments[0].String()
+	args := []string{}
+
+	// 解析参数
+	if len(call.Arguments) > 1 {
+		if argsVal := call.Arguments[1]; argsVal != nil && !goja.IsUndefined(argsVal) && !goja.IsNull(argsVal) {
+			if argsExport, ok := argsVal.Export().([]interface{}); ok {
+				for _, arg := range argsExport {
+					args = append(args, fmt.Sprintf("%v", arg))
+				}
+			}
+		}
+	}
+
+	// 解析选项
+	options := e.parseOptions(call, 2)
+
+	e.checkRun(command)
+	cmd := exec.Command(command, args...)
+	if options.cwd != "" {
+		cmd.Dir = options.cwd
+	}
+	if len(options.env) > 0 {
+		cmd.Env = append(os.Environ(), options.env...)
+	}
+
+	child := events.New(e.vm)
+	child.Set("spawnfile", command)
+	child.Set("spawnargs", append([]string{command}, args...))
+	child.Set("exitCode", goja.Null())
+	child.Set("signalCode", goja.Null())
+	child.Set("killed", false)
+
+	stdin, err := cmd.StdinPipe()
+	if err == nil {
+		stdout, stdoutErr := cmd.StdoutPipe()
+		stderr, stderrErr := cmd.StderrPipe()
+		switch {
+		case stdoutErr != nil:
+			err = stdoutErr
+		case stderrErr != nil:
+			err = stderrErr
+		default:
+			err = cmd.Start()
+		}
+		if err == nil {
+			child.Set("stdin", stream.NewWritable(e.vm, stdin, stdin.Close, nil, stdin))
+			child.Set("stdout", stream.NewReadable(e.vm, stdout, nil, stdout))
+			child.Set("stderr", stream.NewReadable(e.vm, stderr, nil, stderr))
+		}
+	}
+
+	if err != nil {
+		// 与 Node.js 一致，启动失败时在下一轮事件循环触发 error 事件
+		child.Set("pid", goja.Undefined())
+		stream.Async(e.vm, func() func(vm *goja.Runtime) error {
+			return func(vm *goja.Runtime) error {
+				return events.Emit(vm, child, "error", vm.NewGoError(err))
+			}
+		})
+		return child
+	}
+
+	child.Set("pid", cmd.Process.Pid)
+	child.Set("kill", func(call goja.FunctionCall) goja.Value {
+		sig := syscall.SIGTERM
+		if name := call.Argument(0); !goja.IsUndefined(name) {
+			s, ok := signals[name.String()]
+			if !ok {
+				panic(e.vm.NewTypeError("Unknown signal: %s", name.String()))
+			}
+			sig = s
+		}
+		if !goja.IsNull(child.Get("exitCode")) || !goja.IsNull(child.Get("signalCode")) {
+			return e.vm.ToValue(false)
+		}
+		if err := cmd.Process.Signal(sig); err != nil {
+			return e.vm.ToValue(false)
+		}
+		child.Set("killed", true)
+		return e.vm.ToValue(true)
+	})
+	e.watchChild(child, cmd)
+
+	return child
+}
+
+// watchChild 等待子进程退出并触发 exit 事件，stdout、stderr 关闭后触发 close 事件
+func (e *ExecModule) watchChild(child *goja.Object, cmd *exec.Cmd) {
+	// 不使用 cmd.Wait：它会在退出后关闭管道，丢弃尚未读取的输出
+	remaining := 3
+	var code, signal goja.Value
+	closed := func() {
+		remaining--
+		if remaining == 0 {
+			if err := events.Emit(e.vm, child, "close", code, signal); err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	for _, name := range []string{"stdout", "stderr"} {
+		readable := child.Get(name).ToObject(e.vm)
+		once, _ := goja.AssertFunction(readable.Get("once"))
+		if _, err := once(readable, e.vm.ToValue("close"), e.vm.ToValue(func(goja.FunctionCall) goja.Value {
+			closed()
+			return goja.Undefined()
+		})); err != nil {
+			panic(err)
+		}
+	}
+
+	stream.Async(e.vm, func() func(vm *goja.Runtime) error {
+		state, err := cmd.Process.Wait()
+		return func(vm *goja.Runtime) error {
+			if err != nil {
+				return events.Emit(vm, child, "error", vm.NewGoError(err))
+			}
+			code, signal = goja.Null(), goja.Null()
+			if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+				signal = vm.ToValue(signalName(status.Signal()))
+			} else {
+				code = vm.ToValue(state.ExitCode())
+			}
+			child.Set("exitCode", code)
+			child.Set("signalCode", signal)
+			if err := events.Emit(vm, child, "exit", code, signal); err != nil {
+				return err
+			}
+			closed()
+			return nil
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package process
+
+import (
+	"fmt"
+
+	"github.com/issueye/sw_runtime/internal/builtins/buffer"
+	"github.com/issueye/sw_runtime/internal/standalone"
+
+	"github.com/dop251/goja"
+)
+
+// StandaloneModule 独立可执行文件模块，用于读取编译时嵌入的资源
+type StandaloneModule struct {
+	vm *goja.Runtime
+}
+
+// NewStandaloneModule 创建独立可执行文件模块
+func NewStandaloneModule(vm *goja.Runtime) *StandaloneModule {
+	return &StandaloneModule{vm: vm}
+}
+
+// GetModule 获取模块对象
+func (s *StandaloneModule) GetModule() *goja.Object {
+	obj := s.vm.NewObject()
+
+	payload := s.payload()
+	obj.Set("isStandalone", payload != nil)
+	if payload != nil {
+		obj.Set("entry", payload.Entry)
+	} else {
+		obj.Set("entry", goja.Null())
+	}
+
+	obj.Set("hasAsset", s.hasAsset)
+	obj.Set("getAsset", s.getAsset)
+	obj.Set("listAssets", s.listAssets)
+
+	return obj
+}
+
+// payload 获取当前可执行文件中的载荷，读取失败时视为普通运行时
+func (s *StandaloneModule) payload() *standalone.Payload {
+	payload, err := standalone.Current()
+	if err != nil {
+		return nil
+	}
+	return payload
+}
+
+// hasAsset 检查资源是否存在
+func (s *StandaloneModule) hasAsset(call goja.FunctionCall) goja.Value {
+	payload := s.payload()
+	if payload == nil || len(call.Arguments) == 0 {
+		return s.vm.ToValue(false)
+	}
+	_, ok := payload.Asset(call.Argument(0).String())
+	return s.vm.ToValue(ok)
+}
+
+// getAsset 读取资源，默认返回 Buffer，指定编码时返回字符串
+func (s *StandaloneModule) getAsset(call goja.FunctionCall) goja.Value {
+	if len(call.Arguments) == 0 {
+		panic(s.vm.NewTypeError("getAsset requires a name argument"))
+	}
+	name := call.Argument(0).String()
+
+	payload := s.payload()
+	if payload == nil {
+		panic(s.vm.NewGoError(fmt.Errorf("not running as a standalone executable: %s", name)))
+	}
+	data, ok := payload.Asset(name)
+	if !ok {
+		panic(s.vm.NewGoError(fmt.Errorf("asset not found: %s", name)))
+	}
+	return buffer.Encode(s.vm, data, buffer.Encoding(s.vm, call.Argument(1), "buffer"))
+}
+
+// listAssets 列出所有资源名称
+func (s *StandaloneModule) listAssets(call goja.FunctionCall) goja.Value {
+	payload := s.payload()
+	if payload == nil {
+		return s.vm.ToValue([]interface{}{})
+	}
+	names := payload.AssetNames()
+	result := make([]interface{}, len(names))
+	for i, name := range names {
+		result[i] = name
+	}
+	return s.vm.ToValue(result)
+}
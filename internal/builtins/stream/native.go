@@ -0,0 +1,347 @@
+package stream
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/issueye/sw_runtime/internal/builtins/buffer"
+
+	"github.com/dop251/goja"
+)
+
+// Scheduler 把回调投递到 VM 所在的事件循环执行，回调返回的错误作为未捕获异常报告
+type Scheduler func(fn func(vm *goja.Runtime) error)
+
+// vmState 每个 VM 的原生流状态：调度器、进行中的 I/O 数量和尚未关闭的句柄
+type vmState struct {
+	schedule Scheduler
+	pending  atomic.Int64
+	mu       sync.Mutex
+	handles  map[*handle]struct{}
+}
+
+var states sync.Map // *goja.Runtime -> *vmState
+
+func stateOf(vm *goja.Runtime) *vmState {
+	if s, ok := states.Load(vm); ok {
+		return s.(*vmState)
+	}
+	s, _ := states.LoadOrStore(vm, &vmState{handles: make(map[*handle]struct{})})
+	return s.(*vmState)
+}
+
+// SetScheduler 设置 VM 的事件循环调度器，原生流的 I/O 完成后通过它回到 JS 线程
+func SetScheduler(vm *goja.Runtime, schedule Scheduler) {
+	stateOf(vm).schedule = schedule
+}
+
+// RemoveScheduler 关闭 VM 的所有原生流并移除其状态
+func RemoveScheduler(vm *goja.Runtime) {
+	CloseAll(vm)
+	states.Delete(vm)
+}
+
+// HasPendingIO 检查 VM 是否有进行中的原生流读写，事件循环据此保持运行
+func HasPendingIO(vm *goja.Runtime) bool {
+	s, ok := states.Load(vm)
+	return ok && s.(*vmState).pending.Load() > 0
+}
+
+// CloseAll 关闭 VM 中所有尚未关闭的原生流句柄
+func CloseAll(vm *goja.Runtime) {
+	s, ok := states.Load(vm)
+	if !ok {
+		return
+	}
+	state := s.(*vmState)
+	state.mu.Lock()
+	handles := make([]*handle, 0, len(state.handles))
+	for h := range state.handles {
+		handles = append(handles, h)
+	}
+	state.mu.Unlock()
+
+	for _, h := range handles {
+		h.close()
+	}
+}
+
+// handle 原生 I/O 句柄，读写在独立 goroutine 中执行，完成后通过调度器回调 JS
+type handle struct {
+	vm        *goja.Runtime
+	state     *vmState
+	schedule  Scheduler // 非 nil 时代替事件循环调度回调（如 HTTP 服务器的请求处理队列）
+	reader    io.Reader
+	writer    io.Writer
+	endWriter func() error
+	closers   []io.Closer
+	closeOnce sync.Once
+}
+
+func newHandle(vm *goja.Runtime) *handle {
+	h := &handle{vm: vm, state: stateOf(vm)}
+	h.state.mu.Lock()
+	h.state.handles[h] = struct{}{}
+	h.state.mu.Unlock()
+	return h
+}
+
+func (h *handle) close() {
+	h.closeOnce.Do(func() {
+		h.state.mu.Lock()
+		delete(h.state.handles, h)
+		h.state.mu.Unlock()
+		for _, c := range h.closers {
+			c.Close()
+		}
+	})
+}
+
+// async 在 goroutine 中执行 work，完成后通过调度器执行 done
+func (h *handle) async(work func() func(vm *goja.Runtime) error) {
+	if h.schedule == nil {
+		Async(h.vm, work)
+		return
+	}
+	go func() {
+		h.schedule(work())
+	}()
+}
+
+// Async 在 goroutine 中执行阻塞操作 work，再把它返回的回调投递到 VM 的事件循环执行。
+// 操作完成前事件循环保持运行；未设置调度器时直接在该 goroutine 中执行回调
+func Async(vm *goja.Runtime, work func() func(vm *goja.Runtime) error) {
+	state := stateOf(vm)
+	state.pending.Add(1)
+	go func() {
+		done := work()
+		if state.schedule != nil {
+			state.schedule(func(vm *goja.Runtime) error {
+				defer state.pending.Add(-1)
+				return done(vm)
+			})
+			return
+		}
+		defer state.pending.Add(-1)
+		done(vm)
+	}()
+}
+
+func callback(vm *goja.Runtime, cb goja.Callable, err error, value goja.Value) error {
+	errValue := goja.Null()
+	if err != nil {
+		errValue = vm.NewGoError(err)
+	}
+	if value == nil {
+		value = goja.Undefined()
+	}
+	_, callErr := cb(goja.Undefined(), errValue, value)
+	return callErr
+}
+
+func (h *handle) object() *goja.Object {
+	vm := h.vm
+	obj := vm.NewObject()
+
+	if h.reader != nil {
+		obj.Set("read", func(call goja.FunctionCall) goja.Value {
+			size := int(call.Argument(0).ToInteger())
+			if size <= 0 {
+				size = 64 * 1024
+			}
+			cb, _ := goja.AssertFunction(call.Argument(1))
+			h.async(func() func(vm *goja.Runtime) error {
+				buf := make([]byte, size)
+				n, err := h.reader.Read(buf)
+				return func(vm *goja.Runtime) error {
+					if n > 0 {
+						return callback(vm, cb, nil, buffer.New(vm, buf[:n]))
+					}
+					if err == nil || isEOF(err) {
+						if err == nil {
+							// 空读取：返回空块，由流继续请求下一次读取
+							return callback(vm, cb, nil, buffer.New(vm, nil))
+						}
+						return callback(vm, cb, nil, goja.Null())
+					}
+					return callback(vm, cb, err, nil)
+				}
+			})
+			return goja.Undefined()
+		})
+	}
+
+	if h.writer != nil {
+		obj.Set("write", func(call goja.FunctionCall) goja.Value {
+			data := buffer.ToBytes(vm, call.Argument(0))
+			// 复制数据，避免脚本在写入完成前修改 Buffer
+			chunk := append([]byte(nil), data...)
+			cb, _ := goja.AssertFunction(call.Argument(1))
+			h.async(func() func(vm *goja.Runtime) error {
+				_, err := h.writer.Write(chunk)
+				return func(vm *goja.Runtime) error {
+					return callback(vm, cb, err, nil)
+				}
+			})
+			return goja.Undefined()
+		})
+		obj.Set("end", func(call goja.FunctionCall) goja.Value {
+			cb, _ := goja.AssertFunction(call.Argument(0))
+			h.async(func() func(vm *goja.Runtime) error {
+				var err error
+				if h.endWriter != nil {
+					err = h.endWriter()
+				}
+				return func(vm *goja.Runtime) error {
+					return callback(vm, cb, err, nil)
+				}
+			})
+			return goja.Undefined()
+		})
+	}
+
+	obj.Set("close", func(call goja.FunctionCall) goja.Value {
+		h.close()
+		return goja.Undefined()
+	})
+	return obj
+}
+
+// isEOF 把读端关闭视为正常结束（流被销毁后的读取、对端关闭的管道）
+func isEOF(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrClosedPipe) || errors.Is(err, os.ErrClosed) ||
+		errors.Is(err, net.ErrClosed)
+}
+
+func fromHandle(vm *goja.Runtime, h *handle, opts goja.Value, transform bool) *goja.Object {
+	fn, ok := goja.AssertFunction(exportsOf(vm).Get("fromHandle"))
+	if !ok {
+		panic(vm.NewTypeError("invalid stream factory"))
+	}
+	if opts == nil {
+		opts = goja.Undefined()
+	}
+	stream, err := fn(goja.Undefined(), h.object(), opts, vm.ToValue(transform))
+	if err != nil {
+		panic(err)
+	}
+	return stream.ToObject(vm)
+}
+
+// NewReadable 把 io.Reader 包装为 Readable 流，流销毁时关闭 closers
+func NewReadable(vm *goja.Runtime, r io.Reader, opts goja.Value, closers ...io.Closer) *goja.Object {
+	return NewReadableOn(vm, nil, r, opts, closers...)
+}
+
+// NewReadableOn 同 NewReadable，I/O 回调通过 schedule 投递（nil 表示事件循环）
+func NewReadableOn(vm *goja.Runtime, schedule Scheduler, r io.Reader, opts goja.Value, closers ...io.Closer) *goja.Object {
+	h := newHandle(vm)
+	h.schedule = schedule
+	h.reader = r
+	h.closers = closers
+	return fromHandle(vm, h, opts, false)
+}
+
+// NewWritable 把 io.Writer 包装为 Writable 流，end() 时调用 end（可为 nil），流销毁时关闭 closers
+func NewWritable(vm *goja.Runtime, w io.Writer, end func() error, opts goja.Value, closers ...io.Closer) *goja.Object {
+	return NewWritableOn(vm, nil, w, end, opts, closers...)
+}
+
+// NewWritableOn 同 NewWritable，I/O 回调通过 schedule 投递（nil 表示事件循环）
+func NewWritableOn(vm *goja.Runtime, schedule Scheduler, w io.Writer, end func() error, opts goja.Value, closers ...io.Closer) *goja.Object {
+	h := newHandle(vm)
+	h.schedule = schedule
+	h.writer = w
+	h.endWriter = end
+	h.closers = closers
+	return fromHandle(vm, h, opts, false)
+}
+
+// NewDuplex 把同时可读写的连接包装为 Duplex 流，end() 时调用 end 关闭写方向
+func NewDuplex(vm *goja.Runtime, rw io.ReadWriter, end func() error, opts goja.Value, closers ...io.Closer) *goja.Object {
+	h := newHandle(vm)
+	h.reader = rw
+	h.writer = rw
+	h.endWriter = end
+	h.closers = closers
+	return fromHandle(vm, h, opts, false)
+}
+
+// NewTransform 创建由 Go 过滤器实现的 Transform 流。filter 从 src 读取输入、向 dst 写入输出，
+// 返回后输出结束（用于 gzip 等压缩格式）
+func NewTransform(vm *goja.Runtime, filter func(dst io.Writer, src io.Reader) error, opts goja.Value) *goja.Object {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	go func() {
+		err := filter(outW, inR)
+		// 出错时让写入端也失败，避免 write 回调永远阻塞
+		inR.CloseWithError(errOr(err, io.ErrClosedPipe))
+		outW.CloseWithError(err)
+	}()
+
+	h := newHandle(vm)
+	h.reader = outR
+	h.writer = inW
+	h.endWriter = inW.Close
+	h.closers = []io.Closer{inW, outR}
+	return fromHandle(vm, h, opts, true)
+}
+
+func errOr(err, fallback error) error {
+	if err != nil {
+		return err
+	}
+	return fallback
+}
+
+// NewSyncWritable 创建同步写入的 Writable 流（如 process.stdout），write 在调用 write() 时立即执行，
+// 保证与 console 输出的顺序一致；作为 pipe 目标时不会被结束
+func NewSyncWritable(vm *goja.Runtime, write func(data []byte) error) *goja.Object {
+	fn, ok := goja.AssertFunction(exportsOf(vm).Get("stdio"))
+	if !ok {
+		panic(vm.NewTypeError("invalid stream factory"))
+	}
+	native := func(call goja.FunctionCall) goja.Value {
+		var data []byte
+		if s, ok := call.Argument(0).Export().(string); ok {
+			data = buffer.DecodeString(s, call.Argument(1).String())
+		} else {
+			data = buffer.ToBytes(vm, call.Argument(0))
+		}
+		if err := write(data); err != nil {
+			panic(vm.NewGoError(err))
+		}
+		return goja.Undefined()
+	}
+	w, err := fn(goja.Undefined(), vm.ToValue(native))
+	if err != nil {
+		panic(err)
+	}
+	return w.ToObject(vm)
+}
+
+// Reader 把 Readable 流转换为 io.ReadCloser（如作为 HTTP 请求体），数据通过 pipe 按背压写入；
+// 源流出错时读取返回该错误。必须在 VM 所在的线程调用
+func Reader(vm *goja.Runtime, readable *goja.Object) (io.ReadCloser, error) {
+	pr, pw := io.Pipe()
+	dest := NewWritable(vm, pw, pw.Close, nil, pw)
+	on, ok := goja.AssertFunction(readable.Get("on"))
+	if !ok {
+		return nil, errors.New("source is not a readable stream")
+	}
+	onError := func(call goja.FunctionCall) goja.Value {
+		pw.CloseWithError(errors.New(call.Argument(0).String()))
+		return goja.Undefined()
+	}
+	if _, err := on(readable, vm.ToValue("error"), vm.ToValue(onError)); err != nil {
+		return nil, err
+	}
+	if err := Pipe(vm, readable, dest); err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
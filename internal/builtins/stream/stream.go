@@ -0,0 +1,1740 @@
+package stream
+
+import (
+	"errors"
+
+	"github.com/issueye/sw_runtime/internal/builtins/buffer"
+	"github.com/issueye/sw_runtime/internal/builtins/events"
+
+	"github.com/dop251/goja"
+)
+
+// exportsKey 在全局对象上缓存 stream 实现的隐藏键，
+// 保证同一个 VM 中脚本和内置模块创建的流共享同一原型
+var exportsKey = goja.NewSymbol("sw.stream")
+
+// streamProgram 流实现（预编译，可在多个 VM 间复用）
+var streamProgram = goja.MustCompile("stream.js", streamSource, true)
+
+// StreamModule stream 模块
+type StreamModule struct {
+	vm *goja.Runtime
+}
+
+// NewStreamModule 创建 stream 模块
+func NewStreamModule(vm *goja.Runtime) *StreamModule {
+	return &StreamModule{vm: vm}
+}
+
+// GetModule 获取 stream 模块对象，与 Node.js 一致即 Stream 构造函数本身
+func (s *StreamModule) GetModule() *goja.Object {
+	return exportsOf(s.vm).Get("Stream").ToObject(s.vm)
+}
+
+// exportsOf 获取 VM 中的流实现（Stream 构造函数与内部的 fromHandle），首次调用时初始化
+func exportsOf(vm *goja.Runtime) *goja.Object {
+	global := vm.GlobalObject()
+	if exports, ok := global.GetSymbol(exportsKey).(*goja.Object); ok {
+		return exports
+	}
+
+	factory, err := vm.RunProgram(streamProgram)
+	if err != nil {
+		panic(err)
+	}
+	fn, ok := goja.AssertFunction(factory)
+	if !ok {
+		panic(vm.NewTypeError("invalid stream factory"))
+	}
+	native := vm.NewObject()
+	native.Set("normalize", buffer.NormalizeEncoding)
+	result, err := fn(goja.Undefined(), events.Constructor(vm), buffer.Constructor(vm), native)
+	if err != nil {
+		panic(err)
+	}
+
+	exports := result.ToObject(vm)
+	global.DefineDataPropertySymbol(exportsKey, exports, goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_FALSE)
+	return exports
+}
+
+// Class 获取流类（Readable、Writable、Duplex、Transform、PassThrough）
+func Class(vm *goja.Runtime, name string) *goja.Object {
+	return exportsOf(vm).Get("Stream").ToObject(vm).Get(name).ToObject(vm)
+}
+
+// IsReadable 检查值是否为 Readable 流（包括 Duplex 和 Transform）
+func IsReadable(vm *goja.Runtime, value goja.Value) bool {
+	_, ok := value.(*goja.Object)
+	return ok && vm.InstanceOf(value, Class(vm, "Readable"))
+}
+
+// Pipe 调用 src.pipe(dest)
+func Pipe(vm *goja.Runtime, src, dest *goja.Object) error {
+	pipe, ok := goja.AssertFunction(src.Get("pipe"))
+	if !ok {
+		return errors.New("source is not a readable stream")
+	}
+	_, err := pipe(src, dest)
+	return err
+}
+
+// Destroy 调用 s.destroy(err)，err 为 nil 时正常销毁。error 和 close 事件在当前同步代码结束后触发
+func Destroy(vm *goja.Runtime, s *goja.Object, err error) error {
+	destroy, ok := goja.AssertFunction(s.Get("destroy"))
+	if !ok {
+		return errors.New("object is not a stream")
+	}
+	var errValue goja.Value = goja.Undefined()
+	if err != nil {
+		errValue = vm.NewGoError(err)
+	}
+	_, callErr := destroy(s, errValue)
+	return callErr
+}
+
+// streamSource Node.js 兼容的流实现：Readable、Writable、Duplex、Transform、PassThrough、
+// pipeline 和 finished。可读端支持 highWaterMark 背压、暂停/流动两种模式和 for await 异步迭代
+const streamSource = `(function (EventEmitter, Buffer, native) {
+	'use strict';
+
+	const kAsyncIterator = Symbol.asyncIterator || Symbol.for('Symbol.asyncIterator');
+	const kCallback = Symbol('kCallback');
+	const kStdio = Symbol('kStdio');
+	const kDefaultHighWaterMark = 16 * 1024;
+	const kObjectHighWaterMark = 16;
+	const realHasInstance = Function.prototype[Symbol.hasInstance];
+
+	function nop() {}
+
+	// nextTick 在当前任务结束前（微任务队列中）执行。回调先放入队列，
+	// 每批只占用一个微任务，避免每个数据块都创建 Promise
+	let ticks = [];
+	let tickScheduled = false;
+	function runTicks() {
+		tickScheduled = false;
+		const queue = ticks;
+		ticks = [];
+		let i = 0;
+		try {
+			for (; i < queue.length; i++) {
+				queue[i].fn.apply(undefined, queue[i].args);
+			}
+		} finally {
+			// 回调抛出异常时，剩余的回调在下一个微任务中继续执行
+			if (i + 1 < queue.length) {
+				ticks = queue.slice(i + 1).concat(ticks);
+			}
+			if (ticks.length && !tickScheduled) {
+				tickScheduled = true;
+				Promise.resolve().then(runTicks);
+			}
+		}
+	}
+	function nextTick(fn) {
+		ticks.push({ fn: fn, args: Array.prototype.slice.call(arguments, 1) });
+		if (!tickScheduled) {
+			tickScheduled = true;
+			Promise.resolve().then(runTicks);
+		}
+	}
+
+	function hidden(obj, key, value) {
+		Object.defineProperty(obj, key, { value: value, writable: true, configurable: true, enumerable: false });
+	}
+
+	function codedError(Ctor, code, message) {
+		const err = new Ctor(message);
+		err.code = code;
+		return err;
+	}
+
+	function prematureClose() {
+		return codedError(Error, 'ERR_STREAM_PREMATURE_CLOSE', 'Premature close');
+	}
+
+	function abortError(signal) {
+		const err = new Error('The operation was aborted');
+		err.name = 'AbortError';
+		err.code = 'ABORT_ERR';
+		err.cause = signal.reason;
+		return err;
+	}
+
+	function getHighWaterMark(options, key, objectMode) {
+		let hwm = options[key];
+		if (hwm === undefined || hwm === null) hwm = options.highWaterMark;
+		if (hwm === undefined || hwm === null) return objectMode ? kObjectHighWaterMark : kDefaultHighWaterMark;
+		if (typeof hwm !== 'number' || hwm < 0 || hwm !== hwm) {
+			throw codedError(TypeError, 'ERR_INVALID_ARG_VALUE', "The property 'options." + key + "' is invalid. Received " + hwm);
+		}
+		return Math.floor(hwm);
+	}
+
+	function toBuffer(chunk, encoding) {
+		if (typeof chunk === 'string') return Buffer.from(chunk, encoding || 'utf8');
+		if (Buffer.isBuffer(chunk)) return chunk;
+		if (chunk instanceof Uint8Array) return Buffer.from(chunk.buffer, chunk.byteOffset, chunk.byteLength);
+		throw codedError(TypeError, 'ERR_INVALID_ARG_TYPE', 'The "chunk" argument must be of type string or an instance of Buffer or Uint8Array');
+	}
+
+	// incompleteUtf8 返回末尾被截断的 UTF-8 多字节字符的字节数
+	function incompleteUtf8(buf) {
+		for (let i = 1; i <= 3 && i <= buf.length; i++) {
+			const byte = buf[buf.length - i];
+			if ((byte & 0xC0) === 0x80) continue;
+			let need = 1;
+			if ((byte & 0xE0) === 0xC0) need = 2;
+			else if ((byte & 0xF0) === 0xE0) need = 3;
+			else if ((byte & 0xF8) === 0xF0) need = 4;
+			return need > i ? i : 0;
+		}
+		return 0;
+	}
+
+	// Decoder 把 Buffer 解码为字符串，跨块的多字节字符（utf8）和不完整分组（base64、utf16le）留到下一块
+	function Decoder(encoding) {
+		const normalized = native.normalize(String(encoding));
+		if (!normalized) throw new TypeError('Unknown encoding: ' + encoding);
+		this.encoding = normalized;
+		this.pending = null;
+	}
+
+	Decoder.prototype.write = function (buf) {
+		if (this.pending) {
+			buf = Buffer.concat([this.pending, buf]);
+			this.pending = null;
+		}
+		let keep = 0;
+		if (this.encoding === 'utf8') keep = incompleteUtf8(buf);
+		else if (this.encoding === 'base64' || this.encoding === 'base64url') keep = buf.length % 3;
+		else if (this.encoding === 'utf16le') keep = buf.length % 2;
+		if (keep > 0) {
+			this.pending = buf.subarray(buf.length - keep);
+			buf = buf.subarray(0, buf.length - keep);
+		}
+		return buf.toString(this.encoding);
+	};
+
+	Decoder.prototype.end = function () {
+		const rest = this.pending;
+		this.pending = null;
+		return rest ? rest.toString(this.encoding) : '';
+	};
+
+	function Stream(options) {
+		EventEmitter.call(this, options);
+	}
+	Object.setPrototypeOf(Stream.prototype, EventEmitter.prototype);
+	Object.setPrototypeOf(Stream, EventEmitter);
+
+	// ---- 销毁与错误 ----
+
+	function destroy(err, cb) {
+		const r = this._readableState;
+		const w = this._writableState;
+		if ((w && w.destroyed) || (r && r.destroyed)) {
+			if (typeof cb === 'function') nextTick(cb);
+			return this;
+		}
+		setErrored(this, err);
+		if (w) w.destroyed = true;
+		if (r) r.destroyed = true;
+
+		let called = false;
+		const onDestroy = (er) => {
+			if (called) return;
+			called = true;
+			setErrored(this, er);
+			if (w) w.closed = true;
+			if (r) r.closed = true;
+			if (typeof cb === 'function') cb(er);
+			if (er) nextTick(emitErrorCloseNT, this, er);
+			else nextTick(emitCloseNT, this);
+		};
+		try {
+			this._destroy(err || null, onDestroy);
+		} catch (er) {
+			onDestroy(er);
+		}
+		return this;
+	}
+
+	function setErrored(stream, err) {
+		if (!err) return;
+		const r = stream._readableState;
+		const w = stream._writableState;
+		if (w && !w.errored) w.errored = err;
+		if (r && !r.errored) r.errored = err;
+	}
+
+	function emitErrorCloseNT(stream, err) {
+		try {
+			emitErrorNT(stream, err);
+		} finally {
+			emitCloseNT(stream);
+		}
+	}
+
+	function emitErrorNT(stream, err) {
+		const r = stream._readableState;
+		const w = stream._writableState;
+		if ((w && w.errorEmitted) || (r && r.errorEmitted)) return;
+		if (w) w.errorEmitted = true;
+		if (r) r.errorEmitted = true;
+		stream.emit('error', err);
+	}
+
+	function emitCloseNT(stream) {
+		const r = stream._readableState;
+		const w = stream._writableState;
+		if (w) {
+			w.closeEmitted = true;
+			callFinished(w, w.errored || prematureClose());
+		}
+		if (r) r.closeEmitted = true;
+		if ((w && w.emitClose) || (r && r.emitClose)) stream.emit('close');
+	}
+
+	// errorOrDestroy 开启 autoDestroy 时销毁流，否则只触发 error 事件
+	function errorOrDestroy(stream, err, sync) {
+		const r = stream._readableState;
+		const w = stream._writableState;
+		if ((w && w.destroyed) || (r && r.destroyed)) return;
+		if ((r && r.autoDestroy) || (w && w.autoDestroy)) {
+			stream.destroy(err);
+		} else if (err) {
+			setErrored(stream, err);
+			if (sync) nextTick(emitErrorNT, stream, err);
+			else emitErrorNT(stream, err);
+		}
+	}
+
+	function defaultDestroy(err, cb) {
+		cb(err);
+	}
+
+	function addAbortSignal(signal, stream) {
+		const onAbort = () => stream.destroy(abortError(signal));
+		if (signal.aborted) onAbort();
+		else {
+			signal.addEventListener('abort', onAbort);
+			stream.once('close', () => signal.removeEventListener('abort', onAbort));
+		}
+		return stream;
+	}
+
+	// ---- Readable ----
+
+	function ReadableState(options, isDuplex) {
+		this.objectMode = !!(options.objectMode || (isDuplex && options.readableObjectMode));
+		this.highWaterMark = getHighWaterMark(options, isDuplex ? 'readableHighWaterMark' : 'highWaterMark', this.objectMode);
+		this.buffer = [];
+		this.length = 0;
+		this.pipes = [];
+		this.awaitDrain = null;
+		this.flowing = null;
+		this.paused = null;
+		this.ended = false;
+		this.endEmitted = false;
+		this.reading = false;
+		this.readingMore = false;
+		this.sync = true;
+		this.needReadable = false;
+		this.emittedReadable = false;
+		this.readableListening = false;
+		this.resumeScheduled = false;
+		this.dataEmitted = false;
+		this.destroyed = false;
+		this.errored = null;
+		this.errorEmitted = false;
+		this.closed = false;
+		this.closeEmitted = false;
+		this.autoDestroy = options.autoDestroy !== false;
+		this.emitClose = options.emitClose !== false;
+		this.decoder = null;
+		this.encoding = null;
+		if (options.encoding) {
+			this.decoder = new Decoder(options.encoding);
+			this.encoding = this.decoder.encoding;
+		}
+	}
+
+	function Readable(options) {
+		if (!(this instanceof Readable)) return new Readable(options);
+		options = options || {};
+		hidden(this, '_readableState', new ReadableState(options, this instanceof Duplex));
+		if (typeof options.read === 'function') hidden(this, '_read', options.read);
+		if (typeof options.destroy === 'function') hidden(this, '_destroy', options.destroy);
+		Stream.call(this, options);
+		if (options.signal) addAbortSignal(options.signal, this);
+	}
+	Object.setPrototypeOf(Readable.prototype, Stream.prototype);
+	Object.setPrototypeOf(Readable, Stream);
+
+	Readable.prototype.destroy = destroy;
+	Readable.prototype._destroy = defaultDestroy;
+
+	Readable.prototype._read = function () {
+		throw codedError(Error, 'ERR_METHOD_NOT_IMPLEMENTED', 'The _read() method is not implemented');
+	};
+
+	Readable.prototype.push = function (chunk, encoding) {
+		return addChunk(this, chunk, encoding, false);
+	};
+
+	Readable.prototype.unshift = function (chunk, encoding) {
+		return addChunk(this, chunk, encoding, true);
+	};
+
+	function addChunk(stream, chunk, encoding, addToFront) {
+		const state = stream._readableState;
+		if (chunk === null) {
+			state.reading = false;
+			onEofChunk(stream, state);
+			return false;
+		}
+		if (!state.objectMode && chunk !== undefined) {
+			try {
+				chunk = toBuffer(chunk, encoding);
+			} catch (err) {
+				errorOrDestroy(stream, err);
+				return false;
+			}
+		}
+		if (state.destroyed || state.errored) return false;
+		if (state.endEmitted || (state.ended && !addToFront)) {
+			errorOrDestroy(stream, codedError(Error, 'ERR_STREAM_PUSH_AFTER_EOF', 'stream.push() after EOF'));
+			return false;
+		}
+		if (!addToFront) state.reading = false;
+		if (chunk === undefined) return canPushMore(state);
+
+		if (state.decoder && !state.objectMode) chunk = state.decoder.write(chunk);
+		if (!state.objectMode && chunk.length === 0) {
+			maybeReadMore(stream, state);
+			return canPushMore(state);
+		}
+
+		if (state.flowing && state.length === 0 && !state.sync && !addToFront && stream.listenerCount('data') > 0) {
+			// 流动模式下缓冲区为空时直接交给 data 监听器
+			state.dataEmitted = true;
+			stream.emit('data', chunk);
+		} else {
+			state.length += state.objectMode ? 1 : chunk.length;
+			if (addToFront) state.buffer.unshift(chunk);
+			else state.buffer.push(chunk);
+			if (state.needReadable) emitReadable(stream);
+		}
+		maybeReadMore(stream, state);
+		return canPushMore(state);
+	}
+
+	function canPushMore(state) {
+		return !state.ended && (state.length < state.highWaterMark || state.length === 0);
+	}
+
+	function onEofChunk(stream, state) {
+		if (state.ended) return;
+		if (state.decoder) {
+			const rest = state.decoder.end();
+			if (rest.length) {
+				state.buffer.push(rest);
+				state.length += state.objectMode ? 1 : rest.length;
+			}
+		}
+		state.ended = true;
+		if (state.sync) {
+			emitReadable(stream);
+		} else {
+			state.needReadable = false;
+			state.emittedReadable = true;
+			emitReadableNT(stream);
+		}
+	}
+
+	function emitReadable(stream) {
+		const state = stream._readableState;
+		state.needReadable = false;
+		if (!state.emittedReadable) {
+			state.emittedReadable = true;
+			nextTick(emitReadableNT, stream);
+		}
+	}
+
+	function emitReadableNT(stream) {
+		const state = stream._readableState;
+		if (!state.destroyed && !state.errored && (state.length || state.ended)) {
+			stream.emit('readable');
+			state.emittedReadable = false;
+		}
+		state.needReadable = !state.flowing && !state.ended && state.length <= state.highWaterMark;
+		flow(stream);
+	}
+
+	// maybeReadMore 缓冲区低于 highWaterMark 时预先读取更多数据
+	function maybeReadMore(stream, state) {
+		if (!state.readingMore) {
+			state.readingMore = true;
+			nextTick(maybeReadMoreNT, stream, state);
+		}
+	}
+
+	function maybeReadMoreNT(stream, state) {
+		while (!state.reading && !state.ended && !state.destroyed &&
+			(state.length < state.highWaterMark || (state.flowing && state.length === 0))) {
+			const len = state.length;
+			stream.read(0);
+			if (len === state.length) break;
+		}
+		state.readingMore = false;
+	}
+
+	function howMuchToRead(n, state) {
+		if (n <= 0 || (state.length === 0 && state.ended)) return 0;
+		if (state.objectMode) return 1;
+		if (n !== n) {
+			// 流动模式每次返回一个块，暂停模式返回缓冲区中的全部数据
+			if (state.flowing && state.length) return state.buffer[0].length;
+			return state.length;
+		}
+		if (n <= state.length) return n;
+		return state.ended ? state.length : 0;
+	}
+
+	// fromList 从缓冲区取出 n 个字节（字符）或一个对象
+	function fromList(n, state) {
+		const buffer = state.buffer;
+		if (buffer.length === 0) return null;
+		if (state.objectMode || n === buffer[0].length) return buffer.shift();
+		if (state.decoder) {
+			let ret = '';
+			while (ret.length < n) {
+				const str = buffer[0];
+				const need = n - ret.length;
+				if (str.length <= need) {
+					ret += str;
+					buffer.shift();
+				} else {
+					ret += str.slice(0, need);
+					buffer[0] = str.slice(need);
+				}
+			}
+			return ret;
+		}
+		if (n < buffer[0].length) {
+			const head = buffer[0];
+			buffer[0] = head.subarray(n);
+			return head.subarray(0, n);
+		}
+		const ret = Buffer.allocUnsafe(n);
+		let offset = 0;
+		while (offset < n) {
+			const buf = buffer[0];
+			const need = n - offset;
+			if (buf.length <= need) {
+				ret.set(buf, offset);
+				offset += buf.length;
+				buffer.shift();
+			} else {
+				ret.set(buf.subarray(0, need), offset);
+				buffer[0] = buf.subarray(need);
+				offset += need;
+			}
+		}
+		return ret;
+	}
+
+	Readable.prototype.read = function (n) {
+		const state = this._readableState;
+		if (n === undefined) n = NaN;
+		else if (typeof n !== 'number') n = parseInt(n, 10);
+		const nOrig = n;
+		if (n !== 0) state.emittedReadable = false;
+
+		if (n === 0 && state.needReadable &&
+			((state.highWaterMark !== 0 ? state.length >= state.highWaterMark : state.length > 0) || state.ended)) {
+			if (state.length === 0 && state.ended) endReadable(this);
+			else emitReadable(this);
+			return null;
+		}
+
+		n = howMuchToRead(n, state);
+		if (n === 0 && state.ended) {
+			if (state.length === 0) endReadable(this);
+			return null;
+		}
+
+		let doRead = state.needReadable || state.length === 0 || state.length - n < state.highWaterMark;
+		if (state.ended || state.reading || state.destroyed || state.errored) {
+			doRead = false;
+		} else if (doRead) {
+			state.reading = true;
+			state.sync = true;
+			if (state.length === 0) state.needReadable = true;
+			try {
+				this._read(state.highWaterMark);
+			} catch (err) {
+				errorOrDestroy(this, err);
+			}
+			state.sync = false;
+			if (!state.reading) n = howMuchToRead(nOrig, state);
+		}
+
+		const ret = n > 0 ? fromList(n, state) : null;
+		if (ret === null) {
+			state.needReadable = state.length <= state.highWaterMark;
+			n = 0;
+		} else {
+			state.length -= state.objectMode ? 1 : n;
+		}
+
+		if (state.length === 0) {
+			if (!state.ended) state.needReadable = true;
+			if (nOrig !== n && state.ended) endReadable(this);
+		}
+
+		if (ret !== null && !state.errorEmitted && !state.closeEmitted) {
+			state.dataEmitted = true;
+			this.emit('data', ret);
+		}
+		return ret;
+	};
+
+	function endReadable(stream) {
+		const state = stream._readableState;
+		if (!state.endEmitted) {
+			state.ended = true;
+			nextTick(endReadableNT, state, stream);
+		}
+	}
+
+	function endReadableNT(state, stream) {
+		if (state.errored || state.closeEmitted || state.endEmitted || state.length !== 0) return;
+		state.endEmitted = true;
+		stream.emit('end');
+
+		const w = stream._writableState;
+		if (w && stream.allowHalfOpen === false && !w.ending && !w.destroyed) {
+			// 不允许半开时，可读端结束后同时结束可写端
+			stream.end();
+		} else if (state.autoDestroy && (!w || (w.autoDestroy && (w.finished || w.writable === false)))) {
+			stream.destroy();
+		}
+	}
+
+	function flow(stream) {
+		const state = stream._readableState;
+		while (state.flowing && stream.read() !== null);
+	}
+
+	Readable.prototype.resume = function () {
+		const state = this._readableState;
+		if (!state.flowing) {
+			state.flowing = !state.readableListening;
+			if (!state.resumeScheduled) {
+				state.resumeScheduled = true;
+				nextTick(resumeNT, this, state);
+			}
+		}
+		state.paused = false;
+		return this;
+	};
+
+	function resumeNT(stream, state) {
+		if (!state.reading) stream.read(0);
+		state.resumeScheduled = false;
+		stream.emit('resume');
+		flow(stream);
+		if (state.flowing && !state.reading) stream.read(0);
+	}
+
+	Readable.prototype.pause = function () {
+		const state = this._readableState;
+		if (state.flowing !== false) {
+			state.flowing = false;
+			this.emit('pause');
+		}
+		state.paused = true;
+		return this;
+	};
+
+	Readable.prototype.isPaused = function () {
+		const state = this._readableState;
+		return state.paused === true || state.flowing === false;
+	};
+
+	Readable.prototype.setEncoding = function (encoding) {
+		const state = this._readableState;
+		const decoder = new Decoder(encoding);
+		state.decoder = decoder;
+		state.encoding = decoder.encoding;
+
+		// 已缓冲的数据按新编码解码
+		let content = '';
+		for (const data of state.buffer) {
+			content += typeof data === 'string' ? data : decoder.write(data);
+		}
+		state.buffer = content ? [content] : [];
+		state.length = content.length;
+		return this;
+	};
+
+	// onListener 添加 data 监听器时切换到流动模式，添加 readable 监听器时切换到暂停模式
+	function onListener(stream, ev) {
+		const state = stream._readableState;
+		if (ev === 'data') {
+			state.readableListening = stream.listenerCount('readable') > 0;
+			if (state.flowing !== false) stream.resume();
+		} else if (ev === 'readable') {
+			if (!state.endEmitted && !state.readableListening) {
+				state.readableListening = state.needReadable = true;
+				state.flowing = false;
+				state.emittedReadable = false;
+				if (state.length) emitReadable(stream);
+				else if (!state.reading) nextTick(readZeroNT, stream);
+			}
+		}
+	}
+
+	function readZeroNT(stream) {
+		stream.read(0);
+	}
+
+	function updateReadableListening(stream) {
+		const state = stream._readableState;
+		state.readableListening = stream.listenerCount('readable') > 0;
+		if (state.resumeScheduled && state.paused === false) state.flowing = true;
+		else if (stream.listenerCount('data') > 0) stream.resume();
+		else if (!state.readableListening) state.flowing = null;
+	}
+
+	for (const method of ['on', 'addListener', 'prependListener', 'once', 'prependOnceListener']) {
+		Readable.prototype[method] = function (ev, fn) {
+			const res = Stream.prototype[method].call(this, ev, fn);
+			onListener(this, ev);
+			return res;
+		};
+	}
+
+	Readable.prototype.removeListener = function (ev, fn) {
+		const res = Stream.prototype.removeListener.call(this, ev, fn);
+		if (ev === 'readable') nextTick(updateReadableListening, this);
+		return res;
+	};
+	Readable.prototype.off = Readable.prototype.removeListener;
+
+	Readable.prototype.removeAllListeners = function (ev) {
+		const res = Stream.prototype.removeAllListeners.apply(this, arguments);
+		if (ev === 'readable' || ev === undefined) nextTick(updateReadableListening, this);
+		return res;
+	};
+
+	// pipe 把数据写入 dest，dest.write 返回 false 时暂停读取，直到 dest 触发 drain
+	Readable.prototype.pipe = function (dest, options) {
+		const src = this;
+		const state = this._readableState;
+		state.pipes.push(dest);
+
+		let cleanedUp = false;
+		let ondrain = null;
+
+		function ondata(chunk) {
+			if (dest.write(chunk) === false) pause();
+		}
+
+		function pause() {
+			if (!cleanedUp) {
+				if (!state.awaitDrain) state.awaitDrain = new Set();
+				state.awaitDrain.add(dest);
+				src.pause();
+			}
+			if (!ondrain) {
+				ondrain = () => {
+					if (state.awaitDrain) state.awaitDrain.delete(dest);
+					if ((!state.awaitDrain || state.awaitDrain.size === 0) && src.listenerCount('data') > 0) {
+						src.resume();
+					}
+				};
+				dest.on('drain', ondrain);
+			}
+		}
+
+		function onerror(err) {
+			unpipe();
+			dest.removeListener('error', onerror);
+			if (dest.listenerCount('error') === 0) {
+				const s = dest._writableState || dest._readableState;
+				if (s && !s.errorEmitted) errorOrDestroy(dest, err);
+				else dest.emit('error', err);
+			}
+		}
+
+		function onclose() {
+			dest.removeListener('finish', onfinish);
+			unpipe();
+		}
+
+		function onfinish() {
+			dest.removeListener('close', onclose);
+			unpipe();
+		}
+
+		function onunpipe(readable) {
+			if (readable === src) cleanup();
+		}
+
+		function onend() {
+			dest.end();
+		}
+
+		function unpipe() {
+			src.unpipe(dest);
+		}
+
+		function cleanup() {
+			cleanedUp = true;
+			dest.removeListener('close', onclose);
+			dest.removeListener('finish', onfinish);
+			if (ondrain) dest.removeListener('drain', ondrain);
+			dest.removeListener('error', onerror);
+			dest.removeListener('unpipe', onunpipe);
+			src.removeListener('end', onend);
+			src.removeListener('end', unpipe);
+			src.removeListener('data', ondata);
+		}
+
+		// 与 Node.js 一致，process.stdout/stderr 作为目标时不会被结束
+		const doEnd = (!options || options.end !== false) && !dest[kStdio];
+		const endFn = doEnd ? onend : unpipe;
+		if (state.endEmitted) nextTick(endFn);
+		else src.once('end', endFn);
+
+		dest.on('unpipe', onunpipe);
+		src.on('data', ondata);
+		dest.prependListener('error', onerror);
+		dest.once('close', onclose);
+		dest.once('finish', onfinish);
+		dest.emit('pipe', src);
+
+		if (dest.writableNeedDrain === true) pause();
+		else if (!state.flowing) src.resume();
+		return dest;
+	};
+
+	Readable.prototype.unpipe = function (dest) {
+		const state = this._readableState;
+		const dests = dest ? [dest] : state.pipes.slice();
+		for (const d of dests) {
+			const index = state.pipes.indexOf(d);
+			if (index === -1) continue;
+			state.pipes.splice(index, 1);
+			if (state.awaitDrain) state.awaitDrain.delete(d);
+			if (state.pipes.length === 0) this.pause();
+			d.emit('unpipe', this, { hasUnpiped: false });
+		}
+		return this;
+	};
+
+	// 异步迭代：每次返回缓冲区中的数据，结束时 done，出错时抛出；提前退出循环会销毁流
+	Readable.prototype[kAsyncIterator] = function () {
+		const stream = this;
+		let error = null;
+		let finishedReading = false;
+		let wake = null;
+
+		const notify = () => {
+			if (wake) {
+				const fn = wake;
+				wake = null;
+				fn();
+			}
+		};
+		stream.on('readable', notify);
+		const cleanup = finished(stream, { writable: false }, (err) => {
+			error = err && err.code !== 'ERR_STREAM_PREMATURE_CLOSE' ? err : null;
+			finishedReading = true;
+			notify();
+		});
+
+		const done = () => {
+			cleanup();
+			stream.removeListener('readable', notify);
+		};
+
+		return {
+			next() {
+				return new Promise((resolve, reject) => {
+					const attempt = () => {
+						const chunk = stream.destroyed ? null : stream.read();
+						if (chunk !== null) {
+							resolve({ value: chunk, done: false });
+						} else if (error) {
+							done();
+							reject(error);
+						} else if (finishedReading) {
+							done();
+							resolve({ value: undefined, done: true });
+						} else {
+							wake = attempt;
+						}
+					};
+					attempt();
+				});
+			},
+			return() {
+				done();
+				stream.destroy();
+				return Promise.resolve({ value: undefined, done: true });
+			},
+			[kAsyncIterator]() {
+				return this;
+			},
+		};
+	};
+
+	Readable.from = function (iterable, options) {
+		options = Object.assign({ objectMode: true, highWaterMark: 1 }, options);
+		if (typeof iterable === 'string' || iterable instanceof Uint8Array) {
+			let pushed = false;
+			return new Readable(Object.assign(options, {
+				read() {
+					if (!pushed) {
+						pushed = true;
+						this.push(iterable);
+					}
+					this.push(null);
+				},
+			}));
+		}
+
+		let iterator;
+		let isAsync = false;
+		if (iterable && typeof iterable[kAsyncIterator] === 'function') {
+			iterator = iterable[kAsyncIterator]();
+			isAsync = true;
+		} else if (iterable && typeof iterable[Symbol.iterator] === 'function') {
+			iterator = iterable[Symbol.iterator]();
+		} else {
+			throw codedError(TypeError, 'ERR_INVALID_ARG_TYPE', 'The "iterable" argument must be an instance of Iterable');
+		}
+
+		const readable = new Readable(options);
+		let reading = false;
+		// push 推入一个值，返回是否继续读取下一个
+		function push(value) {
+			if (value === null) {
+				throw codedError(TypeError, 'ERR_STREAM_NULL_VALUES', 'May not write null values to stream');
+			}
+			if (readable.push(value)) return true;
+			reading = false;
+			return false;
+		}
+		function next() {
+			if (!isAsync) {
+				// 同步迭代器直接取值，只有值为 Promise 时才等待
+				try {
+					for (;;) {
+						const res = iterator.next();
+						if (res.done) {
+							readable.push(null);
+							return;
+						}
+						if (res.value && typeof res.value.then === 'function') {
+							Promise.resolve(res.value)
+								.then((value) => {
+									if (push(value)) next();
+								})
+								.catch((err) => readable.destroy(err));
+							return;
+						}
+						if (!push(res.value)) return;
+					}
+				} catch (err) {
+					readable.destroy(err);
+				}
+				return;
+			}
+			Promise.resolve()
+				.then(() => iterator.next())
+				.then((res) => {
+					if (res.done) {
+						readable.push(null);
+						return;
+					}
+					return Promise.resolve(res.value).then((value) => {
+						if (push(value)) next();
+					});
+				})
+				.catch((err) => readable.destroy(err));
+		}
+		hidden(readable, '_read', function () {
+			if (!reading) {
+				reading = true;
+				next();
+			}
+		});
+		hidden(readable, '_destroy', function (err, cb) {
+			if (typeof iterator.return !== 'function') {
+				cb(err);
+				return;
+			}
+			Promise.resolve()
+				.then(() => iterator.return())
+				.then(() => cb(err), (e) => cb(err || e));
+		});
+		return readable;
+	};
+
+	const readableProps = {
+		readable(s) {
+			return s.readable !== false && !s.destroyed && !s.errorEmitted && !s.endEmitted;
+		},
+		readableEnded: (s) => s.endEmitted,
+		readableLength: (s) => s.length,
+		readableHighWaterMark: (s) => s.highWaterMark,
+		readableObjectMode: (s) => s.objectMode,
+		readableEncoding: (s) => s.encoding,
+		readableAborted: (s) => (s.destroyed || !!s.errored) && !s.endEmitted,
+		readableDidRead: (s) => s.dataEmitted,
+	};
+	for (const key of Object.keys(readableProps)) {
+		Object.defineProperty(Readable.prototype, key, {
+			configurable: true,
+			get() {
+				return readableProps[key](this._readableState);
+			},
+		});
+	}
+	Object.defineProperty(Readable.prototype, 'readableFlowing', {
+		configurable: true,
+		get() {
+			return this._readableState.flowing;
+		},
+		set(value) {
+			this._readableState.flowing = value;
+		},
+	});
+
+	function defineCommonProps(proto, primary) {
+		Object.defineProperties(proto, {
+			destroyed: {
+				configurable: true,
+				get() {
+					const s = this[primary];
+					return !!s && s.destroyed;
+				},
+				set(value) {
+					if (this._readableState) this._readableState.destroyed = value;
+					if (this._writableState) this._writableState.destroyed = value;
+				},
+			},
+			closed: {
+				configurable: true,
+				get() {
+					const s = this[primary];
+					return !!s && s.closed;
+				},
+			},
+			errored: {
+				configurable: true,
+				get() {
+					const s = this[primary];
+					return s ? s.errored : null;
+				},
+			},
+		});
+	}
+	defineCommonProps(Readable.prototype, '_readableState');
+
+	// ---- Writable ----
+
+	function WritableState(options, isDuplex) {
+		this.objectMode = !!(options.objectMode || (isDuplex && options.writableObjectMode));
+		this.highWaterMark = getHighWaterMark(options, isDuplex ? 'writableHighWaterMark' : 'highWaterMark', this.objectMode);
+		this.decodeStrings = options.decodeStrings !== false;
+		this.defaultEncoding = options.defaultEncoding || 'utf8';
+		this.length = 0;
+		this.writing = false;
+		this.corked = 0;
+		this.sync = true;
+		this.buffered = [];
+		this.pendingcb = 0;
+		this.writecb = null;
+		this.writelen = 0;
+		this.onwrite = null;
+		this.needDrain = false;
+		this.ending = false;
+		this.ended = false;
+		this.finalCalled = false;
+		this.prefinished = false;
+		this.finished = false;
+		this.onFinished = [];
+		this.destroyed = false;
+		this.errored = null;
+		this.errorEmitted = false;
+		this.closed = false;
+		this.closeEmitted = false;
+		this.autoDestroy = options.autoDestroy !== false;
+		this.emitClose = options.emitClose !== false;
+	}
+
+	function Writable(options) {
+		const isDuplex = this instanceof Duplex;
+		if (!isDuplex && !realHasInstance.call(Writable, this)) return new Writable(options);
+		options = options || {};
+		const state = new WritableState(options, isDuplex);
+		state.onwrite = (err) => onwrite(this, err);
+		hidden(this, '_writableState', state);
+		if (typeof options.write === 'function') hidden(this, '_write', options.write);
+		if (typeof options.writev === 'function') hidden(this, '_writev', options.writev);
+		if (typeof options.final === 'function') hidden(this, '_final', options.final);
+		if (typeof options.destroy === 'function') hidden(this, '_destroy', options.destroy);
+		Stream.call(this, options);
+		if (options.signal) addAbortSignal(options.signal, this);
+	}
+	Object.setPrototypeOf(Writable.prototype, Stream.prototype);
+	Object.setPrototypeOf(Writable, Stream);
+
+	// Duplex 只继承 Readable，instanceof Writable 按是否有可写状态判断
+	Object.defineProperty(Writable, Symbol.hasInstance, {
+		value(obj) {
+			if (realHasInstance.call(this, obj)) return true;
+			if (this !== Writable) return false;
+			return !!(obj && obj._writableState);
+		},
+	});
+
+	Writable.prototype.destroy = destroy;
+	Writable.prototype._destroy = defaultDestroy;
+
+	Writable.prototype._write = function () {
+		throw codedError(Error, 'ERR_METHOD_NOT_IMPLEMENTED', 'The _write() method is not implemented');
+	};
+
+	// writeChunk 写入或缓冲数据块，返回是否低于 highWaterMark；写入已结束或已销毁的流时返回错误
+	function writeChunk(stream, chunk, encoding, cb) {
+		const state = stream._writableState;
+		if (typeof encoding === 'function') {
+			cb = encoding;
+			encoding = null;
+		}
+		if (!encoding) encoding = state.defaultEncoding;
+		if (typeof cb !== 'function') cb = nop;
+
+		if (chunk === null) {
+			throw codedError(TypeError, 'ERR_STREAM_NULL_VALUES', 'May not write null values to stream');
+		}
+		if (!state.objectMode) {
+			if (typeof chunk !== 'string' || state.decodeStrings) {
+				chunk = toBuffer(chunk, encoding);
+				encoding = 'buffer';
+			}
+		}
+
+		let err;
+		if (state.ending) err = codedError(Error, 'ERR_STREAM_WRITE_AFTER_END', 'write after end');
+		else if (state.destroyed) err = codedError(Error, 'ERR_STREAM_DESTROYED', 'Cannot call write after a stream was destroyed');
+		if (err) {
+			nextTick(cb, err);
+			errorOrDestroy(stream, err, true);
+			return err;
+		}
+
+		state.pendingcb++;
+		const len = state.objectMode ? 1 : chunk.length;
+		state.length += len;
+		const ret = state.length < state.highWaterMark;
+		if (!ret) state.needDrain = true;
+
+		if (state.writing || state.corked || state.errored) {
+			state.buffered.push({ chunk: chunk, encoding: encoding, callback: cb });
+		} else {
+			doWrite(stream, state, chunk, encoding, cb, len);
+		}
+		return ret && !state.errored && !state.destroyed;
+	}
+
+	function doWrite(stream, state, chunk, encoding, cb, len, writev) {
+		state.writelen = len;
+		state.writecb = cb;
+		state.writing = true;
+		state.sync = true;
+		if (state.destroyed) state.onwrite(codedError(Error, 'ERR_STREAM_DESTROYED', 'Cannot call write after a stream was destroyed'));
+		else if (writev) stream._writev(chunk, state.onwrite);
+		else stream._write(chunk, encoding, state.onwrite);
+		state.sync = false;
+	}
+
+	function onwrite(stream, err) {
+		const state = stream._writableState;
+		const sync = state.sync;
+		const cb = state.writecb;
+		if (typeof cb !== 'function') {
+			errorOrDestroy(stream, codedError(Error, 'ERR_MULTIPLE_CALLBACK', 'Callback called multiple times'));
+			return;
+		}
+		state.writing = false;
+		state.writecb = null;
+		state.length -= state.writelen;
+		state.writelen = 0;
+
+		if (err) {
+			if (!state.errored) state.errored = err;
+			if (sync) nextTick(onwriteError, stream, state, err, cb);
+			else onwriteError(stream, state, err, cb);
+			return;
+		}
+		if (state.buffered.length) clearBuffer(stream, state);
+		if (sync) nextTick(afterWrite, stream, state, cb);
+		else afterWrite(stream, state, cb);
+	}
+
+	function onwriteError(stream, state, err, cb) {
+		state.pendingcb--;
+		cb(err);
+		errorBuffer(state);
+		errorOrDestroy(stream, err);
+	}
+
+	function afterWrite(stream, state, cb) {
+		if (!state.ending && !state.destroyed && state.length === 0 && state.needDrain) {
+			state.needDrain = false;
+			stream.emit('drain');
+		}
+		state.pendingcb--;
+		cb(null);
+		if (state.destroyed) errorBuffer(state);
+		finishMaybe(stream, state);
+	}
+
+	// errorBuffer 写入出错或流被销毁后，以错误回调所有缓冲中的写入
+	function errorBuffer(state) {
+		if (state.writing) return;
+		const buffered = state.buffered;
+		state.buffered = [];
+		for (const entry of buffered) {
+			state.length -= state.objectMode ? 1 : entry.chunk.length;
+			state.pendingcb--;
+			entry.callback(state.errored || codedError(Error, 'ERR_STREAM_DESTROYED', 'Cannot call write after a stream was destroyed'));
+		}
+		callFinished(state, state.errored || prematureClose());
+	}
+
+	// clearBuffer 写入下一个缓冲块；实现了 _writev 时一次写入全部缓冲块
+	function clearBuffer(stream, state) {
+		if (state.corked || state.writing || state.destroyed || state.buffered.length === 0) return;
+		if (state.buffered.length > 1 && typeof stream._writev === 'function') {
+			const entries = state.buffered;
+			state.buffered = [];
+			let len = 0;
+			for (const entry of entries) len += state.objectMode ? 1 : entry.chunk.length;
+			const callback = (err) => {
+				// 每个缓冲块各占一个 pendingcb，afterWrite 只减少一次
+				state.pendingcb -= entries.length - 1;
+				for (const entry of entries) entry.callback(err);
+			};
+			doWrite(stream, state, entries, '', callback, len, true);
+			return;
+		}
+		const entry = state.buffered.shift();
+		doWrite(stream, state, entry.chunk, entry.encoding, entry.callback, state.objectMode ? 1 : entry.chunk.length);
+	}
+
+	Writable.prototype.write = function (chunk, encoding, cb) {
+		return writeChunk(this, chunk, encoding, cb) === true;
+	};
+
+	Writable.prototype.cork = function () {
+		this._writableState.corked++;
+	};
+
+	Writable.prototype.uncork = function () {
+		const state = this._writableState;
+		if (state.corked) {
+			state.corked--;
+			if (!state.writing) clearBuffer(this, state);
+		}
+	};
+
+	Writable.prototype.setDefaultEncoding = function (encoding) {
+		if (!native.normalize(String(encoding))) {
+			throw codedError(TypeError, 'ERR_UNKNOWN_ENCODING', 'Unknown encoding: ' + encoding);
+		}
+		this._writableState.defaultEncoding = encoding;
+		return this;
+	};
+
+	Writable.prototype.end = function (chunk, encoding, cb) {
+		const state = this._writableState;
+		if (typeof chunk === 'function') {
+			cb = chunk;
+			chunk = null;
+			encoding = null;
+		} else if (typeof encoding === 'function') {
+			cb = encoding;
+			encoding = null;
+		}
+
+		let err;
+		if (chunk !== null && chunk !== undefined) {
+			const ret = writeChunk(this, chunk, encoding);
+			if (ret instanceof Error) err = ret;
+		}
+		if (state.corked) {
+			state.corked = 1;
+			this.uncork();
+		}
+
+		if (err) {
+			// 写入错误已经交给回调和 error 事件
+		} else if (!state.errored && !state.ending) {
+			state.ending = true;
+			finishMaybe(this, state, true);
+			state.ended = true;
+		} else if (state.finished) {
+			err = codedError(Error, 'ERR_STREAM_ALREADY_FINISHED', 'Cannot call end after a stream was finished');
+		} else if (state.destroyed) {
+			err = codedError(Error, 'ERR_STREAM_DESTROYED', 'Cannot call end after a stream was destroyed');
+		}
+
+		if (typeof cb === 'function') {
+			if (err || state.finished) nextTick(cb, err);
+			else state.onFinished.push(cb);
+		}
+		return this;
+	};
+
+	function needFinish(state) {
+		return state.ending && !state.destroyed && state.length === 0 && !state.errored &&
+			state.buffered.length === 0 && !state.finished && !state.writing &&
+			!state.errorEmitted && !state.closeEmitted;
+	}
+
+	function callFinal(stream, state) {
+		let called = false;
+		function onFinish(err) {
+			if (called) {
+				errorOrDestroy(stream, codedError(Error, 'ERR_MULTIPLE_CALLBACK', 'Callback called multiple times'));
+				return;
+			}
+			called = true;
+			state.pendingcb--;
+			if (err) {
+				callFinished(state, err);
+				errorOrDestroy(stream, err, state.sync);
+			} else if (needFinish(state)) {
+				state.prefinished = true;
+				stream.emit('prefinish');
+				state.pendingcb++;
+				nextTick(finish, stream, state);
+			}
+		}
+		state.sync = true;
+		state.pendingcb++;
+		try {
+			stream._final(onFinish);
+		} catch (err) {
+			onFinish(err);
+		}
+		state.sync = false;
+	}
+
+	function prefinish(stream, state) {
+		if (state.prefinished || state.finalCalled) return;
+		if (typeof stream._final === 'function' && !state.destroyed) {
+			state.finalCalled = true;
+			callFinal(stream, state);
+		} else {
+			state.prefinished = true;
+			stream.emit('prefinish');
+		}
+	}
+
+	function finishMaybe(stream, state, sync) {
+		if (!needFinish(state)) return;
+		prefinish(stream, state);
+		if (state.pendingcb !== 0) return;
+		state.pendingcb++;
+		if (sync) {
+			nextTick(() => {
+				if (needFinish(state)) finish(stream, state);
+				else state.pendingcb--;
+			});
+		} else {
+			finish(stream, state);
+		}
+	}
+
+	function finish(stream, state) {
+		state.pendingcb--;
+		state.finished = true;
+		callFinished(state);
+		stream.emit('finish');
+
+		if (state.autoDestroy) {
+			const r = stream._readableState;
+			if (!r || (r.autoDestroy && (r.endEmitted || r.readable === false))) stream.destroy();
+		}
+	}
+
+	function callFinished(state, err) {
+		const callbacks = state.onFinished.splice(0);
+		for (const cb of callbacks) cb(err);
+	}
+
+	const writableProps = {
+		writable(s) {
+			return s.writable !== false && !s.destroyed && !s.errored && !s.ending && !s.ended;
+		},
+		writableEnded: (s) => s.ending,
+		writableFinished: (s) => s.finished,
+		writableLength: (s) => s.length,
+		writableHighWaterMark: (s) => s.highWaterMark,
+		writableObjectMode: (s) => s.objectMode,
+		writableCorked: (s) => s.corked,
+		writableNeedDrain(s) {
+			return !s.destroyed && !s.ending && s.needDrain;
+		},
+	};
+	for (const key of Object.keys(writableProps)) {
+		Object.defineProperty(Writable.prototype, key, {
+			configurable: true,
+			get() {
+				const state = this._writableState;
+				return state ? writableProps[key](state) : undefined;
+			},
+		});
+	}
+	defineCommonProps(Writable.prototype, '_writableState');
+
+	// ---- Duplex / Transform / PassThrough ----
+
+	function Duplex(options) {
+		if (!(this instanceof Duplex)) return new Duplex(options);
+		options = options || {};
+		Readable.call(this, options);
+		Writable.call(this, options);
+		hidden(this, 'allowHalfOpen', options.allowHalfOpen !== false);
+		if (options.readable === false) {
+			const r = this._readableState;
+			r.readable = false;
+			r.ended = true;
+			r.endEmitted = true;
+		}
+		if (options.writable === false) {
+			const w = this._writableState;
+			w.writable = false;
+			w.ending = true;
+			w.ended = true;
+			w.finished = true;
+		}
+	}
+	Object.setPrototypeOf(Duplex.prototype, Readable.prototype);
+	Object.setPrototypeOf(Duplex, Readable);
+	for (const key of Object.getOwnPropertyNames(Writable.prototype)) {
+		if (key === 'constructor' || Object.prototype.hasOwnProperty.call(Duplex.prototype, key)) continue;
+		if (Object.prototype.hasOwnProperty.call(Readable.prototype, key)) continue;
+		Object.defineProperty(Duplex.prototype, key, Object.getOwnPropertyDescriptor(Writable.prototype, key));
+	}
+
+	function Transform(options) {
+		if (!(this instanceof Transform)) return new Transform(options);
+		options = options || {};
+		Duplex.call(this, options);
+		this._readableState.sync = false;
+		hidden(this, kCallback, null);
+		if (typeof options.transform === 'function') hidden(this, '_transform', options.transform);
+		if (typeof options.flush === 'function') hidden(this, '_flush', options.flush);
+	}
+	Object.setPrototypeOf(Transform.prototype, Duplex.prototype);
+	Object.setPrototypeOf(Transform, Duplex);
+
+	Transform.prototype._transform = function () {
+		throw codedError(Error, 'ERR_METHOD_NOT_IMPLEMENTED', 'The _transform() method is not implemented');
+	};
+
+	// _final 可写端结束时调用 _flush，然后结束可读端
+	Transform.prototype._final = function (cb) {
+		if (typeof this._flush !== 'function' || this.destroyed) {
+			this.push(null);
+			cb();
+			return;
+		}
+		this._flush((err, data) => {
+			if (err) {
+				cb(err);
+				return;
+			}
+			if (data !== null && data !== undefined) this.push(data);
+			this.push(null);
+			cb();
+		});
+	};
+
+	// _write 可读端缓冲区满时暂缓回调，等待下游读取后再接收下一块
+	Transform.prototype._write = function (chunk, encoding, callback) {
+		const r = this._readableState;
+		const w = this._writableState;
+		const length = r.length;
+		this._transform(chunk, encoding, (err, value) => {
+			if (err) {
+				callback(err);
+				return;
+			}
+			if (value !== null && value !== undefined) this.push(value);
+			if (w.ended || length === r.length || r.length < r.highWaterMark) callback();
+			else this[kCallback] = callback;
+		});
+	};
+
+	Transform.prototype._read = function () {
+		const callback = this[kCallback];
+		if (callback) {
+			this[kCallback] = null;
+			callback();
+		}
+	};
+
+	function PassThrough(options) {
+		if (!(this instanceof PassThrough)) return new PassThrough(options);
+		Transform.call(this, options);
+	}
+	Object.setPrototypeOf(PassThrough.prototype, Transform.prototype);
+	Object.setPrototypeOf(PassThrough, Transform);
+
+	PassThrough.prototype._transform = function (chunk, encoding, cb) {
+		cb(null, chunk);
+	};
+
+	// ---- finished / pipeline ----
+
+	function isStream(obj) {
+		return !!obj && typeof obj === 'object' && typeof obj.on === 'function' &&
+			(typeof obj.pipe === 'function' || typeof obj.write === 'function');
+	}
+
+	// finished 在流结束（end/finish）、出错或过早关闭时回调一次，返回移除监听器的函数
+	function finished(stream, options, callback) {
+		if (typeof options === 'function') {
+			callback = options;
+			options = {};
+		}
+		options = options || {};
+		const r = stream._readableState;
+		const w = stream._writableState;
+		const readable = options.readable !== undefined ? !!options.readable : !!r && r.readable !== false;
+		const writable = options.writable !== undefined ? !!options.writable : !!w && w.writable !== false;
+		let readableFinished = !r || r.endEmitted;
+		let writableFinished = !w || w.finished;
+
+		let called = false;
+		const done = (err) => {
+			if (called) return;
+			called = true;
+			cleanup();
+			callback.call(stream, err);
+		};
+		const onend = () => {
+			readableFinished = true;
+			if (!writable || writableFinished) done();
+		};
+		const onfinish = () => {
+			writableFinished = true;
+			if (!readable || readableFinished) done();
+		};
+		const onerror = (err) => done(err);
+		const onclose = () => {
+			if (readable && !readableFinished) done((r && r.errored) || prematureClose());
+			else if (writable && !writableFinished) done((w && w.errored) || prematureClose());
+			else done();
+		};
+		function cleanup() {
+			stream.removeListener('end', onend);
+			stream.removeListener('finish', onfinish);
+			stream.removeListener('error', onerror);
+			stream.removeListener('close', onclose);
+		}
+
+		stream.on('end', onend);
+		stream.on('finish', onfinish);
+		stream.on('error', onerror);
+		stream.on('close', onclose);
+
+		const errored = (r && r.errorEmitted && r.errored) || (w && w.errorEmitted && w.errored);
+		if (errored) nextTick(done, errored);
+		else if ((r && r.closeEmitted) || (w && w.closeEmitted)) nextTick(onclose);
+		else if ((!readable || readableFinished) && (!writable || writableFinished)) nextTick(done);
+		return cleanup;
+	}
+
+	function splitArgs(args) {
+		let streams = Array.prototype.slice.call(args);
+		if (streams.length === 1 && Array.isArray(streams[0])) streams = streams[0];
+		return streams;
+	}
+
+	// pipeline 依次连接各个流，任一流出错时销毁所有流；全部完成后回调。
+	// 第一个参数可以是可迭代对象，其余位置可以是接收上一级可迭代数据的（异步生成器）函数
+	function pipeline() {
+		const streams = splitArgs(arguments);
+		const callback = streams.pop();
+		if (typeof callback !== 'function') {
+			throw codedError(TypeError, 'ERR_INVALID_ARG_TYPE', 'The "callback" argument must be of type function');
+		}
+		return pipelineImpl(streams, callback);
+	}
+
+	function pipelineImpl(streams, callback) {
+		if (streams.length < 2) {
+			throw codedError(TypeError, 'ERR_MISSING_ARGS', 'The "streams" argument must be specified');
+		}
+
+		const destroys = [];
+		let error = null;
+		let value;
+		let remaining = 0;
+		let called = false;
+
+		function finishOne(err) {
+			if (err && (!error || error.code === 'ERR_STREAM_PREMATURE_CLOSE')) {
+				const first = !error;
+				error = err;
+				if (first) destroys.forEach((fn) => fn(err));
+			}
+			if (--remaining === 0 && !called) {
+				called = true;
+				nextTick(callback, error || undefined, value);
+			}
+		}
+
+		function track(stream, readable, writable) {
+			remaining++;
+			destroys.push((err) => {
+				if (!stream.destroyed && typeof stream.destroy === 'function') stream.destroy(err);
+			});
+			finished(stream, { readable: readable, writable: writable }, finishOne);
+		}
+
+		let prev = null;
+		let last = null;
+		for (let i = 0; i < streams.length; i++) {
+			const isLast = i === streams.length - 1;
+			let stream = streams[i];
+			if (typeof stream === 'function') {
+				if (i === 0) {
+					stream = Readable.from(stream());
+					track(stream, true, false);
+				} else if (isLast) {
+					remaining++;
+					Promise.resolve()
+						.then(() => stream(prev))
+						.then((result) => {
+							value = result;
+							finishOne();
+						}, finishOne);
+					last = null;
+					continue;
+				} else {
+					stream = Readable.from(stream(prev));
+					track(stream, true, false);
+				}
+			} else if (!isStream(stream)) {
+				if (i !== 0) {
+					throw codedError(TypeError, 'ERR_INVALID_ARG_TYPE', 'The "streams[' + i + ']" argument must be a stream or function');
+				}
+				stream = Readable.from(stream);
+				track(stream, true, false);
+			} else {
+				track(stream, !isLast, i > 0);
+				if (i > 0) {
+					prev.pipe(stream);
+					// pipe 不结束标准输出流，与 Node.js 一致 pipeline 在源流结束时结束它
+					if (stream[kStdio]) prev.once('end', () => stream.end());
+				}
+			}
+			prev = stream;
+			last = stream;
+		}
+		return last;
+	}
+
+	const promises = {
+		pipeline() {
+			const streams = splitArgs(arguments);
+			return new Promise((resolve, reject) => {
+				pipelineImpl(streams, (err, value) => (err ? reject(err) : resolve(value)));
+			});
+		},
+		finished(stream, options) {
+			return new Promise((resolve, reject) => {
+				finished(stream, options, (err) => (err ? reject(err) : resolve()));
+			});
+		},
+	};
+
+	// fromHandle 把原生 I/O 句柄包装为流：句柄提供 read(size, cb) 时可读，提供 write(chunk, cb)
+	// 和 end(cb) 时可写；流销毁时调用 close()
+	function fromHandle(handle, options, transform) {
+		options = Object.assign({ highWaterMark: 64 * 1024 }, options);
+		const readable = typeof handle.read === 'function';
+		const writable = typeof handle.write === 'function';
+		const Ctor = transform ? Transform : readable && writable ? Duplex : readable ? Readable : Writable;
+		const stream = new Ctor(options);
+		if (readable) {
+			hidden(stream, '_read', function (size) {
+				handle.read(size, (err, chunk) => {
+					if (err) this.destroy(err);
+					else this.push(chunk);
+				});
+			});
+		}
+		if (writable) {
+			hidden(stream, '_write', function (chunk, encoding, cb) {
+				handle.write(typeof chunk === 'string' ? Buffer.from(chunk, encoding) : chunk, cb);
+				// 转换流在写入后开始读取输出（直到 highWaterMark），及时发现转换错误
+				if (transform) this.read(0);
+			});
+			// 合并缓冲中的多个小块，减少原生写入次数
+			hidden(stream, '_writev', function (entries, cb) {
+				const chunks = entries.map((e) => (typeof e.chunk === 'string' ? Buffer.from(e.chunk, e.encoding) : e.chunk));
+				this._write(Buffer.concat(chunks), 'buffer', cb);
+			});
+			hidden(stream, '_final', function (cb) {
+				handle.end(cb);
+			});
+		}
+		hidden(stream, '_destroy', function (err, cb) {
+			handle.close();
+			cb(err);
+		});
+		return stream;
+	}
+
+	// stdio 创建同步写入的标准输出流，write(chunk, encoding) 由原生代码实现
+	function stdio(write) {
+		const stream = new Writable({
+			decodeStrings: false,
+			write(chunk, encoding, cb) {
+				write(chunk, encoding);
+				cb();
+			},
+		});
+		hidden(stream, kStdio, true);
+		return stream;
+	}
+
+	Stream.Stream = Stream;
+	Stream.Readable = Readable;
+	Stream.Writable = Writable;
+	Stream.Duplex = Duplex;
+	Stream.Transform = Transform;
+	Stream.PassThrough = PassThrough;
+	Stream.pipeline = pipeline;
+	Stream.finished = finished;
+	Stream.addAbortSignal = addAbortSignal;
+	Stream.promises = promises;
+
+	return { Stream: Stream, fromHandle: fromHandle, stdio: stdio };
+})
+`
@@ -10,3 +10,9 @@ type NamespaceModule interface {
 	GetModule() *goja.Object
 	GetSubModule(name string) (BuiltinModule, bool)
 }
+
+// Resetter 由持有服务器、连接、数据库等资源的模块实现，
+// Runner 复用前调用 Reset 关闭上一次使用打开的资源
+type Resetter interface {
+	Reset()
+}
@@ -0,0 +1,36 @@
+package types
+
+import (
+	"errors"
+
+	"github.com/issueye/sw_runtime/internal/security"
+
+	"github.com/dop251/goja"
+)
+
+// PermissionDeniedName 权限错误在 JS 中的 name
+const PermissionDeniedName = "PermissionDenied"
+
+// NewPermissionError 将权限错误转换为 JS 错误对象：
+// name 为 PermissionDenied，code 为 ERR_PERMISSION_DENIED，并带有 permission 和 target 字段。
+// 包装过的错误只保留权限错误本身，使所有模块的错误消息一致。
+// 对象仍是 GoError，抛出后在 Go 侧可以通过 errors.As 取回 *security.PermissionDeniedError。
+func NewPermissionError(vm *goja.Runtime, err error) *goja.Object {
+	var denied *security.PermissionDeniedError
+	if !errors.As(err, &denied) {
+		return vm.NewGoError(err)
+	}
+	obj := vm.NewGoError(denied)
+	obj.Set("name", PermissionDeniedName)
+	obj.Set("code", "ERR_PERMISSION_DENIED")
+	obj.Set("permission", string(denied.Permission))
+	obj.Set("target", denied.Target)
+	return obj
+}
+
+// RejectPermission 返回以权限错误拒绝的 Promise，用于返回 Promise 的异步 API
+func RejectPermission(vm *goja.Runtime, err error) goja.Value {
+	promise, _, reject := vm.NewPromise()
+	reject(NewPermissionError(vm, err))
+	return vm.ToValue(promise)
+}
@@ -6,7 +6,10 @@ import (
 	"compress/zlib"
 	"encoding/base64"
 	"io"
-	"sw_runtime/internal/pool"
+
+	"github.com/issueye/sw_runtime/internal/builtins/buffer"
+	"github.com/issueye/sw_runtime/internal/builtins/stream"
+	"github.com/issueye/sw_runtime/internal/pool"
 
 	"github.com/dop251/goja"
 )
@@ -37,6 +40,12 @@ func (c *CompressionModule) GetModule() *goja.Object {
 	obj.Set("zlibCompress", c.zlibCompress)
 	obj.Set("zlibDecompress", c.zlibDecompress)
 
+	// 流式压缩/解压（Transform 流）
+	obj.Set("createGzip", c.createGzip)
+	obj.Set("createGunzip", c.createGunzip)
+	obj.Set("createDeflate", c.createDeflate)
+	obj.Set("createInflate", c.createInflate)
+
 	return obj
 }
 
@@ -46,7 +55,7 @@ func (c *CompressionModule) gzipCompress(call goja.FunctionCall) goja.Value {
 		panic(c.vm.NewTypeError("gzipCompress() missing data"))
 	}
 
-	data := call.Arguments[0].String()
+	data := buffer.ToBytes(c.vm, call.Arguments[0])
 
 	// 使用对象池获取缓冲区
 	buf := pool.GlobalManager.GetByteBuffer()
@@ -54,7 +63,7 @@ func (c *CompressionModule) gzipCompress(call goja.FunctionCall) goja.Value {
 
 	writer := gzip.NewWriter(buf)
 
-	_, err := writer.Write([]byte(data))
+	_, err := writer.Write(data)
 	if err != nil {
 		panic(c.vm.NewGoError(err))
 	}
@@ -64,9 +73,12 @@ func (c *CompressionModule) gzipCompress(call goja.FunctionCall) goja.Value {
 		panic(c.vm.NewGoError(err))
 	}
 
-	// 返回 base64 编码的压缩数据
-	encoded := base64.StdEncoding.EncodeToString(buf.Bytes())
-	return c.vm.ToValue(encoded)
+	// 默认返回 base64 编码的压缩数据，encoding 为 "buffer" 时返回 Buffer
+	encoding := buffer.Encoding(c.vm, call.Argument(1), "base64")
+	if encoding == "buffer" {
+		return buffer.New(c.vm, bytes.Clone(buf.Bytes()))
+	}
+	return buffer.Encode(c.vm, buf.Bytes(), encoding)
 }
 
 // gzipDecompress Gzip 解压
@@ -75,17 +87,19 @@ func (c *CompressionModule) gzipDecompress(call goja.FunctionCall) goja.Value {
 		panic(c.vm.NewTypeError("gzipDecompress() missing data"))
 	}
 
-	data := call.Arguments[0].String()
-
 	// 使用对象池获取字节切片
 	compressed := pool.GlobalManager.GetByteSlice()
 	defer pool.GlobalManager.PutByteSlice(compressed)
 
-	// 解码 base64
-	var err error
-	compressed, err = base64.StdEncoding.AppendDecode(compressed, []byte(data))
-	if err != nil {
-		panic(c.vm.NewGoError(err))
+	// 二进制数据直接解压，字符串按 base64 解码
+	if data, ok := buffer.Bytes(c.vm, call.Arguments[0]); ok {
+		compressed = append(compressed, data...)
+	} else {
+		var err error
+		compressed, err = base64.StdEncoding.AppendDecode(compressed, []byte(call.Arguments[0].String()))
+		if err != nil {
+			panic(c.vm.NewGoError(err))
+		}
 	}
 
 	reader, err := gzip.NewReader(bytes.NewReader(compressed))
@@ -113,7 +127,12 @@ func (c *CompressionModule) gzipDecompress(call goja.FunctionCall) goja.Value {
 		}
 	}
 
-	return c.vm.ToValue(string(decompressed))
+	// 默认返回 UTF-8 字符串，encoding 为 "buffer" 时返回 Buffer
+	encoding := buffer.Encoding(c.vm, call.Argument(1), "utf8")
+	if encoding == "buffer" {
+		return buffer.New(c.vm, bytes.Clone(decompressed))
+	}
+	return buffer.Encode(c.vm, decompressed, encoding)
 }
 
 // zlibCompress Zlib 压缩
@@ -122,7 +141,7 @@ func (c *CompressionModule) zlibCompress(call goja.FunctionCall) goja.Value {
 		panic(c.vm.NewTypeError("zlibCompress() missing data"))
 	}
 
-	data := call.Arguments[0].String()
+	data := buffer.ToBytes(c.vm, call.Arguments[0])
 
 	// 使用对象池获取缓冲区
 	buf := pool.GlobalManager.GetByteBuffer()
@@ -130,7 +149,7 @@ func (c *CompressionModule) zlibCompress(call goja.FunctionCall) goja.Value {
 
 	writer := zlib.NewWriter(buf)
 
-	_, err := writer.Write([]byte(data))
+	_, err := writer.Write(data)
 	if err != nil {
 		panic(c.vm.NewGoError(err))
 	}
@@ -140,9 +159,12 @@ func (c *CompressionModule) zlibCompress(call goja.FunctionCall) goja.Value {
 		panic(c.vm.NewGoError(err))
 	}
 
-	// 返回 base64 编码的压缩数据
-	encoded := base64.StdEncoding.EncodeToString(buf.Bytes())
-	return c.vm.ToValue(encoded)
+	// 默认返回 base64 编码的压缩数据，encoding 为 "buffer" 时返回 Buffer
+	encoding := buffer.Encoding(c.vm, call.Argument(1), "base64")
+	if encoding == "buffer" {
+		return buffer.New(c.vm, bytes.Clone(buf.Bytes()))
+	}
+	return buffer.Encode(c.vm, buf.Bytes(), encoding)
 }
 
 // zlibDecompress Zlib 解压
@@ -151,17 +173,19 @@ func (c *CompressionModule) zlibDecompress(call goja.FunctionCall) goja.Value {
 		panic(c.vm.NewTypeError("zlibDecompress() missing data"))
 	}
 
-	data := call.Arguments[0].String()
-
 	// 使用对象池获取字节切片
 	compressed := pool.GlobalManager.GetByteSlice()
 	defer pool.GlobalManager.PutByteSlice(compressed)
 
-	// 解码 base64
-	var err error
-	compressed, err = base64.StdEncoding.AppendDecode(compressed, []byte(data))
-	if err != nil {
-		panic(c.vm.NewGoError(err))
+	// 二进制数据直接解压，字符串按 base64 解码
+	if data, ok := buffer.Bytes(c.vm, call.Arguments[0]); ok {
+		compressed = append(compressed, data...)
+	} else {
+		var err error
+		compressed, err = base64.StdEncoding.AppendDecode(compressed, []byte(call.Arguments[0].String()))
+		if err != nil {
+			panic(c.vm.NewGoError(err))
+		}
 	}
 
 	reader, err := zlib.NewReader(bytes.NewReader(compressed))
@@ -189,5 +213,75 @@ func (c *CompressionModule) zlibDecompress(call goja.FunctionCall) goja.Value {
 		}
 	}
 
-	return c.vm.ToValue(string(decompressed))
+	// 默认返回 UTF-8 字符串，encoding 为 "buffer" 时返回 Buffer
+	encoding := buffer.Encoding(c.vm, call.Argument(1), "utf8")
+	if encoding == "buffer" {
+		return buffer.New(c.vm, bytes.Clone(decompressed))
+	}
+	return buffer.Encode(c.vm, decompressed, encoding)
+}
+
+// level 读取流选项中的压缩级别，默认为 DefaultCompression
+func (c *CompressionModule) level(options goja.Value) int {
+	if options == nil || goja.IsUndefined(options) || goja.IsNull(options) {
+		return gzip.DefaultCompression
+	}
+	if v := options.ToObject(c.vm).Get("level"); v != nil && !goja.IsUndefined(v) {
+		return int(v.ToInteger())
+	}
+	return gzip.DefaultCompression
+}
+
+// compressStream 创建压缩 Transform 流，newWriter 包装输出端
+func (c *CompressionModule) compressStream(options goja.Value, newWriter func(io.Writer, int) (io.WriteCloser, error)) goja.Value {
+	level := c.level(options)
+	return stream.NewTransform(c.vm, func(dst io.Writer, src io.Reader) error {
+		writer, err := newWriter(dst, level)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(writer, src); err != nil {
+			return err
+		}
+		return writer.Close()
+	}, options)
+}
+
+// decompressStream 创建解压 Transform 流，newReader 包装输入端
+func (c *CompressionModule) decompressStream(options goja.Value, newReader func(io.Reader) (io.ReadCloser, error)) goja.Value {
+	return stream.NewTransform(c.vm, func(dst io.Writer, src io.Reader) error {
+		reader, err := newReader(src)
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		_, err = io.Copy(dst, reader)
+		return err
+	}, options)
+}
+
+// createGzip 创建 Gzip 压缩流，选项 level 为压缩级别
+func (c *CompressionModule) createGzip(call goja.FunctionCall) goja.Value {
+	return c.compressStream(call.Argument(0), func(w io.Writer, level int) (io.WriteCloser, error) {
+		return gzip.NewWriterLevel(w, level)
+	})
+}
+
+// createGunzip 创建 Gzip 解压流
+func (c *CompressionModule) createGunzip(call goja.FunctionCall) goja.Value {
+	return c.decompressStream(call.Argument(0), func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	})
+}
+
+// createDeflate 创建 Zlib 压缩流，选项 level 为压缩级别
+func (c *CompressionModule) createDeflate(call goja.FunctionCall) goja.Value {
+	return c.compressStream(call.Argument(0), func(w io.Writer, level int) (io.WriteCloser, error) {
+		return zlib.NewWriterLevel(w, level)
+	})
+}
+
+// createInflate 创建 Zlib 解压流
+func (c *CompressionModule) createInflate(call goja.FunctionCall) goja.Value {
+	return c.decompressStream(call.Argument(0), zlib.NewReader)
 }
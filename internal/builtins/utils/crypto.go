@@ -13,6 +13,8 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/issueye/sw_runtime/internal/builtins/buffer"
+
 	"github.com/dop251/goja"
 )
 
@@ -60,9 +62,9 @@ func (c *CryptoModule) md5Hash(call goja.FunctionCall) goja.Value {
 		panic(c.vm.NewTypeError("md5() missing data"))
 	}
 
-	data := call.Arguments[0].String()
-	hash := md5.Sum([]byte(data))
-	return c.vm.ToValue(hex.EncodeToString(hash[:]))
+	data := buffer.ToBytes(c.vm, call.Arguments[0])
+	hash := md5.Sum(data)
+	return buffer.Encode(c.vm, hash[:], buffer.Encoding(c.vm, call.Argument(1), "hex"))
 }
 
 // sha1Hash SHA1 哈希
@@ -71,9 +73,9 @@ func (c *CryptoModule) sha1Hash(call goja.FunctionCall) goja.Value {
 		panic(c.vm.NewTypeError("sha1() missing data"))
 	}
 
-	data := call.Arguments[0].String()
-	hash := sha1.Sum([]byte(data))
-	return c.vm.ToValue(hex.EncodeToString(hash[:]))
+	data := buffer.ToBytes(c.vm, call.Arguments[0])
+	hash := sha1.Sum(data)
+	return buffer.Encode(c.vm, hash[:], buffer.Encoding(c.vm, call.Argument(1), "hex"))
 }
 
 // sha256Hash SHA256 哈希
@@ -82,9 +84,9 @@ func (c *CryptoModule) sha256Hash(call goja.FunctionCall) goja.Value {
 		panic(c.vm.NewTypeError("sha256() missing data"))
 	}
 
-	data := call.Arguments[0].String()
-	hash := sha256.Sum256([]byte(data))
-	return c.vm.ToValue(hex.EncodeToString(hash[:]))
+	data := buffer.ToBytes(c.vm, call.Arguments[0])
+	hash := sha256.Sum256(data)
+	return buffer.Encode(c.vm, hash[:], buffer.Encoding(c.vm, call.Argument(1), "hex"))
 }
 
 // sha512Hash SHA512 哈希
@@ -93,9 +95,9 @@ func (c *CryptoModule) sha512Hash(call goja.FunctionCall) goja.Value {
 		panic(c.vm.NewTypeError("sha512() missing data"))
 	}
 
-	data := call.Arguments[0].String()
-	hash := sha512.Sum512([]byte(data))
-	return c.vm.ToValue(hex.EncodeToString(hash[:]))
+	data := buffer.ToBytes(c.vm, call.Arguments[0])
+	hash := sha512.Sum512(data)
+	return buffer.Encode(c.vm, hash[:], buffer.Encoding(c.vm, call.Argument(1), "hex"))
 }
 
 // base64Encode Base64 编码
@@ -104,8 +106,8 @@ func (c *CryptoModule) base64Encode(call goja.FunctionCall) goja.Value {
 		panic(c.vm.NewTypeError("base64Encode() missing data"))
 	}
 
-	data := call.Arguments[0].String()
-	encoded := base64.StdEncoding.EncodeToString([]byte(data))
+	data := buffer.ToBytes(c.vm, call.Arguments[0])
+	encoded := base64.StdEncoding.EncodeToString(data)
 	return c.vm.ToValue(encoded)
 }
 
@@ -120,7 +122,7 @@ func (c *CryptoModule) base64Decode(call goja.FunctionCall) goja.Value {
 	if err != nil {
 		panic(c.vm.NewGoError(err))
 	}
-	return c.vm.ToValue(string(decoded))
+	return buffer.Encode(c.vm, decoded, buffer.Encoding(c.vm, call.Argument(1), "utf8"))
 }
 
 // hexEncode Hex 编码
@@ -129,8 +131,8 @@ func (c *CryptoModule) hexEncode(call goja.FunctionCall) goja.Value {
 		panic(c.vm.NewTypeError("hexEncode() missing data"))
 	}
 
-	data := call.Arguments[0].String()
-	encoded := hex.EncodeToString([]byte(data))
+	data := buffer.ToBytes(c.vm, call.Arguments[0])
+	encoded := hex.EncodeToString(data)
 	return c.vm.ToValue(encoded)
 }
 
@@ -145,7 +147,7 @@ func (c *CryptoModule) hexDecode(call goja.FunctionCall) goja.Value {
 	if err != nil {
 		panic(c.vm.NewGoError(err))
 	}
-	return c.vm.ToValue(string(decoded))
+	return buffer.Encode(c.vm, decoded, buffer.Encoding(c.vm, call.Argument(1), "utf8"))
 }
 
 // aesEncrypt AES 加密
@@ -154,12 +156,12 @@ func (c *CryptoModule) aesEncrypt(call goja.FunctionCall) goja.Value {
 		panic(c.vm.NewTypeError("aesEncrypt() missing data or key"))
 	}
 
-	data := call.Arguments[0].String()
-	key := call.Arguments[1].String()
+	data := buffer.ToBytes(c.vm, call.Arguments[0])
+	key := buffer.ToBytes(c.vm, call.Arguments[1])
 
 	// 确保密钥长度为 32 字节 (AES-256)
 	keyBytes := make([]byte, 32)
-	copy(keyBytes, []byte(key))
+	copy(keyBytes, key)
 
 	block, err := aes.NewCipher(keyBytes)
 	if err != nil {
@@ -179,9 +181,8 @@ func (c *CryptoModule) aesEncrypt(call goja.FunctionCall) goja.Value {
 	}
 
 	// 加密
-	ciphertext := gcm.Seal(nonce, nonce, []byte(data), nil)
-	encoded := base64.StdEncoding.EncodeToString(ciphertext)
-	return c.vm.ToValue(encoded)
+	ciphertext := gcm.Seal(nonce, nonce, data, nil)
+	return buffer.Encode(c.vm, ciphertext, buffer.Encoding(c.vm, call.Argument(2), "base64"))
 }
 
 // aesDecrypt AES 解密
@@ -190,18 +191,21 @@ func (c *CryptoModule) aesDecrypt(call goja.FunctionCall) goja.Value {
 		panic(c.vm.NewTypeError("aesDecrypt() missing data or key"))
 	}
 
-	data := call.Arguments[0].String()
-	key := call.Arguments[1].String()
+	key := buffer.ToBytes(c.vm, call.Arguments[1])
 
-	// 解码 base64
-	ciphertext, err := base64.StdEncoding.DecodeString(data)
-	if err != nil {
-		panic(c.vm.NewGoError(err))
+	// 二进制数据直接作为密文，字符串按 base64 解码
+	ciphertext, ok := buffer.Bytes(c.vm, call.Arguments[0])
+	if !ok {
+		var err error
+		ciphertext, err = base64.StdEncoding.DecodeString(call.Arguments[0].String())
+		if err != nil {
+			panic(c.vm.NewGoError(err))
+		}
 	}
 
 	// 确保密钥长度为 32 字节 (AES-256)
 	keyBytes := make([]byte, 32)
-	copy(keyBytes, []byte(key))
+	copy(keyBytes, key)
 
 	block, err := aes.NewCipher(keyBytes)
 	if err != nil {
@@ -224,7 +228,7 @@ func (c *CryptoModule) aesDecrypt(call goja.FunctionCall) goja.Value {
 		panic(c.vm.NewGoError(err))
 	}
 
-	return c.vm.ToValue(string(plaintext))
+	return buffer.Encode(c.vm, plaintext, buffer.Encoding(c.vm, call.Argument(2), "utf8"))
 }
 
 // randomBytes 生成随机字节
@@ -239,5 +243,5 @@ func (c *CryptoModule) randomBytes(call goja.FunctionCall) goja.Value {
 		panic(c.vm.NewGoError(err))
 	}
 
-	return c.vm.ToValue(hex.EncodeToString(bytes))
+	return buffer.Encode(c.vm, bytes, buffer.Encoding(c.vm, call.Argument(1), "hex"))
 }
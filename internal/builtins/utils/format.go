@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+// Format 按 printf 风格格式化参数，规则参照 Node.js 的 util.format：
+// 第一个参数为字符串时解析 %s %d %i %f %j %o %O %c %% 占位符，
+// 多余的参数以空格连接追加在后面，字符串原样输出，其他值使用 Inspect 格式化。
+func Format(vm *goja.Runtime, args []goja.Value, opts InspectOptions) string {
+	if len(args) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	rest := args
+	if goja.IsString(args[0]) {
+		first := args[0].String()
+		rest = args[1:]
+		for i := 0; i < len(first); i++ {
+			if first[i] != '%' || i+1 >= len(first) {
+				b.WriteByte(first[i])
+				continue
+			}
+			verb := first[i+1]
+			if verb == '%' {
+				b.WriteByte('%')
+				i++
+				continue
+			}
+			if !strings.ContainsRune("sdifjoOc", rune(verb)) || len(rest) == 0 {
+				b.WriteByte('%')
+				continue
+			}
+			b.WriteString(formatVerb(vm, verb, rest[0], opts))
+			rest = rest[1:]
+			i++
+		}
+	} else {
+		b.WriteString(formatArg(vm, args[0], opts))
+		rest = args[1:]
+	}
+
+	for _, arg := range rest {
+		b.WriteByte(' ')
+		b.WriteString(formatArg(vm, arg, opts))
+	}
+	return b.String()
+}
+
+// formatArg 格式化占位符以外的参数：字符串原样输出，其他值使用 Inspect
+func formatArg(vm *goja.Runtime, v goja.Value, opts InspectOptions) string {
+	if goja.IsString(v) {
+		return v.String()
+	}
+	return Inspect(vm, v, opts)
+}
+
+// formatVerb 格式化单个占位符
+func formatVerb(vm *goja.Runtime, verb byte, v goja.Value, opts InspectOptions) string {
+	switch verb {
+	case 's':
+		if _, ok := v.(*goja.Object); ok && !hasCustomToString(vm, v) {
+			opts.Depth = 0
+			opts.Colors = false
+			return Inspect(vm, v, opts)
+		}
+		if n, ok := v.Export().(*big.Int); ok {
+			return n.String() + "n"
+		}
+		if sym, ok := v.(*goja.Symbol); ok {
+			return symbolString(sym)
+		}
+		return v.String()
+	case 'd', 'i', 'f':
+		if n, ok := v.Export().(*big.Int); ok && verb != 'f' {
+			return n.String() + "n"
+		}
+		if _, ok := v.(*goja.Symbol); ok {
+			return "NaN"
+		}
+		switch verb {
+		case 'i':
+			return callGlobal(vm, "parseInt", v)
+		case 'f':
+			return callGlobal(vm, "parseFloat", v)
+		}
+		return vm.ToValue(v.ToFloat()).String()
+	case 'j':
+		return stringifyJSON(vm, v)
+	case 'o':
+		opts.Depth = 4
+		return Inspect(vm, v, opts)
+	case 'O':
+		return Inspect(vm, v, opts)
+	}
+	// %c 的 CSS 样式在终端中忽略
+	return ""
+}
+
+// hasCustomToString 检查对象是否定义了自己的 toString（Error 除外，按对象格式化）
+func hasCustomToString(vm *goja.Runtime, v goja.Value) bool {
+	obj := v.(*goja.Object)
+	if _, ok := goja.AssertFunction(obj); ok {
+		return true
+	}
+	toString := obj.Get("toString")
+	objectToString := vm.Get("Object").ToObject(vm).Get("prototype").ToObject(vm).Get("toString")
+	errorToString := vm.Get("Error").ToObject(vm).Get("prototype").ToObject(vm).Get("toString")
+	arrayToString := vm.Get("Array").ToObject(vm).Get("prototype").ToObject(vm).Get("toString")
+	if toString == nil || toString.SameAs(objectToString) || toString.SameAs(errorToString) || toString.SameAs(arrayToString) {
+		return false
+	}
+	_, ok := goja.AssertFunction(toString)
+	return ok
+}
+
+// callGlobal 调用全局函数（如 parseInt）并返回字符串结果
+func callGlobal(vm *goja.Runtime, name string, v goja.Value) string {
+	fn, ok := goja.AssertFunction(vm.Get(name))
+	if !ok {
+		return v.String()
+	}
+	result, err := fn(goja.Undefined(), v)
+	if err != nil {
+		return "NaN"
+	}
+	return result.String()
+}
+
+// stringifyJSON 使用 JSON.stringify 序列化，循环引用时返回 [Circular]
+func stringifyJSON(vm *goja.Runtime, v goja.Value) string {
+	stringify, ok := goja.AssertFunction(vm.Get("JSON").ToObject(vm).Get("stringify"))
+	if !ok {
+		return v.String()
+	}
+	result, err := stringify(goja.Undefined(), v)
+	if err != nil {
+		if strings.Contains(err.Error(), "circular") || strings.Contains(err.Error(), "Circular") {
+			return "[Circular]"
+		}
+		return err.Error()
+	}
+	if goja.IsUndefined(result) {
+		return "undefined"
+	}
+	return result.String()
+}
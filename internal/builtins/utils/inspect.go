@@ -0,0 +1,572 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+// InspectOptions util.inspect 格式化选项
+type InspectOptions struct {
+	Depth       int  // 对象嵌套展开的层数，负数表示不限制
+	Colors      bool // 是否输出 ANSI 颜色
+	BreakLength int  // 单行最大长度，超过后分行显示
+}
+
+// DefaultInspectOptions 默认格式化选项，与 Node.js 保持一致
+var DefaultInspectOptions = InspectOptions{Depth: 2, BreakLength: 80}
+
+const (
+	maxArrayItems  = 100 // 数组、Map、Set 最多显示的元素个数
+	maxBufferBytes = 50  // Buffer 最多显示的字节数
+)
+
+// ANSI 颜色
+const (
+	styleNumber    = "33"
+	styleString    = "32"
+	styleUndefined = "90"
+	styleNull      = "1"
+	styleSpecial   = "36"
+	styleDate      = "35"
+	styleRegExp    = "31"
+)
+
+var (
+	identifierPattern = regexp.MustCompile(`^[A-Za-z_$][\w$]*$`)
+	ansiPattern       = regexp.MustCompile("\x1b\\[[0-9;]*m")
+)
+
+var typedArrayTags = map[string]bool{
+	"Int8Array": true, "Uint8Array": true, "Uint8ClampedArray": true,
+	"Int16Array": true, "Uint16Array": true, "Int32Array": true, "Uint32Array": true,
+	"Float32Array": true, "Float64Array": true, "BigInt64Array": true, "BigUint64Array": true,
+}
+
+// Inspect 将 JS 值格式化为便于阅读的字符串，格式参照 Node.js 的 util.inspect
+func Inspect(vm *goja.Runtime, value goja.Value, opts InspectOptions) string {
+	in := &inspector{vm: vm, opts: opts}
+	if in.opts.BreakLength <= 0 {
+		in.opts.BreakLength = DefaultInspectOptions.BreakLength
+	}
+
+	object := vm.Get("Object").ToObject(vm)
+	in.toString, _ = goja.AssertFunction(object.Get("prototype").ToObject(vm).Get("toString"))
+	in.getOwnPropertyDescriptor, _ = goja.AssertFunction(object.Get("getOwnPropertyDescriptor"))
+	in.arrayFrom, _ = goja.AssertFunction(vm.Get("Array").ToObject(vm).Get("from"))
+
+	return in.format(value, 0)
+}
+
+// inspector 单次格式化的状态
+type inspector struct {
+	vm   *goja.Runtime
+	opts InspectOptions
+	seen []*goja.Object
+
+	toString                 goja.Callable
+	getOwnPropertyDescriptor goja.Callable
+	arrayFrom                goja.Callable
+}
+
+// stylize 按需添加颜色
+func (in *inspector) stylize(s, style string) string {
+	if !in.opts.Colors {
+		return s
+	}
+	return "\x1b[" + style + "m" + s + "\x1b[0m"
+}
+
+// format 格式化任意值
+func (in *inspector) format(v goja.Value, depth int) string {
+	if v == nil || goja.IsUndefined(v) {
+		return in.stylize("undefined", styleUndefined)
+	}
+	if goja.IsNull(v) {
+		return in.stylize("null", styleNull)
+	}
+	if sym, ok := v.(*goja.Symbol); ok {
+		return in.stylize(symbolString(sym), styleString)
+	}
+	obj, ok := v.(*goja.Object)
+	if !ok {
+		return in.formatPrimitive(v)
+	}
+
+	for _, s := range in.seen {
+		if s == obj {
+			return in.stylize("[Circular]", styleSpecial)
+		}
+	}
+	in.seen = append(in.seen, obj)
+	defer func() { in.seen = in.seen[:len(in.seen)-1] }()
+
+	return in.formatObject(obj, depth)
+}
+
+// formatPrimitive 格式化字符串、数字、布尔值与 BigInt
+func (in *inspector) formatPrimitive(v goja.Value) string {
+	switch x := v.Export().(type) {
+	case string:
+		return in.stylize(quoteString(x), styleString)
+	case bool:
+		return in.stylize(strconv.FormatBool(x), styleNumber)
+	case float64:
+		if x == 0 && math.Signbit(x) {
+			return in.stylize("-0", styleNumber)
+		}
+		return in.stylize(v.String(), styleNumber)
+	case *big.Int:
+		return in.stylize(x.String()+"n", styleNumber)
+	}
+	return in.stylize(v.String(), styleNumber)
+}
+
+// tag 获取 Object.prototype.toString 返回的类型标签，如 Map、Uint8Array
+func (in *inspector) tag(obj *goja.Object) string {
+	if in.toString == nil {
+		return obj.ClassName()
+	}
+	s, err := in.toString(obj)
+	if err != nil {
+		return obj.ClassName()
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(s.String(), "[object "), "]")
+}
+
+// constructorName 沿原型链查找构造函数名称，空原型返回 ok=false
+func (in *inspector) constructorName(obj *goja.Object) (string, bool) {
+	for proto := obj.Prototype(); proto != nil; proto = proto.Prototype() {
+		if ctor, ok := proto.Get("constructor").(*goja.Object); ok {
+			if name := ctor.Get("name"); name != nil && name.String() != "" {
+				return name.String(), true
+			}
+		}
+	}
+	return "", obj.Prototype() != nil
+}
+
+// formatObject 格式化对象，按类型分派
+func (in *inspector) formatObject(obj *goja.Object, depth int) string {
+	tag := in.tag(obj)
+	ctor, hasProto := in.constructorName(obj)
+
+	if _, ok := goja.AssertFunction(obj); ok {
+		return in.formatFunction(obj, tag, depth)
+	}
+
+	switch tag {
+	case "Error":
+		return in.formatError(obj)
+	case "Date":
+		return in.formatDate(obj)
+	case "RegExp":
+		return in.stylize(obj.String(), styleRegExp)
+	case "Promise":
+		return in.formatPromise(obj, depth)
+	case "Map", "Set":
+		return in.formatCollection(obj, tag, depth)
+	case "WeakMap", "WeakSet":
+		return tag + " { " + in.stylize("<items unknown>", styleSpecial) + " }"
+	case "ArrayBuffer", "SharedArrayBuffer":
+		return in.formatArrayBuffer(obj, tag)
+	}
+
+	if ctor == "Buffer" {
+		return in.formatBuffer(obj)
+	}
+	if typedArrayTags[tag] {
+		return in.formatArrayLike(obj, fmt.Sprintf("%s(%d) ", tag, obj.Get("length").ToInteger()), tag, depth)
+	}
+	if obj.ClassName() == "Array" {
+		prefix := ""
+		if ctor != "Array" {
+			prefix = fmt.Sprintf("%s(%d) ", ctor, obj.Get("length").ToInteger())
+		}
+		return in.formatArrayLike(obj, prefix, "Array", depth)
+	}
+
+	prefix := ""
+	switch {
+	case !hasProto:
+		prefix = "[Object: null prototype] "
+	case ctor != "Object":
+		prefix = ctor + " "
+	}
+	if tag != "Object" && tag != ctor {
+		prefix += "[" + tag + "] "
+	}
+
+	if in.tooDeep(depth) {
+		name := strings.TrimSpace(prefix)
+		if name == "" {
+			name = "Object"
+		}
+		return in.stylize("["+name+"]", styleSpecial)
+	}
+
+	entries := in.formatProperties(obj, nil, depth)
+	if len(entries) == 0 {
+		return prefix + "{}"
+	}
+	return in.reduce(prefix, "{", "}", entries)
+}
+
+// tooDeep 检查是否超过展开层数
+func (in *inspector) tooDeep(depth int) bool {
+	return in.opts.Depth >= 0 && depth > in.opts.Depth
+}
+
+// formatProperties 格式化对象自身的可枚举属性，skip 中的属性不输出
+func (in *inspector) formatProperties(obj *goja.Object, skip func(string) bool, depth int) []string {
+	var entries []string
+	for _, key := range obj.Keys() {
+		if skip != nil && skip(key) {
+			continue
+		}
+		name := key
+		if !identifierPattern.MatchString(key) {
+			name = in.stylize(quoteString(key), styleString)
+		}
+		entries = append(entries, name+": "+in.formatProperty(obj, in.vm.ToValue(key), depth))
+	}
+	for _, sym := range obj.Symbols() {
+		entries = append(entries, "["+in.stylize(symbolString(sym), styleString)+"]: "+in.formatProperty(obj, sym, depth))
+	}
+	return entries
+}
+
+// formatProperty 格式化单个属性，访问器属性不会被调用
+func (in *inspector) formatProperty(obj *goja.Object, key goja.Value, depth int) string {
+	if in.getOwnPropertyDescriptor != nil {
+		if desc, err := in.getOwnPropertyDescriptor(goja.Undefined(), obj, key); err == nil {
+			if d, ok := desc.(*goja.Object); ok {
+				getter := d.Get("get")
+				setter := d.Get("set")
+				hasGetter := getter != nil && !goja.IsUndefined(getter)
+				hasSetter := setter != nil && !goja.IsUndefined(setter)
+				switch {
+				case hasGetter && hasSetter:
+					return in.stylize("[Getter/Setter]", styleSpecial)
+				case hasGetter:
+					return in.stylize("[Getter]", styleSpecial)
+				case hasSetter:
+					return in.stylize("[Setter]", styleSpecial)
+				}
+				return in.format(d.Get("value"), depth+1)
+			}
+		}
+	}
+	var value goja.Value
+	if sym, ok := key.(*goja.Symbol); ok {
+		value = obj.GetSymbol(sym)
+	} else {
+		value = obj.Get(key.String())
+	}
+	return in.format(value, depth+1)
+}
+
+// formatFunction 格式化函数与类
+func (in *inspector) formatFunction(obj *goja.Object, tag string, depth int) string {
+	name := ""
+	if n := obj.Get("name"); n != nil && !goja.IsUndefined(n) {
+		name = n.String()
+	}
+
+	var base string
+	if strings.HasPrefix(obj.String(), "class") {
+		base = "[class " + name + "]"
+		if name == "" {
+			base = "[class (anonymous)]"
+		}
+	} else {
+		kind := tag
+		if kind != "AsyncFunction" && kind != "GeneratorFunction" && kind != "AsyncGeneratorFunction" {
+			kind = "Function"
+		}
+		if name == "" {
+			base = "[" + kind + " (anonymous)]"
+		} else {
+			base = "[" + kind + ": " + name + "]"
+		}
+	}
+	base = in.stylize(base, styleSpecial)
+
+	if in.tooDeep(depth) {
+		return base
+	}
+	entries := in.formatProperties(obj, nil, depth)
+	if len(entries) == 0 {
+		return base
+	}
+	return in.reduce(base+" ", "{", "}", entries)
+}
+
+// formatError 格式化错误对象，优先使用调用栈
+func (in *inspector) formatError(obj *goja.Object) string {
+	if stack := obj.Get("stack"); stack != nil && !goja.IsUndefined(stack) && stack.String() != "" {
+		return strings.TrimRight(stack.String(), "\n")
+	}
+	return obj.String()
+}
+
+// formatDate 格式化日期为 ISO 字符串
+func (in *inspector) formatDate(obj *goja.Object) string {
+	if toISO, ok := goja.AssertFunction(obj.Get("toISOString")); ok {
+		if s, err := toISO(obj); err == nil {
+			return in.stylize(s.String(), styleDate)
+		}
+	}
+	return in.stylize("Invalid Date", styleDate)
+}
+
+// formatPromise 格式化 Promise 及其状态
+func (in *inspector) formatPromise(obj *goja.Object, depth int) string {
+	p, ok := obj.Export().(*goja.Promise)
+	if !ok {
+		return "Promise {}"
+	}
+	switch p.State() {
+	case goja.PromiseStatePending:
+		return "Promise { " + in.stylize("<pending>", styleSpecial) + " }"
+	case goja.PromiseStateRejected:
+		return in.reduce("Promise ", "{", "}", []string{in.stylize("<rejected>", styleSpecial) + " " + in.format(p.Result(), depth+1)})
+	}
+	return in.reduce("Promise ", "{", "}", []string{in.format(p.Result(), depth+1)})
+}
+
+// formatCollection 格式化 Map 与 Set
+func (in *inspector) formatCollection(obj *goja.Object, tag string, depth int) string {
+	size := obj.Get("size").ToInteger()
+	prefix := fmt.Sprintf("%s(%d) ", tag, size)
+	if size == 0 {
+		return prefix + "{}"
+	}
+	if in.tooDeep(depth) {
+		return in.stylize("["+tag+"]", styleSpecial)
+	}
+	if in.arrayFrom == nil {
+		return prefix + "{}"
+	}
+	items, err := in.arrayFrom(in.vm.Get("Array"), obj)
+	if err != nil {
+		return prefix + "{}"
+	}
+
+	list := items.ToObject(in.vm)
+	var entries []string
+	for i := int64(0); i < size; i++ {
+		if i >= maxArrayItems {
+			entries = append(entries, fmt.Sprintf("... %d more items", size-i))
+			break
+		}
+		item := list.Get(strconv.FormatInt(i, 10))
+		if tag == "Map" {
+			pair := item.ToObject(in.vm)
+			entries = append(entries, in.format(pair.Get("0"), depth+1)+" => "+in.format(pair.Get("1"), depth+1))
+		} else {
+			entries = append(entries, in.format(item, depth+1))
+		}
+	}
+	return in.reduce(prefix, "{", "}", entries)
+}
+
+// formatArrayLike 格式化数组与类型化数组，附带非索引属性
+func (in *inspector) formatArrayLike(obj *goja.Object, prefix, name string, depth int) string {
+	length := obj.Get("length").ToInteger()
+	if in.tooDeep(depth) {
+		return in.stylize("["+name+"]", styleSpecial)
+	}
+
+	var entries []string
+	for i := int64(0); i < length; i++ {
+		if i >= maxArrayItems {
+			entries = append(entries, fmt.Sprintf("... %d more items", length-i))
+			break
+		}
+		entries = append(entries, in.format(obj.Get(strconv.FormatInt(i, 10)), depth+1))
+	}
+	entries = append(entries, in.formatProperties(obj, func(key string) bool {
+		n, err := strconv.ParseInt(key, 10, 64)
+		return err == nil && n >= 0 && n < length
+	}, depth)...)
+
+	if len(entries) == 0 {
+		return prefix + "[]"
+	}
+	return in.reduce(prefix, "[", "]", in.groupEntries(entries))
+}
+
+// formatBuffer 以十六进制格式化 Buffer
+func (in *inspector) formatBuffer(obj *goja.Object) string {
+	length := obj.Get("length").ToInteger()
+	var b strings.Builder
+	b.WriteString("<Buffer")
+	for i := int64(0); i < length && i < maxBufferBytes; i++ {
+		fmt.Fprintf(&b, " %02x", obj.Get(strconv.FormatInt(i, 10)).ToInteger()&0xff)
+	}
+	if length > maxBufferBytes {
+		fmt.Fprintf(&b, " ... %d more bytes", length-maxBufferBytes)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// formatArrayBuffer 格式化 ArrayBuffer 的字节内容
+func (in *inspector) formatArrayBuffer(obj *goja.Object, tag string) string {
+	var data []byte
+	switch buf := obj.Export().(type) {
+	case goja.ArrayBuffer:
+		data = buf.Bytes()
+	case []byte:
+		data = buf
+	}
+
+	var b strings.Builder
+	b.WriteString("<")
+	for i, c := range data {
+		if i >= maxBufferBytes {
+			fmt.Fprintf(&b, " ... %d more bytes", len(data)-maxBufferBytes)
+			break
+		}
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		fmt.Fprintf(&b, "%02x", c)
+	}
+	b.WriteString(">")
+
+	length := obj.Get("byteLength")
+	return in.reduce(tag+" ", "{", "}", []string{
+		"[Uint8Contents]: " + b.String(),
+		"byteLength: " + in.format(length, 1),
+	})
+}
+
+// reduce 拼接条目：放得下时输出单行，否则每个条目一行并缩进
+func (in *inspector) reduce(prefix, open, close string, entries []string) string {
+	total := len(prefix) + len(open) + len(close) + 2
+	multiline := false
+	for _, e := range entries {
+		total += VisibleLen(e) + 2
+		if strings.Contains(e, "\n") {
+			multiline = true
+		}
+	}
+	if !multiline && total <= in.opts.BreakLength {
+		return prefix + open + " " + strings.Join(entries, ", ") + " " + close
+	}
+
+	var b strings.Builder
+	b.WriteString(prefix + open + "\n")
+	for i, e := range entries {
+		b.WriteString("  " + strings.ReplaceAll(e, "\n", "\n  "))
+		if i < len(entries)-1 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(close)
+	return b.String()
+}
+
+// groupEntries 将较多的短元素按列排成多行，避免长数组每个元素占一行
+func (in *inspector) groupEntries(entries []string) []string {
+	if len(entries) <= 6 {
+		return entries
+	}
+	total, maxLen := 0, 0
+	for _, e := range entries {
+		if strings.Contains(e, "\n") {
+			return entries
+		}
+		l := VisibleLen(e)
+		total += l + 2
+		if l > maxLen {
+			maxLen = l
+		}
+	}
+	if total <= in.opts.BreakLength {
+		return entries
+	}
+
+	// 列数参照 Node.js：兼顾输出接近方形与单行宽度
+	width := maxLen + 2
+	columns := int(math.Round(math.Sqrt(2.5*float64(width)*float64(len(entries))) / float64(width)))
+	columns = min(columns, in.opts.BreakLength/width, 15)
+	if columns <= 1 {
+		return entries
+	}
+
+	var rows []string
+	for i := 0; i < len(entries); i += columns {
+		end := min(i+columns, len(entries))
+		var row strings.Builder
+		for j := i; j < end; j++ {
+			e := entries[j]
+			if j < end-1 {
+				e += ","
+				row.WriteString(e + strings.Repeat(" ", width-VisibleLen(e)))
+			} else {
+				row.WriteString(e)
+			}
+		}
+		rows = append(rows, row.String())
+	}
+	return rows
+}
+
+// symbolString 获取 Symbol 的描述形式，如 Symbol(foo)
+func symbolString(sym *goja.Symbol) string {
+	return "Symbol(" + sym.String() + ")"
+}
+
+// VisibleLen 计算去掉颜色控制符后的显示长度
+func VisibleLen(s string) int {
+	return len([]rune(ansiPattern.ReplaceAllString(s, "")))
+}
+
+// quoteString 使用 JS 风格的引号包裹字符串：优先单引号，包含单引号时改用双引号或反引号
+func quoteString(s string) string {
+	quote := byte('\'')
+	if strings.ContainsRune(s, '\'') {
+		if !strings.ContainsRune(s, '"') {
+			quote = '"'
+		} else if !strings.ContainsRune(s, '`') && !strings.Contains(s, "${") {
+			quote = '`'
+		}
+	}
+
+	var b strings.Builder
+	b.WriteByte(quote)
+	for _, r := range s {
+		switch {
+		case r == rune(quote) || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\b':
+			b.WriteString(`\b`)
+		case r == '\f':
+			b.WriteString(`\f`)
+		case r == '\v':
+			b.WriteString(`\v`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\x%02X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte(quote)
+	return b.String()
+}
@@ -1,7 +1,7 @@
 package utils
 
 import (
-	"sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/builtins/types"
 
 	"github.com/dop251/goja"
 )
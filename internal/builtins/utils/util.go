@@ -1,7 +1,7 @@
 package utils
 
 import (
-	"fmt"
+	"math"
 	"reflect"
 	"strings"
 
@@ -36,36 +36,48 @@ func (u *UtilModule) GetModule() *goja.Object {
 	return obj
 }
 
-// format 格式化字符串
+// format 按 printf 风格格式化字符串，支持 %s %d %i %f %j %o %O %c %%
 func (u *UtilModule) format(call goja.FunctionCall) goja.Value {
-	if len(call.Arguments) == 0 {
-		return u.vm.ToValue("")
-	}
-
-	f := call.Arguments[0].String()
-	if len(call.Arguments) == 1 {
-		return u.vm.ToValue(f)
-	}
+	return u.vm.ToValue(Format(u.vm, call.Arguments, DefaultInspectOptions))
+}
 
-	args := make([]interface{}, len(call.Arguments)-1)
-	for i := 0; i < len(call.Arguments)-1; i++ {
-		args[i] = call.Arguments[i+1].Export()
+// inspect 对象检查，支持 util.inspect(value, { depth, colors, breakLength })
+// 以及旧式的 util.inspect(value, showHidden, depth, colors)
+func (u *UtilModule) inspect(call goja.FunctionCall) goja.Value {
+	opts := DefaultInspectOptions
+
+	if arg := call.Argument(1); !goja.IsUndefined(arg) && !goja.IsNull(arg) {
+		if optObj, ok := arg.(*goja.Object); ok {
+			if v := optObj.Get("depth"); v != nil && !goja.IsUndefined(v) {
+				opts.Depth = inspectDepth(v)
+			}
+			if v := optObj.Get("colors"); v != nil && !goja.IsUndefined(v) {
+				opts.Colors = v.ToBoolean()
+			}
+			if v := optObj.Get("breakLength"); v != nil && !goja.IsUndefined(v) {
+				opts.BreakLength = int(v.ToInteger())
+			}
+		} else {
+			if v := call.Argument(2); !goja.IsUndefined(v) {
+				opts.Depth = inspectDepth(v)
+			}
+			opts.Colors = call.Argument(3).ToBoolean()
+		}
 	}
 
-	// 简单的 printf 风格实现
-	result := fmt.Sprintf(strings.ReplaceAll(f, "%j", "%v"), args...)
-	return u.vm.ToValue(result)
+	return u.vm.ToValue(Inspect(u.vm, call.Argument(0), opts))
 }
 
-// inspect 对象检查
-func (u *UtilModule) inspect(call goja.FunctionCall) goja.Value {
-	if len(call.Arguments) == 0 {
-		return goja.Undefined()
+// inspectDepth 解析 depth 选项，null 与 Infinity 表示不限制
+func inspectDepth(v goja.Value) int {
+	if goja.IsNull(v) {
+		return -1
 	}
-
-	arg := call.Arguments[0]
-	// 简单实现：使用 Export 并格式化
-	return u.vm.ToValue(fmt.Sprintf("%#v", arg.Export()))
+	f := v.ToFloat()
+	if math.IsInf(f, 1) || f > math.MaxInt32 {
+		return -1
+	}
+	return int(f)
 }
 
 // isDeepStrictEqual 深度比较
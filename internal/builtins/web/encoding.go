@@ -0,0 +1,265 @@
+package web
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/issueye/sw_runtime/internal/builtins/buffer"
+
+	"github.com/dop251/goja"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/transform"
+)
+
+// decoderKey 在 TextDecoder 实例上保存解码状态的隐藏键
+var decoderKey = goja.NewSymbol("sw.TextDecoder")
+
+// textDecoder TextDecoder 的解码状态
+type textDecoder struct {
+	name      string
+	fatal     bool
+	ignoreBOM bool
+	dec       *encoding.Decoder
+	pending   []byte // 流式解码时末尾不完整的字节序列
+	started   bool   // 已输出过内容，之后不再去除 BOM
+}
+
+// installEncoding 安装 TextEncoder、TextDecoder、atob 和 btoa
+func installEncoding(vm *goja.Runtime, global *goja.Object) {
+	global.Set("TextEncoder", newTextEncoder(vm))
+	global.Set("TextDecoder", newTextDecoder(vm))
+
+	global.Set("btoa", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) == 0 {
+			panic(vm.NewTypeError("The \"data\" argument must be specified"))
+		}
+		s := call.Arguments[0].String()
+		data := make([]byte, 0, len(s))
+		for _, r := range s {
+			if r > 0xff {
+				panic(newError(vm, "InvalidCharacterError", "Invalid character"))
+			}
+			data = append(data, byte(r))
+		}
+		return vm.ToValue(base64.StdEncoding.EncodeToString(data))
+	})
+
+	global.Set("atob", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) == 0 {
+			panic(vm.NewTypeError("The \"data\" argument must be specified"))
+		}
+		// 忽略 ASCII 空白，补齐符可以省略
+		s := strings.Map(func(r rune) rune {
+			if r == ' ' || r == '\t' || r == '\n' || r == '\f' || r == '\r' {
+				return -1
+			}
+			return r
+		}, call.Arguments[0].String())
+		if len(s)%4 == 0 {
+			s = strings.TrimSuffix(strings.TrimSuffix(s, "="), "=")
+		}
+		data, err := base64.RawStdEncoding.DecodeString(s)
+		if err != nil {
+			panic(newError(vm, "InvalidCharacterError", "The string to be decoded is not correctly encoded."))
+		}
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return vm.ToValue(string(runes))
+	})
+}
+
+// newTextEncoder 创建 TextEncoder 构造函数，只支持 UTF-8
+func newTextEncoder(vm *goja.Runtime) *goja.Object {
+	ctor := vm.ToValue(func(call goja.ConstructorCall) *goja.Object {
+		return nil
+	}).(*goja.Object)
+	proto := ctor.Get("prototype").ToObject(vm)
+
+	proto.DefineAccessorProperty("encoding", vm.ToValue(func(goja.FunctionCall) goja.Value {
+		return vm.ToValue("utf-8")
+	}), nil, goja.FLAG_TRUE, goja.FLAG_TRUE)
+
+	// encode 将字符串编码为 UTF-8 字节的 Uint8Array
+	proto.Set("encode", func(call goja.FunctionCall) goja.Value {
+		s := ""
+		if input := call.Argument(0); !goja.IsUndefined(input) {
+			s = input.String()
+		}
+		return newUint8Array(vm, []byte(s))
+	})
+
+	// encodeInto 将字符串编码到已有的 Uint8Array，返回读取的 UTF-16 码元数和写入的字节数
+	proto.Set("encodeInto", func(call goja.FunctionCall) goja.Value {
+		dest, ok := call.Argument(1).Export().([]byte)
+		if !ok {
+			panic(vm.NewTypeError("The \"dest\" argument must be an instance of Uint8Array"))
+		}
+		read, written := 0, 0
+		for _, r := range call.Argument(0).String() {
+			n := utf8.RuneLen(r)
+			if written+n > len(dest) {
+				break
+			}
+			utf8.EncodeRune(dest[written:], r)
+			written += n
+			read++
+			if r >= 0x10000 {
+				read++
+			}
+		}
+		result := vm.NewObject()
+		result.Set("read", read)
+		result.Set("written", written)
+		return result
+	})
+
+	proto.DefineDataPropertySymbol(goja.SymToStringTag, vm.ToValue("TextEncoder"), goja.FLAG_FALSE, goja.FLAG_TRUE, goja.FLAG_FALSE)
+	return ctor
+}
+
+// newTextDecoder 创建 TextDecoder 构造函数，支持 WHATWG Encoding 标准中的编码标签
+func newTextDecoder(vm *goja.Runtime) *goja.Object {
+	ctor := vm.ToValue(func(call goja.ConstructorCall) *goja.Object {
+		label := "utf-8"
+		if arg := call.Argument(0); !goja.IsUndefined(arg) {
+			label = arg.String()
+		}
+		enc, err := htmlindex.Get(label)
+		name := ""
+		if err == nil {
+			name, err = htmlindex.Name(enc)
+		}
+		if err != nil || name == "replacement" {
+			panic(newRangeError(vm, "The \""+label+"\" encoding is not supported"))
+		}
+
+		d := &textDecoder{name: name, dec: enc.NewDecoder()}
+		if options, ok := call.Argument(1).(*goja.Object); ok {
+			d.fatal = options.Get("fatal") != nil && options.Get("fatal").ToBoolean()
+			d.ignoreBOM = options.Get("ignoreBOM") != nil && options.Get("ignoreBOM").ToBoolean()
+		}
+		call.This.DefineDataPropertySymbol(decoderKey, vm.ToValue(d), goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_FALSE)
+		return nil
+	}).(*goja.Object)
+	proto := ctor.Get("prototype").ToObject(vm)
+
+	state := func(this goja.Value) *textDecoder {
+		if obj, ok := this.(*goja.Object); ok {
+			if d, ok := obj.GetSymbol(decoderKey).Export().(*textDecoder); ok {
+				return d
+			}
+		}
+		panic(vm.NewTypeError("Value of \"this\" must be of type TextDecoder"))
+	}
+	getter := func(get func(d *textDecoder) interface{}) goja.Value {
+		return vm.ToValue(func(call goja.FunctionCall) goja.Value {
+			return vm.ToValue(get(state(call.This)))
+		})
+	}
+	proto.DefineAccessorProperty("encoding", getter(func(d *textDecoder) interface{} { return d.name }), nil, goja.FLAG_TRUE, goja.FLAG_TRUE)
+	proto.DefineAccessorProperty("fatal", getter(func(d *textDecoder) interface{} { return d.fatal }), nil, goja.FLAG_TRUE, goja.FLAG_TRUE)
+	proto.DefineAccessorProperty("ignoreBOM", getter(func(d *textDecoder) interface{} { return d.ignoreBOM }), nil, goja.FLAG_TRUE, goja.FLAG_TRUE)
+
+	// decode 解码 ArrayBuffer 或 ArrayBufferView，options.stream 为 true 时保留末尾不完整的字节序列
+	proto.Set("decode", func(call goja.FunctionCall) goja.Value {
+		d := state(call.This)
+		var data []byte
+		if input := call.Argument(0); !goja.IsUndefined(input) {
+			b, ok := buffer.Bytes(vm, input)
+			if !ok {
+				panic(vm.NewTypeError("The \"input\" argument must be an instance of ArrayBuffer or ArrayBufferView"))
+			}
+			data = b
+		}
+		stream := false
+		if options, ok := call.Argument(1).(*goja.Object); ok {
+			stream = options.Get("stream") != nil && options.Get("stream").ToBoolean()
+		}
+
+		s, err := d.decode(data, !stream)
+		if err != nil {
+			panic(vm.NewTypeError("The encoded data was not valid for encoding %s", d.name))
+		}
+		return vm.ToValue(s)
+	})
+
+	proto.DefineDataPropertySymbol(goja.SymToStringTag, vm.ToValue("TextDecoder"), goja.FLAG_FALSE, goja.FLAG_TRUE, goja.FLAG_FALSE)
+	return ctor
+}
+
+// errInvalidData fatal 模式下遇到无效的字节序列
+var errInvalidData = errors.New("invalid encoded data")
+
+// decode 解码一段数据，final 为 false 时末尾不完整的字节序列留到下一次调用
+func (d *textDecoder) decode(data []byte, final bool) (string, error) {
+	if len(d.pending) > 0 {
+		data = append(d.pending, data...)
+		d.pending = nil
+	}
+
+	var out []byte
+	if d.name == "utf-8" && utf8.Valid(data) {
+		// 常见情况：完整有效的 UTF-8 无需转换
+		out = data
+	} else {
+		src := data
+		dst := make([]byte, len(src)*3+utf8.UTFMax)
+		for {
+			nDst, nSrc, err := d.dec.Transform(dst, src, final)
+			out = append(out, dst[:nDst]...)
+			src = src[nSrc:]
+			if err == transform.ErrShortDst {
+				if nDst == 0 && nSrc == 0 {
+					dst = make([]byte, len(dst)*2)
+				}
+				continue
+			}
+			if err == transform.ErrShortSrc && !final {
+				d.pending = append([]byte(nil), src...)
+				break
+			}
+			if err != nil {
+				d.reset()
+				return "", err
+			}
+			break
+		}
+		if d.fatal && d.name == "utf-8" && !utf8.Valid(data[:len(data)-len(d.pending)]) {
+			d.reset()
+			return "", errInvalidData
+		}
+	}
+
+	s := string(out)
+	if !d.started && s != "" {
+		d.started = true
+		if !d.ignoreBOM && strings.HasPrefix(s, "\uFEFF") && strings.HasPrefix(d.name, "utf-") {
+			s = s[len("\uFEFF"):]
+		}
+	}
+	if final {
+		d.reset()
+	}
+	return s, nil
+}
+
+// reset 结束一个解码流
+func (d *textDecoder) reset() {
+	d.dec.Reset()
+	d.pending = nil
+	d.started = false
+}
+
+// newUint8Array 创建 Uint8Array
+func newUint8Array(vm *goja.Runtime, data []byte) goja.Value {
+	arr, err := vm.New(vm.Get("Uint8Array"), vm.ToValue(vm.NewArrayBuffer(data)))
+	if err != nil {
+		panic(err)
+	}
+	return arr
+}
@@ -0,0 +1,614 @@
+package web
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+// urlProgram URL、URLSearchParams 实现（预编译，可在多个 VM 间复用）
+var urlProgram = goja.MustCompile("url.js", urlSource, true)
+
+// specialSchemes WHATWG URL 标准中的特殊协议及其默认端口
+var specialSchemes = map[string]string{
+	"ftp":   "21",
+	"file":  "",
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+}
+
+// schemePattern 匹配输入开头的协议
+var schemePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.\-]*:`)
+
+// upperhex 百分号编码使用的大写十六进制字符
+const upperhex = "0123456789ABCDEF"
+
+// installURL 安装 URL 和 URLSearchParams
+func installURL(vm *goja.Runtime, global *goja.Object) {
+	factory, err := vm.RunProgram(urlProgram)
+	if err != nil {
+		panic(err)
+	}
+	fn, ok := goja.AssertFunction(factory)
+	if !ok {
+		panic(vm.NewTypeError("invalid url factory"))
+	}
+
+	native := vm.NewObject()
+	native.Set("parse", func(call goja.FunctionCall) goja.Value {
+		var base *url.URL
+		if b := call.Argument(1); !goja.IsUndefined(b) {
+			u, err := parseURL(b.String(), nil)
+			if err != nil {
+				return goja.Null()
+			}
+			base = u
+		}
+		u, err := parseURL(call.Argument(0).String(), base)
+		if err != nil {
+			return goja.Null()
+		}
+		return urlState(vm, u)
+	})
+	native.Set("parseQuery", func(call goja.FunctionCall) goja.Value {
+		return parseQuery(vm, call.Argument(0).String())
+	})
+	native.Set("encode", func(call goja.FunctionCall) goja.Value {
+		return vm.ToValue(encodeForm(call.Argument(0).String()))
+	})
+
+	exports, err := fn(goja.Undefined(), native)
+	if err != nil {
+		panic(err)
+	}
+	obj := exports.ToObject(vm)
+	global.Set("URL", obj.Get("URL"))
+	global.Set("URLSearchParams", obj.Get("URLSearchParams"))
+}
+
+// parseURL 按 WHATWG URL 标准（常见子集）解析 input，base 不为 nil 时支持相对地址
+func parseURL(input string, base *url.URL) (*url.URL, error) {
+	// 去掉首尾的控制字符和空格，以及中间的制表符和换行
+	input = strings.TrimFunc(input, func(r rune) bool { return r <= ' ' })
+	input = strings.NewReplacer("\t", "", "\n", "", "\r", "").Replace(input)
+
+	scheme := schemePattern.FindString(input)
+	special := false
+	if scheme != "" {
+		_, special = specialSchemes[strings.ToLower(scheme[:len(scheme)-1])]
+	} else if base != nil {
+		_, special = specialSchemes[base.Scheme]
+	}
+	if special {
+		// 特殊协议中反斜杠等同于斜杠
+		end := strings.IndexAny(input, "?#")
+		if end < 0 {
+			end = len(input)
+		}
+		input = strings.ReplaceAll(input[:end], `\`, "/") + input[end:]
+	}
+
+	ref, err := url.Parse(input)
+	if err != nil {
+		return nil, err
+	}
+
+	u := ref
+	if ref.Scheme == "" {
+		switch {
+		case base == nil:
+			return nil, errInvalidURL
+		case base.Opaque != "":
+			// 不透明地址（如 mailto:）只能相对地替换片段
+			if !strings.HasPrefix(input, "#") {
+				return nil, errInvalidURL
+			}
+			next := *base
+			next.Fragment, next.RawFragment = ref.Fragment, ref.RawFragment
+			u = &next
+		default:
+			u = base.ResolveReference(ref)
+			if ref.Host == "" {
+				u.OmitHost = base.OmitHost
+			}
+		}
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	defaultPort, special := specialSchemes[u.Scheme]
+	if special {
+		if u.Opaque != "" || (u.Host == "" && u.Scheme != "file") {
+			return nil, errInvalidURL
+		}
+		if u.Path == "" {
+			u.Path, u.RawPath = "/", ""
+		}
+	}
+
+	if u.Host != "" {
+		hostname, port := u.Hostname(), u.Port()
+		if special {
+			hostname = strings.ToLower(hostname)
+		}
+		if strings.Contains(hostname, ":") {
+			hostname = "[" + hostname + "]"
+		}
+		if port != "" {
+			n, err := strconv.Atoi(port)
+			if err != nil || n > 65535 {
+				return nil, errInvalidURL
+			}
+			port = strconv.Itoa(n)
+			if port == defaultPort {
+				port = ""
+			}
+		}
+		u.Host = hostname
+		if port != "" {
+			u.Host += ":" + port
+		}
+	}
+	return u, nil
+}
+
+// errInvalidURL 无法解析的地址
+var errInvalidURL = &url.Error{Op: "parse", Err: strconv.ErrSyntax}
+
+// urlState 返回 URL 各组成部分，JS 端据此实现属性读写和序列化
+func urlState(vm *goja.Runtime, u *url.URL) goja.Value {
+	_, special := specialSchemes[u.Scheme]
+
+	state := vm.NewObject()
+	state.Set("protocol", u.Scheme+":")
+
+	username, password := "", ""
+	if u.User != nil {
+		username, password, _ = strings.Cut(u.User.String(), ":")
+	}
+	state.Set("username", username)
+	state.Set("password", password)
+	state.Set("hostname", strings.TrimSuffix(u.Host, ":"+u.Port()))
+	state.Set("port", u.Port())
+
+	pathname := u.Opaque
+	if pathname == "" {
+		pathname = u.EscapedPath()
+		if strings.HasPrefix(pathname, "/") {
+			pathname = removeDotSegments(pathname)
+		}
+	}
+	state.Set("pathname", pathname)
+
+	search := ""
+	if u.RawQuery != "" {
+		search = "?" + encodeQuery(u.RawQuery, special)
+	}
+	state.Set("search", search)
+
+	hash := ""
+	if u.Fragment != "" {
+		hash = "#" + u.EscapedFragment()
+	}
+	state.Set("hash", hash)
+
+	// 是否带有 // 开头的授权部分（如 file:///tmp 带有，mailto:a@b 不带）
+	state.Set("slashes", special || (u.Opaque == "" && !u.OmitHost))
+	state.Set("special", special)
+	return state
+}
+
+// removeDotSegments 移除路径中的 . 和 .. 段（RFC 3986 5.2.4）
+func removeDotSegments(p string) string {
+	if !strings.Contains(p, ".") {
+		return p
+	}
+	segments := strings.Split(p, "/")
+	last := len(segments) - 1
+	out := make([]string, 0, len(segments))
+	for i, seg := range segments {
+		switch seg {
+		case ".":
+			if i == last {
+				out = append(out, "")
+			}
+		case "..":
+			if len(out) > 1 {
+				out = out[:len(out)-1]
+			}
+			if i == last {
+				out = append(out, "")
+			}
+		default:
+			out = append(out, seg)
+		}
+	}
+	return strings.Join(out, "/")
+}
+
+// encodeQuery 对查询字符串中的空白、非 ASCII 和保留字符进行百分号编码，已有的编码保持不变
+func encodeQuery(q string, special bool) string {
+	var b strings.Builder
+	for i := 0; i < len(q); i++ {
+		c := q[i]
+		if c < 0x21 || c > 0x7e || c == '"' || c == '<' || c == '>' || (special && c == '\'') {
+			b.WriteByte('%')
+			b.WriteByte(upperhex[c>>4])
+			b.WriteByte(upperhex[c&15])
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// encodeForm 按 application/x-www-form-urlencoded 编码字符串
+func encodeForm(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '*', c == '-', c == '.', c == '_':
+			b.WriteByte(c)
+		case c == ' ':
+			b.WriteByte('+')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(upperhex[c>>4])
+			b.WriteByte(upperhex[c&15])
+		}
+	}
+	return b.String()
+}
+
+// decodeForm 解码 application/x-www-form-urlencoded 字符串，无效的百分号编码原样保留
+func decodeForm(s string) string {
+	if !strings.ContainsAny(s, "+%") {
+		return s
+	}
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '+':
+			out = append(out, ' ')
+		case c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			out = append(out, unhex(s[i+1])<<4|unhex(s[i+2]))
+			i += 2
+		default:
+			out = append(out, c)
+		}
+	}
+	return strings.ToValidUTF8(string(out), "\uFFFD")
+}
+
+// parseQuery 解析查询字符串为 [name, value] 数组
+func parseQuery(vm *goja.Runtime, query string) goja.Value {
+	query = strings.TrimPrefix(query, "?")
+	pairs := make([]interface{}, 0)
+	for _, part := range strings.Split(query, "&") {
+		if part == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(part, "=")
+		pairs = append(pairs, vm.NewArray(decodeForm(name), decodeForm(value)))
+	}
+	return vm.NewArray(pairs...)
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
+
+// urlSource URL、URLSearchParams 实现，解析由 native.parse 完成，
+// 组成部分保存在 state 中，修改属性时重新序列化并解析
+const urlSource = `(function (native) {
+	const kState = Symbol('state');
+	const kParams = Symbol('params');
+	const kList = Symbol('list');
+	const kURL = Symbol('url');
+
+	function invalid(input) {
+		const err = new TypeError('Invalid URL');
+		err.code = 'ERR_INVALID_URL';
+		err.input = input;
+		return err;
+	}
+
+	function parse(input, base) {
+		input = String(input);
+		const state = native.parse(input, base === undefined ? undefined : String(base));
+		if (state === null) throw invalid(input);
+		return state;
+	}
+
+	function serialize(s) {
+		let out = s.protocol;
+		if (s.slashes) {
+			out += '//';
+			if (s.username || s.password) {
+				out += s.username + (s.password ? ':' + s.password : '') + '@';
+			}
+			out += s.hostname + (s.port ? ':' + s.port : '');
+		}
+		return out + s.pathname + s.search + s.hash;
+	}
+
+	// update 修改一个组成部分后重新解析，结果无效时忽略（与浏览器一致）
+	function update(url, key, value) {
+		const next = Object.assign({}, url[kState], { [key]: value });
+		const state = native.parse(serialize(next));
+		if (state === null) return;
+		url[kState] = state;
+		if (url[kParams]) url[kParams][kList] = native.parseQuery(state.search);
+	}
+
+	// escape 编码会截断当前组成部分的字符
+	function escape(value, chars) {
+		return String(value).replace(chars, (c) => '%' + c.charCodeAt(0).toString(16).toUpperCase());
+	}
+
+	class URL {
+		constructor(input, base) {
+			if (arguments.length === 0) throw new TypeError('The "url" argument must be specified');
+			this[kState] = parse(input, base);
+			this[kParams] = null;
+		}
+
+		static canParse(input, base) {
+			return native.parse(String(input), base === undefined ? undefined : String(base)) !== null;
+		}
+
+		static parse(input, base) {
+			const state = native.parse(String(input), base === undefined ? undefined : String(base));
+			if (state === null) return null;
+			const url = Object.create(URL.prototype);
+			url[kState] = state;
+			url[kParams] = null;
+			return url;
+		}
+
+		get href() { return serialize(this[kState]); }
+		set href(value) {
+			const state = parse(value);
+			this[kState] = state;
+			if (this[kParams]) this[kParams][kList] = native.parseQuery(state.search);
+		}
+
+		get origin() {
+			const s = this[kState];
+			if (!s.special || s.protocol === 'file:') return 'null';
+			return s.protocol + '//' + this.host;
+		}
+
+		get protocol() { return this[kState].protocol; }
+		set protocol(value) {
+			const scheme = String(value).split(':')[0].toLowerCase();
+			const special = ['ftp', 'file', 'http', 'https', 'ws', 'wss'].includes(scheme);
+			// 特殊协议与普通协议之间不能互相切换
+			if (special !== this[kState].special) return;
+			update(this, 'protocol', scheme + ':');
+		}
+
+		get username() { return this[kState].username; }
+		set username(value) {
+			if (!this[kState].hostname) return;
+			update(this, 'username', encodeURIComponent(String(value)));
+		}
+
+		get password() { return this[kState].password; }
+		set password(value) {
+			if (!this[kState].hostname) return;
+			update(this, 'password', encodeURIComponent(String(value)));
+		}
+
+		get host() {
+			const s = this[kState];
+			return s.hostname + (s.port ? ':' + s.port : '');
+		}
+		set host(value) {
+			if (!this[kState].slashes) return;
+			const host = String(value).split(/[\/?#]/)[0];
+			if (/:\d*$/.test(host)) {
+				const next = Object.assign({}, this[kState], { hostname: host, port: '' });
+				const state = native.parse(serialize(next));
+				if (state !== null) this[kState] = state;
+				return;
+			}
+			update(this, 'hostname', host);
+		}
+
+		get hostname() { return this[kState].hostname; }
+		set hostname(value) {
+			if (!this[kState].slashes) return;
+			update(this, 'hostname', String(value).split(/[\/?#]/)[0]);
+		}
+
+		get port() { return this[kState].port; }
+		set port(value) {
+			const str = String(value);
+			if (str === '') {
+				update(this, 'port', '');
+				return;
+			}
+			const digits = /^\d+/.exec(str);
+			if (digits) update(this, 'port', digits[0]);
+		}
+
+		get pathname() { return this[kState].pathname; }
+		set pathname(value) {
+			if (!this[kState].slashes) return;
+			let path = escape(value, /[?#]/g);
+			if (!path.startsWith('/')) path = '/' + path;
+			update(this, 'pathname', path);
+		}
+
+		get search() { return this[kState].search; }
+		set search(value) {
+			const str = escape(value, /#/g).replace(/^\?/, '');
+			update(this, 'search', str === '' ? '' : '?' + str);
+			if (str === '' && this[kParams]) this[kParams][kList] = [];
+		}
+
+		get searchParams() {
+			if (!this[kParams]) {
+				const params = new URLSearchParams(this[kState].search);
+				params[kURL] = this;
+				this[kParams] = params;
+			}
+			return this[kParams];
+		}
+
+		get hash() { return this[kState].hash; }
+		set hash(value) {
+			const str = String(value).replace(/^#/, '');
+			update(this, 'hash', str === '' ? '' : '#' + str);
+		}
+
+		toString() { return this.href; }
+		toJSON() { return this.href; }
+		get [Symbol.toStringTag]() { return 'URL'; }
+	}
+
+	// updateURL URLSearchParams 修改后同步到关联的 URL
+	function updateURL(params) {
+		const url = params[kURL];
+		if (url) {
+			const query = params.toString();
+			url[kState].search = query ? '?' + query : '';
+		}
+	}
+
+	class URLSearchParams {
+		constructor(init) {
+			this[kList] = [];
+			this[kURL] = null;
+			if (init === undefined || init === null) return;
+			if (typeof init === 'object' || typeof init === 'function') {
+				if (typeof init[Symbol.iterator] === 'function') {
+					for (const pair of init) {
+						const entry = Array.from(pair);
+						if (entry.length !== 2) {
+							throw new TypeError('Each query pair must be an iterable [name, value] tuple');
+						}
+						this[kList].push([String(entry[0]), String(entry[1])]);
+					}
+				} else {
+					for (const key of Object.keys(init)) {
+						this[kList].push([key, String(init[key])]);
+					}
+				}
+				return;
+			}
+			this[kList] = native.parseQuery(String(init));
+		}
+
+		get size() { return this[kList].length; }
+
+		append(name, value) {
+			this[kList].push([String(name), String(value)]);
+			updateURL(this);
+		}
+
+		delete(name, value) {
+			name = String(name);
+			value = value === undefined ? undefined : String(value);
+			this[kList] = this[kList].filter(([k, v]) => k !== name || (value !== undefined && v !== value));
+			updateURL(this);
+		}
+
+		get(name) {
+			name = String(name);
+			const entry = this[kList].find(([k]) => k === name);
+			return entry ? entry[1] : null;
+		}
+
+		getAll(name) {
+			name = String(name);
+			return this[kList].filter(([k]) => k === name).map(([, v]) => v);
+		}
+
+		has(name, value) {
+			name = String(name);
+			value = value === undefined ? undefined : String(value);
+			return this[kList].some(([k, v]) => k === name && (value === undefined || v === value));
+		}
+
+		set(name, value) {
+			name = String(name);
+			value = String(value);
+			const list = [];
+			let found = false;
+			for (const entry of this[kList]) {
+				if (entry[0] !== name) {
+					list.push(entry);
+				} else if (!found) {
+					found = true;
+					list.push([name, value]);
+				}
+			}
+			if (!found) list.push([name, value]);
+			this[kList] = list;
+			updateURL(this);
+		}
+
+		sort() {
+			// 按名称的 UTF-16 码元稳定排序
+			this[kList] = this[kList]
+				.map((entry, i) => [entry, i])
+				.sort((a, b) => (a[0][0] < b[0][0] ? -1 : a[0][0] > b[0][0] ? 1 : a[1] - b[1]))
+				.map(([entry]) => entry);
+			updateURL(this);
+		}
+
+		forEach(callback, thisArg) {
+			if (typeof callback !== 'function') {
+				throw new TypeError('The "callback" argument must be of type function');
+			}
+			for (const [name, value] of this[kList]) {
+				callback.call(thisArg, value, name, this);
+			}
+		}
+
+		*entries() {
+			for (let i = 0; i < this[kList].length; i++) {
+				const [name, value] = this[kList][i];
+				yield [name, value];
+			}
+		}
+
+		*keys() {
+			for (const [name] of this.entries()) yield name;
+		}
+
+		*values() {
+			for (const [, value] of this.entries()) yield value;
+		}
+
+		[Symbol.iterator]() { return this.entries(); }
+
+		toString() {
+			return this[kList].map(([name, value]) => native.encode(name) + '=' + native.encode(value)).join('&');
+		}
+
+		get [Symbol.toStringTag]() { return 'URLSearchParams'; }
+	}
+
+	return { URL, URLSearchParams };
+})`
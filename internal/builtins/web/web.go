@@ -0,0 +1,123 @@
+// Package web 提供 Web 平台的标准全局对象：URL、URLSearchParams、TextEncoder、TextDecoder、
+// atob、btoa、structuredClone、performance 和 crypto
+package web
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/issueye/sw_runtime/internal/builtins/clone"
+
+	"github.com/dop251/goja"
+)
+
+// integerArrays getRandomValues 支持的整数 TypedArray 类型
+var integerArrays = []string{
+	"Int8Array", "Uint8Array", "Uint8ClampedArray",
+	"Int16Array", "Uint16Array", "Int32Array", "Uint32Array",
+	"BigInt64Array", "BigUint64Array",
+}
+
+// Install 在全局对象上安装 Web 平台全局对象，origin 为 performance.timeOrigin
+func Install(vm *goja.Runtime, global *goja.Object, origin time.Time) {
+	installURL(vm, global)
+	installEncoding(vm, global)
+
+	global.Set("structuredClone", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) == 0 {
+			panic(vm.NewTypeError("The \"value\" argument must be specified"))
+		}
+		return clone.StructuredClone(vm, call.Arguments[0])
+	})
+
+	global.Set("performance", newPerformance(vm, origin))
+	global.Set("crypto", newCrypto(vm))
+}
+
+// newPerformance 创建 performance 对象，now() 返回自 timeOrigin 起的毫秒数（单调时钟）
+func newPerformance(vm *goja.Runtime, origin time.Time) *goja.Object {
+	perf := vm.NewObject()
+	timeOrigin := float64(origin.UnixNano()) / 1e6
+	perf.Set("timeOrigin", timeOrigin)
+	perf.Set("now", func(goja.FunctionCall) goja.Value {
+		return vm.ToValue(float64(time.Since(origin).Nanoseconds()) / 1e6)
+	})
+	perf.Set("toJSON", func(goja.FunctionCall) goja.Value {
+		result := vm.NewObject()
+		result.Set("timeOrigin", timeOrigin)
+		return result
+	})
+	return perf
+}
+
+// newCrypto 创建 crypto 对象（randomUUID、getRandomValues）
+func newCrypto(vm *goja.Runtime) *goja.Object {
+	c := vm.NewObject()
+
+	c.Set("randomUUID", func(goja.FunctionCall) goja.Value {
+		var b [16]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			panic(vm.NewGoError(err))
+		}
+		// 版本 4（随机），RFC 4122 变体
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		return vm.ToValue(fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]))
+	})
+
+	// getRandomValues 用随机数填充整数 TypedArray 并返回它本身，最多 65536 字节
+	c.Set("getRandomValues", func(call goja.FunctionCall) goja.Value {
+		arr, ok := call.Argument(0).(*goja.Object)
+		if !ok || !isIntegerArray(vm, arr) {
+			panic(newError(vm, "TypeMismatchError", "The data argument must be an integer-type TypedArray"))
+		}
+		length := arr.Get("byteLength").ToInteger()
+		if length > 65536 {
+			panic(newError(vm, "QuotaExceededError", "The ArrayBufferView's byte length exceeds the number of bytes of entropy available via this API (65536)"))
+		}
+
+		// 通过覆盖同一段内存的 Uint8Array 写入
+		view, err := vm.New(vm.Get("Uint8Array"), arr.Get("buffer"), arr.Get("byteOffset"), vm.ToValue(length))
+		if err != nil {
+			panic(err)
+		}
+		if data, ok := view.Export().([]byte); ok {
+			if _, err := rand.Read(data); err != nil {
+				panic(vm.NewGoError(err))
+			}
+		}
+		return arr
+	})
+
+	return c
+}
+
+// isIntegerArray 检查对象是否为整数 TypedArray
+func isIntegerArray(vm *goja.Runtime, obj *goja.Object) bool {
+	for _, name := range integerArrays {
+		if ctor, ok := vm.Get(name).(*goja.Object); ok && vm.InstanceOf(obj, ctor) {
+			return true
+		}
+	}
+	return false
+}
+
+// newError 创建带 name 的错误对象（如 InvalidCharacterError）
+func newError(vm *goja.Runtime, name string, message string) *goja.Object {
+	errObj, err := vm.New(vm.Get("Error"), vm.ToValue(message))
+	if err != nil {
+		panic(err)
+	}
+	errObj.Set("name", name)
+	return errObj
+}
+
+// newRangeError 创建 RangeError
+func newRangeError(vm *goja.Runtime, message string) *goja.Object {
+	errObj, err := vm.New(vm.Get("RangeError"), vm.ToValue(message))
+	if err != nil {
+		panic(err)
+	}
+	return errObj
+}
@@ -11,6 +11,10 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/issueye/sw_runtime/internal/importmap"
+	"github.com/issueye/sw_runtime/internal/remote"
+	"github.com/issueye/sw_runtime/internal/tsconfig"
+
 	"github.com/evanw/esbuild/pkg/api"
 )
 
@@ -19,16 +23,22 @@ type Options struct {
 	EntryFile    string   // 入口文件
 	OutputFile   string   // 输出文件
 	Minify       bool     // 是否压缩
-	Sourcemap    bool     // 是否生成 source map
+	Sourcemap    bool     // 是否生成外部 source map 文件（默认内联不含源码内容的 source map）
 	ExcludeFiles []string // 排除的文件列表
 	Encrypt      bool     // 是否加密
 	EncryptKey   string   // 加密密钥（如果为空则自动生成）
+
+	// ImportMap 导入映射，与 run 使用同一映射保证开发与打包结果一致
+	ImportMap *importmap.ImportMap
+
+	// Remote 远程模块加载器，http(s) 依赖下载后内联到打包结果中；为 nil 时使用默认加载器
+	Remote *remote.Loader
 }
 
 // Result 打包结果
 type Result struct {
 	Code       string   // 打包后的代码
-	Sourcemap  string   // Source map (如果生成)
+	Sourcemap  string   // 外部 source map 内容（仅当 Sourcemap 选项开启时）
 	Modules    []string // 包含的模块列表
 	Encrypted  bool     // 是否加密
 	EncryptKey string   // 加密密钥（仅当加密时有效）
@@ -48,7 +58,7 @@ type Bundler struct {
 var defaultBuiltinModules = []string{
 	"server", "sqlite", "websocket", "ws", "fs", "crypto",
 	"zlib", "compression", "http", "redis", "exec",
-	"child_process", "path", "httpserver",
+	"child_process", "path", "httpserver", "standalone",
 }
 
 // New 创建新的打包器
@@ -93,6 +103,13 @@ func (b *Bundler) Bundle() (*Result, error) {
 		return nil, err
 	}
 
+	// esbuild 会为每个文件读取最近的 tsconfig.json（装饰器、JSX、paths 等），
+	// 输出目标与 JSX 处理方式按入口文件的配置确定
+	config, err := tsconfig.Find(filepath.Dir(entryAbs))
+	if err != nil {
+		return nil, err
+	}
+
 	// 使用 esbuild 进行打包
 	buildOptions := api.BuildOptions{
 		EntryPoints:       []string{entryAbs},
@@ -100,16 +117,31 @@ func (b *Bundler) Bundle() (*Result, error) {
 		Write:             false,
 		Platform:          api.PlatformNode,
 		Format:            api.FormatCommonJS,
-		Target:            api.ES2020,
+		Target:            config.Target(),
 		MinifyWhitespace:  b.options.Minify,
 		MinifyIdentifiers: b.options.Minify,
 		MinifySyntax:      b.options.Minify,
-		Sourcemap:         api.SourceMapNone,
+		Outfile:           b.outputPath(),
+		Sourcemap:         api.SourceMapInline,
+		SourcesContent:    api.SourcesContentExclude,
 		External:          b.getExternalModules(),
 	}
 
+	if b.options.ImportMap != nil {
+		buildOptions.Plugins = append(buildOptions.Plugins, b.importMapPlugin())
+	}
+	buildOptions.Plugins = append(buildOptions.Plugins, b.remotePlugin())
+
+	// jsx 为 preserve 时改为转换，运行时无法执行保留的 JSX
+	if config.PreserveJSX() {
+		buildOptions.JSX = api.JSXTransform
+	}
+
+	// 始终生成 source map 以便异常堆栈指向原始文件；
+	// 默认内联且不含源码内容，开启 Sourcemap 选项时输出包含源码的外部 .map 文件
 	if b.options.Sourcemap {
-		buildOptions.Sourcemap = api.SourceMapInline
+		buildOptions.Sourcemap = api.SourceMapLinked
+		buildOptions.SourcesContent = api.SourcesContentInclude
 	}
 
 	result := api.Build(buildOptions)
@@ -119,19 +151,23 @@ func (b *Bundler) Bundle() (*Result, error) {
 		for _, err := range result.Errors {
 			errorMsg += fmt.Sprintf("  %s\n", err.Text)
 		}
-		return nil, fmt.Errorf(errorMsg)
+		return nil, fmt.Errorf("%s", errorMsg)
 	}
 
 	if len(result.OutputFiles) == 0 {
 		return nil, fmt.Errorf("打包未生成输出文件")
 	}
 
-	code := string(result.OutputFiles[0].Contents)
+	code := ""
 	sourcemap := ""
 
-	// 提取 sourcemap（如果存在）
-	if b.options.Sourcemap && len(result.OutputFiles) > 1 {
-		sourcemap = string(result.OutputFiles[1].Contents)
+	// 提取代码与外部 sourcemap（如果存在）
+	for _, file := range result.OutputFiles {
+		if strings.HasSuffix(file.Path, ".map") {
+			sourcemap = string(file.Contents)
+		} else {
+			code = string(file.Contents)
+		}
 	}
 
 	// 如果需要加密
@@ -169,12 +205,29 @@ func (b *Bundler) Bundle() (*Result, error) {
 	}, nil
 }
 
+// outputPath 获取输出文件的绝对路径，source map 中的源文件路径相对于它的目录
+func (b *Bundler) outputPath() string {
+	output := b.options.OutputFile
+	if output == "" {
+		ext := filepath.Ext(b.options.EntryFile)
+		output = strings.TrimSuffix(b.options.EntryFile, ext) + ".bundle.js"
+	}
+	abs, err := filepath.Abs(output)
+	if err != nil {
+		return output
+	}
+	return abs
+}
+
 // analyzeModule 分析模块及其依赖
 func (b *Bundler) analyzeModule(modulePath string, parentPath string) error {
-	// 规范化路径
-	absPath, err := filepath.Abs(modulePath)
-	if err != nil {
-		return err
+	// 规范化路径，远程模块使用 URL
+	absPath := modulePath
+	if !remote.IsURL(modulePath) {
+		var err error
+		if absPath, err = filepath.Abs(modulePath); err != nil {
+			return err
+		}
 	}
 
 	// 检查是否已处理或需要排除
@@ -187,20 +240,34 @@ func (b *Bundler) analyzeModule(modulePath string, parentPath string) error {
 	b.moduleOrder = append(b.moduleOrder, absPath)
 
 	// 读取文件内容
-	content, err := os.ReadFile(absPath)
+	content, err := b.readModule(absPath)
 	if err != nil {
 		return fmt.Errorf("读取模块 %s 失败: %w", absPath, err)
 	}
 
-	// 如果是 TypeScript，先编译
+	// 如果是 TypeScript，先编译（远程模块不使用本地的 tsconfig.json）
 	ext := filepath.Ext(absPath)
+	if remote.IsURL(absPath) {
+		ext = remote.Ext(absPath)
+	}
 	code := string(content)
 	if ext == ".ts" || ext == ".tsx" {
-		result := api.Transform(code, api.TransformOptions{
+		var config *tsconfig.Config
+		if !remote.IsURL(absPath) {
+			if config, err = tsconfig.Find(filepath.Dir(absPath)); err != nil {
+				return err
+			}
+		}
+		options := api.TransformOptions{
 			Loader: api.LoaderTS,
 			Target: api.ES2020,
 			Format: api.FormatCommonJS,
-		})
+		}
+		if ext == ".tsx" {
+			options.Loader = api.LoaderTSX
+		}
+		config.Apply(&options)
+		result := api.Transform(code, options)
 
 		if len(result.Errors) > 0 {
 			return fmt.Errorf("编译 TypeScript 失败: %s", result.Errors[0].Text)
@@ -275,29 +342,27 @@ func (b *Bundler) extractDependencies(code string) []string {
 
 // resolveModule 解析模块路径
 func (b *Bundler) resolveModule(id string, parentPath string) (string, error) {
+	// 远程模块中的导入以模块 URL 为基准解析
+	if remote.IsURL(parentPath) {
+		return b.resolveRemote(id, parentPath)
+	}
+
+	// 导入映射优先于其他解析规则
+	if resolved, ok, err := b.resolveImportMap(id, filepath.Dir(parentPath), parentPath); err != nil || ok {
+		return resolved, err
+	}
+
+	// http(s) URL 形式的远程模块
+	if remote.IsURL(id) {
+		return id, nil
+	}
+
 	// 相对路径
 	if strings.HasPrefix(id, "./") || strings.HasPrefix(id, "../") {
 		basePath := filepath.Dir(parentPath)
-		resolved := filepath.Join(basePath, id)
-
-		// 尝试不同的扩展名
-		extensions := []string{"", ".js", ".ts", ".tsx", ".json"}
-		for _, ext := range extensions {
-			fullPath := resolved + ext
-			if _, err := os.Stat(fullPath); err == nil {
-				return filepath.Abs(fullPath)
-			}
+		if resolved, ok := resolveFile(filepath.Join(basePath, id)); ok {
+			return resolved, nil
 		}
-
-		// 尝试 index 文件
-		indexExtensions := []string{"/index.js", "/index.ts", "/index.tsx", "/index.json"}
-		for _, ext := range indexExtensions {
-			fullPath := resolved + ext
-			if _, err := os.Stat(fullPath); err == nil {
-				return filepath.Abs(fullPath)
-			}
-		}
-
 		return "", fmt.Errorf("模块未找到: %s", id)
 	}
 
@@ -309,6 +374,14 @@ func (b *Bundler) resolveModule(id string, parentPath string) (string, error) {
 		return "", fmt.Errorf("模块未找到: %s", id)
 	}
 
+	// tsconfig.json 的 paths 与 baseUrl 别名
+	config, _ := tsconfig.Find(filepath.Dir(parentPath))
+	for _, candidate := range config.Candidates(id) {
+		if resolved, ok := resolveFile(candidate); ok {
+			return resolved, nil
+		}
+	}
+
 	// node_modules 查找（简化实现）
 	nodeModulesPath := filepath.Join(b.basePath, "node_modules", id)
 	if _, err := os.Stat(nodeModulesPath); err == nil {
@@ -318,6 +391,153 @@ func (b *Bundler) resolveModule(id string, parentPath string) (string, error) {
 	return "", fmt.Errorf("模块未找到: %s", id)
 }
 
+// resolveImportMap 按导入映射解析模块标识符，未匹配时返回 false
+func (b *Bundler) resolveImportMap(id string, dir string, importer string) (string, bool, error) {
+	target, ok, err := b.options.ImportMap.Resolve(id, dir, importer)
+	if err != nil || !ok {
+		return "", false, err
+	}
+	if remote.IsURL(target) {
+		return target, true, nil
+	}
+	resolved, ok := resolveFile(target)
+	if !ok {
+		return "", false, fmt.Errorf("模块未找到: %s (导入映射指向 %s)", id, target)
+	}
+	return resolved, true, nil
+}
+
+// importMapPlugin 让 esbuild 按导入映射解析模块，与运行时的解析结果一致
+func (b *Bundler) importMapPlugin() api.Plugin {
+	return api.Plugin{
+		Name: "sw-import-map",
+		Setup: func(build api.PluginBuild) {
+			// 远程模块中的导入由 remotePlugin 处理
+			build.OnResolve(api.OnResolveOptions{Filter: ".*", Namespace: "file"}, func(args api.OnResolveArgs) (api.OnResolveResult, error) {
+				// 内置模块保持外部引用
+				if b.builtinModules[args.Path] {
+					return api.OnResolveResult{}, nil
+				}
+				resolved, ok, err := b.resolveImportMap(args.Path, args.ResolveDir, args.Importer)
+				if err != nil || !ok {
+					return api.OnResolveResult{}, err
+				}
+				if remote.IsURL(resolved) {
+					return api.OnResolveResult{Path: resolved, Namespace: remoteNamespace}, nil
+				}
+				return api.OnResolveResult{Path: resolved}, nil
+			})
+		},
+	}
+}
+
+// remoteNamespace esbuild 中远程模块所在的命名空间
+const remoteNamespace = "sw-remote"
+
+// remotePlugin 下载 http(s) 远程模块并内联到打包结果中，运行打包结果时不再需要网络
+func (b *Bundler) remotePlugin() api.Plugin {
+	return api.Plugin{
+		Name: "sw-remote",
+		Setup: func(build api.PluginBuild) {
+			build.OnResolve(api.OnResolveOptions{Filter: "^https?://", Namespace: "file"}, func(args api.OnResolveArgs) (api.OnResolveResult, error) {
+				return api.OnResolveResult{Path: args.Path, Namespace: remoteNamespace}, nil
+			})
+			build.OnResolve(api.OnResolveOptions{Filter: ".*", Namespace: remoteNamespace}, func(args api.OnResolveArgs) (api.OnResolveResult, error) {
+				if b.builtinModules[args.Path] {
+					return api.OnResolveResult{Path: args.Path, External: true}, nil
+				}
+				resolved, err := b.resolveRemote(args.Path, args.Importer)
+				if err != nil {
+					return api.OnResolveResult{}, err
+				}
+				return api.OnResolveResult{Path: resolved, Namespace: remoteNamespace}, nil
+			})
+			build.OnLoad(api.OnLoadOptions{Filter: ".*", Namespace: remoteNamespace}, func(args api.OnLoadArgs) (api.OnLoadResult, error) {
+				content, err := b.readModule(args.Path)
+				if err != nil {
+					return api.OnLoadResult{}, err
+				}
+				contents := string(content)
+				return api.OnLoadResult{Contents: &contents, Loader: remoteLoader(args.Path)}, nil
+			})
+		},
+	}
+}
+
+// resolveRemote 解析远程模块中的导入，规则与运行时一致：相对标识符以模块 URL 为基准，
+// 裸标识符只能通过导入映射指向其他 URL
+func (b *Bundler) resolveRemote(id string, parentURL string) (string, error) {
+	specifier := id
+	if remote.IsRelative(id) {
+		resolved, err := remote.Resolve(parentURL, id)
+		if err != nil {
+			return "", err
+		}
+		specifier = resolved
+	}
+	if target, ok, err := b.options.ImportMap.Resolve(specifier, b.basePath, parentURL); err != nil {
+		return "", err
+	} else if ok {
+		specifier = target
+	}
+	if remote.IsURL(specifier) {
+		return specifier, nil
+	}
+	return "", fmt.Errorf("模块未找到: %s (远程模块 %s 只能导入 URL)", id, parentURL)
+}
+
+// readModule 读取本地文件或下载远程模块
+func (b *Bundler) readModule(path string) ([]byte, error) {
+	if !remote.IsURL(path) {
+		return os.ReadFile(path)
+	}
+	loader := b.options.Remote
+	if loader == nil {
+		loader = remote.Default()
+	}
+	return loader.Load(path, nil)
+}
+
+// remoteLoader 根据远程模块 URL 的扩展名选择 esbuild loader
+func remoteLoader(rawURL string) api.Loader {
+	switch remote.Ext(rawURL) {
+	case ".ts", ".mts", ".cts":
+		return api.LoaderTS
+	case ".tsx":
+		return api.LoaderTSX
+	case ".jsx":
+		return api.LoaderJSX
+	case ".json":
+		return api.LoaderJSON
+	}
+	return api.LoaderJS
+}
+
+// resolveFile 依次尝试原路径、补全扩展名和目录下的 index 文件
+func resolveFile(resolved string) (string, bool) {
+	// 尝试不同的扩展名
+	extensions := []string{"", ".js", ".ts", ".tsx", ".json"}
+	for _, ext := range extensions {
+		fullPath := resolved + ext
+		if info, err := os.Stat(fullPath); err == nil && !info.IsDir() {
+			abs, err := filepath.Abs(fullPath)
+			return abs, err == nil
+		}
+	}
+
+	// 尝试 index 文件
+	indexExtensions := []string{"/index.js", "/index.ts", "/index.tsx", "/index.json"}
+	for _, ext := range indexExtensions {
+		fullPath := resolved + ext
+		if _, err := os.Stat(fullPath); err == nil {
+			abs, err := filepath.Abs(fullPath)
+			return abs, err == nil
+		}
+	}
+
+	return "", false
+}
+
 // getExternalModules 获取外部模块列表（内置模块）
 func (b *Bundler) getExternalModules() []string {
 	external := make([]string, 0, len(b.builtinModules))
@@ -0,0 +1,286 @@
+// Package cache 提供按内容哈希寻址的磁盘缓存，保存 TypeScript 与 ES 模块的转译结果（含内联 source map），
+// 源码不变时跳过 esbuild 转换。多个进程可以共享同一个缓存目录
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DirEnv 设置缓存目录的环境变量，设置为空字符串时禁用缓存
+const DirEnv = "SW_RUNTIME_CACHE_DIR"
+
+// DefaultMaxSize 缓存目录的默认大小上限
+const DefaultMaxSize int64 = 256 << 20
+
+// version 缓存格式版本，转译选项或输出格式变化时递增，使旧条目失效
+const version = "1"
+
+// Cache 磁盘缓存，nil 表示禁用（所有方法均可在 nil 上调用）
+type Cache struct {
+	dir     string
+	maxSize int64
+
+	mu   sync.Mutex
+	size int64 // 缓存目录的当前大小，-1 表示尚未统计
+}
+
+var (
+	disabled atomic.Bool
+
+	cachesMu sync.Mutex
+	caches   = make(map[string]*Cache)
+
+	// salt 参与计算键的版本信息：缓存格式版本与 esbuild 版本
+	salt = version + "|" + esbuildVersion()
+)
+
+// Open 打开 dir 作为缓存目录，同一目录返回同一个 Cache。目录在首次写入时创建
+func Open(dir string) *Cache {
+	dir = filepath.Clean(dir)
+
+	cachesMu.Lock()
+	defer cachesMu.Unlock()
+	if c, ok := caches[dir]; ok {
+		return c
+	}
+	c := &Cache{dir: dir, maxSize: DefaultMaxSize, size: -1}
+	caches[dir] = c
+	return c
+}
+
+// Default 返回默认的转译缓存：$SW_RUNTIME_CACHE_DIR，未设置时为用户缓存目录下的 sw_runtime/transpile
+// （Linux 上为 $XDG_CACHE_HOME/sw_runtime/transpile 或 ~/.cache/sw_runtime/transpile）。
+// 环境变量为空或无法确定用户缓存目录时返回 nil
+func Default() *Cache {
+	if disabled.Load() {
+		return nil
+	}
+	dir := DefaultDir()
+	if dir == "" {
+		return nil
+	}
+	return Open(dir)
+}
+
+// Disable 在当前进程中禁用默认缓存，如运行解密后的代码时避免转译结果以明文写入磁盘
+func Disable() {
+	disabled.Store(true)
+}
+
+// DefaultDir 返回默认缓存目录，禁用时返回空字符串
+func DefaultDir() string {
+	if dir, ok := os.LookupEnv(DirEnv); ok {
+		return dir
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(base, "sw_runtime", "transpile")
+}
+
+// Dir 返回缓存目录
+func (c *Cache) Dir() string {
+	if c == nil {
+		return ""
+	}
+	return c.dir
+}
+
+// SetMaxSize 设置缓存目录的大小上限，超出时按最近使用时间淘汰
+func (c *Cache) SetMaxSize(size int64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.maxSize = size
+	c.mu.Unlock()
+}
+
+// Key 根据转译类型、文件名和源码计算缓存键。文件名会写入 source map，因此参与计算
+func Key(kind, filename, code string) string {
+	h := sha256.New()
+	for _, part := range []string{salt, kind, filename} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	h.Write([]byte(code))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Do 返回 kind、filename、code 对应的缓存内容，未命中时调用 build 并写入缓存。
+// build 返回错误时不缓存；缓存读写失败不影响结果
+func (c *Cache) Do(kind, filename, code string, build func() ([]byte, error)) ([]byte, error) {
+	if c == nil {
+		return build()
+	}
+	key := Key(kind, filename, code)
+	if data, ok := c.Get(key); ok {
+		return data, nil
+	}
+	data, err := build()
+	if err != nil {
+		return nil, err
+	}
+	c.Put(key, data)
+	return data, nil
+}
+
+// Get 读取缓存条目，并更新其最近使用时间
+func (c *Cache) Get(key string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return data, true
+}
+
+// Put 写入缓存条目。先写临时文件再重命名，并发的进程不会读到不完整的内容
+func (c *Cache) Put(key string, data []byte) {
+	if c == nil {
+		return
+	}
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+
+	c.mu.Lock()
+	if c.size < 0 {
+		c.size = c.scanSize()
+	} else {
+		c.size += int64(len(data))
+	}
+	over := c.size > c.maxSize
+	c.mu.Unlock()
+
+	if over {
+		c.evict()
+	}
+}
+
+// Clear 删除缓存目录中的所有条目
+func (c *Cache) Clear() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.size = 0
+	return os.RemoveAll(c.dir)
+}
+
+// Size 返回缓存目录中所有条目的总大小
+func (c *Cache) Size() int64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.size = c.scanSize()
+	return c.size
+}
+
+// path 返回条目的文件路径，按键的前两位分目录，避免单个目录文件过多
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+// entry 淘汰时使用的条目信息
+type entry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// entries 列出缓存目录中的所有条目
+func (c *Cache) entries() []entry {
+	var list []entry
+	filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			list = append(list, entry{path: path, size: info.Size(), modTime: info.ModTime()})
+		}
+		return nil
+	})
+	return list
+}
+
+// scanSize 统计缓存目录的大小（调用时必须持有 mu）
+func (c *Cache) scanSize() int64 {
+	var total int64
+	for _, e := range c.entries() {
+		total += e.size
+	}
+	return total
+}
+
+// evict 按最近使用时间从旧到新删除条目，直到总大小降到上限的 3/4，避免每次写入都触发淘汰
+func (c *Cache) evict() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	list := c.entries()
+	sort.Slice(list, func(i, j int) bool { return list[i].modTime.Before(list[j].modTime) })
+
+	var total int64
+	for _, e := range list {
+		total += e.size
+	}
+	target := c.maxSize / 4 * 3
+	for _, e := range list {
+		if total <= target {
+			break
+		}
+		if os.Remove(e.path) == nil {
+			total -= e.size
+		}
+	}
+	c.size = total
+}
+
+// esbuildVersion 返回编译进程序的 esbuild 版本，升级 esbuild 后旧的转译结果自动失效
+func esbuildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == "github.com/evanw/esbuild" {
+			return dep.Version
+		}
+	}
+	return ""
+}
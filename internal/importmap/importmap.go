@@ -0,0 +1,259 @@
+// Package importmap 实现 WHATWG 导入映射（import map）：按 imports 与 scopes 将模块标识符
+// 或标识符前缀映射到本地文件、目录或 URL，模块系统与打包器共用同一套解析规则
+package importmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ImportMap 解析后的导入映射
+type ImportMap struct {
+	Path string // 导入映射文件的路径，内联在 sw.json 中时为 sw.json 的路径
+
+	imports specifierMap
+	scopes  []scope // 按前缀降序排列，更具体的作用域优先
+}
+
+// entry 一条映射，target 为空表示映射无效（按规范视为被阻止）
+type entry struct {
+	key    string
+	target string
+}
+
+// specifierMap 按键降序排列的映射，较长的前缀先于较短的前缀匹配
+type specifierMap []entry
+
+// scope 只对前缀下的模块生效的映射
+type scope struct {
+	prefix  string
+	imports specifierMap
+}
+
+// mapFile 导入映射的 JSON 结构
+type mapFile struct {
+	Imports map[string]interface{}            `json:"imports"`
+	Scopes  map[string]map[string]interface{} `json:"scopes"`
+}
+
+// Load 读取导入映射文件，相对地址基于文件所在目录
+func Load(path string) (*ImportMap, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import map: %w", err)
+	}
+	m, err := Parse(data, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("invalid import map %s: %w", path, err)
+	}
+	m.Path = path
+	return m, nil
+}
+
+// Parse 解析导入映射 JSON，baseDir 为相对地址（./、../）的基准目录
+func Parse(data []byte, baseDir string) (*ImportMap, error) {
+	var file mapFile
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&file); err != nil {
+		return nil, err
+	}
+
+	base := toSlash(baseDir) + "/"
+	m := &ImportMap{imports: parseSpecifierMap(file.Imports, base)}
+	for prefix, imports := range file.Scopes {
+		scopePrefix, ok := resolveAddress(prefix, base)
+		if !ok {
+			return nil, fmt.Errorf("invalid scope %q", prefix)
+		}
+		m.scopes = append(m.scopes, scope{prefix: scopePrefix, imports: parseSpecifierMap(imports, base)})
+	}
+	sort.Slice(m.scopes, func(i, j int) bool { return m.scopes[i].prefix > m.scopes[j].prefix })
+	return m, nil
+}
+
+// parseSpecifierMap 规范化映射的键和值。URL 形式的键（/、./、../）解析为绝对路径，
+// 无效的值或以 / 结尾的键对应不以 / 结尾的值记为无效映射
+func parseSpecifierMap(raw map[string]interface{}, base string) specifierMap {
+	var entries specifierMap
+	for key, value := range raw {
+		if key == "" {
+			continue
+		}
+		normalized := key
+		if isURLLike(key) || isURL(key) {
+			if resolved, ok := resolveAddress(key, base); ok {
+				normalized = resolved
+			}
+		}
+
+		target := ""
+		if s, ok := value.(string); ok {
+			if resolved, ok := resolveAddress(s, base); ok {
+				target = resolved
+			}
+		}
+		if strings.HasSuffix(key, "/") && !strings.HasSuffix(target, "/") {
+			target = ""
+		}
+		entries = append(entries, entry{key: normalized, target: target})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key > entries[j].key })
+	return entries
+}
+
+// Resolve 按导入映射解析模块标识符。dir 为相对标识符的基准目录，
+// referrer 为发起导入的文件路径或远程模块 URL（为空时使用 dir），决定生效的 scopes。
+// 返回映射后的绝对路径或 URL；未匹配时返回 false，映射无效或被阻止时返回错误
+func (m *ImportMap) Resolve(specifier string, dir string, referrer string) (string, bool, error) {
+	if m == nil || specifier == "" {
+		return "", false, nil
+	}
+
+	base := toSlash(dir) + "/"
+	if referrer == "" {
+		referrer = base
+	} else if !isURL(referrer) {
+		referrer = toSlash(referrer)
+	}
+
+	// URL 形式的标识符先解析为绝对路径，再与映射的键比较
+	normalized := specifier
+	if isURLLike(specifier) || isURL(specifier) {
+		resolved, ok := resolveAddress(specifier, base)
+		if !ok {
+			return "", false, nil
+		}
+		normalized = resolved
+	}
+
+	for _, s := range m.scopes {
+		if s.prefix == referrer || strings.HasSuffix(s.prefix, "/") && strings.HasPrefix(referrer, s.prefix) {
+			if target, ok, err := s.imports.resolve(specifier, normalized); ok || err != nil {
+				return fromSlash(target), ok, err
+			}
+		}
+	}
+	target, ok, err := m.imports.resolve(specifier, normalized)
+	return fromSlash(target), ok, err
+}
+
+// resolve 在映射中查找精确匹配或最长的前缀匹配
+func (sm specifierMap) resolve(specifier string, normalized string) (string, bool, error) {
+	for _, e := range sm {
+		if e.key == normalized {
+			if e.target == "" {
+				return "", false, fmt.Errorf("import of %q is blocked by an invalid or null entry in the import map", specifier)
+			}
+			return e.target, true, nil
+		}
+		if !strings.HasSuffix(e.key, "/") || !strings.HasPrefix(normalized, e.key) {
+			continue
+		}
+		if e.target == "" {
+			return "", false, fmt.Errorf("import of %q is blocked by an invalid or null entry in the import map", specifier)
+		}
+		target, ok := joinPrefix(e.target, normalized[len(e.key):])
+		// 映射结果不能通过 ../ 跳出目标前缀
+		if !ok || !strings.HasPrefix(target, e.target) {
+			return "", false, fmt.Errorf("import of %q backtracks above its prefix %q in the import map", specifier, e.key)
+		}
+		return target, true, nil
+	}
+	return "", false, nil
+}
+
+// resolveAddress 将地址解析为绝对路径（使用 / 分隔）或 http(s) URL。
+// 只接受 /、./、../ 开头的相对地址与 file:、http:、https: URL，base 为以 / 结尾的基准目录
+func resolveAddress(address string, base string) (string, bool) {
+	if isURL(address) {
+		u, err := url.Parse(address)
+		if err != nil {
+			return "", false
+		}
+		switch strings.ToLower(u.Scheme) {
+		case "file":
+			return keepSlash(u.Path, address), true
+		case "http", "https":
+			return u.String(), true
+		}
+		return "", false
+	}
+	if strings.HasPrefix(address, "/") {
+		return keepSlash(cleanSlash(address), address), true
+	}
+	if isURLLike(address) {
+		return keepSlash(cleanSlash(base+address), address), true
+	}
+	return "", false
+}
+
+// joinPrefix 将前缀匹配后的剩余部分拼接到目标前缀（目录路径或 URL）之后
+func joinPrefix(prefix string, rest string) (string, bool) {
+	if isURL(prefix) {
+		u, err := url.Parse(prefix)
+		if err != nil {
+			return "", false
+		}
+		ref, err := url.Parse(rest)
+		if err != nil || ref.IsAbs() {
+			return "", false
+		}
+		return u.ResolveReference(ref).String(), true
+	}
+	return keepSlash(cleanSlash(prefix+rest), rest), true
+}
+
+// isURLLike 判断是否为 URL 形式的相对标识符
+func isURLLike(s string) bool {
+	return strings.HasPrefix(s, "/") || strings.HasPrefix(s, "./") || strings.HasPrefix(s, "../")
+}
+
+// isURL 判断是否为带协议的 URL（排除 Windows 盘符）
+func isURL(s string) bool {
+	i := strings.Index(s, "://")
+	return i > 1 || strings.HasPrefix(s, "file:")
+}
+
+// cleanSlash 规范化使用 / 分隔的路径
+func cleanSlash(p string) string {
+	return filepath.ToSlash(filepath.Clean(filepath.FromSlash(p)))
+}
+
+// keepSlash 保留原地址末尾的 /（目录前缀）
+func keepSlash(p string, original string) string {
+	if strings.HasSuffix(original, "/") && !strings.HasSuffix(p, "/") {
+		return p + "/"
+	}
+	return p
+}
+
+// toSlash 将目录转换为使用 / 分隔的绝对路径
+func toSlash(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return strings.TrimSuffix(filepath.ToSlash(dir), "/")
+}
+
+// fromSlash 将映射结果转换为本地路径，URL 保持不变
+func fromSlash(target string) string {
+	if target == "" || isURL(target) {
+		return target
+	}
+	return filepath.FromSlash(target)
+}
+
+// IsURL 判断映射结果是否为 URL（而不是本地路径）
+func IsURL(target string) bool {
+	return isURL(target)
+}
@@ -0,0 +1,620 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/cache"
+	"github.com/issueye/sw_runtime/internal/remote"
+	"github.com/issueye/sw_runtime/internal/tsconfig"
+
+	"github.com/dop251/goja"
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+const (
+	// awaitMarker 顶层 await 的占位符，与 "await" 等长，保证后续错误位置不偏移
+	awaitMarker = "~~~~~"
+	// forAwaitLabel 顶层 for await 的占位标签
+	forAwaitLabel = "__sw_for_await: "
+	// importFuncName 模块内动态 import 的替换函数名
+	importFuncName = "__sw_import"
+	// importMetaName 模块内 import.meta 的替换对象名
+	importMetaName = "__sw_import_meta"
+	// importRequireName 静态 import 转换后使用的加载函数名（按 import 条件解析）
+	importRequireName = "__sw_import_require"
+)
+
+var (
+	// esmSyntaxPattern 检测 import/export 语句或 import.meta
+	esmSyntaxPattern = regexp.MustCompile(`(?m)^[ \t]*(?:import[ \t]*(?:[\w$*{]|"|')|export[ \t]*(?:[\w$*{]))|\bimport\.meta\b`)
+	// esmDepPattern 提取 esbuild 转换后的顶层静态依赖
+	esmDepPattern = regexp.MustCompile(`(?m)^(?:var [\w$]+ = (?:__toESM\()?require\(("(?:[^"\\]|\\.)*")\)|__reExport\([\w$]+, require\(("(?:[^"\\]|\\.)*")\)|require\(("(?:[^"\\]|\\.)*")\);)`)
+	// esmLinkPattern esbuild 生成的导出对象赋值语句
+	esmLinkPattern = regexp.MustCompile(`(?m)^module\.exports = __toCommonJS\(`)
+	// asyncIterationPattern 检测 for await 与异步生成器
+	asyncIterationPattern = regexp.MustCompile(`\bfor\s+await\s*\(|\basync\s+function\s*\*|\basync\s*\*`)
+)
+
+// esmSupported 需要 esbuild 降级的语法（goja 尚不支持）
+var esmSupported = map[string]bool{
+	"async-generator": false,
+	"for-await":       false,
+}
+
+// compiledModule 表示编译后的 ES 模块
+type compiledModule struct {
+	Code  string   // CommonJS 形式的代码
+	Async bool     // 是否包含顶层 await
+	Deps  []string // 静态依赖，按出现顺序
+}
+
+// isESMFile 判断文件是否按 ES 模块处理
+func isESMFile(filename string, code string) bool {
+	switch extname(filename) {
+	case ".mjs", ".mts":
+		return true
+	case ".cjs", ".cts", ".json":
+		return false
+	}
+	return esmSyntaxPattern.MatchString(code)
+}
+
+// loaderFor 根据扩展名选择 esbuild loader
+func loaderFor(filename string) api.Loader {
+	switch extname(filename) {
+	case ".ts", ".mts", ".cts":
+		return api.LoaderTS
+	case ".tsx":
+		return api.LoaderTSX
+	case ".jsx":
+		return api.LoaderJSX
+	}
+	return api.LoaderJS
+}
+
+// compileESM 将 ES 模块转换为可在 goja 中执行的 CommonJS 代码，结果按源码内容缓存在磁盘上。
+// TypeScript 与 JSX 文件使用最近的 tsconfig.json 中的编译选项，远程模块使用默认编译选项
+func compileESM(code string, filename string) (*compiledModule, error) {
+	var config *tsconfig.Config
+	if loaderFor(filename) != api.LoaderJS && !remote.IsURL(filename) {
+		var err error
+		if config, err = tsconfig.Find(filepath.Dir(filename)); err != nil {
+			return nil, err
+		}
+	}
+
+	var compiled *compiledModule
+	data, err := cache.Default().Do("esm"+config.CacheKey(), filename, code, func() ([]byte, error) {
+		var err error
+		if compiled, err = transformESM(code, filename, config); err != nil {
+			return nil, err
+		}
+		return json.Marshal(compiled)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if compiled != nil {
+		return compiled, nil
+	}
+	compiled = &compiledModule{}
+	if err := json.Unmarshal(data, compiled); err != nil {
+		// 缓存内容损坏时重新转换
+		return transformESM(code, filename, config)
+	}
+	return compiled, nil
+}
+
+// transformESM 使用 esbuild 转换 ES 模块，config 为 nil 时使用默认编译选项
+// 顶层 await 先替换为占位符转换，再还原并在异步函数中二次降级
+func transformESM(code string, filename string, config *tsconfig.Config) (*compiledModule, error) {
+	options := api.TransformOptions{
+		Loader:     loaderFor(filename),
+		Target:     api.ES2020,
+		Format:     api.FormatCommonJS,
+		Sourcefile: filename,
+		Supported:  esmSupported,
+		Define:     map[string]string{"import.meta": importMetaName},
+		// 内联 source map 随模块缓存，异常位置映射回原始文件
+		Sourcemap:      api.SourceMapInline,
+		SourcesContent: api.SourcesContentExclude,
+	}
+	config.Apply(&options)
+
+	result := api.Transform(code, options)
+	async := false
+	if len(result.Errors) > 0 {
+		marked, ok := markTopLevelAwait(code, result.Errors)
+		if !ok {
+			return nil, fmt.Errorf("transpile error: %s", formatMessage(result.Errors[0]))
+		}
+		result = api.Transform(marked, options)
+		if len(result.Errors) > 0 {
+			return nil, fmt.Errorf("transpile error: %s", formatMessage(result.Errors[0]))
+		}
+		async = true
+	}
+
+	out := string(result.Code)
+	compiled := &compiledModule{
+		Async: async,
+		Deps:  extractDeps(out),
+	}
+
+	// 静态 import 生成的 require 改为独立函数，与用户代码中的 require 区分解析条件
+	out = esmDepPattern.ReplaceAllStringFunc(out, func(line string) string {
+		return strings.Replace(line, "require(", importRequireName+"(", 1)
+	})
+
+	// 让导出对象复用预先创建的 module.exports，保证循环依赖拿到的是同一个对象
+	out = esmLinkPattern.ReplaceAllLiteralString(out,
+		`__copyProps(__defProp(module.exports, "__esModule", { value: true }), `)
+	out = rewriteDynamicImport(out, importFuncName)
+
+	if async {
+		out = strings.ReplaceAll(out, awaitMarker, "await ")
+		out = strings.ReplaceAll(out, forAwaitLabel+"for (", "for await (")
+	}
+	compiled.Code = out
+	return compiled, nil
+}
+
+// lowerAsyncWrapper 对包含顶层 await 的模块包装函数做二次转换，
+// 降级 for await 等 goja 不支持的语法
+func lowerAsyncWrapper(code string, filename string) (string, error) {
+	data, err := cache.Default().Do("async-wrapper", filename, code, func() ([]byte, error) {
+		return transformAsyncWrapper(code, filename)
+	})
+	return string(data), err
+}
+
+// transformAsyncWrapper 使用 esbuild 降级顶层 await 模块的包装函数
+func transformAsyncWrapper(code string, filename string) ([]byte, error) {
+	result := api.Transform(code, api.TransformOptions{
+		Loader:     api.LoaderJS,
+		Target:     api.ES2020,
+		Sourcefile: filename,
+		Supported:  esmSupported,
+		// 与输入中的 source map 合并，保持映射到原始文件
+		Sourcemap:      api.SourceMapInline,
+		SourcesContent: api.SourcesContentExclude,
+	})
+	if len(result.Errors) > 0 {
+		return nil, fmt.Errorf("transpile error: %s", formatMessage(result.Errors[0]))
+	}
+	return result.Code, nil
+}
+
+// markTopLevelAwait 将 esbuild 报告的顶层 await 替换为占位符
+// 仅当所有错误都是顶层 await 时返回 true
+func markTopLevelAwait(code string, errors []api.Message) (string, bool) {
+	if strings.Contains(code, awaitMarker) || strings.Contains(code, strings.TrimSpace(forAwaitLabel)) {
+		return "", false
+	}
+
+	// 计算每行的起始偏移
+	lineStarts := []int{0}
+	for i := 0; i < len(code); i++ {
+		if code[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	offsets := make([]int, 0, len(errors))
+	for _, msg := range errors {
+		if !strings.HasPrefix(msg.Text, "Top-level await") || msg.Location == nil {
+			return "", false
+		}
+		line := msg.Location.Line - 1
+		if line < 0 || line >= len(lineStarts) {
+			return "", false
+		}
+		offset := lineStarts[line] + msg.Location.Column
+		if offset+5 > len(code) || code[offset:offset+5] != "await" {
+			return "", false
+		}
+		offsets = append(offsets, offset)
+	}
+
+	// 从后向前替换，避免偏移失效
+	sort.Sort(sort.Reverse(sort.IntSlice(offsets)))
+	for _, offset := range offsets {
+		before := strings.TrimRight(code[:offset], " \t\r\n")
+		if strings.HasSuffix(before, "for") {
+			// for await (...) -> 标签 + for (...)
+			forStart := len(before) - 3
+			rest := strings.TrimLeft(code[offset+5:], " \t\r\n")
+			code = code[:forStart] + forAwaitLabel + "for " + rest
+			continue
+		}
+		code = code[:offset] + awaitMarker + code[offset+5:]
+	}
+	return code, true
+}
+
+// extractDeps 提取转换后代码中的静态依赖
+func extractDeps(code string) []string {
+	var deps []string
+	seen := make(map[string]bool)
+	for _, match := range esmDepPattern.FindAllStringSubmatch(code, -1) {
+		for _, quoted := range match[1:] {
+			if quoted == "" {
+				continue
+			}
+			dep, err := strconv.Unquote(quoted)
+			if err != nil || seen[dep] {
+				continue
+			}
+			seen[dep] = true
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// formatMessage 格式化 esbuild 错误信息
+func formatMessage(msg api.Message) string {
+	if msg.Location == nil {
+		return msg.Text
+	}
+	return fmt.Sprintf("%s (%s:%d:%d)", msg.Text, msg.Location.File, msg.Location.Line, msg.Location.Column)
+}
+
+// RewriteDynamicImport 将脚本中的 import(...) 调用改写为指定函数调用
+// goja 不支持动态 import 语法，需要在执行前替换
+func RewriteDynamicImport(code string, replacement string) string {
+	return rewriteDynamicImport(code, replacement)
+}
+
+// rewriteDynamicImport 词法扫描代码，跳过字符串、模板、注释和正则，
+// 将独立的 import( 替换为 replacement(
+func rewriteDynamicImport(code string, replacement string) string {
+	if !strings.Contains(code, "import") {
+		return code
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(code))
+
+	// 模板字符串中 ${ 的嵌套深度栈
+	var templateDepth []int
+	braceDepth := 0
+	// 上一个有效记号，用于区分除号与正则
+	lastToken := ""
+	regexAllowedAfter := map[string]bool{
+		"return": true, "typeof": true, "instanceof": true, "in": true, "of": true,
+		"new": true, "delete": true, "void": true, "throw": true, "case": true,
+		"do": true, "else": true, "yield": true, "await": true,
+	}
+
+	n := len(code)
+	i := 0
+	for i < n {
+		c := code[i]
+		switch {
+		case c == '/' && i+1 < n && code[i+1] == '/':
+			end := strings.IndexByte(code[i:], '\n')
+			if end < 0 {
+				end = n - i
+			}
+			sb.WriteString(code[i : i+end])
+			i += end
+		case c == '/' && i+1 < n && code[i+1] == '*':
+			end := strings.Index(code[i+2:], "*/")
+			if end < 0 {
+				end = n - i - 2
+			} else {
+				end += 2
+			}
+			sb.WriteString(code[i : i+2+end])
+			i += 2 + end
+		case c == '"' || c == '\'':
+			j := skipString(code, i, c)
+			sb.WriteString(code[i:j])
+			i = j
+			lastToken = "str"
+		case c == '`':
+			j := skipTemplate(code, i+1)
+			sb.WriteString(code[i:j])
+			if j > 1 && strings.HasSuffix(code[:j], "${") {
+				templateDepth = append(templateDepth, braceDepth)
+				braceDepth++
+				lastToken = "{"
+			} else {
+				lastToken = "str"
+			}
+			i = j
+		case c == '}' && len(templateDepth) > 0 && templateDepth[len(templateDepth)-1] == braceDepth-1:
+			// 模板插值结束，继续扫描模板剩余部分
+			templateDepth = templateDepth[:len(templateDepth)-1]
+			braceDepth--
+			j := skipTemplate(code, i+1)
+			sb.WriteString(code[i:j])
+			if strings.HasSuffix(code[:j], "${") {
+				templateDepth = append(templateDepth, braceDepth)
+				braceDepth++
+				lastToken = "{"
+			} else {
+				lastToken = "str"
+			}
+			i = j
+		case c == '/':
+			if isIdentToken(lastToken) && !regexAllowedAfter[lastToken] || lastToken == ")" || lastToken == "]" || lastToken == "}" || lastToken == "str" {
+				sb.WriteByte(c)
+				i++
+				lastToken = "/"
+				continue
+			}
+			j := skipRegexp(code, i)
+			sb.WriteString(code[i:j])
+			i = j
+			lastToken = "str"
+		case isIdentStart(c):
+			j := i + 1
+			for j < n && isIdentPart(code[j]) {
+				j++
+			}
+			word := code[i:j]
+			if word == "import" && lastToken != "." {
+				k := j
+				for k < n && (code[k] == ' ' || code[k] == '\t' || code[k] == '\n' || code[k] == '\r') {
+					k++
+				}
+				if k < n && code[k] == '(' {
+					sb.WriteString(replacement)
+					i = j
+					lastToken = word
+					continue
+				}
+			}
+			sb.WriteString(word)
+			i = j
+			lastToken = word
+		case c >= '0' && c <= '9':
+			j := i + 1
+			for j < n && (isIdentPart(code[j]) || code[j] == '.') {
+				j++
+			}
+			sb.WriteString(code[i:j])
+			i = j
+			lastToken = "num"
+		default:
+			switch c {
+			case '{':
+				braceDepth++
+			case '}':
+				braceDepth--
+			}
+			sb.WriteByte(c)
+			i++
+			if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+				lastToken = string(c)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// skipString 跳过引号字符串，返回结束位置
+func skipString(code string, i int, quote byte) int {
+	j := i + 1
+	for j < len(code) {
+		switch code[j] {
+		case '\\':
+			j += 2
+			continue
+		case quote, '\n':
+			return j + 1
+		}
+		j++
+	}
+	return len(code)
+}
+
+// skipTemplate 跳过模板字符串片段，遇到反引号或 ${ 时停止
+func skipTemplate(code string, j int) int {
+	for j < len(code) {
+		switch code[j] {
+		case '\\':
+			j += 2
+			continue
+		case '`':
+			return j + 1
+		case '$':
+			if j+1 < len(code) && code[j+1] == '{' {
+				return j + 2
+			}
+		}
+		j++
+	}
+	return len(code)
+}
+
+// skipRegexp 跳过正则字面量，返回结束位置
+func skipRegexp(code string, i int) int {
+	j := i + 1
+	inClass := false
+	for j < len(code) {
+		switch code[j] {
+		case '\\':
+			j += 2
+			continue
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '\n':
+			return j
+		case '/':
+			if !inClass {
+				j++
+				for j < len(code) && isIdentPart(code[j]) {
+					j++
+				}
+				return j
+			}
+		}
+		j++
+	}
+	return len(code)
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9'
+}
+
+func isIdentToken(token string) bool {
+	return token != "" && isIdentStart(token[0])
+}
+
+// esmHelpers JS 侧辅助函数，用于串联异步模块求值
+const esmHelpersSource = `({
+	evaluate: function(deps, body, done) {
+		return Promise.all(deps).then(function() { return body(); }).then(function() { done(); });
+	},
+	then: function(p, get) {
+		return p.then(function() { return get(); });
+	}
+})`
+
+// helpers 懒加载 JS 辅助函数
+func (ms *System) helpers() *goja.Object {
+	if ms.esmHelpers == nil {
+		value, err := ms.vm.RunString(esmHelpersSource)
+		if err != nil {
+			panic(err)
+		}
+		ms.esmHelpers = value.ToObject(ms.vm)
+	}
+	return ms.esmHelpers
+}
+
+// callHelper 调用 JS 辅助函数
+func (ms *System) callHelper(name string, args ...goja.Value) (goja.Value, error) {
+	helpers := ms.helpers()
+	fn, _ := goja.AssertFunction(helpers.Get(name))
+	return fn(helpers, args...)
+}
+
+// pending 判断模块是否仍在异步求值中
+func (m *Module) pending() bool {
+	return m.evaluation != nil && m.evaluation.State() == goja.PromiseStatePending
+}
+
+// checkEvaluated 检查模块求值状态，供 require 同步访问时使用
+func (ms *System) checkEvaluated(module *Module) error {
+	if module.evaluation == nil {
+		return nil
+	}
+	switch module.evaluation.State() {
+	case goja.PromiseStatePending:
+		if module.Async {
+			return fmt.Errorf("cannot require() ES module %s with pending top-level await, use import() instead", module.Filename)
+		}
+	case goja.PromiseStateRejected:
+		return fmt.Errorf("module %s failed to evaluate: %v", module.Filename, module.evaluation.Result())
+	}
+	return nil
+}
+
+// namespaceOf 返回动态 import 得到的模块命名空间对象
+// CommonJS 模块的 module.exports 作为 default 导出，同时拷贝其属性
+func (ms *System) namespaceOf(module *Module) goja.Value {
+	exports := module.Exports
+	if module.IsESM {
+		return exports
+	}
+	if esModule := exports.Get("__esModule"); esModule != nil && esModule.ToBoolean() {
+		return exports
+	}
+	ns := ms.vm.NewObject()
+	for _, key := range exports.Keys() {
+		ns.Set(key, exports.Get(key))
+	}
+	ns.Set("default", exports)
+	return ns
+}
+
+// Import 实现动态 import()，返回模块命名空间的 Promise
+// 异步模块会等待其顶层 await 完成后再 resolve
+func (ms *System) Import(id string, parentPath string) goja.Value {
+	promise, resolve, reject := ms.vm.NewPromise()
+
+	module, err := ms.loadModule(id, parentPath, importConditions)
+	if err != nil {
+		reject(types.NewPermissionError(ms.vm, err))
+		return ms.vm.ToValue(promise)
+	}
+
+	if module.evaluation == nil {
+		resolve(ms.namespaceOf(module))
+		return ms.vm.ToValue(promise)
+	}
+
+	result, err := ms.callHelper("then", ms.vm.ToValue(module.evaluation), ms.vm.ToValue(func() goja.Value {
+		return ms.namespaceOf(module)
+	}))
+	if err != nil {
+		reject(ms.vm.NewGoError(err))
+		return ms.vm.ToValue(promise)
+	}
+	return result
+}
+
+// LoadMain 以入口模块方式加载文件（require.main），返回模块记录
+// 调用方可通过 Evaluation 等待顶层 await 完成
+func (ms *System) LoadMain(filename string) (*Module, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	ms.mainFile, ms.main = abs, nil
+	return ms.LoadModule(abs, "")
+}
+
+// Evaluation 返回模块的异步求值 Promise，同步模块返回 nil
+func (m *Module) Evaluation() *goja.Promise {
+	return m.evaluation
+}
+
+// LowerAsyncIteration 将 CommonJS 脚本中的 for await 与异步生成器降级为 goja 支持的语法，
+// 不包含这些语法或转换失败时原样返回（由 goja 报告语法错误）
+func LowerAsyncIteration(code string, filename string) string {
+	if !asyncIterationPattern.MatchString(code) {
+		return code
+	}
+	data, err := cache.Default().Do("lower", filename, code, func() ([]byte, error) {
+		result := api.Transform(code, api.TransformOptions{
+			Loader:         api.LoaderJS,
+			Target:         api.ES2020,
+			Sourcefile:     filename,
+			Supported:      esmSupported,
+			Sourcemap:      api.SourceMapInline,
+			SourcesContent: api.SourcesContentExclude,
+		})
+		if len(result.Errors) > 0 {
+			return nil, fmt.Errorf("transpile error: %s", formatMessage(result.Errors[0]))
+		}
+		return result.Code, nil
+	})
+	if err != nil {
+		return code
+	}
+	return string(data)
+}
+
+// IsESMSource 判断文件内容是否为 ES 模块
+func IsESMSource(filename string, code string) bool {
+	return isESMFile(filename, code)
+}
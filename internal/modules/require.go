@@ -0,0 +1,264 @@
+package modules
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/remote"
+
+	"github.com/dop251/goja"
+)
+
+// newModuleObject 创建模块代码中的 module 对象。require.main、require.cache、
+// module.parent 与 module.children 引用同一个对象
+func (ms *System) newModuleObject(module *Module, parent *Module) {
+	obj := ms.vm.NewObject()
+	id := module.ID
+	if module.Filename == ms.mainFile && ms.main == nil {
+		ms.main = module
+		id = "."
+	}
+	obj.Set("id", id)
+	obj.Set("filename", module.Filename)
+	obj.Set("exports", module.Exports)
+	obj.Set("loaded", false)
+	obj.Set("children", ms.vm.NewArray())
+	obj.Set("paths", ms.modulePaths(module.Filename))
+	if parent != nil && parent.object != nil {
+		obj.Set("parent", parent.object)
+	} else {
+		obj.Set("parent", goja.Null())
+	}
+	module.object = obj
+}
+
+// NewMain 为以普通脚本方式执行的入口文件创建模块记录，作为 require.main 并加入模块缓存。
+// 调用方将返回模块的 Object、Exports 与 NewRequire(module) 作为脚本的 module、exports 与 require
+func (ms *System) NewMain(filename string) *Module {
+	module := &Module{
+		ID:       filename,
+		Filename: filename,
+		Exports:  ms.vm.NewObject(),
+	}
+	ms.mainFile = filename
+	ms.main = nil
+	ms.newModuleObject(module, nil)
+
+	ms.mu.Lock()
+	ms.cache[filename] = module
+	ms.mu.Unlock()
+	return module
+}
+
+// Object 返回模块代码中的 module 对象，内置模块返回 nil
+func (m *Module) Object() *goja.Object {
+	return m.object
+}
+
+// cachedModule 返回模块缓存中的模块，不存在时返回 nil
+func (ms *System) cachedModule(path string) *Module {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.cache[path]
+}
+
+// addChild 将 child 记录到 parent 的 module.children，已记录的模块不重复添加
+func (ms *System) addChild(parent *Module, child *Module) {
+	if parent == nil || parent.object == nil || child.object == nil || parent == child {
+		return
+	}
+	for _, filename := range parent.Children {
+		if filename == child.Filename {
+			return
+		}
+	}
+	parent.Children = append(parent.Children, child.Filename)
+
+	children := parent.object.Get("children")
+	if obj, ok := children.(*goja.Object); ok {
+		if push, ok := goja.AssertFunction(obj.Get("push")); ok {
+			push(obj, child.object)
+		}
+	}
+}
+
+// modulePaths 返回模块查找 npm 包时依次搜索的 node_modules 目录（module.paths），远程模块为空
+func (ms *System) modulePaths(filename string) []string {
+	if remote.IsURL(filename) {
+		return []string{}
+	}
+	return ms.nodeModulesPaths(filepath.Dir(filename))
+}
+
+// NewRequire 创建模块的 require 函数，module 为 nil 时以 basePath 为基准（全局 require）
+func (ms *System) NewRequire(module *Module) *goja.Object {
+	return ms.newRequire(module, requireConditions)
+}
+
+// newRequire 创建 require 函数及其 resolve、cache、main 属性，conditions 决定 exports 条件匹配
+func (ms *System) newRequire(module *Module, conditions []string) *goja.Object {
+	parentPath := ms.basePath
+	if module != nil {
+		parentPath = module.Filename
+	}
+
+	require := ms.vm.ToValue(func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) == 0 {
+			panic(ms.vm.NewTypeError("require() missing path"))
+		}
+
+		requiredModule, err := ms.loadModule(call.Arguments[0].String(), parentPath, conditions)
+		if err != nil {
+			panic(types.NewPermissionError(ms.vm, err))
+		}
+		if err := ms.checkEvaluated(requiredModule); err != nil {
+			panic(ms.vm.NewGoError(err))
+		}
+
+		return requiredModule.Exports
+	}).(*goja.Object)
+
+	resolve := ms.vm.ToValue(func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) == 0 {
+			panic(ms.vm.NewTypeError("require.resolve() missing path"))
+		}
+		resolved, err := ms.resolveFrom(call.Arguments[0].String(), parentPath, call.Argument(1), conditions)
+		if err != nil {
+			panic(ms.vm.NewGoError(err))
+		}
+		return ms.vm.ToValue(resolved)
+	}).(*goja.Object)
+	resolve.Set("paths", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) == 0 {
+			panic(ms.vm.NewTypeError("require.resolve.paths() missing path"))
+		}
+		return ms.lookupPaths(call.Arguments[0].String(), parentPath)
+	})
+
+	require.Set("resolve", resolve)
+	require.Set("cache", ms.requireCacheObject())
+	require.DefineAccessorProperty("main", ms.vm.ToValue(func(goja.FunctionCall) goja.Value {
+		if ms.main == nil || ms.main.object == nil {
+			return goja.Undefined()
+		}
+		return ms.main.object
+	}), nil, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	return require
+}
+
+// resolveFrom 实现 require.resolve(id, { paths })：指定 paths 时依次以其中的目录为基准解析，
+// 相对目录基于 basePath
+func (ms *System) resolveFrom(id string, parentPath string, options goja.Value, conditions []string) (string, error) {
+	bases := []string{parentPath}
+	if opts, ok := options.(*goja.Object); ok {
+		if paths := opts.Get("paths"); paths != nil && !goja.IsUndefined(paths) && !goja.IsNull(paths) {
+			var list []string
+			if err := ms.vm.ExportTo(paths, &list); err != nil {
+				panic(ms.vm.NewTypeError("require.resolve() options.paths must be an array of strings"))
+			}
+			bases = bases[:0]
+			for _, dir := range list {
+				if !filepath.IsAbs(dir) && !remote.IsURL(dir) {
+					dir = filepath.Join(ms.basePath, dir)
+				}
+				bases = append(bases, dir)
+			}
+		}
+	}
+
+	err := fmt.Errorf("module not found: %s", id)
+	for _, base := range bases {
+		resolved, resolveErr := ms.resolveModuleWithConditions(id, base, conditions)
+		if resolveErr == nil {
+			return resolved, nil
+		}
+		err = resolveErr
+	}
+	return "", err
+}
+
+// lookupPaths 实现 require.resolve.paths(id)：内置模块返回 null，相对路径返回基准目录，
+// 其他标识符返回依次搜索的 node_modules 目录
+func (ms *System) lookupPaths(id string, parentPath string) goja.Value {
+	if ms.builtinManager.HasModule(id) || remote.IsURL(parentPath) {
+		return goja.Null()
+	}
+	dir := ms.parentDir(parentPath)
+	if strings.HasPrefix(id, "./") || strings.HasPrefix(id, "../") || id == "." || id == ".." {
+		return ms.vm.ToValue([]string{dir})
+	}
+	return ms.vm.ToValue(ms.nodeModulesPaths(dir))
+}
+
+// requireCacheObject 返回 require.cache 对象，所有模块的 require 共享同一个对象
+func (ms *System) requireCacheObject() *goja.Object {
+	if ms.requireCache == nil {
+		ms.requireCache = ms.vm.NewDynamicObject(&requireCache{ms: ms})
+	}
+	return ms.requireCache
+}
+
+// requireCache require.cache 的实现：以文件名为键访问模块缓存中的 module 对象（不含内置模块），
+// 删除条目后再次 require 会重新加载模块；赋值的对象按已加载的模块处理
+type requireCache struct {
+	ms *System
+}
+
+func (c *requireCache) Get(key string) goja.Value {
+	if module := c.ms.cachedModule(key); module != nil && module.object != nil {
+		return module.object
+	}
+	return nil
+}
+
+func (c *requireCache) Set(key string, val goja.Value) bool {
+	obj, ok := val.(*goja.Object)
+	if !ok {
+		return false
+	}
+	exports := c.ms.vm.NewObject()
+	if value := obj.Get("exports"); value != nil && !goja.IsUndefined(value) && !goja.IsNull(value) {
+		exports = value.ToObject(c.ms.vm)
+	}
+	module := &Module{
+		ID:       key,
+		Filename: key,
+		Exports:  exports,
+		Loaded:   true,
+		object:   obj,
+	}
+
+	c.ms.mu.Lock()
+	c.ms.cache[key] = module
+	c.ms.mu.Unlock()
+	return true
+}
+
+func (c *requireCache) Has(key string) bool {
+	return c.Get(key) != nil
+}
+
+func (c *requireCache) Delete(key string) bool {
+	c.ms.mu.Lock()
+	defer c.ms.mu.Unlock()
+	if module, ok := c.ms.cache[key]; ok && module.object != nil {
+		delete(c.ms.cache, key)
+	}
+	return true
+}
+
+func (c *requireCache) Keys() []string {
+	c.ms.mu.RLock()
+	defer c.ms.mu.RUnlock()
+	keys := make([]string, 0, len(c.ms.cache))
+	for key, module := range c.ms.cache {
+		if module.object != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,586 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/issueye/sw_runtime/internal/remote"
+	"github.com/issueye/sw_runtime/internal/tsconfig"
+)
+
+var (
+	// requireConditions require() 使用的条件导出
+	requireConditions = []string{"require", "node", "default"}
+	// importConditions import 使用的条件导出
+	importConditions = []string{"import", "node", "default"}
+
+	// fileExtensions 省略扩展名时依次尝试的扩展名
+	fileExtensions = []string{".js", ".ts", ".json", ".mjs", ".cjs", ".tsx", ".mts", ".cts"}
+	// indexFiles 目录模块依次尝试的入口文件
+	indexFiles = []string{"index.js", "index.ts", "index.json", "index.mjs", "index.cjs"}
+)
+
+// jsonObject 保持键顺序的 JSON 对象，条件导出依赖声明顺序
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// packageJSON 表示解析后的 package.json
+type packageJSON struct {
+	dir     string
+	name    string
+	main    string
+	module  string
+	typ     string
+	exports interface{}
+	imports interface{}
+}
+
+// packageFile 返回 package.json 的完整路径
+func (p *packageJSON) packageFile() string {
+	return filepath.Join(p.dir, "package.json")
+}
+
+// decodeOrderedJSON 解码 JSON，对象保留键顺序
+func decodeOrderedJSON(dec *json.Decoder) (interface{}, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			obj := &jsonObject{values: make(map[string]interface{})}
+			for dec.More() {
+				keyToken, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, _ := keyToken.(string)
+				value, err := decodeOrderedJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				if _, exists := obj.values[key]; !exists {
+					obj.keys = append(obj.keys, key)
+				}
+				obj.values[key] = value
+			}
+			_, err := dec.Token()
+			return obj, err
+		case '[':
+			var arr []interface{}
+			for dec.More() {
+				value, err := decodeOrderedJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, value)
+			}
+			_, err := dec.Token()
+			return arr, err
+		}
+	}
+	return token, nil
+}
+
+// readPackageJSON 读取并缓存目录下的 package.json，不存在或无效时返回 nil
+func (ms *System) readPackageJSON(dir string) *packageJSON {
+	ms.mu.RLock()
+	pkg, cached := ms.packages[dir]
+	ms.mu.RUnlock()
+	if cached {
+		return pkg
+	}
+
+	pkg = nil
+	if data, err := os.ReadFile(filepath.Join(dir, "package.json")); err == nil {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if value, err := decodeOrderedJSON(dec); err == nil {
+			if obj, ok := value.(*jsonObject); ok {
+				pkg = &packageJSON{
+					dir:     dir,
+					exports: obj.values["exports"],
+					imports: obj.values["imports"],
+				}
+				pkg.name, _ = obj.values["name"].(string)
+				pkg.main, _ = obj.values["main"].(string)
+				pkg.module, _ = obj.values["module"].(string)
+				pkg.typ, _ = obj.values["type"].(string)
+			}
+		}
+	}
+
+	ms.mu.Lock()
+	ms.packages[dir] = pkg
+	ms.mu.Unlock()
+	return pkg
+}
+
+// findPackageScope 从路径向上查找最近的 package.json
+func (ms *System) findPackageScope(path string) *packageJSON {
+	dir := path
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		dir = filepath.Dir(path)
+	}
+	for {
+		if filepath.Base(dir) == "node_modules" {
+			return nil
+		}
+		if pkg := ms.readPackageJSON(dir); pkg != nil {
+			return pkg
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil
+		}
+		dir = parent
+	}
+}
+
+// isModuleType 判断文件所在包是否声明了 "type": "module"，远程模块不属于任何本地包
+func (ms *System) isModuleType(filename string) bool {
+	if remote.IsURL(filename) {
+		return false
+	}
+	pkg := ms.findPackageScope(filename)
+	return pkg != nil && pkg.typ == "module"
+}
+
+// resolveRemote 解析远程模块中的导入：相对标识符以模块 URL 为基准，
+// 裸标识符只能通过导入映射指向其他 URL，远程模块不能导入本地文件
+func (ms *System) resolveRemote(id string, parentURL string) (string, error) {
+	specifier := id
+	if remote.IsRelative(id) {
+		resolved, err := remote.Resolve(parentURL, id)
+		if err != nil {
+			return "", err
+		}
+		specifier = resolved
+	}
+
+	if target, ok, err := ms.importMap.Resolve(specifier, ms.basePath, parentURL); err != nil {
+		return "", err
+	} else if ok {
+		specifier = target
+	}
+
+	if remote.IsURL(specifier) {
+		return specifier, nil
+	}
+	if specifier != id {
+		return "", fmt.Errorf("remote module %s cannot import local file %s", parentURL, specifier)
+	}
+	return "", fmt.Errorf("module not found: %s (imported by remote module %s; map it to a URL in the import map)", id, parentURL)
+}
+
+// extname 返回模块文件名的扩展名，远程模块取 URL 路径的扩展名
+func extname(filename string) string {
+	if remote.IsURL(filename) {
+		return remote.Ext(filename)
+	}
+	return filepath.Ext(filename)
+}
+
+// dirname 返回模块所在目录，远程模块返回上一级 URL
+func dirname(filename string) string {
+	if remote.IsURL(filename) {
+		return remote.Dir(filename)
+	}
+	return filepath.Dir(filename)
+}
+
+// parentDir 获取解析相对路径时使用的目录
+func (ms *System) parentDir(parentPath string) string {
+	if parentPath == "" {
+		return ms.basePath
+	}
+	if info, err := os.Stat(parentPath); err == nil {
+		if info.IsDir() {
+			return parentPath
+		}
+		return filepath.Dir(parentPath)
+	}
+	// 路径不存在时，按是否带扩展名判断文件或目录
+	if filepath.Ext(parentPath) != "" {
+		return filepath.Dir(parentPath)
+	}
+	return parentPath
+}
+
+// isFile 判断路径是否为普通文件
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// isDir 判断路径是否为目录
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// absPath 返回绝对路径
+func absPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return abs
+}
+
+// resolveAsFile 按文件解析，依次尝试原路径和补全扩展名
+func resolveAsFile(path string) (string, bool) {
+	if isFile(path) {
+		return absPath(path), true
+	}
+	for _, ext := range fileExtensions {
+		if isFile(path + ext) {
+			return absPath(path + ext), true
+		}
+	}
+	return "", false
+}
+
+// resolveAsDirectory 按目录解析，优先使用 package.json 的 main/module 字段
+func (ms *System) resolveAsDirectory(dir string, conditions []string) (string, bool) {
+	if !isDir(dir) {
+		return "", false
+	}
+
+	if pkg := ms.readPackageJSON(dir); pkg != nil {
+		entries := []string{pkg.main}
+		if hasCondition(conditions, "import") && pkg.module != "" {
+			entries = []string{pkg.module, pkg.main}
+		}
+		for _, entry := range entries {
+			if entry == "" {
+				continue
+			}
+			target := filepath.Join(dir, entry)
+			if resolved, ok := resolveAsFile(target); ok {
+				return resolved, true
+			}
+			if resolved, ok := resolveIndex(target); ok {
+				return resolved, true
+			}
+		}
+	}
+
+	return resolveIndex(dir)
+}
+
+// resolveIndex 尝试目录下的 index 文件
+func resolveIndex(dir string) (string, bool) {
+	for _, index := range indexFiles {
+		if path := filepath.Join(dir, index); isFile(path) {
+			return absPath(path), true
+		}
+	}
+	return "", false
+}
+
+// resolvePath 将路径解析为文件或目录模块
+func (ms *System) resolvePath(path string, conditions []string) (string, bool) {
+	if resolved, ok := resolveAsFile(path); ok {
+		return resolved, true
+	}
+	return ms.resolveAsDirectory(path, conditions)
+}
+
+// resolveAlias 按最近的 tsconfig.json 中的 paths 与 baseUrl 解析非相对标识符
+func (ms *System) resolveAlias(id string, dir string, conditions []string) (string, bool) {
+	config, _ := tsconfig.Find(dir)
+	for _, candidate := range config.Candidates(id) {
+		if resolved, ok := ms.resolvePath(candidate, conditions); ok {
+			return resolved, true
+		}
+	}
+	return "", false
+}
+
+// splitPackageName 拆分包名与子路径，支持 @scope/name 形式
+func splitPackageName(id string) (name string, subpath string) {
+	parts := strings.SplitN(id, "/", 3)
+	if strings.HasPrefix(id, "@") && len(parts) >= 2 {
+		name = parts[0] + "/" + parts[1]
+		if len(parts) == 3 {
+			subpath = parts[2]
+		}
+		return name, subpath
+	}
+	parts = strings.SplitN(id, "/", 2)
+	name = parts[0]
+	if len(parts) == 2 {
+		subpath = parts[1]
+	}
+	return name, subpath
+}
+
+// nodeModulesPaths 返回从目录向上逐级查找的 node_modules 路径
+func (ms *System) nodeModulesPaths(dir string) []string {
+	var paths []string
+	seen := make(map[string]bool)
+	for {
+		if filepath.Base(dir) != "node_modules" {
+			path := filepath.Join(dir, "node_modules")
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	for _, path := range ms.nodeModules {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// resolvePackage 解析裸模块标识符（npm 包）
+func (ms *System) resolvePackage(id string, dir string, conditions []string) (string, error) {
+	name, subpath := splitPackageName(id)
+
+	for _, nodeModulesPath := range ms.nodeModulesPaths(dir) {
+		pkgDir := filepath.Join(nodeModulesPath, name)
+		if !isDir(pkgDir) {
+			// 兼容 node_modules 下的单文件模块
+			if subpath == "" {
+				if resolved, ok := resolveAsFile(pkgDir); ok {
+					return resolved, nil
+				}
+			}
+			continue
+		}
+
+		pkg := ms.readPackageJSON(pkgDir)
+		if pkg != nil && pkg.exports != nil {
+			return ms.resolvePackageExports(pkg, "./"+subpath, conditions)
+		}
+
+		if subpath == "" {
+			if resolved, ok := ms.resolveAsDirectory(pkgDir, conditions); ok {
+				return resolved, nil
+			}
+			continue
+		}
+		if resolved, ok := ms.resolvePath(filepath.Join(pkgDir, subpath), conditions); ok {
+			return resolved, nil
+		}
+	}
+
+	return "", fmt.Errorf("module not found: %s", id)
+}
+
+// resolvePackageExports 按 package.json 的 exports 字段解析子路径
+func (ms *System) resolvePackageExports(pkg *packageJSON, subpath string, conditions []string) (string, error) {
+	if subpath == "./" {
+		subpath = "."
+	}
+
+	exports := pkg.exports
+	// exports 为字符串、数组或条件对象时，等价于 {".": exports}
+	if obj, ok := exports.(*jsonObject); !ok || !isSubpathMap(obj) {
+		exports = &jsonObject{keys: []string{"."}, values: map[string]interface{}{".": exports}}
+	}
+
+	target, matched := matchSubpath(exports.(*jsonObject), subpath)
+	if matched {
+		if resolved, ok, err := ms.resolveTarget(pkg, target.value, target.pattern, conditions, false); err != nil {
+			return "", err
+		} else if ok {
+			return resolved, nil
+		}
+	}
+
+	return "", fmt.Errorf("package subpath '%s' is not defined by \"exports\" in %s", subpath, pkg.packageFile())
+}
+
+// resolvePackageImports 按最近 package.json 的 imports 字段解析 #internal 标识符
+func (ms *System) resolvePackageImports(id string, dir string, conditions []string) (string, error) {
+	pkg := ms.findPackageScope(dir)
+	if pkg == nil {
+		return "", fmt.Errorf("module not found: %s (no package.json with \"imports\")", id)
+	}
+
+	if imports, ok := pkg.imports.(*jsonObject); ok {
+		if target, matched := matchSubpath(imports, id); matched {
+			if resolved, ok, err := ms.resolveTarget(pkg, target.value, target.pattern, conditions, true); err != nil {
+				return "", err
+			} else if ok {
+				return resolved, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("package import '%s' is not defined by \"imports\" in %s", id, pkg.packageFile())
+}
+
+// isSubpathMap 判断 exports 对象是否为子路径映射（键以 "." 开头）
+func isSubpathMap(obj *jsonObject) bool {
+	for _, key := range obj.keys {
+		if strings.HasPrefix(key, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// subpathMatch 子路径匹配结果
+type subpathMatch struct {
+	value   interface{}
+	pattern string // 通配符 * 匹配到的内容
+}
+
+// matchSubpath 在 exports/imports 映射中查找子路径，支持精确匹配、* 模式和目录前缀
+func matchSubpath(mapping *jsonObject, subpath string) (subpathMatch, bool) {
+	if value, ok := mapping.values[subpath]; ok && !strings.Contains(subpath, "*") {
+		return subpathMatch{value: value}, true
+	}
+
+	// 按前缀长度降序匹配模式键，与 Node 的 PATTERN_KEY_COMPARE 一致
+	keys := append([]string(nil), mapping.keys...)
+	sort.SliceStable(keys, func(i, j int) bool {
+		return patternPrefixLen(keys[i]) > patternPrefixLen(keys[j])
+	})
+
+	for _, key := range keys {
+		star := strings.Index(key, "*")
+		if star >= 0 {
+			prefix, suffix := key[:star], key[star+1:]
+			if strings.HasPrefix(subpath, prefix) && strings.HasSuffix(subpath, suffix) &&
+				len(subpath) >= len(prefix)+len(suffix) && subpath != prefix {
+				return subpathMatch{value: mapping.values[key], pattern: subpath[len(prefix) : len(subpath)-len(suffix)]}, true
+			}
+			continue
+		}
+		// 旧式目录映射 "./dir/": "./lib/"
+		if strings.HasSuffix(key, "/") && strings.HasPrefix(subpath, key) {
+			return subpathMatch{value: mapping.values[key], pattern: "\x00" + subpath[len(key):]}, true
+		}
+	}
+	return subpathMatch{}, false
+}
+
+// patternPrefixLen 返回模式键中 * 之前的长度，无通配符的键视为其全长
+func patternPrefixLen(key string) int {
+	if star := strings.Index(key, "*"); star >= 0 {
+		return star
+	}
+	return len(key)
+}
+
+// resolveTarget 解析 exports/imports 的目标值（字符串、数组或条件对象）。
+// 与 Node 一致，目标必须以 ./ 开头并留在包目录内，包含 ..、. 或 node_modules 段的目标视为无效
+func (ms *System) resolveTarget(pkg *packageJSON, target interface{}, pattern string, conditions []string, allowBare bool) (string, bool, error) {
+	switch t := target.(type) {
+	case string:
+		if !strings.HasPrefix(t, "./") {
+			if allowBare && !strings.HasPrefix(t, "../") && !strings.HasPrefix(t, "/") && !filepath.IsAbs(t) && !strings.Contains(t, ":") {
+				if strings.HasPrefix(pattern, "\x00") {
+					t += pattern[1:]
+				} else if pattern != "" {
+					t = strings.ReplaceAll(t, "*", pattern)
+				}
+				resolved, err := ms.resolvePackage(t, pkg.dir, conditions)
+				return resolved, err == nil, nil
+			}
+			return "", false, invalidTargetError(pkg, t)
+		}
+		if hasInvalidSegment(t[2:]) {
+			return "", false, invalidTargetError(pkg, t)
+		}
+
+		// 通配符与目录前缀匹配到的部分同样不能包含无效段
+		if strings.HasPrefix(pattern, "\x00") {
+			if hasInvalidSegment(pattern[1:]) {
+				return "", false, invalidTargetError(pkg, t+pattern[1:])
+			}
+			t += pattern[1:]
+		} else if pattern != "" {
+			if hasInvalidSegment(pattern) {
+				return "", false, invalidTargetError(pkg, strings.ReplaceAll(t, "*", pattern))
+			}
+			t = strings.ReplaceAll(t, "*", pattern)
+		}
+
+		path := filepath.Join(pkg.dir, t)
+		if !isInside(pkg.dir, path) {
+			return "", false, invalidTargetError(pkg, t)
+		}
+		if isFile(path) {
+			return absPath(path), true, nil
+		}
+		return "", false, nil
+	case []interface{}:
+		// 数组中无效的目标被跳过，全部失败时报告最后一个错误
+		var lastErr error
+		for _, item := range t {
+			resolved, ok, err := ms.resolveTarget(pkg, item, pattern, conditions, allowBare)
+			if ok {
+				return resolved, true, nil
+			}
+			if err != nil {
+				lastErr = err
+			}
+		}
+		return "", false, lastErr
+	case *jsonObject:
+		for _, key := range t.keys {
+			if hasCondition(conditions, key) {
+				if resolved, ok, err := ms.resolveTarget(pkg, t.values[key], pattern, conditions, allowBare); ok || err != nil {
+					return resolved, ok, err
+				}
+			}
+		}
+	}
+	return "", false, nil
+}
+
+// hasInvalidSegment 判断 / 或 \ 分隔的路径是否包含 ..、. 或 node_modules 段
+func hasInvalidSegment(path string) bool {
+	for _, segment := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '\\' }) {
+		switch strings.ToLower(segment) {
+		case "..", ".", "node_modules":
+			return true
+		}
+	}
+	return false
+}
+
+// isInside 判断 path 是否位于 dir 内
+func isInside(dir string, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// invalidTargetError 返回跳出包目录或包含无效段的目标错误
+func invalidTargetError(pkg *packageJSON, target string) error {
+	return fmt.Errorf("invalid package target %q in %s: targets must start with \"./\" and stay inside the package", target, pkg.packageFile())
+}
+
+// hasCondition 判断条件列表是否包含指定条件
+func hasCondition(conditions []string, name string) bool {
+	for _, condition := range conditions {
+		if condition == name {
+			return true
+		}
+	}
+	return false
+}
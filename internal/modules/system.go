@@ -2,13 +2,22 @@ package modules
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
-	"sw_runtime/internal/builtins"
 	"sync"
 	"time"
 
+	"github.com/issueye/sw_runtime/internal/builtins"
+	"github.com/issueye/sw_runtime/internal/builtins/http"
+	"github.com/issueye/sw_runtime/internal/builtins/process"
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/importmap"
+	"github.com/issueye/sw_runtime/internal/remote"
+	"github.com/issueye/sw_runtime/internal/security"
+	"github.com/issueye/sw_runtime/internal/sourcemap"
+
 	"github.com/dop251/goja"
 )
 
@@ -20,6 +29,13 @@ type System struct {
 	mu             sync.RWMutex
 	basePath       string
 	nodeModules    []string
+	packages       map[string]*packageJSON
+	esmHelpers     *goja.Object
+	importMap      *importmap.ImportMap
+	remote         *remote.Loader
+	main           *Module      // 入口模块（require.main）
+	mainFile       string       // 入口文件路径，创建其模块记录时设为 require.main
+	requireCache   *goja.Object // require.cache，所有 require 共享
 }
 
 // Module 表示一个模块
@@ -30,6 +46,14 @@ type Module struct {
 	Loaded   bool
 	Children []string
 	Parent   string
+	IsESM    bool // 是否为 ES 模块
+	Async    bool // 是否包含顶层 await
+
+	// SourceMap 转译生成的 source map（JSON），未经转译的模块为空
+	SourceMap string
+
+	object     *goja.Object  // 模块代码中的 module 对象，内置模块为 nil
+	evaluation *goja.Promise // 异步求值 Promise（仅异步模块或依赖异步模块时存在）
 }
 
 // NewSystem 创建新的模块系统
@@ -39,98 +63,112 @@ func NewSystem(vm *goja.Runtime, basePath string) *System {
 		cache:          make(map[string]*Module),
 		builtinManager: builtins.NewManager(vm, basePath),
 		basePath:       basePath,
+		packages:       make(map[string]*packageJSON),
 		nodeModules: []string{
 			filepath.Join(basePath, "node_modules"),
 		},
 	}
+	ms.builtinManager.SetHeapRoots(ms.HeapRoots)
 
 	return ms
 }
 
-// resolveModule 解析模块路径
+// HeapRoots 返回堆遍历的起点：全局对象和已加载模块的 exports。
+// 模块作用域中未导出的变量无法从这些起点访问到
+func (ms *System) HeapRoots() []goja.Value {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	roots := make([]goja.Value, 0, len(ms.cache)+1)
+	roots = append(roots, ms.vm.GlobalObject())
+	for _, module := range ms.cache {
+		if module.Exports != nil {
+			roots = append(roots, module.Exports)
+		}
+	}
+	return roots
+}
+
+// resolveModule 解析模块路径（require 条件）
 func (ms *System) resolveModule(id string, parentPath string) (string, error) {
+	return ms.resolveModuleWithConditions(id, parentPath, requireConditions)
+}
+
+// resolveModuleWithConditions 按 Node 规则解析模块路径，conditions 用于 exports/imports 条件匹配
+func (ms *System) resolveModuleWithConditions(id string, parentPath string, conditions []string) (string, error) {
 	// 内置模块
 	if ms.builtinManager.HasModule(id) {
 		return id, nil
 	}
 
-	// 相对路径
-	if strings.HasPrefix(id, "./") || strings.HasPrefix(id, "../") {
-		var basePath string
-		if parentPath == "" {
-			basePath = ms.basePath
-		} else {
-			// 如果 parentPath 是文件，取其目录；如果是目录，直接使用
-			if filepath.Ext(parentPath) != "" {
-				basePath = filepath.Dir(parentPath)
-			} else {
-				basePath = parentPath
-			}
-		}
-		resolved := filepath.Join(basePath, id)
-
-		// 尝试不同的扩展名
-		extensions := []string{"", ".js", ".ts", ".json"}
-		for _, ext := range extensions {
-			fullPath := resolved + ext
-			if _, err := os.Stat(fullPath); err == nil {
-				abs, _ := filepath.Abs(fullPath)
-				return abs, nil
-			}
-		}
+	// 远程模块中的导入以模块 URL 为基准解析
+	if remote.IsURL(parentPath) {
+		return ms.resolveRemote(id, parentPath)
+	}
 
-		// 尝试 index 文件
-		indexExtensions := []string{"/index.js", "/index.ts", "/index.json"}
-		for _, ext := range indexExtensions {
-			fullPath := resolved + ext
-			if _, err := os.Stat(fullPath); err == nil {
-				abs, _ := filepath.Abs(fullPath)
-				return abs, nil
-			}
+	dir := ms.parentDir(parentPath)
+
+	// 导入映射（import map）优先于其他解析规则，从目录发起的导入（入口脚本）只匹配包含该目录的 scopes
+	referrer := parentPath
+	if referrer == dir {
+		referrer = ""
+	}
+	if target, ok, err := ms.importMap.Resolve(id, dir, referrer); err != nil {
+		return "", err
+	} else if ok {
+		if remote.IsURL(target) {
+			return target, nil
+		}
+		if resolved, ok := ms.resolvePath(target, conditions); ok {
+			return resolved, nil
 		}
+		return "", fmt.Errorf("module not found: %s (mapped to %s by import map)", id, target)
+	}
 
-		return "", fmt.Errorf("module not found: %s", id)
+	// http(s) URL 形式的远程模块
+	if remote.IsURL(id) {
+		return id, nil
 	}
 
-	// 绝对路径
-	if filepath.IsAbs(id) {
-		if _, err := os.Stat(id); err == nil {
-			return id, nil
+	// 相对路径与绝对路径
+	if strings.HasPrefix(id, "./") || strings.HasPrefix(id, "../") || id == "." || id == ".." || filepath.IsAbs(id) {
+		target := id
+		if !filepath.IsAbs(id) {
+			target = filepath.Join(dir, id)
+		}
+		if resolved, ok := ms.resolvePath(target, conditions); ok {
+			return resolved, nil
 		}
 		return "", fmt.Errorf("module not found: %s", id)
 	}
 
-	// node_modules 查找
-	for _, nodeModulesPath := range ms.nodeModules {
-		modulePath := filepath.Join(nodeModulesPath, id)
-
-		// 尝试不同的扩展名
-		extensions := []string{"", ".js", ".ts", ".json"}
-		for _, ext := range extensions {
-			fullPath := modulePath + ext
-			if _, err := os.Stat(fullPath); err == nil {
-				abs, _ := filepath.Abs(fullPath)
-				return abs, nil
-			}
-		}
+	// tsconfig.json 的 paths 与 baseUrl 别名，未匹配时按 npm 包解析
+	if resolved, ok := ms.resolveAlias(id, dir, conditions); ok {
+		return resolved, nil
+	}
 
-		// 尝试 package.json 中的 main 字段
-		packageJsonPath := filepath.Join(modulePath, "package.json")
-		if _, err := os.Stat(packageJsonPath); err == nil {
-			// 这里简化处理，直接尝试 index.js
-			indexPath := filepath.Join(modulePath, "index.js")
-			if _, err := os.Stat(indexPath); err == nil {
-				abs, _ := filepath.Abs(indexPath)
-				return abs, nil
-			}
-		}
+	// package.json imports 字段 (#internal)
+	if strings.HasPrefix(id, "#") {
+		return ms.resolvePackageImports(id, dir, conditions)
 	}
 
-	return "", fmt.Errorf("module not found: %s", id)
+	// node_modules 查找，逐级向上
+	return ms.resolvePackage(id, dir, conditions)
 }
 
-// LoadModule 加载模块
+// LoadModule 由宿主（Go 代码）加载模块，不检查入口文件的读取权限；
+// 模块内部的 require 与 import 仍受权限限制
 func (ms *System) LoadModule(id string, parentPath string) (*Module, error) {
+	return ms.load(id, parentPath, requireConditions, false)
+}
+
+// loadModule 按指定的解析条件加载脚本请求的模块，读取本地文件前检查读取权限
+func (ms *System) loadModule(id string, parentPath string, conditions []string) (*Module, error) {
+	return ms.load(id, parentPath, conditions, true)
+}
+
+// load 加载模块，checkRead 为 true 时读取本地文件前检查读取权限
+func (ms *System) load(id string, parentPath string, conditions []string, checkRead bool) (*Module, error) {
 	// 检查是否是命名空间模块 (http/server 格式)
 	if strings.Contains(id, "/") {
 		if subModule, exists := ms.builtinManager.GetNamespacedModule(id); exists {
@@ -155,17 +193,16 @@ func (ms *System) LoadModule(id string, parentPath string) (*Module, error) {
 		return module, nil
 	}
 
-	resolvedPath, err := ms.resolveModule(id, parentPath)
+	resolvedPath, err := ms.resolveModuleWithConditions(id, parentPath, conditions)
 	if err != nil {
 		return nil, err
 	}
 
-	ms.mu.RLock()
-	if cached, exists := ms.cache[resolvedPath]; exists {
-		ms.mu.RUnlock()
+	parent := ms.cachedModule(parentPath)
+	if cached := ms.cachedModule(resolvedPath); cached != nil {
+		ms.addChild(parent, cached)
 		return cached, nil
 	}
-	ms.mu.RUnlock()
 
 	// 内置模块
 	if builtinModule, exists := ms.builtinManager.GetModule(resolvedPath); exists {
@@ -183,8 +220,18 @@ func (ms *System) LoadModule(id string, parentPath string) (*Module, error) {
 		return module, nil
 	}
 
-	// 文件模块
-	content, err := os.ReadFile(resolvedPath)
+	// 文件模块与远程模块
+	var content []byte
+	if remote.IsURL(resolvedPath) {
+		content, err = ms.RemoteLoader().Load(resolvedPath, ms.builtinManager.Guard().CheckURL)
+	} else {
+		if checkRead {
+			err = ms.builtinManager.Guard().CheckRead(resolvedPath)
+		}
+		if err == nil {
+			content, err = os.ReadFile(resolvedPath)
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to read module %s: %w", resolvedPath, err)
 	}
@@ -196,10 +243,12 @@ func (ms *System) LoadModule(id string, parentPath string) (*Module, error) {
 		Loaded:   false,
 		Parent:   parentPath,
 	}
+	ms.newModuleObject(module, parent)
 
 	ms.mu.Lock()
 	ms.cache[resolvedPath] = module
 	ms.mu.Unlock()
+	ms.addChild(parent, module)
 
 	// 执行模块代码
 	err = ms.executeModule(string(content), module)
@@ -210,22 +259,16 @@ func (ms *System) LoadModule(id string, parentPath string) (*Module, error) {
 		return nil, err
 	}
 
-	module.Loaded = true
+	if module.evaluation == nil {
+		module.Loaded = true
+		module.object.Set("loaded", true)
+	}
 	return module, nil
 }
 
 // executeModule 执行模块代码
 func (ms *System) executeModule(code string, module *Module) error {
-	ext := filepath.Ext(module.Filename)
-
-	// 如果是 TypeScript 文件，先编译
-	if ext == ".ts" || ext == ".tsx" {
-		jsCode, err := transpileTS(code, module.Filename)
-		if err != nil {
-			return fmt.Errorf("failed to transpile %s: %w", module.Filename, err)
-		}
-		code = jsCode
-	}
+	ext := extname(module.Filename)
 
 	// JSON 文件直接解析
 	if ext == ".json" {
@@ -235,73 +278,75 @@ func (ms *System) executeModule(code string, module *Module) error {
 		}
 		if result != nil {
 			module.Exports = result.ToObject(ms.vm)
+			module.object.Set("exports", module.Exports)
 		}
 		return nil
 	}
 
-	// 创建模块作用域
-	moduleObj := ms.vm.NewObject()
-	moduleObj.Set("exports", module.Exports)
-	moduleObj.Set("id", module.ID)
-	moduleObj.Set("filename", module.Filename)
-	moduleObj.Set("loaded", false)
-	moduleObj.Set("children", ms.vm.NewArray())
-	moduleObj.Set("parent", nil)
-
-	// 创建 require 函数
-	requireFunc := func(call goja.FunctionCall) goja.Value {
-		if len(call.Arguments) == 0 {
-			panic(ms.vm.NewTypeError("require() missing path"))
-		}
-
-		id := call.Arguments[0].String()
-		requiredModule, err := ms.LoadModule(id, module.Filename)
+	// TypeScript 与 ES 模块统一经 esbuild 转换为 CommonJS 形式
+	var deps []string
+	isTS := ext == ".ts" || ext == ".tsx" || ext == ".mts" || ext == ".cts"
+	module.IsESM = isESMFile(module.Filename, code) ||
+		ext == ".js" && ms.isModuleType(module.Filename)
+	if isTS || module.IsESM {
+		compiled, err := compileESM(code, module.Filename)
 		if err != nil {
-			panic(ms.vm.NewGoError(err))
+			return fmt.Errorf("failed to transpile %s: %w", module.Filename, err)
 		}
-
-		return requiredModule.Exports
+		code = compiled.Code
+		deps = compiled.Deps
+		module.Async = compiled.Async
+	} else {
+		code = rewriteDynamicImport(LowerAsyncIteration(code, module.Filename), importFuncName)
 	}
 
+	// 模块作用域，require 的 conditions 决定 exports 条件匹配（ES 模块转换后的 require 使用 import 条件）
+	moduleObj := module.object
+	requireFunc := ms.newRequire(module, requireConditions)
+	importRequireFunc := ms.newRequire(module, importConditions)
+	moduleObj.Set("require", requireFunc)
+
 	// 创建动态 import 函数 (返回 Promise)
 	importFunc := func(call goja.FunctionCall) goja.Value {
 		if len(call.Arguments) == 0 {
 			panic(ms.vm.NewTypeError("import() missing path"))
 		}
 
-		id := call.Arguments[0].String()
-
-		// 创建 Promise
-		promise, resolve, reject := ms.vm.NewPromise()
-
-		// 异步加载模块
-		go func() {
-			requiredModule, err := ms.LoadModule(id, module.Filename)
-			if err != nil {
-				reject(ms.vm.NewGoError(err))
-			} else {
-				resolve(requiredModule.Exports)
-			}
-		}()
+		return ms.Import(call.Arguments[0].String(), module.Filename)
+	}
 
-		return ms.vm.ToValue(promise)
+	// 包装代码为 CommonJS 模块格式，同时支持动态 import 与 import.meta
+	prefix := ""
+	if module.Async {
+		prefix = "async "
+	}
+	// 包装函数占用一行，source map 相应下移；结尾的 "})" 不影响 source map 注释的识别
+	wrappedCode := fmt.Sprintf("(%sfunction(exports, require, module, __filename, __dirname, %s, %s, %s) {\n%s\n})",
+		prefix, importFuncName, importMetaName, importRequireName, sourcemap.ShiftLines(code, 1))
+	if module.Async {
+		lowered, err := lowerAsyncWrapper(wrappedCode, module.Filename)
+		if err != nil {
+			return fmt.Errorf("failed to transpile %s: %w", module.Filename, err)
+		}
+		wrappedCode = lowered
 	}
 
-	// 包装代码为 CommonJS 模块格式，同时支持动态 import
-	wrappedCode := fmt.Sprintf(`
-(function(exports, require, module, __filename, __dirname, importFunc) {
-	// 添加动态 import 支持
-	if (typeof globalThis !== 'undefined') {
-		globalThis.import = importFunc;
-	} else if (typeof global !== 'undefined') {
-		global.import = importFunc;
+	// ES 模块的静态依赖先于模块体加载（与规范中的链接阶段一致）
+	var pending []goja.Value
+	for _, dep := range deps {
+		depModule, err := ms.loadModule(dep, module.Filename, importConditions)
+		if err != nil {
+			return err
+		}
+		if depModule.pending() {
+			pending = append(pending, ms.vm.ToValue(depModule.evaluation))
+		}
 	}
 
-%s
-});`, code)
+	module.SourceMap = sourcemap.Extract(wrappedCode)
 
 	// 编译并执行
-	program, err := goja.Compile(module.Filename, wrappedCode, false)
+	program, err := sourcemap.Compile(module.Filename, wrappedCode, false)
 	if err != nil {
 		return fmt.Errorf("failed to compile module %s: %w", module.Filename, err)
 	}
@@ -312,33 +357,96 @@ func (ms *System) executeModule(code string, module *Module) error {
 	}
 
 	// 调用模块函数
-	dirname := filepath.Dir(module.Filename)
+	dirname := dirname(module.Filename)
 	callable, ok := goja.AssertFunction(moduleFunc)
 	if !ok {
 		return fmt.Errorf("module %s did not return a function", module.Filename)
 	}
 
-	_, err = callable(goja.Undefined(),
-		module.Exports,
-		ms.vm.ToValue(requireFunc),
-		moduleObj,
-		ms.vm.ToValue(module.Filename),
-		ms.vm.ToValue(dirname),
-		ms.vm.ToValue(importFunc),
-	)
-
-	if err != nil {
-		return fmt.Errorf("failed to execute module %s: %w", module.Filename, err)
+	run := func() (goja.Value, error) {
+		return callable(goja.Undefined(),
+			module.Exports,
+			requireFunc,
+			moduleObj,
+			ms.vm.ToValue(module.Filename),
+			ms.vm.ToValue(dirname),
+			ms.vm.ToValue(importFunc),
+			ms.newImportMeta(module),
+			importRequireFunc,
+		)
 	}
 
 	// 更新 exports (可能被重新赋值)
-	if newExports := moduleObj.Get("exports"); newExports != nil {
-		module.Exports = newExports.ToObject(ms.vm)
+	updateExports := func() {
+		if newExports := moduleObj.Get("exports"); newExports != nil {
+			module.Exports = newExports.ToObject(ms.vm)
+		}
 	}
 
+	// 同步模块直接执行
+	if !module.Async && len(pending) == 0 {
+		if _, err := run(); err != nil {
+			return fmt.Errorf("failed to execute module %s: %w", module.Filename, err)
+		}
+		updateExports()
+		return nil
+	}
+
+	// 异步模块：等待依赖求值完成后执行模块体
+	body := func(goja.FunctionCall) goja.Value {
+		result, err := run()
+		if err != nil {
+			if exception, ok := err.(*goja.Exception); ok {
+				panic(exception)
+			}
+			panic(ms.vm.NewGoError(err))
+		}
+		return result
+	}
+	done := func(goja.FunctionCall) goja.Value {
+		updateExports()
+		module.Loaded = true
+		moduleObj.Set("loaded", true)
+		return goja.Undefined()
+	}
+
+	evaluation, err := ms.callHelper("evaluate", ms.vm.ToValue(pending), ms.vm.ToValue(body), ms.vm.ToValue(done))
+	if err != nil {
+		return fmt.Errorf("failed to execute module %s: %w", module.Filename, err)
+	}
+	if promise, ok := evaluation.Export().(*goja.Promise); ok {
+		module.evaluation = promise
+	}
 	return nil
 }
 
+// newImportMeta 创建模块的 import.meta 对象
+func (ms *System) newImportMeta(module *Module) *goja.Object {
+	meta := ms.vm.NewObject()
+	if remote.IsURL(module.Filename) {
+		meta.Set("url", module.Filename)
+	} else {
+		url := filepath.ToSlash(module.Filename)
+		if !strings.HasPrefix(url, "/") {
+			url = "/" + url
+		}
+		meta.Set("url", "file://"+url)
+	}
+	meta.Set("filename", module.Filename)
+	meta.Set("dirname", dirname(module.Filename))
+	meta.Set("resolve", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) == 0 {
+			panic(ms.vm.NewTypeError("import.meta.resolve() missing specifier"))
+		}
+		resolved, err := ms.resolveModuleWithConditions(call.Arguments[0].String(), module.Filename, importConditions)
+		if err != nil {
+			panic(ms.vm.NewGoError(err))
+		}
+		return ms.vm.ToValue(resolved)
+	})
+	return meta
+}
+
 // Require 实现全局 require 函数
 func (ms *System) Require(call goja.FunctionCall) goja.Value {
 	if len(call.Arguments) == 0 {
@@ -346,21 +454,36 @@ func (ms *System) Require(call goja.FunctionCall) goja.Value {
 	}
 
 	id := call.Arguments[0].String()
-	// 使用当前工作目录作为父路径
-	currentDir, _ := os.Getwd()
-	module, err := ms.LoadModule(id, currentDir)
+	// 使用模块系统的基础路径作为父路径
+	module, err := ms.loadModule(id, ms.basePath, requireConditions)
 	if err != nil {
+		panic(types.NewPermissionError(ms.vm, err))
+	}
+	if err := ms.checkEvaluated(module); err != nil {
 		panic(ms.vm.NewGoError(err))
 	}
 
 	return module.Exports
 }
 
+// RequireModule 由宿主以 basePath 为基准加载模块并返回其导出，与 LoadModule 一样不检查入口文件的读取权限
+func (ms *System) RequireModule(id string) (*goja.Object, error) {
+	module, err := ms.LoadModule(id, ms.basePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := ms.checkEvaluated(module); err != nil {
+		return nil, err
+	}
+	return module.Exports, nil
+}
+
 // ClearCache 清除模块缓存
 func (ms *System) ClearCache() {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 	ms.cache = make(map[string]*Module)
+	ms.packages = make(map[string]*packageJSON)
 }
 
 // GetLoadedModules 获取已加载的模块列表
@@ -380,17 +503,97 @@ func (ms *System) GetBuiltinModules() []string {
 	return ms.builtinManager.GetModuleNames()
 }
 
+// InstallGlobals 将内置模块提供的全局 API 安装到全局对象
+func (ms *System) InstallGlobals(global *goja.Object) {
+	ms.builtinManager.InstallGlobals(global)
+}
+
 // Close 关闭模块系统并清理资源
 func (ms *System) Close() {
 	ms.builtinManager.Close()
 	ms.ClearCache()
 }
 
+// Reset 关闭内置模块打开的服务器、连接和数据库并清除模块缓存与入口模块
+func (ms *System) Reset() {
+	ms.builtinManager.Reset()
+	ms.ClearCache()
+	ms.main, ms.mainFile = nil, ""
+}
+
+// SetServerWorkerSpawner 设置多 VM HTTP 服务器创建工作 VM 的函数
+func (ms *System) SetServerWorkerSpawner(spawner http.WorkerSpawner) {
+	ms.builtinManager.SetServerWorkerSpawner(spawner)
+}
+
+// SetSharedStore 设置 http/server 的跨 VM 共享存储
+func (ms *System) SetSharedStore(store *http.SharedStore) {
+	ms.builtinManager.SetSharedStore(store)
+}
+
+// SharedStore 返回 http/server 的跨 VM 共享存储
+func (ms *System) SharedStore() *http.SharedStore {
+	return ms.builtinManager.SharedStore()
+}
+
 // SetArgv 设置命令行参数
 func (ms *System) SetArgv(argv []string) {
 	ms.builtinManager.SetArgv(argv)
 }
 
+// RegisterModule 注册自定义内置模块，之后可通过 require(name) 和 import 加载
+func (ms *System) RegisterModule(name string, module types.BuiltinModule) {
+	ms.builtinManager.RegisterModule(name, module)
+}
+
+// ProcessEvents 返回 process 的生命周期事件
+func (ms *System) ProcessEvents() *process.Events {
+	return ms.builtinManager.ProcessEvents()
+}
+
+// SetStdio 设置 process.stdout 和 process.stderr 的输出目标
+func (ms *System) SetStdio(stdout, stderr io.Writer) {
+	ms.builtinManager.SetStdio(stdout, stderr)
+}
+
+// SetImportMap 设置模块解析使用的导入映射，nil 表示不使用
+func (ms *System) SetImportMap(m *importmap.ImportMap) {
+	ms.importMap = m
+}
+
+// ImportMap 返回当前的导入映射
+func (ms *System) ImportMap() *importmap.ImportMap {
+	return ms.importMap
+}
+
+// SetRemoteLoader 设置加载 http(s) 远程模块使用的加载器，nil 表示使用默认加载器
+func (ms *System) SetRemoteLoader(l *remote.Loader) {
+	ms.remote = l
+}
+
+// RemoteLoader 返回远程模块加载器，未设置时返回默认加载器
+func (ms *System) RemoteLoader() *remote.Loader {
+	if ms.remote == nil {
+		return remote.Default()
+	}
+	return ms.remote
+}
+
+// SetPermissions 设置内置模块的访问权限，nil 表示不限制
+func (ms *System) SetPermissions(p *security.Permissions) {
+	ms.builtinManager.SetPermissions(p)
+}
+
+// Permissions 返回当前权限配置，未限制时返回 nil
+func (ms *System) Permissions() *security.Permissions {
+	return ms.builtinManager.Permissions()
+}
+
+// Guard 返回当前权限配置的访问检查器
+func (ms *System) Guard() *security.Guard {
+	return ms.builtinManager.Guard()
+}
+
 // SetStartTime 设置起始时间
 func (ms *System) SetStartTime(t time.Time) {
 	ms.builtinManager.SetStartTime(t)
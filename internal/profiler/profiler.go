@@ -0,0 +1,247 @@
+package profiler
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/dop251/goja"
+)
+
+// ErrNotActive 未开始 CPU 采样时调用 StopCPU 返回的错误
+var ErrNotActive = errors.New("cpu profiler is not active")
+
+// cpu 进程内唯一的 CPU 采样状态，goja 的采样器对所有 VM 全局生效
+var cpu struct {
+	mu  sync.Mutex
+	buf *bytes.Buffer
+}
+
+// StartCPU 开始采样 JS 的执行时间，结果为 pprof 格式，JS 函数以调用栈帧出现在 pprof 中。
+// 采样对进程内所有 VM（包括 Worker）生效，正在执行的 VM 也会被纳入，已在采样时返回错误。
+func StartCPU() error {
+	cpu.mu.Lock()
+	defer cpu.mu.Unlock()
+	if cpu.buf != nil {
+		return errors.New("cpu profiler is already active")
+	}
+	buf := new(bytes.Buffer)
+	if err := goja.StartProfile(buf); err != nil {
+		return err
+	}
+	cpu.buf = buf
+	return nil
+}
+
+// StopCPU 停止采样并返回 pprof 数据，可用 go tool pprof 查看
+func StopCPU() ([]byte, error) {
+	cpu.mu.Lock()
+	defer cpu.mu.Unlock()
+	if cpu.buf == nil {
+		return nil, ErrNotActive
+	}
+	goja.StopProfile()
+	data := cpu.buf.Bytes()
+	cpu.buf = nil
+	return data, nil
+}
+
+// CPUActive 返回是否正在采样
+func CPUActive() bool {
+	cpu.mu.Lock()
+	defer cpu.mu.Unlock()
+	return cpu.buf != nil
+}
+
+// HeapSummary JS 堆中可达对象按构造函数统计的摘要
+type HeapSummary struct {
+	Objects      int                `json:"objects"`      // 对象总数
+	Constructors []ConstructorCount `json:"constructors"` // 按数量从多到少排列
+}
+
+// ConstructorCount 单个构造函数的对象数量
+type ConstructorCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// TakeHeapSummary 从 roots（通常是全局对象和已加载模块的 exports）出发，
+// 沿属性、数组元素、Map/Set 元素和原型遍历可达对象，按构造函数名称计数。
+// 只被闭包捕获的对象无法通过属性访问，不会被统计；访问器属性不会被调用。
+// 必须在执行该 VM 的 goroutine 上调用。
+func TakeHeapSummary(vm *goja.Runtime, roots ...goja.Value) *HeapSummary {
+	w := newWalker(vm)
+	for _, root := range roots {
+		w.push(root)
+	}
+	for len(w.queue) > 0 {
+		obj := w.queue[len(w.queue)-1]
+		w.queue = w.queue[:len(w.queue)-1]
+		w.visit(obj)
+	}
+
+	summary := &HeapSummary{Constructors: make([]ConstructorCount, 0, len(w.counts))}
+	for name, count := range w.counts {
+		summary.Objects += count
+		summary.Constructors = append(summary.Constructors, ConstructorCount{Name: name, Count: count})
+	}
+	sort.Slice(summary.Constructors, func(i, j int) bool {
+		a, b := summary.Constructors[i], summary.Constructors[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Name < b.Name
+	})
+	return summary
+}
+
+// walker 遍历状态
+type walker struct {
+	vm     *goja.Runtime
+	seen   map[*goja.Object]struct{}
+	queue  []*goja.Object
+	counts map[string]int
+
+	toString                 goja.Callable
+	getOwnPropertyDescriptor goja.Callable
+	arrayFrom                goja.Callable
+}
+
+// newWalker 创建遍历器
+func newWalker(vm *goja.Runtime) *walker {
+	w := &walker{
+		vm:     vm,
+		seen:   make(map[*goja.Object]struct{}),
+		counts: make(map[string]int),
+	}
+	object := vm.Get("Object").ToObject(vm)
+	w.toString, _ = goja.AssertFunction(object.Get("prototype").ToObject(vm).Get("toString"))
+	w.getOwnPropertyDescriptor, _ = goja.AssertFunction(object.Get("getOwnPropertyDescriptor"))
+	w.arrayFrom, _ = goja.AssertFunction(vm.Get("Array").ToObject(vm).Get("from"))
+	return w
+}
+
+// push 将未访问过的对象加入队列
+func (w *walker) push(v goja.Value) {
+	obj, ok := v.(*goja.Object)
+	if !ok {
+		return
+	}
+	if _, seen := w.seen[obj]; seen {
+		return
+	}
+	w.seen[obj] = struct{}{}
+	w.queue = append(w.queue, obj)
+}
+
+// visit 统计对象并将其引用的对象加入队列
+func (w *walker) visit(obj *goja.Object) {
+	w.counts[w.name(obj)]++
+	if proto := obj.Prototype(); proto != nil {
+		w.push(proto)
+	}
+
+	switch tag := w.tag(obj); tag {
+	case "ArrayBuffer", "SharedArrayBuffer", "Int8Array", "Uint8Array", "Uint8ClampedArray",
+		"Int16Array", "Uint16Array", "Int32Array", "Uint32Array", "Float32Array", "Float64Array",
+		"BigInt64Array", "BigUint64Array":
+		// 二进制数据只包含数值
+		return
+	case "Map", "Set":
+		w.pushEntries(obj, tag == "Map")
+	}
+
+	if obj.ClassName() == "Array" {
+		length := obj.Get("length").ToInteger()
+		for i := int64(0); i < length; i++ {
+			w.push(obj.Get(strconv.FormatInt(i, 10)))
+		}
+		return
+	}
+
+	for _, key := range obj.GetOwnPropertyNames() {
+		w.pushProperty(obj, w.vm.ToValue(key))
+	}
+	for _, sym := range obj.Symbols() {
+		w.pushProperty(obj, sym)
+	}
+}
+
+// pushEntries 将 Map 的键值或 Set 的元素加入队列，Array.from 生成的临时数组不计数
+func (w *walker) pushEntries(obj *goja.Object, isMap bool) {
+	if w.arrayFrom == nil {
+		return
+	}
+	items, err := w.arrayFrom(w.vm.Get("Array"), obj)
+	if err != nil {
+		return
+	}
+	list := items.ToObject(w.vm)
+	length := list.Get("length").ToInteger()
+	for i := int64(0); i < length; i++ {
+		item := list.Get(strconv.FormatInt(i, 10))
+		if pair, ok := item.(*goja.Object); ok && isMap {
+			w.push(pair.Get("0"))
+			w.push(pair.Get("1"))
+			continue
+		}
+		w.push(item)
+	}
+}
+
+// pushProperty 将属性值（或访问器函数本身）加入队列，不调用 getter
+func (w *walker) pushProperty(obj *goja.Object, key goja.Value) {
+	if w.getOwnPropertyDescriptor == nil {
+		return
+	}
+	desc, err := w.getOwnPropertyDescriptor(goja.Undefined(), obj, key)
+	if err != nil {
+		return
+	}
+	d, ok := desc.(*goja.Object)
+	if !ok {
+		return
+	}
+	for _, field := range []string{"value", "get", "set"} {
+		w.push(d.Get(field))
+	}
+}
+
+// name 获取对象的构造函数名称
+func (w *walker) name(obj *goja.Object) string {
+	if _, ok := goja.AssertFunction(obj); ok {
+		return "Function"
+	}
+	proto := obj.Prototype()
+	if proto == nil {
+		return "Object (null prototype)"
+	}
+	for ; proto != nil; proto = proto.Prototype() {
+		ctor, ok := proto.Get("constructor").(*goja.Object)
+		if !ok {
+			continue
+		}
+		if name := ctor.Get("name"); name != nil && name.String() != "" {
+			return name.String()
+		}
+	}
+	return "Object"
+}
+
+// tag 获取 Object.prototype.toString 返回的类型标签
+func (w *walker) tag(obj *goja.Object) string {
+	if w.toString == nil {
+		return obj.ClassName()
+	}
+	s, err := w.toString(obj)
+	if err != nil {
+		return obj.ClassName()
+	}
+	str := s.String()
+	if len(str) > 9 {
+		return str[8 : len(str)-1]
+	}
+	return obj.ClassName()
+}
@@ -0,0 +1,82 @@
+// Package project 读取项目配置文件 sw.json
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/issueye/sw_runtime/internal/importmap"
+)
+
+// FileName 项目配置文件名
+const FileName = "sw.json"
+
+// Config sw.json 项目配置
+type Config struct {
+	Path string `json:"-"` // sw.json 的绝对路径
+
+	// ImportMap 导入映射：导入映射文件的路径（相对于 sw.json），或内联的 {"imports", "scopes"} 对象
+	ImportMap json.RawMessage `json:"importMap"`
+}
+
+// Find 从 dir 开始逐级向上查找最近的 sw.json，未找到时返回 nil
+func Find(dir string) (*Config, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		path := filepath.Join(dir, FileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return Load(path)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// Load 读取指定的 sw.json
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	config.Path, _ = filepath.Abs(path)
+	return config, nil
+}
+
+// Dir 返回 sw.json 所在目录
+func (c *Config) Dir() string {
+	return filepath.Dir(c.Path)
+}
+
+// LoadImportMap 加载 importMap 配置的导入映射，未配置时返回 nil
+func (c *Config) LoadImportMap() (*importmap.ImportMap, error) {
+	if c == nil || len(c.ImportMap) == 0 || string(c.ImportMap) == "null" {
+		return nil, nil
+	}
+
+	var path string
+	if err := json.Unmarshal(c.ImportMap, &path); err == nil {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.Dir(), path)
+		}
+		return importmap.Load(path)
+	}
+
+	m, err := importmap.Parse(c.ImportMap, c.Dir())
+	if err != nil {
+		return nil, fmt.Errorf("invalid \"importMap\" in %s: %w", c.Path, err)
+	}
+	m.Path = c.Path
+	return m, nil
+}
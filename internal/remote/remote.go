@@ -0,0 +1,347 @@
+// Package remote 加载 http(s) URL 形式的远程模块：下载的源码缓存在磁盘上，
+// SHA-256 完整性记录在项目的 sw.lock 中，模块系统与打包器共用同一个加载器
+package remote
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/issueye/sw_runtime/internal/security"
+)
+
+// LockFileName 记录远程模块完整性的锁文件名
+const LockFileName = "sw.lock"
+
+// DirEnv 设置远程模块缓存目录的环境变量
+const DirEnv = "SW_RUNTIME_REMOTE_DIR"
+
+// 默认下载超时与单个模块的大小上限
+const (
+	defaultTimeout = 30 * time.Second
+	maxModuleSize  = 32 << 20
+	maxRedirects   = 10
+)
+
+// Options 远程模块加载选项
+type Options struct {
+	CacheDir     string        // 下载缓存目录，为空时使用 DefaultDir()
+	LockFile     string        // sw.lock 路径，为空时不校验完整性
+	Reload       bool          // 忽略磁盘缓存，每个 URL 在本进程中重新下载一次
+	LockWrite    bool          // 完整性不一致时以新内容更新 sw.lock 而不是报错
+	Offline      bool          // 离线模式：只使用磁盘缓存，未缓存的模块直接报错
+	AllowPrivate bool          // 允许从内网、回环地址下载（内部模块服务器）
+	Timeout      time.Duration // 单次下载超时，0 表示默认的 30 秒
+}
+
+// Loader 远程模块加载器，可在多个运行器之间共享
+type Loader struct {
+	options   Options
+	validator *security.URLValidator
+
+	mu      sync.Mutex
+	sources map[string][]byte // 本进程中已加载并校验的源码
+	lock    *lockFile
+}
+
+// lockFile sw.lock 的内容：URL 到 "sha256-<base64>" 的映射
+type lockFile struct {
+	Version int               `json:"version"`
+	Remote  map[string]string `json:"remote"`
+
+	path   string
+	loaded bool
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultLoader *Loader
+)
+
+// New 创建远程模块加载器
+func New(options Options) *Loader {
+	if options.CacheDir == "" {
+		options.CacheDir = DefaultDir()
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = defaultTimeout
+	}
+	validator := security.NewURLValidator()
+	if options.AllowPrivate {
+		validator = security.NewURLValidatorWithPrivate()
+	}
+	return &Loader{
+		options:   options,
+		validator: validator,
+		sources:   make(map[string][]byte),
+		lock:      &lockFile{path: options.LockFile},
+	}
+}
+
+// Default 返回默认加载器：使用默认缓存目录，不校验 sw.lock
+func Default() *Loader {
+	defaultOnce.Do(func() {
+		defaultLoader = New(Options{})
+	})
+	return defaultLoader
+}
+
+// DefaultDir 返回默认的下载缓存目录：$SW_RUNTIME_REMOTE_DIR，未设置时为用户缓存目录下的 sw_runtime/remote
+func DefaultDir() string {
+	if dir := os.Getenv(DirEnv); dir != "" {
+		return dir
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	return filepath.Join(base, "sw_runtime", "remote")
+}
+
+// Options 返回加载选项
+func (l *Loader) Options() Options {
+	return l.options
+}
+
+// Load 返回远程模块的源码。check 为额外的访问检查（如 --allow-net 权限），
+// 对原始 URL 与每次重定向的目标生效；为 nil 时只经过 URLValidator 校验
+func (l *Loader) Load(rawURL string, check func(string) error) ([]byte, error) {
+	if err := l.checkURL(rawURL, check); err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	if data, ok := l.sources[rawURL]; ok {
+		l.mu.Unlock()
+		return data, nil
+	}
+	l.mu.Unlock()
+
+	data, cached := l.readCache(rawURL)
+	if !cached {
+		if l.options.Offline {
+			return nil, fmt.Errorf("remote module %s is not cached (offline mode)", rawURL)
+		}
+		var err error
+		if data, err = l.fetch(rawURL, check); err != nil {
+			return nil, err
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.verify(rawURL, data); err != nil {
+		return nil, err
+	}
+	if !cached {
+		if err := l.writeCache(rawURL, data); err != nil {
+			return nil, err
+		}
+	}
+	l.sources[rawURL] = data
+	return data, nil
+}
+
+// checkURL 校验 URL 的协议、地址与访问权限
+func (l *Loader) checkURL(rawURL string, check func(string) error) error {
+	if !IsURL(rawURL) {
+		return fmt.Errorf("invalid remote module URL: %s", rawURL)
+	}
+	if check != nil {
+		if err := check(rawURL); err != nil {
+			return err
+		}
+	}
+	if err := l.validator.Validate(rawURL); err != nil {
+		return fmt.Errorf("remote module %s is not allowed: %w", rawURL, err)
+	}
+	return nil
+}
+
+// fetch 下载远程模块，重定向目标同样经过校验
+func (l *Loader) fetch(rawURL string, check func(string) error) ([]byte, error) {
+	client := &http.Client{
+		Timeout: l.options.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("too many redirects")
+			}
+			return l.checkURL(req.URL.String(), check)
+		},
+	}
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch remote module %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch remote module %s: %s", rawURL, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxModuleSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch remote module %s: %w", rawURL, err)
+	}
+	if len(data) > maxModuleSize {
+		return nil, fmt.Errorf("remote module %s exceeds %d bytes", rawURL, maxModuleSize)
+	}
+	return data, nil
+}
+
+// verify 按 sw.lock 校验完整性，新模块的哈希写入 sw.lock。调用方持有 l.mu
+func (l *Loader) verify(rawURL string, data []byte) error {
+	if l.lock.path == "" {
+		return nil
+	}
+	if err := l.lock.load(); err != nil {
+		return err
+	}
+
+	sum := Integrity(data)
+	if expected, ok := l.lock.Remote[rawURL]; ok {
+		if expected == sum {
+			return nil
+		}
+		if !l.options.LockWrite {
+			return fmt.Errorf("integrity check failed for %s: %s expects %s, got %s (use --lock-write to update)",
+				rawURL, LockFileName, expected, sum)
+		}
+	}
+	l.lock.Remote[rawURL] = sum
+	return l.lock.save()
+}
+
+// cachePath 返回 URL 对应的缓存文件路径
+func (l *Loader) cachePath(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	key := hex.EncodeToString(sum[:])
+	return filepath.Join(l.options.CacheDir, key[:2], key)
+}
+
+// readCache 读取磁盘缓存，--reload 时忽略缓存
+func (l *Loader) readCache(rawURL string) ([]byte, bool) {
+	if l.options.Reload {
+		return nil, false
+	}
+	data, err := os.ReadFile(l.cachePath(rawURL))
+	return data, err == nil
+}
+
+// writeCache 写入磁盘缓存，先写临时文件再重命名，避免并发读到不完整的内容
+func (l *Loader) writeCache(rawURL string, data []byte) error {
+	path := l.cachePath(rawURL)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to cache remote module %s: %w", rawURL, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to cache remote module %s: %w", rawURL, err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to cache remote module %s: %w", rawURL, err)
+	}
+	return nil
+}
+
+// load 首次校验时读取 sw.lock，文件不存在时视为空
+func (f *lockFile) load() error {
+	if f.loaded {
+		return nil
+	}
+	f.loaded = true
+	f.Version = 1
+	f.Remote = make(map[string]string)
+
+	data, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, f); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", f.path, err)
+	}
+	if f.Remote == nil {
+		f.Remote = make(map[string]string)
+	}
+	return nil
+}
+
+// save 写回 sw.lock，键按字母顺序排列
+func (f *lockFile) save() error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(f.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", f.path, err)
+	}
+	return nil
+}
+
+// Integrity 返回内容的完整性摘要（与 Subresource Integrity 相同的 sha256-<base64> 格式）
+func Integrity(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256-" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// IsURL 判断是否为 http(s) URL
+func IsURL(s string) bool {
+	return strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://")
+}
+
+// IsRelative 判断是否为相对于当前模块 URL 解析的标识符（./、../ 或 /）
+func IsRelative(specifier string) bool {
+	return strings.HasPrefix(specifier, "./") || strings.HasPrefix(specifier, "../") || strings.HasPrefix(specifier, "/")
+}
+
+// Resolve 以 base URL 为基准解析相对标识符
+func Resolve(base string, specifier string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(specifier)
+	if err != nil {
+		return "", fmt.Errorf("invalid module specifier %q: %w", specifier, err)
+	}
+	return u.ResolveReference(ref).String(), nil
+}
+
+// Ext 返回 URL 路径的扩展名，忽略查询参数与片段
+func Ext(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return path.Ext(u.Path)
+}
+
+// Dir 返回 URL 所在的“目录”，即去掉最后一段路径后的 URL
+func Dir(rawURL string) string {
+	dir, err := Resolve(rawURL, ".")
+	if err != nil {
+		return rawURL
+	}
+	return strings.TrimSuffix(dir, "/")
+}
@@ -0,0 +1,284 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// errLineInterrupted 读取一行时按下了 Ctrl+C
+var errLineInterrupted = errors.New("line interrupted")
+
+// CompleteFunc 补全函数，返回光标前文本的候选项与被补全的前缀
+type CompleteFunc func(line string) ([]string, string)
+
+// lineReader 逐行读取输入
+type lineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+// plainReader 非终端输入（管道、文件）按行读取，不显示提示符
+type plainReader struct {
+	in *bufio.Reader
+}
+
+func (r *plainReader) ReadLine(prompt string) (string, error) {
+	line, err := r.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// lineEditor 终端行编辑器：光标移动、历史记录与 Tab 补全
+type lineEditor struct {
+	fd       int
+	in       *bufio.Reader
+	out      io.Writer
+	history  *history
+	complete CompleteFunc
+
+	prompt string
+	line   []rune
+	pos    int
+}
+
+// ReadLine 在原始模式下读取一行，Ctrl+C 返回 errLineInterrupted，空行上的 Ctrl+D 返回 io.EOF
+func (e *lineEditor) ReadLine(prompt string) (string, error) {
+	restore, err := makeRaw(e.fd)
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	e.prompt, e.line, e.pos = prompt, nil, 0
+	historyPos, pending := e.history.Len(), ""
+	e.refresh()
+
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(e.out, "\n")
+			return string(e.line), nil
+		case 3: // Ctrl+C
+			fmt.Fprint(e.out, "^C\n")
+			return "", errLineInterrupted
+		case 4: // Ctrl+D
+			if len(e.line) == 0 {
+				fmt.Fprint(e.out, "\n")
+				return "", io.EOF
+			}
+			e.deleteAt(e.pos)
+		case 127, 8: // Backspace
+			if e.pos > 0 {
+				e.pos--
+				e.deleteAt(e.pos)
+			}
+		case '\t':
+			e.completeLine()
+		case 1: // Ctrl+A
+			e.pos = 0
+		case 5: // Ctrl+E
+			e.pos = len(e.line)
+		case 2: // Ctrl+B
+			e.moveLeft()
+		case 6: // Ctrl+F
+			e.moveRight()
+		case 11: // Ctrl+K
+			e.line = e.line[:e.pos]
+		case 21: // Ctrl+U
+			e.line = append([]rune{}, e.line[e.pos:]...)
+			e.pos = 0
+		case 23: // Ctrl+W
+			e.deleteWord()
+		case 12: // Ctrl+L
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+		case 16: // Ctrl+P
+			historyPos, pending = e.recall(historyPos-1, historyPos, pending)
+		case 14: // Ctrl+N
+			historyPos, pending = e.recall(historyPos+1, historyPos, pending)
+		case 27: // ESC 转义序列
+			switch e.readEscape() {
+			case "[A", "OA":
+				historyPos, pending = e.recall(historyPos-1, historyPos, pending)
+			case "[B", "OB":
+				historyPos, pending = e.recall(historyPos+1, historyPos, pending)
+			case "[C", "OC":
+				e.moveRight()
+			case "[D", "OD":
+				e.moveLeft()
+			case "[H", "OH", "[1~", "[7~":
+				e.pos = 0
+			case "[F", "OF", "[4~", "[8~":
+				e.pos = len(e.line)
+			case "[3~":
+				e.deleteAt(e.pos)
+			}
+		default:
+			if unicode.IsPrint(r) {
+				e.line = append(e.line[:e.pos], append([]rune{r}, e.line[e.pos:]...)...)
+				e.pos++
+			}
+		}
+		e.refresh()
+	}
+}
+
+// readEscape 读取 ESC 之后的控制序列，如 "[A"、"[3~"
+func (e *lineEditor) readEscape() string {
+	first, _, err := e.in.ReadRune()
+	if err != nil || (first != '[' && first != 'O') {
+		return ""
+	}
+	seq := []rune{first}
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return string(seq)
+		}
+		seq = append(seq, r)
+		if r >= '@' && r <= '~' && !(r >= '0' && r <= '9') {
+			return string(seq)
+		}
+	}
+}
+
+// recall 切换到第 to 条历史记录，越过末尾时恢复正在编辑的内容
+func (e *lineEditor) recall(to, from int, pending string) (int, string) {
+	if to < 0 || to > e.history.Len() {
+		return from, pending
+	}
+	if from == e.history.Len() {
+		pending = string(e.line)
+	}
+	if to == e.history.Len() {
+		e.line = []rune(pending)
+	} else {
+		e.line = []rune(e.history.At(to))
+	}
+	e.pos = len(e.line)
+	return to, pending
+}
+
+func (e *lineEditor) moveLeft() {
+	if e.pos > 0 {
+		e.pos--
+	}
+}
+
+func (e *lineEditor) moveRight() {
+	if e.pos < len(e.line) {
+		e.pos++
+	}
+}
+
+// deleteAt 删除指定位置的字符
+func (e *lineEditor) deleteAt(i int) {
+	if i >= 0 && i < len(e.line) {
+		e.line = append(e.line[:i], e.line[i+1:]...)
+	}
+}
+
+// deleteWord 删除光标前的一个单词
+func (e *lineEditor) deleteWord() {
+	start := e.pos
+	for start > 0 && e.line[start-1] == ' ' {
+		start--
+	}
+	for start > 0 && e.line[start-1] != ' ' {
+		start--
+	}
+	e.line = append(e.line[:start], e.line[e.pos:]...)
+	e.pos = start
+}
+
+// completeLine 补全光标前的内容：唯一候选直接补全，多个候选补全公共前缀并列出
+func (e *lineEditor) completeLine() {
+	if e.complete == nil {
+		return
+	}
+	candidates, prefix := e.complete(string(e.line[:e.pos]))
+	if len(candidates) == 0 {
+		fmt.Fprint(e.out, "\a")
+		return
+	}
+
+	common := candidates[0]
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(c, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	if insert := []rune(strings.TrimPrefix(common, prefix)); len(insert) > 0 {
+		e.line = append(e.line[:e.pos], append(insert, e.line[e.pos:]...)...)
+		e.pos += len(insert)
+		return
+	}
+	if len(candidates) > 1 {
+		fmt.Fprint(e.out, "\n"+formatColumns(candidates, 80))
+	}
+}
+
+// refresh 重绘当前行并把光标移到正确位置
+func (e *lineEditor) refresh() {
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", e.prompt, string(e.line))
+	if back := displayWidth(e.line[e.pos:]); back > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", back)
+	}
+}
+
+// formatColumns 将候选项按列排列
+func formatColumns(items []string, width int) string {
+	maxLen := 0
+	for _, item := range items {
+		maxLen = max(maxLen, len(item))
+	}
+	colWidth := maxLen + 2
+	columns := max(width/colWidth, 1)
+
+	var b strings.Builder
+	for i, item := range items {
+		b.WriteString(item)
+		if (i+1)%columns == 0 || i == len(items)-1 {
+			b.WriteString("\n")
+		} else {
+			b.WriteString(strings.Repeat(" ", colWidth-len(item)))
+		}
+	}
+	return b.String()
+}
+
+// displayWidth 计算字符在终端中占用的列数，中日韩等宽字符占两列
+func displayWidth(runes []rune) int {
+	width := 0
+	for _, r := range runes {
+		if isWide(r) {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// isWide 判断是否为全角字符
+func isWide(r rune) bool {
+	return r >= 0x1100 && (r <= 0x115f || r == 0x2329 || r == 0x232a ||
+		(r >= 0x2e80 && r <= 0xa4cf && r != 0x303f) ||
+		(r >= 0xac00 && r <= 0xd7a3) ||
+		(r >= 0xf900 && r <= 0xfaff) ||
+		(r >= 0xfe30 && r <= 0xfe6f) ||
+		(r >= 0xff00 && r <= 0xff60) ||
+		(r >= 0xffe0 && r <= 0xffe6) ||
+		(r >= 0x1f300 && r <= 0x1f64f) ||
+		(r >= 0x1f900 && r <= 0x1f9ff) ||
+		(r >= 0x20000 && r <= 0x3fffd))
+}
@@ -0,0 +1,80 @@
+package repl
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxHistory 历史记录最多保存的条数
+const maxHistory = 1000
+
+// history 输入历史，持久化到文件中，每行一条
+type history struct {
+	file    string
+	entries []string
+}
+
+// loadHistory 从文件加载历史记录，file 为空时不持久化
+func loadHistory(file string) *history {
+	h := &history{file: file}
+	if file == "" {
+		return h
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return h
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+	h.trim()
+	return h
+}
+
+// Len 历史记录条数
+func (h *history) Len() int {
+	return len(h.entries)
+}
+
+// At 获取第 i 条历史记录
+func (h *history) At(i int) string {
+	return h.entries[i]
+}
+
+// Add 添加一条记录，忽略空行与连续重复的输入
+func (h *history) Add(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if n := len(h.entries); n > 0 && h.entries[n-1] == line {
+		return
+	}
+	h.entries = append(h.entries, line)
+	h.trim()
+}
+
+// trim 只保留最近的 maxHistory 条记录
+func (h *history) trim() {
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+	}
+}
+
+// Save 将历史记录写回文件
+func (h *history) Save() error {
+	if h.file == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(h.file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(h.file, []byte(strings.Join(h.entries, "\n")+"\n"), 0600)
+}
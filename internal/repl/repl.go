@@ -0,0 +1,274 @@
+// Package repl 实现交互式命令行：行编辑、多行输入、历史记录与 Tab 补全
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
+)
+
+// HistoryFileEnv 指定历史记录文件的环境变量，设置为空字符串时不保存历史
+const HistoryFileEnv = "SW_RUNTIME_REPL_HISTORY"
+
+// Options REPL 选项
+type Options struct {
+	In          *os.File  // 输入，默认 os.Stdin
+	Out         io.Writer // 输出，默认 os.Stdout
+	HistoryFile string    // 历史记录文件，为空时不保存
+	Banner      string    // 终端模式下启动时显示的欢迎信息
+}
+
+// DefaultHistoryFile 获取默认的历史记录文件路径（~/.sw_runtime_repl_history），
+// 可通过 SW_RUNTIME_REPL_HISTORY 环境变量覆盖
+func DefaultHistoryFile() string {
+	if file, ok := os.LookupEnv(HistoryFileEnv); ok {
+		return file
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".sw_runtime_repl_history")
+}
+
+// IsTerminal 检查文件是否连接到终端
+func IsTerminal(f *os.File) bool {
+	return isTerminal(int(f.Fd()))
+}
+
+// command 以 . 开头的 REPL 命令
+type command struct {
+	help string
+	run  func(s *shell, arg string) bool // 返回 false 表示退出
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		".help":  {"显示帮助信息", (*shell).cmdHelp},
+		".exit":  {"退出 REPL", func(*shell, string) bool { return false }},
+		".break": {"放弃当前的多行输入", (*shell).cmdBreak},
+		".load":  {"在当前会话中执行文件: .load <file>", (*shell).cmdLoad},
+		".save":  {"将本次会话的输入保存到文件: .save <file>", (*shell).cmdSave},
+	}
+}
+
+// shell 一次交互会话
+type shell struct {
+	session *runtime.REPL
+	reader  lineReader
+	out     io.Writer
+	history *history
+
+	buffer []string // 多行输入中已读取的行
+	inputs []string // 本次会话执行过的输入，用于 .save
+}
+
+// Run 运行交互式会话，直到输入 .exit、Ctrl+D 或输入流结束。
+// 终端输入启用行编辑与补全，其他输入（如管道）按行读取并执行。
+func Run(session *runtime.REPL, opts Options) error {
+	in := opts.In
+	if in == nil {
+		in = os.Stdin
+	}
+	out := opts.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	s := &shell{session: session, out: out, history: loadHistory("")}
+	reader := bufio.NewReader(in)
+	if IsTerminal(in) {
+		s.history = loadHistory(opts.HistoryFile)
+		s.reader = &lineEditor{
+			fd:       int(in.Fd()),
+			in:       reader,
+			out:      out,
+			history:  s.history,
+			complete: s.complete,
+		}
+		if opts.Banner != "" {
+			fmt.Fprintln(out, opts.Banner)
+		}
+	} else {
+		s.reader = &plainReader{in: reader}
+	}
+
+	err := s.loop()
+	if saveErr := s.history.Save(); err == nil {
+		err = saveErr
+	}
+	return err
+}
+
+// loop 读取-求值-输出循环
+func (s *shell) loop() error {
+	interrupted := false
+	for {
+		prompt := "> "
+		if len(s.buffer) > 0 {
+			prompt = "... "
+		}
+
+		line, err := s.reader.ReadLine(prompt)
+		if errors.Is(err, errLineInterrupted) {
+			// 多行输入中按 Ctrl+C 放弃输入，空行上连续按两次退出
+			if len(s.buffer) > 0 {
+				s.buffer = nil
+				interrupted = false
+				continue
+			}
+			if interrupted {
+				return nil
+			}
+			interrupted = true
+			fmt.Fprintln(s.out, "(再按一次 Ctrl+C 退出，或按 Ctrl+D、输入 .exit)")
+			continue
+		}
+		interrupted = false
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		s.history.Add(line)
+
+		if name, arg, ok := parseCommand(line); ok {
+			if !commands[name].run(s, arg) {
+				return nil
+			}
+			continue
+		}
+
+		s.buffer = append(s.buffer, line)
+		input := strings.Join(s.buffer, "\n")
+		output, err := s.eval(input)
+		if errors.Is(err, runtime.ErrIncompleteInput) {
+			continue
+		}
+		s.buffer = nil
+		s.inputs = append(s.inputs, input)
+		s.print(output, err)
+	}
+}
+
+// parseCommand 解析 . 开头的命令，未知命令（如 .5、.then(...)）按代码处理
+func parseCommand(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	name, arg, _ := strings.Cut(line, " ")
+	if _, ok := commands[name]; !ok {
+		return "", "", false
+	}
+	return name, strings.TrimSpace(arg), true
+}
+
+// eval 执行输入，执行期间按 Ctrl+C 中断
+func (s *shell) eval(input string) (string, error) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT)
+	defer signal.Stop(sigChan)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-sigChan:
+			s.session.Interrupt()
+		case <-done:
+		}
+	}()
+
+	return s.session.Eval(input)
+}
+
+// print 输出求值结果或错误
+func (s *shell) print(output string, err error) {
+	switch {
+	case errors.Is(err, runtime.ErrInterrupted):
+		fmt.Fprintln(s.out, "执行已被 Ctrl+C 中断")
+	case err != nil:
+		fmt.Fprintln(s.out, err.Error())
+	case output != "":
+		fmt.Fprintln(s.out, output)
+	}
+}
+
+// complete 补全命令名或 JS 表达式
+func (s *shell) complete(line string) ([]string, string) {
+	if len(s.buffer) == 0 && strings.HasPrefix(line, ".") && !strings.Contains(line, " ") {
+		var names []string
+		for name := range commands {
+			if strings.HasPrefix(name, line) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		return names, line
+	}
+	return s.session.Complete(line)
+}
+
+func (s *shell) cmdHelp(string) bool {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(s.out, "%-8s %s\n", name, commands[name].help)
+	}
+	fmt.Fprintln(s.out, "\n支持 TypeScript 与顶层 await，_ 保存上一次的结果，_error 保存上一次的异常")
+	fmt.Fprintln(s.out, "按 Tab 补全，Ctrl+C 中断执行或放弃输入，Ctrl+D 退出")
+	return true
+}
+
+func (s *shell) cmdBreak(string) bool {
+	s.buffer = nil
+	return true
+}
+
+func (s *shell) cmdLoad(file string) bool {
+	if file == "" {
+		fmt.Fprintln(s.out, "用法: .load <file>")
+		return true
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(s.out, "读取文件失败: %v\n", err)
+		return true
+	}
+	output, err := s.eval(string(content))
+	if errors.Is(err, runtime.ErrIncompleteInput) {
+		fmt.Fprintf(s.out, "%s: 输入不完整\n", file)
+		return true
+	}
+	s.inputs = append(s.inputs, strings.TrimRight(string(content), "\n"))
+	s.print(output, err)
+	return true
+}
+
+func (s *shell) cmdSave(file string) bool {
+	if file == "" {
+		fmt.Fprintln(s.out, "用法: .save <file>")
+		return true
+	}
+	content := strings.Join(s.inputs, "\n") + "\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		fmt.Fprintf(s.out, "保存失败: %v\n", err)
+		return true
+	}
+	fmt.Fprintf(s.out, "会话已保存到 %s\n", file)
+	return true
+}
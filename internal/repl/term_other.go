@@ -0,0 +1,15 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+
+package repl
+
+import "errors"
+
+// isTerminal 当前平台不支持行编辑，按普通输入流逐行读取
+func isTerminal(fd int) bool {
+	return false
+}
+
+// makeRaw 当前平台不支持原始模式
+func makeRaw(fd int) (func(), error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
@@ -0,0 +1,155 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/issueye/sw_runtime/internal/sourcemap"
+
+	"github.com/dop251/goja"
+)
+
+// ErrLoopStopped 事件循环已停止（Runner 已关闭或收到中断信号）时 Do 和 Call 返回的错误
+var ErrLoopStopped = errors.New("event loop is stopped")
+
+// RejectedError Call 调用的函数返回的 Promise 被拒绝
+type RejectedError struct {
+	Reason  interface{} // 拒绝原因导出的 Go 值
+	message string
+	cause   error
+}
+
+// Error 实现 error 接口，包含 JS 错误的消息和调用栈
+func (e *RejectedError) Error() string {
+	return e.message
+}
+
+// Unwrap 拒绝原因是 Go 错误（如 PermissionDeniedError）时返回该错误
+func (e *RejectedError) Unwrap() error {
+	return e.cause
+}
+
+// Do 在事件循环中执行 fn 并等待完成，脚本的异步任务仍在运行时也可以安全地访问 VM。
+// fn 中抛出的 JS 异常作为错误返回；ctx 超时或取消的处理与 RunCodeContext 相同
+func (r *Runner) Do(ctx context.Context, fn func(vm *goja.Runtime) error) error {
+	select {
+	case <-r.loop.Done():
+		return ErrLoopStopped
+	default:
+	}
+	r.startLoop()
+	return r.do(ctx, fn)
+}
+
+// do 在已启动的事件循环中执行 fn 并等待完成
+func (r *Runner) do(ctx context.Context, fn func(vm *goja.Runtime) error) error {
+	select {
+	case <-r.loop.Done():
+		return ErrLoopStopped
+	default:
+	}
+
+	done := make(chan error, 1)
+	r.loop.RunOnLoop(func(vm *goja.Runtime) {
+		defer func() {
+			if v := recover(); v != nil {
+				if exception, ok := v.(*goja.Exception); ok {
+					done <- exception
+					return
+				}
+				done <- fmt.Errorf("runtime panic: %v", v)
+			}
+		}()
+		done <- fn(vm)
+	})
+	return r.wait(ctx, done)
+}
+
+// Call 在事件循环中以 this 调用 JS 函数，参数按 SetValue 的规则转换。
+// 返回 Promise 时等待其完成并返回结果，Promise 被拒绝时返回 *RejectedError。
+// 返回值只应在 Do 中读取，避免与事件循环中的 JS 并发访问
+func (r *Runner) Call(ctx context.Context, fn, this goja.Value, args ...interface{}) (goja.Value, error) {
+	var (
+		result  goja.Value
+		promise *goja.Promise
+	)
+	settled := make(chan error, 1)
+	err := r.Do(ctx, func(vm *goja.Runtime) error {
+		callable, ok := goja.AssertFunction(fn)
+		if !ok {
+			return fmt.Errorf("value is not a function: %v", fn)
+		}
+		values := make([]goja.Value, len(args))
+		for i, arg := range args {
+			values[i] = vm.ToValue(arg)
+		}
+		if this == nil {
+			this = goja.Undefined()
+		}
+		v, err := callable(this, values...)
+		if err != nil {
+			return err
+		}
+
+		p, ok := v.Export().(*goja.Promise)
+		if !ok {
+			result = v
+			settled <- nil
+			return nil
+		}
+		promise = p
+		then, _ := goja.AssertFunction(v.(*goja.Object).Get("then"))
+		_, err = then(v,
+			vm.ToValue(func(goja.FunctionCall) goja.Value {
+				settled <- nil
+				return goja.Undefined()
+			}),
+			vm.ToValue(func(call goja.FunctionCall) goja.Value {
+				settled <- newRejectedError(call.Argument(0))
+				return goja.Undefined()
+			}))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.wait(ctx, settled); err != nil {
+		return nil, err
+	}
+	if promise != nil {
+		result = promise.Result()
+	}
+	return result, nil
+}
+
+// wait 等待 ch 返回结果，ctx 结束时中断 VM，事件循环停止时返回 ErrLoopStopped
+func (r *Runner) wait(ctx context.Context, ch <-chan error) error {
+	return r.runContext(ctx, func() error {
+		select {
+		case err := <-ch:
+			return err
+		case <-r.loop.Done():
+			return ErrLoopStopped
+		}
+	})
+}
+
+// newRejectedError 根据 Promise 的拒绝原因创建错误，必须在事件循环中调用
+func newRejectedError(reason goja.Value) *RejectedError {
+	e := &RejectedError{message: sourcemap.FormatError(reason)}
+	if reason == nil {
+		return e
+	}
+	e.Reason = reason.Export()
+	if obj, ok := reason.(*goja.Object); ok {
+		// vm.NewGoError 创建的错误在 value 属性中保存原始 Go 错误
+		if value := obj.Get("value"); value != nil {
+			if cause, ok := value.Export().(error); ok {
+				e.cause = cause
+			}
+		}
+	}
+	return e
+}
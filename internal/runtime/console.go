@@ -0,0 +1,541 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/issueye/sw_runtime/internal/builtins/utils"
+
+	"github.com/dop251/goja"
+)
+
+// LogLevel 控制台输出级别，低于设置级别的输出会被丢弃
+type LogLevel int
+
+const (
+	LogLevelDebug  LogLevel = iota // console.debug、console.trace
+	LogLevelInfo                   // console.log、info、dir、table、time、count、group
+	LogLevelWarn                   // console.warn
+	LogLevelError                  // console.error、console.assert
+	LogLevelSilent                 // 不输出
+)
+
+var logLevelNames = []string{"debug", "info", "warn", "error", "silent"}
+
+// String 返回级别名称
+func (l LogLevel) String() string {
+	if l < 0 || int(l) >= len(logLevelNames) {
+		return strconv.Itoa(int(l))
+	}
+	return logLevelNames[l]
+}
+
+// ParseLogLevel 解析级别名称：debug、info、warn、error、silent
+func ParseLogLevel(name string) (LogLevel, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "warning" {
+		name = "warn"
+	}
+	for i, n := range logLevelNames {
+		if n == name {
+			return LogLevel(i), nil
+		}
+	}
+	return LogLevelDebug, fmt.Errorf("invalid log level %q (expected debug, info, warn, error or silent)", name)
+}
+
+// ConsoleOptions console 对象的输出选项
+type ConsoleOptions struct {
+	Level  LogLevel  // 最低输出级别
+	JSON   bool      // 每次输出一行 JSON（time、level、msg），便于日志采集
+	Colors bool      // 格式化对象时使用 ANSI 颜色，JSON 模式下忽略
+	Stdout io.Writer // log、info、debug 等的输出，nil 表示 os.Stdout
+	Stderr io.Writer // warn、error、trace、assert 的输出，nil 表示 os.Stderr
+}
+
+// DefaultConsoleOptions 返回默认选项：输出全部级别，标准输出是终端且未设置 NO_COLOR 时使用颜色
+func DefaultConsoleOptions() ConsoleOptions {
+	return ConsoleOptions{
+		Level:  LogLevelDebug,
+		Colors: isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == "",
+	}
+}
+
+// isTerminal 检查文件是否为字符设备（终端）
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// console 实现全局 console 对象，格式化规则参照 Node.js
+type console struct {
+	vm *goja.Runtime
+
+	mu     sync.Mutex
+	opts   ConsoleOptions
+	groups []string             // 当前 group 的标签，决定缩进层数
+	counts map[string]int       // console.count 计数
+	timers map[string]time.Time // console.time 起始时间
+}
+
+// newConsole 创建 console
+func newConsole(vm *goja.Runtime) *console {
+	return &console{
+		vm:     vm,
+		opts:   DefaultConsoleOptions(),
+		counts: make(map[string]int),
+		timers: make(map[string]time.Time),
+	}
+}
+
+// options 获取当前选项
+func (c *console) options() ConsoleOptions {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.opts
+}
+
+// setOptions 设置选项
+func (c *console) setOptions(opts ConsoleOptions) {
+	c.mu.Lock()
+	c.opts = opts
+	c.mu.Unlock()
+}
+
+// reset 清除 group 缩进、计数和计时
+func (c *console) reset() {
+	c.mu.Lock()
+	c.groups = nil
+	c.counts = make(map[string]int)
+	c.timers = make(map[string]time.Time)
+	c.mu.Unlock()
+}
+
+// object 创建 JS 的 console 对象
+func (c *console) object() *goja.Object {
+	obj := c.vm.NewObject()
+	obj.Set("log", c.logger(LogLevelInfo, false))
+	obj.Set("info", c.logger(LogLevelInfo, false))
+	obj.Set("debug", c.logger(LogLevelDebug, false))
+	obj.Set("warn", c.logger(LogLevelWarn, true))
+	obj.Set("error", c.logger(LogLevelError, true))
+	obj.Set("dirxml", c.logger(LogLevelInfo, false))
+	obj.Set("trace", c.trace)
+	obj.Set("dir", c.dir)
+	obj.Set("table", c.table)
+	obj.Set("assert", c.assert)
+	obj.Set("count", c.count)
+	obj.Set("countReset", c.countReset)
+	obj.Set("time", c.time)
+	obj.Set("timeEnd", c.timeEnd)
+	obj.Set("timeLog", c.timeLog)
+	obj.Set("group", c.group)
+	obj.Set("groupCollapsed", c.group)
+	obj.Set("groupEnd", c.groupEnd)
+	obj.Set("clear", c.clear)
+	return obj
+}
+
+// inspectOptions 格式化对象使用的选项
+func (c *console) inspectOptions() utils.InspectOptions {
+	opts := utils.DefaultInspectOptions
+	o := c.options()
+	opts.Colors = o.Colors && !o.JSON
+	return opts
+}
+
+// format 按 printf 风格格式化参数
+func (c *console) format(args []goja.Value) string {
+	return utils.Format(c.vm, args, c.inspectOptions())
+}
+
+// logger 创建按级别输出的方法
+func (c *console) logger(level LogLevel, stderr bool) func(call goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		if c.enabled(level) {
+			c.print(level, stderr, c.format(call.Arguments))
+		}
+		return goja.Undefined()
+	}
+}
+
+// enabled 检查级别是否需要输出，避免为被丢弃的输出格式化参数
+func (c *console) enabled(level LogLevel) bool {
+	return level >= c.options().Level
+}
+
+// consoleRecord JSON 模式下的一条输出
+type consoleRecord struct {
+	Time  string   `json:"time"`
+	Level string   `json:"level"`
+	Msg   string   `json:"msg"`
+	Group []string `json:"group,omitempty"`
+}
+
+// print 输出一条消息：文本模式按 group 缩进，JSON 模式输出一行 JSON
+func (c *console) print(level LogLevel, stderr bool, msg string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if level < c.opts.Level {
+		return
+	}
+
+	w := c.opts.Stdout
+	if stderr {
+		w = c.opts.Stderr
+	}
+	if w == nil {
+		w = os.Stdout
+		if stderr {
+			w = os.Stderr
+		}
+	}
+
+	var buf bytes.Buffer
+	if c.opts.JSON {
+		record := consoleRecord{
+			Time:  time.Now().Format(time.RFC3339Nano),
+			Level: level.String(),
+			Msg:   msg,
+			Group: c.groups,
+		}
+		data, _ := json.Marshal(record)
+		buf.Write(data)
+	} else {
+		indent := strings.Repeat("  ", len(c.groups))
+		buf.WriteString(indent)
+		buf.WriteString(strings.ReplaceAll(msg, "\n", "\n"+indent))
+	}
+	buf.WriteByte('\n')
+	w.Write(buf.Bytes())
+}
+
+// warning 输出 console 自身的警告，如计时器标签不存在
+func (c *console) warning(format string, args ...interface{}) {
+	c.print(LogLevelWarn, true, "Warning: "+fmt.Sprintf(format, args...))
+}
+
+// trace 输出消息和当前调用栈
+func (c *console) trace(call goja.FunctionCall) goja.Value {
+	if !c.enabled(LogLevelDebug) {
+		return goja.Undefined()
+	}
+	var b bytes.Buffer
+	b.WriteString("Trace")
+	if len(call.Arguments) > 0 {
+		b.WriteString(": " + c.format(call.Arguments))
+	}
+	frames := c.vm.CaptureCallStack(0, nil)
+	if len(frames) > 0 {
+		// 第一帧是 console.trace 本身
+		frames = frames[1:]
+	}
+	for _, frame := range frames {
+		b.WriteString("\n    at ")
+		frame.Write(&b)
+	}
+	c.print(LogLevelDebug, true, b.String())
+	return goja.Undefined()
+}
+
+// dir 使用 util.inspect 输出对象，支持 depth、colors 选项
+func (c *console) dir(call goja.FunctionCall) goja.Value {
+	if !c.enabled(LogLevelInfo) {
+		return goja.Undefined()
+	}
+	opts := c.inspectOptions()
+	if optObj, ok := call.Argument(1).(*goja.Object); ok {
+		if v := optObj.Get("depth"); v != nil && !goja.IsUndefined(v) {
+			opts.Depth = -1
+			if !goja.IsNull(v) && !math.IsInf(v.ToFloat(), 1) {
+				opts.Depth = int(v.ToInteger())
+			}
+		}
+		if v := optObj.Get("colors"); v != nil && !goja.IsUndefined(v) {
+			opts.Colors = v.ToBoolean() && !c.options().JSON
+		}
+	}
+	c.print(LogLevelInfo, false, utils.Inspect(c.vm, call.Argument(0), opts))
+	return goja.Undefined()
+}
+
+// assert 断言失败时以 error 级别输出 "Assertion failed"
+func (c *console) assert(call goja.FunctionCall) goja.Value {
+	if call.Argument(0).ToBoolean() || !c.enabled(LogLevelError) {
+		return goja.Undefined()
+	}
+	data := call.Arguments
+	if len(data) > 0 {
+		data = data[1:]
+	}
+	msg := "Assertion failed"
+	if len(data) > 0 {
+		args := append([]goja.Value{c.vm.ToValue("Assertion failed:")}, data...)
+		if goja.IsString(data[0]) {
+			args = append([]goja.Value{c.vm.ToValue("Assertion failed: " + data[0].String())}, data[1:]...)
+		}
+		msg = c.format(args)
+	}
+	c.print(LogLevelError, true, msg)
+	return goja.Undefined()
+}
+
+// label 获取计数器和计时器的标签，默认为 default
+func label(call goja.FunctionCall) string {
+	if v := call.Argument(0); !goja.IsUndefined(v) {
+		return v.String()
+	}
+	return "default"
+}
+
+// count 输出标签被调用的次数
+func (c *console) count(call goja.FunctionCall) goja.Value {
+	name := label(call)
+	c.mu.Lock()
+	c.counts[name]++
+	n := c.counts[name]
+	c.mu.Unlock()
+	c.print(LogLevelInfo, false, fmt.Sprintf("%s: %d", name, n))
+	return goja.Undefined()
+}
+
+// countReset 重置计数
+func (c *console) countReset(call goja.FunctionCall) goja.Value {
+	name := label(call)
+	c.mu.Lock()
+	_, ok := c.counts[name]
+	delete(c.counts, name)
+	c.mu.Unlock()
+	if !ok {
+		c.warning("Count for '%s' does not exist", name)
+	}
+	return goja.Undefined()
+}
+
+// time 开始计时
+func (c *console) time(call goja.FunctionCall) goja.Value {
+	name := label(call)
+	c.mu.Lock()
+	_, exists := c.timers[name]
+	if !exists {
+		c.timers[name] = time.Now()
+	}
+	c.mu.Unlock()
+	if exists {
+		c.warning("Label '%s' already exists for console.time()", name)
+	}
+	return goja.Undefined()
+}
+
+// timeEnd 输出耗时并结束计时
+func (c *console) timeEnd(call goja.FunctionCall) goja.Value {
+	c.logTime(call, "console.timeEnd()", true)
+	return goja.Undefined()
+}
+
+// timeLog 输出耗时和附加数据，不结束计时
+func (c *console) timeLog(call goja.FunctionCall) goja.Value {
+	c.logTime(call, "console.timeLog()", false)
+	return goja.Undefined()
+}
+
+// logTime 输出计时器的耗时
+func (c *console) logTime(call goja.FunctionCall, method string, end bool) {
+	name := label(call)
+	c.mu.Lock()
+	start, ok := c.timers[name]
+	if ok && end {
+		delete(c.timers, name)
+	}
+	c.mu.Unlock()
+	if !ok {
+		c.warning("No such label '%s' for %s", name, method)
+		return
+	}
+
+	msg := fmt.Sprintf("%s: %s", name, formatDuration(time.Since(start)))
+	if !end && len(call.Arguments) > 1 {
+		msg += " " + c.format(call.Arguments[1:])
+	}
+	c.print(LogLevelInfo, false, msg)
+}
+
+// formatDuration 格式化耗时，格式与 Node.js 相同：1.234ms、1.234s、1:02.345 (m:ss.mmm)
+func formatDuration(d time.Duration) string {
+	ms := float64(d) / float64(time.Millisecond)
+	if d < time.Second {
+		return strconv.FormatFloat(math.Round(ms*1000)/1000, 'f', -1, 64) + "ms"
+	}
+	if d < time.Minute {
+		return fmt.Sprintf("%.3fs", d.Seconds())
+	}
+	hours := int(d / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+	seconds := float64(d%time.Minute) / float64(time.Second)
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%06.3f (h:mm:ss.mmm)", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%d:%06.3f (m:ss.mmm)", minutes, seconds)
+}
+
+// group 输出标签并增加后续输出的缩进
+func (c *console) group(call goja.FunctionCall) goja.Value {
+	msg := ""
+	if len(call.Arguments) > 0 {
+		msg = c.format(call.Arguments)
+		c.print(LogLevelInfo, false, msg)
+	}
+	c.mu.Lock()
+	c.groups = append(c.groups, msg)
+	c.mu.Unlock()
+	return goja.Undefined()
+}
+
+// groupEnd 减少缩进
+func (c *console) groupEnd(call goja.FunctionCall) goja.Value {
+	c.mu.Lock()
+	if len(c.groups) > 0 {
+		c.groups = c.groups[:len(c.groups)-1]
+	}
+	c.mu.Unlock()
+	return goja.Undefined()
+}
+
+// clear 标准输出是终端时清屏
+func (c *console) clear(call goja.FunctionCall) goja.Value {
+	opts := c.options()
+	if opts.Colors && !opts.JSON && opts.Stdout == nil {
+		os.Stdout.WriteString("\x1b[1;1H\x1b[0J")
+	}
+	return goja.Undefined()
+}
+
+// table 以表格形式输出数组或对象，非对象参数按 console.log 输出
+func (c *console) table(call goja.FunctionCall) goja.Value {
+	data, ok := call.Argument(0).(*goja.Object)
+	if !ok {
+		return c.logger(LogLevelInfo, false)(call)
+	}
+	if !c.enabled(LogLevelInfo) {
+		return goja.Undefined()
+	}
+
+	// 指定要显示的列
+	var properties []string
+	if props, ok := call.Argument(1).(*goja.Object); ok {
+		if list, ok := props.Export().([]interface{}); ok {
+			for _, p := range list {
+				properties = append(properties, fmt.Sprint(p))
+			}
+		}
+	}
+
+	cell := func(v goja.Value) string {
+		opts := c.inspectOptions()
+		opts.Depth = 0
+		opts.BreakLength = math.MaxInt32
+		if obj, ok := v.(*goja.Object); ok && obj.ClassName() != "Array" && len(obj.Keys()) > 2 {
+			opts.Depth = -1
+		}
+		return utils.Inspect(c.vm, v, opts)
+	}
+
+	indexes := data.Keys()
+	var columns []string
+	values := make(map[string]map[int]string)
+	primitives := make(map[int]string)
+	for i, index := range indexes {
+		item := data.Get(index)
+		itemObj, isObj := item.(*goja.Object)
+		if properties == nil && !isObj {
+			primitives[i] = cell(item)
+			continue
+		}
+		keys := properties
+		if keys == nil {
+			keys = itemObj.Keys()
+		}
+		for _, key := range keys {
+			if _, ok := values[key]; !ok {
+				columns = append(columns, key)
+				values[key] = make(map[int]string)
+			}
+			if isObj && hasOwn(itemObj, key) {
+				values[key][i] = cell(itemObj.Get(key))
+			}
+		}
+	}
+
+	head := append([]string{"(index)"}, columns...)
+	if len(primitives) > 0 {
+		head = append(head, "Values")
+	}
+	rows := make([][]string, len(indexes))
+	for i, index := range indexes {
+		row := []string{index}
+		for _, col := range columns {
+			row = append(row, values[col][i])
+		}
+		if len(primitives) > 0 {
+			row = append(row, primitives[i])
+		}
+		rows[i] = row
+	}
+
+	c.print(LogLevelInfo, false, renderTable(head, rows))
+	return goja.Undefined()
+}
+
+// hasOwn 检查对象自身是否有该属性
+func hasOwn(obj *goja.Object, key string) bool {
+	for _, k := range obj.Keys() {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// renderTable 使用制表符绘制表格，单元格内容居中
+func renderTable(head []string, rows [][]string) string {
+	widths := make([]int, len(head))
+	for i, h := range head {
+		widths[i] = utils.VisibleLen(h)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utils.VisibleLen(cell))
+		}
+	}
+
+	divider := make([]string, len(widths))
+	for i, w := range widths {
+		divider[i] = strings.Repeat("─", w+2)
+	}
+	renderRow := func(cells []string) string {
+		parts := make([]string, len(cells))
+		for i, cell := range cells {
+			needed := widths[i] - utils.VisibleLen(cell)
+			parts[i] = strings.Repeat(" ", needed/2) + cell + strings.Repeat(" ", needed-needed/2)
+		}
+		return "│ " + strings.Join(parts, " │ ") + " │"
+	}
+
+	var b strings.Builder
+	b.WriteString("┌" + strings.Join(divider, "┬") + "┐\n")
+	b.WriteString(renderRow(head) + "\n")
+	b.WriteString("├" + strings.Join(divider, "┼") + "┤\n")
+	for _, row := range rows {
+		b.WriteString(renderRow(row) + "\n")
+	}
+	b.WriteString("└" + strings.Join(divider, "┴") + "┘")
+	return b.String()
+}
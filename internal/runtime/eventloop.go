@@ -3,16 +3,19 @@ package runtime
 import (
 	"container/heap"
 	"context"
+	"errors"
 	"os"
 	"os/signal"
-	"sw_runtime/internal/builtins/http"
-	"sw_runtime/internal/builtins/net"
-	"sw_runtime/internal/pool"
 	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/issueye/sw_runtime/internal/builtins/http"
+	"github.com/issueye/sw_runtime/internal/builtins/net"
+	"github.com/issueye/sw_runtime/internal/builtins/stream"
+	"github.com/issueye/sw_runtime/internal/pool"
+
 	"github.com/dop251/goja"
 )
 
@@ -35,10 +38,16 @@ type EventLoop struct {
 	vmQueue chan vmTask
 	vmMu    sync.Mutex // 备用锁，用于非队列场景
 
+	// setImmediate 回调队列
+	immediates        []*immediateTask
+	immediateMu       sync.Mutex
+	runningImmediates []*immediateTask // 正在执行的一批回调，只在持有 vmMu 时访问
+
 	// 状态管理
 	running      atomic.Bool
 	activeJobs   atomic.Int32
 	hasLongLived atomic.Bool
+	executing    atomic.Bool // vmProcessor 正在执行回调
 
 	// 生命周期控制
 	ctx       context.Context
@@ -48,6 +57,11 @@ type EventLoop struct {
 
 	// 配置
 	idleTimeout time.Duration // 空闲超时时间
+
+	// 生命周期钩子，在启动事件循环前设置
+	onError   func(error)          // 回调抛出未捕获的异常，在 VM 线程中调用
+	onSignal  func(os.Signal) bool // 收到信号，返回 true 表示已处理、不停止事件循环
+	afterTask func()               // 每个任务及其微任务执行完后调用，在 VM 线程中调用
 }
 
 // vmTask VM 任务
@@ -65,6 +79,14 @@ type timerTask struct {
 	index    int // 堆索引
 }
 
+// immediateTask setImmediate 任务
+type immediateTask struct {
+	id       int
+	fn       goja.Callable
+	args     []goja.Value
+	canceled atomic.Bool
+}
+
 // intervalTask 间隔定时器任务
 type intervalTask struct {
 	id       int
@@ -146,7 +168,12 @@ func (el *EventLoop) vmProcessor() {
 		case <-el.ctx.Done():
 			return
 		case task := <-el.vmQueue:
+			el.executing.Store(true)
 			el.safeExecute(task.fn)
+			if el.afterTask != nil {
+				el.safeExecute(el.afterTask)
+			}
+			el.executing.Store(false)
 			if task.done != nil {
 				close(task.done)
 			}
@@ -217,10 +244,11 @@ func (el *EventLoop) processExpiredTimers() {
 			continue
 		}
 
-		// 提交到 VM 队列执行
-		callback := task.callback
+		// 提交到 VM 队列执行，执行前已被取消的（clearTimeout、ClearTimers）不再执行
 		el.submitTask(func() {
-			callback()
+			if !task.canceled.Load() {
+				task.callback()
+			}
 		})
 
 		pool.GlobalMemoryMonitor.DecrementTimerCount()
@@ -275,8 +303,27 @@ func (el *EventLoop) Stop() {
 	}
 
 	el.cancel()
+	el.clearTimers()
+
+	// 发送停止信号
+	select {
+	case el.stopChan <- struct{}{}:
+	default:
+	}
+
+	close(el.stoppedCh)
+}
+
+// ClearTimers 取消所有未触发的定时器和间隔定时器并清除长期任务标记，事件循环继续运行。
+// 用于 Runner 复用前的重置
+func (el *EventLoop) ClearTimers() {
+	el.clearTimers()
+	el.hasLongLived.Store(false)
+	el.notifyTimerChange()
+}
 
-	// 清理所有定时器
+// clearTimers 取消所有定时器和间隔定时器
+func (el *EventLoop) clearTimers() {
 	el.timerMu.Lock()
 	for el.timerHeap.Len() > 0 {
 		task := heap.Pop(&el.timerHeap).(*timerTask)
@@ -285,7 +332,13 @@ func (el *EventLoop) Stop() {
 	}
 	el.timerMu.Unlock()
 
-	// 清理所有间隔定时器
+	el.immediateMu.Lock()
+	for _, task := range el.immediates {
+		task.canceled.Store(true)
+	}
+	el.immediates = nil
+	el.immediateMu.Unlock()
+
 	el.intervalMu.Lock()
 	for id, task := range el.intervals {
 		task.canceled.Store(true)
@@ -293,14 +346,11 @@ func (el *EventLoop) Stop() {
 		delete(el.intervals, id)
 	}
 	el.intervalMu.Unlock()
+}
 
-	// 发送停止信号
-	select {
-	case el.stopChan <- struct{}{}:
-	default:
-	}
-
-	close(el.stoppedCh)
+// Done 返回事件循环停止时关闭的 channel
+func (el *EventLoop) Done() <-chan struct{} {
+	return el.ctx.Done()
 }
 
 // AddJob 增加活跃任务计数
@@ -322,7 +372,7 @@ func (el *EventLoop) SetLongLived() {
 func (el *EventLoop) WaitAndProcess() {
 	// 设置信号处理
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 	defer signal.Stop(sigChan)
 
 	// 给异步任务一些启动时间
@@ -343,7 +393,11 @@ func (el *EventLoop) WaitAndProcess() {
 		}
 
 		select {
-		case <-sigChan:
+		case sig := <-sigChan:
+			if el.onSignal != nil && el.onSignal(sig) {
+				idleStart = time.Time{}
+				continue
+			}
 			el.Stop()
 			return
 		case <-el.stopChan:
@@ -370,6 +424,35 @@ func (el *EventLoop) WaitAndProcess() {
 	}
 }
 
+// HasPendingWork 返回是否还有定时器、服务器、进行中的请求等待处理的工作
+func (el *EventLoop) HasPendingWork() bool {
+	return el.hasWork()
+}
+
+// SetErrorHandler 设置回调抛出未捕获异常时的处理函数
+func (el *EventLoop) SetErrorHandler(fn func(error)) {
+	el.onError = fn
+}
+
+// SetSignalHandler 设置收到 SIGINT、SIGTERM、SIGHUP 时的处理函数，返回 false 时按默认方式停止事件循环
+func (el *EventLoop) SetSignalHandler(fn func(os.Signal) bool) {
+	el.onSignal = fn
+}
+
+// SetAfterTask 设置每个任务执行完后调用的函数
+func (el *EventLoop) SetAfterTask(fn func()) {
+	el.afterTask = fn
+}
+
+// reportError 报告回调中未捕获的异常，被中断的脚本不报告
+func (el *EventLoop) reportError(err error) {
+	var interrupted *goja.InterruptedError
+	if el.onError == nil || errors.As(err, &interrupted) {
+		return
+	}
+	el.onError(err)
+}
+
 // hasWork 检查是否有待处理的工作
 func (el *EventLoop) hasWork() bool {
 	// 检查长期运行任务
@@ -378,12 +461,17 @@ func (el *EventLoop) hasWork() bool {
 	}
 
 	// 检查 HTTP 服务器
-	if http.IsHTTPServerRunning() {
+	if http.HasHTTPServers(el.vm) {
 		return true
 	}
 
 	// 检查 TCP 服务器
-	if net.IsTCPServerRunning() {
+	if net.HasTCPServers(el.vm) {
+		return true
+	}
+
+	// 检查进行中的流读写与 fetch 请求
+	if stream.HasPendingIO(el.vm) {
 		return true
 	}
 
@@ -392,6 +480,11 @@ func (el *EventLoop) hasWork() bool {
 		return true
 	}
 
+	// 检查正在执行或排队中的回调（如耗时较长的定时器回调）
+	if el.executing.Load() || len(el.vmQueue) > 0 {
+		return true
+	}
+
 	// 检查定时器
 	el.timerMu.Lock()
 	hasTimers := el.timerHeap.Len() > 0
@@ -445,7 +538,9 @@ func (el *EventLoop) SetTimeout(call goja.FunctionCall) goja.Value {
 					// 忽略回调中的 panic
 				}
 			}()
-			fn(goja.Undefined())
+			if _, err := fn(goja.Undefined()); err != nil {
+				el.reportError(err)
+			}
 		},
 	}
 
@@ -516,7 +611,9 @@ func (el *EventLoop) SetInterval(call goja.FunctionCall) goja.Value {
 					// 忽略回调中的 panic
 				}
 			}()
-			fn(goja.Undefined())
+			if _, err := fn(goja.Undefined()); err != nil {
+				el.reportError(err)
+			}
 		},
 		ctx:    ctx,
 		cancel: cancel,
@@ -569,32 +666,81 @@ func (el *EventLoop) ClearInterval(call goja.FunctionCall) goja.Value {
 	return goja.Undefined()
 }
 
-// SetImmediate 实现 setImmediate（高优先级任务）
+// SetImmediate 实现 setImmediate：回调在当前任务（包括微任务）结束后按顺序执行，
+// 执行期间新加入的回调留到下一轮，不会饿死定时器和 I/O 回调
 func (el *EventLoop) SetImmediate(call goja.FunctionCall) goja.Value {
-	if len(call.Arguments) < 1 {
-		return goja.Undefined()
+	fn, ok := goja.AssertFunction(call.Argument(0))
+	if !ok {
+		panic(el.vm.NewTypeError("The \"callback\" argument must be of type function"))
 	}
 
-	fn, ok := goja.AssertFunction(call.Arguments[0])
-	if !ok {
-		return goja.Undefined()
+	task := &immediateTask{
+		id: int(el.timerID.Add(1)),
+		fn: fn,
+	}
+	if len(call.Arguments) > 1 {
+		task.args = append([]goja.Value(nil), call.Arguments[1:]...)
 	}
 
-	id := int(el.timerID.Add(1))
+	el.immediateMu.Lock()
+	el.immediates = append(el.immediates, task)
+	first := len(el.immediates) == 1
+	el.immediateMu.Unlock()
 
-	// 立即提交到队列
-	el.submitTask(func() {
-		el.vmMu.Lock()
-		defer el.vmMu.Unlock()
-		defer func() {
-			if r := recover(); r != nil {
-				// 忽略回调中的 panic
+	// 一批回调只提交一个任务，避免主脚本持有 VM 时大量调用填满队列
+	if first {
+		el.submitTask(el.runImmediates)
+	}
+
+	return el.vm.ToValue(task.id)
+}
+
+// ClearImmediate 实现 clearImmediate
+func (el *EventLoop) ClearImmediate(call goja.FunctionCall) goja.Value {
+	id := int(call.Argument(0).ToInteger())
+
+	el.immediateMu.Lock()
+	for _, task := range el.immediates {
+		if task.id == id {
+			task.canceled.Store(true)
+		}
+	}
+	el.immediateMu.Unlock()
+	if el.runningImmediates != nil {
+		// 在 runImmediates 中执行（持有 vmMu），同一批中尚未执行的回调也可以取消
+		for _, task := range el.runningImmediates {
+			if task.id == id {
+				task.canceled.Store(true)
 			}
-		}()
-		fn(goja.Undefined())
-	})
+		}
+	}
 
-	return el.vm.ToValue(id)
+	return goja.Undefined()
+}
+
+// runImmediates 执行当前排队的 setImmediate 回调
+func (el *EventLoop) runImmediates() {
+	el.vmMu.Lock()
+	defer el.vmMu.Unlock()
+
+	el.immediateMu.Lock()
+	batch := el.immediates
+	el.immediates = nil
+	el.immediateMu.Unlock()
+
+	el.runningImmediates = batch
+	defer func() { el.runningImmediates = nil }()
+
+	for _, task := range batch {
+		if task.canceled.Load() {
+			continue
+		}
+		el.safeExecute(func() {
+			if _, err := task.fn(goja.Undefined(), task.args...); err != nil {
+				el.reportError(err)
+			}
+		})
+	}
 }
 
 // NextTick 实现 process.nextTick
@@ -617,7 +763,9 @@ func (el *EventLoop) NextTick(call goja.FunctionCall) goja.Value {
 				// 忽略回调中的 panic
 			}
 		}()
-		fn(goja.Undefined())
+		if _, err := fn(goja.Undefined()); err != nil {
+			el.reportError(err)
+		}
 	})
 
 	return goja.Undefined()
@@ -637,6 +785,36 @@ func (el *EventLoop) GetActiveIntervalCount() int {
 	return len(el.intervals)
 }
 
+// RunOnLoop 在事件循环中异步执行函数，用于从其他 goroutine（如 Worker）投递任务
+func (el *EventLoop) RunOnLoop(fn func(*goja.Runtime)) {
+	el.submitTask(func() {
+		el.vmMu.Lock()
+		defer el.vmMu.Unlock()
+		defer func() {
+			if r := recover(); r != nil {
+				// 忽略回调中的 panic
+			}
+		}()
+		fn(el.vm)
+	})
+}
+
+// Exclusive 独占 VM 执行 fn（如主脚本），执行期间事件循环中的回调等待其完成
+func (el *EventLoop) Exclusive(fn func() error) error {
+	el.vmMu.Lock()
+	defer el.vmMu.Unlock()
+	return fn()
+}
+
+// RunCallback 在事件循环中异步执行回调，回调返回的错误作为未捕获异常报告
+func (el *EventLoop) RunCallback(fn func(*goja.Runtime) error) {
+	el.RunOnLoop(func(vm *goja.Runtime) {
+		if err := fn(vm); err != nil {
+			el.reportError(err)
+		}
+	})
+}
+
 // RunOnLoopSync 在事件循环中同步执行函数并返回结果
 // 用于从其他 goroutine (如 Raft Controller) 同步调用 JS 逻辑
 func (el *EventLoop) RunOnLoopSync(fn func(*goja.Runtime) interface{}) interface{} {
@@ -6,6 +6,10 @@ import (
 	"os/signal"
 	"sync"
 	"syscall"
+
+	"github.com/issueye/sw_runtime/internal/importmap"
+	"github.com/issueye/sw_runtime/internal/remote"
+	"github.com/issueye/sw_runtime/internal/security"
 )
 
 // RunnerManager 运行器管理器，支持热重载
@@ -17,6 +21,10 @@ type RunnerManager struct {
 	decryptKeyFile string
 	verbose        bool
 	quiet          bool
+	permissions    *security.Permissions
+	consoleOpts    *ConsoleOptions
+	importMap      *importmap.ImportMap
+	remoteLoader   *remote.Loader
 
 	currentRunner *Runner
 	restarting    bool
@@ -44,6 +52,26 @@ func NewRunnerManager(scriptPath, workingDir string, clearCache bool,
 	}
 }
 
+// SetPermissions 设置每次重新加载时创建的运行器的权限，nil 表示不限制
+func (rm *RunnerManager) SetPermissions(p *security.Permissions) {
+	rm.permissions = p
+}
+
+// SetImportMap 设置每次重新加载时创建的运行器使用的导入映射
+func (rm *RunnerManager) SetImportMap(m *importmap.ImportMap) {
+	rm.importMap = m
+}
+
+// SetRemoteLoader 设置每次重新加载时创建的运行器使用的远程模块加载器
+func (rm *RunnerManager) SetRemoteLoader(l *remote.Loader) {
+	rm.remoteLoader = l
+}
+
+// SetConsoleOptions 设置每次重新加载时创建的运行器的 console 选项
+func (rm *RunnerManager) SetConsoleOptions(opts ConsoleOptions) {
+	rm.consoleOpts = &opts
+}
+
 // Start 启动运行器管理器
 func (rm *RunnerManager) Start() error {
 	// 处理中断信号
@@ -116,6 +144,13 @@ func (rm *RunnerManager) createAndRunRunner() error {
 		runner = NewOrPanic()
 	}
 
+	runner.SetPermissions(rm.permissions)
+	runner.SetImportMap(rm.importMap)
+	runner.SetRemoteLoader(rm.remoteLoader)
+	if rm.consoleOpts != nil {
+		runner.SetConsoleOptions(*rm.consoleOpts)
+	}
+
 	// 设置当前运行器
 	rm.mu.Lock()
 	rm.currentRunner = runner
@@ -0,0 +1,178 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+
+	"github.com/issueye/sw_runtime/internal/sourcemap"
+
+	"github.com/dop251/goja"
+)
+
+// signalNames 可以通过 process.on 监听的信号
+var signalNames = map[os.Signal]string{
+	syscall.SIGINT:  "SIGINT",
+	syscall.SIGTERM: "SIGTERM",
+	syscall.SIGHUP:  "SIGHUP",
+}
+
+// microtaskProgram queueMicrotask 实现：回调在 Promise 微任务中由 run 调用
+var microtaskProgram = goja.MustCompile("microtask.js", `(function (run) {
+	return function queueMicrotask(callback) {
+		if (typeof callback !== 'function') {
+			throw new TypeError('The "callback" argument must be of type function');
+		}
+		Promise.resolve().then(() => run(callback));
+	};
+})`, true)
+
+// setupLifecycle 将事件循环的信号、未捕获异常和 Promise 拒绝转发为 process 事件
+func (r *Runner) setupLifecycle() {
+	r.events = r.modules.ProcessEvents()
+	r.loop.SetErrorHandler(r.reportUncaught)
+	r.loop.SetSignalHandler(r.handleSignal)
+	r.loop.SetAfterTask(r.checkRejections)
+	r.vm.SetPromiseRejectionTracker(r.trackRejection)
+}
+
+// ExitCode 返回脚本通过 process.exitCode 设置的退出码，未设置时为 0
+func (r *Runner) ExitCode() int {
+	if r.events == nil {
+		return 0
+	}
+	return r.events.ExitCode()
+}
+
+// printError 以 console.error 的方式输出运行时错误
+func (r *Runner) printError(prefix string, v interface{}) {
+	r.console.print(LogLevelError, true, prefix+": "+sourcemap.FormatError(v))
+}
+
+// handleUncaught 将未捕获的 JS 异常交给 uncaughtException 监听器，没有监听器时返回 false
+func (r *Runner) handleUncaught(err error) bool {
+	var exception *goja.Exception
+	if r.events == nil || !errors.As(err, &exception) || !r.events.HasListeners("uncaughtException") {
+		return false
+	}
+	if _, err := r.events.Emit("uncaughtException", exception.Value(), r.vm.ToValue("uncaughtException")); err != nil {
+		r.printError("Uncaught exception", err)
+	}
+	return true
+}
+
+// reportUncaught 处理事件循环回调中未捕获的异常，没有 uncaughtException 监听器时输出到标准错误
+func (r *Runner) reportUncaught(err error) {
+	if !r.handleUncaught(err) {
+		r.printError("Uncaught exception", err)
+	}
+}
+
+// handleSignal 有对应的 process 信号监听器时在事件循环中触发事件并返回 true，否则按默认方式停止
+func (r *Runner) handleSignal(sig os.Signal) bool {
+	name, ok := signalNames[sig]
+	if !ok || r.events == nil || !r.events.HasListeners(name) {
+		return false
+	}
+	r.loop.RunOnLoop(func(vm *goja.Runtime) {
+		if _, err := r.events.Emit(name, vm.ToValue(name)); err != nil {
+			r.reportUncaught(err)
+		}
+	})
+	return true
+}
+
+// newQueueMicrotask 创建 queueMicrotask 函数，回调抛出的异常按未捕获异常处理，而不是成为 Promise 拒绝
+func (r *Runner) newQueueMicrotask() goja.Value {
+	factory, err := r.vm.RunProgram(microtaskProgram)
+	if err != nil {
+		panic(err)
+	}
+	fn, ok := goja.AssertFunction(factory)
+	if !ok {
+		panic(r.vm.NewTypeError("invalid queueMicrotask factory"))
+	}
+	run := func(call goja.FunctionCall) goja.Value {
+		callback, _ := goja.AssertFunction(call.Argument(0))
+		if _, err := callback(goja.Undefined()); err != nil {
+			var interrupted *goja.InterruptedError
+			if !errors.As(err, &interrupted) {
+				r.reportUncaught(err)
+			}
+		}
+		return goja.Undefined()
+	}
+	queueMicrotask, err := fn(goja.Undefined(), r.vm.ToValue(run))
+	if err != nil {
+		panic(err)
+	}
+	return queueMicrotask
+}
+
+// trackRejection 记录没有处理函数的 Promise 拒绝，当前任务结束前添加了处理函数的不再报告
+func (r *Runner) trackRejection(p *goja.Promise, op goja.PromiseRejectionOperation) {
+	switch op {
+	case goja.PromiseRejectionReject:
+		r.rejections = append(r.rejections, p)
+	case goja.PromiseRejectionHandle:
+		for i, pending := range r.rejections {
+			if pending == p {
+				r.rejections = append(r.rejections[:i], r.rejections[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// checkRejections 报告任务结束时仍未处理的 Promise 拒绝：有 unhandledRejection 监听器时交给监听器，
+// 否则输出到标准错误。在脚本同步执行完毕和事件循环的每个任务之后调用
+func (r *Runner) checkRejections() {
+	for len(r.rejections) > 0 {
+		p := r.rejections[0]
+		r.rejections = r.rejections[1:]
+		if r.events != nil && r.events.HasListeners("unhandledRejection") {
+			if _, err := r.events.Emit("unhandledRejection", p.Result(), r.vm.ToValue(p)); err != nil {
+				r.reportUncaught(err)
+			}
+			continue
+		}
+		r.printError("Unhandled promise rejection", p.Result())
+	}
+}
+
+// exitLoop 在事件循环空闲后触发 beforeExit 和 exit 事件。
+// beforeExit 的监听器安排了新任务时继续处理，exit 监听器只能执行同步代码；事件循环被信号或超时停止时不触发
+func (r *Runner) exitLoop() {
+	if r.events == nil {
+		return
+	}
+	emit := func(fn func() error) error {
+		return r.do(context.Background(), func(*goja.Runtime) error {
+			if err := fn(); err != nil {
+				r.reportUncaught(err)
+			}
+			return nil
+		})
+	}
+
+	for r.events.HasListeners("beforeExit") {
+		err := emit(func() error {
+			_, err := r.events.Emit("beforeExit", r.vm.ToValue(r.events.ExitCode()))
+			return err
+		})
+		if err != nil || !r.loop.HasPendingWork() {
+			break
+		}
+		r.loop.WaitAndProcess()
+	}
+
+	// 与 Node.js 一致，exit 监听器中安排的定时器不会执行，在同一个任务中取消，避免定时器先到期
+	if r.events.HasListeners("exit") {
+		emit(func() error {
+			err := r.events.EmitExit(r.events.ExitCode())
+			r.loop.ClearTimers()
+			return err
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package runtime
+
+import (
+	"github.com/issueye/sw_runtime/internal/profiler"
+
+	"github.com/dop251/goja"
+)
+
+// HeapSummary 按构造函数统计从全局对象和模块 exports 可达的 JS 对象。
+// 只能在脚本没有执行时调用（如 RunFile 返回后），执行期间使用 RequestHeapSummary
+func (r *Runner) HeapSummary() *profiler.HeapSummary {
+	return profiler.TakeHeapSummary(r.vm, r.modules.HeapRoots()...)
+}
+
+// RequestHeapSummary 在事件循环中生成堆摘要后调用 fn，可以从其他 goroutine（如信号处理）调用。
+// 事件循环正忙于同步代码时，摘要会在当前任务结束后生成
+func (r *Runner) RequestHeapSummary(fn func(*profiler.HeapSummary)) {
+	r.loop.RunOnLoop(func(*goja.Runtime) {
+		fn(r.HeapSummary())
+	})
+}
@@ -0,0 +1,374 @@
+package runtime
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/issueye/sw_runtime/internal/builtins/utils"
+	"github.com/issueye/sw_runtime/internal/modules"
+	"github.com/issueye/sw_runtime/internal/sourcemap"
+	"github.com/issueye/sw_runtime/internal/tsconfig"
+
+	"github.com/dop251/goja"
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/parser"
+)
+
+// ErrIncompleteInput 输入不完整（如未闭合的括号、模板字符串），需要继续读取下一行
+var ErrIncompleteInput = errors.New("incomplete input")
+
+// ErrInterrupted 求值被 Interrupt 中断
+var ErrInterrupted = errors.New("interrupted")
+
+// replKeywords 补全时提供的关键字
+var replKeywords = []string{
+	"async", "await", "break", "case", "catch", "class", "const", "continue", "debugger",
+	"default", "delete", "do", "else", "export", "extends", "false", "finally", "for",
+	"function", "if", "import", "in", "instanceof", "let", "new", "null", "return",
+	"super", "switch", "this", "throw", "true", "try", "typeof", "undefined", "var",
+	"void", "while", "yield",
+}
+
+// EvalError REPL 求值过程中未捕获的异常
+type EvalError struct {
+	Message string // 格式化后的异常值，Error 对象包含调用栈
+}
+
+func (e *EvalError) Error() string {
+	return "Uncaught " + e.Message
+}
+
+// REPL 交互式求值会话，多次求值共享同一个 Runner 的全局环境与事件循环
+type REPL struct {
+	runner    *Runner
+	inspect   utils.InspectOptions
+	interrupt chan struct{}
+	running   atomic.Bool   // 是否正在同步执行代码
+	awaitFn   goja.Callable // 调用 async 包装函数并注册回调的辅助函数
+}
+
+// NewREPL 在运行器上创建交互式会话并启动事件循环，定时器等异步任务会在输入间隙继续执行
+func (r *Runner) NewREPL() *REPL {
+	r.startLoop()
+	r.vm.Set("__filename", "[repl]")
+	return &REPL{
+		runner:    r,
+		inspect:   utils.DefaultInspectOptions,
+		interrupt: make(chan struct{}, 1),
+	}
+}
+
+// Runner 获取会话所使用的运行器
+func (s *REPL) Runner() *Runner {
+	return s.runner
+}
+
+// SetColors 设置结果输出是否使用 ANSI 颜色
+func (s *REPL) SetColors(colors bool) {
+	s.inspect.Colors = colors
+}
+
+// replResult 一次求值的结果
+type replResult struct {
+	output string
+	err    error
+}
+
+// Eval 执行一段 TypeScript/JavaScript 输入并返回格式化后的结果。
+// 输入不完整时返回 ErrIncompleteInput；包含顶层 await 时会等待 Promise 完成。
+// 结果保存在全局变量 _ 中，未捕获的异常保存在 _error 中并以 *EvalError 返回。
+func (s *REPL) Eval(input string) (string, error) {
+	if strings.TrimSpace(input) == "" {
+		return "", nil
+	}
+
+	config, _ := tsconfig.Find(s.runner.workingDir)
+	code, isAsync, err := compileREPLInput(input, config)
+	if err != nil {
+		return "", err
+	}
+
+	// 清除上一次未被消费的中断请求
+	select {
+	case <-s.interrupt:
+	default:
+	}
+
+	done := make(chan replResult, 1)
+	s.runner.loop.RunOnLoop(func(vm *goja.Runtime) {
+		vm.ClearInterrupt()
+		s.running.Store(true)
+		value, err := vm.RunString(code)
+		s.running.Store(false)
+		vm.ClearInterrupt()
+		if err != nil {
+			done <- s.fail(vm, err)
+			return
+		}
+		if isAsync {
+			s.settle(vm, value, done)
+			return
+		}
+		done <- s.succeed(vm, value)
+	})
+
+	select {
+	case res := <-done:
+		return res.output, res.err
+	case <-s.interrupt:
+		if s.running.Load() {
+			s.runner.vm.Interrupt(ErrInterrupted)
+		}
+		return "", ErrInterrupted
+	}
+}
+
+// settle 调用顶层 await 的 async 包装函数并等待其完成（在事件循环中调用）。
+// 回调在同一次调用中注册，避免 Promise 在注册前被拒绝而被当作未处理的拒绝。
+func (s *REPL) settle(vm *goja.Runtime, fn goja.Value, done chan<- replResult) {
+	if s.awaitFn == nil {
+		helper, err := vm.RunString("(fn, onFulfilled, onRejected) => { fn().then(onFulfilled, onRejected); }")
+		if err != nil {
+			done <- s.fail(vm, err)
+			return
+		}
+		s.awaitFn, _ = goja.AssertFunction(helper)
+	}
+
+	onFulfilled := func(call goja.FunctionCall) goja.Value {
+		done <- s.succeed(vm, call.Argument(0))
+		return goja.Undefined()
+	}
+	onRejected := func(call goja.FunctionCall) goja.Value {
+		done <- s.thrown(vm, call.Argument(0))
+		return goja.Undefined()
+	}
+	if _, err := s.awaitFn(goja.Undefined(), fn, vm.ToValue(onFulfilled), vm.ToValue(onRejected)); err != nil {
+		done <- s.fail(vm, err)
+	}
+}
+
+// succeed 保存并格式化求值结果（在事件循环中调用）
+func (s *REPL) succeed(vm *goja.Runtime, value goja.Value) replResult {
+	vm.Set("_", value)
+	return replResult{output: utils.Inspect(vm, value, s.inspect)}
+}
+
+// thrown 保存并格式化抛出的异常值（在事件循环中调用）
+func (s *REPL) thrown(vm *goja.Runtime, value goja.Value) replResult {
+	vm.Set("_error", value)
+	return replResult{err: &EvalError{Message: utils.Inspect(vm, value, s.inspect)}}
+}
+
+// fail 将执行错误转换为 REPL 错误（在事件循环中调用）
+func (s *REPL) fail(vm *goja.Runtime, err error) replResult {
+	var interrupted *goja.InterruptedError
+	if errors.As(err, &interrupted) {
+		return replResult{err: ErrInterrupted}
+	}
+	var exception *goja.Exception
+	if errors.As(err, &exception) && exception.Value() != nil {
+		return s.thrown(vm, exception.Value())
+	}
+	return replResult{err: &EvalError{Message: err.Error()}}
+}
+
+// Interrupt 中断正在进行的求值，包括死循环与未完成的顶层 await
+func (s *REPL) Interrupt() {
+	select {
+	case s.interrupt <- struct{}{}:
+	default:
+	}
+}
+
+// compileREPLInput 按 config 将输入转译为可执行的 JS 代码，包含顶层 await 时改写为 async 包装函数（不调用）
+// REPL 输入会被改写后执行，不使用 source map
+func compileREPLInput(input string, config *tsconfig.Config) (string, bool, error) {
+	code, err := transpileTS(input, "repl.ts", config)
+	code = sourcemap.Strip(code)
+	if err == nil {
+		return modules.RewriteDynamicImport(code, scriptImportFunc), false, nil
+	}
+	if !strings.Contains(err.Error(), "Top-level await") {
+		return "", false, replSyntaxError(input, err)
+	}
+
+	wrapped, err := transpileTS("(async () => {\n"+input+"\n});", "repl.ts", config)
+	if err != nil {
+		return "", false, replSyntaxError(input, err)
+	}
+	code, err = hoistAsyncBody(sourcemap.Strip(wrapped))
+	if err != nil {
+		return "", false, err
+	}
+	return modules.RewriteDynamicImport(code, scriptImportFunc), true, nil
+}
+
+// replSyntaxError 转换转译错误：输入提前结束（未闭合的括号、模板字符串）时返回 ErrIncompleteInput
+func replSyntaxError(input string, err error) error {
+	msg := strings.TrimPrefix(err.Error(), "transpile error: ")
+	if strings.Contains(msg, "end of file") ||
+		strings.Contains(msg, "Unterminated string literal") && strings.Count(input, "`")%2 == 1 {
+		return ErrIncompleteInput
+	}
+	return &EvalError{Message: "SyntaxError: " + msg}
+}
+
+// hoistAsyncBody 改写 async 包装函数的函数体：
+// 顶层声明改为对全局变量赋值，使其在之后的输入中可见；最后一个表达式作为返回值。
+func hoistAsyncBody(code string) (string, error) {
+	program, err := parser.ParseFile(nil, "", code, 0)
+	if err != nil {
+		return "", err
+	}
+	body := asyncWrapperBody(program)
+	if body == nil {
+		return code, nil
+	}
+
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	text := func(node ast.Node) string {
+		return code[int(node.Idx0())-1 : int(node.Idx1())-1]
+	}
+	assignments := func(list []*ast.Binding) string {
+		parts := make([]string, 0, len(list))
+		for _, b := range list {
+			init := "undefined"
+			if b.Initializer != nil {
+				init = text(b.Initializer)
+			}
+			parts = append(parts, text(b.Target)+" = "+init)
+		}
+		return "void (" + strings.Join(parts, ", ") + ")"
+	}
+
+	for i, stmt := range body.List {
+		start, end := int(stmt.Idx0())-1, int(stmt.Idx1())-1
+		switch st := stmt.(type) {
+		case *ast.VariableStatement:
+			edits = append(edits, edit{start, end, assignments(st.List)})
+		case *ast.LexicalDeclaration:
+			edits = append(edits, edit{start, end, assignments(st.List)})
+		case *ast.FunctionDeclaration:
+			if st.Function.Name != nil {
+				name := st.Function.Name.Name.String()
+				edits = append(edits, edit{start, end, "globalThis." + name + " = " + text(st.Function) + ";"})
+			}
+		case *ast.ClassDeclaration:
+			if st.Class.Name != nil {
+				name := st.Class.Name.Name.String()
+				edits = append(edits, edit{start, end, "globalThis." + name + " = " + text(st.Class) + ";"})
+			}
+		case *ast.ExpressionStatement:
+			if i == len(body.List)-1 {
+				edits = append(edits, edit{start, end, "return (" + text(st.Expression) + ")"})
+			}
+		}
+	}
+
+	// 从后往前替换，保证前面的偏移量有效
+	for i := len(edits) - 1; i >= 0; i-- {
+		e := edits[i]
+		code = code[:e.start] + e.text + code[e.end:]
+	}
+	return code, nil
+}
+
+// asyncWrapperBody 获取 (async () => { ... }) 中的函数体，转译器生成的辅助函数位于其之前
+func asyncWrapperBody(program *ast.Program) *ast.BlockStatement {
+	for i := len(program.Body) - 1; i >= 0; i-- {
+		stmt, ok := program.Body[i].(*ast.ExpressionStatement)
+		if !ok {
+			continue
+		}
+		if arrow, ok := stmt.Expression.(*ast.ArrowFunctionLiteral); ok {
+			body, _ := arrow.Body.(*ast.BlockStatement)
+			return body
+		}
+	}
+	return nil
+}
+
+// Complete 补全输入行末尾的标识符或属性访问表达式（如 "JSON.str"），
+// 返回候选项与被补全的前缀。属性访问只会读取属性，不会调用函数。
+func (s *REPL) Complete(line string) ([]string, string) {
+	end := len(line)
+	start := end
+	for start > 0 {
+		c := line[start-1]
+		if c == '.' || c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' {
+			start--
+			continue
+		}
+		break
+	}
+	expr := line[start:end]
+	if expr == "" || expr[0] >= '0' && expr[0] <= '9' {
+		return nil, ""
+	}
+
+	objectPath, prefix := "", expr
+	if dot := strings.LastIndexByte(expr, '.'); dot >= 0 {
+		objectPath, prefix = expr[:dot], expr[dot+1:]
+	}
+
+	result := s.runner.loop.RunOnLoopSync(func(vm *goja.Runtime) interface{} {
+		var target *goja.Object
+		if objectPath == "" {
+			target = vm.GlobalObject()
+		} else {
+			// 通过求值标识符获取根对象，以便找到 let/const 声明的全局变量
+			path := strings.Split(objectPath, ".")
+			value, err := vm.RunString(path[0])
+			if err != nil {
+				return nil
+			}
+			for _, name := range path[1:] {
+				if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+					return nil
+				}
+				value = value.ToObject(vm).Get(name)
+			}
+			if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+				return nil
+			}
+			target = value.ToObject(vm)
+		}
+		return propertyNames(target)
+	})
+
+	names, _ := result.([]string)
+	if objectPath == "" {
+		names = append(names, replKeywords...)
+	}
+
+	seen := make(map[string]bool)
+	var candidates []string
+	for _, name := range names {
+		if strings.HasPrefix(name, prefix) && !seen[name] {
+			seen[name] = true
+			candidates = append(candidates, name)
+		}
+	}
+	sort.Strings(candidates)
+	return candidates, prefix
+}
+
+// propertyNames 收集对象及其原型链上的属性名
+func propertyNames(obj *goja.Object) []string {
+	var names []string
+	for o := obj; o != nil; o = o.Prototype() {
+		for _, name := range o.GetOwnPropertyNames() {
+			if name != "constructor" && !strings.HasPrefix(name, "__") {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,383 @@
+package runtime
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/issueye/sw_runtime/internal/security"
+
+	"github.com/dop251/goja"
+	"github.com/dop251/goja/ast"
+)
+
+// resetGoroutineTimeout Reset 等待脚本创建的 goroutine 退出的最长时间
+const resetGoroutineTimeout = time.Second
+
+// runnerLabel 标记 Runner 执行脚本的 goroutine 的 pprof 标签名，脚本创建的 goroutine 继承该标签
+const runnerLabel = "sw_runner"
+
+// runnerSeq 用于生成 Runner 的唯一标签值
+var runnerSeq atomic.Uint64
+
+// propertyState 属性在基线中的描述符
+type propertyState struct {
+	key  goja.Value
+	desc goja.Value
+}
+
+// objectState 对象在基线中的原型和自有属性
+type objectState struct {
+	obj   *goja.Object
+	proto *goja.Object
+	keys  map[interface{}]propertyState
+}
+
+// globalBaseline 创建 Runner 时全局对象、内置对象及其 prototype 的快照。
+// 反射函数在快照时取得，脚本替换 Reflect 上的方法不影响恢复
+type globalBaseline struct {
+	ownKeys        goja.Callable
+	getDescriptor  goja.Callable
+	defineProperty goja.Callable
+	deleteProperty goja.Callable
+	objects        []objectState
+
+	permissions *security.Permissions
+	console     ConsoleOptions
+}
+
+// descriptorFields 比较描述符时检查的字段
+var descriptorFields = []string{"value", "get", "set", "writable", "enumerable", "configurable"}
+
+// propertyKey 将属性键转换为可作为 map 键的值
+func propertyKey(key goja.Value) interface{} {
+	if sym, ok := key.(*goja.Symbol); ok {
+		return sym
+	}
+	return key.String()
+}
+
+// arrayValues 返回 JS 数组的元素，Symbol 键导出为 Go 值会丢失身份，因此逐个读取
+func arrayValues(vm *goja.Runtime, arr goja.Value) []goja.Value {
+	obj := arr.ToObject(vm)
+	n := int(obj.Get("length").ToInteger())
+	values := make([]goja.Value, n)
+	for i := range values {
+		values[i] = obj.Get(strconv.Itoa(i))
+	}
+	return values
+}
+
+// captureBaseline 记录全局对象，以及全局属性引用的对象、它们的 prototype 和原型的当前状态
+func (r *Runner) captureBaseline() {
+	reflect := r.vm.Get("Reflect").ToObject(r.vm)
+	callable := func(name string) goja.Callable {
+		fn, _ := goja.AssertFunction(reflect.Get(name))
+		return fn
+	}
+	b := &globalBaseline{
+		ownKeys:        callable("ownKeys"),
+		getDescriptor:  callable("getOwnPropertyDescriptor"),
+		defineProperty: callable("defineProperty"),
+		deleteProperty: callable("deleteProperty"),
+		permissions:    r.Permissions(),
+		console:        r.ConsoleOptions(),
+	}
+
+	seen := make(map[*goja.Object]bool)
+	var add func(obj *goja.Object)
+	add = func(obj *goja.Object) {
+		if obj == nil || seen[obj] {
+			return
+		}
+		seen[obj] = true
+		b.objects = append(b.objects, b.snapshot(r.vm, obj))
+		add(obj.Prototype())
+	}
+
+	global := r.vm.GlobalObject()
+	add(global)
+	for _, state := range b.objects[0].keys {
+		value := state.desc.ToObject(r.vm).Get("value")
+		obj, ok := value.(*goja.Object)
+		if !ok {
+			continue
+		}
+		add(obj)
+		if _, isFunc := goja.AssertFunction(obj); isFunc {
+			if proto, ok := obj.Get("prototype").(*goja.Object); ok {
+				add(proto)
+			}
+		}
+	}
+	r.baseline = b
+}
+
+// snapshot 记录对象的原型和全部自有属性描述符
+func (b *globalBaseline) snapshot(vm *goja.Runtime, obj *goja.Object) objectState {
+	state := objectState{obj: obj, proto: obj.Prototype(), keys: make(map[interface{}]propertyState)}
+	keys, err := b.ownKeys(goja.Undefined(), obj)
+	if err != nil {
+		return state
+	}
+	for _, k := range arrayValues(vm, keys) {
+		desc, err := b.getDescriptor(goja.Undefined(), obj, k)
+		if err != nil {
+			continue
+		}
+		state.keys[propertyKey(k)] = propertyState{key: k, desc: desc}
+	}
+	return state
+}
+
+// restore 删除基线之后新增的属性，恢复被修改或删除的属性和原型。
+// 脚本用 var、function 声明的全局变量不可删除，会被设置为 undefined
+func (b *globalBaseline) restore(vm *goja.Runtime) error {
+	var errs []error
+	for _, state := range b.objects {
+		obj := state.obj
+		if obj.Prototype() != state.proto {
+			if err := obj.SetPrototype(state.proto); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		keys, err := b.ownKeys(goja.Undefined(), obj)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, k := range arrayValues(vm, keys) {
+			if _, ok := state.keys[propertyKey(k)]; ok {
+				continue
+			}
+			deleted, err := b.deleteProperty(goja.Undefined(), obj, k)
+			if err == nil && !deleted.ToBoolean() {
+				_, err = b.defineProperty(goja.Undefined(), obj, k, vm.ToValue(map[string]interface{}{"value": goja.Undefined()}))
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to remove %s: %w", k, err))
+			}
+		}
+
+		for _, prop := range state.keys {
+			current, err := b.getDescriptor(goja.Undefined(), obj, prop.key)
+			if err == nil && sameDescriptor(vm, current, prop.desc) {
+				continue
+			}
+			ok, err := b.defineProperty(goja.Undefined(), obj, prop.key, prop.desc)
+			if err == nil && !ok.ToBoolean() {
+				err = errors.New("property is not configurable")
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to restore %s: %w", prop.key, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sameDescriptor 比较两个属性描述符是否相同
+func sameDescriptor(vm *goja.Runtime, a, b goja.Value) bool {
+	if goja.IsUndefined(a) || goja.IsUndefined(b) {
+		return goja.IsUndefined(a) && goja.IsUndefined(b)
+	}
+	ao, bo := a.ToObject(vm), b.ToObject(vm)
+	for _, field := range descriptorFields {
+		av, bv := ao.Get(field), bo.Get(field)
+		if av == nil || bv == nil {
+			if av != bv {
+				return false
+			}
+			continue
+		}
+		if !av.SameAs(bv) {
+			return false
+		}
+	}
+	return true
+}
+
+// labeled 在带有 Runner 标签的上下文中执行 fn，fn 及其创建的 goroutine 都带有该标签
+func (r *Runner) labeled(fn func() error) (err error) {
+	pprof.Do(context.Background(), pprof.Labels(runnerLabel, r.id), func(context.Context) {
+		err = fn()
+	})
+	return err
+}
+
+// startLoop 在 RunCode、RunFile 之外启动事件循环，使循环的 goroutine 及其中执行的回调创建的
+// goroutine 带有 Runner 标签。pprof.Do 返回时会清除调用方的标签，因此不能在 labeled 中调用
+func (r *Runner) startLoop() {
+	r.labeled(func() error {
+		r.loop.Start()
+		return nil
+	})
+}
+
+// trackLexical 记录脚本顶层的 let、const、class 声明，这些绑定无法从 VM 中移除
+func (r *Runner) trackLexical(program *ast.Program) {
+	for _, stmt := range program.Body {
+		switch decl := stmt.(type) {
+		case *ast.LexicalDeclaration:
+			for _, binding := range decl.List {
+				r.lexical = append(r.lexical, bindingNames(binding.Target)...)
+			}
+		case *ast.ClassDeclaration:
+			if decl.Class.Name != nil {
+				r.lexical = append(r.lexical, decl.Class.Name.Name.String())
+			}
+		}
+	}
+}
+
+// scopeProgram 将脚本的顶层语句包裹在块语句中，使 let、const、class 与函数声明成为块级绑定，
+// 不会留在全局词法环境中，var 声明仍为全局变量。包裹后 "use strict" 指令不再生效，
+// 返回值表示脚本是否需要按严格模式编译
+func scopeProgram(program *ast.Program) (strict bool) {
+	for _, stmt := range program.Body {
+		expr, ok := stmt.(*ast.ExpressionStatement)
+		if !ok {
+			break
+		}
+		directive, ok := expr.Expression.(*ast.StringLiteral)
+		if !ok {
+			break
+		}
+		if directive.Literal == `"use strict"` || directive.Literal == `'use strict'` {
+			strict = true
+		}
+	}
+
+	if len(program.Body) > 0 {
+		program.Body = []ast.Statement{&ast.BlockStatement{
+			LeftBrace:  program.Body[0].Idx0(),
+			List:       program.Body,
+			RightBrace: program.Body[len(program.Body)-1].Idx1(),
+		}}
+	}
+	return strict
+}
+
+// bindingNames 返回绑定目标（包括解构模式）声明的变量名
+func bindingNames(target ast.Expression) []string {
+	switch t := target.(type) {
+	case *ast.Identifier:
+		return []string{t.Name.String()}
+	case *ast.AssignExpression:
+		return bindingNames(t.Left)
+	case *ast.ArrayPattern:
+		var names []string
+		for _, elem := range t.Elements {
+			names = append(names, bindingNames(elem)...)
+		}
+		return append(names, bindingNames(t.Rest)...)
+	case *ast.ObjectPattern:
+		var names []string
+		for _, prop := range t.Properties {
+			switch p := prop.(type) {
+			case *ast.PropertyShort:
+				names = append(names, p.Name.Name.String())
+			case *ast.PropertyKeyed:
+				names = append(names, bindingNames(p.Value)...)
+			}
+		}
+		return append(names, bindingNames(t.Rest)...)
+	}
+	return nil
+}
+
+// Reset 将 Runner 恢复到创建时的状态以便复用：取消定时器、终止 Worker，关闭脚本创建的
+// HTTP/TCP 服务器、连接和数据库，移除 process 事件监听器，清空跨 VM 共享存储和模块缓存，并确认脚本创建的 goroutine 都已退出。
+// 由 ResetFull 模式的 RunnerPool 创建的 Runner 还会恢复全局对象和内置对象的 prototype，
+// 其脚本在独立的块作用域中执行，顶层声明不会残留。其他 Runner 执行过带有顶层 let、const、class
+// 声明的脚本时这些声明无法移除，此时返回错误，Runner 不应再复用
+func (r *Runner) Reset() error {
+	if r.Interrupted() {
+		return ErrLoopStopped
+	}
+
+	r.loop.ClearTimers()
+	r.terminateWorkers()
+	r.modules.Reset()
+
+	// 等待已提交的回调执行完毕，之后事件循环中不再有脚本代码
+	if err := r.Do(context.Background(), func(*goja.Runtime) error { return nil }); err != nil {
+		return err
+	}
+	if err := r.waitGoroutines(resetGoroutineTimeout); err != nil {
+		return err
+	}
+
+	var errs []error
+	if len(r.lexical) > 0 {
+		errs = append(errs, fmt.Errorf("global lexical declarations cannot be removed: %s", strings.Join(r.lexical, ", ")))
+	}
+	if r.baseline != nil {
+		if err := r.baseline.restore(r.vm); err != nil {
+			errs = append(errs, err)
+		}
+		r.SetPermissions(r.baseline.permissions)
+		r.SetConsoleOptions(r.baseline.console)
+	}
+	// 基线会恢复 process 原有的监听器表对象，监听器在恢复之后移除
+	if r.events != nil {
+		r.events.Reset()
+	}
+	r.console.reset()
+	r.rejections = nil
+	r.entryFile, r.entryCode = "", ""
+	return errors.Join(errs...)
+}
+
+// waitGoroutines 等待带有 Runner 标签的 goroutine（事件循环自身除外）退出
+func (r *Runner) waitGoroutines(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		n := r.goroutineCount()
+		if n == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%d goroutine(s) started by the script are still running", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// goroutineCount 统计带有 Runner 标签、且不是事件循环处理器的 goroutine 数量
+func (r *Runner) goroutineCount() int {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		return 0
+	}
+
+	label := fmt.Sprintf("%q:%q", runnerLabel, r.id)
+	count := 0
+	// 每条记录以 "数量 @ 地址" 开头，以空行结束；带标签的记录包含 "# labels: {...}" 行
+	for _, record := range strings.Split(buf.String(), "\n\n") {
+		if !strings.Contains(record, label) ||
+			strings.Contains(record, "(*EventLoop).vmProcessor") ||
+			strings.Contains(record, "(*EventLoop).timerProcessor") {
+			continue
+		}
+		scanner := bufio.NewScanner(strings.NewReader(record))
+		if !scanner.Scan() {
+			continue
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 0 {
+			if n, err := strconv.Atoi(fields[0]); err == nil {
+				count += n
+			}
+		}
+	}
+	return count
+}
@@ -4,10 +4,20 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"sync"
-
-	"sw_runtime/internal/modules"
-	"sw_runtime/internal/pool"
+	"sync/atomic"
+
+	"github.com/issueye/sw_runtime/internal/builtins/process"
+	"github.com/issueye/sw_runtime/internal/builtins/stream"
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/importmap"
+	"github.com/issueye/sw_runtime/internal/modules"
+	"github.com/issueye/sw_runtime/internal/pool"
+	"github.com/issueye/sw_runtime/internal/remote"
+	"github.com/issueye/sw_runtime/internal/security"
+	"github.com/issueye/sw_runtime/internal/sourcemap"
+	"github.com/issueye/sw_runtime/internal/tsconfig"
 
 	"time"
 
@@ -21,6 +31,12 @@ type EventLoopType int
 type eventLoopInterface interface {
 	Start()
 	Stop()
+	Done() <-chan struct{}
+	ClearTimers()
+	HasPendingWork() bool
+	SetErrorHandler(func(error))
+	SetSignalHandler(func(os.Signal) bool)
+	SetAfterTask(func())
 	AddJob()
 	DoneJob()
 	SetLongLived()
@@ -29,10 +45,18 @@ type eventLoopInterface interface {
 	ClearTimeout(call goja.FunctionCall) goja.Value
 	SetInterval(call goja.FunctionCall) goja.Value
 	ClearInterval(call goja.FunctionCall) goja.Value
+	SetImmediate(call goja.FunctionCall) goja.Value
+	ClearImmediate(call goja.FunctionCall) goja.Value
 	NextTick(call goja.FunctionCall) goja.Value
+	RunOnLoop(func(*goja.Runtime))
+	RunCallback(func(*goja.Runtime) error)
+	Exclusive(func() error) error
 	RunOnLoopSync(func(*goja.Runtime) interface{}) interface{}
 }
 
+// scriptImportFunc 脚本中 import(...) 改写后调用的全局函数
+const scriptImportFunc = "globalThis.import"
+
 // Runner JavaScript/TypeScript 运行器
 type Runner struct {
 	vm      *goja.Runtime
@@ -40,13 +64,62 @@ type Runner struct {
 	modules *modules.System
 	argv    []string
 	start   time.Time
+
+	workingDir   string
+	entryFile    string // 入口脚本，多 VM HTTP 服务器的工作 Runner 会重新执行它
+	entryCode    string
+	workers      map[*Worker]struct{}
+	workersMu    sync.Mutex
+	workerEvents *goja.Object // Worker 线程全局事件的 emitter，仅在 Worker 线程中存在
+	interrupted  atomic.Bool  // 被 RunCodeContext/RunFileContext 中断后不可再复用
+	console      *console
+
+	id       string          // pprof 标签值，Reset 据此检查脚本创建的 goroutine
+	baseline *globalBaseline // 放入 ResetFull 池前的全局状态，Reset 据此恢复
+	lexical  []string        // 脚本顶层的 let、const、class 声明
+
+	events     *process.Events // process 的生命周期事件
+	rejections []*goja.Promise // 尚未处理的 Promise 拒绝
+}
+
+// ResetMode Runner 归还到池中时的重置方式
+type ResetMode int
+
+const (
+	// ResetModuleCache 只清空模块缓存，全局变量、定时器和服务器会保留到下一次使用
+	ResetModuleCache ResetMode = iota
+	// ResetFull 调用 Runner.Reset 完全重置，重置失败的 Runner 会被关闭而不放回池中
+	ResetFull
+)
+
+// DefaultPoolSize RunnerPool 默认最多保留的空闲 Runner 数量
+const DefaultPoolSize = 32
+
+// PoolStats Runner 池的统计信息
+type PoolStats struct {
+	Hits          uint64 // 复用池中 Runner 的次数
+	Creates       uint64 // 新建 Runner 的次数
+	Resets        uint64 // 成功重置的次数
+	ResetFailures uint64 // 重置失败而关闭 Runner 的次数
+	Idle          int    // 池中空闲的 Runner 数量
 }
 
 // RunnerPool Runner 对象池，用于复用 Runner 实例以减少频繁创建开销。
-// 注意：池中的 Runner 会复用同一个 JS VM，因此全局状态（global 上挂的变量等）不会自动重置。
-// 仅在你能接受跨调用共享全局状态，或者每次手动清理全局变量的场景下使用。
+// 池持有空闲 Runner 的生命周期：超出容量或池已关闭时归还的 Runner 会被关闭，Close 关闭全部空闲 Runner。
+// 默认的 ResetModuleCache 模式下池中的 Runner 会复用同一个 JS VM，全局状态（global 上挂的变量等）不会重置，
+// 仅在你能接受跨调用共享全局状态的场景下使用；需要隔离时使用 ResetFull 模式。
 type RunnerPool struct {
-	pool sync.Pool
+	mode ResetMode
+	size int
+
+	mu     sync.Mutex
+	idle   []*Runner
+	closed bool
+
+	hits          atomic.Uint64
+	creates       atomic.Uint64
+	resets        atomic.Uint64
+	resetFailures atomic.Uint64
 }
 
 // defaultRunnerPool 默认全局 Runner 池。
@@ -54,35 +127,107 @@ var defaultRunnerPool = NewRunnerPool()
 
 // NewRunnerPool 创建新的 Runner 池。
 func NewRunnerPool() *RunnerPool {
-	rp := &RunnerPool{}
-	rp.pool.New = func() interface{} {
-		// 对于池中暂无可用实例时，退回到正常的 New 创建逻辑。
-		r, err := New()
-		if err != nil {
-			panic(err)
-		}
-		return r
+	return NewRunnerPoolWithMode(ResetModuleCache)
+}
+
+// NewRunnerPoolWithMode 创建使用指定重置方式的 Runner 池。
+// ResetFull 模式在创建 Runner 时记录全局对象的初始状态，创建开销比默认模式大
+func NewRunnerPoolWithMode(mode ResetMode) *RunnerPool {
+	return NewRunnerPoolWithSize(mode, DefaultPoolSize)
+}
+
+// NewRunnerPoolWithSize 创建最多保留 size 个空闲 Runner 的池，size 小于 1 时使用 DefaultPoolSize
+func NewRunnerPoolWithSize(mode ResetMode, size int) *RunnerPool {
+	if size < 1 {
+		size = DefaultPoolSize
 	}
-	return rp
+	return &RunnerPool{mode: mode, size: size}
 }
 
-// Acquire 从默认 Runner 池获取一个 Runner。
+// Acquire 从 Runner 池获取一个 Runner，池中暂无可用实例时新建。
 func (rp *RunnerPool) Acquire() *Runner {
-	return rp.pool.Get().(*Runner)
+	rp.mu.Lock()
+	if n := len(rp.idle); n > 0 {
+		r := rp.idle[n-1]
+		rp.idle[n-1] = nil
+		rp.idle = rp.idle[:n-1]
+		rp.mu.Unlock()
+		rp.hits.Add(1)
+		return r
+	}
+	rp.mu.Unlock()
+
+	r, err := New()
+	if err != nil {
+		panic(err)
+	}
+	if rp.mode == ResetFull {
+		r.captureBaseline()
+	}
+	rp.creates.Add(1)
+	return r
 }
 
-// Release 将 Runner 放回默认 Runner 池以便复用。
-// 当前实现仅清空模块缓存，不会重置 JS 全局状态，也不会主动关闭 HTTP 等长连接服务。
-// 如需完全隔离环境，请继续使用 New/NewOrPanic + Close，而不要复用池。
+// Release 将 Runner 放回 Runner 池以便复用。
+// ResetModuleCache 模式仅清空模块缓存，不会重置 JS 全局状态，也不会主动关闭 HTTP 等长连接服务；
+// ResetFull 模式调用 Runner.Reset，重置失败（如脚本创建的 goroutine 未退出）时关闭 Runner。
+// 因超时或取消被中断的 Runner 事件循环已停止，会直接关闭而不放回池中；池已满或已关闭时同样关闭 Runner。
 func (rp *RunnerPool) Release(r *Runner) {
 	if r == nil {
 		return
 	}
+	if r.Interrupted() {
+		r.Close()
+		return
+	}
+
+	if rp.mode == ResetFull {
+		if err := r.Reset(); err != nil {
+			rp.resetFailures.Add(1)
+			r.Close()
+			return
+		}
+		rp.resets.Add(1)
+	} else {
+		// 清理模块缓存，避免上一次加载的文件模块残留。
+		r.ClearModuleCache()
+	}
+
+	rp.mu.Lock()
+	if rp.closed || len(rp.idle) >= rp.size {
+		rp.mu.Unlock()
+		r.Close()
+		return
+	}
+	rp.idle = append(rp.idle, r)
+	rp.mu.Unlock()
+}
 
-	// 清理模块缓存，避免上一次加载的文件模块残留。
-	r.ClearModuleCache()
+// Close 关闭池中的空闲 Runner，之后归还的 Runner 会直接关闭
+func (rp *RunnerPool) Close() {
+	rp.mu.Lock()
+	idle := rp.idle
+	rp.idle = nil
+	rp.closed = true
+	rp.mu.Unlock()
 
-	rp.pool.Put(r)
+	for _, r := range idle {
+		r.Close()
+	}
+}
+
+// Stats 返回池的统计信息
+func (rp *RunnerPool) Stats() PoolStats {
+	rp.mu.Lock()
+	idle := len(rp.idle)
+	rp.mu.Unlock()
+	return PoolStats{
+		Hits:          rp.hits.Load(),
+		Creates:       rp.creates.Load(),
+		Resets:        rp.resets.Load(),
+		ResetFailures: rp.resetFailures.Load(),
+		Idle:          idle,
+	}
 }
 
 // AcquireRunner 从默认池获取 Runner 的便捷函数。
@@ -176,71 +321,36 @@ func NewOrPanicWithWorkingDir(workingDir string) *Runner {
 
 // setupBuiltinsWithDir 注册内置函数，使用指定的工作目录
 func (r *Runner) setupBuiltinsWithDir(workingDir string) {
+	r.workingDir = workingDir
+	r.id = strconv.FormatUint(runnerSeq.Add(1), 10)
+
 	// console 对象
-	console := r.vm.NewObject()
-	console.Set("log", func(call goja.FunctionCall) goja.Value {
-		args := make([]interface{}, len(call.Arguments))
-		for i, arg := range call.Arguments {
-			args[i] = arg.Export()
-		}
-		fmt.Println(args...)
-		return goja.Undefined()
-	})
-	console.Set("error", func(call goja.FunctionCall) goja.Value {
-		args := make([]interface{}, len(call.Arguments))
-		for i, arg := range call.Arguments {
-			args[i] = arg.Export()
-		}
-		fmt.Fprintln(os.Stderr, args...)
-		return goja.Undefined()
-	})
-	console.Set("warn", func(call goja.FunctionCall) goja.Value {
-		args := make([]interface{}, len(call.Arguments))
-		for i, arg := range call.Arguments {
-			args[i] = arg.Export()
-		}
-		fmt.Println("[WARN]", args)
-		return goja.Undefined()
-	})
-	r.vm.Set("console", console)
+	r.console = newConsole(r.vm)
+	r.vm.Set("console", r.console.object())
 
 	// 定时器函数 - 使用事件循环
 	r.vm.Set("setTimeout", r.loop.SetTimeout)
 	r.vm.Set("clearTimeout", r.loop.ClearTimeout)
 	r.vm.Set("setInterval", r.loop.SetInterval)
 	r.vm.Set("clearInterval", r.loop.ClearInterval)
+	r.vm.Set("setImmediate", r.loop.SetImmediate)
+	r.vm.Set("clearImmediate", r.loop.ClearImmediate)
+	r.vm.Set("queueMicrotask", r.newQueueMicrotask())
 
-	// 模块系统
-	r.vm.Set("require", r.modules.Require)
-
-	// 动态 import 支持
-	r.vm.Set("import", func(call goja.FunctionCall) goja.Value {
-		if len(call.Arguments) == 0 {
-			panic(r.vm.NewTypeError("import() missing path"))
-		}
-
-		id := call.Arguments[0].String()
+	// 原生流（文件、子进程、网络连接）的 I/O 回调在事件循环中执行
+	stream.SetScheduler(r.vm, r.loop.RunCallback)
 
-		// 创建 Promise
-		promise, resolve, reject := r.vm.NewPromise()
+	// Worker 线程
+	r.vm.Set("Worker", r.workerConstructor())
 
-		// 异步加载模块
-		go func() {
-			module, err := r.modules.LoadModule(id, workingDir)
-			if err != nil {
-				reject(r.vm.NewGoError(err))
-			} else {
-				resolve(module.Exports)
-			}
-		}()
+	// 多 VM HTTP 服务器
+	r.modules.SetServerWorkerSpawner(r.spawnServerWorker)
 
-		return r.vm.ToValue(promise)
-	})
+	// 模块系统：require、动态 import、__filename 与 __dirname
+	r.setScriptModule(nil)
 
 	// 全局变量
 	r.vm.Set("global", r.vm.GlobalObject())
-	r.vm.Set("__dirname", workingDir)
-	r.vm.Set("__filename", "")
 
 	// 设置 process 对象
 	process := r.modules.GetBuiltinModule("process")
@@ -248,31 +358,48 @@ func (r *Runner) setupBuiltinsWithDir(workingDir string) {
 		r.vm.Set("process", process)
 	}
 
-	// 启用 Promise
-	r.vm.SetPromiseRejectionTracker(func(p *goja.Promise, op goja.PromiseRejectionOperation) {
-		if op == goja.PromiseRejectionReject {
-			fmt.Fprintf(os.Stderr, "Unhandled promise rejection: %v\n", p.Result())
-		}
-	})
+	// Symbol.asyncIterator 与转译后的 for await 使用同一个符号
+	if _, err := r.vm.RunString(`if (!Symbol.asyncIterator) Symbol.asyncIterator = Symbol.for("Symbol.asyncIterator");`); err != nil {
+		panic(err)
+	}
+
+	// 全局对象（Buffer、fetch 等）
+	r.modules.InstallGlobals(r.vm.GlobalObject())
+
+	// process 生命周期事件，未处理的 Promise 拒绝在任务结束时报告
+	r.setupLifecycle()
 }
 
 // RunCode 执行 TypeScript/JavaScript 代码
 func (r *Runner) RunCode(code string) error {
-	// 尝试作为 TypeScript 编译
-	jsCode, err := transpileTS(code, "inline.ts")
+	return r.labeled(func() error {
+		return r.runCode(code)
+	})
+}
+
+// runCode 执行代码并等待异步任务完成
+func (r *Runner) runCode(code string) error {
+	r.entryFile, r.entryCode = "", code
+
+	// 尝试作为 TypeScript 编译，使用工作目录中的 tsconfig.json（无效时按默认选项）
+	config, _ := tsconfig.Find(r.workingDir)
+	jsCode, err := transpileTS(code, "inline.ts", config)
 	if err != nil {
 		// 如果编译失败，尝试直接作为 JS 执行
 		jsCode = code
 	}
+	jsCode = modules.RewriteDynamicImport(jsCode, scriptImportFunc)
+	r.setScriptModule(nil)
 
 	r.loop.Start()
-	_, err = r.vm.RunString(jsCode)
-	if err != nil {
+	if err := r.runScript("", jsCode); err != nil && !r.handleUncaught(err) {
 		return err
 	}
+	r.checkRejections()
 
 	// 处理异步任务
 	r.loop.WaitAndProcess()
+	r.exitLoop()
 	return nil
 }
 
@@ -281,6 +408,10 @@ func (r *Runner) RunCode(code string) error {
 func (r *Runner) SafeRunCode(code string) (err error) {
 	defer func() {
 		if v := recover(); v != nil {
+			if exception, ok := v.(*goja.Exception); ok {
+				err = exception
+				return
+			}
 			err = fmt.Errorf("runtime panic: %v", v)
 		}
 	}()
@@ -289,6 +420,15 @@ func (r *Runner) SafeRunCode(code string) (err error) {
 
 // RunFile 执行 TypeScript/JavaScript 文件
 func (r *Runner) RunFile(filename string) error {
+	return r.labeled(func() error {
+		return r.runFile(filename, nil)
+	})
+}
+
+// runFile 执行文件，ready 在同步代码执行完毕、开始等待异步任务之前调用
+func (r *Runner) runFile(filename string, ready func()) error {
+	r.entryFile, r.entryCode = filename, ""
+
 	content, err := os.ReadFile(filename)
 	if err != nil {
 		return fmt.Errorf("failed to read file: %w", err)
@@ -297,22 +437,125 @@ func (r *Runner) RunFile(filename string) error {
 	code := string(content)
 	ext := filepath.Ext(filename)
 
+	// ES 模块通过模块系统加载，支持 export、import.meta 与顶层 await
+	if modules.IsESMSource(filename, code) {
+		return r.runMainModule(filename, ready)
+	}
+
 	// 如果是 .ts 或 .tsx 文件，先编译
 	if ext == ".ts" || ext == ".tsx" {
-		code, err = transpileTS(code, filename)
+		code, err = transpileFile(code, filename)
 		if err != nil {
 			return err
 		}
+	} else {
+		code = modules.LowerAsyncIteration(code, filename)
+	}
+
+	code = modules.RewriteDynamicImport(code, scriptImportFunc)
+	if abs, err := filepath.Abs(filename); err == nil {
+		r.setScriptModule(r.modules.NewMain(abs))
 	}
 
 	r.loop.Start()
-	_, err = r.vm.RunString(code)
+	if err := r.runScript(filename, code); err != nil && !r.handleUncaught(err) {
+		return err
+	}
+	r.checkRejections()
+	if ready != nil {
+		ready()
+	}
+
+	// 处理异步任务
+	r.loop.WaitAndProcess()
+	r.exitLoop()
+	return nil
+}
+
+// setScriptModule 设置普通脚本的 CommonJS 绑定。入口文件使用自己的 module、exports、require、
+// __filename 与 __dirname（require.main === module），相对路径以文件所在目录为基准；
+// module 为 nil 时（内联代码）使用以工作目录为基准的全局 require，不提供 module 与 exports
+func (r *Runner) setScriptModule(module *modules.Module) {
+	global := r.vm.GlobalObject()
+	parentPath := r.workingDir
+	if module == nil {
+		global.Delete("module")
+		global.Delete("exports")
+		global.Set("__filename", "")
+		global.Set("__dirname", r.workingDir)
+	} else {
+		parentPath = module.Filename
+		global.Set("module", module.Object())
+		global.Set("exports", module.Exports)
+		global.Set("__filename", module.Filename)
+		global.Set("__dirname", filepath.Dir(module.Filename))
+	}
+	global.Set("require", r.modules.NewRequire(module))
+
+	// 动态 import 支持
+	global.Set("import", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) == 0 {
+			panic(r.vm.NewTypeError("import() missing path"))
+		}
+
+		return r.modules.Import(call.Arguments[0].String(), parentPath)
+	})
+}
+
+// runScript 编译并执行脚本，代码中的 source map 用于映射异常位置
+func (r *Runner) runScript(name, code string) error {
+	prg, err := sourcemap.Parse(name, code)
 	if err != nil {
 		return err
 	}
+	// ResetFull 模式的 Runner 每次执行都使用新的块作用域，归还时无需移除顶层词法声明
+	strict := false
+	if r.baseline != nil {
+		strict = scopeProgram(prg)
+	} else {
+		r.trackLexical(prg)
+	}
+	program, err := goja.CompileAST(prg, strict)
+	if err != nil {
+		return err
+	}
+	return r.loop.Exclusive(func() error {
+		_, err := r.vm.RunProgram(program)
+		return err
+	})
+}
+
+// runMainModule 以 ES 模块方式执行入口文件，并等待顶层 await 完成
+func (r *Runner) runMainModule(filename string, ready func()) error {
+	r.loop.Start()
+	var module *modules.Module
+	err := r.loop.Exclusive(func() (err error) {
+		module, err = r.modules.LoadMain(filename)
+		return err
+	})
+	if err != nil && !r.handleUncaught(err) {
+		return err
+	}
+	r.checkRejections()
+	if ready != nil {
+		ready()
+	}
 
 	// 处理异步任务
 	r.loop.WaitAndProcess()
+	r.exitLoop()
+	if module == nil {
+		return nil
+	}
+
+	if evaluation := module.Evaluation(); evaluation != nil {
+		switch evaluation.State() {
+		case goja.PromiseStateRejected:
+			return fmt.Errorf("failed to execute module %s: %s", module.Filename, sourcemap.FormatError(evaluation.Result()))
+		case goja.PromiseStatePending:
+			return fmt.Errorf("module %s has unsettled top-level await", module.Filename)
+		}
+	}
 	return nil
 }
 
@@ -320,6 +563,10 @@ func (r *Runner) RunFile(filename string) error {
 func (r *Runner) SafeRunFile(filename string) (err error) {
 	defer func() {
 		if v := recover(); v != nil {
+			if exception, ok := v.(*goja.Exception); ok {
+				err = exception
+				return
+			}
 			err = fmt.Errorf("runtime panic: %v", v)
 		}
 	}()
@@ -341,11 +588,23 @@ func (r *Runner) ClearModuleCache() {
 	r.modules.ClearCache()
 }
 
+// Require 由宿主加载模块并返回其导出，相对路径基于工作目录解析。
+// 入口模块不受读取权限限制，需在事件循环中调用（如 Do 的回调内）
+func (r *Runner) Require(id string) (*goja.Object, error) {
+	return r.modules.RequireModule(id)
+}
+
 // GetLoadedModules 获取已加载的模块列表
 func (r *Runner) GetLoadedModules() []string {
 	return r.modules.GetLoadedModules()
 }
 
+// RegisterModule 注册自定义内置模块，脚本中通过 require(name) 或 import 加载。
+// 需要在执行脚本前注册，GetModule 在首次加载时调用，结果随模块缓存保存
+func (r *Runner) RegisterModule(name string, module types.BuiltinModule) {
+	r.modules.RegisterModule(name, module)
+}
+
 // GetBuiltinModules 获取内置模块列表
 func (r *Runner) GetBuiltinModules() []string {
 	return r.modules.GetBuiltinModules()
@@ -356,9 +615,15 @@ func (r *Runner) Close() {
 	// 停止事件循环
 	r.loop.Stop()
 
+	// 终止所有 Worker
+	r.terminateWorkers()
+
 	// 关闭模块系统（包括所有 HTTP 服务器）
 	r.modules.Close()
 
+	// 关闭未结束的原生流
+	stream.RemoveScheduler(r.vm)
+
 	// 减少 Runner 计数
 	pool.GlobalMemoryMonitor.DecrementRunnerCount()
 }
@@ -379,6 +644,51 @@ func (r *Runner) GetArgv() []string {
 	return r.argv
 }
 
+// SetPermissions 设置脚本的访问权限（文件读写、网络、子进程、环境变量），nil 表示不限制。
+// 权限不足时内置模块抛出 name 为 PermissionDenied 的错误，Worker 和多 VM 服务器的工作 VM 继承该权限。
+func (r *Runner) SetPermissions(p *security.Permissions) {
+	r.modules.SetPermissions(p)
+}
+
+// Permissions 返回当前权限配置，未限制时返回 nil
+func (r *Runner) Permissions() *security.Permissions {
+	return r.modules.Permissions()
+}
+
+// SetImportMap 设置模块解析使用的导入映射（require、import 与动态 import()），nil 表示不使用。
+// Worker 与 HTTP 服务器工作 VM 继承该设置
+func (r *Runner) SetImportMap(m *importmap.ImportMap) {
+	r.modules.SetImportMap(m)
+}
+
+// ImportMap 返回当前的导入映射
+func (r *Runner) ImportMap() *importmap.ImportMap {
+	return r.modules.ImportMap()
+}
+
+// SetRemoteLoader 设置加载 http(s) 远程模块使用的加载器（下载缓存、sw.lock、离线模式），
+// nil 表示使用默认加载器。Worker 与 HTTP 服务器工作 VM 继承该设置
+func (r *Runner) SetRemoteLoader(l *remote.Loader) {
+	r.modules.SetRemoteLoader(l)
+}
+
+// RemoteLoader 返回远程模块加载器
+func (r *Runner) RemoteLoader() *remote.Loader {
+	return r.modules.RemoteLoader()
+}
+
+// SetConsoleOptions 设置 console 的输出级别、JSON 模式、颜色和输出目标，Worker 继承该设置。
+// 输出目标同时用于 process.stdout 和 process.stderr
+func (r *Runner) SetConsoleOptions(opts ConsoleOptions) {
+	r.console.setOptions(opts)
+	r.modules.SetStdio(opts.Stdout, opts.Stderr)
+}
+
+// ConsoleOptions 返回当前 console 选项
+func (r *Runner) ConsoleOptions() ConsoleOptions {
+	return r.console.options()
+}
+
 // SetStartTime 设置起始时间
 func (r *Runner) SetStartTime(t time.Time) {
 	r.start = t
@@ -0,0 +1,67 @@
+package runtime
+
+import (
+	"fmt"
+
+	"github.com/issueye/sw_runtime/internal/builtins/http"
+
+	"github.com/dop251/goja"
+)
+
+// serverWorker 多 VM HTTP 服务器的工作 Runner，重新执行父 Runner 的入口脚本，
+// 脚本中的 listen 调用会把服务器加入父服务器的池
+type serverWorker struct {
+	runner *Runner
+	file   string
+	code   string
+}
+
+// spawnServerWorker 以父 Runner 的入口脚本创建工作 Runner
+func (r *Runner) spawnServerWorker() (http.ServerWorker, error) {
+	if r.entryFile == "" && r.entryCode == "" {
+		return nil, fmt.Errorf("no entry script to load into server workers")
+	}
+
+	child, err := NewWithWorkingDir(r.workingDir)
+	if err != nil {
+		return nil, err
+	}
+	child.SetArgv(r.argv)
+	child.SetStartTime(r.start)
+	child.SetPermissions(r.Permissions())
+	child.SetImportMap(r.ImportMap())
+	child.SetRemoteLoader(r.RemoteLoader())
+	child.SetConsoleOptions(r.ConsoleOptions())
+	child.modules.SetSharedStore(r.modules.SharedStore())
+
+	return &serverWorker{
+		runner: child,
+		file:   r.entryFile,
+		code:   r.entryCode,
+	}, nil
+}
+
+// VM 获取工作 Runner 的 VM
+func (w *serverWorker) VM() *goja.Runtime {
+	return w.runner.vm
+}
+
+// Start 在独立 goroutine 中执行入口脚本，服务器关闭后退出
+func (w *serverWorker) Start(onExit func(error)) {
+	go func() {
+		var err error
+		if w.file != "" {
+			err = w.runner.SafeRunFile(w.file)
+		} else {
+			err = w.runner.SafeRunCode(w.code)
+		}
+		w.runner.Close()
+		onExit(err)
+	}()
+}
+
+// Stop 中断正在执行的 JS 并停止事件循环
+func (w *serverWorker) Stop() {
+	w.runner.vm.Interrupt("server worker stopped")
+	w.runner.loop.Stop()
+}
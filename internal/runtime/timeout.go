@@ -0,0 +1,84 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dop251/goja"
+)
+
+// TimeoutError 脚本因 context 超时或取消被中断时返回的错误，
+// 可通过 errors.Is 判断是 context.DeadlineExceeded 还是 context.Canceled
+type TimeoutError struct {
+	Err error // ctx.Err()
+}
+
+// Error 实现 error 接口
+func (e *TimeoutError) Error() string {
+	if errors.Is(e.Err, context.Canceled) {
+		return "script execution canceled"
+	}
+	return "script execution timed out"
+}
+
+// Unwrap 返回 context 错误
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// RunCodeContext 执行代码，ctx 超时或取消时中断正在执行的 JS、停止事件循环并返回 *TimeoutError。
+// 被中断后 Runner 不能再执行脚本，应调用 Close 释放（RunnerPool.Release 会自动关闭它）。
+func (r *Runner) RunCodeContext(ctx context.Context, code string) error {
+	return r.runContext(ctx, func() error {
+		return r.RunCode(code)
+	})
+}
+
+// RunFileContext 执行文件，超时和取消的处理与 RunCodeContext 相同
+func (r *Runner) RunFileContext(ctx context.Context, filename string) error {
+	return r.runContext(ctx, func() error {
+		return r.RunFile(filename)
+	})
+}
+
+// Interrupted 返回 Runner 是否因超时或取消被中断过
+func (r *Runner) Interrupted() bool {
+	return r.interrupted.Load()
+}
+
+// runContext 在 ctx 的控制下执行 run
+func (r *Runner) runContext(ctx context.Context, run func() error) (err error) {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return &TimeoutError{Err: ctxErr}
+	}
+
+	fired := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		defer close(fired)
+		r.interrupted.Store(true)
+		r.vm.Interrupt(&TimeoutError{Err: ctx.Err()})
+		r.loop.Stop()
+	})
+
+	defer func() {
+		v := recover()
+		if !stop() {
+			// 已触发中断：无论脚本返回什么错误都统一报告为超时
+			<-fired
+			// 中断可能发生在事件循环启动之前，这里确保循环已停止
+			r.loop.Stop()
+			err = &TimeoutError{Err: ctx.Err()}
+			return
+		}
+		if v != nil {
+			if exception, ok := v.(*goja.Exception); ok {
+				err = exception
+				return
+			}
+			err = fmt.Errorf("runtime panic: %v", v)
+		}
+	}()
+
+	return run()
+}
@@ -2,11 +2,21 @@ package runtime
 
 import (
 	"fmt"
+	"path/filepath"
 	"sync"
 
+	"github.com/issueye/sw_runtime/internal/cache"
+	"github.com/issueye/sw_runtime/internal/tsconfig"
+
 	"github.com/evanw/esbuild/pkg/api"
 )
 
+// asyncIterationLowering goja 尚不支持、需要 esbuild 降级的语法（for await 与异步生成器）
+var asyncIterationLowering = map[string]bool{
+	"async-generator": false,
+	"for-await":       false,
+}
+
 // TranspilerPool TypeScript 编译器池
 type TranspilerPool struct {
 	pool sync.Pool
@@ -23,6 +33,9 @@ var GlobalTranspilerPool = &TranspilerPool{
 				MinifyWhitespace:  false,
 				MinifyIdentifiers: false,
 				MinifySyntax:      false,
+				Sourcemap:         api.SourceMapInline,
+				SourcesContent:    api.SourcesContentExclude,
+				Supported:         asyncIterationLowering,
 			}
 		},
 	},
@@ -42,19 +55,28 @@ func (tp *TranspilerPool) PutTransformOptions(opts *api.TransformOptions) {
 	opts.MinifyWhitespace = false
 	opts.MinifyIdentifiers = false
 	opts.MinifySyntax = false
+	opts.Sourcemap = api.SourceMapInline
+	opts.SourcesContent = api.SourcesContentExclude
 	opts.Sourcefile = ""
+	opts.Supported = asyncIterationLowering
+	opts.TsconfigRaw = ""
 
 	tp.pool.Put(opts)
 }
 
-// transpileTS 使用 esbuild 将 TypeScript 转换为 JavaScript
-func transpileTS(code string, filename string) (string, error) {
+// transpileTS 使用 esbuild 将 TypeScript 转换为 JavaScript，config 为 nil 时使用默认编译选项，
+// 输出末尾附带内联 source map，用于将异常位置映射回 filename
+func transpileTS(code string, filename string, config *tsconfig.Config) (string, error) {
 	// 使用对象池获取编译选项
 	opts := GlobalTranspilerPool.GetTransformOptions()
 	defer GlobalTranspilerPool.PutTransformOptions(opts)
 
 	// 设置文件名
 	opts.Sourcefile = filename
+	if filepath.Ext(filename) == ".tsx" {
+		opts.Loader = api.LoaderTSX
+	}
+	config.Apply(opts)
 
 	result := api.Transform(code, *opts)
 
@@ -64,3 +86,20 @@ func transpileTS(code string, filename string) (string, error) {
 
 	return string(result.Code), nil
 }
+
+// transpileFile 按最近的 tsconfig.json 编译 TypeScript 文件，结果按源码内容缓存在磁盘上（与模块系统共享缓存目录）。
+// 内联代码和 REPL 输入直接使用 transpileTS，避免一次性的代码占用缓存
+func transpileFile(code string, filename string) (string, error) {
+	config, err := tsconfig.Find(filepath.Dir(filename))
+	if err != nil {
+		return "", err
+	}
+	data, err := cache.Default().Do("ts"+config.CacheKey(), filename, code, func() ([]byte, error) {
+		out, err := transpileTS(code, filename, config)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(out), nil
+	})
+	return string(data), err
+}
@@ -0,0 +1,383 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/issueye/sw_runtime/internal/builtins/clone"
+	"github.com/issueye/sw_runtime/internal/builtins/events"
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+
+	"github.com/dop251/goja"
+)
+
+// workerThreadID Worker 线程 ID 计数器（主线程为 0）
+var workerThreadID atomic.Int64
+
+// Worker 在独立 Runner（独立 VM 与事件循环）中运行脚本的工作线程，
+// 父子线程之间通过结构化克隆的消息通信
+type Worker struct {
+	parent   *Runner
+	child    *Runner
+	filename string
+	name     string
+	threadID int64
+
+	// 父 VM 中的 Worker 对象（EventEmitter）
+	obj *goja.Object
+
+	// 子线程就绪前收到的消息
+	mu      sync.Mutex
+	ready   bool
+	pending []*clone.Data
+
+	terminated atomic.Bool
+	exitOnce   sync.Once
+	exitCode   int
+	exited     bool
+	waiters    []func(interface{}) error
+}
+
+// workerEventNames 可以通过 on<event> 属性设置处理函数的 Worker 事件
+var workerEventNames = []string{"message", "messageerror", "error", "exit"}
+
+// workerConstructor 创建 Worker 构造函数，Worker 对象继承 EventEmitter，
+// 并提供浏览器风格的 addEventListener/removeEventListener
+func (r *Runner) workerConstructor() *goja.Object {
+	ctor := r.vm.ToValue(r.newWorker).ToObject(r.vm)
+	proto := ctor.Get("prototype").ToObject(r.vm)
+	emitterProto := events.Constructor(r.vm).Get("prototype").ToObject(r.vm)
+	proto.SetPrototype(emitterProto)
+	proto.Set("addEventListener", emitterProto.Get("on"))
+	proto.Set("removeEventListener", emitterProto.Get("off"))
+	return ctor
+}
+
+// newWorker 实现 new Worker(filename, options)
+func (r *Runner) newWorker(call goja.ConstructorCall) *goja.Object {
+	if len(call.Arguments) < 1 {
+		panic(r.vm.NewTypeError("Worker requires a script path"))
+	}
+
+	filename := call.Arguments[0].String()
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(r.workingDir, filename)
+	}
+	if err := r.modules.Guard().CheckRead(filename); err != nil {
+		panic(types.NewPermissionError(r.vm, err))
+	}
+	if info, err := os.Stat(filename); err != nil || info.IsDir() {
+		panic(r.vm.NewGoError(fmt.Errorf("cannot find worker script: %s", call.Arguments[0].String())))
+	}
+
+	w := &Worker{
+		parent:   r,
+		filename: filename,
+		threadID: workerThreadID.Add(1),
+	}
+
+	var workerData *clone.Data
+	if len(call.Arguments) > 1 {
+		if options, ok := call.Arguments[1].(*goja.Object); ok {
+			if name := options.Get("name"); name != nil && !goja.IsUndefined(name) {
+				w.name = name.String()
+			}
+			if data := options.Get("workerData"); data != nil {
+				workerData = clone.Serialize(r.vm, data)
+			}
+		}
+	}
+
+	child, err := NewWithWorkingDir(r.workingDir)
+	if err != nil {
+		panic(r.vm.NewGoError(err))
+	}
+	child.SetArgv(r.argv)
+	child.SetStartTime(r.start)
+	child.SetPermissions(r.Permissions())
+	child.SetImportMap(r.ImportMap())
+	child.SetRemoteLoader(r.RemoteLoader())
+	child.SetConsoleOptions(r.ConsoleOptions())
+	child.modules.SetSharedStore(r.modules.SharedStore())
+	w.child = child
+	w.setupWorkerScope(workerData)
+
+	obj := call.This
+	w.obj = obj
+	defineEventHandlers(r.vm, obj, obj)
+	obj.Set("threadId", w.threadID)
+	obj.Set("postMessage", w.postMessage)
+	obj.Set("terminate", w.terminate)
+
+	r.addWorker(w)
+	r.loop.AddJob()
+	go w.run()
+
+	return obj
+}
+
+// setupWorkerScope 在子 VM 中注册 self、postMessage、close 等 Worker 全局对象
+func (w *Worker) setupWorkerScope(workerData *clone.Data) {
+	vm := w.child.vm
+	global := vm.GlobalObject()
+
+	// 全局对象的事件方法作用于独立的 emitter，有 message 监听器时保持子线程事件循环存活
+	emitter := events.New(vm)
+	listening := false
+	events.Watch(vm, emitter, func(name string, count int) {
+		if name != "message" {
+			return
+		}
+		active := count > 0 && !w.terminated.Load()
+		if active != listening {
+			listening = active
+			if active {
+				w.child.loop.AddJob()
+			} else {
+				w.child.loop.DoneJob()
+			}
+		}
+	})
+	events.Bind(vm, global, emitter, "on", "once", "off")
+	global.Set("addEventListener", global.Get("on"))
+	global.Set("removeEventListener", global.Get("off"))
+	defineEventHandlers(vm, global, emitter)
+	w.child.workerEvents = emitter
+
+	global.Set("self", global)
+	global.Set("name", w.name)
+	global.Set("threadId", w.threadID)
+	global.Set("isMainThread", false)
+	if workerData != nil {
+		global.Set("workerData", workerData.Deserialize(vm))
+	} else {
+		global.Set("workerData", goja.Null())
+	}
+
+	// postMessage 向父线程发送消息
+	global.Set("postMessage", func(call goja.FunctionCall) goja.Value {
+		data := clone.Serialize(vm, call.Argument(0))
+		if w.terminated.Load() {
+			return goja.Undefined()
+		}
+		w.parent.loop.RunOnLoop(func(parentVM *goja.Runtime) {
+			if w.isExited() {
+				return
+			}
+			w.dispatch("message", newMessageEvent(parentVM, data.Deserialize(parentVM)))
+		})
+		return goja.Undefined()
+	})
+
+	// close 结束子线程
+	global.Set("close", func(call goja.FunctionCall) goja.Value {
+		w.child.loop.Stop()
+		return goja.Undefined()
+	})
+}
+
+// run 在独立 goroutine 中执行 Worker 脚本
+func (w *Worker) run() {
+	err := w.child.runFile(w.filename, w.start)
+
+	code := 0
+	if w.terminated.Load() {
+		code = 1
+	} else if err != nil {
+		code = 1
+		w.reportError(err)
+	}
+
+	w.child.Close()
+	w.exit(code)
+}
+
+// start 子线程同步代码执行完毕后投递积压的消息
+func (w *Worker) start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.ready = true
+	for _, data := range w.pending {
+		w.deliver(data)
+	}
+	w.pending = nil
+}
+
+// deliver 将消息投递到子线程事件循环
+func (w *Worker) deliver(data *clone.Data) {
+	w.child.loop.RunOnLoop(func(vm *goja.Runtime) {
+		if w.terminated.Load() {
+			return
+		}
+		event := newMessageEvent(vm, data.Deserialize(vm))
+		if err := events.Emit(vm, w.child.workerEvents, "message", event); err != nil {
+			w.reportError(err)
+		}
+	})
+}
+
+// postMessage 父线程向 Worker 发送消息
+func (w *Worker) postMessage(call goja.FunctionCall) goja.Value {
+	data := clone.Serialize(w.parent.vm, call.Argument(0))
+	if w.terminated.Load() {
+		return goja.Undefined()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.ready {
+		w.pending = append(w.pending, data)
+		return goja.Undefined()
+	}
+	w.deliver(data)
+	return goja.Undefined()
+}
+
+// terminate 终止 Worker，返回在线程退出后以退出码 resolve 的 Promise
+func (w *Worker) terminate(call goja.FunctionCall) goja.Value {
+	promise, resolve, _ := w.parent.vm.NewPromise()
+	if w.isExited() {
+		resolve(w.exitCode)
+		return w.parent.vm.ToValue(promise)
+	}
+	w.waiters = append(w.waiters, resolve)
+	w.stop()
+	return w.parent.vm.ToValue(promise)
+}
+
+// stop 中断子线程正在执行的 JS 并停止其事件循环
+func (w *Worker) stop() {
+	if !w.terminated.CompareAndSwap(false, true) {
+		return
+	}
+	w.child.vm.Interrupt("worker terminated")
+	w.child.loop.Stop()
+}
+
+// isExited 检查 Worker 是否已退出（仅在父线程中调用）
+func (w *Worker) isExited() bool {
+	return w.exited
+}
+
+// exit 通知父线程 Worker 已退出
+func (w *Worker) exit(code int) {
+	w.exitOnce.Do(func() {
+		w.parent.removeWorker(w)
+		w.parent.loop.RunOnLoop(func(vm *goja.Runtime) {
+			defer w.parent.loop.DoneJob()
+			w.exited = true
+			w.exitCode = code
+			for _, resolve := range w.waiters {
+				resolve(code)
+			}
+			w.waiters = nil
+			w.dispatch("exit", vm.ToValue(code))
+		})
+	})
+}
+
+// reportError 将子线程中未捕获的异常转发给父线程的 error 事件
+func (w *Worker) reportError(err error) {
+	var data *clone.Data
+	if exception, ok := err.(*goja.Exception); ok {
+		data = clone.Serialize(w.child.vm, exception.Value())
+	} else {
+		data = clone.Serialize(w.child.vm, w.child.vm.NewGoError(err))
+	}
+
+	w.parent.loop.RunOnLoop(func(vm *goja.Runtime) {
+		errValue := data.Deserialize(vm)
+		if events.ListenerCount(vm, w.obj, "error") == 0 {
+			fmt.Fprintf(os.Stderr, "Uncaught error in worker %s: %v\n", w.filename, errValue)
+			return
+		}
+		w.dispatch("error", errValue)
+	})
+}
+
+// dispatch 在父线程中触发 Worker 对象上的事件
+func (w *Worker) dispatch(event string, arg goja.Value) {
+	if err := events.Emit(w.parent.vm, w.obj, event, arg); err != nil {
+		fmt.Fprintf(os.Stderr, "Worker %s handler error: %v\n", event, err)
+	}
+}
+
+// newMessageEvent 创建 message 事件对象
+func newMessageEvent(vm *goja.Runtime, data goja.Value) goja.Value {
+	event := vm.NewObject()
+	event.Set("type", "message")
+	event.Set("data", data)
+	return event
+}
+
+// addWorker 记录运行中的 Worker，Runner 关闭时统一终止
+func (r *Runner) addWorker(w *Worker) {
+	r.workersMu.Lock()
+	defer r.workersMu.Unlock()
+	if r.workers == nil {
+		r.workers = make(map[*Worker]struct{})
+	}
+	r.workers[w] = struct{}{}
+}
+
+// removeWorker 移除已退出的 Worker
+func (r *Runner) removeWorker(w *Worker) {
+	r.workersMu.Lock()
+	defer r.workersMu.Unlock()
+	delete(r.workers, w)
+}
+
+// terminateWorkers 终止所有运行中的 Worker
+func (r *Runner) terminateWorkers() {
+	r.workersMu.Lock()
+	workers := make([]*Worker, 0, len(r.workers))
+	for w := range r.workers {
+		workers = append(workers, w)
+	}
+	r.workersMu.Unlock()
+
+	for _, w := range workers {
+		w.stop()
+	}
+}
+
+// defineEventHandlers 在对象上定义 onmessage、onerror 等属性，
+// 设置的处理函数作为 emitter 的监听器添加，替换时移除之前的处理函数
+func defineEventHandlers(vm *goja.Runtime, obj, emitter *goja.Object) {
+	handlers := make(map[string]goja.Value)
+	for _, event := range workerEventNames {
+		event := event
+		getter := vm.ToValue(func(goja.FunctionCall) goja.Value {
+			if handler, ok := handlers[event]; ok {
+				return handler
+			}
+			return goja.Null()
+		})
+		setter := vm.ToValue(func(call goja.FunctionCall) goja.Value {
+			if handler, ok := handlers[event]; ok {
+				delete(handlers, event)
+				callMethod(emitter, "removeListener", vm.ToValue(event), handler)
+			}
+			if _, ok := goja.AssertFunction(call.Argument(0)); ok {
+				handlers[event] = call.Argument(0)
+				callMethod(emitter, "on", vm.ToValue(event), call.Argument(0))
+			}
+			return goja.Undefined()
+		})
+		obj.DefineAccessorProperty("on"+event, getter, setter, goja.FLAG_TRUE, goja.FLAG_TRUE)
+	}
+}
+
+// callMethod 调用 emitter 的方法，抛出的异常传给脚本
+func callMethod(emitter *goja.Object, name string, args ...goja.Value) {
+	method, ok := goja.AssertFunction(emitter.Get(name))
+	if !ok {
+		return
+	}
+	if _, err := method(emitter, args...); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,290 @@
+package security
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Permission 权限类别
+type Permission string
+
+const (
+	PermRead  Permission = "read"  // 读取文件
+	PermWrite Permission = "write" // 写入文件
+	PermNet   Permission = "net"   // 网络访问和监听
+	PermRun   Permission = "run"   // 执行子进程
+	PermEnv   Permission = "env"   // 读写环境变量
+)
+
+// AllowAllEntry 权限列表中表示允许全部的条目
+const AllowAllEntry = "*"
+
+// maxSymlinkDepth 解析悬空符号链接时最多跟随的层数，防止链接循环
+const maxSymlinkDepth = 40
+
+// Permissions 运行时权限配置，对应 CLI 的 --allow-* 标志。
+// 列表为空表示禁止该类访问，包含 "*" 表示允许该类的全部访问。
+type Permissions struct {
+	Read  []string // 允许读取的文件或目录（目录包含其所有子路径）
+	Write []string // 允许写入的文件或目录
+	Net   []string // 允许访问或监听的主机，格式为 host 或 host:port
+	Run   []string // 允许执行的命令名或路径
+	Env   []string // 允许读写的环境变量名
+}
+
+// AllowAll 返回允许全部访问的权限配置
+func AllowAll() *Permissions {
+	all := []string{AllowAllEntry}
+	return &Permissions{Read: all, Write: all, Net: all, Run: all, Env: all}
+}
+
+// Clone 复制权限配置
+func (p *Permissions) Clone() *Permissions {
+	if p == nil {
+		return nil
+	}
+	return &Permissions{
+		Read:  append([]string(nil), p.Read...),
+		Write: append([]string(nil), p.Write...),
+		Net:   append([]string(nil), p.Net...),
+		Run:   append([]string(nil), p.Run...),
+		Env:   append([]string(nil), p.Env...),
+	}
+}
+
+// PermissionDeniedError 访问未授权的资源时返回的错误
+type PermissionDeniedError struct {
+	Permission Permission
+	Target     string
+}
+
+// Error 实现 error 接口
+func (e *PermissionDeniedError) Error() string {
+	return fmt.Sprintf("requires %s access to %q, run again with the --allow-%s flag", e.Permission, e.Target, e.Permission)
+}
+
+// Guard 所有内置模块共享的权限检查器。
+// nil 或未设置权限的 Guard 不做任何限制，与未引入权限系统时的行为一致。
+type Guard struct {
+	mu    sync.RWMutex
+	perms *Permissions // nil 表示不限制
+}
+
+// NewGuard 创建不做限制的权限检查器
+func NewGuard() *Guard {
+	return &Guard{}
+}
+
+// Set 设置权限配置，nil 表示取消限制。路径条目按当前工作目录解析为绝对路径并解析其中的符号链接
+func (g *Guard) Set(p *Permissions) {
+	p = p.Clone()
+	if p != nil {
+		p.Read = absPaths(p.Read)
+		p.Write = absPaths(p.Write)
+	}
+	g.mu.Lock()
+	g.perms = p
+	g.mu.Unlock()
+}
+
+// Permissions 返回当前权限配置的副本，未限制时返回 nil
+func (g *Guard) Permissions() *Permissions {
+	if g == nil {
+		return nil
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.perms.Clone()
+}
+
+// Restricted 返回是否启用了权限限制
+func (g *Guard) Restricted() bool {
+	return g.Permissions() != nil
+}
+
+// entries 返回指定类别的允许列表，未限制时 ok 为 false
+func (g *Guard) entries(perm Permission) (list []string, ok bool) {
+	if g == nil {
+		return nil, false
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.perms == nil {
+		return nil, false
+	}
+	switch perm {
+	case PermRead:
+		return g.perms.Read, true
+	case PermWrite:
+		return g.perms.Write, true
+	case PermNet:
+		return g.perms.Net, true
+	case PermRun:
+		return g.perms.Run, true
+	case PermEnv:
+		return g.perms.Env, true
+	}
+	return nil, true
+}
+
+// check 按 match 规则检查 target 是否在允许列表中
+func (g *Guard) check(perm Permission, target string, match func(entry string) bool) error {
+	list, restricted := g.entries(perm)
+	if !restricted {
+		return nil
+	}
+	for _, entry := range list {
+		if entry == AllowAllEntry || match(entry) {
+			return nil
+		}
+	}
+	return &PermissionDeniedError{Permission: perm, Target: target}
+}
+
+// CheckRead 检查是否允许读取路径
+func (g *Guard) CheckRead(path string) error {
+	return g.checkPath(PermRead, path)
+}
+
+// CheckWrite 检查是否允许写入路径
+func (g *Guard) CheckWrite(path string) error {
+	return g.checkPath(PermWrite, path)
+}
+
+// checkPath 检查路径是否位于允许的文件或目录内。路径中的符号链接先解析为实际位置，
+// 允许目录中指向外部的链接不能绕过检查
+func (g *Guard) checkPath(perm Permission, path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = filepath.Clean(path)
+	}
+	if _, restricted := g.entries(perm); !restricted {
+		return nil
+	}
+	resolved := resolveSymlinks(abs, 0)
+	return g.check(perm, abs, func(entry string) bool {
+		return resolved == entry || strings.HasPrefix(resolved, strings.TrimSuffix(entry, string(filepath.Separator))+string(filepath.Separator))
+	})
+}
+
+// resolveSymlinks 解析绝对路径中的符号链接。路径不存在时解析最近的已存在父目录后拼接剩余部分，
+// 悬空的符号链接按其指向的位置继续解析（写入时会在该位置创建文件）
+func resolveSymlinks(path string, depth int) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	if depth < maxSymlinkDepth {
+		if target, err := os.Readlink(path); err == nil {
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(path), target)
+			}
+			return resolveSymlinks(target, depth+1)
+		}
+	}
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path
+	}
+	return filepath.Join(resolveSymlinks(parent, depth), filepath.Base(path))
+}
+
+// CheckNet 检查是否允许访问或监听 host:port，host 为空时视为 0.0.0.0（监听所有地址）
+func (g *Guard) CheckNet(host string, port string) error {
+	if host == "" {
+		host = "0.0.0.0"
+	}
+	host = strings.Trim(host, "[]")
+	target := host
+	if port != "" {
+		target = net.JoinHostPort(host, port)
+	}
+	return g.check(PermNet, target, func(entry string) bool {
+		entryHost, entryPort := entry, ""
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			entryHost, entryPort = h, p
+		}
+		return strings.EqualFold(strings.Trim(entryHost, "[]"), host) && (entryPort == "" || entryPort == port)
+	})
+}
+
+// CheckAddr 检查是否允许访问或监听地址，格式为 host:port、:port 或 port
+func (g *Guard) CheckAddr(addr string) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		if strings.Trim(addr, "0123456789") == "" {
+			return g.CheckNet("", addr)
+		}
+		return g.CheckNet(addr, "")
+	}
+	return g.CheckNet(host, port)
+}
+
+// CheckURL 检查是否允许访问 URL，未指定端口时使用协议的默认端口
+func (g *Guard) CheckURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return g.CheckNet(rawURL, "")
+	}
+	port := u.Port()
+	if port == "" {
+		switch strings.ToLower(u.Scheme) {
+		case "https", "wss":
+			port = "443"
+		case "http", "ws":
+			port = "80"
+		}
+	}
+	return g.CheckNet(u.Hostname(), port)
+}
+
+// CheckRun 检查是否允许执行命令，命令名和允许列表中的条目会在 PATH 中查找后比较
+func (g *Guard) CheckRun(command string) error {
+	resolved := lookPath(command)
+	return g.check(PermRun, command, func(entry string) bool {
+		return entry == command || lookPath(entry) == resolved
+	})
+}
+
+// CheckEnv 检查是否允许读写环境变量
+func (g *Guard) CheckEnv(name string) error {
+	return g.check(PermEnv, name, func(entry string) bool {
+		if runtime.GOOS == "windows" {
+			return strings.EqualFold(entry, name)
+		}
+		return entry == name
+	})
+}
+
+// absPaths 将路径条目转换为解析符号链接后的绝对路径
+func absPaths(paths []string) []string {
+	result := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if p == AllowAllEntry {
+			result = append(result, p)
+			continue
+		}
+		if abs, err := filepath.Abs(p); err == nil {
+			p = abs
+		}
+		result = append(result, resolveSymlinks(filepath.Clean(p), 0))
+	}
+	return result
+}
+
+// lookPath 在 PATH 中查找命令，找不到时返回清理后的原始路径
+func lookPath(command string) string {
+	if path, err := exec.LookPath(command); err == nil {
+		if abs, err := filepath.Abs(path); err == nil {
+			return abs
+		}
+		return path
+	}
+	return filepath.Clean(command)
+}
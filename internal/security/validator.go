@@ -6,7 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 
-	"sw_runtime/internal/consts"
+	"github.com/issueye/sw_runtime/internal/consts"
 )
 
 // PathValidator 路径验证器
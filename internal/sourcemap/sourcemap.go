@@ -0,0 +1,153 @@
+// Package sourcemap 处理转译代码中的内联 source map，
+// 使 TypeScript 与打包代码的异常堆栈指向原始源码的文件、行和列
+package sourcemap
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/dop251/goja"
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/parser"
+)
+
+// commentPrefix 内联 source map 注释前缀（esbuild 输出格式）
+const commentPrefix = "//# sourceMappingURL=data:application/json;base64,"
+
+// find 查找最后一个内联 source map 注释，返回注释的起止位置
+func find(code string) (int, int, bool) {
+	start := strings.LastIndex(code, commentPrefix)
+	if start < 0 || (start > 0 && code[start-1] != '\n') {
+		return 0, 0, false
+	}
+	end := strings.IndexByte(code[start:], '\n')
+	if end < 0 {
+		end = len(code)
+	} else {
+		end += start
+	}
+	return start, end, true
+}
+
+// Extract 提取代码中的内联 source map，返回 JSON 内容，没有时返回空字符串
+func Extract(code string) string {
+	start, end, ok := find(code)
+	if !ok {
+		return ""
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(code[start+len(commentPrefix) : end]))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// Strip 删除代码中的内联 source map 注释
+func Strip(code string) string {
+	start, end, ok := find(code)
+	if !ok {
+		return code
+	}
+	return code[:start] + code[end:]
+}
+
+// update 解析内联 source map 并用 fn 修改后写回代码
+func update(code string, fn func(sm map[string]interface{})) string {
+	start, end, ok := find(code)
+	if !ok {
+		return code
+	}
+	var sm map[string]interface{}
+	if err := json.Unmarshal([]byte(Extract(code)), &sm); err != nil {
+		return code
+	}
+	fn(sm)
+	data, err := json.Marshal(sm)
+	if err != nil {
+		return code
+	}
+	return code[:start] + commentPrefix + base64.StdEncoding.EncodeToString(data) + code[end:]
+}
+
+// ShiftLines 在代码前插入 n 行后调整内联 source map，使原有映射保持正确
+func ShiftLines(code string, n int) string {
+	if n <= 0 {
+		return code
+	}
+	return update(code, func(sm map[string]interface{}) {
+		mappings, _ := sm["mappings"].(string)
+		sm["mappings"] = strings.Repeat(";", n) + mappings
+	})
+}
+
+// Rebase 将内联 source map 中的相对源文件路径解析为 dir 下的绝对路径，
+// 用于代码在原始位置之外（如临时文件）执行的情况
+func Rebase(code string, dir string) string {
+	return update(code, func(sm map[string]interface{}) {
+		sources, _ := sm["sources"].([]interface{})
+		for i, source := range sources {
+			if s, ok := source.(string); ok && !filepath.IsAbs(s) && !path.IsAbs(s) {
+				sources[i] = filepath.Join(dir, filepath.FromSlash(s))
+			}
+		}
+	})
+}
+
+// Compile 编译脚本，代码中的 source map 用于将堆栈位置映射回原始源码。
+// 外部 map 文件不存在或 source map 无效时忽略映射，不影响脚本执行。
+func Compile(name, code string, strict bool) (*goja.Program, error) {
+	prg, err := Parse(name, code)
+	if err != nil {
+		return nil, err
+	}
+	return goja.CompileAST(prg, strict)
+}
+
+// Parse 解析脚本并加载其中的 source map，处理方式与 Compile 相同
+func Parse(name, code string) (*ast.Program, error) {
+	loader := parser.WithSourceMapLoader(func(p string) ([]byte, error) {
+		data, err := os.ReadFile(strings.TrimPrefix(p, "file://"))
+		if err != nil {
+			return nil, nil
+		}
+		return data, nil
+	})
+
+	prg, err := goja.Parse(name, code, loader)
+	if err != nil && strings.Contains(err.Error(), "source map") {
+		prg, err = goja.Parse(name, code, parser.WithDisableSourceMaps)
+	}
+	return prg, err
+}
+
+// FormatError 格式化 JS 异常，包含映射到原始源码的完整调用栈
+func FormatError(v interface{}) string {
+	switch e := v.(type) {
+	case nil:
+		return ""
+	case *goja.Exception:
+		return strings.TrimRight(e.String(), "\n")
+	case error:
+		var exception *goja.Exception
+		if errors.As(e, &exception) {
+			// 保留外层错误添加的上下文前缀
+			prefix := strings.TrimSuffix(e.Error(), exception.Error())
+			return prefix + FormatError(exception)
+		}
+		return e.Error()
+	case goja.Value:
+		if obj, ok := e.(*goja.Object); ok {
+			if stack := obj.Get("stack"); stack != nil && !goja.IsUndefined(stack) && !goja.IsNull(stack) {
+				return strings.TrimRight(stack.String(), "\n")
+			}
+		}
+		return e.String()
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,231 @@
+// Package standalone 读写嵌入在可执行文件末尾的脚本载荷，用于生成独立可执行文件
+package standalone
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"sync"
+)
+
+// 载荷布局：[运行时可执行文件][zip 数据][8 字节 zip 长度（小端）][8 字节魔数]
+var magic = []byte("SWRTPKG1")
+
+const (
+	trailerSize  = 16
+	manifestName = "manifest.json"
+	codeName     = "main.js"
+	assetsDir    = "assets/"
+)
+
+// Payload 嵌入可执行文件的脚本与资源
+type Payload struct {
+	Entry     string            `json:"entry"`         // 入口脚本名，用于 process.argv
+	Encrypted bool              `json:"encrypted"`     // Code 是否为加密的 bundle
+	Key       string            `json:"key,omitempty"` // 嵌入的解密密钥（--embed-key，仅混淆），为空时运行时从环境变量读取
+	Code      string            `json:"-"`             // 打包后的代码
+	Assets    map[string][]byte `json:"-"`             // 资源文件，键为 / 分隔的相对路径
+}
+
+// AssetNames 获取排序后的资源名称
+func (p *Payload) AssetNames() []string {
+	names := make([]string, 0, len(p.Assets))
+	for name := range p.Assets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Asset 获取资源内容，名称可带 ./ 前缀
+func (p *Payload) Asset(name string) ([]byte, bool) {
+	data, ok := p.Assets[path.Clean("/" + name)[1:]]
+	return data, ok
+}
+
+// Build 复制运行时可执行文件并在末尾追加载荷，生成独立可执行文件。
+// 如果 runtimePath 本身已包含载荷，会先去掉旧载荷。
+func Build(runtimePath, output string, p *Payload) error {
+	src, err := os.Open(runtimePath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+	if offset, _, err := locate(src, size); err == nil {
+		size = offset
+	}
+
+	archive, err := encode(p)
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, io.NewSectionReader(src, 0, size)); err != nil {
+		dst.Close()
+		return err
+	}
+	trailer := make([]byte, trailerSize)
+	binary.LittleEndian.PutUint64(trailer, uint64(len(archive)))
+	copy(trailer[8:], magic)
+	if _, err := dst.Write(append(archive, trailer...)); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// encode 将载荷编码为 zip
+func encode(p *Payload) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	manifest, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{
+		manifestName: manifest,
+		codeName:     []byte(p.Code),
+	}
+	for name, data := range p.Assets {
+		files[assetsDir+name] = data
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// errNoPayload 文件中没有载荷
+var errNoPayload = errors.New("no embedded payload")
+
+// locate 查找载荷，返回 zip 数据的起始位置与长度
+func locate(r io.ReaderAt, size int64) (int64, int64, error) {
+	if size < trailerSize {
+		return 0, 0, errNoPayload
+	}
+	trailer := make([]byte, trailerSize)
+	if _, err := r.ReadAt(trailer, size-trailerSize); err != nil {
+		return 0, 0, err
+	}
+	if !bytes.Equal(trailer[8:], magic) {
+		return 0, 0, errNoPayload
+	}
+	length := int64(binary.LittleEndian.Uint64(trailer))
+	if length <= 0 || length > size-trailerSize {
+		return 0, 0, fmt.Errorf("corrupted payload: invalid length %d", length)
+	}
+	return size - trailerSize - length, length, nil
+}
+
+// Load 从可执行文件读取载荷，文件不包含载荷时返回 nil, nil
+func Load(exePath string) (*Payload, error) {
+	f, err := os.Open(exePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	offset, length, err := locate(f, info.Size())
+	if errors.Is(err, errNoPayload) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := zip.NewReader(io.NewSectionReader(f, offset, length), length)
+	if err != nil {
+		return nil, fmt.Errorf("corrupted payload: %w", err)
+	}
+
+	p := &Payload{Assets: make(map[string][]byte)}
+	var hasManifest, hasCode bool
+	for _, file := range zr.File {
+		data, err := readZipFile(file)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case file.Name == manifestName:
+			if err := json.Unmarshal(data, p); err != nil {
+				return nil, fmt.Errorf("corrupted payload manifest: %w", err)
+			}
+			hasManifest = true
+		case file.Name == codeName:
+			p.Code = string(data)
+			hasCode = true
+		case len(file.Name) > len(assetsDir) && file.Name[:len(assetsDir)] == assetsDir:
+			p.Assets[file.Name[len(assetsDir):]] = data
+		}
+	}
+	if !hasManifest || !hasCode {
+		return nil, fmt.Errorf("corrupted payload: missing %s or %s", manifestName, codeName)
+	}
+	return p, nil
+}
+
+// readZipFile 读取 zip 中的单个文件
+func readZipFile(file *zip.File) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+var (
+	current     *Payload
+	currentOnce sync.Once
+	currentErr  error
+)
+
+// Current 获取当前进程可执行文件中嵌入的载荷，普通运行时返回 nil
+func Current() (*Payload, error) {
+	currentOnce.Do(func() {
+		exe, err := os.Executable()
+		if err != nil {
+			currentErr = err
+			return
+		}
+		current, currentErr = Load(exe)
+	})
+	return current, currentErr
+}
@@ -0,0 +1,551 @@
+package testrunner
+
+// configGlobal 传递测试配置的全局变量名
+const configGlobal = "__swTestConfig"
+
+// doneGlobal 全部测试执行完毕后设置的全局变量名
+const doneGlobal = "__swTestDone"
+
+// runCode 执行已注册测试的代码，每个结果通过 config.report 交给 Go 侧
+const runCode = `globalThis.__swTest.run().then(() => { globalThis.__swTestDone = true; });`
+
+// harnessSource 测试框架：describe/it/钩子函数与 expect 断言库
+const harnessSource = `(function (config) {
+	function createSuite(name, parent, mode) {
+		return {
+			name: name,
+			parent: parent,
+			mode: mode,
+			children: [],
+			beforeAll: [],
+			afterAll: [],
+			beforeEach: [],
+			afterEach: []
+		};
+	}
+
+	const root = createSuite('', null, '');
+	let current = root;
+	let hasOnly = false;
+	const filter = config.filter ? new RegExp(config.filter) : null;
+	// 之前的运行中已有结果的测试（某个测试阻塞事件循环被中断后，在新的运行器中继续执行其余测试）
+	const completed = new Set(config.skip || []);
+
+	function describe(name, fn, mode) {
+		if (typeof fn !== 'function') throw new TypeError('describe() requires a callback');
+		const suite = createSuite(String(name), current, mode || '');
+		if (mode === 'only') hasOnly = true;
+		current.children.push(suite);
+		const parent = current;
+		current = suite;
+		try {
+			const result = fn();
+			if (result && typeof result.then === 'function') {
+				throw new TypeError('describe() callback must be synchronous');
+			}
+		} finally {
+			current = parent;
+		}
+	}
+	describe.skip = (name, fn) => describe(name, fn, 'skip');
+	describe.only = (name, fn) => describe(name, fn, 'only');
+
+	function it(name, fn, timeout, mode) {
+		if (mode !== 'todo' && typeof fn !== 'function') throw new TypeError('it() requires a callback');
+		if (mode === 'only') hasOnly = true;
+		current.children.push({
+			test: true,
+			name: String(name),
+			fn: fn,
+			timeout: typeof timeout === 'number' ? timeout : config.timeout,
+			mode: mode || ''
+		});
+	}
+	it.skip = (name, fn, timeout) => it(name, fn, timeout, 'skip');
+	it.only = (name, fn, timeout) => it(name, fn, timeout, 'only');
+	it.todo = (name) => it(name, undefined, undefined, 'todo');
+
+	function hook(kind) {
+		return function (fn, timeout) {
+			if (typeof fn !== 'function') throw new TypeError(kind + '() requires a callback');
+			current[kind].push({ fn: fn, timeout: typeof timeout === 'number' ? timeout : config.timeout });
+		};
+	}
+
+	// ---------- 值格式化与比较 ----------
+
+	function format(value, seen) {
+		seen = seen || [];
+		if (typeof value === 'string') return JSON.stringify(value);
+		if (typeof value === 'bigint') return value + 'n';
+		if (typeof value === 'function') return '[Function' + (value.name ? ' ' + value.name : '') + ']';
+		if (typeof value === 'symbol') return value.toString();
+		if (value === null || typeof value !== 'object') return String(value);
+		if (seen.indexOf(value) >= 0) return '[Circular]';
+		seen = seen.concat([value]);
+		if (value instanceof Date) return 'Date(' + (isNaN(value) ? 'Invalid Date' : value.toISOString()) + ')';
+		if (value instanceof RegExp) return String(value);
+		if (value instanceof Error) return value.name + ': ' + value.message;
+		if (Array.isArray(value)) return '[' + value.map(v => format(v, seen)).join(', ') + ']';
+		if (value instanceof Map) {
+			return 'Map {' + Array.from(value).map(([k, v]) => format(k, seen) + ' => ' + format(v, seen)).join(', ') + '}';
+		}
+		if (value instanceof Set) return 'Set {' + Array.from(value).map(v => format(v, seen)).join(', ') + '}';
+		if (ArrayBuffer.isView(value)) return value.constructor.name + ' [' + Array.from(value).join(', ') + ']';
+		const keys = Object.keys(value);
+		const name = value.constructor && value.constructor !== Object ? value.constructor.name + ' ' : '';
+		return name + '{' + keys.map(k => k + ': ' + format(value[k], seen)).join(', ') + '}';
+	}
+
+	function equals(a, b, strict, seen) {
+		if (Object.is(a, b)) return true;
+		if (typeof a !== 'object' || typeof b !== 'object' || a === null || b === null) return false;
+		if (strict && Object.getPrototypeOf(a) !== Object.getPrototypeOf(b)) return false;
+
+		seen = seen || [];
+		for (const [x, y] of seen) {
+			if (x === a && y === b) return true;
+		}
+		seen = seen.concat([[a, b]]);
+
+		if (a instanceof Date || b instanceof Date) {
+			return a instanceof Date && b instanceof Date && a.getTime() === b.getTime();
+		}
+		if (a instanceof RegExp || b instanceof RegExp) {
+			return a instanceof RegExp && b instanceof RegExp && String(a) === String(b);
+		}
+		if (a instanceof Error || b instanceof Error) {
+			return a instanceof Error && b instanceof Error && a.name === b.name && a.message === b.message;
+		}
+		if (Array.isArray(a) !== Array.isArray(b)) return false;
+		if (a instanceof Map || b instanceof Map) {
+			if (!(a instanceof Map && b instanceof Map) || a.size !== b.size) return false;
+			for (const [key, value] of a) {
+				if (!b.has(key) || !equals(value, b.get(key), strict, seen)) return false;
+			}
+			return true;
+		}
+		if (a instanceof Set || b instanceof Set) {
+			if (!(a instanceof Set && b instanceof Set) || a.size !== b.size) return false;
+			outer: for (const x of a) {
+				if (b.has(x)) continue;
+				for (const y of b) {
+					if (equals(x, y, strict, seen)) continue outer;
+				}
+				return false;
+			}
+			return true;
+		}
+		if (ArrayBuffer.isView(a) || ArrayBuffer.isView(b)) {
+			if (!ArrayBuffer.isView(a) || !ArrayBuffer.isView(b) || a.length !== b.length) return false;
+			for (let i = 0; i < a.length; i++) {
+				if (!Object.is(a[i], b[i])) return false;
+			}
+			return true;
+		}
+
+		const keysOf = (obj) => Object.keys(obj).filter(k => strict || obj[k] !== undefined);
+		const keysA = keysOf(a);
+		const keysB = keysOf(b);
+		if (keysA.length !== keysB.length) return false;
+		for (const key of keysA) {
+			if (!Object.prototype.hasOwnProperty.call(b, key)) return false;
+			if (!equals(a[key], b[key], strict, seen)) return false;
+		}
+		return true;
+	}
+
+	function contains(container, item) {
+		if (typeof container === 'string') return container.indexOf(String(item)) >= 0;
+		if (container && typeof container[Symbol.iterator] === 'function') {
+			for (const value of container) {
+				if (value === item) return true;
+			}
+		}
+		return false;
+	}
+
+	function getPath(obj, path) {
+		const parts = Array.isArray(path) ? path : String(path).split('.');
+		let value = obj;
+		for (const part of parts) {
+			if (value === null || value === undefined || !(Object(value).hasOwnProperty(part) || part in Object(value))) {
+				return { found: false };
+			}
+			value = value[part];
+		}
+		return { found: true, value: value };
+	}
+
+	function matchesError(error, expected) {
+		if (expected === undefined) return true;
+		const message = error && error.message !== undefined ? String(error.message) : String(error);
+		if (typeof expected === 'string') return message.indexOf(expected) >= 0;
+		if (expected instanceof RegExp) return expected.test(message);
+		if (typeof expected === 'function') return error instanceof expected;
+		if (expected instanceof Error) return message === expected.message;
+		return false;
+	}
+
+	// ---------- expect ----------
+
+	class AssertionError extends Error {
+		constructor(message) {
+			super(message);
+			this.name = 'AssertionError';
+		}
+	}
+
+	const matchers = {
+		toBe: (received, expected) => ({
+			pass: Object.is(received, expected),
+			message: 'to be ' + format(expected)
+		}),
+		toEqual: (received, expected) => ({
+			pass: equals(received, expected, false),
+			message: 'to equal ' + format(expected)
+		}),
+		toStrictEqual: (received, expected) => ({
+			pass: equals(received, expected, true),
+			message: 'to strictly equal ' + format(expected)
+		}),
+		toBeTruthy: (received) => ({ pass: !!received, message: 'to be truthy' }),
+		toBeFalsy: (received) => ({ pass: !received, message: 'to be falsy' }),
+		toBeNull: (received) => ({ pass: received === null, message: 'to be null' }),
+		toBeUndefined: (received) => ({ pass: received === undefined, message: 'to be undefined' }),
+		toBeDefined: (received) => ({ pass: received !== undefined, message: 'to be defined' }),
+		toBeNaN: (received) => ({ pass: typeof received === 'number' && isNaN(received), message: 'to be NaN' }),
+		toBeGreaterThan: (received, n) => ({ pass: received > n, message: 'to be greater than ' + format(n) }),
+		toBeGreaterThanOrEqual: (received, n) => ({ pass: received >= n, message: 'to be greater than or equal to ' + format(n) }),
+		toBeLessThan: (received, n) => ({ pass: received < n, message: 'to be less than ' + format(n) }),
+		toBeLessThanOrEqual: (received, n) => ({ pass: received <= n, message: 'to be less than or equal to ' + format(n) }),
+		toBeCloseTo: (received, expected, digits) => {
+			const precision = digits === undefined ? 2 : digits;
+			return {
+				pass: Math.abs(expected - received) < Math.pow(10, -precision) / 2,
+				message: 'to be close to ' + format(expected) + ' (' + precision + ' digits)'
+			};
+		},
+		toBeInstanceOf: (received, ctor) => ({
+			pass: received instanceof ctor,
+			message: 'to be an instance of ' + (ctor && ctor.name ? ctor.name : format(ctor))
+		}),
+		toBeTypeOf: (received, type) => ({ pass: typeof received === type, message: 'to be of type ' + type }),
+		toContain: (received, item) => ({ pass: contains(received, item), message: 'to contain ' + format(item) }),
+		toContainEqual: (received, item) => ({
+			pass: Array.from(received || []).some(value => equals(value, item, false)),
+			message: 'to contain equal ' + format(item)
+		}),
+		toHaveLength: (received, length) => ({
+			pass: received !== null && received !== undefined && received.length === length,
+			message: 'to have length ' + length
+		}),
+		toHaveProperty: (received, path, value) => {
+			const result = getPath(received, path);
+			return {
+				pass: result.found && (value === undefined || equals(result.value, value, false)),
+				message: 'to have property ' + format(path) + (value !== undefined ? ' equal to ' + format(value) : '')
+			};
+		},
+		toMatch: (received, pattern) => ({
+			pass: typeof received === 'string' && (pattern instanceof RegExp ? pattern.test(received) : received.indexOf(pattern) >= 0),
+			message: 'to match ' + format(pattern)
+		}),
+		toMatchObject: (received, expected) => {
+			const match = (a, b) => {
+				if (typeof b !== 'object' || b === null) return equals(a, b, false);
+				if (typeof a !== 'object' || a === null) return false;
+				if (Array.isArray(b)) {
+					return Array.isArray(a) && a.length === b.length && b.every((v, i) => match(a[i], v));
+				}
+				return Object.keys(b).every(key => match(a[key], b[key]));
+			};
+			return { pass: match(received, expected), message: 'to match object ' + format(expected) };
+		},
+		toThrow: (received, expected) => {
+			if (typeof received !== 'function') {
+				throw new TypeError('toThrow() expects a function');
+			}
+			let thrown = false;
+			let error;
+			try {
+				received();
+			} catch (e) {
+				thrown = true;
+				error = e;
+			}
+			return {
+				pass: thrown && matchesError(error, expected),
+				message: 'to throw' + (expected !== undefined ? ' ' + format(expected) : '') +
+					(thrown ? ' (threw ' + format(error) + ')' : ''),
+				skipReceived: true
+			};
+		}
+	};
+
+	function assert(result, received, negate) {
+		if (result.pass === negate) {
+			const receivedText = result.skipReceived ? '' : 'expected ' + format(received) + ' ';
+			throw new AssertionError(receivedText + (negate ? 'not ' : '') + result.message);
+		}
+	}
+
+	function buildMatchers(received, negate, promise) {
+		const target = {};
+		for (const name of Object.keys(matchers)) {
+			const matcher = matchers[name];
+			if (!matcher) continue;
+			if (promise) {
+				target[name] = (...args) => promise.then(value => {
+					if (name === 'toThrow') {
+						assert(matcher(() => { throw value; }, ...args), value, negate);
+					} else {
+						assert(matcher(value, ...args), value, negate);
+					}
+				});
+			} else {
+				target[name] = (...args) => {
+					assert(matcher(received, ...args), received, negate);
+				};
+			}
+		}
+		return target;
+	}
+
+	function expect(received) {
+		const result = buildMatchers(received, false);
+		result.not = buildMatchers(received, true);
+
+		const settle = (wantResolved) => Promise.resolve(received).then(
+			value => {
+				if (!wantResolved) throw new AssertionError('expected promise to reject but it resolved with ' + format(value));
+				return value;
+			},
+			error => {
+				if (wantResolved) throw new AssertionError('expected promise to resolve but it rejected with ' + format(error));
+				return error;
+			}
+		);
+		Object.defineProperty(result, 'resolves', {
+			get() {
+				const matchers = buildMatchers(undefined, false, settle(true));
+				matchers.not = buildMatchers(undefined, true, settle(true));
+				return matchers;
+			}
+		});
+		Object.defineProperty(result, 'rejects', {
+			get() {
+				const matchers = buildMatchers(undefined, false, settle(false));
+				matchers.not = buildMatchers(undefined, true, settle(false));
+				return matchers;
+			}
+		});
+		return result;
+	}
+
+	// expect.extend 注册自定义断言：matcher(received, ...args) 返回 { pass, message }
+	expect.extend = function (custom) {
+		for (const name of Object.keys(custom)) {
+			const fn = custom[name];
+			matchers[name] = function () {
+				const result = fn.apply(null, arguments);
+				const message = typeof result.message === 'function' ? result.message() : result.message;
+				return { pass: !!result.pass, message: message || name, skipReceived: !!result.message };
+			};
+		}
+	};
+
+	// ---------- 执行 ----------
+
+	// callWithTimeout 执行测试或钩子函数。step 描述当前步骤，交给 Go 侧的看门狗：
+	// 函数同步阻塞（如死循环）导致计时器无法触发时，由 Go 侧中断 VM 并报告超时
+	function callWithTimeout(fn, timeout, step) {
+		return new Promise((resolve, reject) => {
+			let finished = false;
+			config.begin(JSON.stringify(step), timeout);
+			const timer = setTimeout(() => {
+				finish(new Error('Timeout of ' + timeout + 'ms exceeded'));
+			}, timeout);
+			function finish(error) {
+				if (finished) return;
+				finished = true;
+				config.end();
+				clearTimeout(timer);
+				if (error === undefined || error === null) {
+					resolve();
+				} else {
+					reject(error);
+				}
+			}
+			try {
+				if (fn.length > 0) {
+					// done 回调风格
+					fn((error) => finish(error === undefined || error === null ? undefined : error));
+				} else {
+					Promise.resolve(fn()).then(
+						() => finish(),
+						(error) => finish(error === undefined || error === null ? new Error('Promise rejected with ' + format(error)) : error)
+					);
+				}
+			} catch (error) {
+				finish(error === undefined || error === null ? new Error('Thrown ' + format(error)) : error);
+			}
+		});
+	}
+
+	function serializeError(error) {
+		if (error instanceof Error) {
+			return { name: error.name, message: String(error.message), stack: String(error.stack || '') };
+		}
+		return { name: 'Error', message: format(error), stack: '' };
+	}
+
+	function suitePath(suite) {
+		const names = [];
+		for (let s = suite; s && s.parent; s = s.parent) names.unshift(s.name);
+		return names;
+	}
+
+	function fullName(suite, test) {
+		return suitePath(suite).concat([test.name]).join(' > ');
+	}
+
+	function testStep(suite, test) {
+		return { name: test.name, suites: suitePath(suite), key: fullName(suite, test) };
+	}
+
+	function hookStep(suite, kind) {
+		return { name: kind + ' hook', suites: suitePath(suite), key: '' };
+	}
+
+	function record(result) {
+		config.report(JSON.stringify(result));
+	}
+
+	// skipReason 计算测试是否需要执行，返回 '' 或跳过原因
+	function skipReason(suite, test) {
+		if (completed.has(fullName(suite, test))) return 'filtered';
+		if (filter && !filter.test(fullName(suite, test))) return 'filtered';
+		if (test.mode === 'todo') return 'todo';
+		let only = test.mode === 'only';
+		for (let s = suite; s; s = s.parent) {
+			if (s.mode === 'skip') return 'skip';
+			if (s.mode === 'only') only = true;
+		}
+		if (test.mode === 'skip') return 'skip';
+		if (hasOnly && !only) return 'skip';
+		return '';
+	}
+
+	function hasRunnable(suite) {
+		return suite.children.some(child => child.test ? skipReason(suite, child) === '' : hasRunnable(child));
+	}
+
+	function eachHooks(suite, kind) {
+		const hooks = [];
+		for (let s = suite; s; s = s.parent) {
+			if (kind === 'beforeEach') hooks.unshift(...s.beforeEach);
+			else hooks.push(...s.afterEach);
+		}
+		return hooks;
+	}
+
+	async function runTest(suite, test, suiteError) {
+		const result = {
+			name: test.name,
+			suites: suitePath(suite),
+			status: 'passed',
+			duration: 0
+		};
+		const reason = skipReason(suite, test);
+		if (reason === 'filtered') return;
+		if (reason) {
+			result.status = reason === 'todo' ? 'todo' : 'skipped';
+			record(result);
+			return;
+		}
+		if (suiteError) {
+			result.status = 'failed';
+			result.error = serializeError(suiteError);
+			record(result);
+			return;
+		}
+
+		const start = Date.now();
+		const step = testStep(suite, test);
+		let failure;
+		try {
+			for (const h of eachHooks(suite, 'beforeEach')) {
+				await callWithTimeout(h.fn, h.timeout, step);
+			}
+			await callWithTimeout(test.fn, test.timeout, step);
+		} catch (error) {
+			failure = error;
+		}
+		for (const h of eachHooks(suite, 'afterEach')) {
+			try {
+				await callWithTimeout(h.fn, h.timeout, step);
+			} catch (error) {
+				if (failure === undefined) failure = error;
+			}
+		}
+
+		result.duration = Date.now() - start;
+		if (failure !== undefined) {
+			result.status = 'failed';
+			result.error = serializeError(failure);
+		}
+		record(result);
+	}
+
+	async function runSuite(suite, suiteError) {
+		const runnable = !suiteError && hasRunnable(suite);
+		if (runnable) {
+			try {
+				for (const h of suite.beforeAll) {
+					await callWithTimeout(h.fn, h.timeout, hookStep(suite, 'beforeAll'));
+				}
+			} catch (error) {
+				suiteError = error;
+			}
+		}
+
+		for (const child of suite.children) {
+			if (child.test) {
+				await runTest(suite, child, suiteError);
+			} else {
+				await runSuite(child, suiteError);
+			}
+		}
+
+		if (runnable) {
+			for (const h of suite.afterAll) {
+				try {
+					await callWithTimeout(h.fn, h.timeout, hookStep(suite, 'afterAll'));
+				} catch (error) {
+					record({
+						name: 'afterAll hook',
+						suites: suitePath(suite),
+						status: 'failed',
+						duration: 0,
+						error: serializeError(error)
+					});
+				}
+			}
+		}
+	}
+
+	async function run() {
+		await runSuite(root, null);
+	}
+
+	globalThis.describe = describe;
+	globalThis.it = it;
+	globalThis.test = it;
+	globalThis.beforeAll = hook('beforeAll');
+	globalThis.afterAll = hook('afterAll');
+	globalThis.beforeEach = hook('beforeEach');
+	globalThis.afterEach = hook('afterEach');
+	globalThis.expect = expect;
+	globalThis.__swTest = { run: run };
+})(globalThis.__swTestConfig);`
@@ -0,0 +1,239 @@
+package testrunner
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Reporter 测试结果报告器
+type Reporter interface {
+	// FileDone 单个测试文件执行完毕
+	FileDone(result *FileResult)
+	// Finish 所有测试文件执行完毕
+	Finish(results []*FileResult) error
+}
+
+// NewReporter 根据名称创建报告器：spec（默认）、tap、junit
+func NewReporter(name string, w io.Writer) (Reporter, error) {
+	switch name {
+	case "", "spec":
+		return &specReporter{w: w}, nil
+	case "tap":
+		return &tapReporter{w: w}, nil
+	case "junit":
+		return &junitReporter{w: w}, nil
+	}
+	return nil, fmt.Errorf("unknown reporter: %s (available: spec, tap, junit)", name)
+}
+
+// displayPath 获取相对当前目录的文件路径
+func displayPath(file string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, file); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return file
+}
+
+// specReporter 面向终端的可读报告
+type specReporter struct {
+	w io.Writer
+}
+
+func (r *specReporter) FileDone(result *FileResult) {
+	fmt.Fprintf(r.w, "\n%s\n", displayPath(result.File))
+	if result.Err != nil {
+		fmt.Fprintf(r.w, "  ✗ 加载失败: %v\n", result.Err)
+		return
+	}
+	for _, t := range result.Tests {
+		switch t.Status {
+		case StatusPassed:
+			fmt.Fprintf(r.w, "  ✓ %s (%dms)\n", t.FullName(), t.Duration.Milliseconds())
+		case StatusFailed:
+			fmt.Fprintf(r.w, "  ✗ %s\n", t.FullName())
+			if t.Error != nil {
+				fmt.Fprintf(r.w, "    %s: %s\n", t.Error.Name, t.Error.Message)
+			}
+		case StatusSkipped:
+			fmt.Fprintf(r.w, "  - %s (skipped)\n", t.FullName())
+		case StatusTodo:
+			fmt.Fprintf(r.w, "  - %s (todo)\n", t.FullName())
+		}
+	}
+}
+
+func (r *specReporter) Finish(results []*FileResult) error {
+	s := Summarize(results)
+	fmt.Fprintf(r.w, "\n测试: %d 通过, %d 失败, %d 跳过, %d 待办", s.Passed, s.Failed, s.Skipped, s.Todo)
+	if s.Errors > 0 {
+		fmt.Fprintf(r.w, ", %d 个文件加载失败", s.Errors)
+	}
+	fmt.Fprintf(r.w, " (%d 个文件, %dms)\n", s.Files, s.Duration.Milliseconds())
+	return nil
+}
+
+// tapReporter TAP version 13 格式报告
+type tapReporter struct {
+	w     io.Writer
+	count int
+	began bool
+}
+
+func (r *tapReporter) FileDone(result *FileResult) {
+	if !r.began {
+		fmt.Fprintln(r.w, "TAP version 13")
+		r.began = true
+	}
+	file := displayPath(result.File)
+	if result.Err != nil {
+		r.count++
+		fmt.Fprintf(r.w, "not ok %d - %s\n", r.count, file)
+		writeYAMLBlock(r.w, result.Err.Error(), "")
+		return
+	}
+	for _, t := range result.Tests {
+		r.count++
+		name := file + " > " + t.FullName()
+		switch t.Status {
+		case StatusPassed:
+			fmt.Fprintf(r.w, "ok %d - %s\n", r.count, name)
+		case StatusFailed:
+			fmt.Fprintf(r.w, "not ok %d - %s\n", r.count, name)
+			if t.Error != nil {
+				writeYAMLBlock(r.w, t.Error.Message, t.Error.Stack)
+			}
+		case StatusSkipped:
+			fmt.Fprintf(r.w, "ok %d - %s # SKIP\n", r.count, name)
+		case StatusTodo:
+			fmt.Fprintf(r.w, "not ok %d - %s # TODO\n", r.count, name)
+		}
+	}
+}
+
+func (r *tapReporter) Finish(results []*FileResult) error {
+	if !r.began {
+		fmt.Fprintln(r.w, "TAP version 13")
+	}
+	fmt.Fprintf(r.w, "1..%d\n", r.count)
+	s := Summarize(results)
+	fmt.Fprintf(r.w, "# pass %d\n# fail %d\n# skip %d\n# todo %d\n", s.Passed, s.Failed+s.Errors, s.Skipped, s.Todo)
+	return nil
+}
+
+// writeYAMLBlock 输出 TAP 失败详情
+func writeYAMLBlock(w io.Writer, message, stack string) {
+	fmt.Fprintln(w, "  ---")
+	fmt.Fprintf(w, "  message: %q\n", message)
+	if stack != "" {
+		fmt.Fprintln(w, "  stack: |")
+		for _, line := range strings.Split(strings.TrimRight(stack, "\n"), "\n") {
+			fmt.Fprintf(w, "    %s\n", line)
+		}
+	}
+	fmt.Fprintln(w, "  ...")
+}
+
+// junitReporter JUnit XML 格式报告，所有文件执行完毕后一次性输出
+type junitReporter struct {
+	w io.Writer
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+	Error    *junitFailure   `xml:"error,omitempty"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+func (r *junitReporter) FileDone(result *FileResult) {}
+
+func (r *junitReporter) Finish(results []*FileResult) error {
+	s := Summarize(results)
+	doc := junitTestSuites{
+		Tests:    s.Passed + s.Failed + s.Skipped + s.Todo,
+		Failures: s.Failed,
+		Errors:   s.Errors,
+		Skipped:  s.Skipped + s.Todo,
+		Time:     fmt.Sprintf("%.3f", s.Duration.Seconds()),
+	}
+
+	for _, f := range results {
+		fs := Summarize([]*FileResult{f})
+		suite := junitTestSuite{
+			Name:     displayPath(f.File),
+			Tests:    len(f.Tests),
+			Failures: fs.Failed,
+			Errors:   fs.Errors,
+			Skipped:  fs.Skipped + fs.Todo,
+			Time:     fmt.Sprintf("%.3f", f.Duration.Seconds()),
+		}
+		if f.Err != nil {
+			suite.Error = &junitFailure{Message: f.Err.Error(), Text: f.Err.Error()}
+		}
+		for _, t := range f.Tests {
+			c := junitTestCase{
+				Name:      t.Name,
+				ClassName: strings.Join(append([]string{suite.Name}, t.Suites...), " > "),
+				Time:      fmt.Sprintf("%.3f", t.Duration.Seconds()),
+			}
+			switch t.Status {
+			case StatusFailed:
+				c.Failure = &junitFailure{}
+				if t.Error != nil {
+					c.Failure.Message = t.Error.Message
+					c.Failure.Type = t.Error.Name
+					c.Failure.Text = t.Error.Stack
+				}
+			case StatusSkipped, StatusTodo:
+				c.Skipped = &struct{}{}
+			}
+			suite.Cases = append(suite.Cases, c)
+		}
+		doc.Suites = append(doc.Suites, suite)
+	}
+
+	if _, err := io.WriteString(r.w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(r.w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(r.w, "\n")
+	return err
+}
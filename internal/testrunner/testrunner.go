@@ -0,0 +1,282 @@
+package testrunner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
+)
+
+// Status 测试结果状态
+type Status string
+
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+	StatusTodo    Status = "todo"
+)
+
+// DefaultTimeout 单个测试与钩子函数的默认超时时间
+const DefaultTimeout = 5 * time.Second
+
+// testFilePattern 测试文件名：*.test.ts、*.spec.js 等
+var testFilePattern = regexp.MustCompile(`\.(test|spec)\.(ts|tsx|mts|cts|js|mjs|cjs)$`)
+
+// Options 测试运行选项
+type Options struct {
+	Filter  string        // 按完整测试名（suite > test）过滤的正则表达式
+	Timeout time.Duration // 默认超时时间，测试可单独指定
+	Argv    []string      // 传给测试脚本的命令行参数
+}
+
+// TestError 测试失败信息
+type TestError struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	Stack   string `json:"stack"`
+}
+
+// TestResult 单个测试的结果
+type TestResult struct {
+	Name     string        `json:"name"`
+	Suites   []string      `json:"suites"`
+	Status   Status        `json:"status"`
+	Duration time.Duration `json:"-"`
+	Error    *TestError    `json:"error,omitempty"`
+
+	DurationMs int64 `json:"duration"`
+}
+
+// FullName 获取包含 describe 层级的完整测试名
+func (t *TestResult) FullName() string {
+	return strings.Join(append(append([]string{}, t.Suites...), t.Name), " > ")
+}
+
+// FileResult 单个测试文件的结果
+type FileResult struct {
+	File     string
+	Tests    []TestResult
+	Duration time.Duration
+	Err      error // 文件加载或执行失败
+}
+
+// Failed 检查文件中是否有失败的测试
+func (f *FileResult) Failed() bool {
+	if f.Err != nil {
+		return true
+	}
+	for _, t := range f.Tests {
+		if t.Status == StatusFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary 测试结果统计
+type Summary struct {
+	Files    int
+	Passed   int
+	Failed   int
+	Skipped  int
+	Todo     int
+	Errors   int // 加载失败的文件数
+	Duration time.Duration
+}
+
+// Summarize 统计所有文件的测试结果
+func Summarize(results []*FileResult) Summary {
+	var s Summary
+	s.Files = len(results)
+	for _, f := range results {
+		s.Duration += f.Duration
+		if f.Err != nil {
+			s.Errors++
+		}
+		for _, t := range f.Tests {
+			switch t.Status {
+			case StatusPassed:
+				s.Passed++
+			case StatusFailed:
+				s.Failed++
+			case StatusSkipped:
+				s.Skipped++
+			case StatusTodo:
+				s.Todo++
+			}
+		}
+	}
+	return s
+}
+
+// OK 检查是否全部通过
+func (s Summary) OK() bool {
+	return s.Failed == 0 && s.Errors == 0
+}
+
+// Discover 查找测试文件，参数可以是文件或目录，目录会递归查找（跳过 node_modules 与隐藏目录）
+func Discover(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	seen := make(map[string]bool)
+	var files []string
+	add := func(path string) {
+		abs, err := filepath.Abs(path)
+		if err == nil && !seen[abs] {
+			seen[abs] = true
+			files = append(files, abs)
+		}
+	}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			add(path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				name := d.Name()
+				if p != path && (name == "node_modules" || strings.HasPrefix(name, ".")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if testFilePattern.MatchString(d.Name()) {
+				add(p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// RunFile 在独立的 Runner 中执行一个测试文件。测试同步阻塞（如死循环）超时后中断该 Runner，
+// 报告超时并在新的 Runner 中继续执行文件中其余的测试
+func RunFile(file string, opts Options) *FileResult {
+	start := time.Now()
+	result := &FileResult{File: file}
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
+
+	if opts.Filter != "" {
+		if _, err := regexp.Compile(opts.Filter); err != nil {
+			result.Err = fmt.Errorf("invalid filter: %w", err)
+			return result
+		}
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	var completed []string
+	for {
+		tests, timedOut, err := runAttempt(file, opts, timeout, completed)
+		result.Tests = append(result.Tests, tests...)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		if timedOut == nil {
+			return result
+		}
+
+		result.Tests = append(result.Tests, timedOut.timeoutResult())
+		// beforeAll/afterAll 钩子阻塞时无法跳过它继续执行，结束该文件
+		if timedOut.Key == "" {
+			return result
+		}
+		for _, t := range tests {
+			completed = append(completed, t.FullName())
+		}
+		completed = append(completed, timedOut.Key)
+	}
+}
+
+// runAttempt 在新的 Runner 中执行测试文件，跳过 completed 中的测试。
+// 返回本次得到的测试结果，以及因阻塞事件循环被中断的步骤
+func runAttempt(file string, opts Options, timeout time.Duration, completed []string) ([]TestResult, *step, error) {
+	runner, err := runtime.NewWithWorkingDir(filepath.Dir(file))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer runner.Close()
+	runner.SetArgv(append([]string{"sw_runtime", file}, opts.Argv...))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watchdog := newWatchdog(cancel)
+
+	runner.SetValue(configGlobal, map[string]interface{}{
+		"filter":  opts.Filter,
+		"timeout": timeout.Milliseconds(),
+		"skip":    completed,
+		"begin":   watchdog.begin,
+		"end":     watchdog.end,
+		"report":  watchdog.report,
+	})
+	if err := runner.SafeRunCode(harnessSource); err != nil {
+		return nil, nil, fmt.Errorf("failed to install test harness: %w", err)
+	}
+
+	// 执行测试文件，注册 describe/it
+	if err := runner.SafeRunFile(file); err != nil {
+		return nil, nil, err
+	}
+
+	// 依次执行测试，异步测试由事件循环驱动
+	err = runner.RunCodeContext(ctx, runCode)
+	watchdog.end()
+
+	watchdog.mu.Lock()
+	defer watchdog.mu.Unlock()
+	if watchdog.timedOut != nil {
+		return watchdog.results, watchdog.timedOut, nil
+	}
+	if err != nil {
+		return watchdog.results, nil, err
+	}
+	if watchdog.err != nil {
+		return watchdog.results, nil, watchdog.err
+	}
+
+	done := runner.GetValue(doneGlobal)
+	if done == nil || !done.ToBoolean() {
+		return watchdog.results, nil, fmt.Errorf("tests did not complete (a test may be waiting on a promise that never settles)")
+	}
+	return watchdog.results, nil, nil
+}
+
+// Run 依次执行测试文件，每个文件完成后通知报告器
+func Run(files []string, opts Options, reporter Reporter) []*FileResult {
+	results := make([]*FileResult, 0, len(files))
+	for _, file := range files {
+		result := RunFile(file, opts)
+		results = append(results, result)
+		reporter.FileDone(result)
+	}
+	return results
+}
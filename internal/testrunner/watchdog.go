@@ -0,0 +1,111 @@
+package testrunner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// watchdogGrace JS 侧超时计时器的容差，超过超时时间加上容差仍未结束的步骤视为阻塞了事件循环
+const watchdogGrace = 200 * time.Millisecond
+
+// step 正在执行的测试或钩子函数，key 为测试的完整名称，beforeAll/afterAll 钩子为空
+type step struct {
+	Name   string   `json:"name"`
+	Suites []string `json:"suites"`
+	Key    string   `json:"key"`
+
+	timeout time.Duration
+	start   time.Time
+}
+
+// watchdog 收集测试结果，并在测试同步阻塞（如死循环）使 JS 侧的超时计时器无法触发时取消 ctx，
+// 由 RunCodeContext 中断 VM
+type watchdog struct {
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	current  *step
+	timer    *time.Timer
+	timedOut *step
+	results  []TestResult
+	err      error
+}
+
+func newWatchdog(cancel context.CancelFunc) *watchdog {
+	return &watchdog{cancel: cancel}
+}
+
+// begin 开始执行一个步骤（由测试框架在 JS 线程中调用）
+func (w *watchdog) begin(descriptor string, timeoutMs int64) {
+	s := &step{timeout: time.Duration(timeoutMs) * time.Millisecond, start: time.Now()}
+	json.Unmarshal([]byte(descriptor), s)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stopTimer()
+	w.current = s
+	w.timer = time.AfterFunc(s.timeout+watchdogGrace, func() {
+		w.fire(s)
+	})
+}
+
+// end 当前步骤已结束（完成、失败或由 JS 侧计时器判定超时）
+func (w *watchdog) end() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stopTimer()
+	w.current = nil
+}
+
+// report 记录一个测试结果
+func (w *watchdog) report(data string) {
+	var result TestResult
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := json.Unmarshal([]byte(data), &result); err != nil {
+		w.err = fmt.Errorf("invalid test report: %w", err)
+		return
+	}
+	result.Duration = time.Duration(result.DurationMs) * time.Millisecond
+	w.results = append(w.results, result)
+}
+
+// fire 步骤超时仍未结束：记录超时的步骤并中断执行
+func (w *watchdog) fire(s *step) {
+	w.mu.Lock()
+	if w.current != s {
+		w.mu.Unlock()
+		return
+	}
+	w.timedOut = s
+	w.current = nil
+	w.mu.Unlock()
+	w.cancel()
+}
+
+// stopTimer 停止当前步骤的计时器，调用方持有 w.mu
+func (w *watchdog) stopTimer() {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+}
+
+// timeoutResult 将被中断的步骤转换为失败的测试结果
+func (s *step) timeoutResult() TestResult {
+	duration := time.Since(s.start)
+	return TestResult{
+		Name:   s.Name,
+		Suites: s.Suites,
+		Status: StatusFailed,
+		Error: &TestError{
+			Name:    "Error",
+			Message: fmt.Sprintf("Timeout of %dms exceeded (the event loop was blocked, execution was interrupted)", s.timeout.Milliseconds()),
+		},
+		Duration:   duration,
+		DurationMs: duration.Milliseconds(),
+	}
+}
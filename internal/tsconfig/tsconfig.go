@@ -0,0 +1,460 @@
+// Package tsconfig 查找并解析 tsconfig.json（支持注释、尾随逗号与 extends），
+// 提供 esbuild 使用的编译选项以及 paths/baseUrl 模块别名
+package tsconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// FileName 配置文件名
+const FileName = "tsconfig.json"
+
+// targets target 选项到 esbuild 目标的映射。
+// 低于 ES2015 的按 ES2015 处理（esbuild 无法降级到 ES5），高于 ES2022 的按 ES2022 处理（goja 支持的最高语法级别）
+var targets = map[string]api.Target{
+	"es3":    api.ES2015,
+	"es5":    api.ES2015,
+	"es6":    api.ES2015,
+	"es2015": api.ES2015,
+	"es2016": api.ES2016,
+	"es2017": api.ES2017,
+	"es2018": api.ES2018,
+	"es2019": api.ES2019,
+	"es2020": api.ES2020,
+	"es2021": api.ES2021,
+	"es2022": api.ES2022,
+	"es2023": api.ES2022,
+	"es2024": api.ES2022,
+	"esnext": api.ES2022,
+}
+
+// Config 合并 extends 后的 tsconfig.json
+type Config struct {
+	Path string // tsconfig.json 的绝对路径
+
+	options   compilerOptions
+	baseURL   string    // baseUrl 的绝对路径
+	pathsDir  string    // 定义 paths 的配置文件所在目录
+	patterns  []pattern // paths 映射，按匹配优先级排序
+	target    api.Target
+	raw       string // 传给 esbuild 的 tsconfigRaw
+	preserved bool   // jsx 为 preserve/react-native，需要改为转换
+}
+
+// compilerOptions 使用到的 compilerOptions 字段，空值表示未设置
+type compilerOptions struct {
+	ExperimentalDecorators  *bool               `json:"experimentalDecorators"`
+	UseDefineForClassFields *bool               `json:"useDefineForClassFields"`
+	JSX                     string              `json:"jsx"`
+	JSXFactory              string              `json:"jsxFactory"`
+	JSXFragmentFactory      string              `json:"jsxFragmentFactory"`
+	JSXImportSource         string              `json:"jsxImportSource"`
+	Target                  string              `json:"target"`
+	BaseURL                 string              `json:"baseUrl"`
+	Paths                   map[string][]string `json:"paths"`
+}
+
+// configFile tsconfig.json 的文件结构
+type configFile struct {
+	Extends         json.RawMessage `json:"extends"`
+	CompilerOptions compilerOptions `json:"compilerOptions"`
+}
+
+// pattern paths 中的一条映射
+type pattern struct {
+	prefix   string
+	suffix   string
+	wildcard bool
+	targets  []string
+}
+
+// cached 已解析的配置，文件修改后重新解析
+type cached struct {
+	modTime time.Time
+	size    int64
+	config  *Config
+	err     error
+}
+
+var (
+	mu      sync.Mutex
+	configs = make(map[string]*cached)
+)
+
+// Find 从 dir 开始逐级向上查找最近的 tsconfig.json，未找到时返回 nil。
+// node_modules 中的文件不使用任何 tsconfig.json
+func Find(dir string) (*Config, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, nil
+	}
+	for _, part := range strings.Split(filepath.ToSlash(dir), "/") {
+		if part == "node_modules" {
+			return nil, nil
+		}
+	}
+
+	for {
+		path := filepath.Join(dir, FileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return loadCached(path, info)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// loadCached 返回缓存的解析结果，文件大小或修改时间变化时重新解析
+func loadCached(path string, info os.FileInfo) (*Config, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if c, ok := configs[path]; ok && c.modTime.Equal(info.ModTime()) && c.size == info.Size() {
+		return c.config, c.err
+	}
+	config, err := load(path)
+	configs[path] = &cached{modTime: info.ModTime(), size: info.Size(), config: config, err: err}
+	return config, err
+}
+
+// load 解析配置文件并合并 extends 链
+func load(path string) (*Config, error) {
+	c := &Config{Path: path}
+	if err := c.merge(path, make(map[string]bool)); err != nil {
+		return nil, err
+	}
+	c.finish()
+	return c, nil
+}
+
+// merge 依次合并 path 继承的配置和它自身的 compilerOptions，后者覆盖前者
+func (c *Config) merge(path string, visiting map[string]bool) error {
+	if visiting[path] {
+		return fmt.Errorf("circular extends in %s", path)
+	}
+	visiting[path] = true
+	defer delete(visiting, path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var file configFile
+	if err := json.Unmarshal(stripJSONC(data), &file); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	bases, err := extendsList(file.Extends)
+	if err != nil {
+		return fmt.Errorf("invalid \"extends\" in %s", path)
+	}
+	for _, base := range bases {
+		basePath, err := resolveExtends(base, dir)
+		if err != nil {
+			return fmt.Errorf("%w (extended by %s)", err, path)
+		}
+		if err := c.merge(basePath, visiting); err != nil {
+			return err
+		}
+	}
+
+	o := file.CompilerOptions
+	if o.ExperimentalDecorators != nil {
+		c.options.ExperimentalDecorators = o.ExperimentalDecorators
+	}
+	if o.UseDefineForClassFields != nil {
+		c.options.UseDefineForClassFields = o.UseDefineForClassFields
+	}
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&c.options.JSX, o.JSX},
+		{&c.options.JSXFactory, o.JSXFactory},
+		{&c.options.JSXFragmentFactory, o.JSXFragmentFactory},
+		{&c.options.JSXImportSource, o.JSXImportSource},
+		{&c.options.Target, o.Target},
+	} {
+		if field.src != "" {
+			*field.dst = field.src
+		}
+	}
+	// baseUrl 与 paths 相对于定义它们的配置文件
+	if o.BaseURL != "" {
+		c.baseURL = joinPath(dir, o.BaseURL)
+	}
+	if o.Paths != nil {
+		c.options.Paths = o.Paths
+		c.pathsDir = dir
+	}
+	return nil
+}
+
+// finish 根据合并后的选项生成 esbuild 选项与 paths 映射
+func (c *Config) finish() {
+	c.target = api.ES2020
+	if target, ok := targets[strings.ToLower(c.options.Target)]; ok {
+		c.target = target
+	}
+
+	raw := make(map[string]interface{})
+	if c.options.ExperimentalDecorators != nil {
+		raw["experimentalDecorators"] = *c.options.ExperimentalDecorators
+	}
+	if c.options.UseDefineForClassFields != nil {
+		raw["useDefineForClassFields"] = *c.options.UseDefineForClassFields
+	}
+	// target 只用于推导 useDefineForClassFields 的默认值，输出目标由 Target() 决定
+	if c.options.Target != "" {
+		raw["target"] = c.options.Target
+	}
+	switch jsx := strings.ToLower(c.options.JSX); jsx {
+	case "":
+	case "preserve", "react-native":
+		// 运行时没有其他工具处理保留的 JSX，按 React.createElement 形式转换
+		raw["jsx"] = "react"
+		c.preserved = true
+	default:
+		raw["jsx"] = jsx
+	}
+	if c.options.JSXFactory != "" {
+		raw["jsxFactory"] = c.options.JSXFactory
+	}
+	if c.options.JSXFragmentFactory != "" {
+		raw["jsxFragmentFactory"] = c.options.JSXFragmentFactory
+	}
+	if c.options.JSXImportSource != "" {
+		raw["jsxImportSource"] = c.options.JSXImportSource
+	}
+	if len(raw) > 0 {
+		data, _ := json.Marshal(map[string]interface{}{"compilerOptions": raw})
+		c.raw = string(data)
+	}
+
+	for key, values := range c.options.Paths {
+		p := pattern{prefix: key, targets: values}
+		if star := strings.Index(key, "*"); star >= 0 {
+			p.prefix, p.suffix, p.wildcard = key[:star], key[star+1:], true
+		}
+		c.patterns = append(c.patterns, p)
+	}
+	// 精确匹配优先，其次按 * 之前的前缀长度降序
+	sort.Slice(c.patterns, func(i, j int) bool {
+		a, b := c.patterns[i], c.patterns[j]
+		if a.wildcard != b.wildcard {
+			return !a.wildcard
+		}
+		if len(a.prefix) != len(b.prefix) {
+			return len(a.prefix) > len(b.prefix)
+		}
+		return a.prefix+a.suffix < b.prefix+b.suffix
+	})
+}
+
+// Apply 将编译选项应用到 esbuild 转换选项，c 为 nil 时保持原有选项
+func (c *Config) Apply(opts *api.TransformOptions) {
+	if c == nil {
+		return
+	}
+	opts.Target = c.target
+	opts.TsconfigRaw = c.raw
+}
+
+// Target 返回转换目标，未配置时为 ES2020
+func (c *Config) Target() api.Target {
+	if c == nil {
+		return api.ES2020
+	}
+	return c.target
+}
+
+// PreserveJSX 判断 jsx 是否配置为 preserve 或 react-native（运行时改为 React.createElement 形式转换）
+func (c *Config) PreserveJSX() bool {
+	return c != nil && c.preserved
+}
+
+// CacheKey 返回影响转译结果的选项，作为转译缓存键的一部分
+func (c *Config) CacheKey() string {
+	if c == nil {
+		return ""
+	}
+	return fmt.Sprintf(":%d:%s", c.target, c.raw)
+}
+
+// Candidates 返回非相对模块标识符按 paths 与 baseUrl 映射得到的候选路径（未补全扩展名），
+// 依次为 paths 的各个目标和 baseUrl 下的同名路径，与 TypeScript 的解析顺序一致
+func (c *Config) Candidates(id string) []string {
+	if c == nil || id == "" || strings.HasPrefix(id, "./") || strings.HasPrefix(id, "../") ||
+		id == "." || id == ".." || filepath.IsAbs(id) {
+		return nil
+	}
+
+	var candidates []string
+	if p, star, ok := c.match(id); ok {
+		// paths 相对于 baseUrl，未设置 baseUrl 时相对于定义 paths 的配置文件
+		base := c.baseURL
+		if base == "" {
+			base = c.pathsDir
+		}
+		for _, target := range p.targets {
+			if p.wildcard {
+				target = strings.Replace(target, "*", star, 1)
+			}
+			candidates = append(candidates, joinPath(base, target))
+		}
+	}
+	if c.baseURL != "" {
+		candidates = append(candidates, filepath.Join(c.baseURL, id))
+	}
+	return candidates
+}
+
+// match 查找与标识符匹配的 paths 映射，返回 * 匹配到的内容
+func (c *Config) match(id string) (pattern, string, bool) {
+	for _, p := range c.patterns {
+		if !p.wildcard {
+			if id == p.prefix {
+				return p, "", true
+			}
+			continue
+		}
+		if len(id) >= len(p.prefix)+len(p.suffix) && strings.HasPrefix(id, p.prefix) && strings.HasSuffix(id, p.suffix) {
+			return p, id[len(p.prefix) : len(id)-len(p.suffix)], true
+		}
+	}
+	return pattern{}, "", false
+}
+
+// extendsList 解析 extends 字段，支持字符串和字符串数组
+func extendsList(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return []string{single}, nil
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// resolveExtends 解析 extends 引用的配置文件：相对/绝对路径，或 node_modules 中的包
+func resolveExtends(spec string, dir string) (string, error) {
+	var candidates []string
+	if strings.HasPrefix(spec, "./") || strings.HasPrefix(spec, "../") || filepath.IsAbs(spec) {
+		path := joinPath(dir, spec)
+		candidates = []string{path, path + ".json"}
+	} else {
+		for d := dir; ; {
+			path := filepath.Join(d, "node_modules", spec)
+			candidates = append(candidates, path, path+".json", filepath.Join(path, FileName))
+			parent := filepath.Dir(d)
+			if parent == d {
+				break
+			}
+			d = parent
+		}
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("cannot find base config %q", spec)
+}
+
+// joinPath 将 path 解析为相对于 dir 的绝对路径
+func joinPath(dir string, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(dir, path)
+}
+
+// stripJSONC 去除 JSON 中的注释和尾随逗号（tsconfig.json 允许这两种写法）
+func stripJSONC(data []byte) []byte {
+	data = []byte(strings.TrimPrefix(string(data), "\uFEFF"))
+
+	// 第一遍：注释替换为空白，保留字符串原样
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		switch {
+		case data[i] == '"':
+			j := i + 1
+			for j < len(data) && data[j] != '"' {
+				if data[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(data) {
+				j = len(data) - 1
+			}
+			out = append(out, data[i:j+1]...)
+			i = j
+		case data[i] == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			out = append(out, '\n')
+		case data[i] == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := strings.Index(string(data[i+2:]), "*/")
+			if end < 0 {
+				i = len(data)
+			} else {
+				i += end + 3
+			}
+			out = append(out, ' ')
+		default:
+			out = append(out, data[i])
+		}
+	}
+
+	// 第二遍：去除 } 或 ] 之前的逗号
+	result := make([]byte, 0, len(out))
+	for i := 0; i < len(out); i++ {
+		switch out[i] {
+		case '"':
+			j := i + 1
+			for j < len(out) && out[j] != '"' {
+				if out[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(out) {
+				j = len(out) - 1
+			}
+			result = append(result, out[i:j+1]...)
+			i = j
+			continue
+		case ',':
+			j := i + 1
+			for j < len(out) && (out[j] == ' ' || out[j] == '\t' || out[j] == '\n' || out[j] == '\r') {
+				j++
+			}
+			if j < len(out) && (out[j] == '}' || out[j] == ']') {
+				continue
+			}
+		}
+		result = append(result, out[i])
+	}
+	return result
+}
@@ -1,6 +1,6 @@
 package main
 
-import "sw_runtime/cmd"
+import "github.com/issueye/sw_runtime/cmd"
 
 func main() {
 	cmd.Execute()
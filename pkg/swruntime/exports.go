@@ -0,0 +1,83 @@
+package swruntime
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dop251/goja"
+)
+
+// Exports 模块导出的对象或全局对象
+type Exports struct {
+	rt  *Runtime
+	obj *goja.Object // nil 表示全局对象
+}
+
+// object 返回导出对象，必须在事件循环中调用
+func (e *Exports) object(vm *goja.Runtime) *goja.Object {
+	if e.obj == nil {
+		return vm.GlobalObject()
+	}
+	return e.obj
+}
+
+// Get 读取属性并转换到 target（指向 Go 变量的指针），转换规则与 goja 的 ExportTo 相同
+func (e *Exports) Get(ctx context.Context, name string, target interface{}) error {
+	return e.rt.runner.Do(ctx, func(vm *goja.Runtime) error {
+		v := e.object(vm).Get(name)
+		if v == nil {
+			return fmt.Errorf("property %q is not defined", name)
+		}
+		return vm.ExportTo(v, target)
+	})
+}
+
+// Has 返回是否存在该属性
+func (e *Exports) Has(ctx context.Context, name string) (bool, error) {
+	var ok bool
+	err := e.rt.runner.Do(ctx, func(vm *goja.Runtime) error {
+		ok = e.object(vm).Get(name) != nil
+		return nil
+	})
+	return ok, err
+}
+
+// Call 调用导出的函数并忽略返回值，返回 Promise 时等待其完成。
+// 需要返回值时使用 Call[T]
+func (e *Exports) Call(ctx context.Context, name string, args ...interface{}) error {
+	_, err := call(ctx, e, name, args)
+	return err
+}
+
+// Call 调用导出的函数，将返回值（Promise 则为其结果）转换为 T。
+// 函数抛出异常时返回 *goja.Exception，Promise 被拒绝时返回 *RejectedError，
+// ctx 超时或取消时中断函数并返回 *TimeoutError
+func Call[T any](ctx context.Context, e *Exports, name string, args ...interface{}) (T, error) {
+	var out T
+	result, err := call(ctx, e, name, args)
+	if err != nil {
+		return out, err
+	}
+	err = e.rt.runner.Do(ctx, func(vm *goja.Runtime) error {
+		return vm.ExportTo(result, &out)
+	})
+	return out, err
+}
+
+// call 查找并调用函数，this 为导出对象
+func call(ctx context.Context, e *Exports, name string, args []interface{}) (goja.Value, error) {
+	var fn, this goja.Value
+	err := e.rt.runner.Do(ctx, func(vm *goja.Runtime) error {
+		obj := e.object(vm)
+		fn = obj.Get(name)
+		if _, ok := goja.AssertFunction(fn); !ok {
+			return fmt.Errorf("%q is not a function", name)
+		}
+		this = obj
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return e.rt.runner.Call(ctx, fn, this, args...)
+}
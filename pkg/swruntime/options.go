@@ -0,0 +1,77 @@
+package swruntime
+
+import (
+	"io"
+
+	"github.com/dop251/goja"
+)
+
+// Option 创建 Runtime 时的配置项
+type Option func(*config)
+
+// config Runtime 的创建参数
+type config struct {
+	workingDir  string
+	argv        []string
+	permissions *Permissions
+	stdout      io.Writer
+	stderr      io.Writer
+	logLevel    LogLevel
+	modules     []moduleEntry
+}
+
+// moduleEntry 待注册的自定义模块
+type moduleEntry struct {
+	name    string
+	factory func(vm *goja.Runtime) Module
+}
+
+// WithWorkingDir 设置工作目录：require 相对路径的基准和 fs 模块的沙箱目录，默认为进程当前目录
+func WithWorkingDir(dir string) Option {
+	return func(c *config) {
+		c.workingDir = dir
+	}
+}
+
+// WithArgv 设置脚本中 process.argv 的内容
+func WithArgv(argv ...string) Option {
+	return func(c *config) {
+		c.argv = argv
+	}
+}
+
+// WithPermissions 限制脚本的文件、网络、子进程和环境变量访问，默认不限制
+func WithPermissions(p *Permissions) Option {
+	return func(c *config) {
+		c.permissions = p
+	}
+}
+
+// WithStdout 设置 console.log 等和 process.stdout 的输出目标，默认为 os.Stdout
+func WithStdout(w io.Writer) Option {
+	return func(c *config) {
+		c.stdout = w
+	}
+}
+
+// WithStderr 设置 console.warn、console.error 等和 process.stderr 的输出目标，默认为 os.Stderr
+func WithStderr(w io.Writer) Option {
+	return func(c *config) {
+		c.stderr = w
+	}
+}
+
+// WithLogLevel 设置 console 的最低输出级别，默认输出全部
+func WithLogLevel(level LogLevel) Option {
+	return func(c *config) {
+		c.logLevel = level
+	}
+}
+
+// WithModule 注册自定义模块，脚本中通过 require(name) 或 import 加载。
+// factory 在创建 Runtime 时调用，传入的 vm 用于创建模块对象，不能在其他 goroutine 中使用
+func WithModule(name string, factory func(vm *goja.Runtime) Module) Option {
+	return func(c *config) {
+		c.modules = append(c.modules, moduleEntry{name: name, factory: factory})
+	}
+}
@@ -0,0 +1,160 @@
+// Package swruntime 是在 Go 程序中嵌入 SW Runtime 的公开接口。
+//
+// 每个 Runtime 拥有独立的 JS VM 和事件循环。调用导出函数、读写变量都在事件循环中串行执行，
+// 可以在脚本的定时器、HTTP 服务器等异步任务运行期间从任意 goroutine 调用；
+// RunFile 和 RunCode 不能并发调用。同一个 Runtime 的调用之间共享全局状态。
+package swruntime
+
+import (
+	"context"
+	"os"
+
+	"github.com/issueye/sw_runtime/internal/builtins/types"
+	"github.com/issueye/sw_runtime/internal/runtime"
+	"github.com/issueye/sw_runtime/internal/security"
+
+	"github.com/dop251/goja"
+)
+
+type (
+	// Permissions 脚本的访问权限，nil 表示不限制
+	Permissions = security.Permissions
+	// PermissionDeniedError 权限不足时的错误，可通过 errors.As 取回
+	PermissionDeniedError = security.PermissionDeniedError
+	// Module 自定义模块，GetModule 返回 require 得到的对象
+	Module = types.BuiltinModule
+	// TimeoutError ctx 超时或取消导致脚本被中断
+	TimeoutError = runtime.TimeoutError
+	// RejectedError 调用的函数返回的 Promise 被拒绝
+	RejectedError = runtime.RejectedError
+	// LogLevel console 的输出级别
+	LogLevel = runtime.LogLevel
+)
+
+// console 输出级别
+const (
+	LogLevelDebug  = runtime.LogLevelDebug
+	LogLevelInfo   = runtime.LogLevelInfo
+	LogLevelWarn   = runtime.LogLevelWarn
+	LogLevelError  = runtime.LogLevelError
+	LogLevelSilent = runtime.LogLevelSilent
+)
+
+// ErrClosed Runtime 已关闭或事件循环被中断信号停止
+var ErrClosed = runtime.ErrLoopStopped
+
+// AllowAll 返回允许全部访问的权限
+func AllowAll() *Permissions {
+	return security.AllowAll()
+}
+
+// ModuleFunc 将返回模块对象的函数适配为 Module
+type ModuleFunc func() *goja.Object
+
+// GetModule 实现 Module 接口
+func (f ModuleFunc) GetModule() *goja.Object {
+	return f()
+}
+
+// Runtime 嵌入的 JS/TS 运行时
+type Runtime struct {
+	runner *runtime.Runner
+}
+
+// New 创建 Runtime，使用完毕后调用 Close 释放 VM、定时器和服务器等资源
+func New(opts ...Option) (*Runtime, error) {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	var (
+		runner *runtime.Runner
+		err    error
+	)
+	if cfg.workingDir != "" {
+		if _, statErr := os.Stat(cfg.workingDir); statErr != nil {
+			return nil, statErr
+		}
+		runner, err = runtime.NewWithWorkingDir(cfg.workingDir)
+	} else {
+		runner, err = runtime.New()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.argv != nil {
+		runner.SetArgv(cfg.argv)
+	}
+	runner.SetPermissions(cfg.permissions)
+
+	consoleOpts := runner.ConsoleOptions()
+	consoleOpts.Level = cfg.logLevel
+	if cfg.stdout != nil {
+		consoleOpts.Stdout = cfg.stdout
+		consoleOpts.Colors = false
+	}
+	if cfg.stderr != nil {
+		consoleOpts.Stderr = cfg.stderr
+	}
+	runner.SetConsoleOptions(consoleOpts)
+
+	if len(cfg.modules) > 0 {
+		err := runner.Do(context.Background(), func(vm *goja.Runtime) error {
+			for _, m := range cfg.modules {
+				runner.RegisterModule(m.name, m.factory(vm))
+			}
+			return nil
+		})
+		if err != nil {
+			runner.Close()
+			return nil, err
+		}
+	}
+
+	return &Runtime{runner: runner}, nil
+}
+
+// RunFile 执行 JS/TS 文件并等待其异步任务完成。
+// ctx 超时或取消时中断脚本并返回 *TimeoutError，之后 Runtime 只能关闭
+func (rt *Runtime) RunFile(ctx context.Context, filename string) error {
+	return rt.runner.RunFileContext(ctx, filename)
+}
+
+// RunCode 执行 JS/TS 代码，行为与 RunFile 相同
+func (rt *Runtime) RunCode(ctx context.Context, code string) error {
+	return rt.runner.RunCodeContext(ctx, code)
+}
+
+// Set 设置全局变量，Go 值按 goja 的规则转换（结构体字段使用 json 标签命名）
+func (rt *Runtime) Set(name string, value interface{}) error {
+	return rt.runner.Do(context.Background(), func(vm *goja.Runtime) error {
+		return vm.Set(name, value)
+	})
+}
+
+// Global 返回全局对象，可用于调用全局函数和读取全局变量
+func (rt *Runtime) Global() *Exports {
+	return &Exports{rt: rt}
+}
+
+// Require 加载模块并返回其导出，相对路径基于工作目录解析，也可以加载内置模块和自定义模块。
+// 由宿主加载的模块不受 Permissions.Read 限制，模块内部的 require 与 import 仍受限制
+func (rt *Runtime) Require(ctx context.Context, id string) (*Exports, error) {
+	var exports *goja.Object
+	err := rt.runner.Do(ctx, func(vm *goja.Runtime) error {
+		var err error
+		exports, err = rt.runner.Require(id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Exports{rt: rt, obj: exports}, nil
+}
+
+// Close 停止事件循环、终止 Worker 并关闭脚本创建的服务器
+func (rt *Runtime) Close() {
+	rt.runner.Close()
+}
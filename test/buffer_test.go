@@ -0,0 +1,243 @@
+package test
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
+)
+
+func TestBufferBasics(t *testing.T) {
+	runner := runtime.NewOrPanic()
+	defer runner.Close()
+
+	code := `
+		const text = Buffer.from('héllo wörld');
+		const nums = Buffer.alloc(8);
+		nums.writeUInt32BE(0xdeadbeef, 0);
+		nums.writeInt16LE(-2, 4);
+
+		global.bufferResults = {
+			length: text.length,
+			utf8: text.toString(),
+			hex: Buffer.from('sw').toString('hex'),
+			base64: Buffer.from('hello').toString('base64'),
+			fromBase64: Buffer.from('aGVsbG8', 'base64').toString(),
+			latin1: Buffer.from([0xe9]).toString('latin1'),
+			sliceIsBuffer: Buffer.isBuffer(text.slice(0, 1)),
+			sliceShared: (() => { const b = Buffer.from('abc'); b.slice(1)[0] = 0x7a; return b.toString(); })(),
+			json: JSON.stringify(Buffer.from([1, 2])),
+			numbers: nums.toString('hex'),
+			u32: nums.readUInt32BE(0),
+			i16: nums.readInt16LE(4),
+			concat: Buffer.concat([Buffer.from('a'), Buffer.from('bc')]).toString(),
+			indexOf: text.indexOf('wörld'),
+			fill: Buffer.alloc(5, 'ab').toString(),
+			byteLength: Buffer.byteLength('héllo'),
+			equals: Buffer.from('x').equals(Buffer.from('x')),
+			compare: Buffer.compare(Buffer.from('a'), Buffer.from('b')),
+			isUint8Array: text instanceof Uint8Array,
+			sameModule: require('buffer').Buffer === Buffer
+		};
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("Failed to run Buffer code: %v", err)
+	}
+
+	results := runner.GetValue("bufferResults").ToObject(nil)
+	expect := map[string]interface{}{
+		"length":        int64(13),
+		"utf8":          "héllo wörld",
+		"hex":           "7377",
+		"base64":        "aGVsbG8=",
+		"fromBase64":    "hello",
+		"latin1":        "é",
+		"sliceIsBuffer": true,
+		"sliceShared":   "azc",
+		"json":          `{"type":"Buffer","data":[1,2]}`,
+		"numbers":       "deadbeeffeff0000",
+		"u32":           int64(0xdeadbeef),
+		"i16":           int64(-2),
+		"concat":        "abc",
+		"indexOf":       int64(7),
+		"fill":          "ababa",
+		"byteLength":    int64(6),
+		"equals":        true,
+		"compare":       int64(-1),
+		"isUint8Array":  true,
+		"sameModule":    true,
+	}
+	for key, want := range expect {
+		if got := results.Get(key).Export(); got != want {
+			t.Errorf("%s: expected %v (%T), got %v (%T)", key, want, want, got, got)
+		}
+	}
+}
+
+func TestBufferFSBinaryRoundTrip(t *testing.T) {
+	tempDir := t.TempDir()
+	original := []byte{0x00, 0xff, 0xfe, 0x80, 0x41, 0xc3}
+	if err := os.WriteFile(filepath.Join(tempDir, "input.bin"), original, 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+	os.Chdir(tempDir)
+
+	runner := runtime.NewOrPanicWithWorkingDir(tempDir)
+	defer runner.Close()
+
+	code := `
+		const fs = require('fs/fs');
+		const data = fs.readFileSync('input.bin');
+		global.fsResults = {
+			isBuffer: Buffer.isBuffer(data),
+			length: data.length,
+			text: typeof fs.readFileSync('input.bin', 'hex')
+		};
+		fs.writeFileSync('output.bin', data);
+		fs.writeFileSync('encoded.bin', 'AP8=', 'base64');
+		fs.readFile('input.bin').then(buf => { global.fsResults.asyncLength = buf.length; });
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("Failed to run fs code: %v", err)
+	}
+
+	results := runner.GetValue("fsResults").ToObject(nil)
+	if !results.Get("isBuffer").ToBoolean() || results.Get("length").ToInteger() != int64(len(original)) {
+		t.Errorf("Expected readFileSync to return a Buffer, got %v", results.Export())
+	}
+	if got := results.Get("text").String(); got != "string" {
+		t.Errorf("Expected encoding to return string, got %q", got)
+	}
+	if results.Get("asyncLength").ToInteger() != int64(len(original)) {
+		t.Errorf("Expected async readFile to return Buffer, got %v", results.Get("asyncLength"))
+	}
+
+	written, err := os.ReadFile(filepath.Join(tempDir, "output.bin"))
+	if err != nil || !bytes.Equal(written, original) {
+		t.Errorf("Binary round trip corrupted: %v (%v)", written, err)
+	}
+	encoded, err := os.ReadFile(filepath.Join(tempDir, "encoded.bin"))
+	if err != nil || !bytes.Equal(encoded, []byte{0x00, 0xff}) {
+		t.Errorf("Expected base64 write to decode, got %v (%v)", encoded, err)
+	}
+}
+
+func TestBufferCryptoAndCompression(t *testing.T) {
+	runner := runtime.NewOrPanic()
+	defer runner.Close()
+
+	code := `
+		const crypto = require('utils/crypto');
+		const zlib = require('utils/compression');
+		const binary = Buffer.from([0x00, 0xff, 0x80, 0x7f]);
+
+		const compressed = zlib.gzip(binary, 'buffer');
+		const restored = zlib.gunzip(compressed, 'buffer');
+		const encrypted = crypto.aesEncrypt(binary, 'secret', 'buffer');
+		const decrypted = crypto.aesDecrypt(encrypted, 'secret', 'buffer');
+
+		global.binaryResults = {
+			md5: crypto.md5(binary),
+			md5Same: crypto.md5(Buffer.from('abc')) === crypto.md5('abc'),
+			digest: Buffer.isBuffer(crypto.sha256('abc', 'buffer')) && crypto.sha256('abc', 'buffer').length,
+			random: crypto.randomBytes(12, 'buffer').length,
+			compressedIsBuffer: Buffer.isBuffer(compressed),
+			restored: restored.toString('hex'),
+			base64Restored: zlib.gunzip(zlib.gzip('text')),
+			decrypted: decrypted.toString('hex'),
+			base64: crypto.base64Encode(binary)
+		};
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("Failed to run crypto code: %v", err)
+	}
+
+	results := runner.GetValue("binaryResults").ToObject(nil)
+	if got := results.Get("md5").String(); got != "35913419231430075897c28e5e159b65" {
+		t.Errorf("Unexpected md5 of binary data: %s", got)
+	}
+	if !results.Get("md5Same").ToBoolean() {
+		t.Error("Expected md5 of Buffer and string to match")
+	}
+	if results.Get("digest").ToInteger() != 32 {
+		t.Errorf("Expected 32-byte digest Buffer, got %v", results.Get("digest"))
+	}
+	if results.Get("random").ToInteger() != 12 {
+		t.Errorf("Expected 12 random bytes, got %v", results.Get("random"))
+	}
+	if !results.Get("compressedIsBuffer").ToBoolean() {
+		t.Error("Expected gzip(..., 'buffer') to return Buffer")
+	}
+	if got := results.Get("restored").String(); got != "00ff807f" {
+		t.Errorf("Compression corrupted binary data: %s", got)
+	}
+	if got := results.Get("base64Restored").String(); got != "text" {
+		t.Errorf("Expected base64 compatibility, got %q", got)
+	}
+	if got := results.Get("decrypted").String(); got != "00ff807f" {
+		t.Errorf("AES corrupted binary data: %s", got)
+	}
+	if got := results.Get("base64").String(); got != "AP+Afw==" {
+		t.Errorf("Unexpected base64 of binary data: %s", got)
+	}
+}
+
+func TestBufferTCPData(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		data := make([]byte, 4)
+		if _, err := io.ReadFull(conn, data); err != nil {
+			return
+		}
+		received <- data
+		conn.Write([]byte{0xff, 0x00, 0xfe})
+	}()
+
+	runner := runtime.NewOrPanic()
+	defer runner.Close()
+
+	code := `
+		const net = require('net/net');
+		net.connectTCP(ADDR).then(socket => {
+			socket.on('data', data => {
+				global.tcpResult = Buffer.isBuffer(data) ? data.toString('hex') : 'not a buffer';
+				socket.close();
+			});
+			socket.write(Buffer.from([0x00, 0xff, 0x80, 0x0a]));
+		});
+	`
+	runner.SetValue("ADDR", listener.Addr().String())
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("Failed to run TCP code: %v", err)
+	}
+
+	select {
+	case data := <-received:
+		if !bytes.Equal(data, []byte{0x00, 0xff, 0x80, 0x0a}) {
+			t.Errorf("TCP write corrupted binary data: %v", data)
+		}
+	default:
+		t.Fatal("Server did not receive data")
+	}
+	if got := runner.GetValue("tcpResult"); got == nil || got.String() != "ff00fe" {
+		t.Errorf("Expected Buffer data event, got %v", got)
+	}
+}
@@ -2,10 +2,11 @@ package test
 
 import (
 	"os"
+	"strings"
 	"testing"
 
-	"sw_runtime/internal/builtins"
-	"sw_runtime/internal/runtime"
+	"github.com/issueye/sw_runtime/internal/builtins"
+	"github.com/issueye/sw_runtime/internal/runtime"
 
 	"github.com/dop251/goja"
 )
@@ -87,6 +88,53 @@ func TestPathModule(t *testing.T) {
 	}
 }
 
+func TestUtilInspect(t *testing.T) {
+	runner := runtime.NewOrPanic()
+	defer runner.Close()
+
+	code := `
+		const util = require('utils/util');
+		const circular = { name: 'loop' };
+		circular.self = circular;
+		class Point { constructor() { this.x = 1; } get len() { return 1; } }
+
+		global.inspected = [
+			util.inspect({ a: 1, 'b-c': "it's", list: [1, [2, [3, [4]]]], u: undefined, n: null }),
+			util.inspect(circular),
+			util.inspect(new Point()),
+			util.inspect(new Set([1, 'x'])),
+			util.inspect(Buffer.from('hi')),
+			util.inspect(function named() {}),
+			util.inspect({ a: { b: { c: { d: 1 } } } }, { depth: null }),
+			util.inspect('str', { colors: true }),
+		].join('\n');
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("Failed to run util.inspect test: %v", err)
+	}
+
+	expected := []string{
+		`{ a: 1, 'b-c': "it's", list: [ 1, [ 2, [Array] ] ], u: undefined, n: null }`,
+		`{ name: 'loop', self: [Circular] }`,
+		`Point { x: 1 }`,
+		`Set(2) { 1, 'x' }`,
+		`<Buffer 68 69>`,
+		`[Function: named]`,
+		`{ a: { b: { c: { d: 1 } } } }`,
+		"\x1b[32m'str'\x1b[0m",
+	}
+	output := runner.GetValue("inspected").String()
+	lines := strings.Split(output, "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines, got %d:\n%s", len(expected), len(lines), output)
+	}
+	for i, want := range expected {
+		if lines[i] != want {
+			t.Errorf("inspect #%d: expected %q, got %q", i, want, lines[i])
+		}
+	}
+}
+
 func TestFSModule(t *testing.T) {
 	runner := runtime.NewOrPanic()
 
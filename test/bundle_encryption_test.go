@@ -6,7 +6,7 @@ import (
 	"strings"
 	"testing"
 
-	"sw_runtime/internal/bundler"
+	"github.com/issueye/sw_runtime/internal/bundler"
 )
 
 func TestBundlerEncryption(t *testing.T) {
@@ -0,0 +1,194 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
+)
+
+// runConsole 执行代码并返回 console 的标准输出和标准错误内容
+func runConsole(t *testing.T, opts runtime.ConsoleOptions, code string) (string, string) {
+	t.Helper()
+	runner, err := runtime.New()
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	defer runner.Close()
+
+	var stdout, stderr bytes.Buffer
+	opts.Stdout, opts.Stderr = &stdout, &stderr
+	runner.SetConsoleOptions(opts)
+
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+	return stdout.String(), stderr.String()
+}
+
+func TestConsoleFormat(t *testing.T) {
+	stdout, stderr := runConsole(t, runtime.ConsoleOptions{}, `
+		console.log('%s has %d items costing %f (%i%%)', 'cart', 3, '9.5', 42.9);
+		console.log('%j and %o', { a: 1 }, [1, { b: 2 }], 'extra', { c: 'x' });
+		console.log({ nested: { list: [1, 2], map: new Map([['k', 1]]) } }, 'plain');
+		console.info('missing %s');
+		console.debug('debug', null, undefined);
+		console.warn('warned');
+		console.error(new Map([[1, 2]]));
+	`)
+
+	wantOut := strings.Join([]string{
+		"cart has 3 items costing 9.5 (42%)",
+		`{"a":1} and [ 1, { b: 2 } ] extra { c: 'x' }`,
+		"{ nested: { list: [ 1, 2 ], map: Map(1) { 'k' => 1 } } } plain",
+		"missing %s",
+		"debug null undefined",
+	}, "\n") + "\n"
+	if stdout != wantOut {
+		t.Errorf("Unexpected stdout:\n%s\nwant:\n%s", stdout, wantOut)
+	}
+	if stderr != "warned\nMap(1) { 1 => 2 }\n" {
+		t.Errorf("Unexpected stderr:\n%s", stderr)
+	}
+}
+
+func TestConsoleGroupCountTime(t *testing.T) {
+	stdout, stderr := runConsole(t, runtime.ConsoleOptions{}, `
+		console.group('outer');
+		console.log('a\nb');
+		console.group();
+		console.count();
+		console.count();
+		console.count('other');
+		console.groupEnd();
+		console.groupEnd();
+		console.log('done');
+		console.countReset('missing');
+		console.time('job');
+		console.timeLog('job', 'step', 1);
+		console.timeEnd('job');
+		console.timeEnd('job');
+		console.assert(true, 'never');
+		console.assert(1 > 2, 'expected %d', 1);
+		console.assert(false);
+	`)
+
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	want := []string{"outer", "  a", "  b", "    default: 1", "    default: 2", "    other: 1", "done"}
+	for i, w := range want {
+		if i >= len(lines) || lines[i] != w {
+			t.Fatalf("Line %d: expected %q, got:\n%s", i, w, stdout)
+		}
+	}
+	timing := regexp.MustCompile(`^job: \d+(\.\d+)?ms( step 1)?$`)
+	if len(lines) != len(want)+2 || !timing.MatchString(lines[len(want)]) || !strings.HasSuffix(lines[len(want)], " step 1") || !timing.MatchString(lines[len(want)+1]) {
+		t.Errorf("Unexpected timer output:\n%s", stdout)
+	}
+
+	wantErr := strings.Join([]string{
+		"Warning: Count for 'missing' does not exist",
+		"Warning: No such label 'job' for console.timeEnd()",
+		"Assertion failed: expected 1",
+		"Assertion failed",
+	}, "\n") + "\n"
+	if stderr != wantErr {
+		t.Errorf("Unexpected stderr:\n%s", stderr)
+	}
+}
+
+func TestConsoleTable(t *testing.T) {
+	stdout, _ := runConsole(t, runtime.ConsoleOptions{}, `
+		console.table([{ a: 1, b: 'x' }, { a: 22 }, 'text']);
+		console.table({ r1: { c: 1, d: 2 }, r2: { c: 3 } }, ['c']);
+		console.table(5);
+	`)
+
+	want := `┌─────────┬────┬─────┬────────┐
+│ (index) │ a  │  b  │ Values │
+├─────────┼────┼─────┼────────┤
+│    0    │ 1  │ 'x' │        │
+│    1    │ 22 │     │        │
+│    2    │    │     │ 'text' │
+└─────────┴────┴─────┴────────┘
+┌─────────┬───┐
+│ (index) │ c │
+├─────────┼───┤
+│   r1    │ 1 │
+│   r2    │ 3 │
+└─────────┴───┘
+5
+`
+	if stdout != want {
+		t.Errorf("Unexpected table:\n%s\nwant:\n%s", stdout, want)
+	}
+}
+
+func TestConsoleDirAndTrace(t *testing.T) {
+	stdout, stderr := runConsole(t, runtime.ConsoleOptions{Colors: true}, `
+		console.dir({ a: { b: { c: {} } } }, { depth: 0, colors: false });
+		console.log(42, 'str');
+		function inner() { console.trace('at %s', 'inner'); }
+		inner();
+	`)
+
+	if !strings.HasPrefix(stdout, "{ a: [Object] }\n") {
+		t.Errorf("Unexpected dir output:\n%s", stdout)
+	}
+	if !strings.Contains(stdout, "\x1b[33m42\x1b[0m str") {
+		t.Errorf("Expected colored number:\n%q", stdout)
+	}
+	if !strings.HasPrefix(stderr, "Trace: at inner\n    at inner (") || strings.Contains(stderr, "native") {
+		t.Errorf("Unexpected trace:\n%s", stderr)
+	}
+}
+
+func TestConsoleLevelAndJSON(t *testing.T) {
+	stdout, stderr := runConsole(t, runtime.ConsoleOptions{Level: runtime.LogLevelWarn, JSON: true, Colors: true}, `
+		console.debug('hidden');
+		console.log('hidden');
+		console.trace('hidden');
+		console.group('g');
+		console.warn('careful', { n: 1 });
+		console.error('failed: %d', 500);
+	`)
+
+	if stdout != "" {
+		t.Errorf("Info output should be filtered, got:\n%s", stdout)
+	}
+
+	type record struct {
+		Time  string   `json:"time"`
+		Level string   `json:"level"`
+		Msg   string   `json:"msg"`
+		Group []string `json:"group"`
+	}
+	var records []record
+	for _, line := range strings.Split(strings.TrimSpace(stderr), "\n") {
+		var r record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("Invalid JSON line %q: %v", line, err)
+		}
+		records = append(records, r)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d:\n%s", len(records), stderr)
+	}
+	if records[0].Level != "warn" || records[0].Msg != "careful { n: 1 }" || len(records[0].Group) != 1 || records[0].Time == "" {
+		t.Errorf("Unexpected warn record: %+v", records[0])
+	}
+	if records[1].Level != "error" || records[1].Msg != "failed: 500" {
+		t.Errorf("Unexpected error record: %+v", records[1])
+	}
+
+	for _, name := range []string{"debug", "INFO", "warning", "error", "silent"} {
+		if _, err := runtime.ParseLogLevel(name); err != nil {
+			t.Errorf("ParseLogLevel(%q) failed: %v", name, err)
+		}
+	}
+	if _, err := runtime.ParseLogLevel("verbose"); err == nil {
+		t.Error("Expected error for unknown level")
+	}
+}
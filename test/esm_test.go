@@ -0,0 +1,264 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
+)
+
+// writeModuleFiles 在目录中写入一组模块文件
+func writeModuleFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestESMImportExport(t *testing.T) {
+	tempDir := t.TempDir()
+	writeModuleFiles(t, tempDir, map[string]string{
+		"math.js": `
+			export const PI = 3.14;
+			export function add(a, b) { return a + b; }
+			export default function square(x) { return x * x; }
+		`,
+		"reexport.mjs": `
+			export * from './math.js';
+			export { add as plus } from './math.js';
+		`,
+		"main.js": `
+			import square, { add, PI } from './math.js';
+			import * as all from './reexport.mjs';
+
+			global.esmResults = {
+				sum: add(1, 2),
+				pi: PI,
+				square: square(4),
+				plus: all.plus(2, 3),
+				reexportPI: all.PI
+			};
+		`,
+	})
+
+	runner := runtime.NewOrPanic()
+	defer runner.Close()
+
+	if err := runner.RunFile(filepath.Join(tempDir, "main.js")); err != nil {
+		t.Fatalf("Failed to run ESM entry: %v", err)
+	}
+
+	results := runner.GetValue("esmResults").ToObject(nil)
+	if results.Get("sum").ToInteger() != 3 {
+		t.Errorf("Expected sum 3, got %v", results.Get("sum"))
+	}
+	if results.Get("pi").ToFloat() != 3.14 {
+		t.Errorf("Expected PI 3.14, got %v", results.Get("pi"))
+	}
+	if results.Get("square").ToInteger() != 16 {
+		t.Errorf("Expected square 16, got %v", results.Get("square"))
+	}
+	if results.Get("plus").ToInteger() != 5 {
+		t.Errorf("Expected plus 5, got %v", results.Get("plus"))
+	}
+	if results.Get("reexportPI").ToFloat() != 3.14 {
+		t.Errorf("Expected re-exported PI 3.14, got %v", results.Get("reexportPI"))
+	}
+}
+
+func TestESMLiveBindings(t *testing.T) {
+	tempDir := t.TempDir()
+	writeModuleFiles(t, tempDir, map[string]string{
+		"counter.js": `
+			export let count = 0;
+			export function increment() { count++; }
+		`,
+		"main.js": `
+			import { count, increment } from './counter.js';
+			const before = count;
+			increment();
+			increment();
+			global.liveResults = { before, after: count };
+		`,
+	})
+
+	runner := runtime.NewOrPanic()
+	defer runner.Close()
+
+	if err := runner.RunFile(filepath.Join(tempDir, "main.js")); err != nil {
+		t.Fatalf("Failed to run ESM entry: %v", err)
+	}
+
+	results := runner.GetValue("liveResults").ToObject(nil)
+	if results.Get("before").ToInteger() != 0 || results.Get("after").ToInteger() != 2 {
+		t.Errorf("Live binding not updated: before=%v after=%v", results.Get("before"), results.Get("after"))
+	}
+}
+
+func TestESMCyclicImports(t *testing.T) {
+	tempDir := t.TempDir()
+	writeModuleFiles(t, tempDir, map[string]string{
+		"a.js": `
+			import { b } from './b.js';
+			export function a() { return 'a'; }
+			export function callB() { return b(); }
+		`,
+		"b.js": `
+			import { a } from './a.js';
+			export function b() { return 'b' + a(); }
+		`,
+		"main.js": `
+			import { callB } from './a.js';
+			global.cycleResult = callB();
+		`,
+	})
+
+	runner := runtime.NewOrPanic()
+	defer runner.Close()
+
+	if err := runner.RunFile(filepath.Join(tempDir, "main.js")); err != nil {
+		t.Fatalf("Failed to run ESM entry: %v", err)
+	}
+
+	if got := runner.GetValue("cycleResult").String(); got != "ba" {
+		t.Errorf("Expected cyclic result 'ba', got %q", got)
+	}
+}
+
+func TestESMTopLevelAwait(t *testing.T) {
+	tempDir := t.TempDir()
+	writeModuleFiles(t, tempDir, map[string]string{
+		"config.mjs": `
+			const load = () => new Promise(resolve => setTimeout(() => resolve({ port: 8080 }), 10));
+			export const config = await load();
+		`,
+		"main.ts": `
+			import { config } from './config.mjs';
+			const items: number[] = [];
+			for await (const n of [Promise.resolve(1), Promise.resolve(2)]) {
+				items.push(n);
+			}
+			global.tlaResults = { port: config.port, items: items.join(',') };
+		`,
+	})
+
+	runner := runtime.NewOrPanic()
+	defer runner.Close()
+
+	if err := runner.RunFile(filepath.Join(tempDir, "main.ts")); err != nil {
+		t.Fatalf("Failed to run ESM entry: %v", err)
+	}
+
+	results := runner.GetValue("tlaResults")
+	if results == nil {
+		t.Fatal("Top-level await results not found")
+	}
+	obj := results.ToObject(nil)
+	if obj.Get("port").ToInteger() != 8080 {
+		t.Errorf("Expected port 8080, got %v", obj.Get("port"))
+	}
+	if obj.Get("items").String() != "1,2" {
+		t.Errorf("Expected items '1,2', got %v", obj.Get("items"))
+	}
+}
+
+func TestESMCommonJSInterop(t *testing.T) {
+	tempDir := t.TempDir()
+	writeModuleFiles(t, tempDir, map[string]string{
+		"legacy.cjs": `
+			module.exports = function greet(name) { return 'hi ' + name; };
+			module.exports.version = '1.0';
+		`,
+		"modern.mjs": `
+			import greet, { version } from './legacy.cjs';
+			export const message = greet('esm') + ' ' + version;
+			export default 42;
+		`,
+		"dynamic.mjs": `
+			export const meta = import.meta.url;
+		`,
+	})
+
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+	os.Chdir(tempDir)
+
+	runner := runtime.NewOrPanic()
+	defer runner.Close()
+
+	code := `
+		const modern = require('./modern.mjs');
+		global.interopResults = {
+			message: modern.message,
+			defaultValue: modern.default
+		};
+		import('./legacy.cjs').then(ns => {
+			global.interopResults.dynamicDefault = typeof ns.default;
+			global.interopResults.dynamicVersion = ns.version;
+			return import('./dynamic.mjs');
+		}).then(ns => {
+			global.interopResults.metaURL = ns.meta;
+		});
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("Failed to run interop code: %v", err)
+	}
+
+	results := runner.GetValue("interopResults").ToObject(nil)
+	if got := results.Get("message").String(); got != "hi esm 1.0" {
+		t.Errorf("Expected message 'hi esm 1.0', got %q", got)
+	}
+	if results.Get("defaultValue").ToInteger() != 42 {
+		t.Errorf("Expected default export 42, got %v", results.Get("defaultValue"))
+	}
+	if got := results.Get("dynamicDefault").String(); got != "function" {
+		t.Errorf("Expected CommonJS default to be function, got %q", got)
+	}
+	if got := results.Get("dynamicVersion").String(); got != "1.0" {
+		t.Errorf("Expected version '1.0', got %q", got)
+	}
+	if got := results.Get("metaURL").String(); !strings.HasPrefix(got, "file://") || !strings.HasSuffix(got, "dynamic.mjs") {
+		t.Errorf("Unexpected import.meta.url: %q", got)
+	}
+}
+
+func TestESMRequirePendingTopLevelAwait(t *testing.T) {
+	tempDir := t.TempDir()
+	writeModuleFiles(t, tempDir, map[string]string{
+		"slow.mjs": `
+			await new Promise(resolve => setTimeout(resolve, 10));
+			export const ready = true;
+		`,
+	})
+
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+	os.Chdir(tempDir)
+
+	runner := runtime.NewOrPanic()
+	defer runner.Close()
+
+	code := `
+		try {
+			require('./slow.mjs');
+			global.requireError = '';
+		} catch (e) {
+			global.requireError = String(e);
+		}
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("Failed to run code: %v", err)
+	}
+
+	if got := runner.GetValue("requireError").String(); !strings.Contains(got, "top-level await") {
+		t.Errorf("Expected pending top-level await error, got %q", got)
+	}
+}
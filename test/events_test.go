@@ -0,0 +1,226 @@
+package test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
+
+	"github.com/dop251/goja"
+)
+
+func TestEventEmitterBasics(t *testing.T) {
+	runner := runtime.NewOrPanic()
+	defer runner.Close()
+
+	code := `
+		const EventEmitter = require('events');
+		const { errorMonitor } = EventEmitter;
+
+		class Job extends EventEmitter {}
+		const job = new Job();
+		const calls = [];
+		const log = (v) => calls.push('on:' + v);
+
+		job.on('newListener', (name) => { if (name === 'tick') calls.push('new'); });
+		job.once('tick', (v) => calls.push('once:' + v))
+			.on('tick', log)
+			.prependListener('tick', (v) => calls.push('first:' + v));
+		const count = job.listenerCount('tick');
+		job.emit('tick', 1);
+		job.off('tick', log);
+		job.emit('tick', 2);
+
+		const monitored = [];
+		job.on(errorMonitor, (err) => monitored.push(err.message || err));
+		let unhandled = '';
+		try {
+			job.emit('error', new Error('boom'));
+		} catch (e) {
+			unhandled = e.message;
+		}
+		let code = '';
+		try {
+			job.emit('error', 'plain');
+		} catch (e) {
+			code = e.code;
+		}
+
+		job.removeAllListeners('tick');
+		globalThis.result = {
+			calls: calls.join(','),
+			count,
+			names: job.eventNames().filter((n) => typeof n === 'string').join(','),
+			instance: job instanceof EventEmitter && EventEmitter.EventEmitter === EventEmitter,
+			monitored: monitored.join(','),
+			unhandled,
+			code,
+			missing: job.emit('missing'),
+		};
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+
+	result := runner.GetValue("result").ToObject(nil)
+	expect := map[string]string{
+		"calls":     "new,new,new,first:1,once:1,on:1,first:2",
+		"count":     "3",
+		"names":     "newListener",
+		"instance":  "true",
+		"monitored": "boom,plain",
+		"unhandled": "boom",
+		"code":      "ERR_UNHANDLED_ERROR",
+		"missing":   "false",
+	}
+	for key, want := range expect {
+		if got := result.Get(key).String(); got != want {
+			t.Errorf("%s: expected %q, got %q", key, want, got)
+		}
+	}
+}
+
+func TestEventsOnce(t *testing.T) {
+	runner := runtime.NewOrPanic()
+	defer runner.Close()
+
+	code := `
+		const { EventEmitter, once } = require('events');
+		const emitter = new EventEmitter();
+		(async () => {
+			setTimeout(() => emitter.emit('ready', 'a', 'b'), 1);
+			const args = await once(emitter, 'ready');
+
+			setTimeout(() => emitter.emit('error', new Error('failed')), 1);
+			let rejected = '';
+			try {
+				await once(emitter, 'ready');
+			} catch (e) {
+				rejected = e.message;
+			}
+			globalThis.result = args.join(',') + '|' + rejected + '|' + emitter.listenerCount('error');
+		})();
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+	if got := runner.GetValue("result"); got == nil || got.String() != "a,b|failed|0" {
+		t.Errorf("Unexpected result: %v", got)
+	}
+}
+
+func TestTCPConnectionEmitter(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		conn.Write([]byte("hello"))
+		time.Sleep(50 * time.Millisecond)
+		conn.Close()
+	}()
+
+	runner := runtime.NewOrPanic()
+	defer runner.Close()
+
+	code := `
+		const EventEmitter = require('events');
+		const net = require('net/net');
+		const seen = [];
+		net.connectTCP(ADDR).then((socket) => {
+			socket.setEncoding('utf8');
+			socket.on('data', (data) => seen.push('a:' + data));
+			socket.on('data', (data) => seen.push('b:' + data));
+			socket.once('close', () => {
+				globalThis.result = [socket instanceof EventEmitter, seen.join(',')].join('|');
+			});
+		});
+	`
+	runner.SetValue("ADDR", listener.Addr().String())
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+	if got := runner.GetValue("result"); got == nil || got.String() != "true|a:hello,b:hello" {
+		t.Errorf("Unexpected result: %v", got)
+	}
+}
+
+func TestHTTPProxyEmitter(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path + "|" + r.Header.Get("X-Added") + "|" + r.Header.Get("X-Remove")))
+	}))
+	defer upstream.Close()
+
+	runner := runtime.NewOrPanic()
+	defer runner.Close()
+	port := freePort(t)
+
+	// 监听器在事件循环中执行，对请求的修改在转发前生效
+	err := runner.Do(context.Background(), func(vm *goja.Runtime) error {
+		vm.Set("TARGET", upstream.URL)
+		_, err := vm.RunString(`
+			const { proxy } = require('net');
+			const httpProxy = proxy.createHTTPProxy(TARGET);
+			globalThis.seen = [];
+			httpProxy.on('request', (req) => {
+				req.headers['x-added'] = 'yes';
+				delete req.headers['X-Remove'];
+				req.path = '/rewritten';
+				seen.push('request:' + req.method);
+			});
+			httpProxy.on('response', (res) => seen.push('response:' + res.status));
+			httpProxy.listen('` + port + `');
+		`)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Script failed: %v", err)
+	}
+
+	var resp *http.Response
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		req, _ := http.NewRequest("GET", "http://127.0.0.1:"+port+"/original", nil)
+		req.Header.Set("X-Remove", "secret")
+		resp, err = http.DefaultClient.Do(req)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Proxy did not start: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "/rewritten|yes|" {
+		t.Errorf("Request changes not applied: %q", body)
+	}
+
+	var seen string
+	deadline = time.Now().Add(2 * time.Second)
+	for seen != "request:GET,response:200" && time.Now().Before(deadline) {
+		runner.Do(context.Background(), func(vm *goja.Runtime) error {
+			v, err := vm.RunString(`seen.join(',')`)
+			if err == nil {
+				seen = v.String()
+			}
+			return err
+		})
+		time.Sleep(10 * time.Millisecond)
+	}
+	if seen != "request:GET,response:200" {
+		t.Errorf("Unexpected events: %q", seen)
+	}
+}
@@ -0,0 +1,258 @@
+package test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
+)
+
+// newFetchTestServer 创建 fetch 测试用的本地 HTTP 服务器
+func newFetchTestServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Echo", r.Header.Get("X-Test"))
+		fmt.Fprint(w, `{"message":"hello","method":"`+r.Method+`"}`)
+	})
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/json", http.StatusFound)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+			fmt.Fprint(w, "late")
+		case <-r.Context().Done():
+		}
+	})
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(w, "chunk%d;", i)
+			flusher.Flush()
+		}
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestFetchBasic(t *testing.T) {
+	server := newFetchTestServer()
+	defer server.Close()
+
+	runner := runtime.NewOrPanic()
+	defer runner.Close()
+
+	code := `
+		require('http/client').allowPrivateNetwork(true);
+		global.fetchResults = {};
+		fetch(BASE + '/json', { headers: { 'X-Test': 'abc' } })
+			.then(res => {
+				global.fetchResults.status = res.status;
+				global.fetchResults.ok = res.ok;
+				global.fetchResults.echo = res.headers.get('x-echo');
+				global.fetchResults.contentType = res.headers.get('Content-Type');
+				return res.json();
+			})
+			.then(data => {
+				global.fetchResults.message = data.message;
+				return fetch(new Request(BASE + '/echo', { method: 'post', body: 'ping' }));
+			})
+			.then(res => {
+				global.fetchResults.postStatus = res.status;
+				global.fetchResults.postType = res.headers.get('content-type');
+				return res.text();
+			})
+			.then(text => {
+				global.fetchResults.postBody = text;
+				return fetch(BASE + '/redirect');
+			})
+			.then(res => {
+				global.fetchResults.redirected = res.redirected;
+				global.fetchResults.finalURL = res.url;
+			})
+			.catch(e => { global.fetchResults.error = String(e); });
+	`
+	runner.SetValue("BASE", server.URL)
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("Failed to run fetch code: %v", err)
+	}
+
+	results := runner.GetValue("fetchResults").ToObject(nil)
+	if errValue := results.Get("error"); errValue != nil {
+		t.Fatalf("fetch chain failed: %v", errValue)
+	}
+	if results.Get("status").ToInteger() != 200 || !results.Get("ok").ToBoolean() {
+		t.Errorf("Unexpected status: %v ok=%v", results.Get("status"), results.Get("ok"))
+	}
+	if got := results.Get("echo").String(); got != "abc" {
+		t.Errorf("Expected request header to be sent, got %q", got)
+	}
+	if got := results.Get("message").String(); got != "hello" {
+		t.Errorf("Expected json body, got %q", got)
+	}
+	if results.Get("postStatus").ToInteger() != 201 {
+		t.Errorf("Expected 201, got %v", results.Get("postStatus"))
+	}
+	if got := results.Get("postType").String(); !strings.HasPrefix(got, "text/plain") {
+		t.Errorf("Expected text/plain content type for string body, got %q", got)
+	}
+	if got := results.Get("postBody").String(); got != "ping" {
+		t.Errorf("Expected echoed body, got %q", got)
+	}
+	if !results.Get("redirected").ToBoolean() || !strings.HasSuffix(results.Get("finalURL").String(), "/json") {
+		t.Errorf("Expected redirect to be followed, got %v %v", results.Get("redirected"), results.Get("finalURL"))
+	}
+}
+
+func TestFetchBlocksPrivateNetwork(t *testing.T) {
+	server := newFetchTestServer()
+	defer server.Close()
+
+	runner := runtime.NewOrPanic()
+	defer runner.Close()
+
+	code := `
+		fetch(BASE + '/json')
+			.then(() => { global.blockedError = ''; })
+			.catch(e => { global.blockedError = e.name + ': ' + e.message; });
+	`
+	runner.SetValue("BASE", server.URL)
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("Failed to run fetch code: %v", err)
+	}
+
+	got := runner.GetValue("blockedError").String()
+	if !strings.HasPrefix(got, "TypeError") || !strings.Contains(got, "fetch failed") {
+		t.Errorf("Expected SSRF validator to reject loopback fetch, got %q", got)
+	}
+}
+
+func TestFetchAbortAndStream(t *testing.T) {
+	server := newFetchTestServer()
+	defer server.Close()
+
+	runner := runtime.NewOrPanic()
+	defer runner.Close()
+
+	code := `
+		require('http/client').allowPrivateNetwork(true);
+		global.abortResults = { events: 0 };
+
+		const controller = new AbortController();
+		controller.signal.addEventListener('abort', () => { global.abortResults.events++; });
+		fetch(BASE + '/slow', { signal: controller.signal })
+			.then(() => { global.abortResults.aborted = 'resolved'; })
+			.catch(e => { global.abortResults.aborted = e.name; });
+		setTimeout(() => controller.abort(), 20);
+
+		fetch(BASE + '/slow', { signal: AbortSignal.timeout(20) })
+			.catch(e => { global.abortResults.timeout = e.name; });
+
+		fetch(BASE + '/stream').then(async res => {
+			const reader = res.body.getReader();
+			let text = '';
+			while (true) {
+				const { done, value } = await reader.read();
+				if (done) break;
+				text += String.fromCharCode(...value);
+			}
+			global.abortResults.streamed = text;
+		});
+	`
+	runner.SetValue("BASE", server.URL)
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("Failed to run fetch code: %v", err)
+	}
+
+	results := runner.GetValue("abortResults").ToObject(nil)
+	if got := results.Get("aborted").String(); got != "AbortError" {
+		t.Errorf("Expected AbortError, got %q", got)
+	}
+	if results.Get("events").ToInteger() != 1 {
+		t.Errorf("Expected one abort event, got %v", results.Get("events"))
+	}
+	if got := results.Get("timeout").String(); got != "TimeoutError" {
+		t.Errorf("Expected TimeoutError, got %q", got)
+	}
+	if got := results.Get("streamed").String(); got != "chunk0;chunk1;chunk2;" {
+		t.Errorf("Expected streamed body, got %q", got)
+	}
+}
+
+func TestFetchHeadersAndResponse(t *testing.T) {
+	runner := runtime.NewOrPanic()
+	defer runner.Close()
+
+	code := `
+		const headers = new Headers([['Content-Type', 'text/html']]);
+		headers.append('Accept', 'a');
+		headers.append('accept', 'b');
+		const names = [];
+		for (const [name, value] of headers) names.push(name + '=' + value);
+
+		const res = Response.json({ ok: 1 }, { status: 202, headers: { 'X-Custom': 'y' } });
+		let rangeError = '';
+		try { new Response('x', { status: 99 }); } catch (e) { rangeError = String(e); }
+
+		global.headerResults = {
+			names: names.join('&'),
+			has: headers.has('CONTENT-TYPE'),
+			status: res.status,
+			contentType: res.headers.get('content-type'),
+			custom: res.headers.get('x-custom'),
+			rangeError
+		};
+		res.clone().json().then(data => { global.headerResults.cloned = data.ok; });
+		res.text().then(text => {
+			global.headerResults.text = text;
+			global.headerResults.bodyUsed = res.bodyUsed;
+			return res.text();
+		}).catch(e => { global.headerResults.reuseError = String(e); });
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("Failed to run Headers code: %v", err)
+	}
+
+	results := runner.GetValue("headerResults").ToObject(nil)
+	if got := results.Get("names").String(); got != "accept=a, b&content-type=text/html" {
+		t.Errorf("Unexpected header iteration: %q", got)
+	}
+	if !results.Get("has").ToBoolean() {
+		t.Error("Expected case-insensitive has()")
+	}
+	if results.Get("status").ToInteger() != 202 {
+		t.Errorf("Expected status 202, got %v", results.Get("status"))
+	}
+	if got := results.Get("contentType").String(); got != "application/json" {
+		t.Errorf("Expected application/json, got %q", got)
+	}
+	if got := results.Get("custom").String(); got != "y" {
+		t.Errorf("Expected custom header, got %q", got)
+	}
+	if got := results.Get("rangeError").String(); !strings.Contains(got, "RangeError") {
+		t.Errorf("Expected RangeError for invalid status, got %q", got)
+	}
+	if results.Get("cloned").ToInteger() != 1 {
+		t.Errorf("Expected cloned body to be readable, got %v", results.Get("cloned"))
+	}
+	if got := results.Get("text").String(); got != `{"ok":1}` {
+		t.Errorf("Unexpected body text %q", got)
+	}
+	if !results.Get("bodyUsed").ToBoolean() {
+		t.Error("Expected bodyUsed to be true after reading")
+	}
+	if got := results.Get("reuseError").String(); !strings.Contains(got, "already been consumed") {
+		t.Errorf("Expected body reuse error, got %q", got)
+	}
+}
@@ -6,9 +6,10 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"sw_runtime/internal/runtime"
 	"testing"
 	"time"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
 )
 
 // BenchmarkHTTPServerSimpleRoute 基准测试 - 简单路由
@@ -5,9 +5,10 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
-	"sw_runtime/internal/runtime"
 	"testing"
 	"time"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
 )
 
 // TestHTTPServerFileServiceBasic 测试基本的文件服务功能
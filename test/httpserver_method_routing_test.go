@@ -8,7 +8,7 @@ import (
 
 	"github.com/dop251/goja"
 
-	"sw_runtime/internal/builtins"
+	"github.com/issueye/sw_runtime/internal/builtins"
 )
 
 // TestHTTPServerSamePathDifferentMethods 测试相同路径不同 HTTP 方法
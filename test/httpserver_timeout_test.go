@@ -6,7 +6,7 @@ import (
 	"testing"
 	"time"
 
-	"sw_runtime/internal/builtins"
+	"github.com/issueye/sw_runtime/internal/builtins"
 
 	"github.com/dop251/goja"
 )
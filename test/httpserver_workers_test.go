@@ -0,0 +1,158 @@
+package test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
+)
+
+// startWorkerServer 在后台运行服务器脚本，并等待端口可以访问
+func startWorkerServer(t *testing.T, code, url string) *runtime.Runner {
+	t.Helper()
+	runner := runtime.NewOrPanicWithWorkingDir(t.TempDir())
+	go runner.RunCode(code)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if resp, err := http.Get(url); err == nil {
+			resp.Body.Close()
+			return runner
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	runner.Close()
+	t.Fatalf("Server on %s did not start", url)
+	return nil
+}
+
+// getJSON 发送 GET 请求并解析 JSON 响应
+func getJSON(t *testing.T, url string) map[string]interface{} {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	var data map[string]interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		t.Fatalf("Invalid JSON %q: %v", body, err)
+	}
+	return data
+}
+
+func TestHTTPServerWorkersIsolationAndSharedStore(t *testing.T) {
+	code := `
+		const httpserver = require('http/server');
+		httpserver.shared.set('config', { greeting: 'hi', tags: new Set(['a']) });
+
+		const vmId = Math.random().toString(36).slice(2);
+		let local = 0;
+
+		const server = httpserver.createServer();
+		server.get('/count', (req, res) => {
+			local++;
+			const config = httpserver.shared.get('config');
+			res.json({
+				vmId,
+				local,
+				total: httpserver.shared.incr('hits'),
+				greeting: config.greeting,
+				hasSet: config.tags instanceof Set
+			});
+		});
+		server.listen('38950', { workers: 3 });
+	`
+	runner := startWorkerServer(t, code, "http://localhost:38950/count")
+	defer runner.Close()
+
+	vms := make(map[string]bool)
+	var lastTotal float64
+	for i := 0; i < 12; i++ {
+		data := getJSON(t, "http://localhost:38950/count")
+		vms[data["vmId"].(string)] = true
+		if data["greeting"] != "hi" || data["hasSet"] != true {
+			t.Errorf("Unexpected shared config: %v", data)
+		}
+		if data["local"].(float64) > 12 {
+			t.Errorf("Per-VM state leaked across workers: %v", data)
+		}
+		lastTotal = data["total"].(float64)
+	}
+
+	if len(vms) != 3 {
+		t.Errorf("Expected requests to be spread across 3 VMs, got %d", len(vms))
+	}
+	// 启动探测请求也计入共享计数
+	if lastTotal != 13 {
+		t.Errorf("Expected shared counter to reach 13, got %v", lastTotal)
+	}
+}
+
+func TestHTTPServerWorkersRunInParallel(t *testing.T) {
+	code := `
+		const server = require('http/server').createServer();
+		server.get('/spin', (req, res) => {
+			const start = Date.now();
+			if (req.params.ms) {
+				while (Date.now() - start < Number(req.params.ms)) {}
+			}
+			res.send('ok');
+		});
+		server.listen('38951', { workers: 4 });
+	`
+	runner := startWorkerServer(t, code, "http://localhost:38951/spin")
+	defer runner.Close()
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := http.Get("http://localhost:38951/spin?ms=300")
+			if err != nil {
+				t.Errorf("Request failed: %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+		t.Errorf("Requests did not run in parallel: took %v", elapsed)
+	}
+}
+
+func TestHTTPServerWorkersFailure(t *testing.T) {
+	runner := runtime.NewOrPanicWithWorkingDir(t.TempDir())
+	defer runner.Close()
+
+	code := `
+		const httpserver = require('http/server');
+		if (httpserver.isWorker) {
+			throw new Error('worker boot failed');
+		}
+		httpserver.createServer().listen('38952', { workers: 2 })
+			.catch(err => { global.listenError = err.message; });
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("Failed to run server code: %v", err)
+	}
+
+	got := runner.GetValue("listenError")
+	if got == nil || !strings.Contains(got.String(), "worker boot failed") {
+		t.Errorf("Expected listen to reject with worker error, got %v", got)
+	}
+	if resp, err := http.Get("http://localhost:38952/"); err == nil {
+		resp.Body.Close()
+		t.Errorf("Port should not be bound when workers fail")
+	}
+}
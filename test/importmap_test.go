@@ -0,0 +1,168 @@
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/issueye/sw_runtime/internal/bundler"
+	"github.com/issueye/sw_runtime/internal/importmap"
+	"github.com/issueye/sw_runtime/internal/project"
+	"github.com/issueye/sw_runtime/internal/runtime"
+)
+
+// writeImportMapProject 创建使用导入映射的纯 JS 项目
+func writeImportMapProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeModuleFiles(t, dir, map[string]string{
+		"import_map.json": `{
+			"imports": {
+				"lodash": "./vendor/lodash/lodash.js",
+				"lodash/": "./vendor/lodash/",
+				"#helper": "./lib/helper.js",
+				"./lib/old.js": "./lib/helper.js",
+				"blocked": null
+			},
+			"scopes": {
+				"./legacy/": { "lodash": "./vendor/lodash-old/lodash.js" }
+			}
+		}`,
+		"vendor/lodash/lodash.js":     `exports.version = 'new';`,
+		"vendor/lodash/fp/index.js":   `exports.fp = 'fp';`,
+		"vendor/lodash-old/lodash.js": `exports.version = 'old';`,
+		"lib/helper.js":               `exports.helper = () => 'helper';`,
+		"legacy/old.js":               `exports.version = require('lodash').version;`,
+		"esm.mjs":                     `import { version } from 'lodash'; export const esm = version;`,
+		"main.js": `
+			const _ = require('lodash');
+			const { fp } = require('lodash/fp');
+			const { helper } = require('#helper');
+			const legacy = require('./legacy/old.js');
+			const remapped = require('./lib/old.js');
+			globalThis.result = [_.version, fp, helper(), legacy.version, remapped.helper()].join('|');
+		`,
+	})
+	return dir
+}
+
+func TestImportMapResolve(t *testing.T) {
+	dir := writeImportMapProject(t)
+	m, err := importmap.Load(filepath.Join(dir, "import_map.json"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	tests := []struct {
+		specifier string
+		referrer  string
+		expected  string
+	}{
+		{"lodash", "", "vendor/lodash/lodash.js"},
+		{"lodash/fp/map.js", "", "vendor/lodash/fp/map.js"},
+		{"lodash", filepath.Join(dir, "legacy", "old.js"), "vendor/lodash-old/lodash.js"},
+		{"./lib/old.js", "", "lib/helper.js"},
+		{"react", "", ""},
+	}
+	for _, tt := range tests {
+		target, ok, err := m.Resolve(tt.specifier, dir, tt.referrer)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.specifier, err)
+			continue
+		}
+		if tt.expected == "" {
+			if ok {
+				t.Errorf("%s: expected no match, got %s", tt.specifier, target)
+			}
+			continue
+		}
+		if want := filepath.Join(dir, tt.expected); !ok || target != want {
+			t.Errorf("%s: expected %s, got %s (%v)", tt.specifier, want, target, ok)
+		}
+	}
+
+	// null 映射阻止导入，前缀映射不能通过 ../ 跳出目标目录
+	if _, _, err := m.Resolve("blocked", dir, ""); err == nil || !strings.Contains(err.Error(), "blocked") {
+		t.Errorf("Expected blocked error, got %v", err)
+	}
+	if _, _, err := m.Resolve("lodash/../../secret.js", dir, ""); err == nil || !strings.Contains(err.Error(), "backtracks") {
+		t.Errorf("Expected backtracking error, got %v", err)
+	}
+}
+
+func TestImportMapRuntime(t *testing.T) {
+	dir := writeImportMapProject(t)
+	writeModuleFiles(t, dir, map[string]string{"sw.json": `{ "importMap": "./import_map.json" }`})
+
+	// sw.json 的 importMap 配置
+	config, err := project.Find(filepath.Join(dir, "lib"))
+	if err != nil || config == nil {
+		t.Fatalf("Expected sw.json to be found, got %v", err)
+	}
+	m, err := config.LoadImportMap()
+	if err != nil || m == nil {
+		t.Fatalf("LoadImportMap failed: %v", err)
+	}
+
+	runner := runtime.NewOrPanicWithWorkingDir(dir)
+	defer runner.Close()
+	runner.SetImportMap(m)
+
+	if err := runner.RunFile(filepath.Join(dir, "main.js")); err != nil {
+		t.Fatalf("RunFile failed: %v", err)
+	}
+	if got := runner.GetValue("result").String(); got != "new|fp|helper|old|helper" {
+		t.Errorf("Unexpected result: %q", got)
+	}
+
+	// 动态 import() 与 ES 模块的静态 import 使用同一映射
+	code := `
+		Promise.all([import('lodash/fp'), import('./esm.mjs')]).then(([fp, esm]) => {
+			globalThis.dynamic = fp.fp + '|' + esm.esm;
+		});
+		try { require('blocked'); } catch (e) { globalThis.blocked = e.message; }
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+	if got := runner.GetValue("dynamic").String(); got != "fp|new" {
+		t.Errorf("Unexpected dynamic import result: %q", got)
+	}
+	if got := runner.GetValue("blocked").String(); !strings.Contains(got, "blocked by an invalid or null entry") {
+		t.Errorf("Expected blocked error, got %q", got)
+	}
+}
+
+func TestImportMapBundle(t *testing.T) {
+	dir := writeImportMapProject(t)
+	m, err := importmap.Parse([]byte(`{
+		"imports": { "lodash": "./vendor/lodash/lodash.js", "lodash/": "./vendor/lodash/", "#helper": "./lib/helper.js", "./lib/old.js": "./lib/helper.js" },
+		"scopes": { "./legacy/": { "lodash": "./vendor/lodash-old/lodash.js" } }
+	}`), dir)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	b := bundler.New(bundler.Options{
+		EntryFile:  filepath.Join(dir, "main.js"),
+		OutputFile: filepath.Join(dir, "bundle.js"),
+		ImportMap:  m,
+	})
+	result, err := b.Bundle()
+	if err != nil {
+		t.Fatalf("Bundle failed: %v", err)
+	}
+	if len(result.Modules) != 6 {
+		t.Errorf("Expected 6 modules, got %v", result.Modules)
+	}
+
+	// 打包结果与运行时解析一致，运行时不再需要导入映射
+	runner := runtime.NewOrPanicWithWorkingDir(t.TempDir())
+	defer runner.Close()
+	if err := runner.RunCode(result.Code); err != nil {
+		t.Fatalf("Running bundle failed: %v", err)
+	}
+	if got := runner.GetValue("result").String(); got != "new|fp|helper|old|helper" {
+		t.Errorf("Unexpected bundle result: %q", got)
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"testing"
 	"time"
 
-	"sw_runtime/internal/runtime"
+	"github.com/issueye/sw_runtime/internal/runtime"
 
 	"github.com/dop251/goja"
 )
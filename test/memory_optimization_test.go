@@ -5,8 +5,8 @@ import (
 	goruntime "runtime"
 	"testing"
 
-	"sw_runtime/internal/pool"
-	"sw_runtime/internal/runtime"
+	"github.com/issueye/sw_runtime/internal/pool"
+	"github.com/issueye/sw_runtime/internal/runtime"
 )
 
 func TestPoolBasicUsage(t *testing.T) {
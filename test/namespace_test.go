@@ -2,8 +2,9 @@ package test
 
 import (
 	"fmt"
-	"sw_runtime/internal/runtime"
 	"testing"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
 )
 
 func TestNamespaceModules(t *testing.T) {
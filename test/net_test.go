@@ -4,7 +4,7 @@ import (
 	"testing"
 	"time"
 
-	"sw_runtime/internal/runtime"
+	"github.com/issueye/sw_runtime/internal/runtime"
 )
 
 // TestTCPServerCreation 测试创建 TCP 服务器
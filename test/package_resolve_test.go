@@ -0,0 +1,183 @@
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
+)
+
+func TestPackageMainAndScoped(t *testing.T) {
+	tempDir := t.TempDir()
+	writeModuleFiles(t, tempDir, map[string]string{
+		"node_modules/legacy/package.json":      `{"name": "legacy", "main": "lib/entry.js"}`,
+		"node_modules/legacy/lib/entry.js":      `module.exports = { name: 'legacy-main' };`,
+		"node_modules/legacy/lib/extra.js":      `module.exports = 'extra';`,
+		"node_modules/@scope/pkg/package.json":  `{"name": "@scope/pkg", "main": "./dist"}`,
+		"node_modules/@scope/pkg/dist/index.js": `module.exports = { scoped: true };`,
+		"src/app/main.js": `
+			const legacy = require('legacy');
+			const extra = require('legacy/lib/extra');
+			const scoped = require('@scope/pkg');
+			global.pkgResults = { legacy: legacy.name, extra, scoped: scoped.scoped };
+		`,
+	})
+
+	runner := runtime.NewOrPanicWithWorkingDir(tempDir)
+	defer runner.Close()
+
+	// src/app/main.js 需要向上查找 node_modules
+	code := `require('./src/app/main.js');`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("Failed to run package resolution code: %v", err)
+	}
+
+	results := runner.GetValue("pkgResults").ToObject(nil)
+	if got := results.Get("legacy").String(); got != "legacy-main" {
+		t.Errorf("Expected main field to resolve, got %q", got)
+	}
+	if got := results.Get("extra").String(); got != "extra" {
+		t.Errorf("Expected subpath to resolve, got %q", got)
+	}
+	if !results.Get("scoped").ToBoolean() {
+		t.Error("Expected scoped package to resolve")
+	}
+}
+
+func TestPackageConditionalExports(t *testing.T) {
+	tempDir := t.TempDir()
+	writeModuleFiles(t, tempDir, map[string]string{
+		"node_modules/dual/package.json": `{
+			"name": "dual",
+			"exports": {
+				".": {
+					"import": "./esm/index.mjs",
+					"require": "./cjs/index.js"
+				},
+				"./features/*": "./src/features/*.js",
+				"./package.json": "./package.json",
+				"./internal/*": null
+			}
+		}`,
+		"node_modules/dual/esm/index.mjs":          `export const format = 'esm';`,
+		"node_modules/dual/cjs/index.js":           `exports.format = 'cjs';`,
+		"node_modules/dual/src/features/auth.js":   `exports.feature = 'auth';`,
+		"node_modules/dual/src/internal/secret.js": `exports.secret = true;`,
+		"main.mjs": `
+			import { format } from 'dual';
+			import { feature } from 'dual/features/auth';
+			const cjs = require('dual');
+			let hiddenError = '';
+			try {
+				require('dual/src/features/auth.js');
+			} catch (e) {
+				hiddenError = String(e);
+			}
+			global.exportsResults = { esm: format, cjs: cjs.format, feature, hiddenError };
+		`,
+	})
+
+	runner := runtime.NewOrPanicWithWorkingDir(tempDir)
+	defer runner.Close()
+
+	if err := runner.RunFile(filepath.Join(tempDir, "main.mjs")); err != nil {
+		t.Fatalf("Failed to run exports resolution: %v", err)
+	}
+
+	results := runner.GetValue("exportsResults").ToObject(nil)
+	if got := results.Get("esm").String(); got != "esm" {
+		t.Errorf("Expected import condition to pick esm build, got %q", got)
+	}
+	if got := results.Get("cjs").String(); got != "cjs" {
+		t.Errorf("Expected require condition to pick cjs build, got %q", got)
+	}
+	if got := results.Get("feature").String(); got != "auth" {
+		t.Errorf("Expected subpath pattern to resolve, got %q", got)
+	}
+	if got := results.Get("hiddenError").String(); !contains(got, "not defined by \"exports\"") {
+		t.Errorf("Expected unexported subpath error, got %q", got)
+	}
+}
+
+func TestPackageImportsField(t *testing.T) {
+	tempDir := t.TempDir()
+	writeModuleFiles(t, tempDir, map[string]string{
+		"package.json": `{
+			"name": "app",
+			"type": "module",
+			"imports": {
+				"#config": "./config/default.js",
+				"#utils/*": "./lib/utils/*.js"
+			}
+		}`,
+		"config/default.js": `export default { env: 'test' };`,
+		"lib/utils/str.js":  `export const upper = s => s.toUpperCase();`,
+		"main.js": `
+			import config from '#config';
+			import { upper } from '#utils/str';
+			global.importsResults = { env: config.env, upper: upper('ok') };
+		`,
+	})
+
+	runner := runtime.NewOrPanicWithWorkingDir(tempDir)
+	defer runner.Close()
+
+	if err := runner.RunFile(filepath.Join(tempDir, "main.js")); err != nil {
+		t.Fatalf("Failed to run imports resolution: %v", err)
+	}
+
+	results := runner.GetValue("importsResults").ToObject(nil)
+	if got := results.Get("env").String(); got != "test" {
+		t.Errorf("Expected #config to resolve, got %q", got)
+	}
+	if got := results.Get("upper").String(); got != "OK" {
+		t.Errorf("Expected #utils/* pattern to resolve, got %q", got)
+	}
+}
+
+func TestPackageTargetsStayInsidePackage(t *testing.T) {
+	tempDir := t.TempDir()
+	writeModuleFiles(t, tempDir, map[string]string{
+		"secret.js": `module.exports = 'secret';`,
+		"node_modules/evil/package.json": `{
+			"name": "evil",
+			"exports": {
+				".": "./index.js",
+				"./escape": "./../../secret.js",
+				"./parent": "../../secret.js",
+				"./files/*": "./lib/*",
+				"./nested": "./lib/node_modules/x.js"
+			},
+			"imports": { "#up": "./../../secret.js" }
+		}`,
+		"node_modules/evil/index.js":   `exports.up = () => require('#up');`,
+		"node_modules/evil/lib/ok.js":  `module.exports = 'ok';`,
+		"node_modules/evil/lib/sub.js": `module.exports = 'sub';`,
+	})
+
+	runner := runtime.NewOrPanicWithWorkingDir(tempDir)
+	defer runner.Close()
+
+	code := `
+		const errors = [];
+		for (const id of ['evil/escape', 'evil/parent', 'evil/files/../../../secret.js', 'evil/nested']) {
+			try { require(id); errors.push('loaded ' + id); } catch (e) { errors.push(e.message); }
+		}
+		try { require('evil').up(); errors.push('loaded #up'); } catch (e) { errors.push(e.message); }
+		globalThis.targetResults = { ok: String(require('evil/files/ok.js')), errors };
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("Failed to run package target code: %v", err)
+	}
+
+	results := runner.GetValue("targetResults").Export().(map[string]interface{})
+	if results["ok"] != "ok" {
+		t.Errorf("Expected pattern target inside the package to resolve, got %v", results["ok"])
+	}
+	for _, msg := range results["errors"].([]interface{}) {
+		if !strings.Contains(msg.(string), "invalid package target") {
+			t.Errorf("Expected invalid package target error, got %q", msg)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
+	"github.com/issueye/sw_runtime/internal/security"
+)
+
+// newRestrictedRunner 创建带权限限制的 Runner，工作目录中包含 data/input.txt
+func newRestrictedRunner(t *testing.T, perms *security.Permissions) (*runtime.Runner, string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "data"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "data", "input.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	runner, err := runtime.NewWithWorkingDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	t.Cleanup(func() { runner.Close() })
+	runner.SetPermissions(perms)
+	return runner, dir
+}
+
+func TestPermissionsFS(t *testing.T) {
+	runner, dir := newRestrictedRunner(t, &security.Permissions{
+		Read:  []string{"*"},
+		Write: []string{filepath.Join(t.TempDir(), "elsewhere")},
+	})
+
+	err := runner.RunCode(`
+		const { fs } = require('fs');
+		globalThis.content = fs.readFileSync(__dirname + '/data/input.txt', 'utf8');
+		try {
+			fs.writeFileSync(__dirname + '/data/output.txt', 'x');
+		} catch (e) {
+			globalThis.writeError = { name: e.name, code: e.code, permission: e.permission, target: e.target };
+		}
+		fs.writeFile(__dirname + '/data/output.txt', 'x').catch(e => { globalThis.asyncError = e.name; });
+	`)
+	if err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+
+	if got := runner.GetValue("content").String(); got != "hello" {
+		t.Errorf("Expected file content, got %q", got)
+	}
+	writeErr := runner.GetValue("writeError").Export().(map[string]interface{})
+	if writeErr["name"] != "PermissionDenied" || writeErr["code"] != "ERR_PERMISSION_DENIED" || writeErr["permission"] != "write" {
+		t.Errorf("Unexpected write error: %v", writeErr)
+	}
+	if writeErr["target"] != filepath.Join(dir, "data", "output.txt") {
+		t.Errorf("Unexpected target: %v", writeErr["target"])
+	}
+	if got := runner.GetValue("asyncError"); got == nil || got.String() != "PermissionDenied" {
+		t.Errorf("Expected async PermissionDenied, got %v", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "data", "output.txt")); !os.IsNotExist(err) {
+		t.Error("File should not be written")
+	}
+}
+
+func TestPermissionsRunAndEnv(t *testing.T) {
+	os.Setenv("SW_PERM_ALLOWED", "yes")
+	os.Setenv("SW_PERM_HIDDEN", "no")
+	defer os.Unsetenv("SW_PERM_ALLOWED")
+	defer os.Unsetenv("SW_PERM_HIDDEN")
+
+	runner, _ := newRestrictedRunner(t, &security.Permissions{Env: []string{"SW_PERM_ALLOWED"}})
+
+	err := runner.RunCode(`
+		const { exec, process: proc } = require('process');
+		globalThis.allowed = proc.env.SW_PERM_ALLOWED;
+		globalThis.hidden = proc.env.SW_PERM_HIDDEN === undefined;
+		try {
+			exec.getEnv('SW_PERM_HIDDEN');
+		} catch (e) {
+			globalThis.envError = e.name;
+		}
+		try {
+			exec.execSync('echo', ['hi']);
+		} catch (e) {
+			globalThis.runError = e.message;
+		}
+	`)
+	if err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+
+	if got := runner.GetValue("allowed").String(); got != "yes" {
+		t.Errorf("Expected allowed env, got %q", got)
+	}
+	if !runner.GetValue("hidden").ToBoolean() {
+		t.Error("Env not in allow list should be hidden")
+	}
+	if got := runner.GetValue("envError"); got == nil || got.String() != "PermissionDenied" {
+		t.Errorf("Expected getEnv PermissionDenied, got %v", got)
+	}
+	want := `requires run access to "echo", run again with the --allow-run flag`
+	if got := runner.GetValue("runError"); got == nil || got.String() != want {
+		t.Errorf("Expected %q, got %v", want, got)
+	}
+}
+
+func TestPermissionsNet(t *testing.T) {
+	runner, _ := newRestrictedRunner(t, &security.Permissions{Net: []string{"localhost:1"}})
+
+	err := runner.RunCode(`
+		globalThis.errors = [];
+		const { net } = require('net');
+		net.connectTCP('127.0.0.1:9').catch(e => errors.push('tcp:' + e.target));
+		fetch('https://example.com/api').catch(e => errors.push('fetch:' + e.target));
+	`)
+	if err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+
+	got := runner.GetValue("errors").Export().([]interface{})
+	if len(got) != 2 || got[0] != "tcp:127.0.0.1:9" || got[1] != "fetch:example.com:443" {
+		t.Errorf("Unexpected net errors: %v", got)
+	}
+}
+
+func TestPermissionsGoError(t *testing.T) {
+	runner, _ := newRestrictedRunner(t, &security.Permissions{})
+
+	err := runner.RunCode(`require('fs').fs.readFileSync(__dirname + '/data/input.txt')`)
+	var denied *security.PermissionDeniedError
+	if !errors.As(err, &denied) {
+		t.Fatalf("Expected PermissionDeniedError, got %v", err)
+	}
+	if denied.Permission != security.PermRead {
+		t.Errorf("Expected read permission, got %s", denied.Permission)
+	}
+
+	// 取消限制后恢复默认行为
+	runner.SetPermissions(nil)
+	if runner.Permissions() != nil {
+		t.Error("Permissions should be nil after reset")
+	}
+	if err := runner.RunCode(`require('fs').fs.readFileSync(__dirname + '/data/input.txt')`); err != nil {
+		t.Errorf("Unrestricted read failed: %v", err)
+	}
+}
+
+func TestPermissionsWorkerInherits(t *testing.T) {
+	runner, dir := newRestrictedRunner(t, &security.Permissions{})
+	worker := filepath.Join(dir, "worker.js")
+	// 加载 Worker 脚本本身需要读取权限
+	runner.SetPermissions(&security.Permissions{Read: []string{worker}})
+	code := `
+		try {
+			require('fs').fs.readFileSync(__dirname + '/data/input.txt');
+			self.postMessage('allowed');
+		} catch (e) {
+			self.postMessage(e.name);
+		}
+	`
+	if err := os.WriteFile(worker, []byte(code), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := runner.RunCode(`
+		const w = new Worker('./worker.js');
+		w.onmessage = (e) => { globalThis.result = e.data; w.terminate(); };
+	`)
+	if err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+	if got := runner.GetValue("result"); got == nil || got.String() != "PermissionDenied" {
+		t.Errorf("Worker should inherit permissions, got %v", got)
+	}
+}
+
+func TestPermissionsModuleLoading(t *testing.T) {
+	runner, dir := newRestrictedRunner(t, &security.Permissions{})
+	writeModuleFiles(t, dir, map[string]string{
+		"lib/mod.js":    `module.exports = 'loaded';`,
+		"lib/esm.mjs":   `export default 'esm';`,
+		"lib/worker.js": `self.postMessage('started');`,
+	})
+
+	code := `
+		const names = [];
+		try { require('./lib/mod.js'); names.push('required'); } catch (e) { names.push(e.name + ':' + e.permission); }
+		try { new Worker('./lib/worker.js'); names.push('worker'); } catch (e) { names.push(e.name + ':' + e.permission); }
+		import('./lib/esm.mjs').then(() => names.push('imported'), (e) => names.push(e.name + ':' + e.permission))
+			.then(() => { globalThis.result = names.join(','); });
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+	expected := "PermissionDenied:read,PermissionDenied:read,PermissionDenied:read"
+	if got := runner.GetValue("result"); got == nil || got.String() != expected {
+		t.Errorf("Expected %q, got %v", expected, got)
+	}
+
+	// 授权目录后可以正常加载
+	allowed, dir := newRestrictedRunner(t, &security.Permissions{})
+	allowed.SetPermissions(&security.Permissions{Read: []string{dir}})
+	writeModuleFiles(t, dir, map[string]string{"mod.js": `module.exports = 'loaded';`})
+	if err := allowed.RunCode(`globalThis.result = String(require('./mod.js'));`); err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+	if got := allowed.GetValue("result").String(); got != "loaded" {
+		t.Errorf("Expected module to load with read permission, got %q", got)
+	}
+}
+
+func TestPermissionsSymlink(t *testing.T) {
+	runner, dir := newRestrictedRunner(t, &security.Permissions{})
+	outside := t.TempDir()
+	writeModuleFiles(t, outside, map[string]string{
+		"secret.txt": "secret",
+		"mod.js":     `module.exports = 'escaped';`,
+	})
+	// 允许目录内指向外部的目录链接、文件链接和尚不存在的目标
+	for link, target := range map[string]string{
+		"data/outside":    outside,
+		"data/secret.txt": filepath.Join(outside, "secret.txt"),
+		"data/new.txt":    filepath.Join(outside, "new.txt"),
+		"alias":           filepath.Join(dir, "data"),
+	} {
+		if err := os.Symlink(target, filepath.Join(dir, link)); err != nil {
+			t.Skipf("Symlinks not supported: %v", err)
+		}
+	}
+	data := filepath.Join(dir, "data")
+	runner.SetPermissions(&security.Permissions{Read: []string{data, filepath.Join(dir, "alias")}, Write: []string{data}})
+
+	code := `
+		const { fs } = require('fs');
+		const names = [];
+		const attempt = (fn) => { try { fn(); names.push('ok'); } catch (e) { names.push(e.name); } };
+		attempt(() => fs.readFileSync(__dirname + '/data/secret.txt', 'utf8'));
+		attempt(() => fs.readFileSync(__dirname + '/data/outside/secret.txt', 'utf8'));
+		attempt(() => require('./data/outside/mod.js'));
+		attempt(() => fs.writeFileSync(__dirname + '/data/new.txt', 'x'));
+		attempt(() => fs.writeFileSync(__dirname + '/data/outside/other.txt', 'x'));
+		attempt(() => fs.readFileSync(__dirname + '/alias/input.txt', 'utf8'));
+		globalThis.result = names.join(',');
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+	expected := "PermissionDenied,PermissionDenied,PermissionDenied,PermissionDenied,PermissionDenied,ok"
+	if got := runner.GetValue("result"); got == nil || got.String() != expected {
+		t.Errorf("Expected %q, got %v", expected, got)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "new.txt")); !os.IsNotExist(err) {
+		t.Errorf("Write through dangling symlink should not create the target: %v", err)
+	}
+
+	guard := security.NewGuard()
+	guard.Set(&security.Permissions{Read: []string{data}})
+	var denied *security.PermissionDeniedError
+	if err := guard.CheckRead(filepath.Join(data, "outside", "missing", "file.txt")); !errors.As(err, &denied) {
+		t.Errorf("Expected PermissionDeniedError for path under escaping symlink, got %v", err)
+	}
+	if err := guard.CheckRead(filepath.Join(data, "missing", "file.txt")); err != nil {
+		t.Errorf("Nonexistent path inside allowed dir should be allowed: %v", err)
+	}
+}
+
+func TestGuardChecks(t *testing.T) {
+	// 未设置权限时不限制
+	var nilGuard *security.Guard
+	if err := nilGuard.CheckRead("/etc/passwd"); err != nil {
+		t.Errorf("Nil guard should allow: %v", err)
+	}
+
+	dir := t.TempDir()
+	guard := security.NewGuard()
+	guard.Set(&security.Permissions{
+		Read: []string{dir},
+		Net:  []string{"example.com", "127.0.0.1:8080"},
+		Env:  []string{"HOME"},
+	})
+
+	checks := []struct {
+		name string
+		err  error
+		ok   bool
+	}{
+		{"read inside", guard.CheckRead(filepath.Join(dir, "a", "b.txt")), true},
+		{"read dir itself", guard.CheckRead(dir), true},
+		{"read sibling prefix", guard.CheckRead(dir + "-other"), false},
+		{"write", guard.CheckWrite(filepath.Join(dir, "a.txt")), false},
+		{"host any port", guard.CheckNet("EXAMPLE.com", "8443"), true},
+		{"host:port", guard.CheckAddr("127.0.0.1:8080"), true},
+		{"other port", guard.CheckAddr("127.0.0.1:8081"), false},
+		{"listen all", guard.CheckAddr(":8080"), false},
+		{"url", guard.CheckURL("wss://example.com/socket"), true},
+		{"env", guard.CheckEnv("HOME"), true},
+		{"other env", guard.CheckEnv("PATH"), false},
+		{"run", guard.CheckRun("ls"), false},
+	}
+	for _, c := range checks {
+		if (c.err == nil) != c.ok {
+			t.Errorf("%s: expected ok=%v, got %v", c.name, c.ok, c.err)
+		}
+	}
+
+	guard.Set(security.AllowAll())
+	if err := guard.CheckRun("anything"); err != nil || !guard.Restricted() {
+		t.Errorf("AllowAll should allow everything: %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package test
+
+import (
+	"bytes"
+	goruntime "runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
+)
+
+// syncBuffer 可以被事件循环和测试同时访问的输出缓冲区
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// runWithOutput 在新 Runner 中执行代码，返回 Runner 以及 console 的标准输出和标准错误
+func runWithOutput(t *testing.T, code string) (*runtime.Runner, string, string) {
+	t.Helper()
+	runner, err := runtime.New()
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	t.Cleanup(runner.Close)
+
+	var stdout, stderr syncBuffer
+	opts := runner.ConsoleOptions()
+	opts.Stdout, opts.Stderr, opts.Colors = &stdout, &stderr, false
+	runner.SetConsoleOptions(opts)
+
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+	return runner, stdout.String(), stderr.String()
+}
+
+func TestProcessExitEvents(t *testing.T) {
+	runner, stdout, _ := runWithOutput(t, `
+		let rounds = 0;
+		process.on('beforeExit', (code) => {
+			console.log('beforeExit', code);
+			if (++rounds === 1) setTimeout(() => console.log('more work'), 5);
+		});
+		process.on('exit', (code) => {
+			console.log('exit', code);
+			setTimeout(() => console.log('never'), 0);
+		});
+		process.exitCode = 2;
+		console.log('main');
+	`)
+
+	want := "main\nbeforeExit 2\nmore work\nbeforeExit 2\nexit 2\n"
+	if stdout != want {
+		t.Errorf("Unexpected output:\n%s\nwant:\n%s", stdout, want)
+	}
+	if code := runner.ExitCode(); code != 2 {
+		t.Errorf("Expected exit code 2, got %d", code)
+	}
+}
+
+func TestProcessErrorEvents(t *testing.T) {
+	_, stdout, stderr := runWithOutput(t, `
+		process.on('uncaughtException', (err, origin) => console.log('caught', err.message, origin));
+		process.on('unhandledRejection', (reason, promise) => console.log('unhandled', reason.message, promise instanceof Promise));
+		setTimeout(() => { throw new Error('timer'); }, 20);
+		Promise.reject(new Error('rejected'));
+		Promise.reject(new Error('handled later')).catch(() => {});
+		throw new Error('sync');
+	`)
+	for _, want := range []string{"caught sync uncaughtException", "caught timer uncaughtException", "unhandled rejected true"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Missing %q in output:\n%s", want, stdout)
+		}
+	}
+	if strings.Contains(stdout, "handled later") || stderr != "" {
+		t.Errorf("Unexpected output:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+
+	// 没有监听器时输出到标准错误
+	_, _, stderr = runWithOutput(t, `
+		Promise.reject(new Error('plain rejection'));
+		setTimeout(() => { throw new Error('plain exception'); }, 20);
+	`)
+	if !strings.Contains(stderr, "Unhandled promise rejection: Error: plain rejection") ||
+		!strings.Contains(stderr, "Uncaught exception: Error: plain exception") {
+		t.Errorf("Unexpected stderr:\n%s", stderr)
+	}
+}
+
+func TestProcessListenerMethods(t *testing.T) {
+	runner, _, _ := runWithOutput(t, `
+		const calls = [];
+		const log = (v) => calls.push('on:' + v);
+		process.once('custom', (v) => calls.push('once:' + v))
+			.on('custom', log)
+			.prependListener('custom', (v) => calls.push('first:' + v));
+		const count = process.listenerCount('custom');
+		process.emit('custom', 1);
+		process.off('custom', log);
+		const emitted = process.emit('custom', 2);
+		const none = process.emit('missing');
+		process.removeAllListeners('custom');
+		globalThis.result = [calls.join(','), count, emitted, none, process.listeners('custom').length].join('|');
+	`)
+	want := "first:1,once:1,on:1,first:2|3|true|false|0"
+	if got := runner.GetValue("result").String(); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestProcessEmitterShared(t *testing.T) {
+	// 全局 process、require('process') 与其 process 属性共享同一组监听器
+	runner, _, _ := runWithOutput(t, `
+		const { EventEmitter } = require('events');
+		const mod = require('process');
+		const calls = [];
+		mod.on('custom', (v) => calls.push('module:' + v));
+		mod.process.once('custom', (v) => calls.push('process:' + v));
+		process.emit('custom', 1);
+		process.emit('custom', 2);
+		globalThis.result = [calls.join(','), process instanceof EventEmitter, process.eventNames().join(',')].join('|');
+	`)
+	want := "module:1,process:1,module:2|true|custom"
+	if got := runner.GetValue("result").String(); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestProcessSignalEvent(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("signals are not supported on windows")
+	}
+	_, stdout, _ := runWithOutput(t, `
+		const timer = setInterval(() => {}, 1000);
+		process.on('SIGTERM', (signal) => {
+			console.log('received', signal);
+			clearInterval(timer);
+		});
+		setTimeout(() => process.kill(process.pid, 'SIGTERM'), 50);
+	`)
+	if stdout != "received SIGTERM\n" {
+		t.Errorf("Unexpected output: %q", stdout)
+	}
+}
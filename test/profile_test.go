@@ -0,0 +1,194 @@
+package test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/issueye/sw_runtime/internal/profiler"
+	"github.com/issueye/sw_runtime/internal/runtime"
+	"github.com/issueye/sw_runtime/internal/security"
+)
+
+// pprofContains 解压 pprof 数据并检查字符串表中是否包含 name
+func pprofContains(t *testing.T, data []byte, name string) bool {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Profile is not gzip data: %v", err)
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("Failed to decompress profile: %v", err)
+	}
+	return bytes.Contains(raw, []byte(name))
+}
+
+func TestCPUProfile(t *testing.T) {
+	runner, err := runtime.New()
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	defer runner.Close()
+
+	if err := profiler.StartCPU(); err != nil {
+		t.Fatalf("StartCPU failed: %v", err)
+	}
+	if err := profiler.StartCPU(); err == nil {
+		t.Error("Second StartCPU should fail")
+	}
+	if !profiler.CPUActive() {
+		t.Error("Profiler should be active")
+	}
+
+	err = runner.RunCode(`
+		function busyProfiledWork(n) { let s = 0; for (let i = 0; i < n; i++) s += Math.sqrt(i); return s; }
+		const until = Date.now() + 300;
+		while (Date.now() < until) busyProfiledWork(1e4);
+	`)
+	data, stopErr := profiler.StopCPU()
+	if err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+	if stopErr != nil {
+		t.Fatalf("StopCPU failed: %v", stopErr)
+	}
+	if !pprofContains(t, data, "busyProfiledWork") {
+		t.Error("Profile should contain the JS function name")
+	}
+	if _, err := profiler.StopCPU(); err != profiler.ErrNotActive {
+		t.Errorf("Expected ErrNotActive, got %v", err)
+	}
+}
+
+func TestProcessProfile(t *testing.T) {
+	runner, dir := newRestrictedRunner(t, nil)
+
+	err := runner.RunCode(`
+		process.profile.start();
+		globalThis.active = process.profile.isActive();
+		function spin() { let s = 0; for (let i = 0; i < 1e4; i++) s += i; return s; }
+		// 采样分析器按固定间隔采样，运行足够长的时间确保 spin 被采到
+		const until = Date.now() + 300;
+		while (Date.now() < until) spin();
+		globalThis.file = process.profile.stop(__dirname + '/cpu.pprof');
+		globalThis.inactive = !process.profile.isActive();
+
+		process.profile.start();
+		globalThis.bytes = process.profile.stop().byteLength;
+		try {
+			process.profile.stop();
+		} catch (e) {
+			globalThis.stopError = e.message;
+		}
+	`)
+	if err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+
+	if !runner.GetValue("active").ToBoolean() || !runner.GetValue("inactive").ToBoolean() {
+		t.Error("isActive should follow start/stop")
+	}
+	path := filepath.Join(dir, "cpu.pprof")
+	if got := runner.GetValue("file").String(); got != path {
+		t.Errorf("Expected %s, got %s", path, got)
+	}
+	if data, err := os.ReadFile(path); err != nil || !pprofContains(t, data, "spin") {
+		t.Errorf("Profile file should contain spin: %v", err)
+	}
+	if runner.GetValue("bytes").ToInteger() == 0 {
+		t.Error("stop() without file should return profile data")
+	}
+	if got := runner.GetValue("stopError"); got == nil || got.String() != profiler.ErrNotActive.Error() {
+		t.Errorf("Expected not active error, got %v", got)
+	}
+}
+
+func TestProcessProfilePermission(t *testing.T) {
+	runner, dir := newRestrictedRunner(t, &security.Permissions{})
+	defer profiler.StopCPU()
+
+	err := runner.RunCode(`
+		process.profile.start();
+		try {
+			process.profile.stop(__dirname + '/cpu.pprof');
+		} catch (e) {
+			globalThis.name = e.name;
+		}
+		globalThis.stillActive = process.profile.isActive();
+	`)
+	if err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+	if got := runner.GetValue("name"); got == nil || got.String() != "PermissionDenied" {
+		t.Errorf("Expected PermissionDenied, got %v", got)
+	}
+	if !runner.GetValue("stillActive").ToBoolean() {
+		t.Error("Denied stop should keep sampling")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "cpu.pprof")); !os.IsNotExist(err) {
+		t.Error("File should not be written")
+	}
+}
+
+func TestHeapSummary(t *testing.T) {
+	dir := t.TempDir()
+	module := `
+		class Cached { }
+		module.exports = { items: [new Cached(), new Cached()] };
+	`
+	if err := os.WriteFile(filepath.Join(dir, "cache.js"), []byte(module), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	runner, err := runtime.NewWithWorkingDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	defer runner.Close()
+
+	err = runner.RunCode(`
+		require(__dirname + '/cache.js');
+		class Point { constructor(x) { this.x = x; } }
+		class Key { }
+		globalThis.points = [];
+		for (let i = 0; i < 100; i++) points.push(new Point(i));
+		globalThis.index = new Map([[new Key(), new Point(-1)]]);
+		Object.defineProperty(globalThis, 'lazy', { get() { throw new Error('getter called'); } });
+
+		const top = process.profile.heapSummary(2);
+		globalThis.topNames = top.constructors.map(c => c.name + ':' + c.count);
+		globalThis.total = top.objects;
+	`)
+	if err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+
+	summary := runner.HeapSummary()
+	counts := make(map[string]int)
+	for _, c := range summary.Constructors {
+		counts[c.Name] = c.Count
+	}
+	if counts["Point"] != 101 || counts["Key"] != 1 || counts["Cached"] != 2 || counts["Map"] != 1 {
+		t.Errorf("Unexpected counts: %v", counts)
+	}
+	if summary.Objects < 104 {
+		t.Errorf("Unexpected total: %d", summary.Objects)
+	}
+	for i := 1; i < len(summary.Constructors); i++ {
+		if summary.Constructors[i].Count > summary.Constructors[i-1].Count {
+			t.Fatalf("Constructors should be sorted by count: %v", summary.Constructors)
+		}
+	}
+
+	top := runner.GetValue("topNames").Export().([]interface{})
+	if len(top) != 2 || top[1] != "Point:101" {
+		t.Errorf("Unexpected top constructors: %v", top)
+	}
+	if runner.GetValue("total").ToInteger() < 104 {
+		t.Errorf("Unexpected JS total: %v", runner.GetValue("total"))
+	}
+}
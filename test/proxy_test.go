@@ -4,8 +4,8 @@ import (
 	"testing"
 	"time"
 
-	"sw_runtime/internal/builtins/net"
-	"sw_runtime/internal/runtime"
+	"github.com/issueye/sw_runtime/internal/builtins/net"
+	"github.com/issueye/sw_runtime/internal/runtime"
 
 	"github.com/dop251/goja"
 )
@@ -13,7 +13,7 @@ import (
 // TestProxyModuleCreation 测试代理模块创建
 func TestProxyModuleCreation(t *testing.T) {
 	vm := goja.New()
-	proxyModule := net.NewProxyModule(vm)
+	proxyModule := net.NewProxyModule(vm, nil)
 
 	if proxyModule == nil {
 		t.Fatal("Failed to create proxy module")
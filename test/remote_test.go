@@ -0,0 +1,229 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/issueye/sw_runtime/internal/bundler"
+	"github.com/issueye/sw_runtime/internal/importmap"
+	"github.com/issueye/sw_runtime/internal/remote"
+	"github.com/issueye/sw_runtime/internal/runtime"
+)
+
+// remoteServer 提供远程模块的测试服务器，记录请求次数，内容可在测试中修改
+type remoteServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	files    map[string]string
+	requests atomic.Int32
+}
+
+func newRemoteServer(t *testing.T) *remoteServer {
+	s := &remoteServer{files: map[string]string{
+		"/lib/mod.ts": `
+			import { helper } from './helper.ts';
+			export const greet = (name: string): string => helper() + ' ' + name;
+			export const url = import.meta.url;
+		`,
+		"/lib/helper.ts": `export function helper(): string { return 'hello'; }`,
+		"/lib/cjs.js": `
+			const { greet } = require('./mod.ts');
+			module.exports = { message: greet('cjs'), dir: __dirname };
+		`,
+	}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		s.mu.Lock()
+		content, ok := s.files[r.URL.Path]
+		s.mu.Unlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *remoteServer) set(path, content string) {
+	s.mu.Lock()
+	s.files[path] = content
+	s.mu.Unlock()
+}
+
+// writeRemoteProject 创建导入远程模块的项目
+func writeRemoteProject(t *testing.T, serverURL string) string {
+	dir := t.TempDir()
+	main := `
+		import { greet, url } from '` + serverURL + `/lib/mod.ts';
+		const cjs = require('` + serverURL + `/lib/cjs.js');
+		(globalThis as any).result = [greet('world'), cjs.message, cjs.dir === '` + serverURL + `/lib', url].join('|');
+	`
+	if err := os.WriteFile(filepath.Join(dir, "main.ts"), []byte(main), 0644); err != nil {
+		t.Fatalf("Failed to write main.ts: %v", err)
+	}
+	return dir
+}
+
+// runRemote 使用给定的加载器运行入口文件，返回 result 全局变量
+func runRemote(t *testing.T, dir string, loader *remote.Loader) (string, error) {
+	runner := runtime.NewOrPanicWithWorkingDir(dir)
+	defer runner.Close()
+	runner.SetRemoteLoader(loader)
+	if err := runner.RunFile(filepath.Join(dir, "main.ts")); err != nil {
+		return "", err
+	}
+	return runner.GetValue("result").String(), nil
+}
+
+func TestRemoteModuleRuntime(t *testing.T) {
+	server := newRemoteServer(t)
+	dir := writeRemoteProject(t, server.URL)
+	cacheDir := t.TempDir()
+	lockFile := filepath.Join(dir, remote.LockFileName)
+	expected := "hello world|hello cjs|true|" + server.URL + "/lib/mod.ts"
+
+	result, err := runRemote(t, dir, remote.New(remote.Options{CacheDir: cacheDir, LockFile: lockFile, AllowPrivate: true}))
+	if err != nil {
+		t.Fatalf("RunFile failed: %v", err)
+	}
+	if result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+	if got := server.requests.Load(); got != 3 {
+		t.Errorf("Expected 3 requests, got %d", got)
+	}
+
+	// sw.lock 记录每个远程模块的 SHA-256
+	lock, err := os.ReadFile(lockFile)
+	if err != nil {
+		t.Fatalf("Expected sw.lock to be written: %v", err)
+	}
+	helperSum := remote.Integrity([]byte(`export function helper(): string { return 'hello'; }`))
+	if !strings.Contains(string(lock), helperSum) {
+		t.Errorf("sw.lock should contain %s, got %s", helperSum, lock)
+	}
+
+	// 离线模式直接使用磁盘缓存
+	result, err = runRemote(t, dir, remote.New(remote.Options{CacheDir: cacheDir, LockFile: lockFile, Offline: true, AllowPrivate: true}))
+	if err != nil || result != expected {
+		t.Errorf("Offline run failed: %q, %v", result, err)
+	}
+	if got := server.requests.Load(); got != 3 {
+		t.Errorf("Offline run should not fetch, got %d requests", got)
+	}
+
+	// 内容变化后 --reload 重新下载，完整性与 sw.lock 不一致时报错
+	server.set("/lib/helper.ts", `export function helper(): string { return 'changed'; }`)
+	_, err = runRemote(t, dir, remote.New(remote.Options{CacheDir: cacheDir, LockFile: lockFile, Reload: true, AllowPrivate: true}))
+	if err == nil || !strings.Contains(err.Error(), "integrity check failed") {
+		t.Errorf("Expected integrity error, got %v", err)
+	}
+
+	// --lock-write 接受新内容并更新 sw.lock
+	result, err = runRemote(t, dir, remote.New(remote.Options{CacheDir: cacheDir, LockFile: lockFile, Reload: true, LockWrite: true, AllowPrivate: true}))
+	if err != nil || !strings.HasPrefix(result, "changed world|changed cjs") {
+		t.Errorf("Lock write run failed: %q, %v", result, err)
+	}
+	lock, _ = os.ReadFile(lockFile)
+	if strings.Contains(string(lock), helperSum) {
+		t.Error("sw.lock should be updated with --lock-write")
+	}
+}
+
+func TestRemoteModuleErrors(t *testing.T) {
+	server := newRemoteServer(t)
+	dir := writeRemoteProject(t, server.URL)
+
+	// 默认与 fetch 一样不允许访问内网和本机地址
+	_, err := runRemote(t, dir, remote.New(remote.Options{CacheDir: t.TempDir()}))
+	if err == nil || !strings.Contains(err.Error(), "private network") {
+		t.Errorf("Expected private network error, got %v", err)
+	}
+
+	// 离线模式下未缓存的模块直接报错，不发起请求
+	before := server.requests.Load()
+	_, err = runRemote(t, dir, remote.New(remote.Options{CacheDir: t.TempDir(), Offline: true, AllowPrivate: true}))
+	if err == nil || !strings.Contains(err.Error(), "not cached (offline mode)") {
+		t.Errorf("Expected offline error, got %v", err)
+	}
+	if server.requests.Load() != before {
+		t.Error("Offline mode should not fetch")
+	}
+
+	// 远程模块只能通过 URL 导入其他模块
+	server.set("/lib/local.js", `require('../../secret.js'); require('lodash');`)
+	runner := runtime.NewOrPanicWithWorkingDir(dir)
+	defer runner.Close()
+	runner.SetRemoteLoader(remote.New(remote.Options{CacheDir: t.TempDir(), AllowPrivate: true}))
+	err = runner.RunCode(`require('` + server.URL + `/lib/local.js')`)
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected relative import to resolve against the module URL, got %v", err)
+	}
+	server.set("/lib/local.js", `require('lodash');`)
+	runner.SetRemoteLoader(remote.New(remote.Options{CacheDir: t.TempDir(), AllowPrivate: true}))
+	err = runner.RunCode(`require('` + server.URL + `/lib/local.js?v=2')`)
+	if err == nil || !strings.Contains(err.Error(), "imported by remote module") {
+		t.Errorf("Expected bare specifier error, got %v", err)
+	}
+}
+
+func TestRemoteModuleImportMap(t *testing.T) {
+	server := newRemoteServer(t)
+	dir := t.TempDir()
+	m, err := importmap.Parse([]byte(`{ "imports": { "greeter": "`+server.URL+`/lib/mod.ts", "lib/": "`+server.URL+`/lib/" } }`), dir)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	runner := runtime.NewOrPanicWithWorkingDir(dir)
+	defer runner.Close()
+	runner.SetImportMap(m)
+	runner.SetRemoteLoader(remote.New(remote.Options{CacheDir: t.TempDir(), AllowPrivate: true}))
+	code := `
+		const { greet } = require('greeter');
+		globalThis.result = greet('map') + '|' + require('lib/cjs.js').message;
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+	if got := runner.GetValue("result").String(); got != "hello map|hello cjs" {
+		t.Errorf("Unexpected result: %q", got)
+	}
+}
+
+func TestRemoteModuleBundle(t *testing.T) {
+	server := newRemoteServer(t)
+	dir := writeRemoteProject(t, server.URL)
+
+	b := bundler.New(bundler.Options{
+		EntryFile:  filepath.Join(dir, "main.ts"),
+		OutputFile: filepath.Join(dir, "bundle.js"),
+		Remote:     remote.New(remote.Options{CacheDir: t.TempDir(), AllowPrivate: true}),
+	})
+	result, err := b.Bundle()
+	if err != nil {
+		t.Fatalf("Bundle failed: %v", err)
+	}
+	if modules := strings.Join(result.Modules, ","); !strings.Contains(modules, server.URL+"/lib/helper.ts") {
+		t.Errorf("Bundle should contain remote modules, got %s", modules)
+	}
+
+	// 远程依赖已内联，运行打包结果不再访问服务器
+	server.Close()
+	runner := runtime.NewOrPanicWithWorkingDir(t.TempDir())
+	defer runner.Close()
+	if err := runner.RunCode(result.Code); err != nil {
+		t.Fatalf("Running bundle failed: %v", err)
+	}
+	if got := runner.GetValue("result").String(); !strings.HasPrefix(got, "hello world|hello cjs") {
+		t.Errorf("Unexpected bundle result: %q", got)
+	}
+}
@@ -0,0 +1,171 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/issueye/sw_runtime/internal/repl"
+	"github.com/issueye/sw_runtime/internal/runtime"
+)
+
+// newREPLSession 创建 REPL 会话
+func newREPLSession(t *testing.T) *runtime.REPL {
+	t.Helper()
+	runner, err := runtime.New()
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	t.Cleanup(runner.Close)
+	return runner.NewREPL()
+}
+
+func TestREPLEval(t *testing.T) {
+	session := newREPLSession(t)
+
+	steps := []struct {
+		input string
+		want  string
+	}{
+		{"1 + 2", "3"},
+		{"const n: number = 20", "undefined"},
+		{"interface User { name: string }\nconst user: User = { name: 'sw' }", "undefined"},
+		{"user", "{ name: 'sw' }"},
+		{"const v = await new Promise(r => setTimeout(() => r(22), 10))", "undefined"},
+		{"n + v", "42"},
+		{"_ * 2", "84"},
+		{"class Point { x = 1 }\nconst p = await Promise.resolve(new Point())", "undefined"},
+		{"p", "Point { x: 1 }"},
+		{"async function twice(x) { return x * 2 }\nawait twice(n)", "40"},
+		{"let { a, b } = await Promise.resolve({ a: 1, b: 2 }); a + b", "3"},
+		{"new Map([['k', [1, 'two']]])", "Map(1) { 'k' => [ 1, 'two' ] }"},
+	}
+	for _, step := range steps {
+		got, err := session.Eval(step.input)
+		if err != nil {
+			t.Fatalf("Eval(%q) failed: %v", step.input, err)
+		}
+		if got != step.want {
+			t.Errorf("Eval(%q) = %q, want %q", step.input, got, step.want)
+		}
+	}
+}
+
+func TestREPLEvalErrors(t *testing.T) {
+	session := newREPLSession(t)
+
+	for _, input := range []string{"function f() {", "const s = `abc", "[1, 2,"} {
+		if _, err := session.Eval(input); !errors.Is(err, runtime.ErrIncompleteInput) {
+			t.Errorf("Eval(%q): expected ErrIncompleteInput, got %v", input, err)
+		}
+	}
+
+	_, err := session.Eval("throw new TypeError('bad value')")
+	var evalErr *runtime.EvalError
+	if !errors.As(err, &evalErr) || !strings.HasPrefix(err.Error(), "Uncaught TypeError: bad value") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if got, _ := session.Eval("_error.message"); got != "'bad value'" {
+		t.Errorf("Expected _error to hold the thrown error, got %s", got)
+	}
+
+	if _, err := session.Eval("await Promise.reject(42)"); err == nil || err.Error() != "Uncaught 42" {
+		t.Errorf("Expected rejected await error, got %v", err)
+	}
+
+	if _, err := session.Eval("let = ;"); !errors.As(err, &evalErr) || !strings.Contains(err.Error(), "SyntaxError") {
+		t.Errorf("Expected syntax error, got %v", err)
+	}
+
+	// 中断死循环后会话仍可继续使用
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		session.Interrupt()
+	}()
+	if _, err := session.Eval("while (true) {}"); !errors.Is(err, runtime.ErrInterrupted) {
+		t.Fatalf("Expected ErrInterrupted, got %v", err)
+	}
+	if got, err := session.Eval("'still alive'"); err != nil || got != "'still alive'" {
+		t.Errorf("Session unusable after interrupt: %q %v", got, err)
+	}
+}
+
+func TestREPLComplete(t *testing.T) {
+	session := newREPLSession(t)
+	if _, err := session.Eval("const config = { port: 8080, path: '/' }"); err != nil {
+		t.Fatal(err)
+	}
+
+	candidates, prefix := session.Complete("JSON.str")
+	if prefix != "str" || len(candidates) != 1 || candidates[0] != "stringify" {
+		t.Errorf("Unexpected completion: %v %q", candidates, prefix)
+	}
+
+	candidates, prefix = session.Complete("console.log(config.p")
+	if prefix != "p" || !strings.HasPrefix(strings.Join(candidates, ","), "path,port,") {
+		t.Errorf("Unexpected completion: %v %q", candidates, prefix)
+	}
+
+	candidates, _ = session.Complete("setTime")
+	if len(candidates) != 1 || candidates[0] != "setTimeout" {
+		t.Errorf("Unexpected global completion: %v", candidates)
+	}
+
+	if candidates, _ := session.Complete("missing.x"); len(candidates) != 0 {
+		t.Errorf("Expected no completion, got %v", candidates)
+	}
+}
+
+func TestREPLRunPiped(t *testing.T) {
+	dir := t.TempDir()
+	lib := filepath.Join(dir, "lib.js")
+	if err := os.WriteFile(lib, []byte("function greet(name) { return 'hi ' + name }"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	saved := filepath.Join(dir, "session.js")
+
+	input := strings.Join([]string{
+		".load " + lib,
+		"const items = [",
+		"  1, 2, 3",
+		"]",
+		"greet('repl') + items.length",
+		".save " + saved,
+		".exit",
+		"'not evaluated'",
+	}, "\n")
+	in := filepath.Join(dir, "input.txt")
+	if err := os.WriteFile(in, []byte(input), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var out bytes.Buffer
+	if err := repl.Run(newREPLSession(t), repl.Options{In: f, Out: &out}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	output := out.String()
+	if !strings.Contains(output, "'hi repl3'") {
+		t.Errorf("Missing result in output:\n%s", output)
+	}
+	if strings.Contains(output, "not evaluated") {
+		t.Errorf("Input after .exit was evaluated:\n%s", output)
+	}
+
+	content, err := os.ReadFile(saved)
+	if err != nil {
+		t.Fatalf("Session not saved: %v", err)
+	}
+	if !strings.Contains(string(content), "function greet") || !strings.Contains(string(content), "const items = [\n  1, 2, 3\n]") {
+		t.Errorf("Unexpected saved session:\n%s", content)
+	}
+}
@@ -0,0 +1,125 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
+)
+
+// writeRequireProject 创建检查 CommonJS 模块语义的项目
+func writeRequireProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeModuleFiles(t, dir, map[string]string{
+		"lib/child.js": `
+			exports.isMain = require.main === module;
+			exports.parentIsMain = module.parent === require.main;
+			exports.file = __filename;
+			exports.dir = __dirname;
+		`,
+		"lib/counter.js":                `globalThis.loads = (globalThis.loads || 0) + 1; exports.n = globalThis.loads;`,
+		"lib/node_modules/dep/index.js": `module.exports = 'dep';`,
+		"other/target.js":               `module.exports = 'other';`,
+		"main.js": `
+			const child = require('./lib/child');
+			const first = require('./lib/counter').n;
+			delete require.cache[require.resolve('./lib/counter')];
+			const second = require('./lib/counter').n;
+			globalThis.result = {
+				isMain: require.main === module,
+				id: module.id,
+				file: __filename,
+				dir: __dirname,
+				child: [child.isMain, child.parentIsMain, child.file, child.dir],
+				children: module.children.map(m => m.filename),
+				childObject: module.children[0] === require.cache[require.resolve('./lib/child')],
+				reload: [first, second],
+				resolved: require.resolve('./target', { paths: ['./other'] }),
+				builtin: require.resolve('fs'),
+				dep: require.resolve('dep', { paths: [__dirname + '/lib'] }),
+				paths: module.paths[0],
+				cached: Object.keys(require.cache).length,
+			};
+		`,
+		"main.mjs": `
+			import './lib/child.js';
+			globalThis.esmMain = require.main === module && module.id === '.';
+		`,
+	})
+	return dir
+}
+
+func TestRequireSemantics(t *testing.T) {
+	dir := writeRequireProject(t)
+	runner := runtime.NewOrPanicWithWorkingDir(dir)
+	defer runner.Close()
+
+	if err := runner.RunFile(filepath.Join(dir, "main.js")); err != nil {
+		t.Fatalf("RunFile failed: %v", err)
+	}
+	result := runner.GetValue("result").Export().(map[string]interface{})
+
+	child := result["child"].([]interface{})
+	checks := map[string][2]interface{}{
+		"require.main === module": {result["isMain"], true},
+		"module.id":               {result["id"], "."},
+		"__filename":              {result["file"], filepath.Join(dir, "main.js")},
+		"__dirname":               {result["dir"], dir},
+		"child isMain":            {child[0], false},
+		"child parent":            {child[1], true},
+		"child __filename":        {child[2], filepath.Join(dir, "lib", "child.js")},
+		"child __dirname":         {child[3], filepath.Join(dir, "lib")},
+		"children identity":       {result["childObject"], true},
+		"resolve paths":           {result["resolved"], filepath.Join(dir, "other", "target.js")},
+		"resolve builtin":         {result["builtin"], "fs"},
+		"resolve node_modules":    {result["dep"], filepath.Join(dir, "lib", "node_modules", "dep", "index.js")},
+		"module.paths":            {result["paths"], filepath.Join(dir, "node_modules")},
+		"require.cache size":      {result["cached"], int64(3)},
+	}
+	for name, check := range checks {
+		if check[0] != check[1] {
+			t.Errorf("%s: expected %v, got %v", name, check[1], check[0])
+		}
+	}
+
+	children := result["children"].([]interface{})
+	if len(children) != 2 || children[0] != filepath.Join(dir, "lib", "child.js") {
+		t.Errorf("Unexpected module.children: %v", children)
+	}
+
+	// 删除 require.cache 中的条目后重新加载模块
+	reload := result["reload"].([]interface{})
+	if reload[0] != int64(1) || reload[1] != int64(2) {
+		t.Errorf("Expected module to be reloaded, got %v", reload)
+	}
+}
+
+func TestRequireMainESMAndInline(t *testing.T) {
+	dir := writeRequireProject(t)
+	runner := runtime.NewOrPanicWithWorkingDir(dir)
+	defer runner.Close()
+
+	if err := runner.RunFile(filepath.Join(dir, "main.mjs")); err != nil {
+		t.Fatalf("RunFile failed: %v", err)
+	}
+	if !runner.GetValue("esmMain").ToBoolean() {
+		t.Error("ES module entry should be require.main")
+	}
+
+	// 内联代码没有 module 与 require.main，require.resolve 以工作目录为基准，未找到时抛出错误
+	inline := runtime.NewOrPanicWithWorkingDir(dir)
+	defer inline.Close()
+	code := `
+		let error = '';
+		try { require.resolve('missing'); } catch (e) { error = e.message; }
+		globalThis.result = [typeof module, String(require.main), require.resolve('./lib/child'), error].join('|');
+	`
+	if err := inline.RunCode(code); err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+	expected := "undefined|undefined|" + filepath.Join(dir, "lib", "child.js") + "|module not found: missing"
+	if got := inline.GetValue("result").String(); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,214 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
+
+	"github.com/dop251/goja"
+)
+
+// freePort 返回一个当前未被占用的本地端口
+func freePort(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return fmt.Sprint(ln.Addr().(*net.TCPAddr).Port)
+}
+
+func TestRunnerPoolFullReset(t *testing.T) {
+	pool := runtime.NewRunnerPoolWithMode(runtime.ResetFull)
+	runner := pool.Acquire()
+
+	var ticks atomic.Int32
+	runner.SetValue("tick", func() { ticks.Add(1) })
+	port := freePort(t)
+
+	// 通过 Do 执行，脚本的定时器和服务器保持运行
+	err := runner.Do(context.Background(), func(vm *goja.Runtime) error {
+		_, err := vm.RunString(`
+			globalThis.leaked = 'secret';
+			var declared = 1;
+			function helper() {}
+			Array.prototype.evil = () => 'evil';
+			Object.prototype.polluted = true;
+			JSON.stringify = () => 'hijacked';
+			setInterval(tick, 10);
+			setTimeout(tick, 20);
+			var { server } = require('http');
+			var app = server.createServer();
+			app.get('/', (req, res) => res.send('ok'));
+			app.listen('` + port + `');
+			require('http/server').shared.set('session', { user: 'alice' });
+			process.on('custom', tick);
+			process.exitCode = 3;
+		`)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Script failed: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		conn, err := net.Dial("tcp", "127.0.0.1:"+port)
+		if err == nil {
+			conn.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Server did not start: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	pool.Release(runner)
+	if stats := pool.Stats(); stats.Resets != 1 || stats.ResetFailures != 0 {
+		t.Fatalf("Unexpected stats after reset: %+v", stats)
+	}
+
+	// 定时器已取消，服务器已关闭
+	before := ticks.Load()
+	time.Sleep(50 * time.Millisecond)
+	if after := ticks.Load(); after != before {
+		t.Errorf("Timers still running after reset: %d -> %d", before, after)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:"+port)
+	if err != nil {
+		t.Errorf("Port still in use after reset: %v", err)
+	} else {
+		ln.Close()
+	}
+
+	next := pool.Acquire()
+	defer pool.Release(next)
+	err = next.RunCode(`
+		globalThis.state = [
+			typeof leaked, typeof declared, typeof helper, typeof tick,
+			typeof [].evil, typeof ({}).polluted, JSON.stringify({ a: 1 }),
+			typeof require('http/server').shared.get('session'),
+			process.listenerCount('custom'), typeof process.exitCode,
+		].join(',');
+	`)
+	if err != nil {
+		t.Fatalf("Pooled runner unusable: %v", err)
+	}
+	if state := next.GetValue("state").String(); state != `undefined,undefined,undefined,undefined,undefined,undefined,{"a":1},undefined,0,undefined` {
+		t.Errorf("Global state leaked across uses: %s", state)
+	}
+
+	stats := pool.Stats()
+	if stats.Hits+stats.Creates != 2 {
+		t.Errorf("Expected 2 acquisitions, got %+v", stats)
+	}
+}
+
+func TestRunnerPoolLexicalReset(t *testing.T) {
+	pool := runtime.NewRunnerPoolWithMode(runtime.ResetFull)
+	defer pool.Close()
+	runner := pool.Acquire()
+	code := `
+		const { a, b: [c] } = { a: 1, b: [2] };
+		class Config {}
+		function helper() { return a + c; }
+		globalThis.result = helper();
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("Script failed: %v", err)
+	}
+	if got := runner.GetValue("result").ToInteger(); got != 3 {
+		t.Errorf("Expected 3, got %d", got)
+	}
+
+	// 顶层 let/const/class 声明在块作用域中，重置后同一个 Runner 可以再次执行相同的声明
+	pool.Release(runner)
+	if stats := pool.Stats(); stats.Resets != 1 || stats.ResetFailures != 0 || stats.Idle != 1 {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+	next := pool.Acquire()
+	defer pool.Release(next)
+	if next != runner {
+		t.Fatal("Expected the reset runner to be reused")
+	}
+	if err := next.RunCode(`'use strict'; const a = 2; globalThis.result = [typeof Config, typeof helper, a].join(',');`); err != nil {
+		t.Fatalf("Reused runner failed: %v", err)
+	}
+	if got := next.GetValue("result").String(); got != "undefined,undefined,2" {
+		t.Errorf("Declarations leaked across uses: %s", got)
+	}
+}
+
+func TestRunnerPoolResetFailure(t *testing.T) {
+	// 不是由 ResetFull 池创建的 Runner 中，顶层声明留在全局词法环境里，无法重置
+	runner := runtime.NewOrPanic()
+	if err := runner.RunCode(`const { a, b: [c] } = { a: 1, b: [2] }; class Config {}`); err != nil {
+		t.Fatalf("Script failed: %v", err)
+	}
+	err := runner.Reset()
+	if err == nil || !strings.Contains(err.Error(), "a, c, Config") {
+		t.Errorf("Expected lexical declaration error, got %v", err)
+	}
+
+	// 重置失败的 Runner 被关闭而不放回池中
+	pool := runtime.NewRunnerPoolWithMode(runtime.ResetFull)
+	defer pool.Close()
+	pool.Release(runner)
+	if stats := pool.Stats(); stats.Resets != 0 || stats.ResetFailures != 1 || stats.Idle != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestRunnerPoolSize(t *testing.T) {
+	pool := runtime.NewRunnerPoolWithSize(runtime.ResetModuleCache, 1)
+	first, second := pool.Acquire(), pool.Acquire()
+	for _, r := range []*runtime.Runner{first, second} {
+		if err := r.RunCode(`globalThis.used = true`); err != nil {
+			t.Fatalf("Script failed: %v", err)
+		}
+	}
+	pool.Release(first)
+	pool.Release(second)
+
+	// 超出容量的 Runner 被关闭
+	if stats := pool.Stats(); stats.Creates != 2 || stats.Idle != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if err := second.Do(context.Background(), func(*goja.Runtime) error { return nil }); err != runtime.ErrLoopStopped {
+		t.Error("Runner released to a full pool should be closed")
+	}
+
+	// 关闭池时关闭空闲 Runner，之后归还的 Runner 也会被关闭
+	pool.Close()
+	if stats := pool.Stats(); stats.Idle != 0 {
+		t.Errorf("Expected no idle runners after Close, got %+v", stats)
+	}
+	if err := first.Do(context.Background(), func(*goja.Runtime) error { return nil }); err != runtime.ErrLoopStopped {
+		t.Error("Idle runner should be closed with the pool")
+	}
+}
+
+func TestRunnerPoolModuleCacheMode(t *testing.T) {
+	pool := runtime.NewRunnerPool()
+	runner := pool.Acquire()
+	if err := runner.RunCode(`globalThis.shared = 1`); err != nil {
+		t.Fatal(err)
+	}
+	pool.Release(runner)
+
+	// 默认模式不重置全局状态
+	if v := runner.GetValue("shared"); v == nil || v.ToInteger() != 1 {
+		t.Errorf("Default mode should keep globals, got %v", v)
+	}
+	if stats := pool.Stats(); stats.Creates != 1 || stats.Resets != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"testing"
 	"time"
 
-	"sw_runtime/internal/runtime"
+	"github.com/issueye/sw_runtime/internal/runtime"
 )
 
 func TestRunnerBasicFunctionality(t *testing.T) {
@@ -0,0 +1,184 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/issueye/sw_runtime/pkg/swruntime"
+
+	"github.com/dop251/goja"
+)
+
+// pluginResult 插件函数返回的结构
+type pluginResult struct {
+	Total int      `json:"total"`
+	Tags  []string `json:"tags"`
+	Owner struct {
+		Name string `json:"name"`
+	} `json:"owner"`
+}
+
+// newSDKRuntime 创建工作目录中包含 plugin.js 的 Runtime
+func newSDKRuntime(t *testing.T, plugin string, opts ...swruntime.Option) *swruntime.Runtime {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "plugin.js"), []byte(plugin), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rt, err := swruntime.New(append([]swruntime.Option{swruntime.WithWorkingDir(dir)}, opts...)...)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	t.Cleanup(rt.Close)
+	return rt
+}
+
+func TestSDKCallExports(t *testing.T) {
+	var stdout bytes.Buffer
+	rt := newSDKRuntime(t, `
+		const { greet } = require('host');
+		module.exports = {
+			prefix: 'sum',
+			sum(items) {
+				console.log(greet('plugin'));
+				return { total: items.reduce((a, b) => a + b, 0), tags: [this.prefix], owner: { name: 'js' } };
+			},
+			async later(ms) {
+				await new Promise(resolve => setTimeout(resolve, ms));
+				return ms * 2;
+			},
+			async fail() { throw new TypeError('bad input'); },
+		};
+	`,
+		swruntime.WithStdout(&stdout),
+		swruntime.WithArgv("host", "plugin.js", "--flag"),
+		swruntime.WithModule("host", func(vm *goja.Runtime) swruntime.Module {
+			return swruntime.ModuleFunc(func() *goja.Object {
+				obj := vm.NewObject()
+				obj.Set("greet", func(name string) string { return "hello " + name })
+				return obj
+			})
+		}),
+	)
+	ctx := context.Background()
+
+	plugin, err := rt.Require(ctx, "./plugin.js")
+	if err != nil {
+		t.Fatalf("Require failed: %v", err)
+	}
+
+	result, err := swruntime.Call[pluginResult](ctx, plugin, "sum", []int{1, 2, 3})
+	if err != nil {
+		t.Fatalf("Call sum failed: %v", err)
+	}
+	if result.Total != 6 || len(result.Tags) != 1 || result.Tags[0] != "sum" || result.Owner.Name != "js" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if stdout.String() != "hello plugin\n" {
+		t.Errorf("Unexpected stdout: %q", stdout.String())
+	}
+
+	doubled, err := swruntime.Call[int](ctx, plugin, "later", 20)
+	if err != nil || doubled != 40 {
+		t.Errorf("Expected 40, got %d (%v)", doubled, err)
+	}
+
+	_, err = swruntime.Call[int](ctx, plugin, "fail")
+	var rejected *swruntime.RejectedError
+	if !errors.As(err, &rejected) || !strings.Contains(err.Error(), "TypeError: bad input") {
+		t.Errorf("Expected RejectedError, got %v", err)
+	}
+	if err := plugin.Call(ctx, "missing"); err == nil {
+		t.Error("Calling missing function should fail")
+	}
+
+	var prefix string
+	if err := plugin.Get(ctx, "prefix", &prefix); err != nil || prefix != "sum" {
+		t.Errorf("Expected prefix, got %q (%v)", prefix, err)
+	}
+
+	if err := rt.RunCode(ctx, `globalThis.argv = require('process').process.argv.join(' ');`); err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+	var argv string
+	if err := rt.Global().Get(ctx, "argv", &argv); err != nil || argv != "host plugin.js --flag" {
+		t.Errorf("Unexpected argv %q (%v)", argv, err)
+	}
+}
+
+func TestSDKGlobalsAndOutput(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	rt := newSDKRuntime(t, ``,
+		swruntime.WithStdout(&stdout),
+		swruntime.WithStderr(&stderr),
+		swruntime.WithLogLevel(swruntime.LogLevelInfo),
+	)
+	ctx := context.Background()
+
+	if err := rt.Set("config", map[string]interface{}{"factor": 3}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	err := rt.RunCode(ctx, `
+		function scale(n) { return n * config.factor; }
+		console.debug('hidden');
+		const { process: proc } = require('process');
+		proc.stdout.write('out ');
+		proc.stderr.write('err ');
+		console.warn('warned');
+	`)
+	if err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+
+	n, err := swruntime.Call[float64](ctx, rt.Global(), "scale", 2.5)
+	if err != nil || n != 7.5 {
+		t.Errorf("Expected 7.5, got %v (%v)", n, err)
+	}
+	if stdout.String() != "out " {
+		t.Errorf("Unexpected stdout: %q", stdout.String())
+	}
+	if stderr.String() != "err warned\n" {
+		t.Errorf("Unexpected stderr: %q", stderr.String())
+	}
+	if ok, _ := rt.Global().Has(ctx, "scale"); !ok {
+		t.Error("scale should be defined")
+	}
+}
+
+func TestSDKPermissionsAndTimeout(t *testing.T) {
+	rt := newSDKRuntime(t, `
+		module.exports = {
+			read() { return require('fs').fs.readFileSync(__dirname + '/plugin.js', 'utf8'); },
+			spin() { for (;;) {} },
+		};
+	`, swruntime.WithPermissions(&swruntime.Permissions{}))
+	ctx := context.Background()
+
+	plugin, err := rt.Require(ctx, "./plugin.js")
+	if err != nil {
+		t.Fatalf("Require failed: %v", err)
+	}
+
+	_, err = swruntime.Call[string](ctx, plugin, "read")
+	var denied *swruntime.PermissionDeniedError
+	if !errors.As(err, &denied) {
+		t.Errorf("Expected PermissionDeniedError, got %v", err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	err = plugin.Call(timeoutCtx, "spin")
+	var timeoutErr *swruntime.TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("Expected TimeoutError, got %v", err)
+	}
+	if err := plugin.Call(ctx, "read"); !errors.Is(err, swruntime.ErrClosed) {
+		t.Errorf("Interrupted runtime should be closed, got %v", err)
+	}
+}
@@ -0,0 +1,264 @@
+package test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/issueye/sw_runtime/internal/bundler"
+	"github.com/issueye/sw_runtime/internal/modules"
+	"github.com/issueye/sw_runtime/internal/runtime"
+	"github.com/issueye/sw_runtime/internal/sourcemap"
+
+	"github.com/dop251/goja"
+)
+
+// writeSourceMapProject 创建测试用的 TypeScript 项目，lib.ts 第 5 行抛出异常
+func writeSourceMapProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"lib.ts": `// 类型声明会在转译后被删除，使生成代码的行号发生变化
+interface Options { factor: number }
+
+export function fail(value: number, opts: Options = { factor: 2 }): never {
+  throw new Error('failed with ' + value * opts.factor);
+}
+`,
+		"main.ts": `import { fail } from './lib';
+
+type Input = { value: number };
+const input: Input = { value: 21 };
+
+fail(input.value);
+`,
+		"script.ts": `const { fail } = require('./lib');
+
+type Input = { value: number };
+const input: Input = { value: 21 };
+
+fail(input.value);
+`,
+		"async.ts": `import { fail } from './lib';
+
+type Input = { value: number };
+const input: Input = await Promise.resolve({ value: 21 });
+
+fail(input.value);
+`,
+	}
+	writeModuleFiles(t, dir, files)
+	return dir
+}
+
+// assertMappedStack 检查堆栈指向原始 TypeScript 文件的位置
+func assertMappedStack(t *testing.T, stack string, locations ...string) {
+	t.Helper()
+	for _, location := range locations {
+		if !strings.Contains(stack, location) {
+			t.Errorf("Stack does not contain %q:\n%s", location, stack)
+		}
+	}
+}
+
+func TestSourceMapStackTrace(t *testing.T) {
+	dir := writeSourceMapProject(t)
+
+	for _, entry := range []string{"main.ts", "script.ts", "async.ts"} {
+		t.Run(entry, func(t *testing.T) {
+			runner, err := runtime.NewWithWorkingDir(dir)
+			if err != nil {
+				t.Fatalf("Failed to create runner: %v", err)
+			}
+			defer runner.Close()
+
+			err = runner.SafeRunFile(filepath.Join(dir, entry))
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+			stack := sourcemap.FormatError(err)
+			assertMappedStack(t, stack,
+				"failed with 42",
+				filepath.Join(dir, "lib.ts")+":5:",
+				filepath.Join(dir, entry)+":6:",
+			)
+		})
+	}
+}
+
+func TestSourceMapErrorStackProperty(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "stack.ts")
+	code := `type Result = { stack: string };
+
+function capture(): Result {
+  const err = new Error('captured');
+  return { stack: err.stack as string };
+}
+
+globalThis.captured = capture().stack;
+`
+	if err := os.WriteFile(script, []byte(code), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	runner, err := runtime.NewWithWorkingDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	defer runner.Close()
+
+	if err := runner.RunFile(script); err != nil {
+		t.Fatalf("RunFile failed: %v", err)
+	}
+	assertMappedStack(t, runner.GetValue("captured").String(), script+":4:", script+":8:")
+}
+
+func TestSourceMapModuleCache(t *testing.T) {
+	dir := writeSourceMapProject(t)
+
+	system := modules.NewSystem(goja.New(), dir)
+	defer system.Close()
+
+	module, err := system.LoadModule("./lib.ts", filepath.Join(dir, "index.js"))
+	if err != nil {
+		t.Fatalf("LoadModule failed: %v", err)
+	}
+
+	var sm struct {
+		Sources  []string `json:"sources"`
+		Mappings string   `json:"mappings"`
+	}
+	if err := json.Unmarshal([]byte(module.SourceMap), &sm); err != nil {
+		t.Fatalf("Invalid cached source map %q: %v", module.SourceMap, err)
+	}
+	if len(sm.Sources) != 1 || sm.Sources[0] != filepath.Join(dir, "lib.ts") || !strings.HasPrefix(sm.Mappings, ";") {
+		t.Errorf("Unexpected source map: %+v", sm)
+	}
+}
+
+func TestSourceMapBundle(t *testing.T) {
+	dir := writeSourceMapProject(t)
+	distDir := filepath.Join(dir, "dist")
+	if err := os.Mkdir(distDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(t *testing.T, bundleFile string) string {
+		t.Helper()
+		runner, err := runtime.NewWithWorkingDir(dir)
+		if err != nil {
+			t.Fatalf("Failed to create runner: %v", err)
+		}
+		defer runner.Close()
+		err = runner.RunFile(bundleFile)
+		if err == nil {
+			t.Fatal("Expected error")
+		}
+		return sourcemap.FormatError(err)
+	}
+
+	t.Run("inline", func(t *testing.T) {
+		output := filepath.Join(distDir, "inline.js")
+		result, err := bundler.New(bundler.Options{EntryFile: filepath.Join(dir, "main.ts"), OutputFile: output}).Bundle()
+		if err != nil {
+			t.Fatalf("Bundle failed: %v", err)
+		}
+		if result.Sourcemap != "" || sourcemap.Extract(result.Code) == "" {
+			t.Fatal("Expected inline source map only")
+		}
+		if strings.Contains(sourcemap.Extract(result.Code), "sourcesContent") {
+			t.Error("Inline source map should not embed sources")
+		}
+		if err := os.WriteFile(output, []byte(result.Code), 0644); err != nil {
+			t.Fatal(err)
+		}
+		assertMappedStack(t, run(t, output), filepath.Join(dir, "lib.ts")+":5:", filepath.Join(dir, "main.ts")+":6:")
+	})
+
+	t.Run("external", func(t *testing.T) {
+		output := filepath.Join(distDir, "external.js")
+		result, err := bundler.New(bundler.Options{EntryFile: filepath.Join(dir, "main.ts"), OutputFile: output, Sourcemap: true}).Bundle()
+		if err != nil {
+			t.Fatalf("Bundle failed: %v", err)
+		}
+		if !strings.Contains(result.Code, "//# sourceMappingURL=external.js.map") || !strings.Contains(result.Sourcemap, "sourcesContent") {
+			t.Fatalf("Expected linked external source map, got code tail %q", result.Code[max(0, len(result.Code)-60):])
+		}
+		if err := os.WriteFile(output, []byte(result.Code), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// 缺少 map 文件时仍可运行，只是位置不做映射
+		if stack := run(t, output); !strings.Contains(stack, "external.js:") {
+			t.Errorf("Expected unmapped position without map file:\n%s", stack)
+		}
+
+		if err := os.WriteFile(output+".map", []byte(result.Sourcemap), 0644); err != nil {
+			t.Fatal(err)
+		}
+		assertMappedStack(t, run(t, output), filepath.Join(dir, "lib.ts")+":5:", filepath.Join(dir, "main.ts")+":6:")
+	})
+}
+
+func TestSourceMapHelpers(t *testing.T) {
+	sm := `{"version":3,"sources":["src/app.ts","/abs/lib.ts"],"mappings":"AAAA"}`
+	code := "var a = 1;\n" + "//# sourceMappingURL=data:application/json;base64," +
+		base64.StdEncoding.EncodeToString([]byte(sm)) + "\n"
+
+	if got := sourcemap.Extract(code); got != sm {
+		t.Errorf("Extract = %q", got)
+	}
+	if got := sourcemap.Strip(code); got != "var a = 1;\n\n" {
+		t.Errorf("Strip = %q", got)
+	}
+	if got := sourcemap.Extract("var a = 1;"); got != "" {
+		t.Errorf("Expected no source map, got %q", got)
+	}
+
+	var shifted struct {
+		Mappings string `json:"mappings"`
+	}
+	json.Unmarshal([]byte(sourcemap.Extract(sourcemap.ShiftLines(code, 2))), &shifted)
+	if shifted.Mappings != ";;AAAA" {
+		t.Errorf("ShiftLines mappings = %q", shifted.Mappings)
+	}
+
+	var rebased struct {
+		Sources []string `json:"sources"`
+	}
+	dir := filepath.Join(string(filepath.Separator), "bundles")
+	json.Unmarshal([]byte(sourcemap.Extract(sourcemap.Rebase(code, dir))), &rebased)
+	if len(rebased.Sources) != 2 || rebased.Sources[0] != filepath.Join(dir, "src", "app.ts") || rebased.Sources[1] != "/abs/lib.ts" {
+		t.Errorf("Rebase sources = %v", rebased.Sources)
+	}
+
+	// 无效的 source map 不影响编译
+	broken := "throw new Error('x');\n//# sourceMappingURL=data:application/json;base64,bm90IGpzb24=\n"
+	if _, err := sourcemap.Compile("broken.js", broken, false); err != nil {
+		t.Errorf("Compile with invalid source map failed: %v", err)
+	}
+	if _, err := sourcemap.Compile("missing.js", "1;\n//# sourceMappingURL=missing.js.map\n", false); err != nil {
+		t.Errorf("Compile with missing map file failed: %v", err)
+	}
+}
+
+func TestSourceMapFormatError(t *testing.T) {
+	vm := goja.New()
+	_, err := vm.RunString("function f() { throw new TypeError('bad') }\nf()")
+	stack := sourcemap.FormatError(err)
+	if !strings.HasPrefix(stack, "TypeError: bad\n") || !strings.Contains(stack, "at f (") {
+		t.Errorf("Unexpected exception stack:\n%s", stack)
+	}
+
+	value, _ := vm.RunString("new RangeError('range')")
+	if stack := sourcemap.FormatError(value); !strings.HasPrefix(stack, "RangeError: range") {
+		t.Errorf("Unexpected value stack:\n%s", stack)
+	}
+	if got := sourcemap.FormatError(vm.ToValue(42)); got != "42" {
+		t.Errorf("FormatError(42) = %q", got)
+	}
+}
@@ -0,0 +1,149 @@
+package test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
+	"github.com/issueye/sw_runtime/internal/standalone"
+)
+
+func TestStandaloneBuildLoad(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "runtime")
+	binary := []byte("\x7fELF fake runtime binary")
+	if err := os.WriteFile(base, binary, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// 普通可执行文件没有载荷
+	if payload, err := standalone.Load(base); err != nil || payload != nil {
+		t.Fatalf("Expected no payload, got %v %v", payload, err)
+	}
+
+	output := filepath.Join(dir, "app")
+	err := standalone.Build(base, output, &standalone.Payload{
+		Entry: "app.ts",
+		Code:  "console.log('hi')",
+		Assets: map[string][]byte{
+			"public/index.html": []byte("<h1>hi</h1>"),
+			"data.bin":          {0, 1, 2, 255},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	info, err := os.Stat(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0100 == 0 {
+		t.Errorf("Output is not executable: %v", info.Mode())
+	}
+	content, _ := os.ReadFile(output)
+	if !bytes.HasPrefix(content, binary) {
+		t.Error("Output does not start with the runtime binary")
+	}
+
+	payload, err := standalone.Load(output)
+	if err != nil || payload == nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if payload.Entry != "app.ts" || payload.Code != "console.log('hi')" || payload.Encrypted {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+	if names := payload.AssetNames(); len(names) != 2 || names[0] != "data.bin" || names[1] != "public/index.html" {
+		t.Errorf("Unexpected asset names: %v", names)
+	}
+	if data, ok := payload.Asset("./public/index.html"); !ok || string(data) != "<h1>hi</h1>" {
+		t.Errorf("Unexpected asset: %q %v", data, ok)
+	}
+	if _, ok := payload.Asset("missing.txt"); ok {
+		t.Error("Expected missing asset")
+	}
+
+	// 以已编译的程序为基础重新编译时替换旧载荷
+	rebuilt := filepath.Join(dir, "app2")
+	err = standalone.Build(output, rebuilt, &standalone.Payload{Entry: "v2.js", Code: "1", Encrypted: true, Key: "k"})
+	if err != nil {
+		t.Fatalf("Rebuild failed: %v", err)
+	}
+	payload, err = standalone.Load(rebuilt)
+	if err != nil || payload == nil {
+		t.Fatalf("Load rebuilt failed: %v", err)
+	}
+	if payload.Entry != "v2.js" || !payload.Encrypted || payload.Key != "k" || len(payload.Assets) != 0 {
+		t.Errorf("Unexpected rebuilt payload: %+v", payload)
+	}
+	content, _ = os.ReadFile(rebuilt)
+	if !bytes.HasPrefix(content, binary) || bytes.Contains(content, []byte("<h1>hi</h1>")) {
+		t.Error("Old payload was not stripped")
+	}
+}
+
+func TestStandaloneLoadCorrupted(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "app")
+	if err := os.WriteFile(filepath.Join(dir, "runtime"), []byte("runtime"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := standalone.Build(filepath.Join(dir, "runtime"), output, &standalone.Payload{Entry: "a.js", Code: "1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 破坏 zip 数据但保留结尾标记
+	content, _ := os.ReadFile(output)
+	for i := len("runtime"); i < len(content)-16; i++ {
+		content[i] = 0
+	}
+	if err := os.WriteFile(output, content, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := standalone.Load(output); err == nil {
+		t.Error("Expected error for corrupted payload")
+	}
+}
+
+func TestStandaloneModule(t *testing.T) {
+	runner, err := runtime.New()
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	defer runner.Close()
+
+	// 测试进程不是独立可执行文件
+	err = runner.RunCode(`
+		const standalone = require('standalone');
+		if (standalone.isStandalone !== false) throw new Error('isStandalone: ' + standalone.isStandalone);
+		if (standalone.entry !== null) throw new Error('entry: ' + standalone.entry);
+		if (standalone.listAssets().length !== 0) throw new Error('listAssets not empty');
+		if (standalone.hasAsset('a.txt')) throw new Error('hasAsset returned true');
+		let threw = false;
+		try { standalone.getAsset('a.txt'); } catch (e) { threw = true; }
+		if (!threw) throw new Error('getAsset did not throw');
+	`)
+	if err != nil {
+		t.Fatalf("Script failed: %v", err)
+	}
+}
+
+func TestProcessArgv(t *testing.T) {
+	runner, err := runtime.New()
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	defer runner.Close()
+
+	runner.SetArgv([]string{"sw_runtime", "app.js", "--port", "8080"})
+	err = runner.RunCode(`
+		const { process } = require('process');
+		const args = process.argv.slice(2).join(' ');
+		if (args !== '--port 8080') throw new Error('argv: ' + args);
+	`)
+	if err != nil {
+		t.Fatalf("Script failed: %v", err)
+	}
+}
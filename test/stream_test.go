@@ -0,0 +1,229 @@
+package test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	goruntime "runtime"
+	"strings"
+	"testing"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
+)
+
+func TestStreamCore(t *testing.T) {
+	runner := runtime.NewOrPanic()
+	defer runner.Close()
+
+	code := `
+		const { Readable, Writable, Transform, PassThrough, pipeline, promises } = require('stream');
+		const EventEmitter = require('events');
+		const out = {};
+
+		// 背压：超过 highWaterMark 时 write 返回 false，缓冲写完后触发 drain
+		const slow = new Writable({
+			highWaterMark: 4,
+			write(chunk, encoding, cb) { setTimeout(cb, 1); },
+		});
+		out.backpressure = [slow.write('ab'), slow.write('cdef')].join(',');
+		slow.once('drain', () => { out.drain = true; });
+
+		const upper = new Transform({
+			transform(chunk, encoding, cb) { cb(null, chunk.toString().toUpperCase()); },
+		});
+		out.types = [upper instanceof Readable, upper instanceof Writable, upper instanceof EventEmitter].join(',');
+
+		(async () => {
+			const collected = [];
+			for await (const chunk of Readable.from(['x', 'y', 'z']).pipe(upper)) {
+				collected.push(chunk.toString());
+			}
+			out.iter = collected.join('');
+
+			let sink = '';
+			await promises.pipeline(
+				Readable.from(['a', 'b']),
+				new PassThrough(),
+				new Writable({ write(chunk, encoding, cb) { sink += chunk; cb(); } }),
+			);
+			out.pipeline = sink;
+
+			pipeline(
+				new Readable({ read() { this.destroy(new Error('broken')); } }),
+				new PassThrough(),
+				(err) => { out.error = err && err.message; },
+			);
+		})();
+		globalThis.result = out;
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+
+	result := runner.GetValue("result").ToObject(nil)
+	expect := map[string]string{
+		"backpressure": "true,false",
+		"drain":        "true",
+		"types":        "true,true,true",
+		"iter":         "XYZ",
+		"pipeline":     "ab",
+		"error":        "broken",
+	}
+	for key, want := range expect {
+		if got := result.Get(key); got == nil || got.String() != want {
+			t.Errorf("%s: expected %q, got %v", key, want, got)
+		}
+	}
+}
+
+func TestFSStreams(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+	os.Chdir(tempDir)
+
+	runner := runtime.NewOrPanicWithWorkingDir(tempDir)
+	defer runner.Close()
+
+	code := `
+		const { fs } = require('fs');
+		const { pipeline } = require('stream');
+		const { compression } = require('utils');
+		const out = {};
+
+		const lines = [];
+		for (let i = 0; i < 20000; i++) lines.push('line ' + i);
+		fs.writeFileSync('source.txt', lines.join('\n'));
+
+		// 压缩再解压，经过文件流和压缩流的完整管道
+		pipeline(fs.createReadStream('source.txt'), compression.createGzip(), fs.createWriteStream('source.gz'), (err) => {
+			if (err) throw err;
+			pipeline(fs.createReadStream('source.gz'), compression.createGunzip(), fs.createWriteStream('copy.txt'), (err) => {
+				out.copy = !err && fs.readFileSync('copy.txt', 'utf8') === lines.join('\n');
+			});
+		});
+
+		let range = '';
+		fs.createReadStream('source.txt', { start: 5, end: 5, encoding: 'utf8' })
+			.on('data', (chunk) => { range += chunk; })
+			.on('end', () => { out.range = range; });
+
+		fs.createReadStream('missing.txt').on('error', (err) => { out.missing = err.message.length > 0; });
+
+		const appender = fs.createWriteStream('log.txt', { flags: 'a' });
+		appender.write('one,');
+		appender.end('two', () => { out.append = fs.readFileSync('log.txt', 'utf8'); });
+		globalThis.result = out;
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+
+	result := runner.GetValue("result").ToObject(nil)
+	expect := map[string]string{
+		"copy":    "true",
+		"range":   "0",
+		"missing": "true",
+		"append":  "one,two",
+	}
+	for key, want := range expect {
+		if got := result.Get(key); got == nil || got.String() != want {
+			t.Errorf("%s: expected %q, got %v", key, want, got)
+		}
+	}
+}
+
+func TestSpawnStreams(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	runner := runtime.NewOrPanic()
+	defer runner.Close()
+
+	code := `
+		const { exec } = require('process');
+		const child = exec.spawn('sh', ['-c', 'cat; echo done >&2; exit 3']);
+		let stdout = '', stderr = '';
+		child.stdout.on('data', (chunk) => { stdout += chunk; });
+		child.stderr.on('data', (chunk) => { stderr += chunk; });
+		child.on('close', (code, signal) => {
+			globalThis.result = [stdout, stderr.trim(), code, signal, child.exitCode].join('|');
+		});
+		child.stdin.write('hello ');
+		child.stdin.end('world');
+
+		const sleeper = exec.spawn('sleep', ['5']);
+		sleeper.on('exit', (code, signal) => { globalThis.killed = signal; });
+		sleeper.kill();
+
+		exec.spawn('sw-missing-command').on('error', () => { globalThis.missing = true; });
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+	if got := runner.GetValue("result"); got == nil || got.String() != "hello world|done|3||3" {
+		t.Errorf("Unexpected result: %v", got)
+	}
+	if got := runner.GetValue("killed"); got == nil || got.String() != "SIGTERM" {
+		t.Errorf("Expected SIGTERM, got %v", got)
+	}
+	if got := runner.GetValue("missing"); got == nil || !got.ToBoolean() {
+		t.Error("Expected error event for missing command")
+	}
+}
+
+func TestHTTPServerStreams(t *testing.T) {
+	code := `
+		const { Readable, Transform } = require('stream');
+		const server = require('http/server').createServer();
+		const rows = [];
+		for (let i = 0; i < 5000; i++) rows.push('row ' + i + '\n');
+
+		server.get('/ping', (req, res) => res.send('pong'));
+		server.get('/file', (req, res) => Readable.from(rows).pipe(res));
+		server.post('/count', async (req, res) => {
+			let size = 0;
+			for await (const chunk of req) size += chunk.length;
+			res.json({ size });
+		});
+		server.post('/upper', (req, res) => {
+			req.pipe(new Transform({
+				transform(chunk, encoding, cb) { cb(null, chunk.toString().toUpperCase()); },
+			})).pipe(res);
+		});
+		server.listen('38960');
+	`
+	runner := startWorkerServer(t, code, "http://localhost:38960/ping")
+	defer runner.Close()
+
+	resp, err := http.Get("http://localhost:38960/file")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.HasPrefix(string(body), "row 0\n") || !strings.HasSuffix(string(body), "row 4999\n") {
+		t.Errorf("Unexpected file body of %d bytes", len(body))
+	}
+
+	upload := bytes.Repeat([]byte("0123456789"), 100000)
+	if got := postBody(t, "http://localhost:38960/count", upload); got != `{"size":1000000}` {
+		t.Errorf("Unexpected count response: %s", got)
+	}
+	if got := postBody(t, "http://localhost:38960/upper", []byte("stream me")); got != "STREAM ME" {
+		t.Errorf("Unexpected upper response: %s", got)
+	}
+}
+
+// postBody 发送 POST 请求并返回响应体
+func postBody(t *testing.T, url string, data []byte) string {
+	t.Helper()
+	resp, err := http.Post(url, "application/octet-stream", bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
@@ -0,0 +1,241 @@
+package test
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/issueye/sw_runtime/internal/testrunner"
+)
+
+// resultsByName 按完整测试名索引结果
+func resultsByName(result *testrunner.FileResult) map[string]testrunner.TestResult {
+	byName := make(map[string]testrunner.TestResult)
+	for _, r := range result.Tests {
+		byName[r.FullName()] = r
+	}
+	return byName
+}
+
+func TestTestRunnerDiscover(t *testing.T) {
+	dir := t.TempDir()
+	writeModuleFiles(t, dir, map[string]string{
+		"a.test.ts":                  "",
+		"lib/b.spec.js":              "",
+		"lib/helper.js":              "",
+		"node_modules/pkg/c.test.js": "",
+		".cache/d.test.js":           "",
+	})
+
+	files, err := testrunner.Discover([]string{dir})
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 test files, got %v", files)
+	}
+	if filepath.Base(files[0]) != "a.test.ts" || filepath.Base(files[1]) != "b.spec.js" {
+		t.Errorf("Unexpected test files: %v", files)
+	}
+}
+
+func TestTestRunnerRunFile(t *testing.T) {
+	dir := t.TempDir()
+	writeModuleFiles(t, dir, map[string]string{
+		"math.js": `exports.add = (a, b) => a + b;`,
+		"math.test.ts": `
+			const { add } = require('./math.js');
+			const order: string[] = [];
+
+			describe('math', () => {
+				beforeAll(() => { order.push('beforeAll'); });
+				beforeEach(() => { order.push('beforeEach'); });
+				afterEach(() => { order.push('afterEach'); });
+
+				it('adds', () => {
+					expect(add(1, 2)).toBe(3);
+					expect({ list: [1, { n: 2 }], when: new Date(0) }).toEqual({ list: [1, { n: 2 }], when: new Date(0) });
+					expect([1, 2, 3]).toContain(2);
+					expect('hello').toMatch(/ell/);
+					expect(() => { throw new RangeError('bad'); }).toThrow(RangeError);
+				});
+
+				it('awaits', async () => {
+					const value = await new Promise(resolve => setTimeout(() => resolve(42), 20));
+					expect(value).toBe(42);
+					await expect(Promise.reject(new Error('nope'))).rejects.toThrow('nope');
+				});
+
+				it('uses done', (done) => {
+					setTimeout(done, 10);
+				});
+
+				it('fails', () => {
+					expect({ a: 1 }).toEqual({ a: 2 });
+				});
+
+				it('times out', () => new Promise(() => {}), 50);
+
+				it.skip('is skipped', () => { throw new Error('should not run'); });
+				it.todo('is todo');
+
+				describe('nested', () => {
+					it('sees hooks', () => {
+						expect(order.slice(0, 2)).toEqual(['beforeAll', 'beforeEach']);
+					});
+				});
+			});
+		`,
+	})
+
+	result := testrunner.RunFile(filepath.Join(dir, "math.test.ts"), testrunner.Options{Timeout: time.Second})
+	if result.Err != nil {
+		t.Fatalf("RunFile failed: %v", result.Err)
+	}
+
+	byName := resultsByName(result)
+	expected := map[string]testrunner.Status{
+		"math > adds":                testrunner.StatusPassed,
+		"math > awaits":              testrunner.StatusPassed,
+		"math > uses done":           testrunner.StatusPassed,
+		"math > fails":               testrunner.StatusFailed,
+		"math > times out":           testrunner.StatusFailed,
+		"math > is skipped":          testrunner.StatusSkipped,
+		"math > is todo":             testrunner.StatusTodo,
+		"math > nested > sees hooks": testrunner.StatusPassed,
+	}
+	for name, status := range expected {
+		got, ok := byName[name]
+		if !ok {
+			t.Errorf("Missing result for %q", name)
+			continue
+		}
+		if got.Status != status {
+			t.Errorf("%q: expected %s, got %s (%+v)", name, status, got.Status, got.Error)
+		}
+	}
+
+	if e := byName["math > fails"].Error; e == nil || e.Name != "AssertionError" || !strings.Contains(e.Message, "to equal") {
+		t.Errorf("Unexpected assertion error: %+v", e)
+	}
+	if e := byName["math > times out"].Error; e == nil || !strings.Contains(e.Message, "Timeout of 50ms") {
+		t.Errorf("Unexpected timeout error: %+v", e)
+	}
+
+	summary := testrunner.Summarize([]*testrunner.FileResult{result})
+	if summary.Passed != 4 || summary.Failed != 2 || summary.OK() {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+}
+
+func TestTestRunnerFilterAndOnly(t *testing.T) {
+	dir := t.TempDir()
+	writeModuleFiles(t, dir, map[string]string{
+		"filter.spec.js": `
+			describe('users', () => {
+				it('login', () => {});
+				it('logout', () => {});
+			});
+			it('other', () => {});
+		`,
+		"only.spec.js": `
+			it('normal', () => {});
+			it.only('focused', () => {});
+		`,
+	})
+
+	result := testrunner.RunFile(filepath.Join(dir, "filter.spec.js"), testrunner.Options{Filter: "users > log(in|out)$"})
+	if len(result.Tests) != 2 {
+		t.Fatalf("Expected 2 filtered tests, got %+v", result.Tests)
+	}
+
+	result = testrunner.RunFile(filepath.Join(dir, "only.spec.js"), testrunner.Options{})
+	byName := resultsByName(result)
+	if byName["focused"].Status != testrunner.StatusPassed || byName["normal"].Status != testrunner.StatusSkipped {
+		t.Errorf("Expected only focused test to run, got %+v", result.Tests)
+	}
+}
+
+func TestTestRunnerBlockingTimeout(t *testing.T) {
+	dir := t.TempDir()
+	writeModuleFiles(t, dir, map[string]string{
+		"loop.test.js": `
+			describe('suite', () => {
+				it('before', () => {});
+				it('spins forever', () => { while (true) {} }, 100);
+				it('after', () => { expect(1).toBe(1); });
+			});
+		`,
+	})
+
+	done := make(chan *testrunner.FileResult, 1)
+	go func() {
+		done <- testrunner.RunFile(filepath.Join(dir, "loop.test.js"), testrunner.Options{Timeout: time.Second})
+	}()
+
+	var result *testrunner.FileResult
+	select {
+	case result = <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("RunFile hung on a synchronous infinite loop")
+	}
+	if result.Err != nil {
+		t.Fatalf("RunFile failed: %v", result.Err)
+	}
+
+	// 阻塞的测试报告为超时，其余测试继续执行且只报告一次
+	byName := resultsByName(result)
+	if len(result.Tests) != 3 {
+		t.Errorf("Expected 3 results, got %+v", result.Tests)
+	}
+	if byName["suite > before"].Status != testrunner.StatusPassed || byName["suite > after"].Status != testrunner.StatusPassed {
+		t.Errorf("Expected other tests to pass, got %+v", result.Tests)
+	}
+	spin := byName["suite > spins forever"]
+	if spin.Status != testrunner.StatusFailed || spin.Error == nil || !strings.Contains(spin.Error.Message, "Timeout of 100ms") {
+		t.Errorf("Expected blocking test to time out, got %+v", spin)
+	}
+}
+
+func TestTestRunnerReporters(t *testing.T) {
+	dir := t.TempDir()
+	writeModuleFiles(t, dir, map[string]string{
+		"report.test.js": `
+			it('passes', () => {});
+			it('fails <xml>', () => { expect(1).toBe(2); });
+			it.skip('skipped', () => {});
+		`,
+		"broken.test.js": `throw new Error('load failure');`,
+	})
+	files, err := testrunner.Discover([]string{dir})
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+
+	var tap bytes.Buffer
+	reporter, _ := testrunner.NewReporter("tap", &tap)
+	results := testrunner.Run(files, testrunner.Options{}, reporter)
+	reporter.Finish(results)
+	output := tap.String()
+	for _, want := range []string{"TAP version 13", "not ok 1 - ", "ok 2 - ", "not ok 3 - ", "# SKIP", "1..4"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("TAP output missing %q:\n%s", want, output)
+		}
+	}
+
+	var junit bytes.Buffer
+	reporter, _ = testrunner.NewReporter("junit", &junit)
+	reporter.Finish(results)
+	output = junit.String()
+	for _, want := range []string{`<testsuites tests="3" failures="1" errors="1" skipped="1"`, `name="fails &lt;xml&gt;"`, `<failure message="expected 1 to be 2"`, `<skipped>`} {
+		if !strings.Contains(output, want) {
+			t.Errorf("JUnit output missing %q:\n%s", want, output)
+		}
+	}
+
+	if _, err := testrunner.NewReporter("xml", &junit); err == nil {
+		t.Error("Expected unknown reporter error")
+	}
+}
@@ -0,0 +1,148 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
+)
+
+// runWithTimeout 在新 Runner 中限时执行代码，返回错误和耗时
+func runWithTimeout(t *testing.T, code string, timeout time.Duration) (error, time.Duration) {
+	t.Helper()
+	runner, err := runtime.New()
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	defer runner.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	err = runner.RunCodeContext(ctx, code)
+	return err, time.Since(start)
+}
+
+func TestRunCodeContextTimeout(t *testing.T) {
+	cases := map[string]string{
+		"sync loop":  `while (true) {}`,
+		"timer loop": `setTimeout(() => { while (true) {} }, 10);`,
+		"interval":   `setInterval(() => {}, 20);`,
+		"microtasks": `(async () => { for (;;) await null; })();`,
+	}
+	for name, code := range cases {
+		t.Run(name, func(t *testing.T) {
+			err, elapsed := runWithTimeout(t, code, 200*time.Millisecond)
+
+			var timeoutErr *runtime.TimeoutError
+			if !errors.As(err, &timeoutErr) {
+				t.Fatalf("Expected TimeoutError, got %v", err)
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected DeadlineExceeded, got %v", timeoutErr.Err)
+			}
+			if elapsed > 2*time.Second {
+				t.Errorf("Interrupt took too long: %v", elapsed)
+			}
+		})
+	}
+}
+
+func TestRunCodeContextCancel(t *testing.T) {
+	runner, err := runtime.New()
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	defer runner.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	err = runner.RunCodeContext(ctx, `for (;;) {}`)
+	if !errors.Is(err, context.Canceled) || err.Error() != "script execution canceled" {
+		t.Fatalf("Expected canceled error, got %v", err)
+	}
+	if !runner.Interrupted() {
+		t.Error("Runner should be marked as interrupted")
+	}
+
+	// 已取消的 context 不再执行脚本
+	fresh, _ := runtime.New()
+	defer fresh.Close()
+	err = fresh.RunCodeContext(ctx, `globalThis.ran = true`)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected canceled error, got %v", err)
+	}
+	if fresh.GetValue("ran") != nil {
+		t.Error("Script should not run with canceled context")
+	}
+}
+
+func TestRunCodeContextCompletes(t *testing.T) {
+	runner, err := runtime.New()
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	defer runner.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = runner.RunCodeContext(ctx, `
+		globalThis.result = 0;
+		setTimeout(() => {
+			// 执行时间超过事件循环空闲检测间隔的回调
+			const start = Date.now();
+			while (Date.now() - start < 100) {}
+			globalThis.result = 42;
+		}, 10);
+	`)
+	if err != nil {
+		t.Fatalf("RunCodeContext failed: %v", err)
+	}
+	if got := runner.GetValue("result").ToInteger(); got != 42 {
+		t.Errorf("Expected result 42, got %d", got)
+	}
+	if runner.Interrupted() {
+		t.Error("Runner should not be marked as interrupted")
+	}
+
+	// 脚本错误原样返回
+	err = runner.RunCodeContext(ctx, `throw new Error('boom')`)
+	var timeoutErr *runtime.TimeoutError
+	if err == nil || errors.As(err, &timeoutErr) {
+		t.Errorf("Expected script error, got %v", err)
+	}
+}
+
+func TestRunFileContextTimeout(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "loop.ts")
+	if err := os.WriteFile(script, []byte("const spin = (): never => { while (true) {} };\nspin();\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pool := runtime.NewRunnerPool()
+	runner := pool.Acquire()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	err := runner.RunFileContext(ctx, script)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected timeout, got %v", err)
+	}
+
+	// 被中断的 Runner 归还时被关闭，池会重新创建可用的 Runner
+	pool.Release(runner)
+	next := pool.Acquire()
+	defer pool.Release(next)
+	if err := next.RunCode(`globalThis.ok = 1`); err != nil {
+		t.Fatalf("Pooled runner unusable: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/issueye/sw_runtime/internal/cache"
+	"github.com/issueye/sw_runtime/internal/runtime"
+)
+
+func TestTranspileCacheReuse(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv(cache.DirEnv, cacheDir)
+
+	tempDir := t.TempDir()
+	mainFile := filepath.Join(tempDir, "main.ts")
+	libFile := filepath.Join(tempDir, "lib.ts")
+	mainCode := "const { double } = require('./lib');\nconst n: number = double(21);\n(globalThis as any).result = n;\n"
+	libCode := "export function double(n: number): number { return n * 2; }\n"
+	os.WriteFile(mainFile, []byte(mainCode), 0644)
+	os.WriteFile(libFile, []byte(libCode), 0644)
+
+	run := func() string {
+		runner := runtime.NewOrPanicWithWorkingDir(tempDir)
+		defer runner.Close()
+		if err := runner.RunFile(mainFile); err != nil {
+			t.Fatalf("RunFile failed: %v", err)
+		}
+		return runner.GetValue("result").String()
+	}
+
+	if got := run(); got != "42" {
+		t.Fatalf("Expected 42, got %s", got)
+	}
+
+	// 入口文件和模块的转译结果都已写入缓存
+	entry, ok := cache.Default().Get(cache.Key("ts", mainFile, mainCode))
+	if !ok {
+		t.Fatal("Expected cached entry for main.ts")
+	}
+	if _, ok := cache.Default().Get(cache.Key("esm", libFile, libCode)); !ok {
+		t.Fatal("Expected cached entry for lib.ts")
+	}
+
+	// 改写缓存内容，第二次运行应直接使用缓存而不是重新转译
+	patched := bytes.Replace(entry, []byte("double(21)"), []byte("double(50)"), 1)
+	cache.Default().Put(cache.Key("ts", mainFile, mainCode), patched)
+	if got := run(); got != "100" {
+		t.Fatalf("Expected cached code to be used, got %s", got)
+	}
+
+	// 源码变化后重新转译
+	mainCode = "const { double } = require('./lib');\n(globalThis as any).result = double(1);\n"
+	os.WriteFile(mainFile, []byte(mainCode), 0644)
+	if got := run(); got != "2" {
+		t.Fatalf("Expected 2 after source change, got %s", got)
+	}
+
+	if err := cache.Default().Clear(); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if _, err := os.Stat(cacheDir); !os.IsNotExist(err) {
+		t.Errorf("Expected cache directory to be removed, got %v", err)
+	}
+}
+
+func TestTranspileCacheEviction(t *testing.T) {
+	c := cache.Open(t.TempDir())
+	c.SetMaxSize(1000)
+
+	data := bytes.Repeat([]byte("x"), 200)
+	for i := 0; i < 10; i++ {
+		c.Put(cache.Key("ts", "file.ts", string(rune('a'+i))), data)
+	}
+	if size := c.Size(); size > 1000 {
+		t.Errorf("Expected cache size to stay within 1000 bytes, got %d", size)
+	}
+	if _, ok := c.Get(cache.Key("ts", "file.ts", "j")); !ok {
+		t.Error("Expected most recent entry to be kept")
+	}
+}
+
+func TestTranspileCacheDisabled(t *testing.T) {
+	t.Setenv(cache.DirEnv, "")
+	if cache.Default() != nil {
+		t.Fatal("Expected empty cache directory to disable the cache")
+	}
+
+	tempDir := t.TempDir()
+	file := filepath.Join(tempDir, "main.ts")
+	os.WriteFile(file, []byte("(globalThis as any).result = 'ok';\n"), 0644)
+
+	runner := runtime.NewOrPanicWithWorkingDir(tempDir)
+	defer runner.Close()
+	if err := runner.RunFile(file); err != nil {
+		t.Fatalf("RunFile failed: %v", err)
+	}
+	if got := runner.GetValue("result").String(); got != "ok" {
+		t.Errorf("Expected ok, got %s", got)
+	}
+}
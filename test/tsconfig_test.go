@@ -0,0 +1,178 @@
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/issueye/sw_runtime/internal/bundler"
+	"github.com/issueye/sw_runtime/internal/runtime"
+	"github.com/issueye/sw_runtime/internal/tsconfig"
+)
+
+// writeTSProject 创建使用 tsconfig.json 别名、装饰器和 JSX 的测试项目
+func writeTSProject(t *testing.T, useDefine string) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeModuleFiles(t, dir, map[string]string{
+		"tsconfig.base.json": `{
+			// 公共配置
+			"compilerOptions": {
+				"experimentalDecorators": true,
+				"target": "ES2022",
+				"baseUrl": ".",
+				"paths": {
+					"@app/*": ["src/*"],
+					"@config": ["src/config.ts"],
+				},
+			},
+		}`,
+		"tsconfig.json": `{
+			"extends": "./tsconfig.base.json",
+			/* 覆盖公共配置 */
+			"compilerOptions": {
+				` + useDefine + `
+				"jsx": "react",
+				"jsxFactory": "h",
+				"jsxFragmentFactory": "Fragment"
+			}
+		}`,
+		"src/config.ts": `export const name: string = 'demo';`,
+		"src/services/greeter.ts": `
+			export const calls: string[] = [];
+			function log(target: any, key: string, desc: PropertyDescriptor) {
+				calls.push(key);
+				return desc;
+			}
+			class Base { set value(v: number) { (this as any).seen = v; } }
+			export class Greeter extends Base {
+				value = 1;
+				@log greet(who: string) { return 'hi ' + who; }
+			}
+		`,
+		"src/ui/view.tsx": `
+			const h = (tag: any, props: any, ...children: any[]) => ({ tag, props, children });
+			const Fragment = 'frag';
+			export const view = <><b>x</b></>;
+		`,
+		"main.ts": `
+			import { Greeter, calls } from '@app/services/greeter';
+			import { name } from '@config';
+			import { view } from '@app/ui/view';
+			const g = new Greeter();
+			(globalThis as any).result = [g.greet(name), calls.join(','), (g as any).seen, view.tag, view.children[0].tag].join('|');
+		`,
+	})
+	return dir
+}
+
+func TestTsconfigCompilerOptions(t *testing.T) {
+	t.Setenv("SW_RUNTIME_CACHE_DIR", t.TempDir())
+
+	tests := []struct {
+		name      string
+		useDefine string
+		expected  string
+	}{
+		// useDefineForClassFields 为 false 时字段初始化调用父类的 setter
+		{"AssignSemantics", `"useDefineForClassFields": false,`, "hi demo|greet|1|frag|b"},
+		// target 为 ES2022 时默认使用 define 语义
+		{"DefineSemantics", "", "hi demo|greet||frag|b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeTSProject(t, tt.useDefine)
+			runner := runtime.NewOrPanicWithWorkingDir(dir)
+			defer runner.Close()
+
+			if err := runner.RunFile(filepath.Join(dir, "main.ts")); err != nil {
+				t.Fatalf("RunFile failed: %v", err)
+			}
+			if got := runner.GetValue("result").String(); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestTsconfigPathAliases(t *testing.T) {
+	dir := writeTSProject(t, "")
+
+	// require 与内联代码同样使用工作目录中的别名，baseUrl 下的路径可直接引用
+	runner := runtime.NewOrPanicWithWorkingDir(dir)
+	defer runner.Close()
+	code := `
+		const { name } = require('@config');
+		const { Greeter } = require('src/services/greeter');
+		globalThis.result = name + ':' + new Greeter().greet('x');
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+	if got := runner.GetValue("result").String(); got != "demo:hi x" {
+		t.Errorf("Expected demo:hi x, got %q", got)
+	}
+
+	// 未匹配的别名报告模块未找到
+	err := runner.RunCode(`require('@app/missing')`)
+	if err == nil || !strings.Contains(err.Error(), "module not found") {
+		t.Errorf("Expected module not found error, got %v", err)
+	}
+
+	config, err := tsconfig.Find(filepath.Join(dir, "src", "ui"))
+	if err != nil || config == nil {
+		t.Fatalf("Expected tsconfig.json to be found, got %v", err)
+	}
+	candidates := config.Candidates("@app/ui/view")
+	if len(candidates) != 2 || candidates[0] != filepath.Join(dir, "src", "ui", "view") {
+		t.Errorf("Unexpected candidates: %v", candidates)
+	}
+	if config.Candidates("./local") != nil {
+		t.Error("Relative specifiers should not be aliased")
+	}
+}
+
+func TestTsconfigBundle(t *testing.T) {
+	dir := writeTSProject(t, `"useDefineForClassFields": false,`)
+
+	b := bundler.New(bundler.Options{
+		EntryFile:  filepath.Join(dir, "main.ts"),
+		OutputFile: filepath.Join(dir, "bundle.js"),
+	})
+	result, err := b.Bundle()
+	if err != nil {
+		t.Fatalf("Bundle failed: %v", err)
+	}
+
+	modules := strings.Join(result.Modules, ",")
+	for _, name := range []string{"greeter.ts", "config.ts", "view.tsx"} {
+		if !strings.Contains(modules, name) {
+			t.Errorf("Bundle should contain %s, got %s", name, modules)
+		}
+	}
+
+	runner := runtime.NewOrPanicWithWorkingDir(t.TempDir())
+	defer runner.Close()
+	if err := runner.RunCode(result.Code); err != nil {
+		t.Fatalf("Running bundle failed: %v", err)
+	}
+	if got := runner.GetValue("result").String(); got != "hi demo|greet|1|frag|b" {
+		t.Errorf("Unexpected bundle result: %q", got)
+	}
+}
+
+func TestTsconfigInvalid(t *testing.T) {
+	dir := t.TempDir()
+	writeModuleFiles(t, dir, map[string]string{
+		"tsconfig.json": `{ "extends": "./missing.json" }`,
+		"main.ts":       `const x: number = 1;`,
+	})
+
+	runner := runtime.NewOrPanicWithWorkingDir(dir)
+	defer runner.Close()
+	err := runner.RunFile(filepath.Join(dir, "main.ts"))
+	if err == nil || !strings.Contains(err.Error(), "missing.json") {
+		t.Errorf("Expected extends error, got %v", err)
+	}
+}
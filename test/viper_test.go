@@ -5,7 +5,7 @@ import (
 	"strings"
 	"testing"
 
-	"sw_runtime/internal/runtime"
+	"github.com/issueye/sw_runtime/internal/runtime"
 )
 
 // TestViperModule 测试 viper 配置模块
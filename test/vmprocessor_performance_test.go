@@ -10,7 +10,7 @@ import (
 
 	"github.com/dop251/goja"
 
-	"sw_runtime/internal/builtins"
+	"github.com/issueye/sw_runtime/internal/builtins"
 )
 
 // TestVMProcessorPerformance VMProcessor 性能测试
@@ -0,0 +1,184 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
+)
+
+func TestWebGlobalsURL(t *testing.T) {
+	runner := runtime.NewOrPanic()
+	defer runner.Close()
+
+	code := `
+		const out = {};
+		const url = new URL('HTTPS://user:pw@Example.COM:443/a/./b/../c d?q=1#top');
+		out.href = url.href;
+		out.parts = [url.origin, url.host, url.pathname, url.search, url.hash, url.username].join('|');
+		out.relative = new URL('../x?y#z', 'http://a.com/b/c/d').href;
+		out.ipv6 = new URL('http://[::1]:8080/').host;
+		out.opaque = new URL('mailto:a@b.com').pathname + '|' + new URL('mailto:a@b.com').origin;
+		try { new URL('not a url'); } catch (e) { out.invalid = e.name + ':' + e.code; }
+		out.canParse = [URL.canParse('/x', 'http://a.com'), URL.canParse('http://')].join(',');
+
+		// searchParams 与 URL 双向同步
+		url.searchParams.append('name', 'a b&c');
+		out.appended = url.search;
+		url.search = '?k=v';
+		out.synced = url.searchParams.get('k');
+		url.port = '8443';
+		url.pathname = '/new';
+		out.updated = url.href;
+
+		const params = new URLSearchParams('?b=2&a=1&a=3&c=%E4%BD%A0+x');
+		out.getAll = params.getAll('a').join(',');
+		out.decoded = params.get('c');
+		params.set('b', '~!');
+		params.sort();
+		out.params = params.toString();
+		out.record = new URLSearchParams({ x: 1, y: 'z' }).toString();
+		out.pairs = [...new URLSearchParams([['k', 'v']]).entries()].join(';');
+		out.json = JSON.stringify({ url: new URL('http://a.com') });
+		globalThis.result = out;
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+
+	result := runner.GetValue("result").ToObject(nil)
+	expect := map[string]string{
+		"href":     "https://user:pw@example.com/a/c%20d?q=1#top",
+		"parts":    "https://example.com|example.com|/a/c%20d|?q=1|#top|user",
+		"relative": "http://a.com/b/x?y#z",
+		"ipv6":     "[::1]:8080",
+		"opaque":   "a@b.com|null",
+		"invalid":  "TypeError:ERR_INVALID_URL",
+		"canParse": "true,false",
+		"appended": "?q=1&name=a+b%26c",
+		"synced":   "v",
+		"updated":  "https://user:pw@example.com:8443/new?k=v#top",
+		"getAll":   "1,3",
+		"decoded":  "你 x",
+		"params":   "a=1&a=3&b=%7E%21&c=%E4%BD%A0+x",
+		"record":   "x=1&y=z",
+		"pairs":    "k,v",
+		"json":     `{"url":"http://a.com/"}`,
+	}
+	for key, want := range expect {
+		if got := result.Get(key); got == nil || got.String() != want {
+			t.Errorf("%s: expected %q, got %v", key, want, got)
+		}
+	}
+}
+
+func TestWebGlobalsEncoding(t *testing.T) {
+	runner := runtime.NewOrPanic()
+	defer runner.Close()
+
+	code := `
+		const out = {};
+		const encoder = new TextEncoder();
+		const bytes = encoder.encode('héllo😀');
+		out.encoded = bytes.length + ':' + (bytes instanceof Uint8Array);
+		const dest = new Uint8Array(5);
+		const { read, written } = encoder.encodeInto('ab😀', dest);
+		out.encodeInto = read + ',' + written;
+
+		const decoder = new TextDecoder();
+		out.decoded = decoder.decode(bytes);
+		out.bom = decoder.decode(new Uint8Array([0xef, 0xbb, 0xbf, 0x41]));
+
+		// 多字节字符被拆分到两次流式解码中
+		const euro = encoder.encode('€');
+		out.stream = decoder.decode(euro.subarray(0, 2), { stream: true }) + decoder.decode(euro.subarray(2));
+
+		out.labels = [
+			new TextDecoder('latin1').encoding,
+			new TextDecoder('latin1').decode(new Uint8Array([0xe9])),
+			new TextDecoder('utf-16le').decode(new Uint8Array([0x68, 0, 0x69, 0])),
+			new TextDecoder('gbk').decode(new Uint8Array([0xc4, 0xe3])),
+		].join('|');
+		try { new TextDecoder('utf-8', { fatal: true }).decode(new Uint8Array([0xff])); } catch (e) { out.fatal = e.name; }
+		try { new TextDecoder('no-such-encoding'); } catch (e) { out.unknown = e.name; }
+
+		out.base64 = [btoa('hello'), atob('aGVsbG8='), atob('aGVs bG8')].join('|');
+		try { btoa('你'); } catch (e) { out.btoa = e.name; }
+		try { atob('a'); } catch (e) { out.atob = e.name; }
+		globalThis.result = out;
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+
+	result := runner.GetValue("result").ToObject(nil)
+	expect := map[string]string{
+		"encoded":    "10:true",
+		"encodeInto": "2,2",
+		"decoded":    "héllo😀",
+		"bom":        "A",
+		"stream":     "€",
+		"labels":     "windows-1252|é|hi|你",
+		"fatal":      "TypeError",
+		"unknown":    "RangeError",
+		"base64":     "aGVsbG8=|hello|hello",
+		"btoa":       "InvalidCharacterError",
+		"atob":       "InvalidCharacterError",
+	}
+	for key, want := range expect {
+		if got := result.Get(key); got == nil || got.String() != want {
+			t.Errorf("%s: expected %q, got %v", key, want, got)
+		}
+	}
+}
+
+func TestWebGlobalsMisc(t *testing.T) {
+	runner := runtime.NewOrPanic()
+	defer runner.Close()
+
+	code := `
+		const out = {};
+		const original = { date: new Date(0), map: new Map([[1, { x: 1 }]]) };
+		original.self = original;
+		const copy = structuredClone(original);
+		out.clone = [copy !== original, copy.self === copy, copy.map.get(1).x, copy.date instanceof Date].join(',');
+		try { structuredClone(() => {}); } catch (e) { out.cloneError = e.name; }
+
+		out.performance = typeof performance.now() === 'number' && performance.now() >= 0 && performance.timeOrigin > 0;
+		out.uuid = /^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/.test(crypto.randomUUID());
+		const values = new Uint32Array(8);
+		out.random = crypto.getRandomValues(values) === values && values.some((v) => v !== 0);
+		try { crypto.getRandomValues(new Float64Array(1)); } catch (e) { out.randomError = e.name; }
+
+		// 执行顺序：同步代码、微任务、setImmediate、定时器
+		const order = [];
+		setTimeout(() => { order.push('timeout'); out.order = order.join(','); }, 5);
+		setImmediate((a, b) => order.push('immediate' + a + b), 1, 2);
+		clearImmediate(setImmediate(() => order.push('cleared')));
+		queueMicrotask(() => order.push('microtask'));
+		order.push('sync');
+
+		process.on('uncaughtException', (err) => { out.uncaught = err.message; });
+		queueMicrotask(() => { throw new Error('boom'); });
+		globalThis.result = out;
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("RunCode failed: %v", err)
+	}
+
+	result := runner.GetValue("result").ToObject(nil)
+	expect := map[string]string{
+		"clone":       "true,true,1,true",
+		"cloneError":  "DataCloneError",
+		"performance": "true",
+		"uuid":        "true",
+		"random":      "true",
+		"randomError": "TypeMismatchError",
+		"order":       "sync,microtask,immediate12,timeout",
+		"uncaught":    "boom",
+	}
+	for key, want := range expect {
+		if got := result.Get(key); got == nil || got.String() != want {
+			t.Errorf("%s: expected %q, got %v", key, want, got)
+		}
+	}
+}
@@ -3,7 +3,7 @@ package test
 import (
 	"testing"
 
-	"sw_runtime/internal/runtime"
+	"github.com/issueye/sw_runtime/internal/runtime"
 )
 
 // TestWebSocketClient 测试 WebSocket 客户端基本功能
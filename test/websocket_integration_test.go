@@ -3,7 +3,7 @@ package test
 import (
 	"testing"
 
-	"sw_runtime/internal/runtime"
+	"github.com/issueye/sw_runtime/internal/runtime"
 )
 
 // TestWebSocketServerClientIntegration 集成测试 - WebSocket 服务器和客户端
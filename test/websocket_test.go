@@ -3,7 +3,7 @@ package test
 import (
 	"testing"
 
-	"sw_runtime/internal/runtime"
+	"github.com/issueye/sw_runtime/internal/runtime"
 )
 
 // TestWebSocketBasic 测试基本的 WebSocket 功能
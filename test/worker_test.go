@@ -0,0 +1,261 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/issueye/sw_runtime/internal/runtime"
+)
+
+// writeWorkerScript 在临时目录中写入 Worker 脚本
+func writeWorkerScript(t *testing.T, dir, name, code string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(code), 0644); err != nil {
+		t.Fatalf("Failed to write worker script: %v", err)
+	}
+}
+
+func TestWorkerMessaging(t *testing.T) {
+	tempDir := t.TempDir()
+	writeWorkerScript(t, tempDir, "job.ts", `
+		const factor: number = workerData.factor;
+		self.onmessage = (e: any) => {
+			const { id, values, meta } = e.data;
+			postMessage({
+				id,
+				sum: values.reduce((a: number, b: number) => a + b, 0) * factor,
+				when: meta.when instanceof Date,
+				tags: meta.tags instanceof Set ? [...meta.tags].join(',') : 'no set',
+				bytes: meta.bytes instanceof Uint8Array ? meta.bytes.length : -1,
+				self: meta.self === meta,
+				name: self.name,
+				isMainThread
+			});
+		};
+	`)
+
+	runner := runtime.NewOrPanicWithWorkingDir(tempDir)
+	defer runner.Close()
+
+	code := `
+		global.workerResults = [];
+		const worker = new Worker('./job.ts', { name: 'summer', workerData: { factor: 2 } });
+		worker.onmessage = (e) => {
+			global.workerResults.push(e.data);
+			if (global.workerResults.length === 2) worker.terminate();
+		};
+		worker.on('exit', (code) => { global.workerExit = code; });
+
+		const meta = { when: new Date(), tags: new Set(['a', 'b']), bytes: new Uint8Array(3) };
+		meta.self = meta;
+		worker.postMessage({ id: 1, values: [1, 2, 3], meta });
+		worker.postMessage({ id: 2, values: [10], meta });
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("Failed to run worker code: %v", err)
+	}
+
+	results := runner.GetValue("workerResults").Export().([]interface{})
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 replies, got %v", results)
+	}
+	first := results[0].(map[string]interface{})
+	if first["id"] != int64(1) || first["sum"] != int64(12) {
+		t.Errorf("Unexpected first reply: %v", first)
+	}
+	if first["when"] != true || first["tags"] != "a,b" || first["bytes"] != int64(3) || first["self"] != true {
+		t.Errorf("Structured clone lost types: %v", first)
+	}
+	if first["name"] != "summer" || first["isMainThread"] != false {
+		t.Errorf("Unexpected worker scope: %v", first)
+	}
+	if second := results[1].(map[string]interface{}); second["sum"] != int64(20) {
+		t.Errorf("Unexpected second reply: %v", second)
+	}
+	if exit := runner.GetValue("workerExit"); exit == nil || exit.ToInteger() != 1 {
+		t.Errorf("Expected terminated worker to exit with 1, got %v", exit)
+	}
+}
+
+func TestWorkerRunsInParallel(t *testing.T) {
+	tempDir := t.TempDir()
+	writeWorkerScript(t, tempDir, "spin.js", `
+		const start = Date.now();
+		while (Date.now() - start < 300) {}
+		postMessage(workerData);
+	`)
+
+	runner := runtime.NewOrPanicWithWorkingDir(tempDir)
+	defer runner.Close()
+
+	code := `
+		global.done = [];
+		for (let i = 0; i < 4; i++) {
+			const worker = new Worker('spin.js', { workerData: i });
+			worker.onmessage = (e) => { global.done.push(e.data); };
+			worker.onexit = (code) => { global.exitCodes = (global.exitCodes || 0) + code; };
+		}
+	`
+	start := time.Now()
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("Failed to run worker code: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	if got := len(runner.GetValue("done").Export().([]interface{})); got != 4 {
+		t.Fatalf("Expected 4 workers to finish, got %d", got)
+	}
+	if exit := runner.GetValue("exitCodes"); exit == nil || exit.ToInteger() != 0 {
+		t.Errorf("Expected clean exits, got %v", exit)
+	}
+	if elapsed > 1100*time.Millisecond {
+		t.Errorf("Workers did not run in parallel: took %v", elapsed)
+	}
+}
+
+func TestWorkerErrorsAndTerminate(t *testing.T) {
+	tempDir := t.TempDir()
+	writeWorkerScript(t, tempDir, "throws.js", `throw new RangeError('worker failed');`)
+	writeWorkerScript(t, tempDir, "forever.js", `postMessage('started'); while (true) {}`)
+
+	runner := runtime.NewOrPanicWithWorkingDir(tempDir)
+	defer runner.Close()
+
+	code := `
+		global.errorResults = {};
+		const failing = new Worker('throws.js');
+		failing.addEventListener('error', (err) => {
+			global.errorResults.name = err.name;
+			global.errorResults.message = err.message;
+		});
+		failing.onexit = (code) => { global.errorResults.failingExit = code; };
+
+		const busy = new Worker('forever.js');
+		busy.onmessage = () => {
+			busy.terminate().then(code => { global.errorResults.terminated = code; });
+		};
+
+		try { new Worker('missing.js'); } catch (e) { global.errorResults.missing = e.message; }
+		try { busy.postMessage(() => {}); } catch (e) { global.errorResults.cloneError = e.name; }
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("Failed to run worker code: %v", err)
+	}
+
+	results := runner.GetValue("errorResults").ToObject(nil)
+	if got := results.Get("name").String(); got != "RangeError" {
+		t.Errorf("Expected RangeError from worker, got %q", got)
+	}
+	if got := results.Get("message").String(); got != "worker failed" {
+		t.Errorf("Expected error message, got %q", got)
+	}
+	if results.Get("failingExit").ToInteger() != 1 {
+		t.Errorf("Expected failing worker to exit with 1, got %v", results.Get("failingExit"))
+	}
+	if terminated := results.Get("terminated"); terminated == nil || terminated.ToInteger() != 1 {
+		t.Errorf("Expected busy worker to be terminated, got %v", terminated)
+	}
+	if got := results.Get("missing").String(); !strings.Contains(got, "missing.js") {
+		t.Errorf("Expected missing script error, got %q", got)
+	}
+	if got := results.Get("cloneError").String(); got != "DataCloneError" {
+		t.Errorf("Expected DataCloneError, got %q", got)
+	}
+}
+
+func TestWorkerSharedArrayBuffer(t *testing.T) {
+	tempDir := t.TempDir()
+	writeWorkerScript(t, tempDir, "fill.js", `
+		onmessage = (e) => {
+			const view = new Int32Array(e.data.shared);
+			view[e.data.index] = e.data.index * 10;
+			const copy = new Uint8Array(e.data.copied);
+			copy[0] = 99;
+			postMessage('done');
+		};
+	`)
+
+	runner := runtime.NewOrPanicWithWorkingDir(tempDir)
+	defer runner.Close()
+
+	code := `
+		const shared = new SharedArrayBuffer(16);
+		const copied = new ArrayBuffer(1);
+		let pending = 4;
+		for (let i = 0; i < 4; i++) {
+			const worker = new Worker('fill.js');
+			worker.onmessage = () => {
+				worker.terminate();
+				if (--pending === 0) {
+					global.sharedResult = Array.from(new Int32Array(shared)).join(',');
+					global.copiedResult = new Uint8Array(copied)[0];
+				}
+			};
+			worker.postMessage({ shared, copied, index: i });
+		}
+		global.isArrayBuffer = shared instanceof ArrayBuffer;
+		global.tag = Object.prototype.toString.call(shared);
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("Failed to run worker code: %v", err)
+	}
+
+	if got := runner.GetValue("sharedResult"); got == nil || got.String() != "0,10,20,30" {
+		t.Errorf("Expected shared memory writes to be visible, got %v", got)
+	}
+	if got := runner.GetValue("copiedResult"); got == nil || got.ToInteger() != 0 {
+		t.Errorf("Expected ArrayBuffer to be copied, got %v", got)
+	}
+	if got := runner.GetValue("tag").String(); got != "[object SharedArrayBuffer]" {
+		t.Errorf("Unexpected SharedArrayBuffer tag %q", got)
+	}
+}
+
+func TestWorkerEventEmitter(t *testing.T) {
+	tempDir := t.TempDir()
+	writeWorkerScript(t, tempDir, "echo.js", `
+		const double = (e) => postMessage(e.data * 2);
+		addEventListener('message', double);
+		self.once('message', () => {
+			removeEventListener('message', double);
+			self.onmessage = (e) => {
+				postMessage('handler:' + e.data);
+				close();
+			};
+		});
+	`)
+
+	runner := runtime.NewOrPanicWithWorkingDir(tempDir)
+	defer runner.Close()
+
+	code := `
+		const { EventEmitter } = require('events');
+		const calls = [];
+		const worker = new Worker('echo.js');
+		global.isEmitter = worker instanceof EventEmitter && worker instanceof Worker;
+		worker.onmessage = (e) => calls.push('replaced:' + e.data);
+		worker.onmessage = (e) => calls.push('handler:' + e.data);
+		worker.once('message', function (e) { calls.push('once:' + e.data + ':' + (this === worker)); });
+		global.listenerCount = worker.listenerCount('message');
+		worker.on('exit', (code) => { global.workerCalls = calls.join(',') + '|' + code; });
+		worker.postMessage(1);
+		worker.postMessage(2);
+	`
+	if err := runner.RunCode(code); err != nil {
+		t.Fatalf("Failed to run worker code: %v", err)
+	}
+
+	if !runner.GetValue("isEmitter").ToBoolean() {
+		t.Error("Expected Worker to be an EventEmitter")
+	}
+	if got := runner.GetValue("listenerCount").ToInteger(); got != 2 {
+		t.Errorf("Expected onmessage to replace the previous handler, got %d listeners", got)
+	}
+	want := "handler:2,once:2:true,handler:handler:2|0"
+	if got := runner.GetValue("workerCalls"); got == nil || got.String() != want {
+		t.Errorf("Expected %q, got %v", want, got)
+	}
+}